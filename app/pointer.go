@@ -2,6 +2,7 @@ package app
 
 import "time"
 
+func BooleanAsPointer(source bool) *bool                    { return &source }
 func StringAsPointer(source string) *string                 { return &source }
 func StringArrayAsPointer(source []string) *[]string        { return &source }
 func IntegerAsPointer(source int) *int                      { return &source }
@@ -10,6 +10,13 @@ import (
 )
 
 var _ = Describe("Pointer", func() {
+	Context("BooleanAsPointer", func() {
+		It("returns a pointer to the specified source", func() {
+			source := true
+			Expect(*app.BooleanAsPointer(source)).To(Equal(source))
+		})
+	})
+
 	Context("StringAsPointer", func() {
 		It("returns a pointer to the specified source", func() {
 			source := "abc"
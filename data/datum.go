@@ -10,6 +10,9 @@ type Datum interface {
 	Normalize(normalizer Normalizer) error
 
 	IdentityFields() ([]string, error)
+	FuzzyIdentityFields() ([]string, error)
+
+	DatumID() string
 
 	SetUserID(userID string)
 	SetGroupID(groupID string)
@@ -14,14 +14,43 @@ type Deduplicator interface {
 
 	AddDatasetData(datasetData []Datum) error
 	DeduplicateDataset() error
+	PreviewDeduplicateDataset() (*DeduplicatorPreview, error)
+	ReopenDataset() (bool, error)
+	UndeduplicateDataset() error
 
 	DeleteDataset() error
 }
 
+type DeduplicatorPreview struct {
+	Name    string                       `json:"name"`
+	Version string                       `json:"version"`
+	Actions []*DeduplicatorPreviewAction `json:"actions"`
+}
+
+type DeduplicatorPreviewAction struct {
+	Action    string   `json:"action"`
+	Count     int      `json:"count"`
+	SampleIDs []string `json:"sampleIds"`
+}
+
+func NewDeduplicatorPreview(name string, version string) *DeduplicatorPreview {
+	return &DeduplicatorPreview{
+		Name:    name,
+		Version: version,
+		Actions: []*DeduplicatorPreviewAction{},
+	}
+}
+
+func (d *DeduplicatorPreview) AddAction(action string, count int, sampleIDs []string) {
+	d.Actions = append(d.Actions, &DeduplicatorPreviewAction{Action: action, Count: count, SampleIDs: sampleIDs})
+}
+
 type DeduplicatorDescriptor struct {
-	Name    string `bson:"name,omitempty"`
-	Version string `bson:"version,omitempty"`
-	Hash    string `bson:"hash,omitempty"`
+	Name         string `bson:"name,omitempty"`
+	Version      string `bson:"version,omitempty"`
+	Hash         string `bson:"hash,omitempty"`
+	FuzzyHash    string `bson:"fuzzyHash,omitempty"`
+	FoldedIntoID string `bson:"foldedIntoId,omitempty"`
 }
 
 func NewDeduplicatorDescriptor() *DeduplicatorDescriptor {
@@ -1,7 +1,9 @@
 package deduplicator
 
 import (
+	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/blang/semver"
 
@@ -14,8 +16,10 @@ import (
 
 type BaseFactory struct {
 	Factory
-	name    string
-	version string
+	name            string
+	version         string
+	rules           Rules
+	newDeduplicator func(baseDeduplicator *BaseDeduplicator) (data.Deduplicator, error)
 }
 
 type BaseDeduplicator struct {
@@ -51,6 +55,24 @@ func NewBaseFactory(name string, version string) (*BaseFactory, error) {
 	return factory, nil
 }
 
+// Creates a base factory for a deduplicator selected by rules, where newDeduplicator validates the
+// dataset and wraps the base deduplicator in the specific deduplicator
+func newRulesBaseFactory(name string, version string, rules Rules, newDeduplicator func(baseDeduplicator *BaseDeduplicator) (data.Deduplicator, error)) (*BaseFactory, error) {
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	factory, err := NewBaseFactory(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	factory.rules = rules
+	factory.newDeduplicator = newDeduplicator
+
+	return factory, nil
+}
+
 func (b *BaseFactory) CanDeduplicateDataset(dataset *upload.Upload) (bool, error) {
 	if dataset == nil {
 		return false, errors.New("deduplicator", "dataset is missing")
@@ -70,40 +92,87 @@ func (b *BaseFactory) CanDeduplicateDataset(dataset *upload.Upload) (bool, error
 }
 
 func (b *BaseFactory) NewDeduplicatorForDataset(logger log.Logger, dataStoreSession store.Session, dataset *upload.Upload) (data.Deduplicator, error) {
-	return NewBaseDeduplicator(b.name, b.version, logger, dataStoreSession, dataset)
+	deduplicator, err := b.newDeduplicatorForDataset(logger, dataStoreSession, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.rules != nil && !b.rules.Matches(dataset) {
+		return nil, errors.New("deduplicator", "dataset does not match rules")
+	}
+
+	return deduplicator, nil
 }
 
+// A dataset registered with a deduplicator is handled by the deduplicator with the registered name
+// and the same or a later version, even if the dataset no longer matches the (reloaded) rules
 func (b *BaseFactory) IsRegisteredWithDataset(dataset *upload.Upload) (bool, error) {
-	if can, err := b.Factory.CanDeduplicateDataset(dataset); err != nil || !can {
+	if can, err := b.CanDeduplicateDataset(dataset); err != nil || !can {
 		return can, err
 	}
 
-	deduplicatorDescriptor := dataset.DeduplicatorDescriptor()
-	if deduplicatorDescriptor == nil {
-		return false, nil
+	return b.isRegisteredWithDeduplicatorDescriptor(dataset.DeduplicatorDescriptor()), nil
+}
+
+func (b *BaseFactory) NewRegisteredDeduplicatorForDataset(logger log.Logger, dataStoreSession store.Session, dataset *upload.Upload) (data.Deduplicator, error) {
+	deduplicator, err := b.newDeduplicatorForDataset(logger, dataStoreSession, dataset)
+	if err != nil {
+		return nil, err
 	}
-	if !deduplicatorDescriptor.IsRegisteredWithNamedDeduplicator(b.name) {
-		return false, nil
+
+	if err = b.validateRegisteredDataset(dataset); err != nil {
+		return nil, err
 	}
 
-	return true, nil
+	return deduplicator, nil
 }
 
-func (b *BaseFactory) NewRegisteredDeduplicatorForDataset(logger log.Logger, dataStoreSession store.Session, dataset *upload.Upload) (data.Deduplicator, error) {
-	deduplicator, err := b.Factory.NewDeduplicatorForDataset(logger, dataStoreSession, dataset)
+func (b *BaseFactory) newDeduplicatorForDataset(logger log.Logger, dataStoreSession store.Session, dataset *upload.Upload) (data.Deduplicator, error) {
+	baseDeduplicator, err := NewBaseDeduplicator(b.name, b.version, logger, dataStoreSession, dataset)
 	if err != nil {
 		return nil, err
 	}
 
+	if b.newDeduplicator == nil {
+		return baseDeduplicator, nil
+	}
+
+	return b.newDeduplicator(baseDeduplicator)
+}
+
+func (b *BaseFactory) validateRegisteredDataset(dataset *upload.Upload) error {
 	deduplicatorDescriptor := dataset.DeduplicatorDescriptor()
 	if deduplicatorDescriptor == nil {
-		return nil, errors.New("deduplicator", "dataset deduplicator descriptor is missing")
+		return errors.New("deduplicator", "dataset deduplicator descriptor is missing")
+	}
+	if !b.isRegisteredWithDeduplicatorDescriptor(deduplicatorDescriptor) {
+		return errors.New("deduplicator", "dataset deduplicator descriptor is not registered with expected deduplicator")
+	}
+
+	return nil
+}
+
+func (b *BaseFactory) isRegisteredWithDeduplicatorDescriptor(deduplicatorDescriptor *data.DeduplicatorDescriptor) bool {
+	if deduplicatorDescriptor == nil {
+		return false
 	}
 	if !deduplicatorDescriptor.IsRegisteredWithNamedDeduplicator(b.name) {
-		return nil, errors.New("deduplicator", "dataset deduplicator descriptor is not registered with expected deduplicator")
+		return false
+	}
+	if deduplicatorDescriptor.Version == "" {
+		return true
 	}
 
-	return deduplicator, nil
+	registeredVersion, err := semver.Parse(deduplicatorDescriptor.Version)
+	if err != nil {
+		return false
+	}
+	version, err := semver.Parse(b.version)
+	if err != nil {
+		return false
+	}
+
+	return registeredVersion.LTE(version)
 }
 
 func NewBaseDeduplicator(name string, version string, logger log.Logger, dataStoreSession store.Session, dataset *upload.Upload) (*BaseDeduplicator, error) {
@@ -203,6 +272,46 @@ func (b *BaseDeduplicator) DeduplicateDataset() error {
 	return nil
 }
 
+func (b *BaseDeduplicator) PreviewDeduplicateDataset() (*data.DeduplicatorPreview, error) {
+	b.logger.Debug("PreviewDeduplicateDataset")
+
+	preview := data.NewDeduplicatorPreview(b.name, b.version)
+	if err := b.previewActivateDatasetData(preview); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+func (b *BaseDeduplicator) previewActivateDatasetData(preview *data.DeduplicatorPreview) error {
+	dataPreview, err := b.dataStoreSession.PreviewActivateDatasetData(b.dataset)
+	if err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to preview activate dataset data with id %s", strconv.Quote(b.dataset.UploadID))
+	}
+
+	preview.AddAction("activate-dataset-data", dataPreview.Count, dataPreview.SampleIDs)
+	return nil
+}
+
+// A reopened dataset keeps its data active, and the device data it archived archived, so only the data
+// appended while the dataset is reopened is deduplicated when the dataset is closed again
+func (b *BaseDeduplicator) ReopenDataset() (bool, error) {
+	b.logger.Debug("ReopenDataset")
+
+	return true, nil
+}
+
+// Undoes the deduplication of the dataset, as if the dataset was never closed
+func (b *BaseDeduplicator) UndeduplicateDataset() error {
+	b.logger.Debug("UndeduplicateDataset")
+
+	if err := b.dataStoreSession.DeactivateDatasetData(b.dataset); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to deactivate dataset data with id %s", strconv.Quote(b.dataset.UploadID))
+	}
+
+	return nil
+}
+
 func (b *BaseDeduplicator) DeleteDataset() error {
 	b.logger.Debug("DeleteDataset")
 
@@ -212,3 +321,57 @@ func (b *BaseDeduplicator) DeleteDataset() error {
 
 	return nil
 }
+
+// The device data archived by the dataset is unarchived when the dataset is deleted, so its archive is first
+// retained to be put back if the dataset is undeleted
+func (b *BaseDeduplicator) retainDeviceDataArchivedByDataset() error {
+	if err := b.dataStoreSession.RetainDeviceDataArchivedByDataset(b.dataset); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to retain device data archived by dataset with id %s", strconv.Quote(b.dataset.UploadID))
+	}
+
+	return nil
+}
+
+type journalStep struct {
+	name string
+	run  func() error
+}
+
+func (b *BaseDeduplicator) runJournal(operation string, steps []journalStep) error {
+	stepNames := []string{}
+	for _, step := range steps {
+		stepNames = append(stepNames, step.name)
+	}
+
+	journal := b.dataset.Journal
+	if journal == nil {
+		journal = upload.NewJournal(operation, stepNames, time.Now().UTC().Format(time.RFC3339))
+		b.dataset.Journal = journal
+		if err := b.dataStoreSession.UpdateDatasetJournal(b.dataset); err != nil {
+			return errors.Wrapf(err, "deduplicator", "unable to update dataset journal with id %s", strconv.Quote(b.dataset.UploadID))
+		}
+	} else if journal.Operation != operation || !reflect.DeepEqual(journal.Steps, stepNames) {
+		return errors.Newf("deduplicator", "dataset with id %s has incomplete journal for operation %s", strconv.Quote(b.dataset.UploadID), strconv.Quote(journal.Operation))
+	} else {
+		b.logger.WithFields(log.Fields{"journalOperation": journal.Operation, "journalStep": journal.NextStep()}).Warn("Recovering dataset from journal")
+	}
+
+	for !journal.IsCompleted() {
+		if err := steps[journal.CompletedSteps].run(); err != nil {
+			return err
+		}
+
+		if journal.CompletedSteps++; !journal.IsCompleted() {
+			if err := b.dataStoreSession.UpdateDatasetJournal(b.dataset); err != nil {
+				return errors.Wrapf(err, "deduplicator", "unable to update dataset journal with id %s", strconv.Quote(b.dataset.UploadID))
+			}
+		}
+	}
+
+	b.dataset.Journal = nil
+	if err := b.dataStoreSession.UpdateDatasetJournal(b.dataset); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to update dataset journal with id %s", strconv.Quote(b.dataset.UploadID))
+	}
+
+	return nil
+}
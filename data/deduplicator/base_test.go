@@ -10,6 +10,7 @@ import (
 	"github.com/tidepool-org/platform/app"
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/deduplicator"
+	"github.com/tidepool-org/platform/data/store"
 	testDataStore "github.com/tidepool-org/platform/data/store/test"
 	testData "github.com/tidepool-org/platform/data/test"
 	"github.com/tidepool-org/platform/data/types/upload"
@@ -499,6 +500,55 @@ var _ = Describe("Base", func() {
 				})
 			})
 
+			Context("PreviewDeduplicateDataset", func() {
+				It("returns an error if there is an error with PreviewActivateDatasetData", func() {
+					testDataStoreSession.PreviewActivateDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: nil, Error: errors.New("test error")}}
+					preview, err := testDeduplicator.PreviewDeduplicateDataset()
+					Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to preview activate dataset data with id "%s"; test error`, testDataset.UploadID)))
+					Expect(preview).To(BeNil())
+				})
+
+				It("returns successfully if there is no error", func() {
+					testDataStoreSession.PreviewActivateDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 2, SampleIDs: []string{"a", "b"}}, Error: nil}}
+					preview, err := testDeduplicator.PreviewDeduplicateDataset()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(preview).To(Equal(&data.DeduplicatorPreview{
+						Name:    testName,
+						Version: testVersion,
+						Actions: []*data.DeduplicatorPreviewAction{{Action: "activate-dataset-data", Count: 2, SampleIDs: []string{"a", "b"}}},
+					}))
+					Expect(testDataStoreSession.PreviewActivateDatasetDataInputs).To(Equal([]*upload.Upload{testDataset}))
+				})
+			})
+
+			Context("ReopenDataset", func() {
+				It("returns successfully and reopens the dataset", func() {
+					Expect(testDeduplicator.ReopenDataset()).To(BeTrue())
+				})
+			})
+
+			Context("UndeduplicateDataset", func() {
+				Context("with deactivating dataset data", func() {
+					BeforeEach(func() {
+						testDataStoreSession.DeactivateDatasetDataOutputs = []error{nil}
+					})
+
+					AfterEach(func() {
+						Expect(testDataStoreSession.DeactivateDatasetDataInputs).To(ConsistOf(testDataset))
+					})
+
+					It("returns an error if there is an error with DeactivateDatasetData", func() {
+						testDataStoreSession.DeactivateDatasetDataOutputs = []error{errors.New("test error")}
+						err := testDeduplicator.UndeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to deactivate dataset data with id "%s"; test error`, testDataset.UploadID)))
+					})
+
+					It("returns successfully if there is no error", func() {
+						Expect(testDeduplicator.UndeduplicateDataset()).To(Succeed())
+					})
+				})
+			})
+
 			Context("DeleteDataset", func() {
 				Context("with deleting dataset", func() {
 					BeforeEach(func() {
@@ -0,0 +1,82 @@
+package deduplicator
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/tidepool-org/platform/errors"
+)
+
+type Config struct {
+	Factories []*FactoryConfig `json:"factories"`
+}
+
+type FactoryConfig struct {
+	Name                string `json:"name"`
+	Rules               Rules  `json:"rules"`
+	DeviceTimeTolerance int    `json:"deviceTimeTolerance,omitempty"`
+}
+
+var _FactoryConstructors = map[string]func(factoryConfig *FactoryConfig) (Factory, error){
+	_TruncateDeduplicatorName: func(factoryConfig *FactoryConfig) (Factory, error) {
+		return NewTruncateFactory(factoryConfig.Rules)
+	},
+	_HashDeactivateOldDeduplicatorName: func(factoryConfig *FactoryConfig) (Factory, error) {
+		return NewHashDeactivateOldFactory(factoryConfig.Rules)
+	},
+	_TimeWindowDeduplicatorName: func(factoryConfig *FactoryConfig) (Factory, error) {
+		return NewTimeWindowFactory(factoryConfig.Rules)
+	},
+	_FuzzyDeactivateOldDeduplicatorName: func(factoryConfig *FactoryConfig) (Factory, error) {
+		return NewFuzzyDeactivateOldFactory(factoryConfig.Rules, time.Duration(factoryConfig.DeviceTimeTolerance)*time.Second)
+	},
+}
+
+func (c *Config) Validate() error {
+	if len(c.Factories) == 0 {
+		return errors.New("deduplicator", "factories is missing")
+	}
+
+	names := map[string]bool{}
+	for _, factoryConfig := range c.Factories {
+		if factoryConfig == nil {
+			return errors.New("deduplicator", "factory is missing")
+		}
+		if factoryConfig.Name == "" {
+			return errors.New("deduplicator", "factory name is missing")
+		}
+		if _, ok := _FactoryConstructors[factoryConfig.Name]; !ok {
+			return errors.Newf("deduplicator", "factory name %s is unknown", strconv.Quote(factoryConfig.Name))
+		}
+		if names[factoryConfig.Name] {
+			return errors.Newf("deduplicator", "factory name %s is duplicated", strconv.Quote(factoryConfig.Name))
+		}
+		names[factoryConfig.Name] = true
+		if err := factoryConfig.Rules.Validate(); err != nil {
+			return errors.Wrapf(err, "deduplicator", "factory %s is invalid", strconv.Quote(factoryConfig.Name))
+		}
+		if factoryConfig.DeviceTimeTolerance < 0 {
+			return errors.Newf("deduplicator", "factory %s device time tolerance is invalid", strconv.Quote(factoryConfig.Name))
+		}
+	}
+	return nil
+}
+
+func NewFactoriesFromConfig(config *Config) ([]Factory, error) {
+	if config == nil {
+		return nil, errors.New("deduplicator", "config is missing")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "deduplicator", "config is invalid")
+	}
+
+	factories := []Factory{}
+	for _, factoryConfig := range config.Factories {
+		factory, err := _FactoryConstructors[factoryConfig.Name](factoryConfig)
+		if err != nil {
+			return nil, err
+		}
+		factories = append(factories, factory)
+	}
+	return factories, nil
+}
@@ -0,0 +1,110 @@
+package deduplicator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/deduplicator"
+	"github.com/tidepool-org/platform/data/types/upload"
+)
+
+var _ = Describe("Config", func() {
+	var testConfig *deduplicator.Config
+
+	BeforeEach(func() {
+		testConfig = &deduplicator.Config{
+			Factories: []*deduplicator.FactoryConfig{
+				{Name: "org.tidepool.truncate", Rules: deduplicator.Rules{{DeviceManufacturers: []string{"Animas"}}}},
+				{Name: "org.tidepool.hash-deactivate-old", Rules: deduplicator.Rules{{DeviceManufacturers: []string{"Abbott"}, DeviceModels: []string{"FreeStyle Libre"}}}},
+			},
+		}
+	})
+
+	Context("Validate", func() {
+		It("returns an error if the factories is missing", func() {
+			testConfig.Factories = nil
+			Expect(testConfig.Validate()).To(MatchError("deduplicator: factories is missing"))
+		})
+
+		It("returns an error if a factory is missing", func() {
+			testConfig.Factories[1] = nil
+			Expect(testConfig.Validate()).To(MatchError("deduplicator: factory is missing"))
+		})
+
+		It("returns an error if a factory name is missing", func() {
+			testConfig.Factories[1].Name = ""
+			Expect(testConfig.Validate()).To(MatchError("deduplicator: factory name is missing"))
+		})
+
+		It("returns an error if a factory name is unknown", func() {
+			testConfig.Factories[1].Name = "org.tidepool.unknown"
+			Expect(testConfig.Validate()).To(MatchError(`deduplicator: factory name "org.tidepool.unknown" is unknown`))
+		})
+
+		It("returns an error if a factory name is duplicated", func() {
+			testConfig.Factories[1].Name = "org.tidepool.truncate"
+			Expect(testConfig.Validate()).To(MatchError(`deduplicator: factory name "org.tidepool.truncate" is duplicated`))
+		})
+
+		It("returns an error if a factory rules is invalid", func() {
+			testConfig.Factories[1].Rules = nil
+			Expect(testConfig.Validate()).To(MatchError(`deduplicator: factory "org.tidepool.hash-deactivate-old" is invalid; deduplicator: rules is missing`))
+		})
+
+		It("returns an error if a factory device time tolerance is invalid", func() {
+			testConfig.Factories[1].DeviceTimeTolerance = -1
+			Expect(testConfig.Validate()).To(MatchError(`deduplicator: factory "org.tidepool.hash-deactivate-old" device time tolerance is invalid`))
+		})
+
+		It("returns successfully", func() {
+			Expect(testConfig.Validate()).To(Succeed())
+		})
+	})
+
+	Context("NewFactoriesFromConfig", func() {
+		It("returns an error if the config is missing", func() {
+			factories, err := deduplicator.NewFactoriesFromConfig(nil)
+			Expect(err).To(MatchError("deduplicator: config is missing"))
+			Expect(factories).To(BeNil())
+		})
+
+		It("returns an error if the config is invalid", func() {
+			testConfig.Factories = nil
+			factories, err := deduplicator.NewFactoriesFromConfig(testConfig)
+			Expect(err).To(MatchError("deduplicator: config is invalid; deduplicator: factories is missing"))
+			Expect(factories).To(BeNil())
+		})
+
+		It("returns an error if a factory cannot be created", func() {
+			testConfig.Factories = append(testConfig.Factories, &deduplicator.FactoryConfig{Name: "org.tidepool.fuzzy-deactivate-old", Rules: deduplicator.Rules{{DeviceManufacturers: []string{"Dexcom"}}}})
+			factories, err := deduplicator.NewFactoriesFromConfig(testConfig)
+			Expect(err).To(MatchError("deduplicator: device time tolerance is invalid"))
+			Expect(factories).To(BeNil())
+		})
+
+		It("returns a fuzzy deactivate old factory with device time tolerance", func() {
+			testConfig.Factories = append(testConfig.Factories, &deduplicator.FactoryConfig{Name: "org.tidepool.fuzzy-deactivate-old", Rules: deduplicator.Rules{{DeviceManufacturers: []string{"Dexcom"}}}, DeviceTimeTolerance: 30})
+			factories, err := deduplicator.NewFactoriesFromConfig(testConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(factories).To(HaveLen(3))
+		})
+
+		It("returns the factories in config order", func() {
+			factories, err := deduplicator.NewFactoriesFromConfig(testConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(factories).To(HaveLen(2))
+			testDataset := upload.Init()
+			testDataset.UserID = app.NewID()
+			testDataset.GroupID = app.NewID()
+			testDataset.DeviceID = app.StringAsPointer(app.NewID())
+			testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Animas"})
+			testDataset.DeviceModel = app.StringAsPointer("FreeStyle Libre")
+			Expect(factories[0].CanDeduplicateDataset(testDataset)).To(BeTrue())
+			Expect(factories[1].CanDeduplicateDataset(testDataset)).To(BeFalse())
+			testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Abbott"})
+			Expect(factories[0].CanDeduplicateDataset(testDataset)).To(BeFalse())
+			Expect(factories[1].CanDeduplicateDataset(testDataset)).To(BeTrue())
+		})
+	})
+})
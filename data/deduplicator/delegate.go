@@ -1,6 +1,8 @@
 package deduplicator
 
 import (
+	"sync"
+
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/store"
 	"github.com/tidepool-org/platform/data/types/upload"
@@ -9,7 +11,9 @@ import (
 )
 
 type DelegateFactory struct {
-	factories []Factory
+	factoriesMutex   sync.RWMutex
+	factories        []Factory
+	retiredFactories []Factory
 }
 
 func NewDelegateFactory(factories []Factory) (*DelegateFactory, error) {
@@ -22,12 +26,42 @@ func NewDelegateFactory(factories []Factory) (*DelegateFactory, error) {
 	}, nil
 }
 
+func (d *DelegateFactory) SetFactories(factories []Factory) error {
+	if len(factories) == 0 {
+		return errors.New("deduplicator", "factories is missing")
+	}
+
+	d.factoriesMutex.Lock()
+	defer d.factoriesMutex.Unlock()
+
+	d.retiredFactories = append(append([]Factory{}, d.factories...), d.retiredFactories...)
+	d.factories = factories
+	return nil
+}
+
+func (d *DelegateFactory) Factories() []Factory {
+	d.factoriesMutex.RLock()
+	defer d.factoriesMutex.RUnlock()
+
+	return d.factories
+}
+
+// Datasets registered with a deduplicator must remain resolvable even if a reload drops the deduplicator
+// from the factories, so the factories replaced by earlier reloads are retained, most recent first, after
+// the current factories, but only for datasets already registered
+func (d *DelegateFactory) registeredFactories() []Factory {
+	d.factoriesMutex.RLock()
+	defer d.factoriesMutex.RUnlock()
+
+	return append(append([]Factory{}, d.factories...), d.retiredFactories...)
+}
+
 func (d *DelegateFactory) CanDeduplicateDataset(dataset *upload.Upload) (bool, error) {
 	if dataset == nil {
 		return false, errors.New("deduplicator", "dataset is missing")
 	}
 
-	for _, factory := range d.factories {
+	for _, factory := range d.Factories() {
 		if can, err := factory.CanDeduplicateDataset(dataset); err != nil {
 			return false, err
 		} else if can {
@@ -48,7 +82,7 @@ func (d *DelegateFactory) NewDeduplicatorForDataset(logger log.Logger, dataStore
 		return nil, errors.New("deduplicator", "dataset is missing")
 	}
 
-	for _, factory := range d.factories {
+	for _, factory := range d.Factories() {
 		if can, err := factory.CanDeduplicateDataset(dataset); err != nil {
 			return nil, err
 		} else if can {
@@ -63,7 +97,7 @@ func (d *DelegateFactory) IsRegisteredWithDataset(dataset *upload.Upload) (bool,
 		return false, errors.New("deduplicator", "dataset is missing")
 	}
 
-	for _, factory := range d.factories {
+	for _, factory := range d.registeredFactories() {
 		if is, err := factory.IsRegisteredWithDataset(dataset); err != nil {
 			return false, err
 		} else if is {
@@ -89,7 +123,7 @@ func (d *DelegateFactory) NewRegisteredDeduplicatorForDataset(logger log.Logger,
 		return nil, errors.Newf("deduplicator", "dataset not registered with deduplicator")
 	}
 
-	for _, factory := range d.factories {
+	for _, factory := range d.registeredFactories() {
 		if is, err := factory.IsRegisteredWithDataset(dataset); err != nil {
 			return nil, err
 		} else if is {
@@ -38,6 +38,79 @@ var _ = Describe("Delegate", func() {
 		})
 	})
 
+	Context("SetFactories", func() {
+		var testFactory *testDataDeduplicator.Factory
+		var testDelegateFactory *deduplicator.DelegateFactory
+
+		BeforeEach(func() {
+			var err error
+			testFactory = testDataDeduplicator.NewFactory()
+			testDelegateFactory, err = deduplicator.NewDelegateFactory([]deduplicator.Factory{testFactory})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(testDelegateFactory).ToNot(BeNil())
+		})
+
+		It("returns an error if factories is nil", func() {
+			Expect(testDelegateFactory.SetFactories(nil)).To(MatchError("deduplicator: factories is missing"))
+			Expect(testDelegateFactory.Factories()).To(Equal([]deduplicator.Factory{testFactory}))
+		})
+
+		It("returns an error if there are no factories", func() {
+			Expect(testDelegateFactory.SetFactories([]deduplicator.Factory{})).To(MatchError("deduplicator: factories is missing"))
+			Expect(testDelegateFactory.Factories()).To(Equal([]deduplicator.Factory{testFactory}))
+		})
+
+		It("replaces the factories", func() {
+			testFactories := []deduplicator.Factory{testDataDeduplicator.NewFactory(), testDataDeduplicator.NewFactory()}
+			Expect(testDelegateFactory.SetFactories(testFactories)).To(Succeed())
+			Expect(testDelegateFactory.Factories()).To(Equal(testFactories))
+		})
+
+		Context("with replaced factories and a dataset registered with a replaced factory", func() {
+			var testReplacementFactory *testDataDeduplicator.Factory
+			var testDataset *upload.Upload
+			var testLogger log.Logger
+			var testDataStoreSession *testDataStore.Session
+
+			BeforeEach(func() {
+				testReplacementFactory = testDataDeduplicator.NewFactory()
+				Expect(testDelegateFactory.SetFactories([]deduplicator.Factory{testReplacementFactory})).To(Succeed())
+				testDataset = upload.Init()
+				testDataset.SetDeduplicatorDescriptor(&data.DeduplicatorDescriptor{Name: "test"})
+				testLogger = log.NewNull()
+				testDataStoreSession = testDataStore.NewSession()
+				testReplacementFactory.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{{Is: false, Error: nil}}
+				testFactory.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{{Is: true, Error: nil}}
+			})
+
+			AfterEach(func() {
+				Expect(testReplacementFactory.UnusedOutputsCount()).To(Equal(0))
+				Expect(testFactory.UnusedOutputsCount()).To(Equal(0))
+			})
+
+			It("returns true if the replaced factory is registered with the dataset", func() {
+				Expect(testDelegateFactory.IsRegisteredWithDataset(testDataset)).To(BeTrue())
+				Expect(testReplacementFactory.IsRegisteredWithDatasetInputs).To(ConsistOf(testDataset))
+				Expect(testFactory.IsRegisteredWithDatasetInputs).To(ConsistOf(testDataset))
+			})
+
+			It("returns a deduplicator from the replaced factory registered with the dataset", func() {
+				testDeduplicator := testData.NewDeduplicator()
+				testFactory.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{{Deduplicator: testDeduplicator, Error: nil}}
+				Expect(testDelegateFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)).To(Equal(testDeduplicator))
+				Expect(testFactory.NewRegisteredDeduplicatorForDatasetInputs).To(ConsistOf(testDataDeduplicator.NewRegisteredDeduplicatorForDatasetInput{Logger: testLogger, DataStoreSession: testDataStoreSession, Dataset: testDataset}))
+			})
+
+			It("does not use the replaced factory for a dataset not yet registered", func() {
+				testReplacementFactory.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
+				testFactory.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
+				testReplacementFactory.CanDeduplicateDatasetOutputs = []testDataDeduplicator.CanDeduplicateDatasetOutput{{Can: false, Error: nil}}
+				Expect(testDelegateFactory.CanDeduplicateDataset(testDataset)).To(BeFalse())
+				Expect(testFactory.CanDeduplicateDatasetInputs).To(BeEmpty())
+			})
+		})
+	})
+
 	Context("with a new delegate factory", func() {
 		var testFirstFactory *testDataDeduplicator.Factory
 		var testSecondFactory *testDataDeduplicator.Factory
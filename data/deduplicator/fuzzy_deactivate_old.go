@@ -0,0 +1,149 @@
+package deduplicator
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/errors"
+)
+
+type fuzzyDeactivateOldFactory struct {
+	*BaseFactory
+	deviceTimeTolerance time.Duration
+}
+
+type fuzzyDeactivateOldDeduplicator struct {
+	*BaseDeduplicator
+	deviceTimeTolerance time.Duration
+}
+
+const _FuzzyDeactivateOldDeduplicatorName = "org.tidepool.fuzzy-deactivate-old"
+const _FuzzyDeactivateOldDeduplicatorVersion = "1.0.0"
+
+func NewFuzzyDeactivateOldFactory(rules Rules, deviceTimeTolerance time.Duration) (Factory, error) {
+	factory := &fuzzyDeactivateOldFactory{}
+
+	baseFactory, err := newRulesBaseFactory(_FuzzyDeactivateOldDeduplicatorName, _FuzzyDeactivateOldDeduplicatorVersion, rules, factory.newDeduplicator)
+	if err != nil {
+		return nil, err
+	}
+	if deviceTimeTolerance <= 0 {
+		return nil, errors.New("deduplicator", "device time tolerance is invalid")
+	}
+
+	factory.BaseFactory = baseFactory
+	factory.Factory = factory
+	factory.deviceTimeTolerance = deviceTimeTolerance
+
+	return factory, nil
+}
+
+func (f *fuzzyDeactivateOldFactory) CanDeduplicateDataset(dataset *upload.Upload) (bool, error) {
+	if can, err := f.BaseFactory.CanDeduplicateDataset(dataset); err != nil || !can {
+		return can, err
+	}
+
+	if dataset.DeviceID == nil {
+		return false, nil
+	}
+	if *dataset.DeviceID == "" {
+		return false, nil
+	}
+	if dataset.DeviceManufacturers == nil {
+		return false, nil
+	}
+
+	return f.rules.Matches(dataset), nil
+}
+
+func (f *fuzzyDeactivateOldFactory) newDeduplicator(baseDeduplicator *BaseDeduplicator) (data.Deduplicator, error) {
+	dataset := baseDeduplicator.dataset
+
+	if dataset.DeviceID == nil {
+		return nil, errors.New("deduplicator", "dataset device id is missing")
+	}
+	if *dataset.DeviceID == "" {
+		return nil, errors.New("deduplicator", "dataset device id is empty")
+	}
+	if dataset.DeviceManufacturers == nil {
+		return nil, errors.New("deduplicator", "dataset device manufacturers is missing")
+	}
+
+	return &fuzzyDeactivateOldDeduplicator{
+		BaseDeduplicator:    baseDeduplicator,
+		deviceTimeTolerance: f.deviceTimeTolerance,
+	}, nil
+}
+
+func (f *fuzzyDeactivateOldDeduplicator) AddDatasetData(datasetData []data.Datum) error {
+	hashes, err := AssignDatasetDataFuzzyIdentityHashes(datasetData)
+	if err != nil {
+		return err
+	} else if len(hashes) == 0 {
+		return nil
+	}
+
+	return f.BaseDeduplicator.AddDatasetData(datasetData)
+}
+
+func (f *fuzzyDeactivateOldDeduplicator) DeduplicateDataset() error {
+	return f.runJournal(upload.JournalOperationDeduplicate, []journalStep{
+		{name: "archive-device-data", run: f.archiveDeviceData},
+		{name: "activate-dataset-data", run: f.BaseDeduplicator.DeduplicateDataset},
+	})
+}
+
+func (f *fuzzyDeactivateOldDeduplicator) PreviewDeduplicateDataset() (*data.DeduplicatorPreview, error) {
+	f.logger.Debug("PreviewDeduplicateDataset")
+
+	dataPreview, err := f.dataStoreSession.PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset(f.dataset, f.deviceTimeTolerance)
+	if err != nil {
+		return nil, errors.Wrapf(err, "deduplicator", "unable to preview archive device data using fuzzy hashes from dataset with id %s", strconv.Quote(f.dataset.UploadID))
+	}
+
+	preview := data.NewDeduplicatorPreview(f.name, f.version)
+	preview.AddAction("archive-device-data", dataPreview.Count, dataPreview.SampleIDs)
+
+	if err = f.previewActivateDatasetData(preview); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+func (f *fuzzyDeactivateOldDeduplicator) archiveDeviceData() error {
+	if err := f.dataStoreSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(f.dataset, f.deviceTimeTolerance); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to archive device data using fuzzy hashes from dataset with id %s", strconv.Quote(f.dataset.UploadID))
+	}
+
+	return nil
+}
+
+func (f *fuzzyDeactivateOldDeduplicator) UndeduplicateDataset() error {
+	if err := f.unarchiveDeviceData(); err != nil {
+		return err
+	}
+
+	return f.BaseDeduplicator.UndeduplicateDataset()
+}
+
+func (f *fuzzyDeactivateOldDeduplicator) DeleteDataset() error {
+	if err := f.retainDeviceDataArchivedByDataset(); err != nil {
+		return err
+	}
+	if err := f.unarchiveDeviceData(); err != nil {
+		return err
+	}
+
+	return f.BaseDeduplicator.DeleteDataset()
+}
+
+func (f *fuzzyDeactivateOldDeduplicator) unarchiveDeviceData() error {
+	if err := f.dataStoreSession.UnarchiveDeviceDataArchivedByDataset(f.dataset); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to unarchive device data archived by dataset with id %s", strconv.Quote(f.dataset.UploadID))
+	}
+
+	return nil
+}
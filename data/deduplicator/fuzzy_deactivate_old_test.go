@@ -0,0 +1,420 @@
+package deduplicator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/deduplicator"
+	"github.com/tidepool-org/platform/data/store"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/log"
+)
+
+var _ = Describe("FuzzyDeactivateOld", func() {
+	var testRules deduplicator.Rules
+	var testDeviceTimeTolerance time.Duration
+
+	BeforeEach(func() {
+		testRules = deduplicator.Rules{{DeviceManufacturers: []string{"Dexcom"}, DeviceTags: []string{"cgm"}}}
+		testDeviceTimeTolerance = 30 * time.Second
+	})
+
+	Context("NewFuzzyDeactivateOldFactory", func() {
+		It("returns an error if the rules are missing", func() {
+			testFactory, err := deduplicator.NewFuzzyDeactivateOldFactory(nil, testDeviceTimeTolerance)
+			Expect(err).To(MatchError("deduplicator: rules is missing"))
+			Expect(testFactory).To(BeNil())
+		})
+
+		It("returns an error if the device time tolerance is invalid", func() {
+			testFactory, err := deduplicator.NewFuzzyDeactivateOldFactory(testRules, 0)
+			Expect(err).To(MatchError("deduplicator: device time tolerance is invalid"))
+			Expect(testFactory).To(BeNil())
+		})
+
+		It("returns a new factory", func() {
+			Expect(deduplicator.NewFuzzyDeactivateOldFactory(testRules, testDeviceTimeTolerance)).ToNot(BeNil())
+		})
+	})
+
+	Context("with a new factory", func() {
+		var testFactory deduplicator.Factory
+		var testUploadID string
+		var testDataset *upload.Upload
+
+		BeforeEach(func() {
+			var err error
+			testFactory, err = deduplicator.NewFuzzyDeactivateOldFactory(testRules, testDeviceTimeTolerance)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(testFactory).ToNot(BeNil())
+			testUploadID = app.NewID()
+			testDataset = upload.Init()
+			Expect(testDataset).ToNot(BeNil())
+			testDataset.UploadID = testUploadID
+			testDataset.UserID = app.NewID()
+			testDataset.GroupID = app.NewID()
+			testDataset.DeviceID = app.StringAsPointer(app.NewID())
+			testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Dexcom"})
+			testDataset.DeviceTags = app.StringArrayAsPointer([]string{"cgm"})
+		})
+
+		Context("CanDeduplicateDataset", func() {
+			It("returns an error if the dataset is missing", func() {
+				can, err := testFactory.CanDeduplicateDataset(nil)
+				Expect(err).To(MatchError("deduplicator: dataset is missing"))
+				Expect(can).To(BeFalse())
+			})
+
+			It("returns false if the dataset id is missing", func() {
+				testDataset.UploadID = ""
+				Expect(testFactory.CanDeduplicateDataset(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the device id is missing", func() {
+				testDataset.DeviceID = nil
+				Expect(testFactory.CanDeduplicateDataset(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the device id is empty", func() {
+				testDataset.DeviceID = app.StringAsPointer("")
+				Expect(testFactory.CanDeduplicateDataset(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the device manufacturers is missing", func() {
+				testDataset.DeviceManufacturers = nil
+				Expect(testFactory.CanDeduplicateDataset(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the device manufacturers does not contain expected device manufacturer", func() {
+				testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Ant", "Zebra", "Cobra"})
+				Expect(testFactory.CanDeduplicateDataset(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the device tags does not contain expected device tag", func() {
+				testDataset.DeviceTags = app.StringArrayAsPointer([]string{"bgm"})
+				Expect(testFactory.CanDeduplicateDataset(testDataset)).To(BeFalse())
+			})
+
+			It("returns true if the dataset matches the rules", func() {
+				Expect(testFactory.CanDeduplicateDataset(testDataset)).To(BeTrue())
+			})
+		})
+
+		Context("with logger and data store session", func() {
+			var testLogger log.Logger
+			var testDataStoreSession *testDataStore.Session
+
+			BeforeEach(func() {
+				testLogger = log.NewNull()
+				Expect(testLogger).ToNot(BeNil())
+				testDataStoreSession = testDataStore.NewSession()
+				Expect(testDataStoreSession).ToNot(BeNil())
+			})
+
+			AfterEach(func() {
+				Expect(testDataStoreSession.UnusedOutputsCount()).To(Equal(0))
+			})
+
+			Context("NewDeduplicatorForDataset", func() {
+				It("returns an error if the logger is missing", func() {
+					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(nil, testDataStoreSession, testDataset)
+					Expect(err).To(MatchError("deduplicator: logger is missing"))
+					Expect(testDeduplicator).To(BeNil())
+				})
+
+				It("returns an error if the dataset device id is missing", func() {
+					testDataset.DeviceID = nil
+					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
+					Expect(err).To(MatchError("deduplicator: dataset device id is missing"))
+					Expect(testDeduplicator).To(BeNil())
+				})
+
+				It("returns an error if the dataset device id is empty", func() {
+					testDataset.DeviceID = app.StringAsPointer("")
+					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
+					Expect(err).To(MatchError("deduplicator: dataset device id is empty"))
+					Expect(testDeduplicator).To(BeNil())
+				})
+
+				It("returns an error if the device manufacturers is missing", func() {
+					testDataset.DeviceManufacturers = nil
+					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
+					Expect(err).To(MatchError("deduplicator: dataset device manufacturers is missing"))
+					Expect(testDeduplicator).To(BeNil())
+				})
+
+				It("returns an error if the dataset does not match the rules", func() {
+					testDataset.DeviceTags = app.StringArrayAsPointer([]string{"bgm"})
+					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
+					Expect(err).To(MatchError("deduplicator: dataset does not match rules"))
+					Expect(testDeduplicator).To(BeNil())
+				})
+
+				It("returns a new deduplicator upon success", func() {
+					Expect(testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)).ToNot(BeNil())
+				})
+			})
+
+			Context("with a dataset registered with the deduplicator", func() {
+				BeforeEach(func() {
+					testDataset.Deduplicator = &data.DeduplicatorDescriptor{Name: "org.tidepool.fuzzy-deactivate-old", Version: "1.0.0"}
+				})
+
+				It("is registered with the dataset", func() {
+					Expect(testFactory.IsRegisteredWithDataset(testDataset)).To(BeTrue())
+				})
+
+				It("is registered with the dataset even if the dataset does not match rules", func() {
+					testDataset.DeviceTags = app.StringArrayAsPointer([]string{"bgm"})
+					Expect(testFactory.IsRegisteredWithDataset(testDataset)).To(BeTrue())
+				})
+
+				It("is not registered with the dataset if the registered version is later", func() {
+					testDataset.Deduplicator.Version = "2.0.0"
+					Expect(testFactory.IsRegisteredWithDataset(testDataset)).To(BeFalse())
+				})
+
+				It("returns a new registered deduplicator upon success", func() {
+					Expect(testFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)).ToNot(BeNil())
+				})
+
+				It("returns a new registered deduplicator even if the dataset does not match rules", func() {
+					testDataset.DeviceTags = app.StringArrayAsPointer([]string{"bgm"})
+					Expect(testFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)).ToNot(BeNil())
+				})
+
+				It("returns an error if the dataset is registered with another deduplicator", func() {
+					testDataset.Deduplicator.Name = "org.tidepool.other"
+					testDeduplicator, err := testFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
+					Expect(err).To(MatchError("deduplicator: dataset deduplicator descriptor is not registered with expected deduplicator"))
+					Expect(testDeduplicator).To(BeNil())
+				})
+			})
+
+			Context("with a new deduplicator", func() {
+				var testDeduplicator data.Deduplicator
+
+				BeforeEach(func() {
+					var err error
+					testDeduplicator, err = testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(testDeduplicator).ToNot(BeNil())
+				})
+
+				Context("AddDatasetData", func() {
+					var testDataData []*testData.Datum
+					var testDatasetData []data.Datum
+
+					BeforeEach(func() {
+						testDataData = []*testData.Datum{}
+						testDatasetData = []data.Datum{}
+						for i := 0; i < 2; i++ {
+							testDatum := testData.NewDatum()
+							testDataData = append(testDataData, testDatum)
+							testDatasetData = append(testDatasetData, testDatum)
+						}
+					})
+
+					AfterEach(func() {
+						for _, testDataDatum := range testDataData {
+							Expect(testDataDatum.UnusedOutputsCount()).To(Equal(0))
+						}
+					})
+
+					It("returns successfully if there is no data", func() {
+						Expect(testDeduplicator.AddDatasetData([]data.Datum{})).To(Succeed())
+					})
+
+					It("returns an error if any datum returns an error getting fuzzy identity fields", func() {
+						testDataData[0].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: nil, Error: errors.New("test error")}}
+						err := testDeduplicator.AddDatasetData(testDatasetData)
+						Expect(err).To(MatchError("deduplicator: unable to gather fuzzy identity fields for datum; test error"))
+					})
+
+					Context("with fuzzy identity fields", func() {
+						BeforeEach(func() {
+							testDataData[0].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: []string{"test", "0"}, Error: nil}}
+							testDataData[1].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: []string{"test", "1"}, Error: nil}}
+						})
+
+						AfterEach(func() {
+							Expect(testDataData[0].DeduplicatorDescriptorValue).To(Equal(&data.DeduplicatorDescriptor{FuzzyHash: "GRp47M02cMlAzSn7oJTQ2LC9eb1Qd6mIPO1U8GeuoYg="}))
+							Expect(testDataData[1].DeduplicatorDescriptorValue).To(Equal(&data.DeduplicatorDescriptor{FuzzyHash: "+cywqM0rcj9REPt87Vfx2U+j9m57cB0XW2kmNZm5Ao8="}))
+						})
+
+						It("returns an error if there is an error with CreateDatasetData", func() {
+							testDataStoreSession.CreateDatasetDataOutputs = []error{errors.New("test error")}
+							err := testDeduplicator.AddDatasetData(testDatasetData)
+							Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to create dataset data with id "%s"; test error`, testUploadID)))
+						})
+
+						It("returns successfully if there is no error", func() {
+							testDataStoreSession.CreateDatasetDataOutputs = []error{nil}
+							Expect(testDeduplicator.AddDatasetData(testDatasetData)).To(Succeed())
+							Expect(testDataStoreSession.CreateDatasetDataInputs).To(ConsistOf(testDataStore.CreateDatasetDataInput{
+								Dataset:     testDataset,
+								DatasetData: testDatasetData,
+							}))
+						})
+					})
+				})
+
+				Context("DeduplicateDataset", func() {
+					It("returns an error if there is an error with UpdateDatasetJournal when creating the journal", func() {
+						testDataStoreSession.UpdateDatasetJournalOutputs = []error{errors.New("test error")}
+						err := testDeduplicator.DeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to update dataset journal with id "%s"; test error`, testUploadID)))
+					})
+
+					It("recovers from the journal by completing only the remaining steps", func() {
+						testDataset.Journal = upload.NewJournal(upload.JournalOperationDeduplicate, []string{"archive-device-data", "activate-dataset-data"}, "2017-09-01T12:00:00Z")
+						testDataset.Journal.CompletedSteps = 1
+						testDataStoreSession.ActivateDatasetDataOutputs = []error{nil}
+						testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil}
+						Expect(testDeduplicator.DeduplicateDataset()).To(Succeed())
+						Expect(testDataStoreSession.ArchiveDeviceDataUsingFuzzyHashesFromDatasetInvocations).To(Equal(0))
+						Expect(testDataStoreSession.ActivateDatasetDataInputs).To(ConsistOf(testDataset))
+						Expect(testDataset.Journal).To(BeNil())
+					})
+
+					Context("with archive device data using fuzzy hashes from dataset", func() {
+						BeforeEach(func() {
+							testDataStoreSession.ArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs = []error{nil}
+						})
+
+						AfterEach(func() {
+							Expect(testDataStoreSession.ArchiveDeviceDataUsingFuzzyHashesFromDatasetInputs).To(Equal([]testDataStore.FuzzyHashesFromDatasetInput{{Dataset: testDataset, DeviceTimeTolerance: testDeviceTimeTolerance}}))
+						})
+
+						It("returns an error if there is an error with ArchiveDeviceDataUsingFuzzyHashesFromDataset", func() {
+							testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil}
+							testDataStoreSession.ArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs = []error{errors.New("test error")}
+							err := testDeduplicator.DeduplicateDataset()
+							Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to archive device data using fuzzy hashes from dataset with id "%s"; test error`, testUploadID)))
+							Expect(testDataset.Journal).ToNot(BeNil())
+							Expect(testDataset.Journal.CompletedSteps).To(Equal(0))
+						})
+
+						Context("with activating dataset data", func() {
+							BeforeEach(func() {
+								testDataStoreSession.ActivateDatasetDataOutputs = []error{nil}
+							})
+
+							AfterEach(func() {
+								Expect(testDataStoreSession.ActivateDatasetDataInputs).To(ConsistOf(testDataset))
+							})
+
+							It("returns an error if there is an error with ActivateDatasetData", func() {
+								testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, nil}
+								testDataStoreSession.ActivateDatasetDataOutputs = []error{errors.New("test error")}
+								err := testDeduplicator.DeduplicateDataset()
+								Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to activate dataset data with id "%s"; test error`, testUploadID)))
+								Expect(testDataset.Journal).ToNot(BeNil())
+								Expect(testDataset.Journal.CompletedSteps).To(Equal(1))
+							})
+
+							It("returns successfully if there is no error", func() {
+								testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, nil, nil}
+								Expect(testDeduplicator.DeduplicateDataset()).To(Succeed())
+								Expect(testDataset.Journal).To(BeNil())
+							})
+						})
+					})
+				})
+
+				Context("PreviewDeduplicateDataset", func() {
+					It("returns an error if there is an error with PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset", func() {
+						testDataStoreSession.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs = []testDataStore.DataPreviewOutput{{Preview: nil, Error: errors.New("test error")}}
+						preview, err := testDeduplicator.PreviewDeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to preview archive device data using fuzzy hashes from dataset with id "%s"; test error`, testUploadID)))
+						Expect(preview).To(BeNil())
+					})
+
+					It("returns successfully if there is no error", func() {
+						testDataStoreSession.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 1, SampleIDs: []string{"a"}}, Error: nil}}
+						testDataStoreSession.PreviewActivateDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 2, SampleIDs: []string{"b", "c"}}, Error: nil}}
+						preview, err := testDeduplicator.PreviewDeduplicateDataset()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(preview).To(Equal(&data.DeduplicatorPreview{
+							Name:    "org.tidepool.fuzzy-deactivate-old",
+							Version: "1.0.0",
+							Actions: []*data.DeduplicatorPreviewAction{
+								{Action: "archive-device-data", Count: 1, SampleIDs: []string{"a"}},
+								{Action: "activate-dataset-data", Count: 2, SampleIDs: []string{"b", "c"}},
+							},
+						}))
+						Expect(testDataStoreSession.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetInputs).To(Equal([]testDataStore.FuzzyHashesFromDatasetInput{{Dataset: testDataset, DeviceTimeTolerance: testDeviceTimeTolerance}}))
+					})
+				})
+
+				Context("UndeduplicateDataset", func() {
+					BeforeEach(func() {
+						testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetOutputs = []error{nil}
+					})
+
+					AfterEach(func() {
+						Expect(testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetInputs).To(Equal([]*upload.Upload{testDataset}))
+					})
+
+					It("returns an error if there is an error with UnarchiveDeviceDataArchivedByDataset", func() {
+						testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetOutputs = []error{errors.New("test error")}
+						err := testDeduplicator.UndeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to unarchive device data archived by dataset with id "%s"; test error`, testUploadID)))
+					})
+
+					It("returns successfully if there is no error", func() {
+						testDataStoreSession.DeactivateDatasetDataOutputs = []error{nil}
+						Expect(testDeduplicator.UndeduplicateDataset()).To(Succeed())
+						Expect(testDataStoreSession.DeactivateDatasetDataInputs).To(ConsistOf(testDataset))
+					})
+				})
+
+				Context("DeleteDataset", func() {
+					BeforeEach(func() {
+						testDataStoreSession.RetainDeviceDataArchivedByDatasetOutputs = []error{nil}
+					})
+
+					AfterEach(func() {
+						Expect(testDataStoreSession.RetainDeviceDataArchivedByDatasetInputs).To(Equal([]*upload.Upload{testDataset}))
+					})
+
+					It("returns an error if there is an error with RetainDeviceDataArchivedByDataset", func() {
+						testDataStoreSession.RetainDeviceDataArchivedByDatasetOutputs = []error{errors.New("test error")}
+						err := testDeduplicator.DeleteDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to retain device data archived by dataset with id "%s"; test error`, testUploadID)))
+					})
+
+					Context("with retaining device data archived by dataset", func() {
+						BeforeEach(func() {
+							testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetOutputs = []error{nil}
+						})
+
+						AfterEach(func() {
+							Expect(testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetInputs).To(Equal([]*upload.Upload{testDataset}))
+						})
+
+						It("returns an error if there is an error with UnarchiveDeviceDataArchivedByDataset", func() {
+							testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetOutputs = []error{errors.New("test error")}
+							err := testDeduplicator.DeleteDataset()
+							Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to unarchive device data archived by dataset with id "%s"; test error`, testUploadID)))
+						})
+
+						It("returns successfully if there is no error", func() {
+							testDataStoreSession.DeleteDatasetOutputs = []error{nil}
+							Expect(testDeduplicator.DeleteDataset()).To(Succeed())
+							Expect(testDataStoreSession.DeleteDatasetInputs).To(ConsistOf(testDataset))
+						})
+					})
+				})
+			})
+		})
+	})
+})
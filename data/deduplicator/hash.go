@@ -42,6 +42,37 @@ func AssignDatasetDataIdentityHashes(datasetData []data.Datum) ([]string, error)
 	return hashes, nil
 }
 
+func AssignDatasetDataFuzzyIdentityHashes(datasetData []data.Datum) ([]string, error) {
+	if len(datasetData) == 0 {
+		return nil, nil
+	}
+
+	hashes := []string{}
+	for _, datasetDatum := range datasetData {
+		fields, err := datasetDatum.FuzzyIdentityFields()
+		if err != nil {
+			return nil, errors.Wrap(err, "deduplicator", "unable to gather fuzzy identity fields for datum")
+		}
+
+		hash, err := GenerateIdentityHash(fields)
+		if err != nil {
+			return nil, errors.Wrap(err, "deduplicator", "unable to generate fuzzy identity hash for datum")
+		}
+
+		deduplicatorDescriptor := datasetDatum.DeduplicatorDescriptor()
+		if deduplicatorDescriptor == nil {
+			deduplicatorDescriptor = data.NewDeduplicatorDescriptor()
+		}
+		deduplicatorDescriptor.FuzzyHash = hash
+
+		datasetDatum.SetDeduplicatorDescriptor(deduplicatorDescriptor)
+
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
 func GenerateIdentityHash(identityFields []string) (string, error) {
 	if len(identityFields) == 0 {
 		return "", errors.New("deduplicator", "identity fields are missing")
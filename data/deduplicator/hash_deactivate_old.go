@@ -3,12 +3,9 @@ package deduplicator
 import (
 	"strconv"
 
-	"github.com/tidepool-org/platform/app"
 	"github.com/tidepool-org/platform/data"
-	"github.com/tidepool-org/platform/data/store"
 	"github.com/tidepool-org/platform/data/types/upload"
 	"github.com/tidepool-org/platform/errors"
-	"github.com/tidepool-org/platform/log"
 )
 
 type hashDeactivateOldFactory struct {
@@ -22,21 +19,15 @@ type hashDeactivateOldDeduplicator struct {
 const _HashDeactivateOldDeduplicatorName = "org.tidepool.hash-deactivate-old"
 const _HashDeactivateOldDeduplicatorVersion = "1.1.0"
 
-var _HashDeactivateOldExpectedDeviceManufacturerModels = map[string][]string{
-	"Medtronic": {"523", "723", "551", "751", "554", "754"},
-	"LifeScan":  {"OneTouch Ultra 2", "OneTouch UltraMini"},
-	"Abbott":    {"FreeStyle Libre"},
-}
+func NewHashDeactivateOldFactory(rules Rules) (Factory, error) {
+	factory := &hashDeactivateOldFactory{}
 
-func NewHashDeactivateOldFactory() (Factory, error) {
-	baseFactory, err := NewBaseFactory(_HashDeactivateOldDeduplicatorName, _HashDeactivateOldDeduplicatorVersion)
+	baseFactory, err := newRulesBaseFactory(_HashDeactivateOldDeduplicatorName, _HashDeactivateOldDeduplicatorVersion, rules, factory.newDeduplicator)
 	if err != nil {
 		return nil, err
 	}
 
-	factory := &hashDeactivateOldFactory{
-		BaseFactory: baseFactory,
-	}
+	factory.BaseFactory = baseFactory
 	factory.Factory = factory
 
 	return factory, nil
@@ -60,14 +51,11 @@ func (h *hashDeactivateOldFactory) CanDeduplicateDataset(dataset *upload.Upload)
 		return false, nil
 	}
 
-	return allowDeviceManufacturerModel(_HashDeactivateOldExpectedDeviceManufacturerModels, *dataset.DeviceManufacturers, *dataset.DeviceModel), nil
+	return h.rules.Matches(dataset), nil
 }
 
-func (h *hashDeactivateOldFactory) NewDeduplicatorForDataset(logger log.Logger, dataStoreSession store.Session, dataset *upload.Upload) (data.Deduplicator, error) {
-	baseDeduplicator, err := NewBaseDeduplicator(h.name, h.version, logger, dataStoreSession, dataset)
-	if err != nil {
-		return nil, err
-	}
+func (h *hashDeactivateOldFactory) newDeduplicator(baseDeduplicator *BaseDeduplicator) (data.Deduplicator, error) {
+	dataset := baseDeduplicator.dataset
 
 	if dataset.DeviceID == nil {
 		return nil, errors.New("deduplicator", "dataset device id is missing")
@@ -82,10 +70,6 @@ func (h *hashDeactivateOldFactory) NewDeduplicatorForDataset(logger log.Logger,
 		return nil, errors.New("deduplicator", "dataset device model is missing")
 	}
 
-	if !allowDeviceManufacturerModel(_HashDeactivateOldExpectedDeviceManufacturerModels, *dataset.DeviceManufacturers, *dataset.DeviceModel) {
-		return nil, errors.New("deduplicator", "dataset device manufacturer and model does not contain expected device manufacturers and models")
-	}
-
 	return &hashDeactivateOldDeduplicator{
 		BaseDeduplicator: baseDeduplicator,
 	}, nil
@@ -103,29 +87,53 @@ func (h *hashDeactivateOldDeduplicator) AddDatasetData(datasetData []data.Datum)
 }
 
 func (h *hashDeactivateOldDeduplicator) DeduplicateDataset() error {
+	return h.runJournal(upload.JournalOperationDeduplicate, []journalStep{
+		{name: "archive-device-data", run: h.archiveDeviceData},
+		{name: "activate-dataset-data", run: h.BaseDeduplicator.DeduplicateDataset},
+	})
+}
+
+func (h *hashDeactivateOldDeduplicator) PreviewDeduplicateDataset() (*data.DeduplicatorPreview, error) {
+	h.logger.Debug("PreviewDeduplicateDataset")
+
+	dataPreview, err := h.dataStoreSession.PreviewArchiveDeviceDataUsingHashesFromDataset(h.dataset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "deduplicator", "unable to preview archive device data using hashes from dataset with id %s", strconv.Quote(h.dataset.UploadID))
+	}
+
+	preview := data.NewDeduplicatorPreview(h.name, h.version)
+	preview.AddAction("archive-device-data", dataPreview.Count, dataPreview.SampleIDs)
+
+	if err = h.previewActivateDatasetData(preview); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+func (h *hashDeactivateOldDeduplicator) archiveDeviceData() error {
 	if err := h.dataStoreSession.ArchiveDeviceDataUsingHashesFromDataset(h.dataset); err != nil {
 		return errors.Wrapf(err, "deduplicator", "unable to archive device data using hashes from dataset with id %s", strconv.Quote(h.dataset.UploadID))
 	}
 
-	return h.BaseDeduplicator.DeduplicateDataset()
+	return nil
 }
 
-func (h *hashDeactivateOldDeduplicator) DeleteDataset() error {
+func (h *hashDeactivateOldDeduplicator) UndeduplicateDataset() error {
 	if err := h.dataStoreSession.UnarchiveDeviceDataUsingHashesFromDataset(h.dataset); err != nil {
 		return errors.Wrapf(err, "deduplicator", "unable to unarchive device data using hashes from dataset with id %s", strconv.Quote(h.dataset.UploadID))
 	}
 
-	return h.BaseDeduplicator.DeleteDataset()
+	return h.BaseDeduplicator.UndeduplicateDataset()
 }
 
-func allowDeviceManufacturerModel(allowedDeviceManufacturerModels map[string][]string, deviceManufacturers []string, deviceModel string) bool {
-	for _, deviceManufacturer := range deviceManufacturers {
-		if deviceModels, found := allowedDeviceManufacturerModels[deviceManufacturer]; found {
-			if app.StringsContainsString(deviceModels, deviceModel) {
-				return true
-			}
-		}
+func (h *hashDeactivateOldDeduplicator) DeleteDataset() error {
+	if err := h.retainDeviceDataArchivedByDataset(); err != nil {
+		return err
+	}
+	if err := h.dataStoreSession.UnarchiveDeviceDataUsingHashesFromDataset(h.dataset); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to unarchive device data using hashes from dataset with id %s", strconv.Quote(h.dataset.UploadID))
 	}
 
-	return false
+	return h.BaseDeduplicator.DeleteDataset()
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/tidepool-org/platform/app"
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/deduplicator"
+	"github.com/tidepool-org/platform/data/store"
 	testDataStore "github.com/tidepool-org/platform/data/store/test"
 	testData "github.com/tidepool-org/platform/data/test"
 	"github.com/tidepool-org/platform/data/types/upload"
@@ -18,9 +19,25 @@ import (
 )
 
 var _ = Describe("HashDeactivateOld", func() {
+	var testRules deduplicator.Rules
+
+	BeforeEach(func() {
+		testRules = deduplicator.Rules{
+			{DeviceManufacturers: []string{"Medtronic"}, DeviceModels: []string{"523", "723", "551", "751", "554", "754"}},
+			{DeviceManufacturers: []string{"LifeScan"}, DeviceModels: []string{"OneTouch Ultra 2", "OneTouch UltraMini"}},
+			{DeviceManufacturers: []string{"Abbott"}, DeviceModels: []string{"FreeStyle Libre"}},
+		}
+	})
+
 	Context("NewHashDeactivateOldFactory", func() {
+		It("returns an error if the rules are missing", func() {
+			testFactory, err := deduplicator.NewHashDeactivateOldFactory(nil)
+			Expect(err).To(MatchError("deduplicator: rules is missing"))
+			Expect(testFactory).To(BeNil())
+		})
+
 		It("returns a new factory", func() {
-			Expect(deduplicator.NewHashDeactivateOldFactory()).ToNot(BeNil())
+			Expect(deduplicator.NewHashDeactivateOldFactory(testRules)).ToNot(BeNil())
 		})
 	})
 
@@ -32,7 +49,7 @@ var _ = Describe("HashDeactivateOld", func() {
 
 		BeforeEach(func() {
 			var err error
-			testFactory, err = deduplicator.NewHashDeactivateOldFactory()
+			testFactory, err = deduplicator.NewHashDeactivateOldFactory(testRules)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(testFactory).ToNot(BeNil())
 			testUploadID = app.NewID()
@@ -215,7 +232,7 @@ var _ = Describe("HashDeactivateOld", func() {
 				It("returns an error if the device manufacturers is empty", func() {
 					testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{})
 					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
-					Expect(err).To(MatchError("deduplicator: dataset device manufacturer and model does not contain expected device manufacturers and models"))
+					Expect(err).To(MatchError("deduplicator: dataset does not match rules"))
 					Expect(testDeduplicator).To(BeNil())
 				})
 
@@ -229,21 +246,21 @@ var _ = Describe("HashDeactivateOld", func() {
 				It("returns an error if the device model is empty", func() {
 					testDataset.DeviceModel = app.StringAsPointer("")
 					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
-					Expect(err).To(MatchError("deduplicator: dataset device manufacturer and model does not contain expected device manufacturers and models"))
+					Expect(err).To(MatchError("deduplicator: dataset does not match rules"))
 					Expect(testDeduplicator).To(BeNil())
 				})
 
 				It("returns an error if the device manufacturers does not contain expected device manufacturer", func() {
 					testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Ant", "Zebra", "Cobra"})
 					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
-					Expect(err).To(MatchError("deduplicator: dataset device manufacturer and model does not contain expected device manufacturers and models"))
+					Expect(err).To(MatchError("deduplicator: dataset does not match rules"))
 					Expect(testDeduplicator).To(BeNil())
 				})
 
 				It("returns an error if the device model does not contain expected device model", func() {
 					testDataset.DeviceModel = app.StringAsPointer("123")
 					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
-					Expect(err).To(MatchError("deduplicator: dataset device manufacturer and model does not contain expected device manufacturers and models"))
+					Expect(err).To(MatchError("deduplicator: dataset does not match rules"))
 					Expect(testDeduplicator).To(BeNil())
 				})
 
@@ -274,6 +291,42 @@ var _ = Describe("HashDeactivateOld", func() {
 				)
 			})
 
+			Context("with a dataset registered with the deduplicator", func() {
+				BeforeEach(func() {
+					testDataset.Deduplicator = &data.DeduplicatorDescriptor{Name: "org.tidepool.hash-deactivate-old", Version: "1.0.0"}
+				})
+
+				It("is registered with the dataset", func() {
+					Expect(testFactory.IsRegisteredWithDataset(testDataset)).To(BeTrue())
+				})
+
+				It("is registered with the dataset even if the dataset does not match rules", func() {
+					testDataset.DeviceModel = app.StringAsPointer("123")
+					Expect(testFactory.IsRegisteredWithDataset(testDataset)).To(BeTrue())
+				})
+
+				It("is not registered with the dataset if the registered version is later", func() {
+					testDataset.Deduplicator.Version = "2.0.0"
+					Expect(testFactory.IsRegisteredWithDataset(testDataset)).To(BeFalse())
+				})
+
+				It("returns a new registered deduplicator upon success", func() {
+					Expect(testFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)).ToNot(BeNil())
+				})
+
+				It("returns a new registered deduplicator even if the dataset does not match rules", func() {
+					testDataset.DeviceModel = app.StringAsPointer("123")
+					Expect(testFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)).ToNot(BeNil())
+				})
+
+				It("returns an error if the dataset is registered with another deduplicator", func() {
+					testDataset.Deduplicator.Name = "org.tidepool.other"
+					testDeduplicator, err := testFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
+					Expect(err).To(MatchError("deduplicator: dataset deduplicator descriptor is not registered with expected deduplicator"))
+					Expect(testDeduplicator).To(BeNil())
+				})
+			})
+
 			Context("with a new deduplicator", func() {
 				var testDeduplicator data.Deduplicator
 				var testDataData []*testData.Datum
@@ -375,6 +428,23 @@ var _ = Describe("HashDeactivateOld", func() {
 				})
 
 				Context("DeduplicateDataset", func() {
+					It("returns an error if there is an error with UpdateDatasetJournal when creating the journal", func() {
+						testDataStoreSession.UpdateDatasetJournalOutputs = []error{errors.New("test error")}
+						err := testDeduplicator.DeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to update dataset journal with id "%s"; test error`, testUploadID)))
+					})
+
+					It("recovers from the journal by completing only the remaining steps", func() {
+						testDataset.Journal = upload.NewJournal(upload.JournalOperationDeduplicate, []string{"archive-device-data", "activate-dataset-data"}, "2017-09-01T12:00:00Z")
+						testDataset.Journal.CompletedSteps = 1
+						testDataStoreSession.ActivateDatasetDataOutputs = []error{nil}
+						testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil}
+						Expect(testDeduplicator.DeduplicateDataset()).To(Succeed())
+						Expect(testDataStoreSession.ArchiveDeviceDataUsingHashesFromDatasetInvocations).To(Equal(0))
+						Expect(testDataStoreSession.ActivateDatasetDataInputs).To(ConsistOf(testDataset))
+						Expect(testDataset.Journal).To(BeNil())
+					})
+
 					Context("with archive device data using hashes from dataset", func() {
 						BeforeEach(func() {
 							testDataStoreSession.ArchiveDeviceDataUsingHashesFromDatasetOutputs = []error{nil}
@@ -385,9 +455,12 @@ var _ = Describe("HashDeactivateOld", func() {
 						})
 
 						It("returns an error if there is an error with ArchiveDeviceDataUsingHashesFromDataset", func() {
+							testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil}
 							testDataStoreSession.ArchiveDeviceDataUsingHashesFromDatasetOutputs = []error{errors.New("test error")}
 							err := testDeduplicator.DeduplicateDataset()
 							Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to archive device data using hashes from dataset with id "%s"; test error`, testUploadID)))
+							Expect(testDataset.Journal).ToNot(BeNil())
+							Expect(testDataset.Journal.CompletedSteps).To(Equal(0))
 						})
 
 						Context("with activating dataset data", func() {
@@ -400,19 +473,64 @@ var _ = Describe("HashDeactivateOld", func() {
 							})
 
 							It("returns an error if there is an error with ActivateDatasetData", func() {
+								testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, nil}
 								testDataStoreSession.ActivateDatasetDataOutputs = []error{errors.New("test error")}
 								err := testDeduplicator.DeduplicateDataset()
 								Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to activate dataset data with id "%s"; test error`, testUploadID)))
+								Expect(testDataset.Journal).ToNot(BeNil())
+								Expect(testDataset.Journal.CompletedSteps).To(Equal(1))
+							})
+
+							It("returns an error if there is an error with UpdateDatasetJournal when clearing the journal", func() {
+								testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, nil, errors.New("test error")}
+								err := testDeduplicator.DeduplicateDataset()
+								Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to update dataset journal with id "%s"; test error`, testUploadID)))
 							})
 
 							It("returns successfully if there is no error", func() {
+								testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, nil, nil}
 								Expect(testDeduplicator.DeduplicateDataset()).To(Succeed())
+								Expect(testDataset.Journal).To(BeNil())
 							})
 						})
 					})
 				})
 
-				Context("DeleteDataset", func() {
+				Context("PreviewDeduplicateDataset", func() {
+					It("returns an error if there is an error with PreviewArchiveDeviceDataUsingHashesFromDataset", func() {
+						testDataStoreSession.PreviewArchiveDeviceDataUsingHashesFromDatasetOutputs = []testDataStore.DataPreviewOutput{{Preview: nil, Error: errors.New("test error")}}
+						preview, err := testDeduplicator.PreviewDeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to preview archive device data using hashes from dataset with id "%s"; test error`, testUploadID)))
+						Expect(preview).To(BeNil())
+					})
+
+					It("returns an error if there is an error with PreviewActivateDatasetData", func() {
+						testDataStoreSession.PreviewArchiveDeviceDataUsingHashesFromDatasetOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 1, SampleIDs: []string{"a"}}, Error: nil}}
+						testDataStoreSession.PreviewActivateDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: nil, Error: errors.New("test error")}}
+						preview, err := testDeduplicator.PreviewDeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to preview activate dataset data with id "%s"; test error`, testUploadID)))
+						Expect(preview).To(BeNil())
+					})
+
+					It("returns successfully if there is no error", func() {
+						testDataStoreSession.PreviewArchiveDeviceDataUsingHashesFromDatasetOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 1, SampleIDs: []string{"a"}}, Error: nil}}
+						testDataStoreSession.PreviewActivateDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 2, SampleIDs: []string{"b", "c"}}, Error: nil}}
+						preview, err := testDeduplicator.PreviewDeduplicateDataset()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(preview).To(Equal(&data.DeduplicatorPreview{
+							Name:    "org.tidepool.hash-deactivate-old",
+							Version: "1.1.0",
+							Actions: []*data.DeduplicatorPreviewAction{
+								{Action: "archive-device-data", Count: 1, SampleIDs: []string{"a"}},
+								{Action: "activate-dataset-data", Count: 2, SampleIDs: []string{"b", "c"}},
+							},
+						}))
+						Expect(testDataStoreSession.PreviewArchiveDeviceDataUsingHashesFromDatasetInputs).To(Equal([]*upload.Upload{testDataset}))
+						Expect(testDataStoreSession.PreviewActivateDatasetDataInputs).To(Equal([]*upload.Upload{testDataset}))
+					})
+				})
+
+				Context("UndeduplicateDataset", func() {
 					Context("with unarchive device data using hashes from dataset", func() {
 						BeforeEach(func() {
 							testDataStoreSession.UnarchiveDeviceDataUsingHashesFromDatasetOutputs = []error{nil}
@@ -424,27 +542,81 @@ var _ = Describe("HashDeactivateOld", func() {
 
 						It("returns an error if there is an error with UnarchiveDeviceDataUsingHashesFromDataset", func() {
 							testDataStoreSession.UnarchiveDeviceDataUsingHashesFromDatasetOutputs = []error{errors.New("test error")}
-							err := testDeduplicator.DeleteDataset()
+							err := testDeduplicator.UndeduplicateDataset()
 							Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to unarchive device data using hashes from dataset with id "%s"; test error`, testUploadID)))
 						})
 
-						Context("with deleting dataset", func() {
+						Context("with deactivating dataset data", func() {
 							BeforeEach(func() {
-								testDataStoreSession.DeleteDatasetOutputs = []error{nil}
+								testDataStoreSession.DeactivateDatasetDataOutputs = []error{nil}
 							})
 
 							AfterEach(func() {
-								Expect(testDataStoreSession.DeleteDatasetInputs).To(ConsistOf(testDataset))
+								Expect(testDataStoreSession.DeactivateDatasetDataInputs).To(ConsistOf(testDataset))
 							})
 
-							It("returns an error if there is an error with DeleteDataset", func() {
-								testDataStoreSession.DeleteDatasetOutputs = []error{errors.New("test error")}
-								err := testDeduplicator.DeleteDataset()
-								Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to delete dataset with id "%s"; test error`, testUploadID)))
+							It("returns an error if there is an error with DeactivateDatasetData", func() {
+								testDataStoreSession.DeactivateDatasetDataOutputs = []error{errors.New("test error")}
+								err := testDeduplicator.UndeduplicateDataset()
+								Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to deactivate dataset data with id "%s"; test error`, testUploadID)))
 							})
 
 							It("returns successfully if there is no error", func() {
-								Expect(testDeduplicator.DeleteDataset()).To(Succeed())
+								Expect(testDeduplicator.UndeduplicateDataset()).To(Succeed())
+							})
+						})
+					})
+				})
+
+				Context("DeleteDataset", func() {
+					BeforeEach(func() {
+						testDataStoreSession.RetainDeviceDataArchivedByDatasetOutputs = []error{nil}
+					})
+
+					AfterEach(func() {
+						Expect(testDataStoreSession.RetainDeviceDataArchivedByDatasetInputs).To(Equal([]*upload.Upload{testDataset}))
+					})
+
+					It("returns an error if there is an error with RetainDeviceDataArchivedByDataset", func() {
+						testDataStoreSession.RetainDeviceDataArchivedByDatasetOutputs = []error{errors.New("test error")}
+						err := testDeduplicator.DeleteDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to retain device data archived by dataset with id "%s"; test error`, testUploadID)))
+					})
+
+					Context("with retaining device data archived by dataset", func() {
+						Context("with unarchive device data using hashes from dataset", func() {
+							BeforeEach(func() {
+								testDataStoreSession.UnarchiveDeviceDataUsingHashesFromDatasetOutputs = []error{nil}
+							})
+
+							AfterEach(func() {
+								Expect(testDataStoreSession.UnarchiveDeviceDataUsingHashesFromDatasetInputs).To(Equal([]*upload.Upload{testDataset}))
+							})
+
+							It("returns an error if there is an error with UnarchiveDeviceDataUsingHashesFromDataset", func() {
+								testDataStoreSession.UnarchiveDeviceDataUsingHashesFromDatasetOutputs = []error{errors.New("test error")}
+								err := testDeduplicator.DeleteDataset()
+								Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to unarchive device data using hashes from dataset with id "%s"; test error`, testUploadID)))
+							})
+
+							Context("with deleting dataset", func() {
+								BeforeEach(func() {
+									testDataStoreSession.DeleteDatasetOutputs = []error{nil}
+								})
+
+								AfterEach(func() {
+									Expect(testDataStoreSession.DeleteDatasetInputs).To(ConsistOf(testDataset))
+								})
+
+								It("returns an error if there is an error with DeleteDataset", func() {
+									testDataStoreSession.DeleteDatasetOutputs = []error{errors.New("test error")}
+									err := testDeduplicator.DeleteDataset()
+									Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to delete dataset with id "%s"; test error`, testUploadID)))
+								})
+
+								It("returns successfully if there is no error", func() {
+									Expect(testDeduplicator.DeleteDataset()).To(Succeed())
+								})
 							})
 						})
 					})
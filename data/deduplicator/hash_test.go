@@ -10,6 +10,8 @@ import (
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/deduplicator"
 	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/basal/scheduled"
+	"github.com/tidepool-org/platform/data/types/bolus/normal"
 )
 
 var _ = Describe("Hash", func() {
@@ -98,6 +100,131 @@ var _ = Describe("Hash", func() {
 		})
 	})
 
+	Context("AssignDatasetDataFuzzyIdentityHashes", func() {
+		var testDataData []*testData.Datum
+		var testDatasetData []data.Datum
+
+		BeforeEach(func() {
+			testDataData = []*testData.Datum{}
+			testDatasetData = []data.Datum{}
+			for i := 0; i < 3; i++ {
+				testDatum := testData.NewDatum()
+				testDataData = append(testDataData, testDatum)
+				testDatasetData = append(testDatasetData, testDatum)
+			}
+		})
+
+		AfterEach(func() {
+			for _, testDataDatum := range testDataData {
+				Expect(testDataDatum.UnusedOutputsCount()).To(Equal(0))
+			}
+		})
+
+		It("returns successfully if there is no data", func() {
+			Expect(deduplicator.AssignDatasetDataFuzzyIdentityHashes([]data.Datum{})).To(BeNil())
+		})
+
+		It("returns an error if any datum returns an error getting fuzzy identity fields", func() {
+			testDataData[0].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: []string{app.NewID(), app.NewID()}, Error: nil}}
+			testDataData[1].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: nil, Error: errors.New("test error")}}
+			hashes, err := deduplicator.AssignDatasetDataFuzzyIdentityHashes(testDatasetData)
+			Expect(err).To(MatchError("deduplicator: unable to gather fuzzy identity fields for datum; test error"))
+			Expect(hashes).To(BeNil())
+		})
+
+		It("returns an error if any datum returns no fuzzy identity fields", func() {
+			testDataData[0].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: []string{app.NewID(), app.NewID()}, Error: nil}}
+			testDataData[1].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: nil, Error: nil}}
+			hashes, err := deduplicator.AssignDatasetDataFuzzyIdentityHashes(testDatasetData)
+			Expect(err).To(MatchError("deduplicator: unable to generate fuzzy identity hash for datum; deduplicator: identity fields are missing"))
+			Expect(hashes).To(BeNil())
+		})
+
+		Context("with fuzzy identity fields", func() {
+			BeforeEach(func() {
+				testDataData[0].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: []string{"test", "0"}, Error: nil}}
+				testDataData[1].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: []string{"test", "1"}, Error: nil}}
+				testDataData[2].FuzzyIdentityFieldsOutputs = []testData.IdentityFieldsOutput{{IdentityFields: []string{"test", "2"}, Error: nil}}
+				testDataData[2].DeduplicatorDescriptorValue = &data.DeduplicatorDescriptor{Hash: "existing"}
+			})
+
+			AfterEach(func() {
+				Expect(testDataData[0].DeduplicatorDescriptorValue).To(Equal(&data.DeduplicatorDescriptor{FuzzyHash: "GRp47M02cMlAzSn7oJTQ2LC9eb1Qd6mIPO1U8GeuoYg="}))
+				Expect(testDataData[1].DeduplicatorDescriptorValue).To(Equal(&data.DeduplicatorDescriptor{FuzzyHash: "+cywqM0rcj9REPt87Vfx2U+j9m57cB0XW2kmNZm5Ao8="}))
+				Expect(testDataData[2].DeduplicatorDescriptorValue).To(Equal(&data.DeduplicatorDescriptor{Hash: "existing", FuzzyHash: "dCPMoOxFVMbPvMkXMbyKeff8QmdBPu8hr/BVeHJhz78="}))
+			})
+
+			It("returns successfully", func() {
+				hashes, err := deduplicator.AssignDatasetDataFuzzyIdentityHashes(testDatasetData)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hashes).To(Equal([]string{
+					"GRp47M02cMlAzSn7oJTQ2LC9eb1Qd6mIPO1U8GeuoYg=",
+					"+cywqM0rcj9REPt87Vfx2U+j9m57cB0XW2kmNZm5Ao8=",
+					"dCPMoOxFVMbPvMkXMbyKeff8QmdBPu8hr/BVeHJhz78=",
+				}))
+			})
+		})
+
+		Context("with distinct data at the same device time", func() {
+			var userID string
+			var deviceID string
+
+			BeforeEach(func() {
+				userID = app.NewID()
+				deviceID = app.NewID()
+			})
+
+			It("assigns distinct fuzzy hashes to two boluses with distinct amounts", func() {
+				datasetData := []data.Datum{}
+				for _, amount := range []float64{1.5, 2.5} {
+					datum := normal.Init()
+					datum.UserID = userID
+					datum.DeviceID = app.StringAsPointer(deviceID)
+					datum.DeviceTime = app.StringAsPointer("2016-09-06T13:45:58")
+					datum.Normal = app.FloatAsPointer(amount)
+					datasetData = append(datasetData, datum)
+				}
+				hashes, err := deduplicator.AssignDatasetDataFuzzyIdentityHashes(datasetData)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hashes).To(HaveLen(2))
+				Expect(hashes[0]).ToNot(Equal(hashes[1]))
+			})
+
+			It("assigns distinct fuzzy hashes to two basals with distinct rates", func() {
+				datasetData := []data.Datum{}
+				for _, rate := range []float64{0.5, 0.75} {
+					datum := scheduled.Init()
+					datum.UserID = userID
+					datum.DeviceID = app.StringAsPointer(deviceID)
+					datum.DeviceTime = app.StringAsPointer("2016-09-06T13:45:58")
+					datum.Rate = app.FloatAsPointer(rate)
+					datum.Duration = app.IntegerAsPointer(3600000)
+					datasetData = append(datasetData, datum)
+				}
+				hashes, err := deduplicator.AssignDatasetDataFuzzyIdentityHashes(datasetData)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hashes).To(HaveLen(2))
+				Expect(hashes[0]).ToNot(Equal(hashes[1]))
+			})
+
+			It("assigns the same fuzzy hash to two boluses with the same amount", func() {
+				datasetData := []data.Datum{}
+				for _, deviceTime := range []string{"2016-09-06T13:45:58", "2016-09-06T13:46:08"} {
+					datum := normal.Init()
+					datum.UserID = userID
+					datum.DeviceID = app.StringAsPointer(deviceID)
+					datum.DeviceTime = app.StringAsPointer(deviceTime)
+					datum.Normal = app.FloatAsPointer(1.5)
+					datasetData = append(datasetData, datum)
+				}
+				hashes, err := deduplicator.AssignDatasetDataFuzzyIdentityHashes(datasetData)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hashes).To(HaveLen(2))
+				Expect(hashes[0]).To(Equal(hashes[1]))
+			})
+		})
+	})
+
 	Context("GenerateIdentityHash", func() {
 		It("returns an error if identity fields is missing", func() {
 			hash, err := deduplicator.GenerateIdentityHash(nil)
@@ -0,0 +1,344 @@
+package deduplicator
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/store"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/errors"
+	lockStore "github.com/tidepool-org/platform/lock/store"
+	"github.com/tidepool-org/platform/log"
+)
+
+const RededuplicateLockOperation = "rededuplicate"
+
+const (
+	RededuplicateSkippedNotClosed     = "not-closed"
+	RededuplicateSkippedNotRegistered = "not-registered"
+	RededuplicateSkippedNotSupported  = "not-supported"
+)
+
+type RededuplicateReport struct {
+	UserID   string                        `json:"userId"`
+	DryRun   bool                          `json:"dryRun"`
+	Datasets []*RededuplicateDatasetReport `json:"datasets"`
+}
+
+type RededuplicateDatasetReport struct {
+	DatasetID   string                    `json:"datasetId"`
+	FromName    string                    `json:"fromName,omitempty"`
+	FromVersion string                    `json:"fromVersion,omitempty"`
+	ToName      string                    `json:"toName,omitempty"`
+	ToVersion   string                    `json:"toVersion,omitempty"`
+	Skipped     string                    `json:"skipped,omitempty"`
+	Preview     *data.DeduplicatorPreview `json:"preview,omitempty"`
+}
+
+func (r *RededuplicateReport) ChangedCount() int {
+	count := 0
+	for _, datasetReport := range r.Datasets {
+		if datasetReport.IsChanged() {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *RededuplicateReport) SkippedCount() int {
+	count := 0
+	for _, datasetReport := range r.Datasets {
+		if datasetReport.Skipped != "" {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *RededuplicateDatasetReport) IsChanged() bool {
+	return r.Skipped == "" && (r.FromName != r.ToName || r.FromVersion != r.ToVersion)
+}
+
+// RededuplicateUser replays all closed datasets for the user through the factory. The deduplication of each
+// dataset is first undone using its registered deduplicator, in reverse upload order, and then redone using
+// the deduplicator currently selected by the factory, in upload order, so redoing an older dataset never
+// archives the data of a newer dataset that is not yet undone. Each dataset records the completed steps of its
+// replay in a journal, and the user data is locked for the duration, so an interrupted replay is resumed,
+// with the user data still locked, when the user is next rededuplicated. With dry run, the user data is not
+// locked and only previews, as if each dataset were undone, are gathered.
+func RededuplicateUser(logger log.Logger, dataStoreSession store.Session, lockStoreSession lockStore.Session, factory Factory, userID string, dryRun bool) (*RededuplicateReport, error) {
+	if logger == nil {
+		return nil, errors.New("deduplicator", "logger is missing")
+	}
+	if dataStoreSession == nil {
+		return nil, errors.New("deduplicator", "data store session is missing")
+	}
+	if lockStoreSession == nil {
+		return nil, errors.New("deduplicator", "lock store session is missing")
+	}
+	if factory == nil {
+		return nil, errors.New("deduplicator", "factory is missing")
+	}
+	if userID == "" {
+		return nil, errors.New("deduplicator", "user id is missing")
+	}
+
+	logger = logger.WithFields(log.Fields{"userId": userID, "dryRun": dryRun})
+
+	if !dryRun {
+		acquired, err := lockStoreSession.AcquireLockForUserByID(userID, RededuplicateLockOperation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "deduplicator", "unable to acquire lock for user with id %s", strconv.Quote(userID))
+		} else if !acquired {
+			return nil, errors.Newf("deduplicator", "user with id %s is locked", strconv.Quote(userID))
+		}
+	}
+
+	datasets, err := getDatasetsInUploadOrder(dataStoreSession, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RededuplicateReport{
+		UserID:   userID,
+		DryRun:   dryRun,
+		Datasets: []*RededuplicateDatasetReport{},
+	}
+
+	replays := []*rededuplicateReplay{}
+	for _, dataset := range datasets {
+		datasetReport := &RededuplicateDatasetReport{DatasetID: dataset.UploadID}
+		report.Datasets = append(report.Datasets, datasetReport)
+
+		if deduplicatorDescriptor := dataset.DeduplicatorDescriptor(); deduplicatorDescriptor != nil {
+			datasetReport.FromName = deduplicatorDescriptor.Name
+			datasetReport.FromVersion = deduplicatorDescriptor.Version
+		}
+
+		if dataset.CurrentState() != upload.StateClosed {
+			datasetReport.Skipped = RededuplicateSkippedNotClosed
+			continue
+		}
+
+		// A dataset with a journal is resumed, even though it may no longer be registered with any deduplicator
+		if dataset.RededuplicateJournal == nil {
+			if is, isErr := factory.IsRegisteredWithDataset(dataset); isErr != nil {
+				return nil, errors.Wrapf(isErr, "deduplicator", "unable to determine if registered with dataset with id %s", strconv.Quote(dataset.UploadID))
+			} else if !is {
+				datasetReport.Skipped = RededuplicateSkippedNotRegistered
+				continue
+			}
+
+			if can, canErr := factory.CanDeduplicateDataset(dataset); canErr != nil {
+				return nil, errors.Wrapf(canErr, "deduplicator", "unable to determine if can deduplicate dataset with id %s", strconv.Quote(dataset.UploadID))
+			} else if !can {
+				datasetReport.Skipped = RededuplicateSkippedNotSupported
+				continue
+			}
+		}
+
+		to, toErr := factory.NewDeduplicatorForDataset(logger, dataStoreSession, dataset)
+		if toErr != nil {
+			return nil, errors.Wrapf(toErr, "deduplicator", "unable to create deduplicator for dataset with id %s", strconv.Quote(dataset.UploadID))
+		}
+
+		datasetReport.ToName = to.Name()
+		datasetReport.ToVersion = to.Version()
+
+		if dryRun {
+			if datasetReport.Preview, err = to.PreviewDeduplicateDataset(); err != nil {
+				return nil, errors.Wrapf(err, "deduplicator", "unable to preview deduplicate dataset with id %s", strconv.Quote(dataset.UploadID))
+			}
+			continue
+		}
+
+		replays = append(replays, &rededuplicateReplay{logger: logger, dataStoreSession: dataStoreSession, factory: factory, dataset: dataset})
+	}
+
+	// On error, the lock is not released so the user data remains read only until the replay is resumed
+	for index := len(replays) - 1; index >= 0; index-- {
+		if err = replays[index].run(_RededuplicateReplayUndoStepsCount); err != nil {
+			return nil, err
+		}
+	}
+	for _, replay := range replays {
+		if err = replay.run(len(replay.steps())); err != nil {
+			return nil, err
+		}
+	}
+
+	if !dryRun {
+		if err = lockStoreSession.ReleaseLockForUserByID(userID, RededuplicateLockOperation); err != nil {
+			return nil, errors.Wrapf(err, "deduplicator", "unable to release lock for user with id %s", strconv.Quote(userID))
+		}
+	}
+
+	logger.WithFields(log.Fields{"datasetsCount": len(report.Datasets), "changedCount": report.ChangedCount(), "skippedCount": report.SkippedCount()}).Debug("RededuplicateUser")
+
+	return report, nil
+}
+
+const _RededuplicateReplayUndoStepsCount = 1
+
+type rededuplicateReplay struct {
+	logger           log.Logger
+	dataStoreSession store.Session
+	factory          Factory
+	dataset          *upload.Upload
+}
+
+func (r *rededuplicateReplay) steps() []journalStep {
+	return []journalStep{
+		{name: "reopen-dataset", run: r.reopenDataset},
+		{name: "register-dataset", run: r.registerDataset},
+		{name: "deduplicate-dataset", run: r.deduplicateDataset},
+	}
+}
+
+// Runs the steps of the replay not yet completed, up to the steps count, recording each completed step in the
+// journal of the dataset. The journal is removed once all steps are completed.
+func (r *rededuplicateReplay) run(stepsCount int) error {
+	steps := r.steps()
+
+	stepNames := []string{}
+	for _, step := range steps {
+		stepNames = append(stepNames, step.name)
+	}
+
+	journal := r.dataset.RededuplicateJournal
+	if journal == nil {
+		journal = upload.NewJournal(upload.JournalOperationRededuplicate, stepNames, time.Now().UTC().Format(time.RFC3339))
+		r.dataset.RededuplicateJournal = journal
+		if err := r.updateJournal(); err != nil {
+			return err
+		}
+	} else if journal.Operation != upload.JournalOperationRededuplicate || !reflect.DeepEqual(journal.Steps, stepNames) {
+		return errors.Newf("deduplicator", "dataset with id %s has incomplete journal for operation %s", strconv.Quote(r.dataset.UploadID), strconv.Quote(journal.Operation))
+	} else if journal.CompletedSteps < stepsCount {
+		r.logger.WithFields(log.Fields{"datasetId": r.dataset.UploadID, "journalOperation": journal.Operation, "journalStep": journal.NextStep()}).Warn("Recovering dataset from journal")
+	}
+
+	for journal.CompletedSteps < stepsCount && !journal.IsCompleted() {
+		if err := steps[journal.CompletedSteps].run(); err != nil {
+			return err
+		}
+
+		if journal.CompletedSteps++; !journal.IsCompleted() {
+			if err := r.updateJournal(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if journal.IsCompleted() {
+		r.dataset.RededuplicateJournal = nil
+		if err := r.updateJournal(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *rededuplicateReplay) reopenDataset() error {
+	from, err := r.factory.NewRegisteredDeduplicatorForDataset(r.logger, r.dataStoreSession, r.dataset)
+	if err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to create registered deduplicator for dataset with id %s", strconv.Quote(r.dataset.UploadID))
+	}
+
+	if err = from.UndeduplicateDataset(); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to undeduplicate dataset with id %s", strconv.Quote(r.dataset.UploadID))
+	}
+
+	deduplicatorDescriptor := r.dataset.DeduplicatorDescriptor()
+	deduplicatorDescriptor.Name = ""
+	deduplicatorDescriptor.Version = ""
+	r.dataset.Journal = nil
+
+	return nil
+}
+
+func (r *rededuplicateReplay) registerDataset() error {
+	to, err := r.factory.NewDeduplicatorForDataset(r.logger, r.dataStoreSession, r.dataset)
+	if err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to create deduplicator for dataset with id %s", strconv.Quote(r.dataset.UploadID))
+	}
+
+	if err = to.RegisterDataset(); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to register dataset with id %s", strconv.Quote(r.dataset.UploadID))
+	}
+
+	return nil
+}
+
+func (r *rededuplicateReplay) deduplicateDataset() error {
+	to, err := r.factory.NewRegisteredDeduplicatorForDataset(r.logger, r.dataStoreSession, r.dataset)
+	if err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to create registered deduplicator for dataset with id %s", strconv.Quote(r.dataset.UploadID))
+	}
+
+	if err = to.DeduplicateDataset(); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to deduplicate dataset with id %s", strconv.Quote(r.dataset.UploadID))
+	}
+
+	return nil
+}
+
+func (r *rededuplicateReplay) updateJournal() error {
+	if err := r.dataStoreSession.UpdateDatasetRededuplicateJournal(r.dataset); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to update dataset rededuplicate journal with id %s", strconv.Quote(r.dataset.UploadID))
+	}
+
+	return nil
+}
+
+func getDatasetsInUploadOrder(dataStoreSession store.Session, userID string) ([]*upload.Upload, error) {
+	datasets := []*upload.Upload{}
+
+	pagination := store.NewPagination()
+	for {
+		page, err := dataStoreSession.GetDatasetsForUserByID(userID, nil, pagination)
+		if err != nil {
+			return nil, errors.Wrapf(err, "deduplicator", "unable to get datasets for user with id %s", strconv.Quote(userID))
+		}
+
+		datasets = append(datasets, page...)
+		if len(page) < pagination.Size {
+			break
+		}
+
+		last := page[len(page)-1]
+		pagination.Cursor = store.NewCursor(last.CreatedTime, last.ID)
+	}
+
+	// The created time has a resolution of one second and comes from the clock of whichever service created
+	// the dataset, so it does not reliably order uploads. Instead, datasets are ordered by the latest time of
+	// their data, which does not change when the dataset is replayed, falling back to the created time for a
+	// dataset without data.
+	orderTimes := map[string]time.Time{}
+	for _, dataset := range datasets {
+		latestTime, err := dataStoreSession.GetDatasetLatestTime(dataset)
+		if err != nil {
+			return nil, errors.Wrapf(err, "deduplicator", "unable to get latest time for dataset with id %s", strconv.Quote(dataset.UploadID))
+		} else if latestTime != nil {
+			orderTimes[dataset.UploadID] = *latestTime
+		} else if createdTime, createdTimeErr := time.Parse(time.RFC3339Nano, dataset.CreatedTime); createdTimeErr == nil {
+			orderTimes[dataset.UploadID] = createdTime
+		}
+	}
+
+	sort.Slice(datasets, func(left int, right int) bool {
+		leftTime, rightTime := orderTimes[datasets[left].UploadID], orderTimes[datasets[right].UploadID]
+		if !leftTime.Equal(rightTime) {
+			return leftTime.Before(rightTime)
+		} else if datasets[left].CreatedTime != datasets[right].CreatedTime {
+			return datasets[left].CreatedTime < datasets[right].CreatedTime
+		}
+		return datasets[left].UploadID < datasets[right].UploadID
+	})
+
+	return datasets, nil
+}
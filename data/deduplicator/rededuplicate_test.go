@@ -0,0 +1,369 @@
+package deduplicator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/deduplicator"
+	testDataDeduplicator "github.com/tidepool-org/platform/data/deduplicator/test"
+	"github.com/tidepool-org/platform/data/store"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/data/types/upload"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/log"
+)
+
+func newRededuplicateTestDataset(userID string, createdTime string) *upload.Upload {
+	testDataset := upload.Init()
+	testDataset.ID = app.NewID()
+	testDataset.UploadID = app.NewID()
+	testDataset.UserID = userID
+	testDataset.GroupID = app.NewID()
+	testDataset.CreatedTime = createdTime
+	testDataset.State = upload.StateClosed
+	testDataset.DeviceID = app.StringAsPointer(app.NewID())
+	testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Dexcom"})
+	testDataset.DeviceTags = app.StringArrayAsPointer([]string{"cgm"})
+	testDataset.Deduplicator = &data.DeduplicatorDescriptor{Name: "org.tidepool.time-window", Version: "0.9.0"}
+	return testDataset
+}
+
+var _ = Describe("Rededuplicate", func() {
+	Context("RededuplicateDatasetReport", func() {
+		Context("IsChanged", func() {
+			It("returns false if skipped", func() {
+				datasetReport := &deduplicator.RededuplicateDatasetReport{FromName: "a", FromVersion: "1.0.0", Skipped: deduplicator.RededuplicateSkippedNotClosed}
+				Expect(datasetReport.IsChanged()).To(BeFalse())
+			})
+
+			It("returns false if the name and version are unchanged", func() {
+				datasetReport := &deduplicator.RededuplicateDatasetReport{FromName: "a", FromVersion: "1.0.0", ToName: "a", ToVersion: "1.0.0"}
+				Expect(datasetReport.IsChanged()).To(BeFalse())
+			})
+
+			It("returns true if the name is changed", func() {
+				datasetReport := &deduplicator.RededuplicateDatasetReport{FromName: "a", FromVersion: "1.0.0", ToName: "b", ToVersion: "1.0.0"}
+				Expect(datasetReport.IsChanged()).To(BeTrue())
+			})
+
+			It("returns true if the version is changed", func() {
+				datasetReport := &deduplicator.RededuplicateDatasetReport{FromName: "a", FromVersion: "1.0.0", ToName: "a", ToVersion: "1.1.0"}
+				Expect(datasetReport.IsChanged()).To(BeTrue())
+			})
+		})
+	})
+
+	Context("RededuplicateReport", func() {
+		It("returns the changed and skipped counts", func() {
+			report := &deduplicator.RededuplicateReport{
+				Datasets: []*deduplicator.RededuplicateDatasetReport{
+					{FromName: "a", FromVersion: "1.0.0", ToName: "a", ToVersion: "1.1.0"},
+					{FromName: "a", FromVersion: "1.1.0", ToName: "a", ToVersion: "1.1.0"},
+					{Skipped: deduplicator.RededuplicateSkippedNotRegistered},
+				},
+			}
+			Expect(report.ChangedCount()).To(Equal(1))
+			Expect(report.SkippedCount()).To(Equal(1))
+		})
+	})
+
+	Context("RededuplicateUser", func() {
+		var testLogger log.Logger
+		var testDataStoreSession *testDataStore.Session
+		var testLockStoreSession *testLockStore.Session
+		var testUserID string
+
+		BeforeEach(func() {
+			testLogger = log.NewNull()
+			testDataStoreSession = testDataStore.NewSession()
+			testLockStoreSession = testLockStore.NewSession()
+			testUserID = app.NewID()
+		})
+
+		AfterEach(func() {
+			Expect(testDataStoreSession.UnusedOutputsCount()).To(Equal(0))
+			Expect(testLockStoreSession.UnusedOutputsCount()).To(Equal(0))
+		})
+
+		Context("with a test factory", func() {
+			var testFactory *testDataDeduplicator.Factory
+
+			BeforeEach(func() {
+				testFactory = testDataDeduplicator.NewFactory()
+			})
+
+			AfterEach(func() {
+				Expect(testFactory.UnusedOutputsCount()).To(Equal(0))
+			})
+
+			It("returns an error if the logger is missing", func() {
+				report, err := deduplicator.RededuplicateUser(nil, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).To(MatchError("deduplicator: logger is missing"))
+				Expect(report).To(BeNil())
+			})
+
+			It("returns an error if the data store session is missing", func() {
+				report, err := deduplicator.RededuplicateUser(testLogger, nil, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).To(MatchError("deduplicator: data store session is missing"))
+				Expect(report).To(BeNil())
+			})
+
+			It("returns an error if the lock store session is missing", func() {
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, nil, testFactory, testUserID, false)
+				Expect(err).To(MatchError("deduplicator: lock store session is missing"))
+				Expect(report).To(BeNil())
+			})
+
+			It("returns an error if the factory is missing", func() {
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, nil, testUserID, false)
+				Expect(err).To(MatchError("deduplicator: factory is missing"))
+				Expect(report).To(BeNil())
+			})
+
+			It("returns an error if the user id is missing", func() {
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, "", false)
+				Expect(err).To(MatchError("deduplicator: user id is missing"))
+				Expect(report).To(BeNil())
+			})
+
+			It("returns an error if there is an error with AcquireLockForUserByID", func() {
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: false, Error: errors.New("test error")}}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to acquire lock for user with id "%s"; test error`, testUserID)))
+				Expect(report).To(BeNil())
+			})
+
+			It("returns an error if the user is locked", func() {
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: false, Error: nil}}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: user with id "%s" is locked`, testUserID)))
+				Expect(report).To(BeNil())
+				Expect(testLockStoreSession.AcquireLockForUserByIDInputs).To(Equal([]testLockStore.AcquireLockForUserByIDInput{{UserID: testUserID, Operation: deduplicator.RededuplicateLockOperation}}))
+			})
+
+			It("returns an error if there is an error with GetDatasetsForUserByID", func() {
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testDataStoreSession.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: nil, Error: errors.New("test error")}}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to get datasets for user with id "%s"; test error`, testUserID)))
+				Expect(report).To(BeNil())
+			})
+
+			It("gets all pages of datasets using a cursor", func() {
+				testDatasets := []*upload.Upload{}
+				for index := 0; index < store.PaginationSizeMaximum; index++ {
+					testDataset := newRededuplicateTestDataset(testUserID, fmt.Sprintf("2017-09-01T12:%02d:%02dZ", 59-index/60, 59-index%60))
+					testDataset.State = upload.StateOpen
+					testDatasets = append(testDatasets, testDataset)
+				}
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testDataStoreSession.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: testDatasets, Error: nil}, {Datasets: []*upload.Upload{}, Error: nil}}
+				for range testDatasets {
+					testDataStoreSession.GetDatasetLatestTimeOutputs = append(testDataStoreSession.GetDatasetLatestTimeOutputs, testDataStore.GetDatasetLatestTimeOutput{LatestTime: nil, Error: nil})
+				}
+				testLockStoreSession.ReleaseLockForUserByIDOutputs = []error{nil}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Datasets).To(HaveLen(store.PaginationSizeMaximum))
+				Expect(report.Datasets[0].DatasetID).To(Equal(testDatasets[store.PaginationSizeMaximum-1].UploadID))
+				Expect(report.SkippedCount()).To(Equal(store.PaginationSizeMaximum))
+				Expect(testDataStoreSession.GetDatasetsForUserByIDInputs).To(HaveLen(2))
+				Expect(testDataStoreSession.GetDatasetsForUserByIDInputs[1].Pagination.Cursor).To(Equal(store.NewCursor(testDatasets[store.PaginationSizeMaximum-1].CreatedTime, testDatasets[store.PaginationSizeMaximum-1].ID)))
+			})
+
+			It("returns an error if there is an error with GetDatasetLatestTime", func() {
+				testDataset := newRededuplicateTestDataset(testUserID, "2017-09-01T12:00:00Z")
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testDataStoreSession.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{testDataset}, Error: nil}}
+				testDataStoreSession.GetDatasetLatestTimeOutputs = []testDataStore.GetDatasetLatestTimeOutput{{LatestTime: nil, Error: errors.New("test error")}}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to get latest time for dataset with id "%s"; test error`, testDataset.UploadID)))
+				Expect(report).To(BeNil())
+			})
+
+			It("orders datasets by the latest time of their data rather than their created time", func() {
+				testDatasetCreatedLater := newRededuplicateTestDataset(testUserID, "2017-09-01T12:00:01Z")
+				testDatasetCreatedLater.State = upload.StateOpen
+				testDatasetCreatedEarlier := newRededuplicateTestDataset(testUserID, "2017-09-01T12:00:00Z")
+				testDatasetCreatedEarlier.State = upload.StateOpen
+				testDatasetWithoutData := newRededuplicateTestDataset(testUserID, "2017-09-01T11:00:00Z")
+				testDatasetWithoutData.State = upload.StateOpen
+				earlierLatestTime, err := time.Parse(time.RFC3339, "2017-09-01T12:00:00-04:00")
+				Expect(err).ToNot(HaveOccurred())
+				laterLatestTime, err := time.Parse(time.RFC3339, "2017-09-01T11:30:00-08:00")
+				Expect(err).ToNot(HaveOccurred())
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testDataStoreSession.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{testDatasetCreatedLater, testDatasetCreatedEarlier, testDatasetWithoutData}, Error: nil}}
+				testDataStoreSession.GetDatasetLatestTimeOutputs = []testDataStore.GetDatasetLatestTimeOutput{{LatestTime: &earlierLatestTime, Error: nil}, {LatestTime: &laterLatestTime, Error: nil}, {LatestTime: nil, Error: nil}}
+				testLockStoreSession.ReleaseLockForUserByIDOutputs = []error{nil}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Datasets).To(HaveLen(3))
+				Expect(report.Datasets[0].DatasetID).To(Equal(testDatasetWithoutData.UploadID))
+				Expect(report.Datasets[1].DatasetID).To(Equal(testDatasetCreatedLater.UploadID))
+				Expect(report.Datasets[2].DatasetID).To(Equal(testDatasetCreatedEarlier.UploadID))
+			})
+
+			It("skips a dataset that is registered but can no longer be deduplicated", func() {
+				testDataset := newRededuplicateTestDataset(testUserID, "2017-09-01T12:00:00Z")
+				testDataStoreSession.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{testDataset}, Error: nil}}
+				testDataStoreSession.GetDatasetLatestTimeOutputs = []testDataStore.GetDatasetLatestTimeOutput{{LatestTime: nil, Error: nil}}
+				testFactory.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{{Is: true, Error: nil}}
+				testFactory.CanDeduplicateDatasetOutputs = []testDataDeduplicator.CanDeduplicateDatasetOutput{{Can: false, Error: nil}}
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testLockStoreSession.ReleaseLockForUserByIDOutputs = []error{nil}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Datasets).To(Equal([]*deduplicator.RededuplicateDatasetReport{
+					{DatasetID: testDataset.UploadID, FromName: "org.tidepool.time-window", FromVersion: "0.9.0", Skipped: deduplicator.RededuplicateSkippedNotSupported},
+				}))
+			})
+
+			It("returns an error if there is an error with IsRegisteredWithDataset", func() {
+				testDataset := newRededuplicateTestDataset(testUserID, "2017-09-01T12:00:00Z")
+				testDataStoreSession.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{testDataset}, Error: nil}}
+				testDataStoreSession.GetDatasetLatestTimeOutputs = []testDataStore.GetDatasetLatestTimeOutput{{LatestTime: nil, Error: nil}}
+				testFactory.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{{Is: false, Error: errors.New("test error")}}
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to determine if registered with dataset with id "%s"; test error`, testDataset.UploadID)))
+				Expect(report).To(BeNil())
+			})
+		})
+
+		Context("with a delegate factory", func() {
+			var testFactory deduplicator.Factory
+			var testDatasetOlder *upload.Upload
+			var testDatasetNewer *upload.Upload
+
+			BeforeEach(func() {
+				timeWindowFactory, err := deduplicator.NewTimeWindowFactory(deduplicator.Rules{{DeviceManufacturers: []string{"Dexcom"}}})
+				Expect(err).ToNot(HaveOccurred())
+				testFactory, err = deduplicator.NewDelegateFactory([]deduplicator.Factory{timeWindowFactory})
+				Expect(err).ToNot(HaveOccurred())
+				testDatasetOlder = newRededuplicateTestDataset(testUserID, "2017-09-01T12:00:00Z")
+				testDatasetNewer = newRededuplicateTestDataset(testUserID, "2017-09-02T12:00:00Z")
+				testDataStoreSession.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{testDatasetNewer, testDatasetOlder}, Error: nil}}
+				testDataStoreSession.GetDatasetLatestTimeOutputs = []testDataStore.GetDatasetLatestTimeOutput{{LatestTime: nil, Error: nil}, {LatestTime: nil, Error: nil}}
+			})
+
+			It("skips datasets that are not closed or not registered", func() {
+				testDatasetOlder.State = upload.StateOpen
+				testDatasetNewer.Deduplicator = nil
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testLockStoreSession.ReleaseLockForUserByIDOutputs = []error{nil}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report).To(Equal(&deduplicator.RededuplicateReport{
+					UserID: testUserID,
+					DryRun: false,
+					Datasets: []*deduplicator.RededuplicateDatasetReport{
+						{DatasetID: testDatasetOlder.UploadID, FromName: "org.tidepool.time-window", FromVersion: "0.9.0", Skipped: deduplicator.RededuplicateSkippedNotClosed},
+						{DatasetID: testDatasetNewer.UploadID, Skipped: deduplicator.RededuplicateSkippedNotRegistered},
+					},
+				}))
+			})
+
+			It("previews all datasets in upload order without modification with dry run", func() {
+				testDataStoreSession.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 0, SampleIDs: []string{}}, Error: nil}, {Preview: &store.DataPreview{Count: 1, SampleIDs: []string{"a"}}, Error: nil}}
+				testDataStoreSession.PreviewActivateDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 2, SampleIDs: []string{"b", "c"}}, Error: nil}, {Preview: &store.DataPreview{Count: 3, SampleIDs: []string{"d", "e", "f"}}, Error: nil}}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.DryRun).To(BeTrue())
+				Expect(report.ChangedCount()).To(Equal(2))
+				Expect(report.Datasets).To(HaveLen(2))
+				Expect(report.Datasets[0].DatasetID).To(Equal(testDatasetOlder.UploadID))
+				Expect(report.Datasets[0].ToName).To(Equal("org.tidepool.time-window"))
+				Expect(report.Datasets[0].ToVersion).To(Equal("1.0.0"))
+				Expect(report.Datasets[1].Preview.Actions).To(Equal([]*data.DeduplicatorPreviewAction{
+					{Action: "archive-device-data", Count: 1, SampleIDs: []string{"a"}},
+					{Action: "activate-dataset-data", Count: 3, SampleIDs: []string{"d", "e", "f"}},
+				}))
+				Expect(testDataStoreSession.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetInputs).To(Equal([]*upload.Upload{testDatasetOlder, testDatasetNewer}))
+				Expect(testDatasetOlder.Deduplicator.Version).To(Equal("0.9.0"))
+				Expect(testDatasetNewer.Deduplicator.Version).To(Equal("0.9.0"))
+				Expect(testLockStoreSession.AcquireLockForUserByIDInvocations).To(Equal(0))
+			})
+
+			It("returns an error and does not release the lock if there is an error with UndeduplicateDataset", func() {
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testDataStoreSession.UpdateDatasetRededuplicateJournalOutputs = []error{nil}
+				testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetOutputs = []error{errors.New("test error")}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to undeduplicate dataset with id "%s"; deduplicator: unable to unarchive device data archived by dataset with id "%s"; test error`, testDatasetNewer.UploadID, testDatasetNewer.UploadID)))
+				Expect(report).To(BeNil())
+				Expect(testDatasetNewer.RededuplicateJournal).To(Equal(&upload.Journal{Operation: upload.JournalOperationRededuplicate, Steps: []string{"reopen-dataset", "register-dataset", "deduplicate-dataset"}, CompletedSteps: 0, CreatedTime: testDatasetNewer.RededuplicateJournal.CreatedTime}))
+				Expect(testDatasetOlder.RededuplicateJournal).To(BeNil())
+			})
+
+			It("returns an error if there is an error with RegisterDataset", func() {
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetOutputs = []error{nil, nil}
+				testDataStoreSession.DeactivateDatasetDataOutputs = []error{nil, nil}
+				testDataStoreSession.UpdateDatasetRededuplicateJournalOutputs = []error{nil, nil, nil, nil}
+				testDataStoreSession.UpdateDatasetOutputs = []error{errors.New("test error")}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to register dataset with id "%s"; deduplicator: unable to update dataset with id "%s"; test error`, testDatasetOlder.UploadID, testDatasetOlder.UploadID)))
+				Expect(report).To(BeNil())
+				Expect(testDatasetOlder.RededuplicateJournal.CompletedSteps).To(Equal(1))
+				Expect(testDatasetNewer.RededuplicateJournal.CompletedSteps).To(Equal(1))
+			})
+
+			It("resumes datasets from the journal", func() {
+				testDatasetOlder.Deduplicator = &data.DeduplicatorDescriptor{}
+				testDatasetOlder.RededuplicateJournal = upload.NewJournal(upload.JournalOperationRededuplicate, []string{"reopen-dataset", "register-dataset", "deduplicate-dataset"}, "2017-09-03T12:00:00Z")
+				testDatasetOlder.RededuplicateJournal.CompletedSteps = 1
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetOutputs = []error{nil}
+				testDataStoreSession.DeactivateDatasetDataOutputs = []error{nil}
+				testDataStoreSession.UpdateDatasetOutputs = []error{nil, nil}
+				testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, nil, nil, nil, nil, nil}
+				testDataStoreSession.UpdateDatasetRededuplicateJournalOutputs = []error{nil, nil, nil, nil, nil, nil}
+				testDataStoreSession.ArchiveDeviceDataUsingTimeWindowFromDatasetOutputs = []error{nil, nil}
+				testDataStoreSession.ActivateDatasetDataOutputs = []error{nil, nil}
+				testLockStoreSession.ReleaseLockForUserByIDOutputs = []error{nil}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Datasets[0].Skipped).To(BeEmpty())
+				Expect(testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetInputs).To(Equal([]*upload.Upload{testDatasetNewer}))
+				Expect(testDataStoreSession.ArchiveDeviceDataUsingTimeWindowFromDatasetInputs).To(Equal([]*upload.Upload{testDatasetOlder, testDatasetNewer}))
+				Expect(testDatasetOlder.Deduplicator).To(Equal(&data.DeduplicatorDescriptor{Name: "org.tidepool.time-window", Version: "1.0.0"}))
+				Expect(testDatasetOlder.RededuplicateJournal).To(BeNil())
+				Expect(testDatasetNewer.RededuplicateJournal).To(BeNil())
+			})
+
+			It("undoes in reverse upload order and redoes in upload order", func() {
+				testDatasetNewer.Journal = upload.NewJournal(upload.JournalOperationDeduplicate, []string{"archive-device-data", "activate-dataset-data"}, "2017-09-02T12:00:00Z")
+				testLockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+				testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetOutputs = []error{nil, nil}
+				testDataStoreSession.DeactivateDatasetDataOutputs = []error{nil, nil}
+				testDataStoreSession.UpdateDatasetOutputs = []error{nil, nil}
+				testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, nil, nil, nil, nil, nil}
+				testDataStoreSession.UpdateDatasetRededuplicateJournalOutputs = []error{nil, nil, nil, nil, nil, nil, nil, nil}
+				testDataStoreSession.ArchiveDeviceDataUsingTimeWindowFromDatasetOutputs = []error{nil, nil}
+				testDataStoreSession.ActivateDatasetDataOutputs = []error{nil, nil}
+				testLockStoreSession.ReleaseLockForUserByIDOutputs = []error{nil}
+				report, err := deduplicator.RededuplicateUser(testLogger, testDataStoreSession, testLockStoreSession, testFactory, testUserID, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(testLockStoreSession.ReleaseLockForUserByIDInputs).To(Equal([]testLockStore.ReleaseLockForUserByIDInput{{UserID: testUserID, Operation: deduplicator.RededuplicateLockOperation}}))
+				Expect(testDatasetOlder.RededuplicateJournal).To(BeNil())
+				Expect(testDatasetNewer.RededuplicateJournal).To(BeNil())
+				Expect(report.ChangedCount()).To(Equal(2))
+				Expect(report.Datasets[0].Preview).To(BeNil())
+				Expect(testDataStoreSession.UnarchiveDeviceDataArchivedByDatasetInputs).To(Equal([]*upload.Upload{testDatasetNewer, testDatasetOlder}))
+				Expect(testDataStoreSession.DeactivateDatasetDataInputs).To(Equal([]*upload.Upload{testDatasetNewer, testDatasetOlder}))
+				Expect(testDataStoreSession.ArchiveDeviceDataUsingTimeWindowFromDatasetInputs).To(Equal([]*upload.Upload{testDatasetOlder, testDatasetNewer}))
+				Expect(testDataStoreSession.ActivateDatasetDataInputs).To(Equal([]*upload.Upload{testDatasetOlder, testDatasetNewer}))
+				Expect(testDatasetOlder.Deduplicator).To(Equal(&data.DeduplicatorDescriptor{Name: "org.tidepool.time-window", Version: "1.0.0"}))
+				Expect(testDatasetNewer.Deduplicator).To(Equal(&data.DeduplicatorDescriptor{Name: "org.tidepool.time-window", Version: "1.0.0"}))
+				Expect(testDatasetNewer.Journal).To(BeNil())
+			})
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package deduplicator
+
+import (
+	"path"
+
+	"github.com/blang/semver"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/errors"
+)
+
+type Rule struct {
+	DeviceManufacturers []string `json:"deviceManufacturers"`
+	DeviceModels        []string `json:"deviceModels"`
+	DeviceTags          []string `json:"deviceTags"`
+	Version             string   `json:"version"`
+}
+
+func (r *Rule) Validate() error {
+	if len(r.DeviceManufacturers) == 0 {
+		return errors.New("deduplicator", "rule device manufacturers is missing")
+	}
+	for _, deviceManufacturer := range r.DeviceManufacturers {
+		if deviceManufacturer == "" {
+			return errors.New("deduplicator", "rule device manufacturer is empty")
+		}
+	}
+	for _, deviceModel := range r.DeviceModels {
+		if deviceModel == "" {
+			return errors.New("deduplicator", "rule device model is empty")
+		} else if _, err := path.Match(deviceModel, ""); err != nil {
+			return errors.Wrap(err, "deduplicator", "rule device model is invalid")
+		}
+	}
+	for _, deviceTag := range r.DeviceTags {
+		if deviceTag == "" {
+			return errors.New("deduplicator", "rule device tag is empty")
+		}
+	}
+	if r.Version != "" {
+		if _, err := semver.ParseRange(r.Version); err != nil {
+			return errors.Wrap(err, "deduplicator", "rule version is invalid")
+		}
+	}
+	return nil
+}
+
+func (r *Rule) Matches(dataset *upload.Upload) bool {
+	if dataset.DeviceManufacturers == nil || !app.StringsContainsAnyStrings(*dataset.DeviceManufacturers, r.DeviceManufacturers) {
+		return false
+	}
+	if len(r.DeviceModels) > 0 {
+		if dataset.DeviceModel == nil || !matchesAnyPattern(r.DeviceModels, *dataset.DeviceModel) {
+			return false
+		}
+	}
+	if len(r.DeviceTags) > 0 {
+		if dataset.DeviceTags == nil || !app.StringsContainsAnyStrings(*dataset.DeviceTags, r.DeviceTags) {
+			return false
+		}
+	}
+	if r.Version != "" {
+		if dataset.Version == nil {
+			return false
+		}
+		versionRange, err := semver.ParseRange(r.Version)
+		if err != nil {
+			return false
+		}
+		version, err := semver.ParseTolerant(*dataset.Version)
+		if err != nil || !versionRange(version) {
+			return false
+		}
+	}
+	return true
+}
+
+type Rules []*Rule
+
+func (r Rules) Validate() error {
+	if len(r) == 0 {
+		return errors.New("deduplicator", "rules is missing")
+	}
+	for _, rule := range r {
+		if rule == nil {
+			return errors.New("deduplicator", "rule is missing")
+		} else if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r Rules) Matches(dataset *upload.Upload) bool {
+	for _, rule := range r {
+		if rule.Matches(dataset) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package deduplicator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/deduplicator"
+	"github.com/tidepool-org/platform/data/types/upload"
+)
+
+var _ = Describe("Rule", func() {
+	Context("Rule", func() {
+		var testRule *deduplicator.Rule
+
+		BeforeEach(func() {
+			testRule = &deduplicator.Rule{
+				DeviceManufacturers: []string{"Medtronic"},
+				DeviceModels:        []string{"5*3", "751"},
+				DeviceTags:          []string{"insulin-pump"},
+				Version:             ">=1.2.0 <2.0.0",
+			}
+		})
+
+		Context("Validate", func() {
+			It("returns an error if the device manufacturers is missing", func() {
+				testRule.DeviceManufacturers = nil
+				Expect(testRule.Validate()).To(MatchError("deduplicator: rule device manufacturers is missing"))
+			})
+
+			It("returns an error if a device manufacturer is empty", func() {
+				testRule.DeviceManufacturers = []string{"Medtronic", ""}
+				Expect(testRule.Validate()).To(MatchError("deduplicator: rule device manufacturer is empty"))
+			})
+
+			It("returns an error if a device model is empty", func() {
+				testRule.DeviceModels = []string{""}
+				Expect(testRule.Validate()).To(MatchError("deduplicator: rule device model is empty"))
+			})
+
+			It("returns an error if a device model is an invalid pattern", func() {
+				testRule.DeviceModels = []string{"[5"}
+				Expect(testRule.Validate()).To(MatchError("deduplicator: rule device model is invalid; syntax error in pattern"))
+			})
+
+			It("returns an error if a device tag is empty", func() {
+				testRule.DeviceTags = []string{""}
+				Expect(testRule.Validate()).To(MatchError("deduplicator: rule device tag is empty"))
+			})
+
+			It("returns an error if the version is an invalid range", func() {
+				testRule.Version = "invalid"
+				Expect(testRule.Validate()).To(HaveOccurred())
+			})
+
+			It("returns successfully with only device manufacturers", func() {
+				Expect((&deduplicator.Rule{DeviceManufacturers: []string{"Animas"}}).Validate()).To(Succeed())
+			})
+
+			It("returns successfully", func() {
+				Expect(testRule.Validate()).To(Succeed())
+			})
+		})
+
+		Context("Matches", func() {
+			var testDataset *upload.Upload
+
+			BeforeEach(func() {
+				testDataset = upload.Init()
+				testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Medtronic"})
+				testDataset.DeviceModel = app.StringAsPointer("523")
+				testDataset.DeviceTags = app.StringArrayAsPointer([]string{"insulin-pump", "cgm"})
+				testDataset.Version = app.StringAsPointer("1.3.0")
+			})
+
+			It("returns false if the dataset device manufacturers is missing", func() {
+				testDataset.DeviceManufacturers = nil
+				Expect(testRule.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the dataset device manufacturers does not match", func() {
+				testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Animas"})
+				Expect(testRule.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the dataset device model is missing", func() {
+				testDataset.DeviceModel = nil
+				Expect(testRule.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the dataset device model does not match", func() {
+				testDataset.DeviceModel = app.StringAsPointer("554")
+				Expect(testRule.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the dataset device tags is missing", func() {
+				testDataset.DeviceTags = nil
+				Expect(testRule.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the dataset device tags does not match", func() {
+				testDataset.DeviceTags = app.StringArrayAsPointer([]string{"bgm"})
+				Expect(testRule.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the dataset version is missing", func() {
+				testDataset.Version = nil
+				Expect(testRule.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the dataset version is invalid", func() {
+				testDataset.Version = app.StringAsPointer("invalid")
+				Expect(testRule.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns false if the dataset version is out of range", func() {
+				testDataset.Version = app.StringAsPointer("2.0.0")
+				Expect(testRule.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns true if the dataset matches", func() {
+				Expect(testRule.Matches(testDataset)).To(BeTrue())
+			})
+
+			It("returns true if the dataset matches with a tolerant version", func() {
+				testDataset.Version = app.StringAsPointer("v1.4")
+				Expect(testRule.Matches(testDataset)).To(BeTrue())
+			})
+
+			It("returns true if the dataset matches a glob device model", func() {
+				testDataset.DeviceModel = app.StringAsPointer("5x3")
+				Expect(testRule.Matches(testDataset)).To(BeTrue())
+			})
+
+			It("returns true if the rule only has device manufacturers", func() {
+				testDataset.DeviceModel = nil
+				testDataset.DeviceTags = nil
+				testDataset.Version = nil
+				Expect((&deduplicator.Rule{DeviceManufacturers: []string{"Medtronic"}}).Matches(testDataset)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("Rules", func() {
+		Context("Validate", func() {
+			It("returns an error if the rules is missing", func() {
+				Expect(deduplicator.Rules{}.Validate()).To(MatchError("deduplicator: rules is missing"))
+			})
+
+			It("returns an error if a rule is missing", func() {
+				Expect(deduplicator.Rules{nil}.Validate()).To(MatchError("deduplicator: rule is missing"))
+			})
+
+			It("returns an error if a rule is invalid", func() {
+				Expect(deduplicator.Rules{{DeviceManufacturers: []string{"Animas"}}, {}}.Validate()).To(MatchError("deduplicator: rule device manufacturers is missing"))
+			})
+
+			It("returns successfully", func() {
+				Expect(deduplicator.Rules{{DeviceManufacturers: []string{"Animas"}}}.Validate()).To(Succeed())
+			})
+		})
+
+		Context("Matches", func() {
+			var testRules deduplicator.Rules
+			var testDataset *upload.Upload
+
+			BeforeEach(func() {
+				testRules = deduplicator.Rules{
+					{DeviceManufacturers: []string{"LifeScan"}, DeviceModels: []string{"OneTouch *"}},
+					{DeviceManufacturers: []string{"Abbott"}, DeviceModels: []string{"FreeStyle Libre"}},
+				}
+				testDataset = upload.Init()
+			})
+
+			It("returns false if no rule matches", func() {
+				testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Abbott"})
+				testDataset.DeviceModel = app.StringAsPointer("OneTouch Ultra 2")
+				Expect(testRules.Matches(testDataset)).To(BeFalse())
+			})
+
+			It("returns true if the first rule matches", func() {
+				testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"LifeScan"})
+				testDataset.DeviceModel = app.StringAsPointer("OneTouch Ultra 2")
+				Expect(testRules.Matches(testDataset)).To(BeTrue())
+			})
+
+			It("returns true if the last rule matches", func() {
+				testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Abbott"})
+				testDataset.DeviceModel = app.StringAsPointer("FreeStyle Libre")
+				Expect(testRules.Matches(testDataset)).To(BeTrue())
+			})
+		})
+	})
+})
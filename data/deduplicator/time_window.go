@@ -0,0 +1,130 @@
+package deduplicator
+
+import (
+	"strconv"
+
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/errors"
+)
+
+type timeWindowFactory struct {
+	*BaseFactory
+}
+
+type timeWindowDeduplicator struct {
+	*BaseDeduplicator
+}
+
+const _TimeWindowDeduplicatorName = "org.tidepool.time-window"
+const _TimeWindowDeduplicatorVersion = "1.0.0"
+
+func NewTimeWindowFactory(rules Rules) (Factory, error) {
+	factory := &timeWindowFactory{}
+
+	baseFactory, err := newRulesBaseFactory(_TimeWindowDeduplicatorName, _TimeWindowDeduplicatorVersion, rules, factory.newDeduplicator)
+	if err != nil {
+		return nil, err
+	}
+
+	factory.BaseFactory = baseFactory
+	factory.Factory = factory
+
+	return factory, nil
+}
+
+func (t *timeWindowFactory) CanDeduplicateDataset(dataset *upload.Upload) (bool, error) {
+	if can, err := t.BaseFactory.CanDeduplicateDataset(dataset); err != nil || !can {
+		return can, err
+	}
+
+	if dataset.DeviceID == nil {
+		return false, nil
+	}
+	if *dataset.DeviceID == "" {
+		return false, nil
+	}
+	if dataset.DeviceManufacturers == nil {
+		return false, nil
+	}
+
+	return t.rules.Matches(dataset), nil
+}
+
+func (t *timeWindowFactory) newDeduplicator(baseDeduplicator *BaseDeduplicator) (data.Deduplicator, error) {
+	dataset := baseDeduplicator.dataset
+
+	if dataset.DeviceID == nil {
+		return nil, errors.New("deduplicator", "dataset device id is missing")
+	}
+	if *dataset.DeviceID == "" {
+		return nil, errors.New("deduplicator", "dataset device id is empty")
+	}
+	if dataset.DeviceManufacturers == nil {
+		return nil, errors.New("deduplicator", "dataset device manufacturers is missing")
+	}
+
+	return &timeWindowDeduplicator{
+		BaseDeduplicator: baseDeduplicator,
+	}, nil
+}
+
+func (t *timeWindowDeduplicator) DeduplicateDataset() error {
+	return t.runJournal(upload.JournalOperationDeduplicate, []journalStep{
+		{name: "archive-device-data", run: t.archiveDeviceData},
+		{name: "activate-dataset-data", run: t.BaseDeduplicator.DeduplicateDataset},
+	})
+}
+
+func (t *timeWindowDeduplicator) PreviewDeduplicateDataset() (*data.DeduplicatorPreview, error) {
+	t.logger.Debug("PreviewDeduplicateDataset")
+
+	dataPreview, err := t.dataStoreSession.PreviewArchiveDeviceDataUsingTimeWindowFromDataset(t.dataset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "deduplicator", "unable to preview archive device data using time window from dataset with id %s", strconv.Quote(t.dataset.UploadID))
+	}
+
+	preview := data.NewDeduplicatorPreview(t.name, t.version)
+	preview.AddAction("archive-device-data", dataPreview.Count, dataPreview.SampleIDs)
+
+	if err = t.previewActivateDatasetData(preview); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+func (t *timeWindowDeduplicator) archiveDeviceData() error {
+	if err := t.dataStoreSession.ArchiveDeviceDataUsingTimeWindowFromDataset(t.dataset); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to archive device data using time window from dataset with id %s", strconv.Quote(t.dataset.UploadID))
+	}
+
+	return nil
+}
+
+func (t *timeWindowDeduplicator) UndeduplicateDataset() error {
+	if err := t.unarchiveDeviceData(); err != nil {
+		return err
+	}
+
+	return t.BaseDeduplicator.UndeduplicateDataset()
+}
+
+func (t *timeWindowDeduplicator) DeleteDataset() error {
+	if err := t.retainDeviceDataArchivedByDataset(); err != nil {
+		return err
+	}
+	if err := t.unarchiveDeviceData(); err != nil {
+		return err
+	}
+
+	return t.BaseDeduplicator.DeleteDataset()
+}
+
+func (t *timeWindowDeduplicator) unarchiveDeviceData() error {
+	if err := t.dataStoreSession.UnarchiveDeviceDataArchivedByDataset(t.dataset); err != nil {
+		return errors.Wrapf(err, "deduplicator", "unable to unarchive device data archived by dataset with id %s", strconv.Quote(t.dataset.UploadID))
+	}
+
+	return nil
+}
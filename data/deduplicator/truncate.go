@@ -3,12 +3,9 @@ package deduplicator
 import (
 	"strconv"
 
-	"github.com/tidepool-org/platform/app"
 	"github.com/tidepool-org/platform/data"
-	"github.com/tidepool-org/platform/data/store"
 	"github.com/tidepool-org/platform/data/types/upload"
 	"github.com/tidepool-org/platform/errors"
-	"github.com/tidepool-org/platform/log"
 )
 
 type truncateFactory struct {
@@ -22,17 +19,15 @@ type truncateDeduplicator struct {
 const _TruncateDeduplicatorName = "org.tidepool.truncate"
 const _TruncateDeduplicatorVersion = "1.0.0"
 
-var _TruncateExpectedDeviceManufacturers = []string{"Animas"}
+func NewTruncateFactory(rules Rules) (Factory, error) {
+	factory := &truncateFactory{}
 
-func NewTruncateFactory() (Factory, error) {
-	baseFactory, err := NewBaseFactory(_TruncateDeduplicatorName, _TruncateDeduplicatorVersion)
+	baseFactory, err := newRulesBaseFactory(_TruncateDeduplicatorName, _TruncateDeduplicatorVersion, rules, factory.newDeduplicator)
 	if err != nil {
 		return nil, err
 	}
 
-	factory := &truncateFactory{
-		BaseFactory: baseFactory,
-	}
+	factory.BaseFactory = baseFactory
 	factory.Factory = factory
 
 	return factory, nil
@@ -52,18 +47,12 @@ func (t *truncateFactory) CanDeduplicateDataset(dataset *upload.Upload) (bool, e
 	if dataset.DeviceManufacturers == nil {
 		return false, nil
 	}
-	if !app.StringsContainsAnyStrings(*dataset.DeviceManufacturers, _TruncateExpectedDeviceManufacturers) {
-		return false, nil
-	}
 
-	return true, nil
+	return t.rules.Matches(dataset), nil
 }
 
-func (t *truncateFactory) NewDeduplicatorForDataset(logger log.Logger, dataStoreSession store.Session, dataset *upload.Upload) (data.Deduplicator, error) {
-	baseDeduplicator, err := NewBaseDeduplicator(t.name, t.version, logger, dataStoreSession, dataset)
-	if err != nil {
-		return nil, err
-	}
+func (t *truncateFactory) newDeduplicator(baseDeduplicator *BaseDeduplicator) (data.Deduplicator, error) {
+	dataset := baseDeduplicator.dataset
 
 	if dataset.DeviceID == nil {
 		return nil, errors.New("deduplicator", "dataset device id is missing")
@@ -74,9 +63,6 @@ func (t *truncateFactory) NewDeduplicatorForDataset(logger log.Logger, dataStore
 	if dataset.DeviceManufacturers == nil {
 		return nil, errors.New("deduplicator", "dataset device manufacturers is missing")
 	}
-	if !app.StringsContainsAnyStrings(*dataset.DeviceManufacturers, _TruncateExpectedDeviceManufacturers) {
-		return nil, errors.New("deduplicator", "dataset device manufacturers does not contain expected device manufacturers")
-	}
 
 	return &truncateDeduplicator{
 		BaseDeduplicator: baseDeduplicator,
@@ -84,13 +70,42 @@ func (t *truncateFactory) NewDeduplicatorForDataset(logger log.Logger, dataStore
 }
 
 func (t *truncateDeduplicator) DeduplicateDataset() error {
-	// TODO: Technically, ActivateDatasetData could succeed, but DeleteOtherDatasetData fail. This would
-	// result in duplicate (and possible incorrect) data. Is there a way to resolve this? Would be nice to have transactions.
+	return t.runJournal(upload.JournalOperationDeduplicate, []journalStep{
+		{name: "activate-dataset-data", run: t.BaseDeduplicator.DeduplicateDataset},
+		{name: "delete-other-dataset-data", run: t.deleteOtherDatasetData},
+	})
+}
 
-	if err := t.BaseDeduplicator.DeduplicateDataset(); err != nil {
-		return err
+func (t *truncateDeduplicator) PreviewDeduplicateDataset() (*data.DeduplicatorPreview, error) {
+	preview, err := t.BaseDeduplicator.PreviewDeduplicateDataset()
+	if err != nil {
+		return nil, err
 	}
 
+	dataPreview, err := t.dataStoreSession.PreviewDeleteOtherDatasets(t.dataset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "deduplicator", "unable to preview delete other datasets except dataset with id %s", strconv.Quote(t.dataset.UploadID))
+	}
+	preview.AddAction("delete-other-datasets", dataPreview.Count, dataPreview.SampleIDs)
+
+	dataPreview, err = t.dataStoreSession.PreviewDeleteOtherDatasetData(t.dataset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "deduplicator", "unable to preview remove all other data except dataset with id %s", strconv.Quote(t.dataset.UploadID))
+	}
+	preview.AddAction("delete-other-dataset-data", dataPreview.Count, dataPreview.SampleIDs)
+
+	return preview, nil
+}
+
+// Closing a reopened dataset would delete the data of any dataset created since the dataset was first
+// closed, so a dataset deduplicated by truncate cannot be reopened
+func (t *truncateDeduplicator) ReopenDataset() (bool, error) {
+	t.logger.Debug("ReopenDataset")
+
+	return false, nil
+}
+
+func (t *truncateDeduplicator) deleteOtherDatasetData() error {
 	if err := t.dataStoreSession.DeleteOtherDatasetData(t.dataset); err != nil {
 		return errors.Wrapf(err, "deduplicator", "unable to remove all other data except dataset with id %s", strconv.Quote(t.dataset.UploadID))
 	}
@@ -10,15 +10,34 @@ import (
 	"github.com/tidepool-org/platform/app"
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/deduplicator"
+	"github.com/tidepool-org/platform/data/store"
 	testDataStore "github.com/tidepool-org/platform/data/store/test"
 	"github.com/tidepool-org/platform/data/types/upload"
 	"github.com/tidepool-org/platform/log"
 )
 
 var _ = Describe("Truncate", func() {
+	var testRules deduplicator.Rules
+
+	BeforeEach(func() {
+		testRules = deduplicator.Rules{{DeviceManufacturers: []string{"Animas"}}}
+	})
+
 	Context("NewTruncateFactory", func() {
+		It("returns an error if the rules are missing", func() {
+			testFactory, err := deduplicator.NewTruncateFactory(nil)
+			Expect(err).To(MatchError("deduplicator: rules is missing"))
+			Expect(testFactory).To(BeNil())
+		})
+
+		It("returns an error if the rules are invalid", func() {
+			testFactory, err := deduplicator.NewTruncateFactory(deduplicator.Rules{{}})
+			Expect(err).To(MatchError("deduplicator: rule device manufacturers is missing"))
+			Expect(testFactory).To(BeNil())
+		})
+
 		It("returns a new factory", func() {
-			Expect(deduplicator.NewTruncateFactory()).ToNot(BeNil())
+			Expect(deduplicator.NewTruncateFactory(testRules)).ToNot(BeNil())
 		})
 	})
 
@@ -28,7 +47,7 @@ var _ = Describe("Truncate", func() {
 
 		BeforeEach(func() {
 			var err error
-			testFactory, err = deduplicator.NewTruncateFactory()
+			testFactory, err = deduplicator.NewTruncateFactory(testRules)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(testFactory).ToNot(BeNil())
 			testDataset = upload.Init()
@@ -175,14 +194,14 @@ var _ = Describe("Truncate", func() {
 				It("returns an error if the device manufacturers is empty", func() {
 					testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{})
 					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
-					Expect(err).To(MatchError("deduplicator: dataset device manufacturers does not contain expected device manufacturers"))
+					Expect(err).To(MatchError("deduplicator: dataset does not match rules"))
 					Expect(testDeduplicator).To(BeNil())
 				})
 
 				It("returns an error if the device manufacturers does not contain expected device manufacturer", func() {
 					testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Ant", "Zebra", "Cobra"})
 					testDeduplicator, err := testFactory.NewDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
-					Expect(err).To(MatchError("deduplicator: dataset device manufacturers does not contain expected device manufacturers"))
+					Expect(err).To(MatchError("deduplicator: dataset does not match rules"))
 					Expect(testDeduplicator).To(BeNil())
 				})
 
@@ -196,6 +215,42 @@ var _ = Describe("Truncate", func() {
 				})
 			})
 
+			Context("with a dataset registered with the deduplicator", func() {
+				BeforeEach(func() {
+					testDataset.Deduplicator = &data.DeduplicatorDescriptor{Name: "org.tidepool.truncate", Version: "1.0.0"}
+				})
+
+				It("is registered with the dataset", func() {
+					Expect(testFactory.IsRegisteredWithDataset(testDataset)).To(BeTrue())
+				})
+
+				It("is registered with the dataset even if the dataset does not match rules", func() {
+					testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Ant", "Zebra", "Cobra"})
+					Expect(testFactory.IsRegisteredWithDataset(testDataset)).To(BeTrue())
+				})
+
+				It("is not registered with the dataset if the registered version is later", func() {
+					testDataset.Deduplicator.Version = "2.0.0"
+					Expect(testFactory.IsRegisteredWithDataset(testDataset)).To(BeFalse())
+				})
+
+				It("returns a new registered deduplicator upon success", func() {
+					Expect(testFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)).ToNot(BeNil())
+				})
+
+				It("returns a new registered deduplicator even if the dataset does not match rules", func() {
+					testDataset.DeviceManufacturers = app.StringArrayAsPointer([]string{"Ant", "Zebra", "Cobra"})
+					Expect(testFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)).ToNot(BeNil())
+				})
+
+				It("returns an error if the dataset is registered with another deduplicator", func() {
+					testDataset.Deduplicator.Name = "org.tidepool.other"
+					testDeduplicator, err := testFactory.NewRegisteredDeduplicatorForDataset(testLogger, testDataStoreSession, testDataset)
+					Expect(err).To(MatchError("deduplicator: dataset deduplicator descriptor is not registered with expected deduplicator"))
+					Expect(testDeduplicator).To(BeNil())
+				})
+			})
+
 			Context("with a new deduplicator", func() {
 				var testDeduplicator data.Deduplicator
 
@@ -207,6 +262,30 @@ var _ = Describe("Truncate", func() {
 				})
 
 				Context("DeduplicateDataset", func() {
+					It("returns an error if there is an error with UpdateDatasetJournal when creating the journal", func() {
+						testDataStoreSession.UpdateDatasetJournalOutputs = []error{errors.New("test error")}
+						err := testDeduplicator.DeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to update dataset journal with id "%s"; test error`, testDataset.UploadID)))
+						Expect(testDataset.Journal).ToNot(BeNil())
+					})
+
+					It("returns an error if the dataset has an incomplete journal for another operation", func() {
+						testDataset.Journal = upload.NewJournal("other", []string{"step"}, "2017-09-01T12:00:00Z")
+						err := testDeduplicator.DeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: dataset with id "%s" has incomplete journal for operation "other"`, testDataset.UploadID)))
+					})
+
+					It("recovers from the journal by completing only the remaining steps", func() {
+						testDataset.Journal = upload.NewJournal(upload.JournalOperationDeduplicate, []string{"activate-dataset-data", "delete-other-dataset-data"}, "2017-09-01T12:00:00Z")
+						testDataset.Journal.CompletedSteps = 1
+						testDataStoreSession.DeleteOtherDatasetDataOutputs = []error{nil}
+						testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil}
+						Expect(testDeduplicator.DeduplicateDataset()).To(Succeed())
+						Expect(testDataStoreSession.ActivateDatasetDataInvocations).To(Equal(0))
+						Expect(testDataStoreSession.DeleteOtherDatasetDataInputs).To(ConsistOf(testDataset))
+						Expect(testDataset.Journal).To(BeNil())
+					})
+
 					Context("with activating dataset data", func() {
 						BeforeEach(func() {
 							testDataStoreSession.ActivateDatasetDataOutputs = []error{nil}
@@ -217,9 +296,18 @@ var _ = Describe("Truncate", func() {
 						})
 
 						It("returns an error if there is an error with ActivateDatasetData", func() {
+							testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil}
 							testDataStoreSession.ActivateDatasetDataOutputs = []error{errors.New("test error")}
 							err := testDeduplicator.DeduplicateDataset()
 							Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to activate dataset data with id "%s"; test error`, testDataset.UploadID)))
+							Expect(testDataset.Journal).ToNot(BeNil())
+							Expect(testDataset.Journal.CompletedSteps).To(Equal(0))
+						})
+
+						It("returns an error if there is an error with UpdateDatasetJournal after ActivateDatasetData", func() {
+							testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, errors.New("test error")}
+							err := testDeduplicator.DeduplicateDataset()
+							Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to update dataset journal with id "%s"; test error`, testDataset.UploadID)))
 						})
 
 						Context("with deleting other dataset data", func() {
@@ -232,17 +320,77 @@ var _ = Describe("Truncate", func() {
 							})
 
 							It("returns an error if there is an error with DeleteOtherDatasetData", func() {
+								testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, nil}
 								testDataStoreSession.DeleteOtherDatasetDataOutputs = []error{errors.New("test error")}
 								err := testDeduplicator.DeduplicateDataset()
 								Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to remove all other data except dataset with id "%s"; test error`, testDataset.UploadID)))
+								Expect(testDataset.Journal).ToNot(BeNil())
+								Expect(testDataset.Journal.CompletedSteps).To(Equal(1))
 							})
 
 							It("returns successfully if there is no error", func() {
+								testDataStoreSession.UpdateDatasetJournalOutputs = []error{nil, nil, nil}
 								Expect(testDeduplicator.DeduplicateDataset()).To(Succeed())
+								Expect(testDataStoreSession.UpdateDatasetJournalInputs).To(Equal([]*upload.Upload{testDataset, testDataset, testDataset}))
+								Expect(testDataset.Journal).To(BeNil())
 							})
 						})
 					})
 				})
+
+				Context("ReopenDataset", func() {
+					It("returns successfully and does not reopen the dataset", func() {
+						reopened, err := testDeduplicator.ReopenDataset()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(reopened).To(BeFalse())
+					})
+				})
+
+				Context("PreviewDeduplicateDataset", func() {
+					BeforeEach(func() {
+						testDataStoreSession.PreviewActivateDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 3, SampleIDs: []string{"a", "b", "c"}}, Error: nil}}
+					})
+
+					It("returns an error if there is an error with PreviewActivateDatasetData", func() {
+						testDataStoreSession.PreviewActivateDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: nil, Error: errors.New("test error")}}
+						preview, err := testDeduplicator.PreviewDeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to preview activate dataset data with id "%s"; test error`, testDataset.UploadID)))
+						Expect(preview).To(BeNil())
+					})
+
+					It("returns an error if there is an error with PreviewDeleteOtherDatasets", func() {
+						testDataStoreSession.PreviewDeleteOtherDatasetsOutputs = []testDataStore.DataPreviewOutput{{Preview: nil, Error: errors.New("test error")}}
+						preview, err := testDeduplicator.PreviewDeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to preview delete other datasets except dataset with id "%s"; test error`, testDataset.UploadID)))
+						Expect(preview).To(BeNil())
+					})
+
+					It("returns an error if there is an error with PreviewDeleteOtherDatasetData", func() {
+						testDataStoreSession.PreviewDeleteOtherDatasetsOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 1, SampleIDs: []string{"d"}}, Error: nil}}
+						testDataStoreSession.PreviewDeleteOtherDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: nil, Error: errors.New("test error")}}
+						preview, err := testDeduplicator.PreviewDeduplicateDataset()
+						Expect(err).To(MatchError(fmt.Sprintf(`deduplicator: unable to preview remove all other data except dataset with id "%s"; test error`, testDataset.UploadID)))
+						Expect(preview).To(BeNil())
+					})
+
+					It("returns successfully if there is no error", func() {
+						testDataStoreSession.PreviewDeleteOtherDatasetsOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 1, SampleIDs: []string{"d"}}, Error: nil}}
+						testDataStoreSession.PreviewDeleteOtherDatasetDataOutputs = []testDataStore.DataPreviewOutput{{Preview: &store.DataPreview{Count: 2, SampleIDs: []string{"e", "f"}}, Error: nil}}
+						preview, err := testDeduplicator.PreviewDeduplicateDataset()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(preview).To(Equal(&data.DeduplicatorPreview{
+							Name:    "org.tidepool.truncate",
+							Version: "1.0.0",
+							Actions: []*data.DeduplicatorPreviewAction{
+								{Action: "activate-dataset-data", Count: 3, SampleIDs: []string{"a", "b", "c"}},
+								{Action: "delete-other-datasets", Count: 1, SampleIDs: []string{"d"}},
+								{Action: "delete-other-dataset-data", Count: 2, SampleIDs: []string{"e", "f"}},
+							},
+						}))
+						Expect(testDataStoreSession.PreviewDeleteOtherDatasetsInputs).To(Equal([]*upload.Upload{testDataset}))
+						Expect(testDataStoreSession.PreviewDeleteOtherDatasetDataInputs).To(Equal([]*upload.Upload{testDataset}))
+					})
+				})
 			})
 		})
 	})
@@ -88,4 +88,21 @@ var _ = Describe("Delegate", func() {
 			})
 		})
 	})
+
+	Context("DeduplicatorPreview", func() {
+		Context("NewDeduplicatorPreview", func() {
+			It("returns a new deduplicator preview with no actions", func() {
+				Expect(data.NewDeduplicatorPreview("test", "1.2.3")).To(Equal(&data.DeduplicatorPreview{Name: "test", Version: "1.2.3", Actions: []*data.DeduplicatorPreviewAction{}}))
+			})
+		})
+
+		Context("AddAction", func() {
+			It("appends the action", func() {
+				testDeduplicatorPreview := data.NewDeduplicatorPreview("test", "1.2.3")
+				testDeduplicatorPreview.AddAction("one", 1, []string{"a"})
+				testDeduplicatorPreview.AddAction("two", 0, []string{})
+				Expect(testDeduplicatorPreview.Actions).To(Equal([]*data.DeduplicatorPreviewAction{{Action: "one", Count: 1, SampleIDs: []string{"a"}}, {Action: "two", Count: 0, SampleIDs: []string{}}}))
+			})
+		})
+	})
 })
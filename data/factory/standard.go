@@ -4,6 +4,7 @@ import (
 	"sort"
 
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types/activity/physical"
 	"github.com/tidepool-org/platform/data/types/basal"
 	"github.com/tidepool-org/platform/data/types/basal/scheduled"
 	"github.com/tidepool-org/platform/data/types/basal/suspend"
@@ -19,10 +20,18 @@ import (
 	"github.com/tidepool-org/platform/data/types/device"
 	"github.com/tidepool-org/platform/data/types/device/alarm"
 	"github.com/tidepool-org/platform/data/types/device/calibration"
+	"github.com/tidepool-org/platform/data/types/device/cannulachange"
+	"github.com/tidepool-org/platform/data/types/device/occlusion"
 	"github.com/tidepool-org/platform/data/types/device/prime"
 	"github.com/tidepool-org/platform/data/types/device/reservoirchange"
+	"github.com/tidepool-org/platform/data/types/device/sensorstart"
+	"github.com/tidepool-org/platform/data/types/device/sensorstop"
 	"github.com/tidepool-org/platform/data/types/device/status"
 	"github.com/tidepool-org/platform/data/types/device/timechange"
+	"github.com/tidepool-org/platform/data/types/device/transmitterchange"
+	"github.com/tidepool-org/platform/data/types/food"
+	"github.com/tidepool-org/platform/data/types/insulin"
+	"github.com/tidepool-org/platform/data/types/settings/cgm"
 	"github.com/tidepool-org/platform/data/types/settings/pump"
 	"github.com/tidepool-org/platform/data/types/upload"
 	"github.com/tidepool-org/platform/errors"
@@ -94,21 +103,30 @@ func NewStandard() (*Standard, error) {
 	}
 
 	var deviceNewFuncMap = NewFuncMap{
-		alarm.SubType():           NewNewFuncWithFunc(alarm.NewDatum),
-		calibration.SubType():     NewNewFuncWithFunc(calibration.NewDatum),
-		prime.SubType():           NewNewFuncWithFunc(prime.NewDatum),
-		reservoirchange.SubType(): NewNewFuncWithFunc(reservoirchange.NewDatum),
-		status.SubType():          NewNewFuncWithFunc(status.NewDatum),
-		timechange.SubType():      NewNewFuncWithFunc(timechange.NewDatum),
+		alarm.SubType():             NewNewFuncWithFunc(alarm.NewDatum),
+		calibration.SubType():       NewNewFuncWithFunc(calibration.NewDatum),
+		cannulachange.SubType():     NewNewFuncWithFunc(cannulachange.NewDatum),
+		occlusion.SubType():         NewNewFuncWithFunc(occlusion.NewDatum),
+		prime.SubType():             NewNewFuncWithFunc(prime.NewDatum),
+		reservoirchange.SubType():   NewNewFuncWithFunc(reservoirchange.NewDatum),
+		sensorstart.SubType():       NewNewFuncWithFunc(sensorstart.NewDatum),
+		sensorstop.SubType():        NewNewFuncWithFunc(sensorstop.NewDatum),
+		status.SubType():            NewNewFuncWithFunc(status.NewDatum),
+		timechange.SubType():        NewNewFuncWithFunc(timechange.NewDatum),
+		transmitterchange.SubType(): NewNewFuncWithFunc(transmitterchange.NewDatum),
 	}
 
 	var baseNewFuncMap = NewFuncMap{
 		basal.Type():         NewNewFuncWithKeyAndMap("deliveryType", basalNewFuncMap),
 		bolus.Type():         NewNewFuncWithKeyAndMap("subType", bolusNewFuncMap),
 		calculator.Type():    NewNewFuncWithFunc(calculator.NewDatum),
+		cgm.Type():           NewNewFuncWithFunc(cgm.NewDatum),
 		continuous.Type():    NewNewFuncWithFunc(continuous.NewDatum),
 		device.Type():        NewNewFuncWithKeyAndMap("subType", deviceNewFuncMap),
+		food.Type():          NewNewFuncWithFunc(food.NewDatum),
+		insulin.Type():       NewNewFuncWithFunc(insulin.NewDatum),
 		ketone.Type():        NewNewFuncWithFunc(ketone.NewDatum),
+		physical.Type():      NewNewFuncWithFunc(physical.NewDatum),
 		pump.Type():          NewNewFuncWithFunc(pump.NewDatum),
 		selfmonitored.Type(): NewNewFuncWithFunc(selfmonitored.NewDatum),
 		upload.Type():        NewNewFuncWithFunc(upload.NewDatum),
@@ -12,6 +12,7 @@ import (
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/factory"
 	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/activity/physical"
 	"github.com/tidepool-org/platform/data/types/basal/scheduled"
 	"github.com/tidepool-org/platform/data/types/basal/suspend"
 	"github.com/tidepool-org/platform/data/types/basal/temporary"
@@ -24,10 +25,18 @@ import (
 	"github.com/tidepool-org/platform/data/types/calculator"
 	"github.com/tidepool-org/platform/data/types/device/alarm"
 	"github.com/tidepool-org/platform/data/types/device/calibration"
+	"github.com/tidepool-org/platform/data/types/device/cannulachange"
+	"github.com/tidepool-org/platform/data/types/device/occlusion"
 	"github.com/tidepool-org/platform/data/types/device/prime"
 	"github.com/tidepool-org/platform/data/types/device/reservoirchange"
+	"github.com/tidepool-org/platform/data/types/device/sensorstart"
+	"github.com/tidepool-org/platform/data/types/device/sensorstop"
 	"github.com/tidepool-org/platform/data/types/device/status"
 	"github.com/tidepool-org/platform/data/types/device/timechange"
+	"github.com/tidepool-org/platform/data/types/device/transmitterchange"
+	"github.com/tidepool-org/platform/data/types/food"
+	"github.com/tidepool-org/platform/data/types/insulin"
+	"github.com/tidepool-org/platform/data/types/settings/cgm"
 	"github.com/tidepool-org/platform/data/types/settings/pump"
 	"github.com/tidepool-org/platform/data/types/upload"
 )
@@ -235,11 +244,20 @@ var _ = Describe("Standard", func() {
 				Entry("is cbg", map[string]string{"type": "cbg"}, continuous.New()),
 				Entry("is deviceEvent alarm", map[string]string{"type": "deviceEvent", "subType": "alarm"}, alarm.New()),
 				Entry("is deviceEvent calibration", map[string]string{"type": "deviceEvent", "subType": "calibration"}, calibration.New()),
+				Entry("is deviceEvent cannulaChange", map[string]string{"type": "deviceEvent", "subType": "cannulaChange"}, cannulachange.New()),
+				Entry("is deviceEvent occlusion", map[string]string{"type": "deviceEvent", "subType": "occlusion"}, occlusion.New()),
 				Entry("is deviceEvent prime", map[string]string{"type": "deviceEvent", "subType": "prime"}, prime.New()),
 				Entry("is deviceEvent reservoirChange", map[string]string{"type": "deviceEvent", "subType": "reservoirChange"}, reservoirchange.New()),
+				Entry("is deviceEvent sensorStart", map[string]string{"type": "deviceEvent", "subType": "sensorStart"}, sensorstart.New()),
+				Entry("is deviceEvent sensorStop", map[string]string{"type": "deviceEvent", "subType": "sensorStop"}, sensorstop.New()),
 				Entry("is deviceEvent status", map[string]string{"type": "deviceEvent", "subType": "status"}, status.New()),
 				Entry("is deviceEvent timeChange", map[string]string{"type": "deviceEvent", "subType": "timeChange"}, timechange.New()),
+				Entry("is deviceEvent transmitterChange", map[string]string{"type": "deviceEvent", "subType": "transmitterChange"}, transmitterchange.New()),
+				Entry("is food", map[string]string{"type": "food"}, food.New()),
+				Entry("is insulin", map[string]string{"type": "insulin"}, insulin.New()),
 				Entry("is bloodKetone", map[string]string{"type": "bloodKetone"}, ketone.New()),
+				Entry("is physicalActivity", map[string]string{"type": "physicalActivity"}, physical.New()),
+				Entry("is cgmSettings", map[string]string{"type": "cgmSettings"}, cgm.New()),
 				Entry("is pumpSettings", map[string]string{"type": "pumpSettings"}, pump.New()),
 				Entry("is smbg", map[string]string{"type": "smbg"}, selfmonitored.New()),
 				Entry("is upload", map[string]string{"type": "upload"}, upload.New()),
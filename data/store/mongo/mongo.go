@@ -1,6 +1,8 @@
 package mongo
 
 import (
+	"sort"
+	"strconv"
 	"time"
 
 	mgo "gopkg.in/mgo.v2"
@@ -14,6 +16,47 @@ import (
 	"github.com/tidepool-org/platform/store/mongo"
 )
 
+const _FuzzyHashDeviceTimeFormat = "2006-01-02T15:04:05"
+
+const _TimeSecondFormat = "2006-01-02T15:04:05"
+
+const _TimeZoneOffsetMaximum = 24 * time.Hour
+
+var _DataSelector = bson.M{
+	"_id":               0,
+	"_userId":           0,
+	"_groupId":          0,
+	"_active":           0,
+	"_deduplicator":     0,
+	"_schemaVersion":    0,
+	"_version":          0,
+	"archivedDatasetId": 0,
+	"archivedTime":      0,
+}
+
+// All data is exported as stored, including whether it is active, archived, or deleted, but without internal state
+var _AllDataSelector = bson.M{
+	"_id":              0,
+	"_userId":          0,
+	"_groupId":         0,
+	"_deduplicator":    0,
+	"_schemaVersion":   0,
+	"_version":         0,
+	"_restore":         0,
+	"_retainedArchive": 0,
+	"_softDeleted":     0,
+}
+
+var _DatasetDataSelector = bson.M{
+	"_id":            0,
+	"_userId":        0,
+	"_groupId":       0,
+	"_active":        0,
+	"_deduplicator":  0,
+	"_schemaVersion": 0,
+	"_version":       0,
+}
+
 func New(logger log.Logger, config *mongo.Config) (*Store, error) {
 	baseStore, err := mongo.New(logger, config)
 	if err != nil {
@@ -68,7 +111,7 @@ func (s *Session) GetDatasetsForUserByID(userID string, filter *store.Filter, pa
 	if !filter.Deleted {
 		query["deletedTime"] = bson.M{"$exists": false}
 	}
-	err := s.C().Find(query).Sort("-createdTime").Skip(pagination.Page * pagination.Size).Limit(pagination.Size).All(&datasets)
+	err := s.findWithPagination(query, "createdTime", pagination).All(&datasets)
 
 	loggerFields := log.Fields{"userId": userID, "datasetsCount": len(datasets), "duration": time.Since(startTime) / time.Microsecond}
 	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetDatasetsForUserByID")
@@ -117,6 +160,146 @@ func (s *Session) GetDatasetByID(datasetID string) (*upload.Upload, error) {
 	return datasets[0], nil
 }
 
+func (s *Session) GetJournaledDatasets() ([]*upload.Upload, error) {
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	var datasets []*upload.Upload
+	query := bson.M{
+		"type":     "upload",
+		"_journal": bson.M{"$exists": true},
+	}
+	err := s.C().Find(query).Sort("createdTime").All(&datasets)
+
+	loggerFields := log.Fields{"datasetsCount": len(datasets), "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetJournaledDatasets")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get journaled datasets")
+	}
+
+	if datasets == nil {
+		datasets = []*upload.Upload{}
+	}
+	return datasets, nil
+}
+
+func (s *Session) GetDatasetSummary(dataset *upload.Upload) (*store.DatasetSummary, error) {
+	if dataset == nil {
+		return nil, errors.New("mongo", "dataset is missing")
+	}
+	if dataset.UploadID == "" {
+		return nil, errors.New("mongo", "dataset upload id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	var results []struct {
+		ID struct {
+			Type    string `bson:"type"`
+			SubType string `bson:"subType"`
+		} `bson:"_id"`
+		Count         int    `bson:"count"`
+		ActiveCount   int    `bson:"activeCount"`
+		ArchivedCount int    `bson:"archivedCount"`
+		EarliestTime  string `bson:"earliestTime"`
+		LatestTime    string `bson:"latestTime"`
+	}
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"uploadId": dataset.UploadID,
+				"type":     bson.M{"$ne": "upload"},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":           bson.M{"type": "$type", "subType": "$subType"},
+				"count":         bson.M{"$sum": 1},
+				"activeCount":   bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$eq": []interface{}{"$_active", true}}, 1, 0}}},
+				"archivedCount": bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$gt": []interface{}{"$archivedDatasetId", nil}}, 1, 0}}},
+				"earliestTime":  bson.M{"$min": "$time"},
+				"latestTime":    bson.M{"$max": "$time"},
+			},
+		},
+		{
+			"$sort": bson.D{{Name: "_id.type", Value: 1}, {Name: "_id.subType", Value: 1}},
+		},
+	}
+	err := s.C().Pipe(pipeline).All(&results)
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "countsCount": len(results), "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetDatasetSummary")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get dataset summary")
+	}
+
+	summary := store.NewDatasetSummary()
+	for _, result := range results {
+		summary.AddCount(&store.DatasetSummaryCount{
+			Type:          result.ID.Type,
+			SubType:       result.ID.SubType,
+			Count:         result.Count,
+			ActiveCount:   result.ActiveCount,
+			ArchivedCount: result.ArchivedCount,
+		}, result.EarliestTime, result.LatestTime)
+	}
+	if deduplicatorDescriptor := dataset.DeduplicatorDescriptor(); deduplicatorDescriptor != nil && deduplicatorDescriptor.IsRegisteredWithAnyDeduplicator() {
+		summary.Deduplicator = &store.DatasetSummaryDeduplicator{
+			Name:    deduplicatorDescriptor.Name,
+			Version: deduplicatorDescriptor.Version,
+		}
+	}
+
+	return summary, nil
+}
+
+func (s *Session) GetDatasetLatestTime(dataset *upload.Upload) (*time.Time, error) {
+	if dataset == nil {
+		return nil, errors.New("mongo", "dataset is missing")
+	}
+	if dataset.UploadID == "" {
+		return nil, errors.New("mongo", "dataset upload id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	// Times with different offsets do not compare as strings, so all times are parsed
+	var latestTime *time.Time
+	iter := s.C().Find(bson.M{"uploadId": dataset.UploadID, "type": bson.M{"$ne": "upload"}, "time": bson.M{"$exists": true}}).Select(bson.M{"time": 1}).Iter()
+	for {
+		var result bson.M
+		if !iter.Next(&result) {
+			break
+		}
+
+		if datumTime, ok := parseStoredTime(result["time"]); ok && (latestTime == nil || datumTime.After(*latestTime)) {
+			latestTime = &datumTime
+		}
+	}
+	err := iter.Close()
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "latestTime": latestTime, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetDatasetLatestTime")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get dataset latest time")
+	}
+	return latestTime, nil
+}
+
 func (s *Session) CreateDataset(dataset *upload.Upload) error {
 	if err := s.validateDataset(dataset); err != nil {
 		return err
@@ -178,6 +361,7 @@ func (s *Session) UpdateDataset(dataset *upload.Upload) error {
 		"_groupId": dataset.GroupID,
 		"uploadId": dataset.UploadID,
 		"type":     dataset.Type,
+		"$or":      constructDatasetStateQuery(dataset.CurrentState()),
 	}
 	err := s.C().Update(selector, dataset)
 
@@ -190,7 +374,7 @@ func (s *Session) UpdateDataset(dataset *upload.Upload) error {
 	return nil
 }
 
-func (s *Session) DeleteDataset(dataset *upload.Upload) error {
+func (s *Session) UpdateDatasetJournal(dataset *upload.Upload) error {
 	if err := s.validateDataset(dataset); err != nil {
 		return err
 	}
@@ -201,60 +385,21 @@ func (s *Session) DeleteDataset(dataset *upload.Upload) error {
 
 	startTime := time.Now()
 
-	timestamp := s.Timestamp()
-	agentUserID := s.AgentUserID()
-
-	var err error
-	var removeInfo *mgo.ChangeInfo
-	var updateInfo *mgo.ChangeInfo
-
-	selector := bson.M{
-		"_userId":  dataset.UserID,
-		"_groupId": dataset.GroupID,
-		"uploadId": dataset.UploadID,
-		"type":     bson.M{"$ne": "upload"},
-	}
-	removeInfo, err = s.C().RemoveAll(selector)
-	if err == nil {
-		selector = bson.M{
-			"_userId":       dataset.UserID,
-			"_groupId":      dataset.GroupID,
-			"uploadId":      dataset.UploadID,
-			"type":          "upload",
-			"deletedTime":   bson.M{"$exists": false},
-			"deletedUserId": bson.M{"$exists": false},
-		}
-		set := bson.M{
-			"deletedTime": timestamp,
-		}
-		unset := bson.M{}
-		if agentUserID != "" {
-			set["deletedUserId"] = agentUserID
-		} else {
-			unset["deletedUserId"] = true
-		}
-		updateInfo, err = s.C().UpdateAll(selector, s.constructUpdate(set, unset))
-	}
+	err := s.updateDatasetJournal(dataset, "_journal", dataset.Journal)
 
-	loggerFields := log.Fields{"datasetId": dataset.UploadID, "removeInfo": removeInfo, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
-	s.Logger().WithFields(loggerFields).WithError(err).Debug("DeleteDataset")
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("UpdateDatasetJournal")
 
 	if err != nil {
-		return errors.Wrap(err, "mongo", "unable to delete dataset")
+		return errors.Wrap(err, "mongo", "unable to update dataset journal")
 	}
-
-	dataset.SetDeletedTime(timestamp)
-	dataset.SetDeletedUserID(agentUserID)
 	return nil
 }
 
-func (s *Session) CreateDatasetData(dataset *upload.Upload, datasetData []data.Datum) error {
+func (s *Session) UpdateDatasetRededuplicateJournal(dataset *upload.Upload) error {
 	if err := s.validateDataset(dataset); err != nil {
 		return err
 	}
-	if datasetData == nil {
-		return errors.New("mongo", "dataset data is missing")
-	}
 
 	if s.IsClosed() {
 		return errors.New("mongo", "session closed")
@@ -262,41 +407,64 @@ func (s *Session) CreateDatasetData(dataset *upload.Upload, datasetData []data.D
 
 	startTime := time.Now()
 
-	timestamp := s.Timestamp()
-	agentUserID := s.AgentUserID()
-
-	insertData := make([]interface{}, len(datasetData))
-	for index, datum := range datasetData {
-		datum.SetUserID(dataset.UserID)
-		datum.SetGroupID(dataset.GroupID)
-		datum.SetDatasetID(dataset.UploadID)
-		datum.SetCreatedTime(timestamp)
-		datum.SetCreatedUserID(agentUserID)
-		insertData[index] = datum
-	}
+	err := s.updateDatasetJournal(dataset, "_rededuplicateJournal", dataset.RededuplicateJournal)
 
-	bulk := s.C().Bulk()
-	bulk.Unordered()
-	bulk.Insert(insertData...)
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("UpdateDatasetRededuplicateJournal")
 
-	_, err := bulk.Run()
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to update dataset rededuplicate journal")
+	}
+	return nil
+}
 
-	loggerFields := log.Fields{"datasetId": dataset.UploadID, "dataCount": len(datasetData), "duration": time.Since(startTime) / time.Microsecond}
-	s.Logger().WithFields(loggerFields).WithError(err).Debug("CreateDatasetData")
+// Sets only the journal field, and the modified time and user, of the dataset, so that concurrent updates to
+// other fields, such as the state transitions, are not overwritten. A nil journal removes the field.
+func (s *Session) updateDatasetJournal(dataset *upload.Upload, field string, journal *upload.Journal) error {
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
 
-	if err != nil {
-		return errors.Wrap(err, "mongo", "unable to create dataset data")
+	selector := bson.M{
+		"_userId":  dataset.UserID,
+		"_groupId": dataset.GroupID,
+		"uploadId": dataset.UploadID,
+		"type":     dataset.Type,
 	}
+	set := bson.M{
+		"modifiedTime": timestamp,
+	}
+	unset := bson.M{}
+	if journal != nil {
+		set[field] = journal
+	} else {
+		unset[field] = true
+	}
+	if agentUserID != "" {
+		set["modifiedUserId"] = agentUserID
+	} else {
+		unset["modifiedUserId"] = true
+	}
+	if err := s.C().Update(selector, s.constructUpdate(set, unset)); err != nil {
+		return err
+	}
+
+	dataset.SetModifiedTime(timestamp)
+	dataset.SetModifiedUserID(agentUserID)
 	return nil
 }
 
-func (s *Session) ActivateDatasetData(dataset *upload.Upload) error {
+func (s *Session) TransitionDatasetState(dataset *upload.Upload, state string, userID string) (bool, error) {
 	if err := s.validateDataset(dataset); err != nil {
-		return err
+		return false, err
+	}
+
+	currentState := dataset.CurrentState()
+	if !upload.IsStateTransitionValid(currentState, state) {
+		return false, errors.Newf("mongo", "dataset state transition from %s to %s is not valid", strconv.Quote(currentState), strconv.Quote(state))
 	}
 
 	if s.IsClosed() {
-		return errors.New("mongo", "session closed")
+		return false, errors.New("mongo", "session closed")
 	}
 
 	startTime := time.Now()
@@ -308,36 +476,48 @@ func (s *Session) ActivateDatasetData(dataset *upload.Upload) error {
 		"_userId":  dataset.UserID,
 		"_groupId": dataset.GroupID,
 		"uploadId": dataset.UploadID,
+		"type":     dataset.Type,
+		"$or":      constructDatasetStateQuery(currentState),
 	}
 	set := bson.M{
-		"_active":      true,
+		"_state":       state,
+		"_dataState":   state, // TODO: Deprecated DataState (after data migration)
 		"modifiedTime": timestamp,
 	}
-	unset := bson.M{
-		"archivedDatasetId": 1,
-		"archivedTime":      1,
-	}
+	unset := bson.M{}
 	if agentUserID != "" {
 		set["modifiedUserId"] = agentUserID
 	} else {
 		unset["modifiedUserId"] = true
 	}
-	updateInfo, err := s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+	update := s.constructUpdate(set, unset)
+	update["$push"] = bson.M{"_transitions": &upload.Transition{From: currentState, To: state, Time: timestamp, UserID: userID}}
+	err := s.C().Update(selector, update)
 
-	loggerFields := log.Fields{"datasetId": dataset.UploadID, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
-	s.Logger().WithFields(loggerFields).WithError(err).Debug("ActivateDatasetData")
+	transitioned := true
+	if err == mgo.ErrNotFound {
+		transitioned = false
+		err = nil
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "fromState": currentState, "toState": state, "transitioned": transitioned, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("TransitionDatasetState")
 
 	if err != nil {
-		return errors.Wrap(err, "mongo", "unable to activate dataset data")
+		return false, errors.Wrap(err, "mongo", "unable to transition dataset state")
 	}
 
-	dataset.SetActive(true)
-	dataset.SetModifiedTime(timestamp)
-	dataset.SetModifiedUserID(agentUserID)
-	return nil
+	if transitioned {
+		if err = dataset.TransitionState(state, userID, timestamp); err != nil {
+			return false, errors.Wrap(err, "mongo", "unable to transition dataset state")
+		}
+		dataset.SetModifiedTime(timestamp)
+		dataset.SetModifiedUserID(agentUserID)
+	}
+	return transitioned, nil
 }
 
-func (s *Session) ArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) error {
+func (s *Session) DeleteDataset(dataset *upload.Upload) error {
 	if err := s.validateDataset(dataset); err != nil {
 		return err
 	}
@@ -351,48 +531,47 @@ func (s *Session) ArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload
 	timestamp := s.Timestamp()
 	agentUserID := s.AgentUserID()
 
+	var err error
+	var dataUpdateInfo *mgo.ChangeInfo
 	var updateInfo *mgo.ChangeInfo
+	var chunksRemoveInfo *mgo.ChangeInfo
 
-	var hashes []string
-	query := bson.M{
-		"uploadId": dataset.UploadID,
-		"type":     bson.M{"$ne": "upload"},
+	selector := bson.M{
+		"_userId":     dataset.UserID,
+		"_groupId":    dataset.GroupID,
+		"uploadId":    dataset.UploadID,
+		"type":        bson.M{"$ne": "upload"},
+		"deletedTime": bson.M{"$exists": false},
 	}
-	err := s.C().Find(query).Distinct("_deduplicator.hash", &hashes)
-	if err == nil && len(hashes) > 0 {
-		selector := bson.M{
-			"_userId":            dataset.UserID,
-			"_groupId":           dataset.GroupID,
-			"deviceId":           *dataset.DeviceID,
-			"type":               bson.M{"$ne": "upload"},
-			"_active":            true,
-			"_deduplicator.hash": bson.M{"$in": hashes},
-		}
-		set := bson.M{
-			"_active":           false,
-			"archivedDatasetId": dataset.UploadID,
-			"archivedTime":      timestamp,
-			"modifiedTime":      timestamp,
-		}
-		unset := bson.M{}
-		if agentUserID != "" {
-			set["modifiedUserId"] = agentUserID
-		} else {
-			unset["modifiedUserId"] = true
+	dataUpdateInfo, err = s.C().UpdateAll(selector, s.constructDeleteUpdate(timestamp, agentUserID, true))
+	if err == nil {
+		selector = bson.M{
+			"_userId":       dataset.UserID,
+			"_groupId":      dataset.GroupID,
+			"uploadId":      dataset.UploadID,
+			"type":          "upload",
+			"deletedTime":   bson.M{"$exists": false},
+			"deletedUserId": bson.M{"$exists": false},
 		}
-		updateInfo, err = s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+		updateInfo, err = s.C().UpdateAll(selector, s.constructDeleteUpdate(timestamp, agentUserID, false))
+	}
+	if err == nil {
+		chunksRemoveInfo, err = s.chunksC().RemoveAll(bson.M{"uploadId": dataset.UploadID})
 	}
 
-	loggerFields := log.Fields{"userId": dataset.UserID, "deviceId": *dataset.DeviceID, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
-	s.Logger().WithFields(loggerFields).WithError(err).Debug("ArchiveDeviceDataUsingHashesFromDataset")
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "dataUpdateInfo": dataUpdateInfo, "updateInfo": updateInfo, "chunksRemoveInfo": chunksRemoveInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("DeleteDataset")
 
 	if err != nil {
-		return errors.Wrap(err, "mongo", "unable to archive device data using hashes from dataset")
+		return errors.Wrap(err, "mongo", "unable to delete dataset")
 	}
+
+	dataset.SetDeletedTime(timestamp)
+	dataset.SetDeletedUserID(agentUserID)
 	return nil
 }
 
-func (s *Session) UnarchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) error {
+func (s *Session) UndeleteDataset(dataset *upload.Upload) error {
 	if err := s.validateDataset(dataset); err != nil {
 		return err
 	}
@@ -406,194 +585,2185 @@ func (s *Session) UnarchiveDeviceDataUsingHashesFromDataset(dataset *upload.Uplo
 	timestamp := s.Timestamp()
 	agentUserID := s.AgentUserID()
 
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"uploadId": dataset.UploadID,
-				"type":     bson.M{"$ne": "upload"},
-			},
-		},
-		{
-			"$group": bson.M{
-				"_id": bson.M{
-					"_active":           "$_active",
-					"archivedDatasetId": "$archivedDatasetId",
-					"archivedTime":      "$archivedTime",
-				},
-				"archivedHashes": bson.M{"$push": "$_deduplicator.hash"},
+	selector := bson.M{
+		"_userId":     dataset.UserID,
+		"_groupId":    dataset.GroupID,
+		"uploadId":    dataset.UploadID,
+		"deletedTime": bson.M{"$exists": true},
+	}
+	set := bson.M{
+		"modifiedTime": timestamp,
+	}
+	unset := bson.M{
+		"deletedTime":   true,
+		"deletedUserId": true,
+		"_softDeleted":  true,
+	}
+	if agentUserID != "" {
+		set["modifiedUserId"] = agentUserID
+	} else {
+		unset["modifiedUserId"] = true
+	}
+	updateInfo, err := s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("UndeleteDataset")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to undelete dataset")
+	}
+
+	dataset.SetDeletedTime("")
+	dataset.SetDeletedUserID("")
+	dataset.SetModifiedTime(timestamp)
+	dataset.SetModifiedUserID(agentUserID)
+	return nil
+}
+
+func (s *Session) GetDatasetChunk(dataset *upload.Upload, chunkID string) (*store.DatasetChunk, error) {
+	if err := s.validateDataset(dataset); err != nil {
+		return nil, err
+	}
+	if chunkID == "" {
+		return nil, errors.New("mongo", "chunk id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	chunk := &store.DatasetChunk{}
+	err := s.chunksC().FindId(datasetChunkID(dataset, chunkID)).One(chunk)
+	if err == mgo.ErrNotFound {
+		chunk = nil
+		err = nil
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "chunkId": chunkID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetDatasetChunk")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get dataset chunk")
+	}
+
+	return chunk, nil
+}
+
+func (s *Session) CreateDatasetChunk(dataset *upload.Upload, chunkID string, datumIDs []string) (bool, error) {
+	if err := s.validateDataset(dataset); err != nil {
+		return false, err
+	}
+	if chunkID == "" {
+		return false, errors.New("mongo", "chunk id is missing")
+	}
+	if datumIDs == nil {
+		return false, errors.New("mongo", "datum ids is missing")
+	}
+
+	if s.IsClosed() {
+		return false, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	now := time.Now().Truncate(time.Millisecond)
+
+	// Either insert a new pending chunk or reclaim a failed chunk or a pending chunk whose lease
+	// expired; an existing chunk that does not match the selector fails the upsert with a duplicate
+	// key. The datum ids of every attempt are recorded so that any data written by an earlier
+	// attempt, which may have failed part way, is removed before the chunk is written again.
+	selector := bson.M{
+		"_id": datasetChunkID(dataset, chunkID),
+		"$or": []bson.M{
+			{"state": store.DatasetChunkStatePending, "expirationTime": bson.M{"$lte": now}},
+			{"state": store.DatasetChunkStateFailed},
+		},
+	}
+	change := mgo.Change{
+		Update: bson.M{
+			"$set": bson.M{
+				"uploadId":       dataset.UploadID,
+				"chunkId":        chunkID,
+				"state":          store.DatasetChunkStatePending,
+				"createdTime":    now,
+				"expirationTime": now.Add(store.DatasetChunkLeaseDuration),
+			},
+			"$addToSet": bson.M{
+				"datumIds": bson.M{"$each": datumIDs},
 			},
 		},
+		Upsert: true,
+	}
+
+	var previousChunk store.DatasetChunk
+	_, err := s.chunksC().Find(selector).Apply(change, &previousChunk)
+
+	created := true
+	if mgo.IsDup(err) {
+		created = false
+		err = nil
+	}
+
+	var removeInfo *mgo.ChangeInfo
+	if created && err == nil && len(previousChunk.DatumIDs) > 0 {
+		selector = bson.M{
+			"_userId":  dataset.UserID,
+			"_groupId": dataset.GroupID,
+			"uploadId": dataset.UploadID,
+			"type":     bson.M{"$ne": "upload"},
+			"id":       bson.M{"$in": previousChunk.DatumIDs},
+		}
+		removeInfo, err = s.C().RemoveAll(selector)
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "chunkId": chunkID, "created": created, "removeInfo": removeInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("CreateDatasetChunk")
+
+	if err != nil {
+		return false, errors.Wrap(err, "mongo", "unable to create dataset chunk")
+	}
+
+	return created, nil
+}
+
+func (s *Session) CompleteDatasetChunk(dataset *upload.Upload, chunkID string, response []byte) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+	if chunkID == "" {
+		return errors.New("mongo", "chunk id is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	selector := bson.M{
+		"_id":   datasetChunkID(dataset, chunkID),
+		"state": store.DatasetChunkStatePending,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"state":         store.DatasetChunkStateCompleted,
+			"response":      response,
+			"completedTime": time.Now().Truncate(time.Millisecond),
+		},
+		"$unset": bson.M{
+			"expirationTime": true,
+		},
+	}
+	err := s.chunksC().Update(selector, update)
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "chunkId": chunkID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("CompleteDatasetChunk")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to complete dataset chunk")
+	}
+	return nil
+}
+
+func (s *Session) FailDatasetChunk(dataset *upload.Upload, chunkID string) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+	if chunkID == "" {
+		return errors.New("mongo", "chunk id is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	selector := bson.M{
+		"_id":   datasetChunkID(dataset, chunkID),
+		"state": store.DatasetChunkStatePending,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"state": store.DatasetChunkStateFailed,
+		},
+		"$unset": bson.M{
+			"expirationTime": true,
+		},
+	}
+	err := s.chunksC().Update(selector, update)
+	if err == mgo.ErrNotFound {
+		err = nil
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "chunkId": chunkID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("FailDatasetChunk")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to fail dataset chunk")
+	}
+	return nil
+}
+
+func (s *Session) GetDatasetDataDeduplicatorHashes(dataset *upload.Upload, hashes []string) ([]string, error) {
+	if err := s.validateDataset(dataset); err != nil {
+		return nil, err
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	if len(hashes) == 0 {
+		return []string{}, nil
+	}
+
+	startTime := time.Now()
+
+	query := bson.M{
+		"_userId":            dataset.UserID,
+		"_groupId":           dataset.GroupID,
+		"uploadId":           dataset.UploadID,
+		"type":               bson.M{"$ne": "upload"},
+		"_deduplicator.hash": bson.M{"$in": hashes},
+	}
+	existingHashes := []string{}
+	err := s.C().Find(query).Distinct("_deduplicator.hash", &existingHashes)
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "hashesCount": len(hashes), "existingHashesCount": len(existingHashes), "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetDatasetDataDeduplicatorHashes")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get dataset data deduplicator hashes")
+	}
+
+	return existingHashes, nil
+}
+
+func (s *Session) CreateDatasetData(dataset *upload.Upload, datasetData []data.Datum) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+	if datasetData == nil {
+		return errors.New("mongo", "dataset data is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	insertData := make([]interface{}, len(datasetData))
+	for index, datum := range datasetData {
+		datum.SetUserID(dataset.UserID)
+		datum.SetGroupID(dataset.GroupID)
+		datum.SetDatasetID(dataset.UploadID)
+		datum.SetCreatedTime(timestamp)
+		datum.SetCreatedUserID(agentUserID)
+		insertData[index] = datum
+	}
+
+	bulk := s.C().Bulk()
+	bulk.Unordered()
+	bulk.Insert(insertData...)
+
+	_, err := bulk.Run()
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "dataCount": len(datasetData), "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("CreateDatasetData")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to create dataset data")
+	}
+	return nil
+}
+
+func (s *Session) ActivateDatasetData(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	selector := bson.M{
+		"_userId":           dataset.UserID,
+		"_groupId":          dataset.GroupID,
+		"uploadId":          dataset.UploadID,
+		"archivedDatasetId": bson.M{"$exists": false},
+	}
+	set := bson.M{
+		"_active":      true,
+		"modifiedTime": timestamp,
+	}
+	unset := bson.M{
+		"archivedTime": 1,
+	}
+	if agentUserID != "" {
+		set["modifiedUserId"] = agentUserID
+	} else {
+		unset["modifiedUserId"] = true
+	}
+	updateInfo, err := s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("ActivateDatasetData")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to activate dataset data")
+	}
+
+	dataset.SetActive(true)
+	dataset.SetModifiedTime(timestamp)
+	dataset.SetModifiedUserID(agentUserID)
+	return nil
+}
+
+func (s *Session) DeactivateDatasetData(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	selector := bson.M{
+		"_userId":  dataset.UserID,
+		"_groupId": dataset.GroupID,
+		"uploadId": dataset.UploadID,
+		"type":     bson.M{"$ne": "upload"},
+	}
+	set := bson.M{
+		"_active":      false,
+		"modifiedTime": timestamp,
+	}
+	unset := bson.M{}
+	if agentUserID != "" {
+		set["modifiedUserId"] = agentUserID
+	} else {
+		unset["modifiedUserId"] = true
+	}
+	updateInfo, err := s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("DeactivateDatasetData")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to deactivate dataset data")
+	}
+	return nil
+}
+
+func (s *Session) DeleteInactiveDatasetData(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	selector := bson.M{
+		"_userId":  dataset.UserID,
+		"_groupId": dataset.GroupID,
+		"uploadId": dataset.UploadID,
+		"type":     bson.M{"$ne": "upload"},
+		"_active":  false,
+	}
+	removeInfo, err := s.C().RemoveAll(selector)
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "removeInfo": removeInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("DeleteInactiveDatasetData")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to delete inactive dataset data")
+	}
+	return nil
+}
+
+func (s *Session) ArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	var updateInfo *mgo.ChangeInfo
+
+	var hashes []string
+	query := bson.M{
+		"uploadId": dataset.UploadID,
+		"type":     bson.M{"$ne": "upload"},
+	}
+	err := s.C().Find(query).Distinct("_deduplicator.hash", &hashes)
+	if err == nil && len(hashes) > 0 {
+		selector := bson.M{
+			"_userId":            dataset.UserID,
+			"_groupId":           dataset.GroupID,
+			"deviceId":           *dataset.DeviceID,
+			"type":               bson.M{"$ne": "upload"},
+			"_active":            true,
+			"_deduplicator.hash": bson.M{"$in": hashes},
+		}
+		set := bson.M{
+			"_active":           false,
+			"archivedDatasetId": dataset.UploadID,
+			"archivedTime":      timestamp,
+			"modifiedTime":      timestamp,
+		}
+		unset := bson.M{}
+		if agentUserID != "" {
+			set["modifiedUserId"] = agentUserID
+		} else {
+			unset["modifiedUserId"] = true
+		}
+		updateInfo, err = s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+	}
+
+	loggerFields := log.Fields{"userId": dataset.UserID, "deviceId": *dataset.DeviceID, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("ArchiveDeviceDataUsingHashesFromDataset")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to archive device data using hashes from dataset")
+	}
+	return nil
+}
+
+func (s *Session) UnarchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"uploadId": dataset.UploadID,
+				"type":     bson.M{"$ne": "upload"},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"_active":           "$_active",
+					"archivedDatasetId": "$archivedDatasetId",
+					"archivedTime":      "$archivedTime",
+				},
+				"archivedHashes": bson.M{"$push": "$_deduplicator.hash"},
+			},
+		},
+	}
+	pipe := s.C().Pipe(pipeline)
+	iter := pipe.Iter()
+
+	var overallUpdateInfo mgo.ChangeInfo
+	var overallErr error
+
+	result := struct {
+		ID struct {
+			Active            bool   `bson:"_active"`
+			ArchivedDatasetID string `bson:"archivedDatasetId"`
+			ArchivedTime      string `bson:"archivedTime"`
+		} `bson:"_id"`
+		ArchivedHashes []string `bson:"archivedHashes"`
+	}{}
+	for iter.Next(&result) {
+		if result.ID.Active != (result.ID.ArchivedDatasetID == "") || result.ID.Active != (result.ID.ArchivedTime == "") {
+			loggerFields := log.Fields{"datasetId": dataset.UploadID, "result": result}
+			s.Logger().WithFields(loggerFields).Error("Unexpected pipe result for UnarchiveDeviceDataUsingHashesFromDataset")
+			continue
+		}
+
+		selector := bson.M{
+			"_userId":            dataset.UserID,
+			"deviceId":           dataset.DeviceID,
+			"archivedDatasetId":  dataset.UploadID,
+			"_deduplicator.hash": bson.M{"$in": result.ArchivedHashes},
+			"deletedTime":        bson.M{"$exists": false},
+		}
+		set := bson.M{
+			"_active":      result.ID.Active,
+			"modifiedTime": timestamp,
+		}
+		unset := bson.M{}
+		if agentUserID != "" {
+			set["modifiedUserId"] = agentUserID
+		} else {
+			unset["modifiedUserId"] = true
+		}
+		if result.ID.Active {
+			unset["archivedDatasetId"] = true
+			unset["archivedTime"] = true
+		} else {
+			set["archivedDatasetId"] = result.ID.ArchivedDatasetID
+			set["archivedTime"] = result.ID.ArchivedTime
+		}
+		updateInfo, err := s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+		if err != nil {
+			loggerFields := log.Fields{"datasetId": dataset.UploadID, "result": result}
+			s.Logger().WithFields(loggerFields).WithError(err).Error("Unable to update result for UnarchiveDeviceDataUsingHashesFromDataset")
+			if overallErr == nil {
+				overallErr = errors.Wrap(err, "mongo", "unable to transfer device data active")
+			}
+		} else {
+			overallUpdateInfo.Updated += updateInfo.Updated
+			overallUpdateInfo.Removed += updateInfo.Removed
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		if overallErr == nil {
+			overallErr = errors.Wrap(err, "mongo", "unable to iterate to transfer device data active")
+		}
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "updateInfo": overallUpdateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(overallErr).Debug("UnarchiveDeviceDataUsingHashesFromDataset")
+
+	return overallErr
+}
+
+func (s *Session) ArchiveDeviceDataUsingTimeWindowFromDataset(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	var updateInfo *mgo.ChangeInfo
+
+	selector, err := s.constructTimeWindowQuery(dataset)
+	if err == nil && selector != nil {
+		set := bson.M{
+			"_active":           false,
+			"archivedDatasetId": dataset.UploadID,
+			"archivedTime":      timestamp,
+			"modifiedTime":      timestamp,
+		}
+		unset := bson.M{}
+		if agentUserID != "" {
+			set["modifiedUserId"] = agentUserID
+		} else {
+			unset["modifiedUserId"] = true
+		}
+		updateInfo, err = s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+	}
+
+	loggerFields := log.Fields{"userId": dataset.UserID, "deviceId": *dataset.DeviceID, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("ArchiveDeviceDataUsingTimeWindowFromDataset")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to archive device data using time window from dataset")
+	}
+	return nil
+}
+
+func (s *Session) UnarchiveDeviceDataArchivedByDataset(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	var updateInfo *mgo.ChangeInfo
+
+	transferredCount, err := s.transferDeviceDataArchivedByDataset(dataset, timestamp, agentUserID)
+	if err == nil {
+		selector := s.constructDeviceDataArchivedByDatasetQuery(dataset)
+		set := bson.M{
+			"_active":      true,
+			"modifiedTime": timestamp,
+		}
+		unset := bson.M{
+			"archivedDatasetId":          true,
+			"archivedTime":               true,
+			"_deduplicator.foldedIntoId": true,
+		}
+		if agentUserID != "" {
+			set["modifiedUserId"] = agentUserID
+		} else {
+			unset["modifiedUserId"] = true
+		}
+		updateInfo, err = s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "transferredCount": transferredCount, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("UnarchiveDeviceDataArchivedByDataset")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to unarchive device data archived by dataset")
+	}
+	return nil
+}
+
+// The archive of device data archived by a dataset is retained before the dataset is deleted, since deleting
+// the dataset unarchives the device data, so the archive can be put back if the dataset is undeleted
+func (s *Session) RetainDeviceDataArchivedByDataset(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	pipeline := []bson.M{
+		{
+			"$match": s.constructDeviceDataArchivedByDatasetQuery(dataset),
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"archivedTime": "$archivedTime",
+					"foldedIntoId": "$_deduplicator.foldedIntoId",
+				},
+			},
+		},
+	}
+	iter := s.C().Pipe(pipeline).Iter()
+
+	var overallUpdateInfo mgo.ChangeInfo
+	var overallErr error
+
+	result := struct {
+		ID struct {
+			ArchivedTime string `bson:"archivedTime"`
+			FoldedIntoID string `bson:"foldedIntoId"`
+		} `bson:"_id"`
+	}{}
+	for iter.Next(&result) {
+		selector := s.constructDeviceDataArchivedByDatasetQuery(dataset)
+		selector["archivedTime"] = result.ID.ArchivedTime
+		retainedArchive := bson.M{
+			"datasetId":    dataset.UploadID,
+			"archivedTime": result.ID.ArchivedTime,
+		}
+		if result.ID.FoldedIntoID != "" {
+			selector["_deduplicator.foldedIntoId"] = result.ID.FoldedIntoID
+			retainedArchive["foldedIntoId"] = result.ID.FoldedIntoID
+		} else {
+			selector["_deduplicator.foldedIntoId"] = bson.M{"$exists": false}
+		}
+
+		updateInfo, err := s.C().UpdateAll(selector, bson.M{"$set": bson.M{"_retainedArchive": retainedArchive}})
+		if err != nil {
+			overallErr = err
+			break
+		}
+
+		overallUpdateInfo.Updated += updateInfo.Updated
+	}
+
+	if err := iter.Close(); err != nil && overallErr == nil {
+		overallErr = err
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "updateInfo": overallUpdateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(overallErr).Debug("RetainDeviceDataArchivedByDataset")
+
+	if overallErr != nil {
+		return errors.Wrap(overallErr, "mongo", "unable to retain device data archived by dataset")
+	}
+	return nil
+}
+
+// Only retained device data that is still active, and so not since archived by another dataset, is archived
+// again, while the retained archive is removed from all device data
+func (s *Session) RearchiveDeviceDataRetainedByDataset(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	var removeUpdateInfo *mgo.ChangeInfo
+
+	selector := bson.M{
+		"_userId":                    dataset.UserID,
+		"_groupId":                   dataset.GroupID,
+		"deviceId":                   *dataset.DeviceID,
+		"_retainedArchive.datasetId": dataset.UploadID,
+		"_active":                    true,
+		"archivedDatasetId":          bson.M{"$exists": false},
+		"deletedTime":                bson.M{"$exists": false},
+	}
+	set := bson.M{
+		"_active":           false,
+		"archivedDatasetId": dataset.UploadID,
+		"modifiedTime":      timestamp,
+	}
+	unset := bson.M{}
+	if agentUserID != "" {
+		set["modifiedUserId"] = agentUserID
+	} else {
+		unset["modifiedUserId"] = true
+	}
+	update := s.constructUpdate(set, unset)
+	update["$rename"] = _RetainedArchiveRenames
+	updateInfo, err := s.C().UpdateAll(selector, update)
+	if err == nil {
+		selector = bson.M{
+			"_userId":                    dataset.UserID,
+			"_retainedArchive.datasetId": dataset.UploadID,
+		}
+		removeUpdateInfo, err = s.C().UpdateAll(selector, bson.M{"$unset": bson.M{"_retainedArchive": true}})
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "updateInfo": updateInfo, "removeUpdateInfo": removeUpdateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("RearchiveDeviceDataRetainedByDataset")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to rearchive device data retained by dataset")
+	}
+	return nil
+}
+
+// As with UnarchiveDeviceDataUsingHashesFromDataset, device data archived by the dataset is transferred to any
+// later dataset that archived the corresponding dataset data (by fold or by time window), rather than unarchived
+func (s *Session) transferDeviceDataArchivedByDataset(dataset *upload.Upload, timestamp string, agentUserID string) (int, error) {
+	constructArchiveUpdate := func(archivedDatasetID string, archivedTime string, foldedIntoID string) bson.M {
+		set := bson.M{
+			"_active":           false,
+			"archivedDatasetId": archivedDatasetID,
+			"archivedTime":      archivedTime,
+			"modifiedTime":      timestamp,
+		}
+		unset := bson.M{}
+		if foldedIntoID != "" {
+			set["_deduplicator.foldedIntoId"] = foldedIntoID
+		} else {
+			unset["_deduplicator.foldedIntoId"] = true
+		}
+		if agentUserID != "" {
+			set["modifiedUserId"] = agentUserID
+		} else {
+			unset["modifiedUserId"] = true
+		}
+		return s.constructUpdate(set, unset)
+	}
+
+	archivedDatasetDataQuery := func() bson.M {
+		return bson.M{
+			"uploadId":          dataset.UploadID,
+			"type":              bson.M{"$ne": "upload"},
+			"_active":           false,
+			"archivedDatasetId": bson.M{"$exists": true, "$ne": dataset.UploadID},
+			"deletedTime":       bson.M{"$exists": false},
+		}
+	}
+
+	transferredCount := 0
+
+	query := archivedDatasetDataQuery()
+	query["_deduplicator.foldedIntoId"] = bson.M{"$exists": true}
+	selection := bson.M{"id": 1, "archivedDatasetId": 1, "archivedTime": 1, "_deduplicator.foldedIntoId": 1}
+
+	var foldedDatasetData []struct {
+		ID                string `bson:"id"`
+		ArchivedDatasetID string `bson:"archivedDatasetId"`
+		ArchivedTime      string `bson:"archivedTime"`
+		Deduplicator      struct {
+			FoldedIntoID string `bson:"foldedIntoId"`
+		} `bson:"_deduplicator"`
+	}
+	if err := s.C().Find(query).Select(selection).All(&foldedDatasetData); err != nil {
+		return transferredCount, err
+	}
+
+	for _, foldedDatasetDatum := range foldedDatasetData {
+		selector := s.constructDeviceDataArchivedByDatasetQuery(dataset)
+		selector["_deduplicator.foldedIntoId"] = foldedDatasetDatum.ID
+		updateInfo, err := s.C().UpdateAll(selector, constructArchiveUpdate(foldedDatasetDatum.ArchivedDatasetID, foldedDatasetDatum.ArchivedTime, foldedDatasetDatum.Deduplicator.FoldedIntoID))
+		if err != nil {
+			return transferredCount, err
+		}
+		transferredCount += updateInfo.Updated
+	}
+
+	query = archivedDatasetDataQuery()
+	query["_deduplicator.foldedIntoId"] = bson.M{"$exists": false}
+	query["time"] = bson.M{"$exists": true}
+	pipeline := []bson.M{
+		{
+			"$match": query,
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"type":              "$type",
+					"archivedDatasetId": "$archivedDatasetId",
+					"archivedTime":      "$archivedTime",
+				},
+				"minimumTime": bson.M{"$min": "$time"},
+				"maximumTime": bson.M{"$max": "$time"},
+			},
+		},
+	}
+
+	var windows []struct {
+		ID struct {
+			Type              string `bson:"type"`
+			ArchivedDatasetID string `bson:"archivedDatasetId"`
+			ArchivedTime      string `bson:"archivedTime"`
+		} `bson:"_id"`
+		MinimumTime string `bson:"minimumTime"`
+		MaximumTime string `bson:"maximumTime"`
+	}
+	if err := s.C().Pipe(pipeline).All(&windows); err != nil {
+		return transferredCount, err
+	}
+
+	for _, window := range windows {
+		selector := s.constructDeviceDataArchivedByDatasetQuery(dataset)
+		selector["_deduplicator.foldedIntoId"] = bson.M{"$exists": false}
+		selector["type"] = window.ID.Type
+		selector["time"] = bson.M{"$gte": window.MinimumTime, "$lte": window.MaximumTime}
+		updateInfo, err := s.C().UpdateAll(selector, constructArchiveUpdate(window.ID.ArchivedDatasetID, window.ID.ArchivedTime, ""))
+		if err != nil {
+			return transferredCount, err
+		}
+		transferredCount += updateInfo.Updated
+	}
+
+	return transferredCount, nil
+}
+
+func (s *Session) constructDeviceDataArchivedByDatasetQuery(dataset *upload.Upload) bson.M {
+	return bson.M{
+		"_userId":           dataset.UserID,
+		"_groupId":          dataset.GroupID,
+		"deviceId":          *dataset.DeviceID,
+		"archivedDatasetId": dataset.UploadID,
+		"deletedTime":       bson.M{"$exists": false},
+	}
+}
+
+func (s *Session) ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset *upload.Upload, deviceTimeTolerance time.Duration) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	var bulkResult *mgo.BulkResult
+
+	folds, err := s.findFuzzyHashFolds(dataset, deviceTimeTolerance, false)
+	if err == nil && len(folds) > 0 {
+		bulk := s.C().Bulk()
+		bulk.Unordered()
+		for id, foldedIntoID := range folds {
+			selector := bson.M{
+				"_userId":  dataset.UserID,
+				"_groupId": dataset.GroupID,
+				"id":       id,
+				"_active":  true,
+			}
+			set := bson.M{
+				"_active":                    false,
+				"archivedDatasetId":          dataset.UploadID,
+				"archivedTime":               timestamp,
+				"modifiedTime":               timestamp,
+				"_deduplicator.foldedIntoId": foldedIntoID,
+			}
+			unset := bson.M{}
+			if agentUserID != "" {
+				set["modifiedUserId"] = agentUserID
+			} else {
+				unset["modifiedUserId"] = true
+			}
+			bulk.Update(selector, s.constructUpdate(set, unset))
+		}
+		bulkResult, err = bulk.Run()
+	}
+
+	loggerFields := log.Fields{"userId": dataset.UserID, "deviceId": *dataset.DeviceID, "foldsCount": len(folds), "bulkResult": bulkResult, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("ArchiveDeviceDataUsingFuzzyHashesFromDataset")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to archive device data using fuzzy hashes from dataset")
+	}
+	return nil
+}
+
+func (s *Session) DeleteOtherDatasetData(dataset *upload.Upload) error {
+	if err := s.validateDataset(dataset); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	var err error
+	var dataUpdateInfo *mgo.ChangeInfo
+	var updateInfo *mgo.ChangeInfo
+
+	selector := bson.M{
+		"_userId":     dataset.UserID,
+		"_groupId":    dataset.GroupID,
+		"deviceId":    *dataset.DeviceID,
+		"uploadId":    bson.M{"$ne": dataset.UploadID},
+		"type":        bson.M{"$ne": "upload"},
+		"deletedTime": bson.M{"$exists": false},
+	}
+	dataUpdateInfo, err = s.C().UpdateAll(selector, s.constructDeleteUpdate(timestamp, agentUserID, true))
+	if err == nil {
+		selector = bson.M{
+			"_userId":       dataset.UserID,
+			"_groupId":      dataset.GroupID,
+			"deviceId":      *dataset.DeviceID,
+			"uploadId":      bson.M{"$ne": dataset.UploadID},
+			"type":          "upload",
+			"deletedTime":   bson.M{"$exists": false},
+			"deletedUserId": bson.M{"$exists": false},
+		}
+		updateInfo, err = s.C().UpdateAll(selector, s.constructDeleteUpdate(timestamp, agentUserID, false))
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "dataUpdateInfo": dataUpdateInfo, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("DeleteOtherDatasetData")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to remove other dataset data")
+	}
+	return nil
+}
+
+func (s *Session) PreviewActivateDatasetData(dataset *upload.Upload) (*store.DataPreview, error) {
+	if err := s.validateDataset(dataset); err != nil {
+		return nil, err
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	query := bson.M{
+		"_userId":           dataset.UserID,
+		"_groupId":          dataset.GroupID,
+		"uploadId":          dataset.UploadID,
+		"type":              bson.M{"$ne": "upload"},
+		"archivedDatasetId": bson.M{"$exists": false},
+	}
+	preview, err := s.previewData(query)
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "preview": preview, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("PreviewActivateDatasetData")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to preview activate dataset data")
+	}
+	return preview, nil
+}
+
+func (s *Session) PreviewArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) (*store.DataPreview, error) {
+	if err := s.validateDataset(dataset); err != nil {
+		return nil, err
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	preview := store.NewDataPreview()
+
+	var hashes []string
+	query := bson.M{
+		"uploadId": dataset.UploadID,
+		"type":     bson.M{"$ne": "upload"},
+	}
+	err := s.C().Find(query).Distinct("_deduplicator.hash", &hashes)
+	if err == nil && len(hashes) > 0 {
+		query = bson.M{
+			"_userId":            dataset.UserID,
+			"_groupId":           dataset.GroupID,
+			"deviceId":           *dataset.DeviceID,
+			"uploadId":           bson.M{"$ne": dataset.UploadID},
+			"type":               bson.M{"$ne": "upload"},
+			"_active":            true,
+			"_deduplicator.hash": bson.M{"$in": hashes},
+		}
+		preview, err = s.previewData(constructReopenedDatasetQuery(dataset, query))
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "preview": preview, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("PreviewArchiveDeviceDataUsingHashesFromDataset")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to preview archive device data using hashes from dataset")
+	}
+	return preview, nil
+}
+
+func (s *Session) PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset *upload.Upload) (*store.DataPreview, error) {
+	if err := s.validateDataset(dataset); err != nil {
+		return nil, err
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	preview := store.NewDataPreview()
+
+	query, err := s.constructTimeWindowQuery(dataset)
+	if err == nil && query != nil {
+		preview, err = s.previewData(constructReopenedDatasetQuery(dataset, query))
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "preview": preview, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("PreviewArchiveDeviceDataUsingTimeWindowFromDataset")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to preview archive device data using time window from dataset")
+	}
+	return preview, nil
+}
+
+func (s *Session) PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset *upload.Upload, deviceTimeTolerance time.Duration) (*store.DataPreview, error) {
+	if err := s.validateDataset(dataset); err != nil {
+		return nil, err
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	preview := store.NewDataPreview()
+
+	folds, err := s.findFuzzyHashFolds(dataset, deviceTimeTolerance, true)
+	if err == nil {
+		ids := []string{}
+		for id := range folds {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		if len(ids) > store.DataPreviewSampleSize {
+			ids = ids[:store.DataPreviewSampleSize]
+		}
+		preview.Count = len(folds)
+		preview.SampleIDs = ids
+	}
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "preview": preview, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to preview archive device data using fuzzy hashes from dataset")
+	}
+	return preview, nil
+}
+
+func (s *Session) PreviewDeleteOtherDatasets(dataset *upload.Upload) (*store.DataPreview, error) {
+	if err := s.validateDataset(dataset); err != nil {
+		return nil, err
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	query := bson.M{
+		"_userId":     dataset.UserID,
+		"_groupId":    dataset.GroupID,
+		"deviceId":    *dataset.DeviceID,
+		"uploadId":    bson.M{"$ne": dataset.UploadID},
+		"type":        "upload",
+		"deletedTime": bson.M{"$exists": false},
+	}
+	preview, err := s.previewData(query)
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "preview": preview, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("PreviewDeleteOtherDatasets")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to preview delete other datasets")
+	}
+	return preview, nil
+}
+
+func (s *Session) PreviewDeleteOtherDatasetData(dataset *upload.Upload) (*store.DataPreview, error) {
+	if err := s.validateDataset(dataset); err != nil {
+		return nil, err
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	query := bson.M{
+		"_userId":     dataset.UserID,
+		"_groupId":    dataset.GroupID,
+		"deviceId":    *dataset.DeviceID,
+		"uploadId":    bson.M{"$ne": dataset.UploadID},
+		"type":        bson.M{"$ne": "upload"},
+		"deletedTime": bson.M{"$exists": false},
+	}
+	preview, err := s.previewData(query)
+
+	loggerFields := log.Fields{"datasetId": dataset.UploadID, "preview": preview, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("PreviewDeleteOtherDatasetData")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to preview delete other dataset data")
+	}
+	return preview, nil
+}
+
+func (s *Session) GetDataForDatasetByID(datasetID string, filter *store.DatasetDataFilter, pagination *store.Pagination) ([]map[string]interface{}, error) {
+	if datasetID == "" {
+		return nil, errors.New("mongo", "dataset id is missing")
+	}
+	if filter == nil {
+		filter = store.NewDatasetDataFilter()
+	} else if err := filter.Validate(); err != nil {
+		return nil, errors.Wrap(err, "mongo", "filter is invalid")
+	}
+	if pagination == nil {
+		pagination = store.NewPagination()
+	} else if err := pagination.Validate(); err != nil {
+		return nil, errors.Wrap(err, "mongo", "pagination is invalid")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	query := bson.M{
+		"uploadId":    datasetID,
+		"type":        bson.M{"$ne": "upload"},
+		"deletedTime": bson.M{"$exists": false},
+	}
+	if !filter.Archived {
+		query["archivedDatasetId"] = bson.M{"$exists": false}
+	}
+
+	var datasetData []map[string]interface{}
+	err := s.findWithPagination(query, "time", pagination).Select(_DatasetDataSelector).All(&datasetData)
+
+	loggerFields := log.Fields{"datasetId": datasetID, "dataCount": len(datasetData), "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetDataForDatasetByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get data for dataset by id")
+	}
+
+	if datasetData == nil {
+		datasetData = []map[string]interface{}{}
+	}
+	return datasetData, nil
+}
+
+func (s *Session) GetDataForUserByID(userID string, filter *store.DataFilter, pagination *store.Pagination) ([]map[string]interface{}, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+	if filter == nil {
+		filter = store.NewDataFilter()
+	} else if err := filter.Validate(); err != nil {
+		return nil, errors.Wrap(err, "mongo", "filter is invalid")
+	}
+	if pagination == nil {
+		pagination = store.NewPagination()
+	} else if err := pagination.Validate(); err != nil {
+		return nil, errors.Wrap(err, "mongo", "pagination is invalid")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	query, err := s.constructDataQuery(userID, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get data for user by id")
+	}
+
+	var datasetData []map[string]interface{}
+	err = s.findWithPagination(query, "time", pagination).Select(_DataSelector).All(&datasetData)
+
+	loggerFields := log.Fields{"userId": userID, "dataCount": len(datasetData), "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetDataForUserByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get data for user by id")
+	}
+
+	if datasetData == nil {
+		datasetData = []map[string]interface{}{}
+	}
+	return datasetData, nil
+}
+
+func (s *Session) IterateDataForUserByID(userID string, filter *store.DataFilter) (store.Iterator, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+	if filter == nil {
+		filter = store.NewDataFilter()
+	} else if err := filter.Validate(); err != nil {
+		return nil, errors.Wrap(err, "mongo", "filter is invalid")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	query, err := s.constructDataQuery(userID, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to iterate data for user by id")
+	}
+
+	iter := s.C().Find(query).Select(_DataSelector).Sort("-time", "-id").Iter()
+
+	loggerFields := log.Fields{"userId": userID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).Debug("IterateDataForUserByID")
+
+	return iter, nil
+}
+
+func (s *Session) IterateAllDataForUserByID(userID string) (store.Iterator, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	iter := s.C().Find(bson.M{"_userId": userID}).Select(_AllDataSelector).Sort("-time", "-id").Iter()
+
+	loggerFields := log.Fields{"userId": userID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).Debug("IterateAllDataForUserByID")
+
+	return iter, nil
+}
+
+func (s *Session) RestoreDataForUserByID(userID string, restoreID string, restoreTime time.Time) (bool, error) {
+	if userID == "" {
+		return false, errors.New("mongo", "user id is missing")
+	}
+	if restoreID == "" {
+		return false, errors.New("mongo", "restore id is missing")
+	}
+	if restoreTime.IsZero() {
+		return false, errors.New("mongo", "restore time is missing")
+	}
+
+	if s.IsClosed() {
+		return false, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	var activateUpdateInfo *mgo.ChangeInfo
+	var archiveUpdateInfo *mgo.ChangeInfo
+
+	// The data activated or archived by a restore keeps the restore until it is undone, so the data is not
+	// restored again while an earlier restore is not undone, since that would overwrite the earlier restore
+	restored := false
+	count, err := s.C().Find(bson.M{"_userId": userID, "type": bson.M{"$ne": "upload"}, "_restore": bson.M{"$exists": true}}).Limit(1).Count()
+	if err == nil {
+		restored = count == 0
+	}
+
+	// The archive of each activated datum is retained with the restore id, and each archived datum is archived
+	// with the restore id, so the restore can be undone
+	var query bson.M
+	if err == nil && restored {
+		query, err = s.constructRestoreActivateQuery(userID, restoreTime)
+	}
+	if err == nil && restored {
+		set := bson.M{
+			"_active":      true,
+			"_restore.id":  restoreID,
+			"modifiedTime": timestamp,
+		}
+		unset := bson.M{}
+		if agentUserID != "" {
+			set["modifiedUserId"] = agentUserID
+		} else {
+			unset["modifiedUserId"] = true
+		}
+		update := s.constructUpdate(set, unset)
+		update["$rename"] = _RestoreArchiveRenames
+		activateUpdateInfo, err = s.C().UpdateAll(query, update)
+	}
+	if err == nil && restored {
+		query, err = s.constructRestoreArchiveQuery(userID, restoreTime)
+	}
+	if err == nil && restored {
+		set := bson.M{
+			"_active":           false,
+			"archivedDatasetId": restoreID,
+			"archivedTime":      timestamp,
+			"_restore.archived": true,
+			"modifiedTime":      timestamp,
+		}
+		unset := bson.M{}
+		if agentUserID != "" {
+			set["modifiedUserId"] = agentUserID
+		} else {
+			unset["modifiedUserId"] = true
+		}
+		archiveUpdateInfo, err = s.C().UpdateAll(query, s.constructUpdate(set, unset))
+	}
+
+	loggerFields := log.Fields{"userId": userID, "restoreId": restoreID, "restoreTime": restoreTime, "restored": restored, "activateUpdateInfo": activateUpdateInfo, "archiveUpdateInfo": archiveUpdateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("RestoreDataForUserByID")
+
+	if err != nil {
+		return false, errors.Wrap(err, "mongo", "unable to restore data for user by id")
+	}
+	return restored, nil
+}
+
+func (s *Session) UndoRestoreDataForUserByID(userID string, restoreID string) error {
+	if userID == "" {
+		return errors.New("mongo", "user id is missing")
+	}
+	if restoreID == "" {
+		return errors.New("mongo", "restore id is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	agentUserID := s.AgentUserID()
+
+	var activateUpdateInfo *mgo.ChangeInfo
+	var archiveUpdateInfo *mgo.ChangeInfo
+
+	selector := bson.M{
+		"_userId":           userID,
+		"type":              bson.M{"$ne": "upload"},
+		"archivedDatasetId": restoreID,
+	}
+	set := bson.M{
+		"_active":      true,
+		"modifiedTime": timestamp,
+	}
+	unset := bson.M{
+		"archivedDatasetId": true,
+		"archivedTime":      true,
+		"_restore":          true,
+	}
+	if agentUserID != "" {
+		set["modifiedUserId"] = agentUserID
+	} else {
+		unset["modifiedUserId"] = true
+	}
+	activateUpdateInfo, err := s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+	if err == nil {
+		selector = bson.M{
+			"_userId":     userID,
+			"type":        bson.M{"$ne": "upload"},
+			"_restore.id": restoreID,
+		}
+		set = bson.M{
+			"_active":      false,
+			"modifiedTime": timestamp,
+		}
+		unset = bson.M{}
+		if agentUserID != "" {
+			set["modifiedUserId"] = agentUserID
+		} else {
+			unset["modifiedUserId"] = true
+		}
+		update := s.constructUpdate(set, unset)
+		update["$rename"] = _RestoreArchiveUndoRenames
+		if archiveUpdateInfo, err = s.C().UpdateAll(selector, update); err == nil {
+			_, err = s.C().UpdateAll(selector, bson.M{"$unset": bson.M{"_restore": true}})
+		}
+	}
+
+	loggerFields := log.Fields{"userId": userID, "restoreId": restoreID, "activateUpdateInfo": activateUpdateInfo, "archiveUpdateInfo": archiveUpdateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("UndoRestoreDataForUserByID")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to undo restore data for user by id")
+	}
+	return nil
+}
+
+func (s *Session) PreviewRestoreDataForUserByID(userID string, restoreTime time.Time) (*store.DataRestorePreview, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+	if restoreTime.IsZero() {
+		return nil, errors.New("mongo", "restore time is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	preview := store.NewDataRestorePreview()
+
+	query, err := s.constructRestoreActivateQuery(userID, restoreTime)
+	if err == nil {
+		preview.Activate, err = s.previewData(query)
+	}
+	if err == nil {
+		query, err = s.constructRestoreArchiveQuery(userID, restoreTime)
+	}
+	if err == nil {
+		preview.Archive, err = s.previewData(query)
+	}
+
+	loggerFields := log.Fields{"userId": userID, "restoreTime": restoreTime, "preview": preview, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("PreviewRestoreDataForUserByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to preview restore data for user by id")
+	}
+	return preview, nil
+}
+
+func (s *Session) DestroyDataForUserByID(userID string) error {
+	if userID == "" {
+		return errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	var chunksRemoveInfo *mgo.ChangeInfo
+	var removeInfo *mgo.ChangeInfo
+
+	selector := bson.M{
+		"_userId": userID,
+	}
+	datasetIDs, err := s.getDatasetIDs(selector)
+	if err == nil {
+		chunksRemoveInfo, err = s.removeDatasetChunks(datasetIDs)
+	}
+	if err == nil {
+		removeInfo, err = s.C().RemoveAll(selector)
+	}
+
+	loggerFields := log.Fields{"userId": userID, "removeInfo": removeInfo, "chunksRemoveInfo": chunksRemoveInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("DestroyDataForUserByID")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to destroy data for user by id")
+	}
+
+	return nil
+}
+
+func (s *Session) DestroyDeletedData(deletedBefore time.Time) error {
+	if deletedBefore.IsZero() {
+		return errors.New("mongo", "deleted before time is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	var retainedUpdateInfo *mgo.ChangeInfo
+	var chunksRemoveInfo *mgo.ChangeInfo
+	var removeInfo *mgo.ChangeInfo
+
+	// Only data soft deleted, and not data deleted before soft deletion, is destroyed, along with the chunks
+	// of, and the archives retained by, destroyed datasets
+	selector := bson.M{
+		"deletedTime":  bson.M{"$lt": deletedBefore.UTC().Format(time.RFC3339)},
+		"_softDeleted": true,
+	}
+	datasetIDs, err := s.getDatasetIDs(selector)
+	if err == nil {
+		chunksRemoveInfo, err = s.removeDatasetChunks(datasetIDs)
+	}
+	if err == nil && len(datasetIDs) > 0 {
+		retainedUpdateInfo, err = s.C().UpdateAll(bson.M{"_retainedArchive.datasetId": bson.M{"$in": datasetIDs}}, bson.M{"$unset": bson.M{"_retainedArchive": true}})
+	}
+	if err == nil {
+		removeInfo, err = s.C().RemoveAll(selector)
+	}
+
+	loggerFields := log.Fields{"deletedBefore": deletedBefore, "removeInfo": removeInfo, "chunksRemoveInfo": chunksRemoveInfo, "retainedUpdateInfo": retainedUpdateInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("DestroyDeletedData")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to destroy deleted data")
+	}
+
+	return nil
+}
+
+func (s *Session) validateDataset(dataset *upload.Upload) error {
+	if dataset == nil {
+		return errors.New("mongo", "dataset is missing")
+	}
+	if dataset.UserID == "" {
+		return errors.New("mongo", "dataset user id is missing")
+	}
+	if dataset.GroupID == "" {
+		return errors.New("mongo", "dataset group id is missing")
+	}
+	if dataset.UploadID == "" {
+		return errors.New("mongo", "dataset upload id is missing")
+	}
+	if dataset.DeviceID == nil || *dataset.DeviceID == "" {
+		return errors.New("mongo", "dataset device id is missing")
+	}
+
+	return nil
+}
+
+func constructDatasetStateQuery(state string) []bson.M {
+	query := []bson.M{
+		{"_state": state},
+		{"_state": bson.M{"$exists": false}, "_dataState": state}, // TODO: Deprecated DataState (after data migration)
+	}
+	if state == upload.StateOpen {
+		query = append(query, bson.M{"_state": bson.M{"$exists": false}, "_dataState": bson.M{"$exists": false}})
+	}
+	return query
+}
+
+func (s *Session) chunksC() *mgo.Collection {
+	collection := s.C()
+	return collection.Database.C(collection.Name + "Chunks")
+}
+
+// Removes the chunks of the datasets matching the selector, which must be done before the datasets are removed
+func (s *Session) getDatasetIDs(selector bson.M) ([]string, error) {
+	datasetSelector := bson.M{"type": "upload"}
+	for key, value := range selector {
+		datasetSelector[key] = value
 	}
-	pipe := s.C().Pipe(pipeline)
-	iter := pipe.Iter()
 
-	var overallUpdateInfo mgo.ChangeInfo
-	var overallErr error
+	datasetIDs := []string{}
+	if err := s.C().Find(datasetSelector).Distinct("uploadId", &datasetIDs); err != nil {
+		return nil, err
+	}
+	return datasetIDs, nil
+}
 
-	result := struct {
-		ID struct {
-			Active            bool   `bson:"_active"`
-			ArchivedDatasetID string `bson:"archivedDatasetId"`
-			ArchivedTime      string `bson:"archivedTime"`
-		} `bson:"_id"`
-		ArchivedHashes []string `bson:"archivedHashes"`
-	}{}
-	for iter.Next(&result) {
-		if result.ID.Active != (result.ID.ArchivedDatasetID == "") || result.ID.Active != (result.ID.ArchivedTime == "") {
-			loggerFields := log.Fields{"datasetId": dataset.UploadID, "result": result}
-			s.Logger().WithFields(loggerFields).Error("Unexpected pipe result for UnarchiveDeviceDataUsingHashesFromDataset")
-			continue
+func (s *Session) removeDatasetChunks(datasetIDs []string) (*mgo.ChangeInfo, error) {
+	if len(datasetIDs) == 0 {
+		return &mgo.ChangeInfo{}, nil
+	}
+
+	return s.chunksC().RemoveAll(bson.M{"uploadId": bson.M{"$in": datasetIDs}})
+}
+
+func datasetChunkID(dataset *upload.Upload, chunkID string) string {
+	return dataset.UploadID + ":" + chunkID
+}
+
+func (s *Session) constructDataQuery(userID string, filter *store.DataFilter) (bson.M, error) {
+	typeQuery := bson.M{"$ne": "upload"}
+	if len(filter.Types) > 0 {
+		typeQuery["$in"] = filter.Types
+	}
+
+	query := bson.M{
+		"_userId": userID,
+		"_active": true,
+		"type":    typeQuery,
+	}
+	if len(filter.SubTypes) > 0 {
+		query["subType"] = bson.M{"$in": filter.SubTypes}
+	}
+	if filter.DeviceID != "" {
+		query["deviceId"] = filter.DeviceID
+	}
+	if filter.UploadID != "" {
+		query["uploadId"] = filter.UploadID
+	}
+	if filter.StartTime != nil || filter.EndTime != nil {
+		return s.constructTimeRangeQuery(query, "time", filter.StartTime, filter.EndTime)
+	}
+
+	return query, nil
+}
+
+// Times are stored as RFC3339 strings that may include a time zone offset and fractional seconds, so they do
+// not compare correctly as strings against a UTC time. So, the times are compared as strings only to the second
+// and widened by the maximum time zone offset, and any datum with a time within the maximum time zone offset of
+// either end of the range is parsed and compared exactly, and excluded if not within the range.
+func (s *Session) constructTimeRangeQuery(query bson.M, field string, startTime *time.Time, endTime *time.Time) (bson.M, error) {
+	timeQuery := bson.M{}
+	boundaryQueries := []bson.M{}
+	if startTime != nil {
+		startSecond := startTime.UTC().Truncate(time.Second)
+		timeQuery["$gte"] = startSecond.Add(-_TimeZoneOffsetMaximum).Format(_TimeSecondFormat)
+		boundaryQueries = append(boundaryQueries, bson.M{field: bson.M{"$lt": startSecond.Add(_TimeZoneOffsetMaximum + time.Second).Format(_TimeSecondFormat)}})
+	}
+	if endTime != nil {
+		endSecond := endTime.UTC().Truncate(time.Second)
+		timeQuery["$lt"] = endSecond.Add(_TimeZoneOffsetMaximum + time.Second).Format(_TimeSecondFormat)
+		boundaryQueries = append(boundaryQueries, bson.M{field: bson.M{"$gte": endSecond.Add(-_TimeZoneOffsetMaximum).Format(_TimeSecondFormat)}})
+	}
+	query[field] = timeQuery
+
+	excludedIDs := []string{}
+
+	iter := s.C().Find(bson.M{"$and": []bson.M{query, {"$or": boundaryQueries}}}).Select(bson.M{"id": 1, field: 1}).Iter()
+	for {
+		var result bson.M
+		if !iter.Next(&result) {
+			break
 		}
 
-		selector := bson.M{
-			"_userId":            dataset.UserID,
-			"deviceId":           dataset.DeviceID,
-			"archivedDatasetId":  dataset.UploadID,
-			"_deduplicator.hash": bson.M{"$in": result.ArchivedHashes},
+		fieldTime, ok := parseStoredTime(result[field])
+		if !ok || (startTime != nil && fieldTime.Before(*startTime)) || (endTime != nil && !fieldTime.Before(*endTime)) {
+			if id, ok := result["id"].(string); ok {
+				excludedIDs = append(excludedIDs, id)
+			}
 		}
-		set := bson.M{
-			"_active":      result.ID.Active,
-			"modifiedTime": timestamp,
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	if len(excludedIDs) > 0 {
+		query["id"] = bson.M{"$nin": excludedIDs}
+	}
+
+	return query, nil
+}
+
+// The cursor is compared against the time field as a string, not as a time, since the data is sorted by the time
+// field as a string. While the sort order of times with differing time zone offsets or fractional seconds is then
+// not strictly chronological, every datum is returned exactly once when paging with the cursor.
+func (s *Session) findWithPagination(query bson.M, timeField string, pagination *store.Pagination) *mgo.Query {
+	if pagination.Cursor != nil {
+		query = bson.M{"$and": []bson.M{query, {"$or": []bson.M{
+			{timeField: bson.M{"$lt": pagination.Cursor.Time}},
+			{timeField: pagination.Cursor.Time, "id": bson.M{"$lt": pagination.Cursor.ID}},
+		}}}}
+	}
+
+	find := s.C().Find(query).Sort("-"+timeField, "-id")
+	if pagination.Cursor == nil {
+		find = find.Skip(pagination.Page * pagination.Size)
+	}
+
+	return find.Limit(pagination.Size)
+}
+
+// The time window of each type is bounded by the earliest and latest times of the dataset data of that type. The
+// times are parsed, rather than compared as strings, since they may have differing time zone offsets or fractional
+// seconds, and the other device data within each time window is selected exactly.
+func (s *Session) constructTimeWindowQuery(dataset *upload.Upload) (bson.M, error) {
+	type timeWindow struct {
+		minimumTime time.Time
+		maximumTime time.Time
+	}
+
+	timeWindows := map[string]*timeWindow{}
+
+	iter := s.C().Find(bson.M{"uploadId": dataset.UploadID, "type": bson.M{"$ne": "upload"}, "time": bson.M{"$exists": true}}).Select(bson.M{"type": 1, "time": 1}).Iter()
+	for {
+		var result bson.M
+		if !iter.Next(&result) {
+			break
 		}
-		unset := bson.M{}
-		if agentUserID != "" {
-			set["modifiedUserId"] = agentUserID
-		} else {
-			unset["modifiedUserId"] = true
+
+		datumType, ok := result["type"].(string)
+		if !ok {
+			continue
 		}
-		if result.ID.Active {
-			unset["archivedDatasetId"] = true
-			unset["archivedTime"] = true
-		} else {
-			set["archivedDatasetId"] = result.ID.ArchivedDatasetID
-			set["archivedTime"] = result.ID.ArchivedTime
+		datumTime, ok := parseStoredTime(result["time"])
+		if !ok {
+			continue
 		}
-		updateInfo, err := s.C().UpdateAll(selector, s.constructUpdate(set, unset))
-		if err != nil {
-			loggerFields := log.Fields{"datasetId": dataset.UploadID, "result": result}
-			s.Logger().WithFields(loggerFields).WithError(err).Error("Unable to update result for UnarchiveDeviceDataUsingHashesFromDataset")
-			if overallErr == nil {
-				overallErr = errors.Wrap(err, "mongo", "unable to transfer device data active")
-			}
-		} else {
-			overallUpdateInfo.Updated += updateInfo.Updated
-			overallUpdateInfo.Removed += updateInfo.Removed
+
+		if window, ok := timeWindows[datumType]; !ok {
+			timeWindows[datumType] = &timeWindow{minimumTime: datumTime, maximumTime: datumTime}
+		} else if datumTime.Before(window.minimumTime) {
+			window.minimumTime = datumTime
+		} else if datumTime.After(window.maximumTime) {
+			window.maximumTime = datumTime
 		}
 	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	} else if len(timeWindows) == 0 {
+		return nil, nil
+	}
 
-	if err := iter.Err(); err != nil {
-		if overallErr == nil {
-			overallErr = errors.Wrap(err, "mongo", "unable to iterate to transfer device data active")
+	datumTypes := []string{}
+	for datumType := range timeWindows {
+		datumTypes = append(datumTypes, datumType)
+	}
+	sort.Strings(datumTypes)
+
+	windows := []bson.M{}
+	for _, datumType := range datumTypes {
+		window := timeWindows[datumType]
+		endTime := window.maximumTime.Add(time.Nanosecond)
+
+		windowQuery, err := s.constructTimeRangeQuery(bson.M{
+			"_userId":  dataset.UserID,
+			"_groupId": dataset.GroupID,
+			"deviceId": *dataset.DeviceID,
+			"uploadId": bson.M{"$ne": dataset.UploadID},
+			"type":     datumType,
+		}, "time", &window.minimumTime, &endTime)
+		if err != nil {
+			return nil, err
+		}
+
+		windowSelector := bson.M{
+			"type": datumType,
+			"time": windowQuery["time"],
 		}
+		if excludedIDs, ok := windowQuery["id"]; ok {
+			windowSelector["id"] = excludedIDs
+		}
+		windows = append(windows, windowSelector)
 	}
 
-	loggerFields := log.Fields{"datasetId": dataset.UploadID, "updateInfo": overallUpdateInfo, "duration": time.Since(startTime) / time.Microsecond}
-	s.Logger().WithFields(loggerFields).WithError(overallErr).Debug("UnarchiveDeviceDataUsingHashesFromDataset")
+	return bson.M{
+		"_userId":  dataset.UserID,
+		"_groupId": dataset.GroupID,
+		"deviceId": *dataset.DeviceID,
+		"uploadId": bson.M{"$ne": dataset.UploadID},
+		"_active":  true,
+		"$or":      windows,
+	}, nil
+}
 
-	return overallErr
+type fuzzyHashDatum struct {
+	ID           string `bson:"id"`
+	DeviceTime   string `bson:"deviceTime"`
+	Deduplicator struct {
+		FuzzyHash string `bson:"fuzzyHash"`
+	} `bson:"_deduplicator"`
 }
 
-func (s *Session) DeleteOtherDatasetData(dataset *upload.Upload) error {
-	if err := s.validateDataset(dataset); err != nil {
-		return err
+type fuzzyHashDeviceTime struct {
+	deviceTime time.Time
+	id         string
+}
+
+func (s *Session) findFuzzyHashFolds(dataset *upload.Upload, deviceTimeTolerance time.Duration, reopened bool) (map[string]string, error) {
+	if deviceTimeTolerance <= 0 {
+		return nil, errors.New("mongo", "device time tolerance is invalid")
 	}
 
-	if s.IsClosed() {
-		return errors.New("mongo", "session closed")
+	selection := bson.M{"id": 1, "deviceTime": 1, "_deduplicator.fuzzyHash": 1}
+
+	var datasetData []fuzzyHashDatum
+	query := bson.M{
+		"uploadId":                dataset.UploadID,
+		"type":                    bson.M{"$ne": "upload"},
+		"_deduplicator.fuzzyHash": bson.M{"$exists": true},
+	}
+	if err := s.C().Find(query).Select(selection).All(&datasetData); err != nil {
+		return nil, err
+	} else if len(datasetData) == 0 {
+		return nil, nil
 	}
 
-	startTime := time.Now()
+	var minimumDeviceTime time.Time
+	var maximumDeviceTime time.Time
+	var datasetDataInvalidDeviceTimeCount int
 
-	timestamp := s.Timestamp()
-	agentUserID := s.AgentUserID()
+	datasetDeviceTimes := map[string][]fuzzyHashDeviceTime{}
+	for _, datasetDatum := range datasetData {
+		deviceTime, err := time.Parse(_FuzzyHashDeviceTimeFormat, datasetDatum.DeviceTime)
+		if err != nil {
+			datasetDataInvalidDeviceTimeCount++
+			continue
+		}
+		if minimumDeviceTime.IsZero() || deviceTime.Before(minimumDeviceTime) {
+			minimumDeviceTime = deviceTime
+		}
+		if maximumDeviceTime.IsZero() || deviceTime.After(maximumDeviceTime) {
+			maximumDeviceTime = deviceTime
+		}
+		datasetDeviceTimes[datasetDatum.Deduplicator.FuzzyHash] = append(datasetDeviceTimes[datasetDatum.Deduplicator.FuzzyHash], fuzzyHashDeviceTime{deviceTime: deviceTime, id: datasetDatum.ID})
+	}
 
-	var err error
-	var removeInfo *mgo.ChangeInfo
-	var updateInfo *mgo.ChangeInfo
+	if datasetDataInvalidDeviceTimeCount > 0 {
+		s.Logger().WithFields(log.Fields{"datasetId": dataset.UploadID, "invalidDeviceTimeCount": datasetDataInvalidDeviceTimeCount}).Warn("Unable to parse device time of dataset data for fuzzy hash folds")
+	}
 
-	selector := bson.M{
+	if len(datasetDeviceTimes) == 0 {
+		return nil, nil
+	}
+
+	hashes := []string{}
+	for hash, deviceTimes := range datasetDeviceTimes {
+		sort.Slice(deviceTimes, func(i int, j int) bool {
+			if deviceTimes[i].deviceTime.Equal(deviceTimes[j].deviceTime) {
+				return deviceTimes[i].id < deviceTimes[j].id
+			}
+			return deviceTimes[i].deviceTime.Before(deviceTimes[j].deviceTime)
+		})
+		hashes = append(hashes, hash)
+	}
+
+	// The device time format sorts lexically, so only device data within the tolerance of the
+	// dataset data device time range need be considered
+	query = bson.M{
 		"_userId":  dataset.UserID,
 		"_groupId": dataset.GroupID,
 		"deviceId": *dataset.DeviceID,
 		"uploadId": bson.M{"$ne": dataset.UploadID},
 		"type":     bson.M{"$ne": "upload"},
+		"_active":  true,
+		"deviceTime": bson.M{
+			"$gte": minimumDeviceTime.Add(-deviceTimeTolerance).Format(_FuzzyHashDeviceTimeFormat),
+			"$lte": maximumDeviceTime.Add(deviceTimeTolerance).Format(_FuzzyHashDeviceTimeFormat),
+		},
 	}
-	removeInfo, err = s.C().RemoveAll(selector)
-	if err == nil {
-		selector = bson.M{
-			"_userId":       dataset.UserID,
-			"_groupId":      dataset.GroupID,
-			"deviceId":      *dataset.DeviceID,
-			"uploadId":      bson.M{"$ne": dataset.UploadID},
-			"type":          "upload",
-			"deletedTime":   bson.M{"$exists": false},
-			"deletedUserId": bson.M{"$exists": false},
+	if reopened {
+		query = constructReopenedDatasetQuery(dataset, query)
+	}
+
+	missingFuzzyHashQuery := bson.M{"$and": []bson.M{query, {"_deduplicator.fuzzyHash": bson.M{"$exists": false}}}}
+	if missingFuzzyHashCount, err := s.C().Find(missingFuzzyHashQuery).Count(); err != nil {
+		return nil, err
+	} else if missingFuzzyHashCount > 0 {
+		s.Logger().WithFields(log.Fields{"datasetId": dataset.UploadID, "missingFuzzyHashCount": missingFuzzyHashCount}).Warn("Device data without fuzzy hash not considered for fuzzy hash folds")
+	}
+
+	query = bson.M{"$and": []bson.M{query, {"_deduplicator.fuzzyHash": bson.M{"$in": hashes}}}}
+
+	var deviceDataInvalidDeviceTimeCount int
+
+	type fuzzyHashFoldCandidate struct {
+		id           string
+		foldedIntoID string
+		delta        time.Duration
+	}
+
+	candidates := []fuzzyHashFoldCandidate{}
+	iter := s.C().Find(query).Select(selection).Iter()
+
+	for {
+		var deviceDatum fuzzyHashDatum
+		if !iter.Next(&deviceDatum) {
+			break
 		}
-		set := bson.M{
-			"deletedTime": timestamp,
+
+		deviceTime, err := time.Parse(_FuzzyHashDeviceTimeFormat, deviceDatum.DeviceTime)
+		if err != nil {
+			deviceDataInvalidDeviceTimeCount++
+			continue
 		}
-		unset := bson.M{}
-		if agentUserID != "" {
-			set["deletedUserId"] = agentUserID
-		} else {
-			unset["deletedUserId"] = true
+
+		deviceTimes := datasetDeviceTimes[deviceDatum.Deduplicator.FuzzyHash]
+		index := sort.Search(len(deviceTimes), func(index int) bool {
+			return !deviceTimes[index].deviceTime.Before(deviceTime.Add(-deviceTimeTolerance))
+		})
+
+		for ; index < len(deviceTimes) && !deviceTimes[index].deviceTime.After(deviceTime.Add(deviceTimeTolerance)); index++ {
+			delta := deviceTime.Sub(deviceTimes[index].deviceTime)
+			if delta < 0 {
+				delta = -delta
+			}
+			candidates = append(candidates, fuzzyHashFoldCandidate{id: deviceDatum.ID, foldedIntoID: deviceTimes[index].id, delta: delta})
 		}
-		updateInfo, err = s.C().UpdateAll(selector, s.constructUpdate(set, unset))
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
 	}
 
-	loggerFields := log.Fields{"datasetId": dataset.UploadID, "removeInfo": removeInfo, "updateInfo": updateInfo, "duration": time.Since(startTime) / time.Microsecond}
-	s.Logger().WithFields(loggerFields).WithError(err).Debug("DeleteOtherDatasetData")
+	if deviceDataInvalidDeviceTimeCount > 0 {
+		s.Logger().WithFields(log.Fields{"datasetId": dataset.UploadID, "invalidDeviceTimeCount": deviceDataInvalidDeviceTimeCount}).Warn("Unable to parse device time of device data for fuzzy hash folds")
+	}
 
-	if err != nil {
-		return errors.Wrap(err, "mongo", "unable to remove other dataset data")
+	// Each device datum folds into at most one dataset datum, and each dataset datum is folded into by at most
+	// one device datum, so two distinct events with the same fuzzy hash within the tolerance are not both folded
+	// into one. The closest candidates are folded first, with ties broken by id for a deterministic result.
+	sort.Slice(candidates, func(i int, j int) bool {
+		if candidates[i].delta != candidates[j].delta {
+			return candidates[i].delta < candidates[j].delta
+		}
+		if candidates[i].id != candidates[j].id {
+			return candidates[i].id < candidates[j].id
+		}
+		return candidates[i].foldedIntoID < candidates[j].foldedIntoID
+	})
+
+	folds := map[string]string{}
+	foldedInto := map[string]bool{}
+	for _, candidate := range candidates {
+		if _, ok := folds[candidate.id]; ok || foldedInto[candidate.foldedIntoID] {
+			continue
+		}
+		folds[candidate.id] = candidate.foldedIntoID
+		foldedInto[candidate.foldedIntoID] = true
 	}
-	return nil
+
+	return folds, nil
 }
 
-func (s *Session) DestroyDataForUserByID(userID string) error {
-	if userID == "" {
-		return errors.New("mongo", "user id is missing")
+// A preview considers the device data as if the dataset were reopened, that is, with the dataset data inactive
+// and the device data archived by the dataset active, so the preview reflects what deduplicating the dataset
+// would do whether or not the dataset was already deduplicated
+func constructReopenedDatasetQuery(dataset *upload.Upload, query bson.M) bson.M {
+	activeQuery := bson.M{}
+	for key, value := range query {
+		if key != "_active" {
+			activeQuery[key] = value
+		}
+	}
+	return bson.M{
+		"$and": []bson.M{
+			activeQuery,
+			{"$or": []bson.M{{"_active": true}, {"archivedDatasetId": dataset.UploadID}}},
+		},
 	}
+}
 
-	if s.IsClosed() {
-		return errors.New("mongo", "session closed")
+func (s *Session) previewData(query bson.M) (*store.DataPreview, error) {
+	count, err := s.C().Find(query).Count()
+	if err != nil {
+		return nil, err
 	}
 
-	startTime := time.Now()
+	var results []struct {
+		ID string `bson:"id"`
+	}
+	if err = s.C().Find(query).Select(bson.M{"id": 1}).Sort("_id").Limit(store.DataPreviewSampleSize).All(&results); err != nil {
+		return nil, err
+	}
 
-	selector := bson.M{
-		"_userId": userID,
+	preview := store.NewDataPreview()
+	preview.Count = count
+	for _, result := range results {
+		preview.SampleIDs = append(preview.SampleIDs, result.ID)
 	}
-	removeInfo, err := s.C().RemoveAll(selector)
+	return preview, nil
+}
 
-	loggerFields := log.Fields{"userId": userID, "removeInfo": removeInfo, "duration": time.Since(startTime) / time.Microsecond}
-	s.Logger().WithFields(loggerFields).WithError(err).Debug("DestroyDataForUserByID")
+var _RetainedArchiveRenames = bson.M{
+	"_retainedArchive.archivedTime": "archivedTime",
+	"_retainedArchive.foldedIntoId": "_deduplicator.foldedIntoId",
+}
 
-	if err != nil {
-		return errors.Wrap(err, "mongo", "unable to destroy data for user by id")
+var _RestoreArchiveRenames = bson.M{
+	"archivedDatasetId":          "_restore.archivedDatasetId",
+	"archivedTime":               "_restore.archivedTime",
+	"_deduplicator.foldedIntoId": "_restore.foldedIntoId",
+}
+
+var _RestoreArchiveUndoRenames = bson.M{
+	"_restore.archivedDatasetId": "archivedDatasetId",
+	"_restore.archivedTime":      "archivedTime",
+	"_restore.foldedIntoId":      "_deduplicator.foldedIntoId",
+}
+
+func (s *Session) constructRestoreActivateQuery(userID string, restoreTime time.Time) (bson.M, error) {
+	query := bson.M{
+		"_userId":     userID,
+		"type":        bson.M{"$ne": "upload"},
+		"_active":     false,
+		"deletedTime": bson.M{"$exists": false},
 	}
+	return s.constructRestoreTimeQuery(query, restoreTime, []string{"archivedTime"}, []string{"createdTime"})
+}
 
-	return nil
+func (s *Session) constructRestoreArchiveQuery(userID string, restoreTime time.Time) (bson.M, error) {
+	query := bson.M{
+		"_userId":     userID,
+		"type":        bson.M{"$ne": "upload"},
+		"_active":     true,
+		"deletedTime": bson.M{"$exists": false},
+	}
+	return s.constructRestoreTimeQuery(query, restoreTime, []string{"createdTime"}, nil)
 }
 
-func (s *Session) validateDataset(dataset *upload.Upload) error {
-	if dataset == nil {
-		return errors.New("mongo", "dataset is missing")
+// Times are stored as RFC3339 strings, but legacy times may include fractional seconds, which do not compare
+// correctly as strings with times without (for example, "12:00:00.5Z" is less than "12:00:00Z"). So, the
+// times are compared as strings only to the second, and any datum with a time within the same second as the
+// restore time is parsed and compared exactly, and excluded if not after (or at or before) the restore time.
+func (s *Session) constructRestoreTimeQuery(query bson.M, restoreTime time.Time, afterFields []string, notAfterFields []string) (bson.M, error) {
+	restoreTime = restoreTime.UTC()
+	restoreSecond := restoreTime.Truncate(time.Second)
+	lowerBound := restoreSecond.Format(_TimeSecondFormat)
+	upperBound := restoreSecond.Add(time.Second).Format(_TimeSecondFormat)
+
+	selection := bson.M{"id": 1}
+	boundaryQueries := []bson.M{}
+	for _, field := range afterFields {
+		query[field] = bson.M{"$gte": lowerBound}
+		selection[field] = 1
+		boundaryQueries = append(boundaryQueries, bson.M{field: bson.M{"$lt": upperBound}})
+	}
+	for _, field := range notAfterFields {
+		query[field] = bson.M{"$lt": upperBound}
+		selection[field] = 1
+		boundaryQueries = append(boundaryQueries, bson.M{field: bson.M{"$gte": lowerBound}})
+	}
+
+	excludedIDs := []string{}
+
+	iter := s.C().Find(bson.M{"$and": []bson.M{query, {"$or": boundaryQueries}}}).Select(selection).Iter()
+	for {
+		var result bson.M
+		if !iter.Next(&result) {
+			break
+		}
+
+		excluded := false
+		for _, field := range afterFields {
+			if fieldTime, ok := parseStoredTime(result[field]); !ok || !fieldTime.After(restoreTime) {
+				excluded = true
+			}
+		}
+		for _, field := range notAfterFields {
+			if fieldTime, ok := parseStoredTime(result[field]); !ok || fieldTime.After(restoreTime) {
+				excluded = true
+			}
+		}
+		if excluded {
+			if id, ok := result["id"].(string); ok {
+				excludedIDs = append(excludedIDs, id)
+			}
+		}
 	}
-	if dataset.UserID == "" {
-		return errors.New("mongo", "dataset user id is missing")
+	if err := iter.Close(); err != nil {
+		return nil, err
 	}
-	if dataset.GroupID == "" {
-		return errors.New("mongo", "dataset group id is missing")
+
+	if len(excludedIDs) > 0 {
+		query["id"] = bson.M{"$nin": excludedIDs}
 	}
-	if dataset.UploadID == "" {
-		return errors.New("mongo", "dataset upload id is missing")
+
+	return query, nil
+}
+
+func parseStoredTime(value interface{}) (time.Time, bool) {
+	stringValue, ok := value.(string)
+	if !ok {
+		return time.Time{}, false
 	}
-	if dataset.DeviceID == nil || *dataset.DeviceID == "" {
-		return errors.New("mongo", "dataset device id is missing")
+	timeValue, err := time.Parse(time.RFC3339Nano, stringValue)
+	if err != nil {
+		return time.Time{}, false
 	}
+	return timeValue, true
+}
 
-	return nil
+// Soft deleted data is marked so that only it, and not data deleted before soft deletion, is later destroyed
+func (s *Session) constructDeleteUpdate(timestamp string, agentUserID string, deactivate bool) bson.M {
+	set := bson.M{
+		"deletedTime":  timestamp,
+		"_softDeleted": true,
+	}
+	if deactivate {
+		set["_active"] = false
+	}
+	unset := bson.M{}
+	if agentUserID != "" {
+		set["deletedUserId"] = agentUserID
+	} else {
+		unset["deletedUserId"] = true
+	}
+	return s.constructUpdate(set, unset)
 }
 
 func (s *Session) constructUpdate(set bson.M, unset bson.M) bson.M {
@@ -77,6 +77,14 @@ func NewDataset(userID string, groupID string, deviceID string) *upload.Upload {
 	return dataset
 }
 
+func DatasetDataIDs(datasetData []data.Datum) []string {
+	ids := []string{}
+	for _, datasetDatum := range datasetData {
+		ids = append(ids, datasetDatum.(*types.Base).ID)
+	}
+	return ids
+}
+
 func NewDatasetData(deviceID string) []data.Datum {
 	datasetData := []data.Datum{}
 	for count := 0; count < 3; count++ {
@@ -315,6 +323,34 @@ var _ = Describe("Mongo", func() {
 						Expect(mongoSession.GetDatasetsForUserByID(userID, filter, pagination)).To(ConsistOf([]*upload.Upload{datasetExistingTwo}))
 					})
 
+					It("succeeds if the pagination cursor is specified", func() {
+						pagination.Size = 2
+						pagination.Cursor = store.NewCursor(dataset.CreatedTime, dataset.ID)
+						Expect(mongoSession.GetDatasetsForUserByID(userID, filter, pagination)).To(ConsistOf([]*upload.Upload{datasetExistingTwo}))
+					})
+
+					It("succeeds if the pagination cursor matches the time of another dataset", func() {
+						datasetExistingTwo.CreatedTime = dataset.CreatedTime
+						Expect(testMongoCollection.Update(bson.M{"id": datasetExistingTwo.ID}, bson.M{"$set": bson.M{"createdTime": dataset.CreatedTime}})).To(Succeed())
+						pagination.Cursor = store.NewCursor(datasetExistingOne.CreatedTime, datasetExistingOne.ID)
+						firstDatasets, err := mongoSession.GetDatasetsForUserByID(userID, filter, &store.Pagination{Size: 1, Cursor: pagination.Cursor})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(firstDatasets).To(HaveLen(1))
+						pagination.Cursor = store.NewCursor(firstDatasets[0].CreatedTime, firstDatasets[0].ID)
+						secondDatasets, err := mongoSession.GetDatasetsForUserByID(userID, filter, pagination)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(secondDatasets).To(HaveLen(1))
+						Expect([]string{firstDatasets[0].ID, secondDatasets[0].ID}).To(ConsistOf(dataset.ID, datasetExistingTwo.ID))
+					})
+
+					It("returns an error if the pagination cursor is specified with page", func() {
+						pagination.Page = 1
+						pagination.Cursor = store.NewCursor(dataset.CreatedTime, dataset.ID)
+						resultDatasets, err := mongoSession.GetDatasetsForUserByID(userID, filter, pagination)
+						Expect(err).To(MatchError("mongo: pagination is invalid; store: page is invalid with cursor"))
+						Expect(resultDatasets).To(BeNil())
+					})
+
 					It("succeeds if it successfully does not find another user datasets", func() {
 						resultDatasets, err := mongoSession.GetDatasetsForUserByID(app.NewID(), filter, pagination)
 						Expect(err).ToNot(HaveOccurred())
@@ -403,6 +439,30 @@ var _ = Describe("Mongo", func() {
 					})
 				})
 
+				Context("GetJournaledDatasets", func() {
+					var journaledDataset *upload.Upload
+
+					BeforeEach(func() {
+						dataset.CreatedTime = "2016-09-01T11:00:00Z"
+						Expect(testMongoCollection.Insert(dataset)).To(Succeed())
+						journaledDataset = NewDataset(app.NewID(), app.NewID(), app.NewID())
+						journaledDataset.CreatedTime = "2016-09-01T12:00:00Z"
+						journaledDataset.Journal = upload.NewJournal(upload.JournalOperationDeduplicate, []string{"step-one", "step-two"}, "2016-09-01T13:00:00Z")
+						Expect(testMongoCollection.Insert(journaledDataset)).To(Succeed())
+					})
+
+					It("succeeds if it successfully finds the journaled datasets", func() {
+						Expect(mongoSession.GetJournaledDatasets()).To(Equal([]*upload.Upload{journaledDataset}))
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						resultDatasets, err := mongoSession.GetJournaledDatasets()
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(resultDatasets).To(BeNil())
+					})
+				})
+
 				Context("CreateDataset", func() {
 					It("succeeds if it successfully creates the dataset", func() {
 						Expect(mongoSession.CreateDataset(dataset)).To(Succeed())
@@ -490,9 +550,9 @@ var _ = Describe("Mongo", func() {
 						Expect(testMongoCollection.Insert(dataset)).To(Succeed())
 					})
 
-					Context("with state closed", func() {
+					Context("with device model updated", func() {
 						BeforeEach(func() {
-							dataset.State = "closed"
+							dataset.DeviceModel = app.StringAsPointer("2345")
 						})
 
 						It("succeeds if it successfully updates the dataset", func() {
@@ -537,10 +597,15 @@ var _ = Describe("Mongo", func() {
 							dataset.UploadID = app.NewID()
 							Expect(mongoSession.UpdateDataset(dataset)).To(MatchError("mongo: unable to update dataset; not found"))
 						})
+
+						It("returns an error if the stored dataset state changed concurrently", func() {
+							Expect(testMongoCollection.Update(bson.M{"uploadId": dataset.UploadID}, bson.M{"$set": bson.M{"_state": "closed", "_dataState": "closed"}})).To(Succeed())
+							Expect(mongoSession.UpdateDataset(dataset)).To(MatchError("mongo: unable to update dataset; not found"))
+						})
 					})
 
 					It("sets the modified time", func() {
-						dataset.State = "closed"
+						dataset.DeviceModel = app.StringAsPointer("2345")
 						Expect(mongoSession.UpdateDataset(dataset)).To(Succeed())
 						Expect(dataset.ModifiedTime).ToNot(BeEmpty())
 						Expect(dataset.ModifiedUserID).To(BeEmpty())
@@ -549,7 +614,7 @@ var _ = Describe("Mongo", func() {
 					It("has the correct stored datasets", func() {
 						ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, datasetExistingOther, datasetExistingOne, datasetExistingTwo, dataset)
 						ValidateDataset(testMongoCollection, bson.M{"modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{})
-						dataset.State = "closed"
+						dataset.DeviceModel = app.StringAsPointer("2345")
 						Expect(mongoSession.UpdateDataset(dataset)).To(Succeed())
 						ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, datasetExistingOther, datasetExistingOne, datasetExistingTwo, dataset)
 						ValidateDataset(testMongoCollection, bson.M{"modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{}, dataset)
@@ -564,7 +629,7 @@ var _ = Describe("Mongo", func() {
 						})
 
 						It("sets the modified time and modified user id", func() {
-							dataset.State = "closed"
+							dataset.DeviceModel = app.StringAsPointer("2345")
 							Expect(mongoSession.UpdateDataset(dataset)).To(Succeed())
 							Expect(dataset.ModifiedTime).ToNot(BeEmpty())
 							Expect(dataset.ModifiedUserID).To(Equal(agentUserID))
@@ -573,7 +638,7 @@ var _ = Describe("Mongo", func() {
 						It("has the correct stored datasets", func() {
 							ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, datasetExistingOther, datasetExistingOne, datasetExistingTwo, dataset)
 							ValidateDataset(testMongoCollection, bson.M{"modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{})
-							dataset.State = "closed"
+							dataset.DeviceModel = app.StringAsPointer("2345")
 							Expect(mongoSession.UpdateDataset(dataset)).To(Succeed())
 							ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, datasetExistingOther, datasetExistingOne, datasetExistingTwo, dataset)
 							ValidateDataset(testMongoCollection, bson.M{"modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{}, dataset)
@@ -581,6 +646,157 @@ var _ = Describe("Mongo", func() {
 					})
 				})
 
+				Context("UpdateDatasetJournal", func() {
+					BeforeEach(func() {
+						dataset.CreatedTime = "2016-09-01T11:00:00Z"
+						Expect(testMongoCollection.Insert(dataset)).To(Succeed())
+						dataset.Journal = upload.NewJournal(upload.JournalOperationDeduplicate, []string{"one", "two"}, "2016-09-01T12:00:00Z")
+					})
+
+					It("returns an error if the dataset is missing", func() {
+						Expect(mongoSession.UpdateDatasetJournal(nil)).To(MatchError("mongo: dataset is missing"))
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						Expect(mongoSession.UpdateDatasetJournal(dataset)).To(MatchError("mongo: session closed"))
+					})
+
+					It("returns an error if the dataset does not yet exist", func() {
+						dataset.UploadID = app.NewID()
+						Expect(mongoSession.UpdateDatasetJournal(dataset)).To(MatchError("mongo: unable to update dataset journal; not found"))
+					})
+
+					It("sets the journal and modified time", func() {
+						Expect(mongoSession.UpdateDatasetJournal(dataset)).To(Succeed())
+						Expect(dataset.ModifiedTime).ToNot(BeEmpty())
+						ValidateDataset(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_journal.completedSteps": 0, "modifiedTime": bson.M{"$exists": true}}, bson.M{}, dataset)
+					})
+
+					It("does not overwrite the state transitions stored concurrently", func() {
+						Expect(testMongoCollection.Update(bson.M{"uploadId": dataset.UploadID}, bson.M{"$set": bson.M{"_state": "closed", "_dataState": "closed"}})).To(Succeed())
+						Expect(mongoSession.UpdateDatasetJournal(dataset)).To(Succeed())
+						dataset.State = "closed"
+						dataset.DataState = "closed"
+						ValidateDataset(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_state": "closed", "_journal": bson.M{"$exists": true}}, bson.M{}, dataset)
+					})
+
+					It("removes the journal if the journal is nil", func() {
+						Expect(mongoSession.UpdateDatasetJournal(dataset)).To(Succeed())
+						dataset.Journal = nil
+						Expect(mongoSession.UpdateDatasetJournal(dataset)).To(Succeed())
+						ValidateDataset(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_journal": bson.M{"$exists": false}}, bson.M{}, dataset)
+					})
+				})
+
+				Context("UpdateDatasetRededuplicateJournal", func() {
+					BeforeEach(func() {
+						dataset.CreatedTime = "2016-09-01T11:00:00Z"
+						Expect(testMongoCollection.Insert(dataset)).To(Succeed())
+						dataset.RededuplicateJournal = upload.NewJournal(upload.JournalOperationRededuplicate, []string{"one", "two"}, "2016-09-01T12:00:00Z")
+					})
+
+					It("returns an error if the dataset is missing", func() {
+						Expect(mongoSession.UpdateDatasetRededuplicateJournal(nil)).To(MatchError("mongo: dataset is missing"))
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						Expect(mongoSession.UpdateDatasetRededuplicateJournal(dataset)).To(MatchError("mongo: session closed"))
+					})
+
+					It("returns an error if the dataset does not yet exist", func() {
+						dataset.UploadID = app.NewID()
+						Expect(mongoSession.UpdateDatasetRededuplicateJournal(dataset)).To(MatchError("mongo: unable to update dataset rededuplicate journal; not found"))
+					})
+
+					It("sets the journal and modified time", func() {
+						Expect(mongoSession.UpdateDatasetRededuplicateJournal(dataset)).To(Succeed())
+						Expect(dataset.ModifiedTime).ToNot(BeEmpty())
+						ValidateDataset(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_rededuplicateJournal.completedSteps": 0, "modifiedTime": bson.M{"$exists": true}}, bson.M{}, dataset)
+					})
+
+					It("does not overwrite the state transitions stored concurrently", func() {
+						Expect(testMongoCollection.Update(bson.M{"uploadId": dataset.UploadID}, bson.M{"$set": bson.M{"_state": "closed", "_dataState": "closed"}})).To(Succeed())
+						Expect(mongoSession.UpdateDatasetRededuplicateJournal(dataset)).To(Succeed())
+						dataset.State = "closed"
+						dataset.DataState = "closed"
+						ValidateDataset(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_state": "closed", "_rededuplicateJournal": bson.M{"$exists": true}}, bson.M{}, dataset)
+					})
+
+					It("removes the journal if the journal is nil", func() {
+						Expect(mongoSession.UpdateDatasetRededuplicateJournal(dataset)).To(Succeed())
+						dataset.RededuplicateJournal = nil
+						Expect(mongoSession.UpdateDatasetRededuplicateJournal(dataset)).To(Succeed())
+						ValidateDataset(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_rededuplicateJournal": bson.M{"$exists": false}}, bson.M{}, dataset)
+					})
+				})
+
+				Context("TransitionDatasetState", func() {
+					BeforeEach(func() {
+						dataset.CreatedTime = "2016-09-01T11:00:00Z"
+						Expect(testMongoCollection.Insert(dataset)).To(Succeed())
+					})
+
+					It("returns an error if the dataset is missing", func() {
+						transitioned, err := mongoSession.TransitionDatasetState(nil, "closed", "")
+						Expect(err).To(MatchError("mongo: dataset is missing"))
+						Expect(transitioned).To(BeFalse())
+					})
+
+					It("returns an error if the user id is missing", func() {
+						dataset.UserID = ""
+						transitioned, err := mongoSession.TransitionDatasetState(dataset, "closed", "")
+						Expect(err).To(MatchError("mongo: dataset user id is missing"))
+						Expect(transitioned).To(BeFalse())
+					})
+
+					It("returns an error if the transition is not valid", func() {
+						transitioned, err := mongoSession.TransitionDatasetState(dataset, "open", "")
+						Expect(err).To(MatchError(`mongo: dataset state transition from "open" to "open" is not valid`))
+						Expect(transitioned).To(BeFalse())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						transitioned, err := mongoSession.TransitionDatasetState(dataset, "closed", "")
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(transitioned).To(BeFalse())
+					})
+
+					It("transitions the dataset and records the transition", func() {
+						userID := app.NewID()
+						transitioned, err := mongoSession.TransitionDatasetState(dataset, "closed", userID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(transitioned).To(BeTrue())
+						Expect(dataset.State).To(Equal("closed"))
+						Expect(dataset.ModifiedTime).ToNot(BeEmpty())
+						Expect(dataset.Transitions).To(HaveLen(1))
+						Expect(dataset.Transitions[0].From).To(Equal("open"))
+						Expect(dataset.Transitions[0].To).To(Equal("closed"))
+						Expect(dataset.Transitions[0].UserID).To(Equal(userID))
+						ValidateDataset(testMongoCollection, bson.M{"_state": "closed", "_dataState": "closed", "_transitions.0.to": "closed", "_transitions.0.userId": userID}, bson.M{}, dataset)
+					})
+
+					It("transitions a legacy dataset with only a data state", func() {
+						Expect(testMongoCollection.Update(bson.M{"uploadId": dataset.UploadID}, bson.M{"$unset": bson.M{"_state": 1}})).To(Succeed())
+						dataset.State = ""
+						transitioned, err := mongoSession.TransitionDatasetState(dataset, "closed", "")
+						Expect(err).ToNot(HaveOccurred())
+						Expect(transitioned).To(BeTrue())
+						ValidateDataset(testMongoCollection, bson.M{"_state": "closed", "_dataState": "closed"}, bson.M{}, dataset)
+					})
+
+					It("does not transition the dataset if the stored state changed concurrently", func() {
+						Expect(testMongoCollection.Update(bson.M{"uploadId": dataset.UploadID}, bson.M{"$set": bson.M{"_state": "aborted", "_dataState": "aborted"}})).To(Succeed())
+						transitioned, err := mongoSession.TransitionDatasetState(dataset, "closed", "")
+						Expect(err).ToNot(HaveOccurred())
+						Expect(transitioned).To(BeFalse())
+						Expect(dataset.State).To(Equal("open"))
+						Expect(dataset.Transitions).To(BeEmpty())
+					})
+				})
+
 				Context("with data", func() {
 					var datasetExistingOtherData []data.Datum
 					var datasetExistingOneData []data.Datum
@@ -599,274 +815,427 @@ var _ = Describe("Mongo", func() {
 						datasetData = NewDatasetData(deviceID)
 					})
 
-					Context("DeleteDataset", func() {
+					Context("GetDatasetSummary", func() {
 						BeforeEach(func() {
 							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
 						})
 
-						It("succeeds if it successfully deletes the dataset", func() {
-							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+						It("succeeds if it successfully summarizes the inactive dataset data", func() {
+							summary, err := mongoSession.GetDatasetSummary(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(summary).ToNot(BeNil())
+							Expect(summary.Counts).To(Equal([]*store.DatasetSummaryCount{{Type: "test", Count: 3, ActiveCount: 0, ArchivedCount: 0}}))
+							Expect(summary.ActiveCount).To(Equal(0))
+							Expect(summary.ArchivedCount).To(Equal(0))
+							Expect(summary.EarliestTime).To(Equal(*datasetData[0].(*types.Base).Time))
+							Expect(summary.LatestTime).To(Equal(*datasetData[2].(*types.Base).Time))
+							Expect(summary.Deduplicator).To(BeNil())
 						})
 
-						It("returns an error if the dataset is missing", func() {
-							Expect(mongoSession.DeleteDataset(nil)).To(MatchError("mongo: dataset is missing"))
+						It("succeeds if it successfully summarizes the active dataset data", func() {
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							summary, err := mongoSession.GetDatasetSummary(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(summary.Counts).To(Equal([]*store.DatasetSummaryCount{{Type: "test", Count: 3, ActiveCount: 3, ArchivedCount: 0}}))
+							Expect(summary.ActiveCount).To(Equal(3))
 						})
 
-						It("returns an error if the user id is missing", func() {
-							dataset.UserID = ""
-							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset user id is missing"))
+						It("succeeds if it successfully summarizes the archived dataset data", func() {
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(testMongoCollection.Update(bson.M{"id": datasetExistingOneData[0].(*types.Base).ID}, bson.M{"$set": bson.M{"_active": false, "archivedDatasetId": dataset.UploadID}})).To(Succeed())
+							summary, err := mongoSession.GetDatasetSummary(datasetExistingOne)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(summary.Counts).To(Equal([]*store.DatasetSummaryCount{{Type: "test", Count: 3, ActiveCount: 2, ArchivedCount: 1}}))
+							Expect(summary.ActiveCount).To(Equal(2))
+							Expect(summary.ArchivedCount).To(Equal(1))
 						})
 
-						It("returns an error if the group id is missing", func() {
-							dataset.GroupID = ""
-							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset group id is missing"))
+						It("succeeds if the dataset is registered with a deduplicator", func() {
+							dataset.Deduplicator = &data.DeduplicatorDescriptor{Name: "test-deduplicator", Version: "1.2.3", Hash: "test-hash"}
+							summary, err := mongoSession.GetDatasetSummary(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(summary.Deduplicator).To(Equal(&store.DatasetSummaryDeduplicator{Name: "test-deduplicator", Version: "1.2.3"}))
+						})
+
+						It("succeeds if the dataset has no data", func() {
+							summary, err := mongoSession.GetDatasetSummary(NewDataset(userID, groupID, deviceID))
+							Expect(err).ToNot(HaveOccurred())
+							Expect(summary).To(Equal(store.NewDatasetSummary()))
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							summary, err := mongoSession.GetDatasetSummary(nil)
+							Expect(err).To(MatchError("mongo: dataset is missing"))
+							Expect(summary).To(BeNil())
 						})
 
 						It("returns an error if the upload id is missing", func() {
 							dataset.UploadID = ""
-							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+							summary, err := mongoSession.GetDatasetSummary(dataset)
+							Expect(err).To(MatchError("mongo: dataset upload id is missing"))
+							Expect(summary).To(BeNil())
 						})
 
-						It("returns an error if the device id is missing (nil)", func() {
-							dataset.DeviceID = nil
-							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							summary, err := mongoSession.GetDatasetSummary(dataset)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(summary).To(BeNil())
 						})
+					})
 
-						It("returns an error if the device id is missing (empty)", func() {
-							dataset.DeviceID = app.StringAsPointer("")
-							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+					Context("GetDatasetLatestTime", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully gets the latest time of the dataset data", func() {
+							latestTime, err := mongoSession.GetDatasetLatestTime(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(latestTime).ToNot(BeNil())
+							Expect(latestTime.Format(time.RFC3339)).To(Equal(*datasetData[2].(*types.Base).Time))
+						})
+
+						It("succeeds if it successfully compares the times of the dataset data with offsets", func() {
+							Expect(testMongoCollection.Update(bson.M{"id": datasetData[0].(*types.Base).ID}, bson.M{"$set": bson.M{"time": "2016-09-01T00:00:00-08:00"}})).To(Succeed())
+							Expect(testMongoCollection.Update(bson.M{"id": datasetData[1].(*types.Base).ID}, bson.M{"$set": bson.M{"time": "2016-09-01T04:00:00Z"}})).To(Succeed())
+							Expect(testMongoCollection.Update(bson.M{"id": datasetData[2].(*types.Base).ID}, bson.M{"$set": bson.M{"time": "2016-09-01T06:00:00+02:00"}})).To(Succeed())
+							latestTime, err := mongoSession.GetDatasetLatestTime(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(latestTime).ToNot(BeNil())
+							Expect(latestTime.UTC().Format(time.RFC3339)).To(Equal("2016-09-01T08:00:00Z"))
+						})
+
+						It("succeeds if the dataset has no data", func() {
+							Expect(mongoSession.GetDatasetLatestTime(NewDataset(userID, groupID, deviceID))).To(BeNil())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							latestTime, err := mongoSession.GetDatasetLatestTime(nil)
+							Expect(err).To(MatchError("mongo: dataset is missing"))
+							Expect(latestTime).To(BeNil())
+						})
+
+						It("returns an error if the upload id is missing", func() {
+							dataset.UploadID = ""
+							latestTime, err := mongoSession.GetDatasetLatestTime(dataset)
+							Expect(err).To(MatchError("mongo: dataset upload id is missing"))
+							Expect(latestTime).To(BeNil())
 						})
 
 						It("returns an error if the session is closed", func() {
 							mongoSession.Close()
-							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: session closed"))
+							latestTime, err := mongoSession.GetDatasetLatestTime(dataset)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(latestTime).To(BeNil())
 						})
+					})
 
-						It("sets the deleted time on the dataset", func() {
-							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
-							Expect(dataset.DeletedTime).ToNot(BeEmpty())
-							Expect(dataset.DeletedUserID).To(BeEmpty())
+					Context("GetDatasetChunk", func() {
+						It("returns nil if the dataset chunk does not exist", func() {
+							Expect(mongoSession.GetDatasetChunk(dataset, "chunk-one")).To(BeNil())
 						})
 
-						It("has the correct stored datasets", func() {
-							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": bson.M{"$exists": false}}, bson.M{})
-							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
-							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset)
+						It("returns the dataset chunk if it exists", func() {
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+							chunk, err := mongoSession.GetDatasetChunk(dataset, "chunk-one")
+							Expect(err).ToNot(HaveOccurred())
+							Expect(chunk).ToNot(BeNil())
+							Expect(chunk.DatasetID).To(Equal(dataset.UploadID))
+							Expect(chunk.ID).To(Equal("chunk-one"))
+							Expect(chunk.State).To(Equal(store.DatasetChunkStatePending))
+							Expect(chunk.IsInProgress(time.Now())).To(BeTrue())
 						})
 
-						It("has the correct stored dataset data", func() {
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID}, bson.M{}, datasetData)
-							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID}, bson.M{}, []data.Datum{})
+						It("does not return the dataset chunk of another dataset", func() {
+							Expect(mongoSession.CreateDatasetChunk(datasetExistingOne, "chunk-one", []string{})).To(BeTrue())
+							Expect(mongoSession.GetDatasetChunk(dataset, "chunk-one")).To(BeNil())
 						})
 
-						Context("with agent specified", func() {
-							var agentUserID string
+						It("returns an error if the chunk id is missing", func() {
+							chunk, err := mongoSession.GetDatasetChunk(dataset, "")
+							Expect(err).To(MatchError("mongo: chunk id is missing"))
+							Expect(chunk).To(BeNil())
+						})
 
-							BeforeEach(func() {
-								agentUserID = app.NewID()
-								mongoSession.SetAgent(&TestAgent{false, agentUserID})
-							})
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							chunk, err := mongoSession.GetDatasetChunk(dataset, "chunk-one")
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(chunk).To(BeNil())
+						})
+					})
 
-							It("sets the deleted time and deleted user id on the dataset", func() {
-								Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
-								Expect(dataset.DeletedTime).ToNot(BeEmpty())
-								Expect(dataset.DeletedUserID).To(Equal(agentUserID))
-							})
+					Context("CreateDatasetChunk", func() {
+						var testMongoChunksCollection *mgo.Collection
 
-							It("has the correct stored datasets", func() {
-								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": agentUserID}, bson.M{})
-								Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
-								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": agentUserID}, bson.M{}, dataset)
-							})
+						BeforeEach(func() {
+							testMongoChunksCollection = testMongoCollection.Database.C(testMongoCollection.Name + "Chunks")
+						})
 
-							It("has the correct stored dataset data", func() {
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID}, bson.M{}, datasetData)
-								Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID}, bson.M{}, []data.Datum{})
-							})
+						It("succeeds if it successfully creates the dataset chunk", func() {
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+							Expect(testMongoChunksCollection.Find(bson.M{"uploadId": dataset.UploadID, "chunkId": "chunk-one", "state": store.DatasetChunkStatePending}).Count()).To(Equal(1))
 						})
-					})
 
-					Context("CreateDatasetData", func() {
-						It("succeeds if it successfully creates the dataset data", func() {
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						It("does not store the dataset chunk on the dataset", func() {
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "type": "upload", "_chunks": bson.M{"$exists": true}}).Count()).To(Equal(0))
 						})
 
-						It("returns an error if the dataset is missing", func() {
-							Expect(mongoSession.CreateDatasetData(nil, datasetData)).To(MatchError("mongo: dataset is missing"))
+						It("does not create the dataset chunk if it is in progress", func() {
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeFalse())
+							Expect(testMongoChunksCollection.Find(bson.M{"uploadId": dataset.UploadID, "chunkId": "chunk-one"}).Count()).To(Equal(1))
 						})
 
-						It("returns an error if the dataset data is missing", func() {
-							Expect(mongoSession.CreateDatasetData(dataset, nil)).To(MatchError("mongo: dataset data is missing"))
+						It("does not create the dataset chunk if it is completed", func() {
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+							Expect(mongoSession.CompleteDatasetChunk(dataset, "chunk-one", []byte("[]"))).To(Succeed())
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeFalse())
+							chunk, err := mongoSession.GetDatasetChunk(dataset, "chunk-one")
+							Expect(err).ToNot(HaveOccurred())
+							Expect(chunk.State).To(Equal(store.DatasetChunkStateCompleted))
 						})
 
-						It("returns an error if the user id is missing", func() {
-							dataset.UserID = ""
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset user id is missing"))
+						It("reclaims the dataset chunk if the lease has expired", func() {
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+							Expect(testMongoChunksCollection.Update(bson.M{"uploadId": dataset.UploadID, "chunkId": "chunk-one"}, bson.M{"$set": bson.M{"expirationTime": time.Now().Add(-time.Minute)}})).To(Succeed())
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+							chunk, err := mongoSession.GetDatasetChunk(dataset, "chunk-one")
+							Expect(err).ToNot(HaveOccurred())
+							Expect(chunk.IsInProgress(time.Now())).To(BeTrue())
 						})
 
-						It("returns an error if the group id is missing", func() {
-							dataset.GroupID = ""
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset group id is missing"))
+						It("reclaims the dataset chunk if it failed and removes the data written by an earlier attempt", func() {
+							datumIDs := []string{datasetData[0].(*types.Base).ID, datasetData[1].(*types.Base).ID}
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", datumIDs)).To(BeTrue())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData[0:1])).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData[2:3])).To(Succeed())
+							Expect(mongoSession.FailDatasetChunk(dataset, "chunk-one")).To(Succeed())
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{app.NewID()})).To(BeTrue())
+							Expect(testMongoCollection.Find(bson.M{"id": datasetData[0].(*types.Base).ID}).Count()).To(Equal(0))
+							Expect(testMongoCollection.Find(bson.M{"id": datasetData[2].(*types.Base).ID}).Count()).To(Equal(1))
+							chunk, err := mongoSession.GetDatasetChunk(dataset, "chunk-one")
+							Expect(err).ToNot(HaveOccurred())
+							Expect(chunk.IsInProgress(time.Now())).To(BeTrue())
+							Expect(chunk.DatumIDs).To(HaveLen(3))
 						})
 
-						It("returns an error if the upload id is missing", func() {
-							dataset.UploadID = ""
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset upload id is missing"))
+						It("returns an error if the dataset is missing", func() {
+							created, err := mongoSession.CreateDatasetChunk(nil, "chunk-one", []string{})
+							Expect(err).To(MatchError("mongo: dataset is missing"))
+							Expect(created).To(BeFalse())
 						})
 
-						It("returns an error if the device id is missing (nil)", func() {
-							dataset.DeviceID = nil
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset device id is missing"))
+						It("returns an error if the chunk id is missing", func() {
+							created, err := mongoSession.CreateDatasetChunk(dataset, "", []string{})
+							Expect(err).To(MatchError("mongo: chunk id is missing"))
+							Expect(created).To(BeFalse())
 						})
 
-						It("returns an error if the device id is missing (empty)", func() {
-							dataset.DeviceID = app.StringAsPointer("")
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset device id is missing"))
+						It("returns an error if the datum ids is missing", func() {
+							created, err := mongoSession.CreateDatasetChunk(dataset, "chunk-one", nil)
+							Expect(err).To(MatchError("mongo: datum ids is missing"))
+							Expect(created).To(BeFalse())
 						})
 
 						It("returns an error if the session is closed", func() {
 							mongoSession.Close()
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: session closed"))
+							created, err := mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(created).To(BeFalse())
 						})
+					})
 
-						It("sets the user id, group id, and upload id on the dataset data to match the dataset", func() {
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
-							for _, datasetDatum := range datasetData {
-								baseDatum, ok := datasetDatum.(*types.Base)
-								Expect(ok).To(BeTrue())
-								Expect(baseDatum).ToNot(BeNil())
-								Expect(baseDatum.UserID).To(Equal(dataset.UserID))
-								Expect(baseDatum.GroupID).To(Equal(dataset.GroupID))
-								Expect(baseDatum.UploadID).To(Equal(dataset.UploadID))
-							}
+					Context("CompleteDatasetChunk", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
 						})
 
-						It("leaves the dataset data not active", func() {
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
-							for _, datasetDatum := range datasetData {
-								baseDatum, ok := datasetDatum.(*types.Base)
-								Expect(ok).To(BeTrue())
-								Expect(baseDatum).ToNot(BeNil())
-								Expect(baseDatum.Active).To(BeFalse())
-							}
+						It("succeeds if it successfully completes the dataset chunk", func() {
+							Expect(mongoSession.CompleteDatasetChunk(dataset, "chunk-one", []byte("[]"))).To(Succeed())
+							chunk, err := mongoSession.GetDatasetChunk(dataset, "chunk-one")
+							Expect(err).ToNot(HaveOccurred())
+							Expect(chunk).ToNot(BeNil())
+							Expect(chunk.State).To(Equal(store.DatasetChunkStateCompleted))
+							Expect(chunk.Response).To(Equal([]byte("[]")))
+							Expect(chunk.CompletedTime).ToNot(BeZero())
+							Expect(chunk.IsInProgress(time.Now())).To(BeFalse())
 						})
 
-						It("sets the created time on the dataset data", func() {
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
-							for _, datasetDatum := range datasetData {
-								baseDatum, ok := datasetDatum.(*types.Base)
-								Expect(ok).To(BeTrue())
-								Expect(baseDatum).ToNot(BeNil())
-								Expect(baseDatum.CreatedTime).ToNot(BeEmpty())
-								Expect(baseDatum.CreatedUserID).To(BeEmpty())
-							}
+						It("returns an error if the chunk does not exist", func() {
+							Expect(mongoSession.CompleteDatasetChunk(dataset, "chunk-two", []byte("[]"))).To(MatchError("mongo: unable to complete dataset chunk; not found"))
 						})
 
-						It("has the correct stored dataset data", func() {
-							datasetBeforeCreateData := append(append(datasetExistingOtherData, datasetExistingOneData...), datasetExistingTwoData...)
-							ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": bson.M{"$exists": false}}, bson.M{}, datasetBeforeCreateData)
-							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
-							ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": bson.M{"$exists": false}}, bson.M{}, append(datasetBeforeCreateData, datasetData...))
+						It("returns an error if the chunk is already completed", func() {
+							Expect(mongoSession.CompleteDatasetChunk(dataset, "chunk-one", []byte("[]"))).To(Succeed())
+							Expect(mongoSession.CompleteDatasetChunk(dataset, "chunk-one", []byte("[]"))).To(MatchError("mongo: unable to complete dataset chunk; not found"))
 						})
 
-						Context("with agent specified", func() {
-							var agentUserID string
+						It("returns an error if the chunk id is missing", func() {
+							Expect(mongoSession.CompleteDatasetChunk(dataset, "", []byte("[]"))).To(MatchError("mongo: chunk id is missing"))
+						})
 
-							BeforeEach(func() {
-								agentUserID = app.NewID()
-								mongoSession.SetAgent(&TestAgent{false, agentUserID})
-							})
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.CompleteDatasetChunk(dataset, "chunk-one", []byte("[]"))).To(MatchError("mongo: session closed"))
+						})
+					})
 
-							It("sets the created time and created user id on the dataset data", func() {
-								Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
-								for _, datasetDatum := range datasetData {
-									baseDatum, ok := datasetDatum.(*types.Base)
-									Expect(ok).To(BeTrue())
-									Expect(baseDatum).ToNot(BeNil())
-									Expect(baseDatum.CreatedTime).ToNot(BeEmpty())
-									Expect(baseDatum.CreatedUserID).To(Equal(agentUserID))
-								}
-							})
+					Context("FailDatasetChunk", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+						})
 
-							It("has the correct stored dataset data", func() {
-								datasetBeforeCreateData := append(append(datasetExistingOtherData, datasetExistingOneData...), datasetExistingTwoData...)
-								ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": bson.M{"$exists": false}}, bson.M{}, datasetBeforeCreateData)
-								Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
-								ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": bson.M{"$exists": false}}, bson.M{}, datasetBeforeCreateData)
-								ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": agentUserID}, bson.M{}, datasetData)
-							})
+						It("succeeds if it successfully fails the dataset chunk", func() {
+							Expect(mongoSession.FailDatasetChunk(dataset, "chunk-one")).To(Succeed())
+							chunk, err := mongoSession.GetDatasetChunk(dataset, "chunk-one")
+							Expect(err).ToNot(HaveOccurred())
+							Expect(chunk).ToNot(BeNil())
+							Expect(chunk.State).To(Equal(store.DatasetChunkStateFailed))
+							Expect(chunk.IsInProgress(time.Now())).To(BeFalse())
+						})
+
+						It("succeeds if the dataset chunk does not exist", func() {
+							Expect(mongoSession.FailDatasetChunk(dataset, "chunk-two")).To(Succeed())
+						})
+
+						It("does not fail a completed dataset chunk", func() {
+							Expect(mongoSession.CompleteDatasetChunk(dataset, "chunk-one", []byte("[]"))).To(Succeed())
+							Expect(mongoSession.FailDatasetChunk(dataset, "chunk-one")).To(Succeed())
+							chunk, err := mongoSession.GetDatasetChunk(dataset, "chunk-one")
+							Expect(err).ToNot(HaveOccurred())
+							Expect(chunk.State).To(Equal(store.DatasetChunkStateCompleted))
+						})
+
+						It("returns an error if the chunk id is missing", func() {
+							Expect(mongoSession.FailDatasetChunk(dataset, "")).To(MatchError("mongo: chunk id is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.FailDatasetChunk(dataset, "chunk-one")).To(MatchError("mongo: session closed"))
 						})
 					})
 
-					Context("ActivateDatasetData", func() {
+					Context("GetDatasetDataDeduplicatorHashes", func() {
+						var hashes []string
+
+						BeforeEach(func() {
+							hashes = []string{}
+							for _, datasetDatum := range datasetData {
+								hashes = append(hashes, datasetDatum.DeduplicatorDescriptor().Hash)
+							}
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData[:2])).To(Succeed())
+						})
+
+						It("returns the hashes already stored for the dataset", func() {
+							existingHashes, err := mongoSession.GetDatasetDataDeduplicatorHashes(dataset, hashes)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(existingHashes).To(ConsistOf(hashes[0], hashes[1]))
+						})
+
+						It("returns no hashes if none are specified", func() {
+							Expect(mongoSession.GetDatasetDataDeduplicatorHashes(dataset, nil)).To(BeEmpty())
+						})
+
+						It("does not return hashes stored for other datasets", func() {
+							Expect(mongoSession.GetDatasetDataDeduplicatorHashes(datasetExistingOne, hashes)).To(BeEmpty())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							existingHashes, err := mongoSession.GetDatasetDataDeduplicatorHashes(nil, hashes)
+							Expect(err).To(MatchError("mongo: dataset is missing"))
+							Expect(existingHashes).To(BeNil())
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							existingHashes, err := mongoSession.GetDatasetDataDeduplicatorHashes(dataset, hashes)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(existingHashes).To(BeNil())
+						})
+					})
+
+					Context("DeleteDataset", func() {
 						BeforeEach(func() {
 							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
 						})
 
-						It("succeeds if it successfully activates the dataset", func() {
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+						It("succeeds if it successfully deletes the dataset", func() {
+							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+						})
+
+						It("removes the dataset chunks", func() {
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+							Expect(mongoSession.CreateDatasetChunk(datasetExistingOne, "chunk-one", []string{})).To(BeTrue())
+							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+							Expect(mongoSession.GetDatasetChunk(dataset, "chunk-one")).To(BeNil())
+							Expect(mongoSession.GetDatasetChunk(datasetExistingOne, "chunk-one")).ToNot(BeNil())
 						})
 
 						It("returns an error if the dataset is missing", func() {
-							Expect(mongoSession.ActivateDatasetData(nil)).To(MatchError("mongo: dataset is missing"))
+							Expect(mongoSession.DeleteDataset(nil)).To(MatchError("mongo: dataset is missing"))
 						})
 
 						It("returns an error if the user id is missing", func() {
 							dataset.UserID = ""
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset user id is missing"))
+							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset user id is missing"))
 						})
 
 						It("returns an error if the group id is missing", func() {
 							dataset.GroupID = ""
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset group id is missing"))
+							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset group id is missing"))
 						})
 
 						It("returns an error if the upload id is missing", func() {
 							dataset.UploadID = ""
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset upload id is missing"))
 						})
 
 						It("returns an error if the device id is missing (nil)", func() {
 							dataset.DeviceID = nil
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset device id is missing"))
+							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
 						})
 
 						It("returns an error if the device id is missing (empty)", func() {
 							dataset.DeviceID = app.StringAsPointer("")
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset device id is missing"))
+							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
 						})
 
 						It("returns an error if the session is closed", func() {
 							mongoSession.Close()
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: session closed"))
+							Expect(mongoSession.DeleteDataset(dataset)).To(MatchError("mongo: session closed"))
 						})
 
-						It("sets the active on the dataset", func() {
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
-							Expect(dataset.Active).To(BeTrue())
+						It("sets the deleted time on the dataset", func() {
+							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+							Expect(dataset.DeletedTime).ToNot(BeEmpty())
+							Expect(dataset.DeletedUserID).To(BeEmpty())
 						})
 
-						It("sets the modified time on the dataset", func() {
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
-							Expect(dataset.ModifiedTime).ToNot(BeEmpty())
-							Expect(dataset.ModifiedUserID).To(BeEmpty())
+						It("has the correct stored datasets", func() {
+							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": bson.M{"$exists": false}}, bson.M{})
+							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset)
 						})
 
-						It("has the correct stored active dataset", func() {
-							ValidateDataset(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{})
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
-							ValidateDataset(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{}, dataset)
+						It("has the correct stored dataset data", func() {
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID}, bson.M{}, datasetData)
+							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "deletedTime": bson.M{"$exists": false}}, bson.M{}, []data.Datum{})
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "type": bson.M{"$ne": "upload"}, "_active": false, "deletedTime": bson.M{"$exists": true}, "deletedUserId": bson.M{"$exists": false}}).Count()).To(Equal(len(datasetData)))
 						})
 
-						It("has the correct stored active dataset data", func() {
-							ValidateDatasetData(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{}, []data.Datum{})
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
-							for _, datasetDatum := range datasetData {
-								datasetDatum.SetActive(true)
-							}
-							ValidateDatasetData(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{"modifiedTime": 0}, datasetData)
+						It("marks the deleted dataset and dataset data as soft deleted", func() {
+							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "_softDeleted": true}).Count()).To(Equal(len(datasetData) + 1))
+						})
+
+						It("does not return the deleted dataset data", func() {
+							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+							Expect(mongoSession.GetDataForDatasetByID(dataset.UploadID, &store.DatasetDataFilter{Archived: true}, nil)).To(BeEmpty())
 						})
 
 						Context("with agent specified", func() {
@@ -877,106 +1246,147 @@ var _ = Describe("Mongo", func() {
 								mongoSession.SetAgent(&TestAgent{false, agentUserID})
 							})
 
-							It("sets the modified time and modified user id on the dataset", func() {
-								Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
-								Expect(dataset.ModifiedTime).ToNot(BeEmpty())
-								Expect(dataset.ModifiedUserID).To(Equal(agentUserID))
+							It("sets the deleted time and deleted user id on the dataset", func() {
+								Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+								Expect(dataset.DeletedTime).ToNot(BeEmpty())
+								Expect(dataset.DeletedUserID).To(Equal(agentUserID))
 							})
 
-							It("has the correct stored active dataset", func() {
-								ValidateDataset(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{})
-								Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
-								ValidateDataset(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{}, dataset)
+							It("has the correct stored datasets", func() {
+								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": agentUserID}, bson.M{})
+								Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": agentUserID}, bson.M{}, dataset)
 							})
 
-							It("has the correct stored active dataset data", func() {
-								ValidateDatasetData(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{}, []data.Datum{})
-								Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
-								for _, datasetDatum := range datasetData {
-									datasetDatum.SetActive(true)
-									datasetDatum.SetModifiedUserID(agentUserID)
-								}
-								ValidateDatasetData(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{"modifiedTime": 0}, datasetData)
+							It("has the correct stored dataset data", func() {
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID}, bson.M{}, datasetData)
+								Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "deletedTime": bson.M{"$exists": false}}, bson.M{}, []data.Datum{})
+								Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "type": bson.M{"$ne": "upload"}, "_active": false, "deletedTime": bson.M{"$exists": true}, "deletedUserId": agentUserID}).Count()).To(Equal(len(datasetData)))
 							})
 						})
 					})
 
-					Context("ArchiveDeviceDataUsingHashesFromDataset", func() {
-						var datasetExistingOneDataCloned []data.Datum
-
+					Context("UndeleteDataset", func() {
 						BeforeEach(func() {
-							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
-							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
-							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
 							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
-							for _, datasetDatum := range append(datasetExistingOneData, datasetExistingOneDataCloned...) {
-								datasetDatum.SetActive(true)
-							}
+							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
 						})
 
-						It("succeeds if it successfully archives device data using hashes from dataset", func() {
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+						It("succeeds if it successfully undeletes the dataset", func() {
+							Expect(mongoSession.UndeleteDataset(dataset)).To(Succeed())
 						})
 
 						It("returns an error if the dataset is missing", func() {
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(nil)).To(MatchError("mongo: dataset is missing"))
+							Expect(mongoSession.UndeleteDataset(nil)).To(MatchError("mongo: dataset is missing"))
 						})
 
 						It("returns an error if the user id is missing", func() {
 							dataset.UserID = ""
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset user id is missing"))
+							Expect(mongoSession.UndeleteDataset(dataset)).To(MatchError("mongo: dataset user id is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.UndeleteDataset(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("clears the deleted time on the dataset", func() {
+							Expect(mongoSession.UndeleteDataset(dataset)).To(Succeed())
+							Expect(dataset.DeletedTime).To(BeEmpty())
+							Expect(dataset.DeletedUserID).To(BeEmpty())
+						})
+
+						It("has the correct stored dataset and dataset data", func() {
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "deletedTime": bson.M{"$exists": true}}).Count()).To(Equal(len(datasetData) + 1))
+							Expect(mongoSession.UndeleteDataset(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "deletedTime": bson.M{"$exists": true}}).Count()).To(Equal(0))
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "_softDeleted": bson.M{"$exists": true}}).Count()).To(Equal(0))
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "type": bson.M{"$ne": "upload"}, "_active": false}).Count()).To(Equal(len(datasetData)))
+						})
+					})
+
+					Context("CreateDatasetData", func() {
+						It("succeeds if it successfully creates the dataset data", func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.CreateDatasetData(nil, datasetData)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the dataset data is missing", func() {
+							Expect(mongoSession.CreateDatasetData(dataset, nil)).To(MatchError("mongo: dataset data is missing"))
+						})
+
+						It("returns an error if the user id is missing", func() {
+							dataset.UserID = ""
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset user id is missing"))
 						})
 
 						It("returns an error if the group id is missing", func() {
 							dataset.GroupID = ""
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset group id is missing"))
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset group id is missing"))
 						})
 
 						It("returns an error if the upload id is missing", func() {
 							dataset.UploadID = ""
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset upload id is missing"))
 						})
 
 						It("returns an error if the device id is missing (nil)", func() {
 							dataset.DeviceID = nil
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset device id is missing"))
 						})
 
 						It("returns an error if the device id is missing (empty)", func() {
 							dataset.DeviceID = app.StringAsPointer("")
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: dataset device id is missing"))
 						})
 
 						It("returns an error if the session is closed", func() {
 							mongoSession.Close()
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: session closed"))
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(MatchError("mongo: session closed"))
 						})
 
-						It("has the correct stored datasets", func() {
-							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
-							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+						It("sets the user id, group id, and upload id on the dataset data to match the dataset", func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							for _, datasetDatum := range datasetData {
+								baseDatum, ok := datasetDatum.(*types.Base)
+								Expect(ok).To(BeTrue())
+								Expect(baseDatum).ToNot(BeNil())
+								Expect(baseDatum.UserID).To(Equal(dataset.UserID))
+								Expect(baseDatum.GroupID).To(Equal(dataset.GroupID))
+								Expect(baseDatum.UploadID).To(Equal(dataset.UploadID))
+							}
 						})
 
-						It("has the correct stored archived dataset data", func() {
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false}, bson.M{}, []data.Datum{})
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, append(datasetExistingOneData, datasetExistingOneDataCloned...))
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
-							for _, datasetDatum := range datasetExistingOneDataCloned {
-								datasetDatum.SetActive(false)
+						It("leaves the dataset data not active", func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							for _, datasetDatum := range datasetData {
+								baseDatum, ok := datasetDatum.(*types.Base)
+								Expect(ok).To(BeTrue())
+								Expect(baseDatum).ToNot(BeNil())
+								Expect(baseDatum.Active).To(BeFalse())
 							}
-							ValidateDatasetData(testMongoCollection,
-								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
-								bson.M{"modifiedTime": 0},
-								datasetExistingOneData)
-							ValidateDatasetData(testMongoCollection,
-								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
-								bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0},
-								datasetExistingOneDataCloned)
-							ValidateDatasetData(testMongoCollection,
-								bson.M{"uploadId": dataset.UploadID, "_active": false, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
-								bson.M{},
-								datasetData)
+						})
+
+						It("sets the created time on the dataset data", func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							for _, datasetDatum := range datasetData {
+								baseDatum, ok := datasetDatum.(*types.Base)
+								Expect(ok).To(BeTrue())
+								Expect(baseDatum).ToNot(BeNil())
+								Expect(baseDatum.CreatedTime).ToNot(BeEmpty())
+								Expect(baseDatum.CreatedUserID).To(BeEmpty())
+							}
+						})
+
+						It("has the correct stored dataset data", func() {
+							datasetBeforeCreateData := append(append(datasetExistingOtherData, datasetExistingOneData...), datasetExistingTwoData...)
+							ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": bson.M{"$exists": false}}, bson.M{}, datasetBeforeCreateData)
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": bson.M{"$exists": false}}, bson.M{}, append(datasetBeforeCreateData, datasetData...))
 						})
 
 						Context("with agent specified", func() {
@@ -987,308 +1397,1628 @@ var _ = Describe("Mongo", func() {
 								mongoSession.SetAgent(&TestAgent{false, agentUserID})
 							})
 
-							It("has the correct stored datasets", func() {
-								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
-								Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
-								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+							It("sets the created time and created user id on the dataset data", func() {
+								Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+								for _, datasetDatum := range datasetData {
+									baseDatum, ok := datasetDatum.(*types.Base)
+									Expect(ok).To(BeTrue())
+									Expect(baseDatum).ToNot(BeNil())
+									Expect(baseDatum.CreatedTime).ToNot(BeEmpty())
+									Expect(baseDatum.CreatedUserID).To(Equal(agentUserID))
+								}
 							})
 
-							It("has the correct stored archived dataset data", func() {
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false}, bson.M{}, []data.Datum{})
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"modifiedTime": 0, "modifiedUserId": 0}, append(datasetExistingOneData, datasetExistingOneDataCloned...))
-								Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
-								for _, datasetDatum := range datasetExistingOneDataCloned {
-									datasetDatum.SetActive(false)
+							It("has the correct stored dataset data", func() {
+								datasetBeforeCreateData := append(append(datasetExistingOtherData, datasetExistingOneData...), datasetExistingTwoData...)
+								ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": bson.M{"$exists": false}}, bson.M{}, datasetBeforeCreateData)
+								Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+								ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": bson.M{"$exists": false}}, bson.M{}, datasetBeforeCreateData)
+								ValidateDatasetData(testMongoCollection, bson.M{"createdTime": bson.M{"$exists": true}, "createdUserId": agentUserID}, bson.M{}, datasetData)
+							})
+						})
+					})
+
+					Context("DeactivateDatasetData", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+						})
+
+						It("succeeds if it successfully deactivates the dataset data", func() {
+							Expect(mongoSession.DeactivateDatasetData(dataset)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.DeactivateDatasetData(nil)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the upload id is missing", func() {
+							dataset.UploadID = ""
+							Expect(mongoSession.DeactivateDatasetData(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.DeactivateDatasetData(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("has the correct stored active dataset", func() {
+							Expect(mongoSession.DeactivateDatasetData(dataset)).To(Succeed())
+							ValidateDataset(testMongoCollection, bson.M{"_active": true}, bson.M{}, dataset)
+						})
+
+						It("has the correct stored inactive dataset data", func() {
+							Expect(mongoSession.DeactivateDatasetData(dataset)).To(Succeed())
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_active": true}, bson.M{}, []data.Datum{})
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_active": false}, bson.M{"modifiedTime": 0}, datasetData)
+						})
+					})
+
+					Context("DeleteInactiveDatasetData", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully deletes the inactive dataset data", func() {
+							Expect(mongoSession.DeleteInactiveDatasetData(dataset)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.DeleteInactiveDatasetData(nil)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the upload id is missing", func() {
+							dataset.UploadID = ""
+							Expect(mongoSession.DeleteInactiveDatasetData(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.DeleteInactiveDatasetData(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("has the correct stored dataset data", func() {
+							Expect(mongoSession.DeleteInactiveDatasetData(dataset)).To(Succeed())
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID}, bson.M{}, []data.Datum{})
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID}, bson.M{}, datasetExistingOneData)
+							ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, datasetExistingOther, datasetExistingOne, datasetExistingTwo, dataset)
+						})
+
+						It("does not delete the active dataset data", func() {
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							Expect(mongoSession.DeleteInactiveDatasetData(dataset)).To(Succeed())
+							for _, datasetDatum := range datasetData {
+								datasetDatum.SetActive(true)
+							}
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID}, bson.M{"modifiedTime": 0}, datasetData)
+						})
+					})
+
+					Context("ActivateDatasetData", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully activates the dataset", func() {
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.ActivateDatasetData(nil)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the user id is missing", func() {
+							dataset.UserID = ""
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset user id is missing"))
+						})
+
+						It("returns an error if the group id is missing", func() {
+							dataset.GroupID = ""
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset group id is missing"))
+						})
+
+						It("returns an error if the upload id is missing", func() {
+							dataset.UploadID = ""
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+						})
+
+						It("returns an error if the device id is missing (nil)", func() {
+							dataset.DeviceID = nil
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						})
+
+						It("returns an error if the device id is missing (empty)", func() {
+							dataset.DeviceID = app.StringAsPointer("")
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("sets the active on the dataset", func() {
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							Expect(dataset.Active).To(BeTrue())
+						})
+
+						It("sets the modified time on the dataset", func() {
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							Expect(dataset.ModifiedTime).ToNot(BeEmpty())
+							Expect(dataset.ModifiedUserID).To(BeEmpty())
+						})
+
+						It("has the correct stored active dataset", func() {
+							ValidateDataset(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{})
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							ValidateDataset(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{}, dataset)
+						})
+
+						It("has the correct stored active dataset data", func() {
+							ValidateDatasetData(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{}, []data.Datum{})
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							for _, datasetDatum := range datasetData {
+								datasetDatum.SetActive(true)
+							}
+							ValidateDatasetData(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}}, bson.M{"modifiedTime": 0}, datasetData)
+						})
+
+						It("does not activate dataset data archived by another dataset", func() {
+							archivedDatasetID := app.NewID()
+							archivedDatum := datasetData[0].(*types.Base)
+							Expect(testMongoCollection.Update(bson.M{"id": archivedDatum.ID}, bson.M{"$set": bson.M{"archivedDatasetId": archivedDatasetID, "archivedTime": "2016-09-01T12:00:00Z"}})).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"id": archivedDatum.ID, "_active": false, "archivedDatasetId": archivedDatasetID, "archivedTime": "2016-09-01T12:00:00Z"}).Count()).To(Equal(1))
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "type": bson.M{"$ne": "upload"}, "_active": true}).Count()).To(Equal(len(datasetData) - 1))
+						})
+
+						Context("with agent specified", func() {
+							var agentUserID string
+
+							BeforeEach(func() {
+								agentUserID = app.NewID()
+								mongoSession.SetAgent(&TestAgent{false, agentUserID})
+							})
+
+							It("sets the modified time and modified user id on the dataset", func() {
+								Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+								Expect(dataset.ModifiedTime).ToNot(BeEmpty())
+								Expect(dataset.ModifiedUserID).To(Equal(agentUserID))
+							})
+
+							It("has the correct stored active dataset", func() {
+								ValidateDataset(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{})
+								Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+								ValidateDataset(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{}, dataset)
+							})
+
+							It("has the correct stored active dataset data", func() {
+								ValidateDatasetData(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{}, []data.Datum{})
+								Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+								for _, datasetDatum := range datasetData {
+									datasetDatum.SetActive(true)
+									datasetDatum.SetModifiedUserID(agentUserID)
 								}
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
-									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
-									datasetExistingOneData)
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID},
-									bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0, "modifiedUserId": 0},
-									datasetExistingOneDataCloned)
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": dataset.UploadID, "_active": false, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
-									bson.M{},
-									datasetData)
+								ValidateDatasetData(testMongoCollection, bson.M{"_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID}, bson.M{"modifiedTime": 0}, datasetData)
 							})
 						})
 					})
 
-					Context("UnarchiveDeviceDataUsingHashesFromDataset", func() {
-						var datasetExistingTwoDataCloned []data.Datum
+					Context("ArchiveDeviceDataUsingHashesFromDataset", func() {
 						var datasetExistingOneDataCloned []data.Datum
 
 						BeforeEach(func() {
-							datasetExistingTwoDataCloned = CloneDatasetData(datasetData)
 							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
-							Expect(mongoSession.CreateDatasetData(datasetExistingTwo, datasetExistingTwoDataCloned)).To(Succeed())
-							Expect(mongoSession.ActivateDatasetData(datasetExistingTwo)).To(Succeed())
 							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
 							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
 							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
-							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
-							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							for _, datasetDatum := range append(datasetExistingOneData, datasetExistingOneDataCloned...) {
+								datasetDatum.SetActive(true)
+							}
 						})
 
-						It("succeeds if it successfully unarchives device data using hashes from dataset", func() {
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+						It("succeeds if it successfully archives device data using hashes from dataset", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
 						})
 
 						It("returns an error if the dataset is missing", func() {
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(nil)).To(MatchError("mongo: dataset is missing"))
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(nil)).To(MatchError("mongo: dataset is missing"))
 						})
 
 						It("returns an error if the user id is missing", func() {
 							dataset.UserID = ""
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset user id is missing"))
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset user id is missing"))
 						})
 
 						It("returns an error if the group id is missing", func() {
 							dataset.GroupID = ""
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset group id is missing"))
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset group id is missing"))
 						})
 
 						It("returns an error if the upload id is missing", func() {
 							dataset.UploadID = ""
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset upload id is missing"))
 						})
 
 						It("returns an error if the device id is missing (nil)", func() {
 							dataset.DeviceID = nil
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						})
+
+						It("returns an error if the device id is missing (empty)", func() {
+							dataset.DeviceID = app.StringAsPointer("")
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("has the correct stored datasets", func() {
+							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+						})
+
+						It("has the correct stored archived dataset data", func() {
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false}, bson.M{}, []data.Datum{})
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, append(datasetExistingOneData, datasetExistingOneDataCloned...))
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+							for _, datasetDatum := range datasetExistingOneDataCloned {
+								datasetDatum.SetActive(false)
+							}
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
+								bson.M{"modifiedTime": 0},
+								datasetExistingOneData)
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
+								bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0},
+								datasetExistingOneDataCloned)
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": dataset.UploadID, "_active": false, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+								bson.M{},
+								datasetData)
+						})
+
+						Context("with agent specified", func() {
+							var agentUserID string
+
+							BeforeEach(func() {
+								agentUserID = app.NewID()
+								mongoSession.SetAgent(&TestAgent{false, agentUserID})
+							})
+
+							It("has the correct stored datasets", func() {
+								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+								Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+							})
+
+							It("has the correct stored archived dataset data", func() {
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false}, bson.M{}, []data.Datum{})
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"modifiedTime": 0, "modifiedUserId": 0}, append(datasetExistingOneData, datasetExistingOneDataCloned...))
+								Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+								for _, datasetDatum := range datasetExistingOneDataCloned {
+									datasetDatum.SetActive(false)
+								}
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
+									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
+									datasetExistingOneData)
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID},
+									bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0, "modifiedUserId": 0},
+									datasetExistingOneDataCloned)
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": dataset.UploadID, "_active": false, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+									bson.M{},
+									datasetData)
+							})
+						})
+					})
+
+					Context("UnarchiveDeviceDataUsingHashesFromDataset", func() {
+						var datasetExistingTwoDataCloned []data.Datum
+						var datasetExistingOneDataCloned []data.Datum
+
+						BeforeEach(func() {
+							datasetExistingTwoDataCloned = CloneDatasetData(datasetData)
+							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
+							Expect(mongoSession.CreateDatasetData(datasetExistingTwo, datasetExistingTwoDataCloned)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingTwo)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+						})
+
+						It("succeeds if it successfully unarchives device data using hashes from dataset", func() {
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(nil)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the user id is missing", func() {
+							dataset.UserID = ""
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset user id is missing"))
+						})
+
+						It("returns an error if the group id is missing", func() {
+							dataset.GroupID = ""
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset group id is missing"))
+						})
+
+						It("returns an error if the upload id is missing", func() {
+							dataset.UploadID = ""
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+						})
+
+						It("returns an error if the device id is missing (nil)", func() {
+							dataset.DeviceID = nil
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						})
+
+						It("returns an error if the device id is missing (empty)", func() {
+							dataset.DeviceID = app.StringAsPointer("")
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("has the correct stored datasets", func() {
+							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+						})
+
+						It("has the correct stored unarchived dataset data", func() {
+							for _, datasetDatum := range append(datasetData, datasetExistingOneData...) {
+								datasetDatum.SetActive(true)
+							}
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingOneDataCloned)
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingOneData)
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, datasetData)
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+							for _, datasetDatum := range datasetExistingOneDataCloned {
+								datasetDatum.SetActive(true)
+							}
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
+								bson.M{"modifiedTime": 0},
+								append(datasetExistingOneData, datasetExistingOneDataCloned...))
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": dataset.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+								bson.M{"modifiedTime": 0},
+								datasetData)
+						})
+
+						It("has the correct stored datasets if an intermediary is unarchived", func() {
+							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{}, datasetExistingTwo)
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
+							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{}, datasetExistingTwo)
+						})
+
+						It("has the correct stored unarchived dataset data if an intermediary is unarchived", func() {
+							for _, datasetDatum := range append(datasetExistingOneData, datasetExistingTwoData...) {
+								datasetDatum.SetActive(true)
+							}
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": false}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingTwoDataCloned)
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingTwoData)
+							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, datasetExistingOneData)
+							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+								bson.M{"modifiedTime": 0},
+								datasetExistingTwoData)
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
+								bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0},
+								datasetExistingTwoDataCloned)
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+								bson.M{"modifiedTime": 0},
+								datasetExistingOneData)
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID},
+								bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0},
+								datasetExistingOneDataCloned)
+						})
+
+						Context("with agent specified", func() {
+							var agentUserID string
+
+							BeforeEach(func() {
+								agentUserID = app.NewID()
+								mongoSession.SetAgent(&TestAgent{false, agentUserID})
+							})
+
+							It("has the correct stored datasets", func() {
+								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+								Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+							})
+
+							It("has the correct stored unarchived dataset data", func() {
+								for _, datasetDatum := range append(datasetData, datasetExistingOneData...) {
+									datasetDatum.SetActive(true)
+								}
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingOneDataCloned)
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingOneData)
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, datasetData)
+								Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+								for _, datasetDatum := range datasetExistingOneDataCloned {
+									datasetDatum.SetActive(true)
+								}
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID},
+									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
+									datasetExistingOneDataCloned)
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
+									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
+									datasetExistingOneData)
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": dataset.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
+									datasetData)
+							})
+
+							It("has the correct stored datasets if an intermediary is unarchived", func() {
+								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{}, datasetExistingTwo)
+								Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
+								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{}, datasetExistingTwo)
+							})
+
+							It("has the correct stored unarchived dataset data if an intermediary is unarchived", func() {
+								for _, datasetDatum := range append(datasetExistingOneData, datasetExistingTwoData...) {
+									datasetDatum.SetActive(true)
+								}
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": false}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingTwoDataCloned)
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingTwoData)
+								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, datasetExistingOneData)
+								Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
+									datasetExistingTwoData)
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID},
+									bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0, "modifiedUserId": 0},
+									datasetExistingTwoDataCloned)
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
+									datasetExistingOneData)
+								ValidateDatasetData(testMongoCollection,
+									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID},
+									bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0, "modifiedUserId": 0},
+									datasetExistingOneDataCloned)
+							})
+
+						})
+					})
+
+					Context("ArchiveDeviceDataUsingTimeWindowFromDataset", func() {
+						var datasetExistingOneDataCloned []data.Datum
+
+						BeforeEach(func() {
+							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
+							datasetExistingOneDataCloned[0].(*types.Base).Type = "other"
+							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							for _, datasetDatum := range append(datasetExistingOneData, datasetExistingOneDataCloned...) {
+								datasetDatum.SetActive(true)
+							}
+						})
+
+						It("succeeds if it successfully archives device data using time window from dataset", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(nil)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the device id is missing (nil)", func() {
+							dataset.DeviceID = nil
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("has the correct stored archived dataset data", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)).To(Succeed())
+							for _, datasetDatum := range datasetExistingOneDataCloned[1:] {
+								datasetDatum.SetActive(false)
+							}
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true},
+								bson.M{"modifiedTime": 0},
+								append(datasetExistingOneData, datasetExistingOneDataCloned[0]))
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
+								bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0},
+								datasetExistingOneDataCloned[1:])
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": dataset.UploadID, "_active": false, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+								bson.M{},
+								datasetData)
+						})
+
+						Context("with times with time zone offsets", func() {
+							BeforeEach(func() {
+								for index, datasetTime := range []string{"2016-09-01T14:00:00+02:00", "2016-09-01T12:30:00Z", "2016-09-01T15:00:00+02:00"} {
+									Expect(testMongoCollection.Update(bson.M{"id": datasetData[index].(*types.Base).ID}, bson.M{"$set": bson.M{"time": datasetTime}})).To(Succeed())
+								}
+								for index, datasetTime := range map[int]string{1: "2016-09-01T12:10:00Z", 2: "2016-09-01T14:30:00Z"} {
+									Expect(testMongoCollection.Update(bson.M{"id": datasetExistingOneDataCloned[index].(*types.Base).ID}, bson.M{"$set": bson.M{"time": datasetTime}})).To(Succeed())
+								}
+							})
+
+							It("archives the device data within the time window compared as times", func() {
+								Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)).To(Succeed())
+								Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[1].(*types.Base).ID, "_active": false, "archivedDatasetId": dataset.UploadID}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[2].(*types.Base).ID, "_active": true}).Count()).To(Equal(1))
+							})
+
+							It("previews the device data within the time window compared as times", func() {
+								preview, err := mongoSession.PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(preview.SampleIDs).To(ContainElement(datasetExistingOneDataCloned[1].(*types.Base).ID))
+								Expect(preview.SampleIDs).ToNot(ContainElement(datasetExistingOneDataCloned[2].(*types.Base).ID))
+							})
+						})
+					})
+
+					Context("UnarchiveDeviceDataArchivedByDataset", func() {
+						var datasetExistingOneDataCloned []data.Datum
+
+						BeforeEach(func() {
+							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
+							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+						})
+
+						It("succeeds if it successfully unarchives device data archived by dataset", func() {
+							Expect(mongoSession.UnarchiveDeviceDataArchivedByDataset(dataset)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.UnarchiveDeviceDataArchivedByDataset(nil)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.UnarchiveDeviceDataArchivedByDataset(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("has the correct stored unarchived dataset data", func() {
+							Expect(testMongoCollection.Find(bson.M{"archivedDatasetId": dataset.UploadID}).Count()).To(Equal(3))
+							Expect(mongoSession.UnarchiveDeviceDataArchivedByDataset(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"archivedDatasetId": dataset.UploadID}).Count()).To(Equal(0))
+							for _, datasetDatum := range append(datasetExistingOneData, datasetExistingOneDataCloned...) {
+								datasetDatum.SetActive(true)
+							}
+							ValidateDatasetData(testMongoCollection,
+								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
+								bson.M{"modifiedTime": 0},
+								append(datasetExistingOneData, datasetExistingOneDataCloned...))
+						})
+
+						It("transfers device data archived by dataset to a later dataset that archived the dataset data", func() {
+							datasetExistingTwoDataCloned := CloneDatasetData(datasetData)
+							Expect(mongoSession.CreateDatasetData(datasetExistingTwo, datasetExistingTwoDataCloned)).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(datasetExistingTwo)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingTwo)).To(Succeed())
+							Expect(mongoSession.UnarchiveDeviceDataArchivedByDataset(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"archivedDatasetId": dataset.UploadID}).Count()).To(Equal(0))
+							Expect(testMongoCollection.Find(bson.M{"uploadId": datasetExistingOne.UploadID, "id": bson.M{"$in": DatasetDataIDs(datasetExistingOneDataCloned)}, "_active": false, "archivedDatasetId": datasetExistingTwo.UploadID}).Count()).To(Equal(3))
+						})
+					})
+
+					Context("RetainDeviceDataArchivedByDataset", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(datasetExistingOne, CloneDatasetData(datasetData))).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+						})
+
+						It("succeeds if it successfully retains device data archived by dataset", func() {
+							Expect(mongoSession.RetainDeviceDataArchivedByDataset(dataset)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.RetainDeviceDataArchivedByDataset(nil)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.RetainDeviceDataArchivedByDataset(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("records the archive of the device data archived by dataset", func() {
+							Expect(mongoSession.RetainDeviceDataArchivedByDataset(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"archivedDatasetId": dataset.UploadID, "_retainedArchive.datasetId": dataset.UploadID, "_retainedArchive.archivedTime": bson.M{"$exists": true}}).Count()).To(Equal(3))
+							Expect(testMongoCollection.Find(bson.M{"_retainedArchive": bson.M{"$exists": true}}).Count()).To(Equal(3))
+						})
+					})
+
+					Context("RearchiveDeviceDataRetainedByDataset", func() {
+						var datasetExistingOneDataCloned []data.Datum
+
+						BeforeEach(func() {
+							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
+							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							Expect(mongoSession.RetainDeviceDataArchivedByDataset(dataset)).To(Succeed())
+							Expect(mongoSession.UnarchiveDeviceDataArchivedByDataset(dataset)).To(Succeed())
+						})
+
+						It("succeeds if it successfully rearchives device data retained by dataset", func() {
+							Expect(mongoSession.RearchiveDeviceDataRetainedByDataset(dataset)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.RearchiveDeviceDataRetainedByDataset(nil)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.RearchiveDeviceDataRetainedByDataset(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("archives again the device data retained by dataset", func() {
+							Expect(testMongoCollection.Find(bson.M{"archivedDatasetId": dataset.UploadID}).Count()).To(Equal(0))
+							Expect(mongoSession.RearchiveDeviceDataRetainedByDataset(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"uploadId": datasetExistingOne.UploadID, "id": bson.M{"$in": DatasetDataIDs(datasetExistingOneDataCloned)}, "_active": false, "archivedDatasetId": dataset.UploadID, "archivedTime": bson.M{"$exists": true}}).Count()).To(Equal(3))
+							Expect(testMongoCollection.Find(bson.M{"_retainedArchive": bson.M{"$exists": true}}).Count()).To(Equal(0))
+						})
+
+						It("does not archive again device data since archived by another dataset", func() {
+							datasetExistingTwoDataCloned := CloneDatasetData(datasetData)
+							Expect(mongoSession.CreateDatasetData(datasetExistingTwo, datasetExistingTwoDataCloned)).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(datasetExistingTwo)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingTwo)).To(Succeed())
+							Expect(mongoSession.RearchiveDeviceDataRetainedByDataset(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"uploadId": datasetExistingOne.UploadID, "id": bson.M{"$in": DatasetDataIDs(datasetExistingOneDataCloned)}, "_active": false, "archivedDatasetId": datasetExistingTwo.UploadID}).Count()).To(Equal(3))
+							Expect(testMongoCollection.Find(bson.M{"_retainedArchive": bson.M{"$exists": true}}).Count()).To(Equal(0))
+						})
+					})
+
+					Context("ArchiveDeviceDataUsingFuzzyHashesFromDataset", func() {
+						var datasetExistingOneDataCloned []data.Datum
+
+						BeforeEach(func() {
+							for _, datasetDatum := range datasetData {
+								datasetDatum.(*types.Base).Deduplicator.FuzzyHash = app.NewID()
+							}
+							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
+							for _, datasetDatum := range datasetExistingOneDataCloned {
+								datasetDatum.(*types.Base).ID = app.NewID()
+							}
+							deviceTime, err := time.Parse("2006-01-02T15:04:05", *datasetData[1].(*types.Base).DeviceTime)
+							Expect(err).ToNot(HaveOccurred())
+							datasetExistingOneDataCloned[1].(*types.Base).DeviceTime = app.StringAsPointer(deviceTime.Add(10 * time.Second).Format("2006-01-02T15:04:05"))
+							deviceTime, err = time.Parse("2006-01-02T15:04:05", *datasetData[2].(*types.Base).DeviceTime)
+							Expect(err).ToNot(HaveOccurred())
+							datasetExistingOneDataCloned[2].(*types.Base).DeviceTime = app.StringAsPointer(deviceTime.Add(2 * time.Minute).Format("2006-01-02T15:04:05"))
+							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully archives device data using fuzzy hashes from dataset", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(nil, 30*time.Second)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the device time tolerance is invalid", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 0)).To(MatchError("mongo: unable to archive device data using fuzzy hashes from dataset; mongo: device time tolerance is invalid"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(MatchError("mongo: session closed"))
+						})
+
+						It("archives device data within the device time tolerance and records the folded into id", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(Succeed())
+							for index, datasetDatum := range datasetExistingOneDataCloned[:2] {
+								Expect(testMongoCollection.Find(bson.M{
+									"id":                         datasetDatum.(*types.Base).ID,
+									"_active":                    false,
+									"archivedDatasetId":          dataset.UploadID,
+									"_deduplicator.foldedIntoId": datasetData[index].(*types.Base).ID,
+								}).Count()).To(Equal(1))
+							}
+							Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[2].(*types.Base).ID, "_active": true, "archivedDatasetId": bson.M{"$exists": false}}).Count()).To(Equal(1))
+							Expect(testMongoCollection.Find(bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}).Count()).To(Equal(4))
+						})
+
+						It("folds at most one device datum into each dataset datum", func() {
+							deviceTime, err := time.Parse("2006-01-02T15:04:05", *datasetData[0].(*types.Base).DeviceTime)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(testMongoCollection.Update(bson.M{"id": datasetExistingOneDataCloned[1].(*types.Base).ID}, bson.M{"$set": bson.M{
+								"deviceTime":              deviceTime.Add(4 * time.Second).Format("2006-01-02T15:04:05"),
+								"_deduplicator.fuzzyHash": datasetData[0].(*types.Base).Deduplicator.FuzzyHash,
+							}})).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[0].(*types.Base).ID, "_active": false, "_deduplicator.foldedIntoId": datasetData[0].(*types.Base).ID}).Count()).To(Equal(1))
+							Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[1].(*types.Base).ID, "_active": true, "archivedDatasetId": bson.M{"$exists": false}}).Count()).To(Equal(1))
+						})
+
+						It("folds each of two distinct device data with the same fuzzy hash within the tolerance into a distinct dataset datum", func() {
+							deviceTime, err := time.Parse("2006-01-02T15:04:05", *datasetData[0].(*types.Base).DeviceTime)
+							Expect(err).ToNot(HaveOccurred())
+							for index, deltas := range [][]time.Duration{{0, 6 * time.Second}, {10 * time.Second, 8 * time.Second}} {
+								Expect(testMongoCollection.Update(bson.M{"id": datasetData[index].(*types.Base).ID}, bson.M{"$set": bson.M{
+									"deviceTime":              deviceTime.Add(deltas[0]).Format("2006-01-02T15:04:05"),
+									"_deduplicator.fuzzyHash": datasetData[0].(*types.Base).Deduplicator.FuzzyHash,
+								}})).To(Succeed())
+								Expect(testMongoCollection.Update(bson.M{"id": datasetExistingOneDataCloned[index].(*types.Base).ID}, bson.M{"$set": bson.M{
+									"deviceTime":              deviceTime.Add(deltas[1]).Format("2006-01-02T15:04:05"),
+									"_deduplicator.fuzzyHash": datasetData[0].(*types.Base).Deduplicator.FuzzyHash,
+								}})).To(Succeed())
+							}
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(Succeed())
+							for _, index := range []int{0, 1} {
+								Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[index].(*types.Base).ID, "_active": false, "_deduplicator.foldedIntoId": datasetData[index].(*types.Base).ID}).Count()).To(Equal(1))
+							}
+						})
+
+						It("does not archive device data with an unparsable device time", func() {
+							Expect(testMongoCollection.Update(bson.M{"id": datasetExistingOneDataCloned[0].(*types.Base).ID}, bson.M{"$set": bson.M{"deviceTime": "invalid"}})).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[0].(*types.Base).ID, "_active": true, "archivedDatasetId": bson.M{"$exists": false}}).Count()).To(Equal(1))
+							Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[1].(*types.Base).ID, "_active": false, "archivedDatasetId": dataset.UploadID}).Count()).To(Equal(1))
+						})
+
+						It("does not archive device data without a fuzzy hash", func() {
+							Expect(testMongoCollection.Update(bson.M{"id": datasetExistingOneDataCloned[0].(*types.Base).ID}, bson.M{"$unset": bson.M{"_deduplicator.fuzzyHash": 1}})).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[0].(*types.Base).ID, "_active": true, "archivedDatasetId": bson.M{"$exists": false}}).Count()).To(Equal(1))
+							Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[1].(*types.Base).ID, "_active": false, "archivedDatasetId": dataset.UploadID}).Count()).To(Equal(1))
+						})
+
+						It("unarchives device data and removes the folded into id", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(Succeed())
+							Expect(mongoSession.UnarchiveDeviceDataArchivedByDataset(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"_deduplicator.foldedIntoId": bson.M{"$exists": true}}).Count()).To(Equal(0))
+							Expect(testMongoCollection.Find(bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}).Count()).To(Equal(6))
+						})
+
+						It("transfers folded device data to the datum a later dataset folded the dataset datum into", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							datasetExistingTwoDataCloned := CloneDatasetData(datasetData)
+							for _, datasetDatum := range datasetExistingTwoDataCloned {
+								datasetDatum.(*types.Base).ID = app.NewID()
+							}
+							Expect(mongoSession.CreateDatasetData(datasetExistingTwo, datasetExistingTwoDataCloned)).To(Succeed())
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(datasetExistingTwo, 30*time.Second)).To(Succeed())
+							Expect(mongoSession.UnarchiveDeviceDataArchivedByDataset(dataset)).To(Succeed())
+							for index, datasetDatum := range datasetExistingOneDataCloned[:2] {
+								Expect(testMongoCollection.Find(bson.M{
+									"id":                         datasetDatum.(*types.Base).ID,
+									"_active":                    false,
+									"archivedDatasetId":          datasetExistingTwo.UploadID,
+									"_deduplicator.foldedIntoId": datasetExistingTwoDataCloned[index].(*types.Base).ID,
+								}).Count()).To(Equal(1))
+							}
+							Expect(testMongoCollection.Find(bson.M{"id": datasetExistingOneDataCloned[2].(*types.Base).ID, "_active": true, "archivedDatasetId": bson.M{"$exists": false}}).Count()).To(Equal(1))
+						})
+					})
+
+					Context("DeleteOtherDatasetData", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully deletes all other dataset data", func() {
+							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							Expect(mongoSession.DeleteOtherDatasetData(nil)).To(MatchError("mongo: dataset is missing"))
+						})
+
+						It("returns an error if the user id is missing", func() {
+							dataset.UserID = ""
+							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset user id is missing"))
+						})
+
+						It("returns an error if the group id is missing", func() {
+							dataset.GroupID = ""
+							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset group id is missing"))
+						})
+
+						It("returns an error if the upload id is missing", func() {
+							dataset.UploadID = ""
+							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+						})
+
+						It("returns an error if the device id is missing (nil)", func() {
+							dataset.DeviceID = nil
+							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						})
+
+						It("returns an error if the device id is missing (empty)", func() {
+							dataset.DeviceID = app.StringAsPointer("")
+							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: session closed"))
+						})
+
+						It("has the correct stored active dataset", func() {
+							ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, dataset, datasetExistingOther, datasetExistingOne, datasetExistingTwo)
+							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset, datasetExistingOther, datasetExistingOne, datasetExistingTwo)
+							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"type": "upload"}).Count()).To(Equal(4))
+							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": bson.M{"$exists": false}}, bson.M{"deletedTime": 0}, datasetExistingTwo, datasetExistingOne)
+							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset, datasetExistingOther)
+						})
+
+						It("has the correct stored active dataset data", func() {
+							datasetDataAfterRemoveData := append(datasetData, datasetExistingOtherData...)
+							datasetDataBeforeRemoveData := append(append(datasetDataAfterRemoveData, datasetExistingOneData...), datasetExistingTwoData...)
+							ValidateDatasetData(testMongoCollection, bson.M{}, bson.M{}, datasetDataBeforeRemoveData)
+							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
+							ValidateDatasetData(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}}, bson.M{}, datasetDataAfterRemoveData)
+							Expect(testMongoCollection.Find(bson.M{"type": bson.M{"$ne": "upload"}, "_active": false, "deletedTime": bson.M{"$exists": true}, "deletedUserId": bson.M{"$exists": false}}).Count()).To(Equal(len(datasetExistingOneData) + len(datasetExistingTwoData)))
+						})
+
+						Context("with agent specified", func() {
+							var agentUserID string
+
+							BeforeEach(func() {
+								agentUserID = app.NewID()
+								mongoSession.SetAgent(&TestAgent{false, agentUserID})
+							})
+
+							It("has the correct stored active dataset", func() {
+								ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, dataset, datasetExistingOther, datasetExistingOne, datasetExistingTwo)
+								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset, datasetExistingOther, datasetExistingOne, datasetExistingTwo)
+								Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
+								Expect(testMongoCollection.Find(bson.M{"type": "upload"}).Count()).To(Equal(4))
+								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": agentUserID}, bson.M{"deletedTime": 0, "deletedUserId": 0}, datasetExistingTwo, datasetExistingOne)
+								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset, datasetExistingOther)
+							})
+
+							It("has the correct stored active dataset data", func() {
+								datasetDataAfterRemoveData := append(datasetData, datasetExistingOtherData...)
+								datasetDataBeforeRemoveData := append(append(datasetDataAfterRemoveData, datasetExistingOneData...), datasetExistingTwoData...)
+								ValidateDatasetData(testMongoCollection, bson.M{}, bson.M{}, datasetDataBeforeRemoveData)
+								Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
+								ValidateDatasetData(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}}, bson.M{}, datasetDataAfterRemoveData)
+								Expect(testMongoCollection.Find(bson.M{"type": bson.M{"$ne": "upload"}, "_active": false, "deletedTime": bson.M{"$exists": true}, "deletedUserId": agentUserID}).Count()).To(Equal(len(datasetExistingOneData) + len(datasetExistingTwoData)))
+							})
+						})
+					})
+
+					Context("PreviewActivateDatasetData", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully previews activating the dataset data", func() {
+							preview, err := mongoSession.PreviewActivateDatasetData(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(3))
+							Expect(preview.SampleIDs).To(ConsistOf(DatasetDataIDs(datasetData)))
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID, "_active": true}).Count()).To(Equal(0))
+						})
+
+						It("previews activating the dataset data as if reopened if the dataset data is already active", func() {
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							preview, err := mongoSession.PreviewActivateDatasetData(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(3))
+							Expect(preview.SampleIDs).To(ConsistOf(DatasetDataIDs(datasetData)))
+						})
+
+						It("returns an error if the dataset is missing", func() {
+							preview, err := mongoSession.PreviewActivateDatasetData(nil)
+							Expect(err).To(MatchError("mongo: dataset is missing"))
+							Expect(preview).To(BeNil())
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							preview, err := mongoSession.PreviewActivateDatasetData(dataset)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(preview).To(BeNil())
+						})
+					})
+
+					Context("PreviewArchiveDeviceDataUsingHashesFromDataset", func() {
+						var datasetExistingOneDataCloned []data.Datum
+
+						BeforeEach(func() {
+							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
+							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully previews archiving device data using hashes from dataset", func() {
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingHashesFromDataset(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(3))
+							Expect(preview.SampleIDs).To(ConsistOf(DatasetDataIDs(datasetExistingOneDataCloned)))
+							Expect(testMongoCollection.Find(bson.M{"archivedDatasetId": dataset.UploadID}).Count()).To(Equal(0))
+						})
+
+						It("previews archiving device data as if reopened if the device data is already archived by the dataset", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingHashesFromDataset(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(3))
+							Expect(preview.SampleIDs).To(ConsistOf(DatasetDataIDs(datasetExistingOneDataCloned)))
+						})
+
+						It("returns an error if the device id is missing (nil)", func() {
+							dataset.DeviceID = nil
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingHashesFromDataset(dataset)
+							Expect(err).To(MatchError("mongo: dataset device id is missing"))
+							Expect(preview).To(BeNil())
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingHashesFromDataset(dataset)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(preview).To(BeNil())
+						})
+					})
+
+					Context("PreviewArchiveDeviceDataUsingTimeWindowFromDataset", func() {
+						var datasetExistingOneDataCloned []data.Datum
+
+						BeforeEach(func() {
+							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
+							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully previews archiving device data using time window from dataset", func() {
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(3))
+							Expect(preview.SampleIDs).To(ConsistOf(DatasetDataIDs(datasetExistingOneDataCloned)))
+							Expect(testMongoCollection.Find(bson.M{"archivedDatasetId": dataset.UploadID}).Count()).To(Equal(0))
+						})
+
+						It("previews archiving device data as if reopened if the device data is already archived by the dataset", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(3))
+							Expect(preview.SampleIDs).To(ConsistOf(DatasetDataIDs(datasetExistingOneDataCloned)))
+						})
+
+						It("returns an error if the device id is missing (nil)", func() {
+							dataset.DeviceID = nil
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset)
+							Expect(err).To(MatchError("mongo: dataset device id is missing"))
+							Expect(preview).To(BeNil())
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(preview).To(BeNil())
+						})
+					})
+
+					Context("PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset", func() {
+						var datasetExistingOneDataCloned []data.Datum
+
+						BeforeEach(func() {
+							for _, datasetDatum := range datasetData {
+								datasetDatum.(*types.Base).Deduplicator.FuzzyHash = app.NewID()
+							}
+							datasetExistingOneDataCloned = CloneDatasetData(datasetData)
+							for _, datasetDatum := range datasetExistingOneDataCloned {
+								datasetDatum.(*types.Base).ID = app.NewID()
+							}
+							Expect(mongoSession.CreateDatasetData(datasetExistingOne, datasetExistingOneDataCloned)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully previews archiving device data using fuzzy hashes from dataset", func() {
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(3))
+							Expect(preview.SampleIDs).To(ConsistOf(DatasetDataIDs(datasetExistingOneDataCloned)))
+							Expect(testMongoCollection.Find(bson.M{"archivedDatasetId": dataset.UploadID}).Count()).To(Equal(0))
+						})
+
+						It("previews archiving device data as if reopened if the device data is already archived by the dataset", func() {
+							Expect(mongoSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(3))
+							Expect(preview.SampleIDs).To(ConsistOf(DatasetDataIDs(datasetExistingOneDataCloned)))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							preview, err := mongoSession.PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, 30*time.Second)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(preview).To(BeNil())
+						})
+					})
+
+					Context("PreviewDeleteOtherDatasets", func() {
+						It("succeeds if it successfully previews deleting other datasets", func() {
+							preview, err := mongoSession.PreviewDeleteOtherDatasets(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(2))
+							Expect(preview.SampleIDs).To(ConsistOf(datasetExistingOne.ID, datasetExistingTwo.ID))
+							Expect(testMongoCollection.Find(bson.M{"type": "upload", "deletedTime": bson.M{"$exists": true}}).Count()).To(Equal(0))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							preview, err := mongoSession.PreviewDeleteOtherDatasets(dataset)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(preview).To(BeNil())
+						})
+					})
+
+					Context("PreviewDeleteOtherDatasetData", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+						})
+
+						It("succeeds if it successfully previews deleting other dataset data", func() {
+							preview, err := mongoSession.PreviewDeleteOtherDatasetData(dataset)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(preview.Count).To(Equal(6))
+							Expect(preview.SampleIDs).To(ConsistOf(DatasetDataIDs(append(datasetExistingOneData, datasetExistingTwoData...))))
+							Expect(testMongoCollection.Find(bson.M{"type": bson.M{"$ne": "upload"}}).Count()).To(Equal(12))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							preview, err := mongoSession.PreviewDeleteOtherDatasetData(dataset)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(preview).To(BeNil())
+						})
+					})
+
+					Context("GetDataForDatasetByID", func() {
+						var filter *store.DatasetDataFilter
+						var pagination *store.Pagination
+
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(datasetExistingOne)).To(Succeed())
+							Expect(testMongoCollection.Update(bson.M{"id": datasetExistingOneData[0].(*types.Base).ID}, bson.M{"$set": bson.M{"_active": false, "archivedDatasetId": dataset.UploadID, "archivedTime": "2016-09-01T12:00:00Z"}})).To(Succeed())
+							filter = store.NewDatasetDataFilter()
+							pagination = store.NewPagination()
+						})
+
+						It("succeeds if it successfully finds the dataset data", func() {
+							resultData, err := mongoSession.GetDataForDatasetByID(dataset.UploadID, filter, pagination)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(resultData).To(HaveLen(3))
+							for _, resultDatum := range resultData {
+								Expect(resultDatum).To(HaveKeyWithValue("uploadId", dataset.UploadID))
+								Expect(resultDatum).ToNot(HaveKey("_userId"))
+								Expect(resultDatum).ToNot(HaveKey("_active"))
+							}
+						})
+
+						It("succeeds if it excludes archived dataset data", func() {
+							resultData, err := mongoSession.GetDataForDatasetByID(datasetExistingOne.UploadID, filter, pagination)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(resultData).To(HaveLen(2))
+						})
+
+						It("succeeds if it includes archived dataset data", func() {
+							filter.Archived = true
+							resultData, err := mongoSession.GetDataForDatasetByID(datasetExistingOne.UploadID, filter, pagination)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(resultData).To(HaveLen(3))
+							archivedData := []map[string]interface{}{}
+							for _, resultDatum := range resultData {
+								if _, ok := resultDatum["archivedDatasetId"]; ok {
+									archivedData = append(archivedData, resultDatum)
+								}
+							}
+							Expect(archivedData).To(HaveLen(1))
+							Expect(archivedData[0]).To(HaveKeyWithValue("archivedDatasetId", dataset.UploadID))
+							Expect(archivedData[0]).To(HaveKeyWithValue("archivedTime", "2016-09-01T12:00:00Z"))
+						})
+
+						It("succeeds if the filter and pagination are not specified", func() {
+							Expect(mongoSession.GetDataForDatasetByID(dataset.UploadID, nil, nil)).To(HaveLen(3))
+						})
+
+						It("succeeds if the pagination size is not default", func() {
+							pagination.Size = 2
+							Expect(mongoSession.GetDataForDatasetByID(dataset.UploadID, filter, pagination)).To(HaveLen(2))
+						})
+
+						It("succeeds if it successfully does not find another dataset data", func() {
+							resultData, err := mongoSession.GetDataForDatasetByID(app.NewID(), filter, pagination)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(resultData).ToNot(BeNil())
+							Expect(resultData).To(BeEmpty())
+						})
+
+						It("returns an error if the dataset id is missing", func() {
+							resultData, err := mongoSession.GetDataForDatasetByID("", filter, pagination)
+							Expect(err).To(MatchError("mongo: dataset id is missing"))
+							Expect(resultData).To(BeNil())
+						})
+
+						It("returns an error if the pagination size is greater than maximum", func() {
+							pagination.Size = 101
+							resultData, err := mongoSession.GetDataForDatasetByID(dataset.UploadID, filter, pagination)
+							Expect(err).To(MatchError("mongo: pagination is invalid; store: size is invalid"))
+							Expect(resultData).To(BeNil())
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							resultData, err := mongoSession.GetDataForDatasetByID(dataset.UploadID, filter, pagination)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(resultData).To(BeNil())
+						})
+					})
+
+					Context("GetDataForUserByID", func() {
+						var filter *store.DataFilter
+						var pagination *store.Pagination
+
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							filter = store.NewDataFilter()
+							pagination = store.NewPagination()
+						})
+
+						It("succeeds if it successfully finds the active user data", func() {
+							resultData, err := mongoSession.GetDataForUserByID(userID, filter, pagination)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(resultData).To(HaveLen(3))
+							for _, resultDatum := range resultData {
+								Expect(resultDatum).To(HaveKeyWithValue("uploadId", dataset.UploadID))
+								Expect(resultDatum).ToNot(HaveKey("_userId"))
+								Expect(resultDatum).ToNot(HaveKey("_active"))
+							}
+						})
+
+						It("succeeds if the filter is not specified", func() {
+							Expect(mongoSession.GetDataForUserByID(userID, nil, pagination)).To(HaveLen(3))
 						})
 
-						It("returns an error if the device id is missing (empty)", func() {
-							dataset.DeviceID = app.StringAsPointer("")
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						It("succeeds if the pagination is not specified", func() {
+							Expect(mongoSession.GetDataForUserByID(userID, filter, nil)).To(HaveLen(3))
 						})
 
-						It("returns an error if the session is closed", func() {
-							mongoSession.Close()
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(MatchError("mongo: session closed"))
+						It("succeeds if the pagination size is not default", func() {
+							pagination.Size = 2
+							Expect(mongoSession.GetDataForUserByID(userID, filter, pagination)).To(HaveLen(2))
 						})
 
-						It("has the correct stored datasets", func() {
-							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
-							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+						It("succeeds if the pagination cursor is specified", func() {
+							pagination.Size = 2
+							firstData, err := mongoSession.GetDataForUserByID(userID, filter, pagination)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(firstData).To(HaveLen(2))
+							lastDatum := firstData[len(firstData)-1]
+							pagination.Cursor = store.NewCursor(lastDatum["time"].(string), lastDatum["id"].(string))
+							secondData, err := mongoSession.GetDataForUserByID(userID, filter, pagination)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(secondData).To(HaveLen(1))
+							Expect([]interface{}{firstData[0]["id"], firstData[1]["id"]}).ToNot(ContainElement(secondData[0]["id"]))
 						})
 
-						It("has the correct stored unarchived dataset data", func() {
-							for _, datasetDatum := range append(datasetData, datasetExistingOneData...) {
-								datasetDatum.SetActive(true)
-							}
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingOneDataCloned)
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingOneData)
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, datasetData)
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
-							for _, datasetDatum := range datasetExistingOneDataCloned {
-								datasetDatum.SetActive(true)
-							}
-							ValidateDatasetData(testMongoCollection,
-								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
-								bson.M{"modifiedTime": 0},
-								append(datasetExistingOneData, datasetExistingOneDataCloned...))
-							ValidateDatasetData(testMongoCollection,
-								bson.M{"uploadId": dataset.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
-								bson.M{"modifiedTime": 0},
-								datasetData)
+						It("succeeds if the filter type matches", func() {
+							filter.Types = []string{"test"}
+							Expect(mongoSession.GetDataForUserByID(userID, filter, pagination)).To(HaveLen(3))
 						})
 
-						It("has the correct stored datasets if an intermediary is unarchived", func() {
-							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{}, datasetExistingTwo)
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
-							ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{}, datasetExistingTwo)
+						It("succeeds if the filter type does not match", func() {
+							filter.Types = []string{"cbg"}
+							Expect(mongoSession.GetDataForUserByID(userID, filter, pagination)).To(BeEmpty())
 						})
 
-						It("has the correct stored unarchived dataset data if an intermediary is unarchived", func() {
-							for _, datasetDatum := range append(datasetExistingOneData, datasetExistingTwoData...) {
-								datasetDatum.SetActive(true)
-							}
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": false}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingTwoDataCloned)
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingTwoData)
-							ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, datasetExistingOneData)
-							Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
-							ValidateDatasetData(testMongoCollection,
-								bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
-								bson.M{"modifiedTime": 0},
-								datasetExistingTwoData)
-							ValidateDatasetData(testMongoCollection,
-								bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
-								bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0},
-								datasetExistingTwoDataCloned)
-							ValidateDatasetData(testMongoCollection,
-								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
-								bson.M{"modifiedTime": 0},
-								datasetExistingOneData)
-							ValidateDatasetData(testMongoCollection,
-								bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID},
-								bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0},
-								datasetExistingOneDataCloned)
+						It("succeeds if the filter upload id does not match", func() {
+							filter.UploadID = datasetExistingOne.UploadID
+							Expect(mongoSession.GetDataForUserByID(userID, filter, pagination)).To(BeEmpty())
 						})
 
-						Context("with agent specified", func() {
-							var agentUserID string
+						It("succeeds if the filter end time is before all data", func() {
+							endTime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+							filter.EndTime = &endTime
+							Expect(mongoSession.GetDataForUserByID(userID, filter, pagination)).To(BeEmpty())
+						})
 
+						Context("with times with time zone offsets and fractional seconds", func() {
 							BeforeEach(func() {
-								agentUserID = app.NewID()
-								mongoSession.SetAgent(&TestAgent{false, agentUserID})
+								for index, datasetTime := range []string{"2016-09-01T14:00:00+02:00", "2016-09-01T12:00:00.500Z", "2016-09-01T13:59:59.999+02:00"} {
+									Expect(testMongoCollection.Update(bson.M{"id": datasetData[index].(*types.Base).ID}, bson.M{"$set": bson.M{"time": datasetTime}})).To(Succeed())
+								}
 							})
 
-							It("has the correct stored datasets", func() {
-								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
-								Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
-								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{}, datasetExistingOne)
+							It("compares the filter start and end times exactly", func() {
+								startTime := time.Date(2016, 9, 1, 12, 0, 0, 0, time.UTC)
+								endTime := time.Date(2016, 9, 1, 12, 0, 0, 500000000, time.UTC)
+								filter.StartTime = &startTime
+								filter.EndTime = &endTime
+								resultData, err := mongoSession.GetDataForUserByID(userID, filter, pagination)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(resultData).To(HaveLen(1))
+								Expect(resultData[0]).To(HaveKeyWithValue("id", datasetData[0].(*types.Base).ID))
 							})
 
-							It("has the correct stored unarchived dataset data", func() {
-								for _, datasetDatum := range append(datasetData, datasetExistingOneData...) {
-									datasetDatum.SetActive(true)
-								}
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingOneDataCloned)
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingOneData)
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": dataset.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, datasetData)
-								Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)).To(Succeed())
-								for _, datasetDatum := range datasetExistingOneDataCloned {
-									datasetDatum.SetActive(true)
+							It("returns each datum exactly once when paging with the cursor", func() {
+								pagination.Size = 1
+								resultIDs := []interface{}{}
+								for count := 0; count < 4; count++ {
+									resultData, err := mongoSession.GetDataForUserByID(userID, filter, pagination)
+									Expect(err).ToNot(HaveOccurred())
+									if len(resultData) == 0 {
+										break
+									}
+									resultIDs = append(resultIDs, resultData[0]["id"])
+									pagination.Cursor = store.NewCursor(resultData[0]["time"].(string), resultData[0]["id"].(string))
 								}
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID},
-									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
-									datasetExistingOneDataCloned)
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": bson.M{"$exists": false}},
-									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
-									datasetExistingOneData)
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": dataset.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
-									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
-									datasetData)
+								Expect(resultIDs).To(ConsistOf(datasetData[0].(*types.Base).ID, datasetData[1].(*types.Base).ID, datasetData[2].(*types.Base).ID))
 							})
+						})
 
-							It("has the correct stored datasets if an intermediary is unarchived", func() {
-								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{}, datasetExistingTwo)
-								Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
-								ValidateDataset(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{}, datasetExistingTwo)
-							})
+						It("succeeds if it successfully does not find another user data", func() {
+							resultData, err := mongoSession.GetDataForUserByID(app.NewID(), filter, pagination)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(resultData).ToNot(BeNil())
+							Expect(resultData).To(BeEmpty())
+						})
 
-							It("has the correct stored unarchived dataset data if an intermediary is unarchived", func() {
-								for _, datasetDatum := range append(datasetExistingOneData, datasetExistingTwoData...) {
-									datasetDatum.SetActive(true)
-								}
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": false}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingTwoDataCloned)
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true}, bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0}, datasetExistingTwoData)
-								ValidateDatasetData(testMongoCollection, bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true}, bson.M{"modifiedTime": 0}, datasetExistingOneData)
-								Expect(mongoSession.UnarchiveDeviceDataUsingHashesFromDataset(datasetExistingOne)).To(Succeed())
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
-									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
-									datasetExistingTwoData)
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": datasetExistingTwo.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID, "modifiedTime": bson.M{"$exists": true}, "modifiedUserId": agentUserID},
-									bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0, "modifiedUserId": 0},
-									datasetExistingTwoDataCloned)
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": true, "archivedTime": bson.M{"$exists": false}, "archivedDatasetId": bson.M{"$exists": false}},
-									bson.M{"modifiedTime": 0, "modifiedUserId": 0},
-									datasetExistingOneData)
-								ValidateDatasetData(testMongoCollection,
-									bson.M{"uploadId": datasetExistingOne.UploadID, "_active": false, "archivedTime": bson.M{"$exists": true}, "archivedDatasetId": dataset.UploadID},
-									bson.M{"archivedTime": 0, "archivedDatasetId": 0, "modifiedTime": 0, "modifiedUserId": 0},
-									datasetExistingOneDataCloned)
-							})
+						It("returns an error if the user id is missing", func() {
+							resultData, err := mongoSession.GetDataForUserByID("", filter, pagination)
+							Expect(err).To(MatchError("mongo: user id is missing"))
+							Expect(resultData).To(BeNil())
+						})
+
+						It("returns an error if the filter is invalid", func() {
+							filter.Types = []string{""}
+							resultData, err := mongoSession.GetDataForUserByID(userID, filter, pagination)
+							Expect(err).To(MatchError("mongo: filter is invalid; store: type is empty"))
+							Expect(resultData).To(BeNil())
+						})
+
+						It("returns an error if the pagination size is greater than maximum", func() {
+							pagination.Size = 101
+							resultData, err := mongoSession.GetDataForUserByID(userID, filter, pagination)
+							Expect(err).To(MatchError("mongo: pagination is invalid; store: size is invalid"))
+							Expect(resultData).To(BeNil())
+						})
 
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							resultData, err := mongoSession.GetDataForUserByID(userID, filter, pagination)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(resultData).To(BeNil())
 						})
 					})
 
-					Context("DeleteOtherDatasetData", func() {
+					Context("IterateDataForUserByID", func() {
+						var filter *store.DataFilter
+
 						BeforeEach(func() {
 							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.ActivateDatasetData(dataset)).To(Succeed())
+							filter = store.NewDataFilter()
+						})
+
+						It("succeeds if it successfully iterates the active user data", func() {
+							iterator, err := mongoSession.IterateDataForUserByID(userID, filter)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(iterator).ToNot(BeNil())
+							var resultData []map[string]interface{}
+							var resultDatum map[string]interface{}
+							for iterator.Next(&resultDatum) {
+								Expect(resultDatum).To(HaveKeyWithValue("uploadId", dataset.UploadID))
+								Expect(resultDatum).ToNot(HaveKey("_userId"))
+								resultData = append(resultData, resultDatum)
+								resultDatum = nil
+							}
+							Expect(iterator.Err()).ToNot(HaveOccurred())
+							Expect(iterator.Close()).To(Succeed())
+							Expect(resultData).To(HaveLen(3))
 						})
 
-						It("succeeds if it successfully deletes all other dataset data", func() {
-							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
-						})
-
-						It("returns an error if the dataset is missing", func() {
-							Expect(mongoSession.DeleteOtherDatasetData(nil)).To(MatchError("mongo: dataset is missing"))
+						It("succeeds if the filter type does not match", func() {
+							filter.Types = []string{"cbg"}
+							iterator, err := mongoSession.IterateDataForUserByID(userID, filter)
+							Expect(err).ToNot(HaveOccurred())
+							var resultDatum map[string]interface{}
+							Expect(iterator.Next(&resultDatum)).To(BeFalse())
+							Expect(iterator.Close()).To(Succeed())
 						})
 
 						It("returns an error if the user id is missing", func() {
-							dataset.UserID = ""
-							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset user id is missing"))
+							iterator, err := mongoSession.IterateDataForUserByID("", filter)
+							Expect(err).To(MatchError("mongo: user id is missing"))
+							Expect(iterator).To(BeNil())
 						})
 
-						It("returns an error if the group id is missing", func() {
-							dataset.GroupID = ""
-							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset group id is missing"))
+						It("returns an error if the filter is invalid", func() {
+							filter.Types = []string{""}
+							iterator, err := mongoSession.IterateDataForUserByID(userID, filter)
+							Expect(err).To(MatchError("mongo: filter is invalid; store: type is empty"))
+							Expect(iterator).To(BeNil())
 						})
 
-						It("returns an error if the upload id is missing", func() {
-							dataset.UploadID = ""
-							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset upload id is missing"))
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							iterator, err := mongoSession.IterateDataForUserByID(userID, filter)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(iterator).To(BeNil())
 						})
+					})
 
-						It("returns an error if the device id is missing (nil)", func() {
-							dataset.DeviceID = nil
-							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset device id is missing"))
+					Context("IterateAllDataForUserByID", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.DeleteDataset(datasetExistingOne)).To(Succeed())
+						})
+
+						It("succeeds if it successfully iterates all user data and datasets, including inactive and deleted", func() {
+							iterator, err := mongoSession.IterateAllDataForUserByID(userID)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(iterator).ToNot(BeNil())
+							uploadIDs := map[string]int{}
+							inactiveCount := 0
+							deletedCount := 0
+							var resultDatum map[string]interface{}
+							for iterator.Next(&resultDatum) {
+								Expect(resultDatum).ToNot(HaveKey("_id"))
+								Expect(resultDatum).ToNot(HaveKey("_userId"))
+								Expect(resultDatum).ToNot(HaveKey("_softDeleted"))
+								uploadIDs[resultDatum["uploadId"].(string)]++
+								if resultDatum["_active"] == false {
+									inactiveCount++
+								}
+								if _, ok := resultDatum["deletedTime"]; ok {
+									deletedCount++
+								}
+								resultDatum = nil
+							}
+							Expect(iterator.Err()).ToNot(HaveOccurred())
+							Expect(iterator.Close()).To(Succeed())
+							Expect(uploadIDs).To(HaveKeyWithValue(dataset.UploadID, len(datasetData)+1))
+							Expect(uploadIDs).To(HaveKey(datasetExistingOne.UploadID))
+							Expect(uploadIDs).To(HaveKey(datasetExistingTwo.UploadID))
+							Expect(uploadIDs).ToNot(HaveKey(datasetExistingOther.UploadID))
+							Expect(inactiveCount).To(BeNumerically(">=", len(datasetData)))
+							Expect(deletedCount).To(BeNumerically(">=", 1))
 						})
 
-						It("returns an error if the device id is missing (empty)", func() {
-							dataset.DeviceID = app.StringAsPointer("")
-							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: dataset device id is missing"))
+						It("returns an error if the user id is missing", func() {
+							iterator, err := mongoSession.IterateAllDataForUserByID("")
+							Expect(err).To(MatchError("mongo: user id is missing"))
+							Expect(iterator).To(BeNil())
 						})
 
 						It("returns an error if the session is closed", func() {
 							mongoSession.Close()
-							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(MatchError("mongo: session closed"))
+							iterator, err := mongoSession.IterateAllDataForUserByID(userID)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(iterator).To(BeNil())
 						})
+					})
 
-						It("has the correct stored active dataset", func() {
-							ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, dataset, datasetExistingOther, datasetExistingOne, datasetExistingTwo)
-							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset, datasetExistingOther, datasetExistingOne, datasetExistingTwo)
-							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
-							Expect(testMongoCollection.Find(bson.M{"type": "upload"}).Count()).To(Equal(4))
-							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": bson.M{"$exists": false}}, bson.M{"deletedTime": 0}, datasetExistingTwo, datasetExistingOne)
-							ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset, datasetExistingOther)
+					Context("with restore data", func() {
+						var restoreUserID string
+						var restoreID string
+						var restoreTime time.Time
+						var restoreData []data.Datum
+
+						BeforeEach(func() {
+							restoreUserID = app.NewID()
+							restoreID = app.NewID()
+							restoreTime, _ = time.Parse(time.RFC3339, "2016-09-02T00:00:00Z")
+							restoreData = append(NewDatasetData(app.NewID()), NewDatasetData(app.NewID())[0])
+							for _, datasetDatum := range restoreData {
+								baseDatum := datasetDatum.(*types.Base)
+								baseDatum.UserID = restoreUserID
+								baseDatum.GroupID = app.NewID()
+								baseDatum.UploadID = app.NewID()
+								baseDatum.CreatedTime = "2016-09-01T10:00:00Z"
+							}
+							restoreData[0].(*types.Base).Active = true
+							restoreData[1].(*types.Base).ArchivedDatasetID = app.NewID()
+							restoreData[1].(*types.Base).ArchivedTime = "2016-09-03T00:00:00Z"
+							restoreData[2].(*types.Base).Active = true
+							restoreData[2].(*types.Base).CreatedTime = "2016-09-02T12:00:00Z"
+							restoreData[3].(*types.Base).ArchivedDatasetID = app.NewID()
+							restoreData[3].(*types.Base).ArchivedTime = "2016-09-01T12:00:00Z"
+							Expect(testMongoCollection.Insert(DatasetDataAsInterface(restoreData)...)).To(Succeed())
+						})
+
+						Context("RestoreDataForUserByID", func() {
+							It("succeeds if it successfully restores data for user by id", func() {
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)).To(BeTrue())
+							})
+
+							It("returns an error if the user id is missing", func() {
+								restored, err := mongoSession.RestoreDataForUserByID("", restoreID, restoreTime)
+								Expect(err).To(MatchError("mongo: user id is missing"))
+								Expect(restored).To(BeFalse())
+							})
+
+							It("returns an error if the restore id is missing", func() {
+								restored, err := mongoSession.RestoreDataForUserByID(restoreUserID, "", restoreTime)
+								Expect(err).To(MatchError("mongo: restore id is missing"))
+								Expect(restored).To(BeFalse())
+							})
+
+							It("returns an error if the restore time is missing", func() {
+								restored, err := mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, time.Time{})
+								Expect(err).To(MatchError("mongo: restore time is missing"))
+								Expect(restored).To(BeFalse())
+							})
+
+							It("returns an error if the session is closed", func() {
+								mongoSession.Close()
+								restored, err := mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)
+								Expect(err).To(MatchError("mongo: session closed"))
+								Expect(restored).To(BeFalse())
+							})
+
+							It("activates data archived after and archives data created after the restore time", func() {
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)).To(BeTrue())
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[0].(*types.Base).ID, "_active": true, "modifiedTime": bson.M{"$exists": false}}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[1].(*types.Base).ID, "_active": true, "archivedDatasetId": bson.M{"$exists": false}, "archivedTime": bson.M{"$exists": false}}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[2].(*types.Base).ID, "_active": false, "archivedTime": bson.M{"$exists": true}}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[3].(*types.Base).ID, "_active": false, "archivedTime": "2016-09-01T12:00:00Z"}).Count()).To(Equal(1))
+							})
+
+							It("does not restore data while an earlier restore is not undone", func() {
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)).To(BeTrue())
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, app.NewID(), restoreTime.Add(-24*time.Hour))).To(BeFalse())
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[1].(*types.Base).ID, "_restore.id": restoreID, "_restore.archivedDatasetId": restoreData[1].(*types.Base).ArchivedDatasetID}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[2].(*types.Base).ID, "archivedDatasetId": restoreID}).Count()).To(Equal(1))
+							})
+
+							It("does not restore data while an earlier restore that only archived data is not undone", func() {
+								Expect(testMongoCollection.Remove(bson.M{"id": restoreData[1].(*types.Base).ID})).To(Succeed())
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)).To(BeTrue())
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, app.NewID(), restoreTime)).To(BeFalse())
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[2].(*types.Base).ID, "archivedDatasetId": restoreID}).Count()).To(Equal(1))
+							})
+
+							It("restores data again once the earlier restore is undone", func() {
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)).To(BeTrue())
+								Expect(mongoSession.UndoRestoreDataForUserByID(restoreUserID, restoreID)).To(Succeed())
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, app.NewID(), restoreTime)).To(BeTrue())
+							})
+
+							It("records the restore id on the activated and archived data", func() {
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)).To(BeTrue())
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[1].(*types.Base).ID, "_restore.id": restoreID, "_restore.archivedDatasetId": restoreData[1].(*types.Base).ArchivedDatasetID, "_restore.archivedTime": "2016-09-03T00:00:00Z"}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[2].(*types.Base).ID, "archivedDatasetId": restoreID, "_restore.archived": true}).Count()).To(Equal(1))
+							})
+
+							It("compares legacy times with fractional seconds exactly", func() {
+								legacyData := append(NewDatasetData(app.NewID())[0:1], NewDatasetData(app.NewID())[0:2]...)
+								for _, datasetDatum := range legacyData {
+									baseDatum := datasetDatum.(*types.Base)
+									baseDatum.UserID = restoreUserID
+									baseDatum.GroupID = app.NewID()
+									baseDatum.UploadID = app.NewID()
+									baseDatum.CreatedTime = "2016-09-01T10:00:00Z"
+								}
+								legacyData[0].(*types.Base).Active = true
+								legacyData[0].(*types.Base).CreatedTime = "2016-09-02T00:00:00.500Z"
+								legacyData[1].(*types.Base).ArchivedDatasetID = app.NewID()
+								legacyData[1].(*types.Base).ArchivedTime = "2016-09-02T00:00:00.500Z"
+								legacyData[2].(*types.Base).ArchivedDatasetID = app.NewID()
+								legacyData[2].(*types.Base).ArchivedTime = "2016-09-02T00:00:00Z"
+								Expect(testMongoCollection.Insert(DatasetDataAsInterface(legacyData)...)).To(Succeed())
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)).To(BeTrue())
+								Expect(testMongoCollection.Find(bson.M{"id": legacyData[0].(*types.Base).ID, "_active": false, "archivedDatasetId": restoreID}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": legacyData[1].(*types.Base).ID, "_active": true, "_restore.id": restoreID}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": legacyData[2].(*types.Base).ID, "_active": false, "archivedTime": "2016-09-02T00:00:00Z"}).Count()).To(Equal(1))
+							})
 						})
 
-						It("has the correct stored active dataset data", func() {
-							datasetDataAfterRemoveData := append(datasetData, datasetExistingOtherData...)
-							datasetDataBeforeRemoveData := append(append(datasetDataAfterRemoveData, datasetExistingOneData...), datasetExistingTwoData...)
-							ValidateDatasetData(testMongoCollection, bson.M{}, bson.M{}, datasetDataBeforeRemoveData)
-							Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
-							ValidateDatasetData(testMongoCollection, bson.M{}, bson.M{"deletedTime": 0}, datasetDataAfterRemoveData)
+						Context("UndoRestoreDataForUserByID", func() {
+							It("returns an error if the user id is missing", func() {
+								Expect(mongoSession.UndoRestoreDataForUserByID("", restoreID)).To(MatchError("mongo: user id is missing"))
+							})
+
+							It("returns an error if the restore id is missing", func() {
+								Expect(mongoSession.UndoRestoreDataForUserByID(restoreUserID, "")).To(MatchError("mongo: restore id is missing"))
+							})
+
+							It("returns an error if the session is closed", func() {
+								mongoSession.Close()
+								Expect(mongoSession.UndoRestoreDataForUserByID(restoreUserID, restoreID)).To(MatchError("mongo: session closed"))
+							})
+
+							It("undoes the restore of data for user by id", func() {
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)).To(BeTrue())
+								Expect(mongoSession.UndoRestoreDataForUserByID(restoreUserID, restoreID)).To(Succeed())
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[0].(*types.Base).ID, "_active": true, "modifiedTime": bson.M{"$exists": false}}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[1].(*types.Base).ID, "_active": false, "archivedDatasetId": restoreData[1].(*types.Base).ArchivedDatasetID, "archivedTime": "2016-09-03T00:00:00Z", "_restore": bson.M{"$exists": false}}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[2].(*types.Base).ID, "_active": true, "archivedDatasetId": bson.M{"$exists": false}, "archivedTime": bson.M{"$exists": false}, "_restore": bson.M{"$exists": false}}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[3].(*types.Base).ID, "_active": false, "archivedTime": "2016-09-01T12:00:00Z"}).Count()).To(Equal(1))
+							})
+
+							It("does not undo a different restore", func() {
+								Expect(mongoSession.RestoreDataForUserByID(restoreUserID, restoreID, restoreTime)).To(BeTrue())
+								Expect(mongoSession.UndoRestoreDataForUserByID(restoreUserID, app.NewID())).To(Succeed())
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[1].(*types.Base).ID, "_active": true, "_restore.id": restoreID}).Count()).To(Equal(1))
+								Expect(testMongoCollection.Find(bson.M{"id": restoreData[2].(*types.Base).ID, "_active": false, "archivedDatasetId": restoreID}).Count()).To(Equal(1))
+							})
 						})
 
-						Context("with agent specified", func() {
-							var agentUserID string
+						Context("PreviewRestoreDataForUserByID", func() {
+							It("returns an error if the user id is missing", func() {
+								preview, err := mongoSession.PreviewRestoreDataForUserByID("", restoreTime)
+								Expect(err).To(MatchError("mongo: user id is missing"))
+								Expect(preview).To(BeNil())
+							})
 
-							BeforeEach(func() {
-								agentUserID = app.NewID()
-								mongoSession.SetAgent(&TestAgent{false, agentUserID})
+							It("returns an error if the restore time is missing", func() {
+								preview, err := mongoSession.PreviewRestoreDataForUserByID(restoreUserID, time.Time{})
+								Expect(err).To(MatchError("mongo: restore time is missing"))
+								Expect(preview).To(BeNil())
 							})
 
-							It("has the correct stored active dataset", func() {
-								ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, dataset, datasetExistingOther, datasetExistingOne, datasetExistingTwo)
-								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset, datasetExistingOther, datasetExistingOne, datasetExistingTwo)
-								Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
-								Expect(testMongoCollection.Find(bson.M{"type": "upload"}).Count()).To(Equal(4))
-								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": true}, "deletedUserId": agentUserID}, bson.M{"deletedTime": 0, "deletedUserId": 0}, datasetExistingTwo, datasetExistingOne)
-								ValidateDataset(testMongoCollection, bson.M{"deletedTime": bson.M{"$exists": false}, "deletedUserId": bson.M{"$exists": false}}, bson.M{}, dataset, datasetExistingOther)
+							It("returns an error if the session is closed", func() {
+								mongoSession.Close()
+								preview, err := mongoSession.PreviewRestoreDataForUserByID(restoreUserID, restoreTime)
+								Expect(err).To(MatchError("mongo: session closed"))
+								Expect(preview).To(BeNil())
 							})
 
-							It("has the correct stored active dataset data", func() {
-								datasetDataAfterRemoveData := append(datasetData, datasetExistingOtherData...)
-								datasetDataBeforeRemoveData := append(append(datasetDataAfterRemoveData, datasetExistingOneData...), datasetExistingTwoData...)
-								ValidateDatasetData(testMongoCollection, bson.M{}, bson.M{}, datasetDataBeforeRemoveData)
-								Expect(mongoSession.DeleteOtherDatasetData(dataset)).To(Succeed())
-								datasetExistingOne.SetDeletedUserID(agentUserID)
-								datasetExistingTwo.SetDeletedUserID(agentUserID)
-								ValidateDatasetData(testMongoCollection, bson.M{}, bson.M{"deletedTime": 0}, datasetDataAfterRemoveData)
+							It("returns the data that would be activated and archived without modification", func() {
+								preview, err := mongoSession.PreviewRestoreDataForUserByID(restoreUserID, restoreTime)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(preview.Activate).To(Equal(&store.DataPreview{Count: 1, SampleIDs: []string{restoreData[1].(*types.Base).ID}}))
+								Expect(preview.Archive).To(Equal(&store.DataPreview{Count: 1, SampleIDs: []string{restoreData[2].(*types.Base).ID}}))
+								Expect(testMongoCollection.Find(bson.M{"_userId": restoreUserID, "_active": true}).Count()).To(Equal(2))
 							})
 						})
 					})
@@ -1316,6 +3046,14 @@ var _ = Describe("Mongo", func() {
 							Expect(mongoSession.DestroyDataForUserByID(deleteUserID)).To(Succeed())
 						})
 
+						It("destroys the dataset chunks for user by id", func() {
+							Expect(mongoSession.CreateDatasetChunk(deleteDataset, "chunk-one", []string{})).To(BeTrue())
+							Expect(mongoSession.CreateDatasetChunk(dataset, "chunk-one", []string{})).To(BeTrue())
+							Expect(mongoSession.DestroyDataForUserByID(deleteUserID)).To(Succeed())
+							Expect(mongoSession.GetDatasetChunk(deleteDataset, "chunk-one")).To(BeNil())
+							Expect(mongoSession.GetDatasetChunk(dataset, "chunk-one")).ToNot(BeNil())
+						})
+
 						It("returns an error if the user id is missing", func() {
 							Expect(mongoSession.DestroyDataForUserByID("")).To(MatchError("mongo: user id is missing"))
 						})
@@ -1339,6 +3077,55 @@ var _ = Describe("Mongo", func() {
 							ValidateDatasetData(testMongoCollection, bson.M{}, bson.M{}, datasetDataAfterRemoveData)
 						})
 					})
+
+					Context("DestroyDeletedData", func() {
+						BeforeEach(func() {
+							Expect(mongoSession.CreateDatasetData(dataset, datasetData)).To(Succeed())
+							Expect(mongoSession.DeleteDataset(dataset)).To(Succeed())
+						})
+
+						It("succeeds if it successfully destroys deleted data", func() {
+							Expect(mongoSession.DestroyDeletedData(time.Now())).To(Succeed())
+						})
+
+						It("returns an error if the deleted before time is missing", func() {
+							Expect(mongoSession.DestroyDeletedData(time.Time{})).To(MatchError("mongo: deleted before time is missing"))
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							Expect(mongoSession.DestroyDeletedData(time.Now())).To(MatchError("mongo: session closed"))
+						})
+
+						It("does not destroy data deleted after the deleted before time", func() {
+							Expect(mongoSession.DestroyDeletedData(time.Now().Add(-time.Hour))).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID}).Count()).To(Equal(len(datasetData) + 1))
+						})
+
+						It("destroys the chunks of the deleted dataset deleted before the deleted before time", func() {
+							Expect(testMongoCollection.Database.C(testMongoCollection.Name + "Chunks").Insert(bson.M{"_id": dataset.UploadID + ":chunk-one", "uploadId": dataset.UploadID, "chunkId": "chunk-one"})).To(Succeed())
+							Expect(mongoSession.DestroyDeletedData(time.Now().Add(time.Hour))).To(Succeed())
+							Expect(mongoSession.GetDatasetChunk(dataset, "chunk-one")).To(BeNil())
+						})
+
+						It("destroys the deleted dataset and dataset data deleted before the deleted before time", func() {
+							Expect(mongoSession.DestroyDeletedData(time.Now().Add(time.Hour))).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"uploadId": dataset.UploadID}).Count()).To(Equal(0))
+							ValidateDataset(testMongoCollection, bson.M{}, bson.M{}, datasetExistingOther, datasetExistingOne, datasetExistingTwo)
+						})
+
+						It("does not destroy data deleted other than by soft deletion", func() {
+							Expect(testMongoCollection.Update(bson.M{"uploadId": datasetExistingOne.UploadID, "type": "upload"}, bson.M{"$set": bson.M{"deletedTime": "2016-09-01T12:00:00Z"}})).To(Succeed())
+							Expect(mongoSession.DestroyDeletedData(time.Now().Add(time.Hour))).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"uploadId": datasetExistingOne.UploadID, "type": "upload"}).Count()).To(Equal(1))
+						})
+
+						It("removes the retained archive of device data archived by the destroyed dataset", func() {
+							Expect(testMongoCollection.Update(bson.M{"uploadId": datasetExistingOne.UploadID, "type": "upload"}, bson.M{"$set": bson.M{"_retainedArchive": bson.M{"datasetId": dataset.UploadID}}})).To(Succeed())
+							Expect(mongoSession.DestroyDeletedData(time.Now().Add(time.Hour))).To(Succeed())
+							Expect(testMongoCollection.Find(bson.M{"_retainedArchive": bson.M{"$exists": true}}).Count()).To(Equal(0))
+						})
+					})
 				})
 			})
 		})
@@ -1,6 +1,10 @@
 package store
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/types/upload"
 	"github.com/tidepool-org/platform/errors"
@@ -19,15 +23,139 @@ type Session interface {
 
 	GetDatasetsForUserByID(userID string, filter *Filter, pagination *Pagination) ([]*upload.Upload, error)
 	GetDatasetByID(datasetID string) (*upload.Upload, error)
+	GetJournaledDatasets() ([]*upload.Upload, error)
+	GetDatasetSummary(dataset *upload.Upload) (*DatasetSummary, error)
+	GetDatasetLatestTime(dataset *upload.Upload) (*time.Time, error)
 	CreateDataset(dataset *upload.Upload) error
 	UpdateDataset(dataset *upload.Upload) error
+	UpdateDatasetJournal(dataset *upload.Upload) error
+	UpdateDatasetRededuplicateJournal(dataset *upload.Upload) error
+	TransitionDatasetState(dataset *upload.Upload, state string, userID string) (bool, error)
 	DeleteDataset(dataset *upload.Upload) error
+	UndeleteDataset(dataset *upload.Upload) error
+	GetDatasetChunk(dataset *upload.Upload, chunkID string) (*DatasetChunk, error)
+	CreateDatasetChunk(dataset *upload.Upload, chunkID string, datumIDs []string) (bool, error)
+	CompleteDatasetChunk(dataset *upload.Upload, chunkID string, response []byte) error
+	FailDatasetChunk(dataset *upload.Upload, chunkID string) error
+	GetDatasetDataDeduplicatorHashes(dataset *upload.Upload, hashes []string) ([]string, error)
 	CreateDatasetData(dataset *upload.Upload, datasetData []data.Datum) error
 	ActivateDatasetData(dataset *upload.Upload) error
+	DeactivateDatasetData(dataset *upload.Upload) error
+	DeleteInactiveDatasetData(dataset *upload.Upload) error
 	ArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) error
 	UnarchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) error
+	ArchiveDeviceDataUsingTimeWindowFromDataset(dataset *upload.Upload) error
+	UnarchiveDeviceDataArchivedByDataset(dataset *upload.Upload) error
+	RetainDeviceDataArchivedByDataset(dataset *upload.Upload) error
+	RearchiveDeviceDataRetainedByDataset(dataset *upload.Upload) error
+	ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset *upload.Upload, deviceTimeTolerance time.Duration) error
 	DeleteOtherDatasetData(dataset *upload.Upload) error
+	PreviewActivateDatasetData(dataset *upload.Upload) (*DataPreview, error)
+	PreviewArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) (*DataPreview, error)
+	PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset *upload.Upload) (*DataPreview, error)
+	PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset *upload.Upload, deviceTimeTolerance time.Duration) (*DataPreview, error)
+	PreviewDeleteOtherDatasets(dataset *upload.Upload) (*DataPreview, error)
+	PreviewDeleteOtherDatasetData(dataset *upload.Upload) (*DataPreview, error)
+	GetDataForDatasetByID(datasetID string, filter *DatasetDataFilter, pagination *Pagination) ([]map[string]interface{}, error)
+	GetDataForUserByID(userID string, filter *DataFilter, pagination *Pagination) ([]map[string]interface{}, error)
+	IterateDataForUserByID(userID string, filter *DataFilter) (Iterator, error)
+	IterateAllDataForUserByID(userID string) (Iterator, error)
+	RestoreDataForUserByID(userID string, restoreID string, restoreTime time.Time) (bool, error)
+	UndoRestoreDataForUserByID(userID string, restoreID string) error
+	PreviewRestoreDataForUserByID(userID string, restoreTime time.Time) (*DataRestorePreview, error)
 	DestroyDataForUserByID(userID string) error
+	DestroyDeletedData(deletedBefore time.Time) error
+}
+
+type Iterator interface {
+	Next(result interface{}) bool
+	Err() error
+	Close() error
+}
+
+const (
+	DatasetChunkStatePending   = "pending"
+	DatasetChunkStateCompleted = "completed"
+	DatasetChunkStateFailed    = "failed"
+
+	DatasetChunkLeaseDuration = 10 * time.Minute
+)
+
+type DatasetChunk struct {
+	DatasetID      string    `bson:"uploadId"`
+	ID             string    `bson:"chunkId"`
+	State          string    `bson:"state"`
+	Response       []byte    `bson:"response,omitempty"`
+	CreatedTime    time.Time `bson:"createdTime"`
+	ExpirationTime time.Time `bson:"expirationTime,omitempty"`
+	CompletedTime  time.Time `bson:"completedTime,omitempty"`
+	DatumIDs       []string  `bson:"datumIds,omitempty"`
+}
+
+func (d *DatasetChunk) IsInProgress(now time.Time) bool {
+	return d.State == DatasetChunkStatePending && now.Before(d.ExpirationTime)
+}
+
+type DatasetSummary struct {
+	Counts        []*DatasetSummaryCount      `json:"counts"`
+	ActiveCount   int                         `json:"activeCount"`
+	ArchivedCount int                         `json:"archivedCount"`
+	EarliestTime  string                      `json:"earliestTime,omitempty"`
+	LatestTime    string                      `json:"latestTime,omitempty"`
+	Deduplicator  *DatasetSummaryDeduplicator `json:"deduplicator,omitempty"`
+}
+
+type DatasetSummaryCount struct {
+	Type          string `json:"type"`
+	SubType       string `json:"subType,omitempty"`
+	Count         int    `json:"count"`
+	ActiveCount   int    `json:"activeCount"`
+	ArchivedCount int    `json:"archivedCount"`
+}
+
+type DatasetSummaryDeduplicator struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+func NewDatasetSummary() *DatasetSummary {
+	return &DatasetSummary{
+		Counts: []*DatasetSummaryCount{},
+	}
+}
+
+func (d *DatasetSummary) AddCount(count *DatasetSummaryCount, earliestTime string, latestTime string) {
+	d.Counts = append(d.Counts, count)
+	d.ActiveCount += count.ActiveCount
+	d.ArchivedCount += count.ArchivedCount
+	if earliestTime != "" && (d.EarliestTime == "" || earliestTime < d.EarliestTime) {
+		d.EarliestTime = earliestTime
+	}
+	if latestTime != "" && (d.LatestTime == "" || latestTime > d.LatestTime) {
+		d.LatestTime = latestTime
+	}
+}
+
+const DataPreviewSampleSize = 10
+
+type DataPreview struct {
+	Count     int
+	SampleIDs []string
+}
+
+func NewDataPreview() *DataPreview {
+	return &DataPreview{
+		SampleIDs: []string{},
+	}
+}
+
+type DataRestorePreview struct {
+	Activate *DataPreview
+	Archive  *DataPreview
+}
+
+func NewDataRestorePreview() *DataRestorePreview {
+	return &DataRestorePreview{}
 }
 
 type Filter struct {
@@ -42,6 +170,48 @@ func (f *Filter) Validate() error {
 	return nil
 }
 
+type DatasetDataFilter struct {
+	Archived bool
+}
+
+func NewDatasetDataFilter() *DatasetDataFilter {
+	return &DatasetDataFilter{}
+}
+
+func (d *DatasetDataFilter) Validate() error {
+	return nil
+}
+
+type DataFilter struct {
+	Types     []string
+	SubTypes  []string
+	DeviceID  string
+	UploadID  string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+func NewDataFilter() *DataFilter {
+	return &DataFilter{}
+}
+
+func (d *DataFilter) Validate() error {
+	for _, typ := range d.Types {
+		if typ == "" {
+			return errors.New("store", "type is empty")
+		}
+	}
+	for _, subType := range d.SubTypes {
+		if subType == "" {
+			return errors.New("store", "sub type is empty")
+		}
+	}
+	if d.StartTime != nil && d.EndTime != nil && d.EndTime.Before(*d.StartTime) {
+		return errors.New("store", "end time is before start time")
+	}
+	return nil
+}
+
 const (
 	PaginationPageMinimum = 0
 	PaginationSizeMinimum = 1
@@ -49,8 +219,9 @@ const (
 )
 
 type Pagination struct {
-	Page int
-	Size int
+	Page   int
+	Size   int
+	Cursor *Cursor
 }
 
 func NewPagination() *Pagination {
@@ -66,5 +237,57 @@ func (p *Pagination) Validate() error {
 	if p.Size < PaginationSizeMinimum || p.Size > PaginationSizeMaximum {
 		return errors.New("store", "size is invalid")
 	}
+	if p.Cursor != nil {
+		if p.Page != PaginationPageMinimum {
+			return errors.New("store", "page is invalid with cursor")
+		}
+		if err := p.Cursor.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+type Cursor struct {
+	Time string `json:"t"`
+	ID   string `json:"i"`
+}
+
+func NewCursor(time string, id string) *Cursor {
+	return &Cursor{
+		Time: time,
+		ID:   id,
+	}
+}
+
+func ParseCursor(token string) (*Cursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("store", "cursor is invalid")
+	}
+
+	cursor := &Cursor{}
+	if err = json.Unmarshal(bytes, cursor); err != nil {
+		return nil, errors.New("store", "cursor is invalid")
+	}
+	if err = cursor.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cursor, nil
+}
+
+func (c *Cursor) Validate() error {
+	if c.Time == "" {
+		return errors.New("store", "cursor time is missing")
+	}
+	if c.ID == "" {
+		return errors.New("store", "cursor id is missing")
+	}
+	return nil
+}
+
+func (c *Cursor) Token() string {
+	bytes, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
@@ -4,10 +4,34 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"time"
+
 	"github.com/tidepool-org/platform/data/store"
 )
 
 var _ = Describe("Store", func() {
+	Context("DatasetChunk", func() {
+		Context("IsInProgress", func() {
+			var now time.Time
+
+			BeforeEach(func() {
+				now = time.Now()
+			})
+
+			It("returns true if the chunk is pending and the lease has not expired", func() {
+				Expect((&store.DatasetChunk{State: store.DatasetChunkStatePending, ExpirationTime: now.Add(time.Minute)}).IsInProgress(now)).To(BeTrue())
+			})
+
+			It("returns false if the chunk is pending and the lease has expired", func() {
+				Expect((&store.DatasetChunk{State: store.DatasetChunkStatePending, ExpirationTime: now.Add(-time.Minute)}).IsInProgress(now)).To(BeFalse())
+			})
+
+			It("returns false if the chunk is completed", func() {
+				Expect((&store.DatasetChunk{State: store.DatasetChunkStateCompleted}).IsInProgress(now)).To(BeFalse())
+			})
+		})
+	})
+
 	Context("Filter", func() {
 		Context("NewFilter", func() {
 			It("successfully returns a new filter", func() {
@@ -43,6 +67,113 @@ var _ = Describe("Store", func() {
 		})
 	})
 
+	Context("DatasetDataFilter", func() {
+		Context("NewDatasetDataFilter", func() {
+			It("successfully returns a new dataset data filter", func() {
+				Expect(store.NewDatasetDataFilter()).ToNot(BeNil())
+			})
+		})
+
+		Context("with a new dataset data filter", func() {
+			var filter *store.DatasetDataFilter
+
+			BeforeEach(func() {
+				filter = store.NewDatasetDataFilter()
+				Expect(filter).ToNot(BeNil())
+			})
+
+			Context("Validate", func() {
+				It("succeeds with defaults", func() {
+					Expect(filter.Validate()).To(Succeed())
+				})
+
+				It("succeeds if Archived is true", func() {
+					filter.Archived = true
+					Expect(filter.Validate()).To(Succeed())
+				})
+			})
+		})
+	})
+
+	Context("DataFilter", func() {
+		Context("NewDataFilter", func() {
+			It("successfully returns a new data filter", func() {
+				Expect(store.NewDataFilter()).ToNot(BeNil())
+			})
+		})
+
+		Context("with a new data filter", func() {
+			var filter *store.DataFilter
+
+			BeforeEach(func() {
+				filter = store.NewDataFilter()
+				Expect(filter).ToNot(BeNil())
+			})
+
+			Context("Validate", func() {
+				It("succeeds with defaults", func() {
+					Expect(filter.Validate()).To(Succeed())
+				})
+
+				Context("Types", func() {
+					It("succeeds if Types is not empty", func() {
+						filter.Types = []string{"cbg", "smbg"}
+						Expect(filter.Validate()).To(Succeed())
+					})
+
+					It("returns an error if Types contains an empty type", func() {
+						filter.Types = []string{"cbg", ""}
+						Expect(filter.Validate()).To(MatchError("store: type is empty"))
+					})
+				})
+
+				Context("SubTypes", func() {
+					It("succeeds if SubTypes is not empty", func() {
+						filter.SubTypes = []string{"normal"}
+						Expect(filter.Validate()).To(Succeed())
+					})
+
+					It("returns an error if SubTypes contains an empty sub type", func() {
+						filter.SubTypes = []string{""}
+						Expect(filter.Validate()).To(MatchError("store: sub type is empty"))
+					})
+				})
+
+				Context("StartTime and EndTime", func() {
+					var startTime time.Time
+					var endTime time.Time
+
+					BeforeEach(func() {
+						startTime = time.Date(2016, 9, 1, 0, 0, 0, 0, time.UTC)
+						endTime = time.Date(2016, 9, 2, 0, 0, 0, 0, time.UTC)
+					})
+
+					It("succeeds if only StartTime is specified", func() {
+						filter.StartTime = &startTime
+						Expect(filter.Validate()).To(Succeed())
+					})
+
+					It("succeeds if only EndTime is specified", func() {
+						filter.EndTime = &endTime
+						Expect(filter.Validate()).To(Succeed())
+					})
+
+					It("succeeds if EndTime is after StartTime", func() {
+						filter.StartTime = &startTime
+						filter.EndTime = &endTime
+						Expect(filter.Validate()).To(Succeed())
+					})
+
+					It("returns an error if EndTime is before StartTime", func() {
+						filter.StartTime = &endTime
+						filter.EndTime = &startTime
+						Expect(filter.Validate()).To(MatchError("store: end time is before start time"))
+					})
+				})
+			})
+		})
+	})
+
 	Context("Pagination", func() {
 		Context("NewPagination", func() {
 			It("successfully returns a new pagination", func() {
@@ -104,4 +235,106 @@ var _ = Describe("Store", func() {
 			})
 		})
 	})
+
+	Context("Cursor", func() {
+		Context("NewCursor", func() {
+			It("successfully returns a new cursor", func() {
+				Expect(store.NewCursor("2016-09-01T12:00:00Z", "1234567890abcdef")).To(Equal(&store.Cursor{Time: "2016-09-01T12:00:00Z", ID: "1234567890abcdef"}))
+			})
+		})
+
+		Context("ParseCursor", func() {
+			It("successfully parses a cursor token", func() {
+				cursor := store.NewCursor("2016-09-01T12:00:00Z", "1234567890abcdef")
+				Expect(store.ParseCursor(cursor.Token())).To(Equal(cursor))
+			})
+
+			It("returns an error if the token is not base64", func() {
+				cursor, err := store.ParseCursor("!!!")
+				Expect(err).To(MatchError("store: cursor is invalid"))
+				Expect(cursor).To(BeNil())
+			})
+
+			It("returns an error if the token is not json", func() {
+				cursor, err := store.ParseCursor("YWJj")
+				Expect(err).To(MatchError("store: cursor is invalid"))
+				Expect(cursor).To(BeNil())
+			})
+
+			It("returns an error if the token id is missing", func() {
+				cursor, err := store.ParseCursor(store.NewCursor("2016-09-01T12:00:00Z", "").Token())
+				Expect(err).To(MatchError("store: cursor id is missing"))
+				Expect(cursor).To(BeNil())
+			})
+		})
+
+		Context("with a new cursor", func() {
+			var cursor *store.Cursor
+
+			BeforeEach(func() {
+				cursor = store.NewCursor("2016-09-01T12:00:00Z", "1234567890abcdef")
+				Expect(cursor).ToNot(BeNil())
+			})
+
+			Context("Validate", func() {
+				It("succeeds with time and id", func() {
+					Expect(cursor.Validate()).To(Succeed())
+				})
+
+				It("returns an error if Time is missing", func() {
+					cursor.Time = ""
+					Expect(cursor.Validate()).To(MatchError("store: cursor time is missing"))
+				})
+
+				It("returns an error if ID is missing", func() {
+					cursor.ID = ""
+					Expect(cursor.Validate()).To(MatchError("store: cursor id is missing"))
+				})
+			})
+
+			Context("Token", func() {
+				It("returns an opaque url safe token", func() {
+					Expect(cursor.Token()).To(MatchRegexp("^[A-Za-z0-9_-]+$"))
+				})
+			})
+		})
+	})
+
+	Context("DatasetSummary", func() {
+		Context("NewDatasetSummary", func() {
+			It("successfully returns a new dataset summary", func() {
+				Expect(store.NewDatasetSummary()).To(Equal(&store.DatasetSummary{Counts: []*store.DatasetSummaryCount{}}))
+			})
+		})
+
+		Context("with a new dataset summary", func() {
+			var summary *store.DatasetSummary
+
+			BeforeEach(func() {
+				summary = store.NewDatasetSummary()
+				Expect(summary).ToNot(BeNil())
+			})
+
+			Context("AddCount", func() {
+				It("adds the counts and time bounds", func() {
+					firstCount := &store.DatasetSummaryCount{Type: "bolus", SubType: "normal", Count: 3, ActiveCount: 2, ArchivedCount: 1}
+					secondCount := &store.DatasetSummaryCount{Type: "cbg", Count: 5, ActiveCount: 5}
+					summary.AddCount(firstCount, "2016-09-01T12:00:00Z", "2016-09-01T14:00:00Z")
+					summary.AddCount(secondCount, "2016-09-01T10:00:00Z", "2016-09-01T13:00:00Z")
+					Expect(summary.Counts).To(Equal([]*store.DatasetSummaryCount{firstCount, secondCount}))
+					Expect(summary.ActiveCount).To(Equal(7))
+					Expect(summary.ArchivedCount).To(Equal(1))
+					Expect(summary.EarliestTime).To(Equal("2016-09-01T10:00:00Z"))
+					Expect(summary.LatestTime).To(Equal("2016-09-01T14:00:00Z"))
+				})
+
+				It("ignores missing time bounds", func() {
+					summary.AddCount(&store.DatasetSummaryCount{Type: "cbg", Count: 1}, "2016-09-01T10:00:00Z", "2016-09-01T13:00:00Z")
+					summary.AddCount(&store.DatasetSummaryCount{Type: "smbg", Count: 1}, "", "")
+					Expect(summary.EarliestTime).To(Equal("2016-09-01T10:00:00Z"))
+					Expect(summary.LatestTime).To(Equal("2016-09-01T13:00:00Z"))
+				})
+			})
+		})
+	})
 })
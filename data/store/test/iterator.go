@@ -0,0 +1,60 @@
+package test
+
+type Iterator struct {
+	NextInvocations  int
+	NextInputs       []interface{}
+	NextOutputs      []interface{}
+	ErrInvocations   int
+	ErrOutputs       []error
+	CloseInvocations int
+	CloseOutputs     []error
+}
+
+func NewIterator() *Iterator {
+	return &Iterator{}
+}
+
+func (i *Iterator) Next(result interface{}) bool {
+	i.NextInvocations++
+
+	i.NextInputs = append(i.NextInputs, result)
+
+	if len(i.NextOutputs) == 0 {
+		return false
+	}
+
+	output := i.NextOutputs[0]
+	i.NextOutputs = i.NextOutputs[1:]
+	*result.(*interface{}) = output
+	return true
+}
+
+func (i *Iterator) Err() error {
+	i.ErrInvocations++
+
+	if len(i.ErrOutputs) == 0 {
+		panic("Unexpected invocation of Err on Iterator")
+	}
+
+	output := i.ErrOutputs[0]
+	i.ErrOutputs = i.ErrOutputs[1:]
+	return output
+}
+
+func (i *Iterator) Close() error {
+	i.CloseInvocations++
+
+	if len(i.CloseOutputs) == 0 {
+		panic("Unexpected invocation of Close on Iterator")
+	}
+
+	output := i.CloseOutputs[0]
+	i.CloseOutputs = i.CloseOutputs[1:]
+	return output
+}
+
+func (i *Iterator) UnusedOutputsCount() int {
+	return len(i.NextOutputs) +
+		len(i.ErrOutputs) +
+		len(i.CloseOutputs)
+}
@@ -1,6 +1,8 @@
 package test
 
 import (
+	"time"
+
 	"github.com/tidepool-org/platform/app"
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/store"
@@ -25,53 +27,292 @@ type GetDatasetByIDOutput struct {
 	Error   error
 }
 
+type GetJournaledDatasetsOutput struct {
+	Datasets []*upload.Upload
+	Error    error
+}
+
+type DataPreviewOutput struct {
+	Preview *store.DataPreview
+	Error   error
+}
+
 type CreateDatasetDataInput struct {
 	Dataset     *upload.Upload
 	DatasetData []data.Datum
 }
 
+type FuzzyHashesFromDatasetInput struct {
+	Dataset             *upload.Upload
+	DeviceTimeTolerance time.Duration
+}
+
+type GetDataForDatasetByIDInput struct {
+	DatasetID  string
+	Filter     *store.DatasetDataFilter
+	Pagination *store.Pagination
+}
+
+type GetDataForDatasetByIDOutput struct {
+	Data  []map[string]interface{}
+	Error error
+}
+
+type GetDataForUserByIDInput struct {
+	UserID     string
+	Filter     *store.DataFilter
+	Pagination *store.Pagination
+}
+
+type GetDataForUserByIDOutput struct {
+	Data  []map[string]interface{}
+	Error error
+}
+
+type IterateDataForUserByIDInput struct {
+	UserID string
+	Filter *store.DataFilter
+}
+
+type IterateDataForUserByIDOutput struct {
+	Iterator store.Iterator
+	Error    error
+}
+
+type IterateAllDataForUserByIDOutput struct {
+	Iterator store.Iterator
+	Error    error
+}
+
+type GetDatasetSummaryOutput struct {
+	Summary *store.DatasetSummary
+	Error   error
+}
+
+type GetDatasetLatestTimeOutput struct {
+	LatestTime *time.Time
+	Error      error
+}
+
+type TransitionDatasetStateInput struct {
+	Dataset *upload.Upload
+	State   string
+	UserID  string
+}
+
+type TransitionDatasetStateOutput struct {
+	Transitioned bool
+	Error        error
+}
+
+type GetDatasetChunkInput struct {
+	Dataset *upload.Upload
+	ChunkID string
+}
+
+type GetDatasetChunkOutput struct {
+	Chunk *store.DatasetChunk
+	Error error
+}
+
+type CreateDatasetChunkInput struct {
+	Dataset  *upload.Upload
+	ChunkID  string
+	DatumIDs []string
+}
+
+type CreateDatasetChunkOutput struct {
+	Created bool
+	Error   error
+}
+
+type CompleteDatasetChunkInput struct {
+	Dataset  *upload.Upload
+	ChunkID  string
+	Response []byte
+}
+
+type FailDatasetChunkInput struct {
+	Dataset *upload.Upload
+	ChunkID string
+}
+
+type GetDatasetDataDeduplicatorHashesInput struct {
+	Dataset *upload.Upload
+	Hashes  []string
+}
+
+type GetDatasetDataDeduplicatorHashesOutput struct {
+	Hashes []string
+	Error  error
+}
+
+type RestoreDataForUserByIDInput struct {
+	UserID      string
+	RestoreID   string
+	RestoreTime time.Time
+}
+
+type RestoreDataForUserByIDOutput struct {
+	Restored bool
+	Error    error
+}
+
+type UndoRestoreDataForUserByIDInput struct {
+	UserID    string
+	RestoreID string
+}
+
+type PreviewRestoreDataForUserByIDInput struct {
+	UserID      string
+	RestoreTime time.Time
+}
+
+type PreviewRestoreDataForUserByIDOutput struct {
+	Preview *store.DataRestorePreview
+	Error   error
+}
+
 type Session struct {
-	ID                                                   string
-	IsClosedInvocations                                  int
-	IsClosedOutputs                                      []bool
-	CloseInvocations                                     int
-	LoggerInvocations                                    int
-	LoggerImpl                                           log.Logger
-	SetAgentInvocations                                  int
-	SetAgentInputs                                       []commonStore.Agent
-	GetDatasetsForUserByIDInvocations                    int
-	GetDatasetsForUserByIDInputs                         []GetDatasetsForUserByIDInput
-	GetDatasetsForUserByIDOutputs                        []GetDatasetsForUserByIDOutput
-	GetDatasetByIDInvocations                            int
-	GetDatasetByIDInputs                                 []string
-	GetDatasetByIDOutputs                                []GetDatasetByIDOutput
-	CreateDatasetInvocations                             int
-	CreateDatasetInputs                                  []*upload.Upload
-	CreateDatasetOutputs                                 []error
-	UpdateDatasetInvocations                             int
-	UpdateDatasetInputs                                  []*upload.Upload
-	UpdateDatasetOutputs                                 []error
-	DeleteDatasetInvocations                             int
-	DeleteDatasetInputs                                  []*upload.Upload
-	DeleteDatasetOutputs                                 []error
-	CreateDatasetDataInvocations                         int
-	CreateDatasetDataInputs                              []CreateDatasetDataInput
-	CreateDatasetDataOutputs                             []error
-	ActivateDatasetDataInvocations                       int
-	ActivateDatasetDataInputs                            []*upload.Upload
-	ActivateDatasetDataOutputs                           []error
-	ArchiveDeviceDataUsingHashesFromDatasetInvocations   int
-	ArchiveDeviceDataUsingHashesFromDatasetInputs        []*upload.Upload
-	ArchiveDeviceDataUsingHashesFromDatasetOutputs       []error
-	UnarchiveDeviceDataUsingHashesFromDatasetInvocations int
-	UnarchiveDeviceDataUsingHashesFromDatasetInputs      []*upload.Upload
-	UnarchiveDeviceDataUsingHashesFromDatasetOutputs     []error
-	DeleteOtherDatasetDataInvocations                    int
-	DeleteOtherDatasetDataInputs                         []*upload.Upload
-	DeleteOtherDatasetDataOutputs                        []error
-	DestroyDataForUserByIDInvocations                    int
-	DestroyDataForUserByIDInputs                         []string
-	DestroyDataForUserByIDOutputs                        []error
+	ID                                                             string
+	IsClosedInvocations                                            int
+	IsClosedOutputs                                                []bool
+	CloseInvocations                                               int
+	LoggerInvocations                                              int
+	LoggerImpl                                                     log.Logger
+	SetAgentInvocations                                            int
+	SetAgentInputs                                                 []commonStore.Agent
+	GetDatasetsForUserByIDInvocations                              int
+	GetDatasetsForUserByIDInputs                                   []GetDatasetsForUserByIDInput
+	GetDatasetsForUserByIDOutputs                                  []GetDatasetsForUserByIDOutput
+	GetDatasetByIDInvocations                                      int
+	GetDatasetByIDInputs                                           []string
+	GetDatasetByIDOutputs                                          []GetDatasetByIDOutput
+	GetJournaledDatasetsInvocations                                int
+	GetJournaledDatasetsOutputs                                    []GetJournaledDatasetsOutput
+	GetDatasetSummaryInvocations                                   int
+	GetDatasetSummaryInputs                                        []*upload.Upload
+	GetDatasetSummaryOutputs                                       []GetDatasetSummaryOutput
+	GetDatasetLatestTimeInvocations                                int
+	GetDatasetLatestTimeInputs                                     []*upload.Upload
+	GetDatasetLatestTimeOutputs                                    []GetDatasetLatestTimeOutput
+	CreateDatasetInvocations                                       int
+	CreateDatasetInputs                                            []*upload.Upload
+	CreateDatasetOutputs                                           []error
+	UpdateDatasetInvocations                                       int
+	UpdateDatasetInputs                                            []*upload.Upload
+	UpdateDatasetOutputs                                           []error
+	UpdateDatasetJournalInvocations                                int
+	UpdateDatasetJournalInputs                                     []*upload.Upload
+	UpdateDatasetJournalOutputs                                    []error
+	UpdateDatasetRededuplicateJournalInvocations                   int
+	UpdateDatasetRededuplicateJournalInputs                        []*upload.Upload
+	UpdateDatasetRededuplicateJournalOutputs                       []error
+	TransitionDatasetStateInvocations                              int
+	TransitionDatasetStateInputs                                   []TransitionDatasetStateInput
+	TransitionDatasetStateOutputs                                  []TransitionDatasetStateOutput
+	DeleteDatasetInvocations                                       int
+	DeleteDatasetInputs                                            []*upload.Upload
+	DeleteDatasetOutputs                                           []error
+	UndeleteDatasetInvocations                                     int
+	UndeleteDatasetInputs                                          []*upload.Upload
+	UndeleteDatasetOutputs                                         []error
+	GetDatasetChunkInvocations                                     int
+	GetDatasetChunkInputs                                          []GetDatasetChunkInput
+	GetDatasetChunkOutputs                                         []GetDatasetChunkOutput
+	CreateDatasetChunkInvocations                                  int
+	CreateDatasetChunkInputs                                       []CreateDatasetChunkInput
+	CreateDatasetChunkOutputs                                      []CreateDatasetChunkOutput
+	CompleteDatasetChunkInvocations                                int
+	CompleteDatasetChunkInputs                                     []CompleteDatasetChunkInput
+	CompleteDatasetChunkOutputs                                    []error
+	FailDatasetChunkInvocations                                    int
+	FailDatasetChunkInputs                                         []FailDatasetChunkInput
+	FailDatasetChunkOutputs                                        []error
+	GetDatasetDataDeduplicatorHashesInvocations                    int
+	GetDatasetDataDeduplicatorHashesInputs                         []GetDatasetDataDeduplicatorHashesInput
+	GetDatasetDataDeduplicatorHashesOutputs                        []GetDatasetDataDeduplicatorHashesOutput
+	CreateDatasetDataInvocations                                   int
+	CreateDatasetDataInputs                                        []CreateDatasetDataInput
+	CreateDatasetDataOutputs                                       []error
+	ActivateDatasetDataInvocations                                 int
+	ActivateDatasetDataInputs                                      []*upload.Upload
+	ActivateDatasetDataOutputs                                     []error
+	DeactivateDatasetDataInvocations                               int
+	DeactivateDatasetDataInputs                                    []*upload.Upload
+	DeactivateDatasetDataOutputs                                   []error
+	DeleteInactiveDatasetDataInvocations                           int
+	DeleteInactiveDatasetDataInputs                                []*upload.Upload
+	DeleteInactiveDatasetDataOutputs                               []error
+	ArchiveDeviceDataUsingHashesFromDatasetInvocations             int
+	ArchiveDeviceDataUsingHashesFromDatasetInputs                  []*upload.Upload
+	ArchiveDeviceDataUsingHashesFromDatasetOutputs                 []error
+	UnarchiveDeviceDataUsingHashesFromDatasetInvocations           int
+	UnarchiveDeviceDataUsingHashesFromDatasetInputs                []*upload.Upload
+	UnarchiveDeviceDataUsingHashesFromDatasetOutputs               []error
+	ArchiveDeviceDataUsingTimeWindowFromDatasetInvocations         int
+	ArchiveDeviceDataUsingTimeWindowFromDatasetInputs              []*upload.Upload
+	ArchiveDeviceDataUsingTimeWindowFromDatasetOutputs             []error
+	UnarchiveDeviceDataArchivedByDatasetInvocations                int
+	UnarchiveDeviceDataArchivedByDatasetInputs                     []*upload.Upload
+	UnarchiveDeviceDataArchivedByDatasetOutputs                    []error
+	RetainDeviceDataArchivedByDatasetInvocations                   int
+	RetainDeviceDataArchivedByDatasetInputs                        []*upload.Upload
+	RetainDeviceDataArchivedByDatasetOutputs                       []error
+	RearchiveDeviceDataRetainedByDatasetInvocations                int
+	RearchiveDeviceDataRetainedByDatasetInputs                     []*upload.Upload
+	RearchiveDeviceDataRetainedByDatasetOutputs                    []error
+	ArchiveDeviceDataUsingFuzzyHashesFromDatasetInvocations        int
+	ArchiveDeviceDataUsingFuzzyHashesFromDatasetInputs             []FuzzyHashesFromDatasetInput
+	ArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs            []error
+	DeleteOtherDatasetDataInvocations                              int
+	DeleteOtherDatasetDataInputs                                   []*upload.Upload
+	DeleteOtherDatasetDataOutputs                                  []error
+	PreviewActivateDatasetDataInvocations                          int
+	PreviewActivateDatasetDataInputs                               []*upload.Upload
+	PreviewActivateDatasetDataOutputs                              []DataPreviewOutput
+	PreviewArchiveDeviceDataUsingHashesFromDatasetInvocations      int
+	PreviewArchiveDeviceDataUsingHashesFromDatasetInputs           []*upload.Upload
+	PreviewArchiveDeviceDataUsingHashesFromDatasetOutputs          []DataPreviewOutput
+	PreviewArchiveDeviceDataUsingTimeWindowFromDatasetInvocations  int
+	PreviewArchiveDeviceDataUsingTimeWindowFromDatasetInputs       []*upload.Upload
+	PreviewArchiveDeviceDataUsingTimeWindowFromDatasetOutputs      []DataPreviewOutput
+	PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetInvocations int
+	PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetInputs      []FuzzyHashesFromDatasetInput
+	PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs     []DataPreviewOutput
+	PreviewDeleteOtherDatasetsInvocations                          int
+	PreviewDeleteOtherDatasetsInputs                               []*upload.Upload
+	PreviewDeleteOtherDatasetsOutputs                              []DataPreviewOutput
+	PreviewDeleteOtherDatasetDataInvocations                       int
+	PreviewDeleteOtherDatasetDataInputs                            []*upload.Upload
+	PreviewDeleteOtherDatasetDataOutputs                           []DataPreviewOutput
+	GetDataForDatasetByIDInvocations                               int
+	GetDataForDatasetByIDInputs                                    []GetDataForDatasetByIDInput
+	GetDataForDatasetByIDOutputs                                   []GetDataForDatasetByIDOutput
+	GetDataForUserByIDInvocations                                  int
+	GetDataForUserByIDInputs                                       []GetDataForUserByIDInput
+	GetDataForUserByIDOutputs                                      []GetDataForUserByIDOutput
+	IterateDataForUserByIDInvocations                              int
+	IterateDataForUserByIDInputs                                   []IterateDataForUserByIDInput
+	IterateDataForUserByIDOutputs                                  []IterateDataForUserByIDOutput
+	IterateAllDataForUserByIDInvocations                           int
+	IterateAllDataForUserByIDInputs                                []string
+	IterateAllDataForUserByIDOutputs                               []IterateAllDataForUserByIDOutput
+	RestoreDataForUserByIDInvocations                              int
+	RestoreDataForUserByIDInputs                                   []RestoreDataForUserByIDInput
+	RestoreDataForUserByIDOutputs                                  []RestoreDataForUserByIDOutput
+	UndoRestoreDataForUserByIDInvocations                          int
+	UndoRestoreDataForUserByIDInputs                               []UndoRestoreDataForUserByIDInput
+	UndoRestoreDataForUserByIDOutputs                              []error
+	PreviewRestoreDataForUserByIDInvocations                       int
+	PreviewRestoreDataForUserByIDInputs                            []PreviewRestoreDataForUserByIDInput
+	PreviewRestoreDataForUserByIDOutputs                           []PreviewRestoreDataForUserByIDOutput
+	DestroyDataForUserByIDInvocations                              int
+	DestroyDataForUserByIDInputs                                   []string
+	DestroyDataForUserByIDOutputs                                  []error
+	DestroyDeletedDataInvocations                                  int
+	DestroyDeletedDataInputs                                       []time.Time
+	DestroyDeletedDataOutputs                                      []error
 }
 
 func NewSession() *Session {
@@ -137,6 +378,46 @@ func (s *Session) GetDatasetByID(datasetID string) (*upload.Upload, error) {
 	return output.Dataset, output.Error
 }
 
+func (s *Session) GetJournaledDatasets() ([]*upload.Upload, error) {
+	s.GetJournaledDatasetsInvocations++
+
+	if len(s.GetJournaledDatasetsOutputs) == 0 {
+		panic("Unexpected invocation of GetJournaledDatasets on Session")
+	}
+
+	output := s.GetJournaledDatasetsOutputs[0]
+	s.GetJournaledDatasetsOutputs = s.GetJournaledDatasetsOutputs[1:]
+	return output.Datasets, output.Error
+}
+
+func (s *Session) GetDatasetSummary(dataset *upload.Upload) (*store.DatasetSummary, error) {
+	s.GetDatasetSummaryInvocations++
+
+	s.GetDatasetSummaryInputs = append(s.GetDatasetSummaryInputs, dataset)
+
+	if len(s.GetDatasetSummaryOutputs) == 0 {
+		panic("Unexpected invocation of GetDatasetSummary on Session")
+	}
+
+	output := s.GetDatasetSummaryOutputs[0]
+	s.GetDatasetSummaryOutputs = s.GetDatasetSummaryOutputs[1:]
+	return output.Summary, output.Error
+}
+
+func (s *Session) GetDatasetLatestTime(dataset *upload.Upload) (*time.Time, error) {
+	s.GetDatasetLatestTimeInvocations++
+
+	s.GetDatasetLatestTimeInputs = append(s.GetDatasetLatestTimeInputs, dataset)
+
+	if len(s.GetDatasetLatestTimeOutputs) == 0 {
+		panic("Unexpected invocation of GetDatasetLatestTime on Session")
+	}
+
+	output := s.GetDatasetLatestTimeOutputs[0]
+	s.GetDatasetLatestTimeOutputs = s.GetDatasetLatestTimeOutputs[1:]
+	return output.LatestTime, output.Error
+}
+
 func (s *Session) CreateDataset(dataset *upload.Upload) error {
 	s.CreateDatasetInvocations++
 
@@ -165,6 +446,48 @@ func (s *Session) UpdateDataset(dataset *upload.Upload) error {
 	return output
 }
 
+func (s *Session) UpdateDatasetJournal(dataset *upload.Upload) error {
+	s.UpdateDatasetJournalInvocations++
+
+	s.UpdateDatasetJournalInputs = append(s.UpdateDatasetJournalInputs, dataset)
+
+	if len(s.UpdateDatasetJournalOutputs) == 0 {
+		panic("Unexpected invocation of UpdateDatasetJournal on Session")
+	}
+
+	output := s.UpdateDatasetJournalOutputs[0]
+	s.UpdateDatasetJournalOutputs = s.UpdateDatasetJournalOutputs[1:]
+	return output
+}
+
+func (s *Session) UpdateDatasetRededuplicateJournal(dataset *upload.Upload) error {
+	s.UpdateDatasetRededuplicateJournalInvocations++
+
+	s.UpdateDatasetRededuplicateJournalInputs = append(s.UpdateDatasetRededuplicateJournalInputs, dataset)
+
+	if len(s.UpdateDatasetRededuplicateJournalOutputs) == 0 {
+		panic("Unexpected invocation of UpdateDatasetRededuplicateJournal on Session")
+	}
+
+	output := s.UpdateDatasetRededuplicateJournalOutputs[0]
+	s.UpdateDatasetRededuplicateJournalOutputs = s.UpdateDatasetRededuplicateJournalOutputs[1:]
+	return output
+}
+
+func (s *Session) TransitionDatasetState(dataset *upload.Upload, state string, userID string) (bool, error) {
+	s.TransitionDatasetStateInvocations++
+
+	s.TransitionDatasetStateInputs = append(s.TransitionDatasetStateInputs, TransitionDatasetStateInput{dataset, state, userID})
+
+	if len(s.TransitionDatasetStateOutputs) == 0 {
+		panic("Unexpected invocation of TransitionDatasetState on Session")
+	}
+
+	output := s.TransitionDatasetStateOutputs[0]
+	s.TransitionDatasetStateOutputs = s.TransitionDatasetStateOutputs[1:]
+	return output.Transitioned, output.Error
+}
+
 func (s *Session) DeleteDataset(dataset *upload.Upload) error {
 	s.DeleteDatasetInvocations++
 
@@ -179,6 +502,90 @@ func (s *Session) DeleteDataset(dataset *upload.Upload) error {
 	return output
 }
 
+func (s *Session) UndeleteDataset(dataset *upload.Upload) error {
+	s.UndeleteDatasetInvocations++
+
+	s.UndeleteDatasetInputs = append(s.UndeleteDatasetInputs, dataset)
+
+	if len(s.UndeleteDatasetOutputs) == 0 {
+		panic("Unexpected invocation of UndeleteDataset on Session")
+	}
+
+	output := s.UndeleteDatasetOutputs[0]
+	s.UndeleteDatasetOutputs = s.UndeleteDatasetOutputs[1:]
+	return output
+}
+
+func (s *Session) GetDatasetChunk(dataset *upload.Upload, chunkID string) (*store.DatasetChunk, error) {
+	s.GetDatasetChunkInvocations++
+
+	s.GetDatasetChunkInputs = append(s.GetDatasetChunkInputs, GetDatasetChunkInput{dataset, chunkID})
+
+	if len(s.GetDatasetChunkOutputs) == 0 {
+		panic("Unexpected invocation of GetDatasetChunk on Session")
+	}
+
+	output := s.GetDatasetChunkOutputs[0]
+	s.GetDatasetChunkOutputs = s.GetDatasetChunkOutputs[1:]
+	return output.Chunk, output.Error
+}
+
+func (s *Session) CreateDatasetChunk(dataset *upload.Upload, chunkID string, datumIDs []string) (bool, error) {
+	s.CreateDatasetChunkInvocations++
+
+	s.CreateDatasetChunkInputs = append(s.CreateDatasetChunkInputs, CreateDatasetChunkInput{dataset, chunkID, datumIDs})
+
+	if len(s.CreateDatasetChunkOutputs) == 0 {
+		panic("Unexpected invocation of CreateDatasetChunk on Session")
+	}
+
+	output := s.CreateDatasetChunkOutputs[0]
+	s.CreateDatasetChunkOutputs = s.CreateDatasetChunkOutputs[1:]
+	return output.Created, output.Error
+}
+
+func (s *Session) CompleteDatasetChunk(dataset *upload.Upload, chunkID string, response []byte) error {
+	s.CompleteDatasetChunkInvocations++
+
+	s.CompleteDatasetChunkInputs = append(s.CompleteDatasetChunkInputs, CompleteDatasetChunkInput{dataset, chunkID, response})
+
+	if len(s.CompleteDatasetChunkOutputs) == 0 {
+		panic("Unexpected invocation of CompleteDatasetChunk on Session")
+	}
+
+	output := s.CompleteDatasetChunkOutputs[0]
+	s.CompleteDatasetChunkOutputs = s.CompleteDatasetChunkOutputs[1:]
+	return output
+}
+
+func (s *Session) FailDatasetChunk(dataset *upload.Upload, chunkID string) error {
+	s.FailDatasetChunkInvocations++
+
+	s.FailDatasetChunkInputs = append(s.FailDatasetChunkInputs, FailDatasetChunkInput{dataset, chunkID})
+
+	if len(s.FailDatasetChunkOutputs) == 0 {
+		panic("Unexpected invocation of FailDatasetChunk on Session")
+	}
+
+	output := s.FailDatasetChunkOutputs[0]
+	s.FailDatasetChunkOutputs = s.FailDatasetChunkOutputs[1:]
+	return output
+}
+
+func (s *Session) GetDatasetDataDeduplicatorHashes(dataset *upload.Upload, hashes []string) ([]string, error) {
+	s.GetDatasetDataDeduplicatorHashesInvocations++
+
+	s.GetDatasetDataDeduplicatorHashesInputs = append(s.GetDatasetDataDeduplicatorHashesInputs, GetDatasetDataDeduplicatorHashesInput{dataset, hashes})
+
+	if len(s.GetDatasetDataDeduplicatorHashesOutputs) == 0 {
+		panic("Unexpected invocation of GetDatasetDataDeduplicatorHashes on Session")
+	}
+
+	output := s.GetDatasetDataDeduplicatorHashesOutputs[0]
+	s.GetDatasetDataDeduplicatorHashesOutputs = s.GetDatasetDataDeduplicatorHashesOutputs[1:]
+	return output.Hashes, output.Error
+}
+
 func (s *Session) CreateDatasetData(dataset *upload.Upload, datasetData []data.Datum) error {
 	s.CreateDatasetDataInvocations++
 
@@ -207,6 +614,34 @@ func (s *Session) ActivateDatasetData(dataset *upload.Upload) error {
 	return output
 }
 
+func (s *Session) DeactivateDatasetData(dataset *upload.Upload) error {
+	s.DeactivateDatasetDataInvocations++
+
+	s.DeactivateDatasetDataInputs = append(s.DeactivateDatasetDataInputs, dataset)
+
+	if len(s.DeactivateDatasetDataOutputs) == 0 {
+		panic("Unexpected invocation of DeactivateDatasetData on Session")
+	}
+
+	output := s.DeactivateDatasetDataOutputs[0]
+	s.DeactivateDatasetDataOutputs = s.DeactivateDatasetDataOutputs[1:]
+	return output
+}
+
+func (s *Session) DeleteInactiveDatasetData(dataset *upload.Upload) error {
+	s.DeleteInactiveDatasetDataInvocations++
+
+	s.DeleteInactiveDatasetDataInputs = append(s.DeleteInactiveDatasetDataInputs, dataset)
+
+	if len(s.DeleteInactiveDatasetDataOutputs) == 0 {
+		panic("Unexpected invocation of DeleteInactiveDatasetData on Session")
+	}
+
+	output := s.DeleteInactiveDatasetDataOutputs[0]
+	s.DeleteInactiveDatasetDataOutputs = s.DeleteInactiveDatasetDataOutputs[1:]
+	return output
+}
+
 func (s *Session) ArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) error {
 	s.ArchiveDeviceDataUsingHashesFromDatasetInvocations++
 
@@ -235,6 +670,76 @@ func (s *Session) UnarchiveDeviceDataUsingHashesFromDataset(dataset *upload.Uplo
 	return output
 }
 
+func (s *Session) ArchiveDeviceDataUsingTimeWindowFromDataset(dataset *upload.Upload) error {
+	s.ArchiveDeviceDataUsingTimeWindowFromDatasetInvocations++
+
+	s.ArchiveDeviceDataUsingTimeWindowFromDatasetInputs = append(s.ArchiveDeviceDataUsingTimeWindowFromDatasetInputs, dataset)
+
+	if len(s.ArchiveDeviceDataUsingTimeWindowFromDatasetOutputs) == 0 {
+		panic("Unexpected invocation of ArchiveDeviceDataUsingTimeWindowFromDataset on Session")
+	}
+
+	output := s.ArchiveDeviceDataUsingTimeWindowFromDatasetOutputs[0]
+	s.ArchiveDeviceDataUsingTimeWindowFromDatasetOutputs = s.ArchiveDeviceDataUsingTimeWindowFromDatasetOutputs[1:]
+	return output
+}
+
+func (s *Session) UnarchiveDeviceDataArchivedByDataset(dataset *upload.Upload) error {
+	s.UnarchiveDeviceDataArchivedByDatasetInvocations++
+
+	s.UnarchiveDeviceDataArchivedByDatasetInputs = append(s.UnarchiveDeviceDataArchivedByDatasetInputs, dataset)
+
+	if len(s.UnarchiveDeviceDataArchivedByDatasetOutputs) == 0 {
+		panic("Unexpected invocation of UnarchiveDeviceDataArchivedByDataset on Session")
+	}
+
+	output := s.UnarchiveDeviceDataArchivedByDatasetOutputs[0]
+	s.UnarchiveDeviceDataArchivedByDatasetOutputs = s.UnarchiveDeviceDataArchivedByDatasetOutputs[1:]
+	return output
+}
+
+func (s *Session) RetainDeviceDataArchivedByDataset(dataset *upload.Upload) error {
+	s.RetainDeviceDataArchivedByDatasetInvocations++
+
+	s.RetainDeviceDataArchivedByDatasetInputs = append(s.RetainDeviceDataArchivedByDatasetInputs, dataset)
+
+	if len(s.RetainDeviceDataArchivedByDatasetOutputs) == 0 {
+		panic("Unexpected invocation of RetainDeviceDataArchivedByDataset on Session")
+	}
+
+	output := s.RetainDeviceDataArchivedByDatasetOutputs[0]
+	s.RetainDeviceDataArchivedByDatasetOutputs = s.RetainDeviceDataArchivedByDatasetOutputs[1:]
+	return output
+}
+
+func (s *Session) RearchiveDeviceDataRetainedByDataset(dataset *upload.Upload) error {
+	s.RearchiveDeviceDataRetainedByDatasetInvocations++
+
+	s.RearchiveDeviceDataRetainedByDatasetInputs = append(s.RearchiveDeviceDataRetainedByDatasetInputs, dataset)
+
+	if len(s.RearchiveDeviceDataRetainedByDatasetOutputs) == 0 {
+		panic("Unexpected invocation of RearchiveDeviceDataRetainedByDataset on Session")
+	}
+
+	output := s.RearchiveDeviceDataRetainedByDatasetOutputs[0]
+	s.RearchiveDeviceDataRetainedByDatasetOutputs = s.RearchiveDeviceDataRetainedByDatasetOutputs[1:]
+	return output
+}
+
+func (s *Session) ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset *upload.Upload, deviceTimeTolerance time.Duration) error {
+	s.ArchiveDeviceDataUsingFuzzyHashesFromDatasetInvocations++
+
+	s.ArchiveDeviceDataUsingFuzzyHashesFromDatasetInputs = append(s.ArchiveDeviceDataUsingFuzzyHashesFromDatasetInputs, FuzzyHashesFromDatasetInput{Dataset: dataset, DeviceTimeTolerance: deviceTimeTolerance})
+
+	if len(s.ArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs) == 0 {
+		panic("Unexpected invocation of ArchiveDeviceDataUsingFuzzyHashesFromDataset on Session")
+	}
+
+	output := s.ArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs[0]
+	s.ArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs = s.ArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs[1:]
+	return output
+}
+
 func (s *Session) DeleteOtherDatasetData(dataset *upload.Upload) error {
 	s.DeleteOtherDatasetDataInvocations++
 
@@ -249,6 +754,188 @@ func (s *Session) DeleteOtherDatasetData(dataset *upload.Upload) error {
 	return output
 }
 
+func (s *Session) PreviewActivateDatasetData(dataset *upload.Upload) (*store.DataPreview, error) {
+	s.PreviewActivateDatasetDataInvocations++
+
+	s.PreviewActivateDatasetDataInputs = append(s.PreviewActivateDatasetDataInputs, dataset)
+
+	if len(s.PreviewActivateDatasetDataOutputs) == 0 {
+		panic("Unexpected invocation of PreviewActivateDatasetData on Session")
+	}
+
+	output := s.PreviewActivateDatasetDataOutputs[0]
+	s.PreviewActivateDatasetDataOutputs = s.PreviewActivateDatasetDataOutputs[1:]
+	return output.Preview, output.Error
+}
+
+func (s *Session) PreviewArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) (*store.DataPreview, error) {
+	s.PreviewArchiveDeviceDataUsingHashesFromDatasetInvocations++
+
+	s.PreviewArchiveDeviceDataUsingHashesFromDatasetInputs = append(s.PreviewArchiveDeviceDataUsingHashesFromDatasetInputs, dataset)
+
+	if len(s.PreviewArchiveDeviceDataUsingHashesFromDatasetOutputs) == 0 {
+		panic("Unexpected invocation of PreviewArchiveDeviceDataUsingHashesFromDataset on Session")
+	}
+
+	output := s.PreviewArchiveDeviceDataUsingHashesFromDatasetOutputs[0]
+	s.PreviewArchiveDeviceDataUsingHashesFromDatasetOutputs = s.PreviewArchiveDeviceDataUsingHashesFromDatasetOutputs[1:]
+	return output.Preview, output.Error
+}
+
+func (s *Session) PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset *upload.Upload) (*store.DataPreview, error) {
+	s.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetInvocations++
+
+	s.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetInputs = append(s.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetInputs, dataset)
+
+	if len(s.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetOutputs) == 0 {
+		panic("Unexpected invocation of PreviewArchiveDeviceDataUsingTimeWindowFromDataset on Session")
+	}
+
+	output := s.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetOutputs[0]
+	s.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetOutputs = s.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetOutputs[1:]
+	return output.Preview, output.Error
+}
+
+func (s *Session) PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset *upload.Upload, deviceTimeTolerance time.Duration) (*store.DataPreview, error) {
+	s.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetInvocations++
+
+	s.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetInputs = append(s.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetInputs, FuzzyHashesFromDatasetInput{Dataset: dataset, DeviceTimeTolerance: deviceTimeTolerance})
+
+	if len(s.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs) == 0 {
+		panic("Unexpected invocation of PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset on Session")
+	}
+
+	output := s.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs[0]
+	s.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs = s.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs[1:]
+	return output.Preview, output.Error
+}
+
+func (s *Session) PreviewDeleteOtherDatasets(dataset *upload.Upload) (*store.DataPreview, error) {
+	s.PreviewDeleteOtherDatasetsInvocations++
+
+	s.PreviewDeleteOtherDatasetsInputs = append(s.PreviewDeleteOtherDatasetsInputs, dataset)
+
+	if len(s.PreviewDeleteOtherDatasetsOutputs) == 0 {
+		panic("Unexpected invocation of PreviewDeleteOtherDatasets on Session")
+	}
+
+	output := s.PreviewDeleteOtherDatasetsOutputs[0]
+	s.PreviewDeleteOtherDatasetsOutputs = s.PreviewDeleteOtherDatasetsOutputs[1:]
+	return output.Preview, output.Error
+}
+
+func (s *Session) PreviewDeleteOtherDatasetData(dataset *upload.Upload) (*store.DataPreview, error) {
+	s.PreviewDeleteOtherDatasetDataInvocations++
+
+	s.PreviewDeleteOtherDatasetDataInputs = append(s.PreviewDeleteOtherDatasetDataInputs, dataset)
+
+	if len(s.PreviewDeleteOtherDatasetDataOutputs) == 0 {
+		panic("Unexpected invocation of PreviewDeleteOtherDatasetData on Session")
+	}
+
+	output := s.PreviewDeleteOtherDatasetDataOutputs[0]
+	s.PreviewDeleteOtherDatasetDataOutputs = s.PreviewDeleteOtherDatasetDataOutputs[1:]
+	return output.Preview, output.Error
+}
+
+func (s *Session) GetDataForDatasetByID(datasetID string, filter *store.DatasetDataFilter, pagination *store.Pagination) ([]map[string]interface{}, error) {
+	s.GetDataForDatasetByIDInvocations++
+
+	s.GetDataForDatasetByIDInputs = append(s.GetDataForDatasetByIDInputs, GetDataForDatasetByIDInput{datasetID, filter, pagination})
+
+	if len(s.GetDataForDatasetByIDOutputs) == 0 {
+		panic("Unexpected invocation of GetDataForDatasetByID on Session")
+	}
+
+	output := s.GetDataForDatasetByIDOutputs[0]
+	s.GetDataForDatasetByIDOutputs = s.GetDataForDatasetByIDOutputs[1:]
+	return output.Data, output.Error
+}
+
+func (s *Session) GetDataForUserByID(userID string, filter *store.DataFilter, pagination *store.Pagination) ([]map[string]interface{}, error) {
+	s.GetDataForUserByIDInvocations++
+
+	s.GetDataForUserByIDInputs = append(s.GetDataForUserByIDInputs, GetDataForUserByIDInput{userID, filter, pagination})
+
+	if len(s.GetDataForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of GetDataForUserByID on Session")
+	}
+
+	output := s.GetDataForUserByIDOutputs[0]
+	s.GetDataForUserByIDOutputs = s.GetDataForUserByIDOutputs[1:]
+	return output.Data, output.Error
+}
+
+func (s *Session) IterateDataForUserByID(userID string, filter *store.DataFilter) (store.Iterator, error) {
+	s.IterateDataForUserByIDInvocations++
+
+	s.IterateDataForUserByIDInputs = append(s.IterateDataForUserByIDInputs, IterateDataForUserByIDInput{userID, filter})
+
+	if len(s.IterateDataForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of IterateDataForUserByID on Session")
+	}
+
+	output := s.IterateDataForUserByIDOutputs[0]
+	s.IterateDataForUserByIDOutputs = s.IterateDataForUserByIDOutputs[1:]
+	return output.Iterator, output.Error
+}
+
+func (s *Session) IterateAllDataForUserByID(userID string) (store.Iterator, error) {
+	s.IterateAllDataForUserByIDInvocations++
+
+	s.IterateAllDataForUserByIDInputs = append(s.IterateAllDataForUserByIDInputs, userID)
+
+	if len(s.IterateAllDataForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of IterateAllDataForUserByID on Session")
+	}
+
+	output := s.IterateAllDataForUserByIDOutputs[0]
+	s.IterateAllDataForUserByIDOutputs = s.IterateAllDataForUserByIDOutputs[1:]
+	return output.Iterator, output.Error
+}
+
+func (s *Session) RestoreDataForUserByID(userID string, restoreID string, restoreTime time.Time) (bool, error) {
+	s.RestoreDataForUserByIDInvocations++
+
+	s.RestoreDataForUserByIDInputs = append(s.RestoreDataForUserByIDInputs, RestoreDataForUserByIDInput{UserID: userID, RestoreID: restoreID, RestoreTime: restoreTime})
+
+	if len(s.RestoreDataForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of RestoreDataForUserByID on Session")
+	}
+
+	output := s.RestoreDataForUserByIDOutputs[0]
+	s.RestoreDataForUserByIDOutputs = s.RestoreDataForUserByIDOutputs[1:]
+	return output.Restored, output.Error
+}
+
+func (s *Session) UndoRestoreDataForUserByID(userID string, restoreID string) error {
+	s.UndoRestoreDataForUserByIDInvocations++
+
+	s.UndoRestoreDataForUserByIDInputs = append(s.UndoRestoreDataForUserByIDInputs, UndoRestoreDataForUserByIDInput{UserID: userID, RestoreID: restoreID})
+
+	if len(s.UndoRestoreDataForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of UndoRestoreDataForUserByID on Session")
+	}
+
+	output := s.UndoRestoreDataForUserByIDOutputs[0]
+	s.UndoRestoreDataForUserByIDOutputs = s.UndoRestoreDataForUserByIDOutputs[1:]
+	return output
+}
+
+func (s *Session) PreviewRestoreDataForUserByID(userID string, restoreTime time.Time) (*store.DataRestorePreview, error) {
+	s.PreviewRestoreDataForUserByIDInvocations++
+
+	s.PreviewRestoreDataForUserByIDInputs = append(s.PreviewRestoreDataForUserByIDInputs, PreviewRestoreDataForUserByIDInput{UserID: userID, RestoreTime: restoreTime})
+
+	if len(s.PreviewRestoreDataForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of PreviewRestoreDataForUserByID on Session")
+	}
+
+	output := s.PreviewRestoreDataForUserByIDOutputs[0]
+	s.PreviewRestoreDataForUserByIDOutputs = s.PreviewRestoreDataForUserByIDOutputs[1:]
+	return output.Preview, output.Error
+}
+
 func (s *Session) DestroyDataForUserByID(userID string) error {
 	s.DestroyDataForUserByIDInvocations++
 
@@ -263,17 +950,64 @@ func (s *Session) DestroyDataForUserByID(userID string) error {
 	return output
 }
 
+func (s *Session) DestroyDeletedData(deletedBefore time.Time) error {
+	s.DestroyDeletedDataInvocations++
+
+	s.DestroyDeletedDataInputs = append(s.DestroyDeletedDataInputs, deletedBefore)
+
+	if len(s.DestroyDeletedDataOutputs) == 0 {
+		panic("Unexpected invocation of DestroyDeletedData on Session")
+	}
+
+	output := s.DestroyDeletedDataOutputs[0]
+	s.DestroyDeletedDataOutputs = s.DestroyDeletedDataOutputs[1:]
+	return output
+}
+
 func (s *Session) UnusedOutputsCount() int {
 	return len(s.IsClosedOutputs) +
 		len(s.GetDatasetsForUserByIDOutputs) +
 		len(s.GetDatasetByIDOutputs) +
+		len(s.GetJournaledDatasetsOutputs) +
+		len(s.GetDatasetSummaryOutputs) +
+		len(s.GetDatasetLatestTimeOutputs) +
 		len(s.CreateDatasetOutputs) +
 		len(s.UpdateDatasetOutputs) +
+		len(s.UpdateDatasetJournalOutputs) +
+		len(s.UpdateDatasetRededuplicateJournalOutputs) +
+		len(s.TransitionDatasetStateOutputs) +
 		len(s.DeleteDatasetOutputs) +
+		len(s.UndeleteDatasetOutputs) +
+		len(s.GetDatasetChunkOutputs) +
+		len(s.CreateDatasetChunkOutputs) +
+		len(s.CompleteDatasetChunkOutputs) +
+		len(s.FailDatasetChunkOutputs) +
+		len(s.GetDatasetDataDeduplicatorHashesOutputs) +
 		len(s.CreateDatasetDataOutputs) +
 		len(s.ActivateDatasetDataOutputs) +
+		len(s.DeactivateDatasetDataOutputs) +
+		len(s.DeleteInactiveDatasetDataOutputs) +
 		len(s.ArchiveDeviceDataUsingHashesFromDatasetOutputs) +
 		len(s.UnarchiveDeviceDataUsingHashesFromDatasetOutputs) +
+		len(s.ArchiveDeviceDataUsingTimeWindowFromDatasetOutputs) +
+		len(s.UnarchiveDeviceDataArchivedByDatasetOutputs) +
+		len(s.RetainDeviceDataArchivedByDatasetOutputs) +
+		len(s.RearchiveDeviceDataRetainedByDatasetOutputs) +
+		len(s.ArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs) +
 		len(s.DeleteOtherDatasetDataOutputs) +
-		len(s.DestroyDataForUserByIDOutputs)
+		len(s.PreviewActivateDatasetDataOutputs) +
+		len(s.PreviewArchiveDeviceDataUsingHashesFromDatasetOutputs) +
+		len(s.PreviewArchiveDeviceDataUsingTimeWindowFromDatasetOutputs) +
+		len(s.PreviewArchiveDeviceDataUsingFuzzyHashesFromDatasetOutputs) +
+		len(s.PreviewDeleteOtherDatasetsOutputs) +
+		len(s.PreviewDeleteOtherDatasetDataOutputs) +
+		len(s.GetDataForDatasetByIDOutputs) +
+		len(s.GetDataForUserByIDOutputs) +
+		len(s.IterateDataForUserByIDOutputs) +
+		len(s.IterateAllDataForUserByIDOutputs) +
+		len(s.RestoreDataForUserByIDOutputs) +
+		len(s.UndoRestoreDataForUserByIDOutputs) +
+		len(s.PreviewRestoreDataForUserByIDOutputs) +
+		len(s.DestroyDataForUserByIDOutputs) +
+		len(s.DestroyDeletedDataOutputs)
 }
@@ -26,6 +26,9 @@ type Datum struct {
 	NormalizeOutputs                     []error
 	IdentityFieldsInvocations            int
 	IdentityFieldsOutputs                []IdentityFieldsOutput
+	FuzzyIdentityFieldsInvocations       int
+	FuzzyIdentityFieldsOutputs           []IdentityFieldsOutput
+	DatumIDInvocations                   int
 	SetUserIDInvocations                 int
 	SetUserIDInputs                      []string
 	SetGroupIDInvocations                int
@@ -127,6 +130,24 @@ func (d *Datum) IdentityFields() ([]string, error) {
 	return output.IdentityFields, output.Error
 }
 
+func (d *Datum) FuzzyIdentityFields() ([]string, error) {
+	d.FuzzyIdentityFieldsInvocations++
+
+	if len(d.FuzzyIdentityFieldsOutputs) == 0 {
+		panic("Unexpected invocation of FuzzyIdentityFields on Datum")
+	}
+
+	output := d.FuzzyIdentityFieldsOutputs[0]
+	d.FuzzyIdentityFieldsOutputs = d.FuzzyIdentityFieldsOutputs[1:]
+	return output.IdentityFields, output.Error
+}
+
+func (d *Datum) DatumID() string {
+	d.DatumIDInvocations++
+
+	return d.ID
+}
+
 func (d *Datum) SetUserID(userID string) {
 	d.SetUserIDInvocations++
 
@@ -204,5 +225,6 @@ func (d *Datum) UnusedOutputsCount() int {
 		len(d.ParseOutputs) +
 		len(d.ValidateOutputs) +
 		len(d.NormalizeOutputs) +
-		len(d.IdentityFieldsOutputs)
+		len(d.IdentityFieldsOutputs) +
+		len(d.FuzzyIdentityFieldsOutputs)
 }
@@ -5,21 +5,37 @@ import (
 	"github.com/tidepool-org/platform/data"
 )
 
+type PreviewDeduplicateDatasetOutput struct {
+	Preview *data.DeduplicatorPreview
+	Error   error
+}
+
+type ReopenDatasetOutput struct {
+	Reopened bool
+	Error    error
+}
+
 type Deduplicator struct {
-	ID                            string
-	NameInvocations               int
-	NameOutputs                   []string
-	VersionInvocations            int
-	VersionOutputs                []string
-	RegisterDatasetInvocations    int
-	RegisterDatasetOutputs        []error
-	AddDatasetDataInvocations     int
-	AddDatasetDataInputs          [][]data.Datum
-	AddDatasetDataOutputs         []error
-	DeduplicateDatasetInvocations int
-	DeduplicateDatasetOutputs     []error
-	DeleteDatasetInvocations      int
-	DeleteDatasetOutputs          []error
+	ID                                   string
+	NameInvocations                      int
+	NameOutputs                          []string
+	VersionInvocations                   int
+	VersionOutputs                       []string
+	RegisterDatasetInvocations           int
+	RegisterDatasetOutputs               []error
+	AddDatasetDataInvocations            int
+	AddDatasetDataInputs                 [][]data.Datum
+	AddDatasetDataOutputs                []error
+	DeduplicateDatasetInvocations        int
+	DeduplicateDatasetOutputs            []error
+	PreviewDeduplicateDatasetInvocations int
+	PreviewDeduplicateDatasetOutputs     []PreviewDeduplicateDatasetOutput
+	ReopenDatasetInvocations             int
+	ReopenDatasetOutputs                 []ReopenDatasetOutput
+	UndeduplicateDatasetInvocations      int
+	UndeduplicateDatasetOutputs          []error
+	DeleteDatasetInvocations             int
+	DeleteDatasetOutputs                 []error
 }
 
 func NewDeduplicator() *Deduplicator {
@@ -90,6 +106,42 @@ func (d *Deduplicator) DeduplicateDataset() error {
 	return output
 }
 
+func (d *Deduplicator) PreviewDeduplicateDataset() (*data.DeduplicatorPreview, error) {
+	d.PreviewDeduplicateDatasetInvocations++
+
+	if len(d.PreviewDeduplicateDatasetOutputs) == 0 {
+		panic("Unexpected invocation of PreviewDeduplicateDataset on Deduplicator")
+	}
+
+	output := d.PreviewDeduplicateDatasetOutputs[0]
+	d.PreviewDeduplicateDatasetOutputs = d.PreviewDeduplicateDatasetOutputs[1:]
+	return output.Preview, output.Error
+}
+
+func (d *Deduplicator) ReopenDataset() (bool, error) {
+	d.ReopenDatasetInvocations++
+
+	if len(d.ReopenDatasetOutputs) == 0 {
+		panic("Unexpected invocation of ReopenDataset on Deduplicator")
+	}
+
+	output := d.ReopenDatasetOutputs[0]
+	d.ReopenDatasetOutputs = d.ReopenDatasetOutputs[1:]
+	return output.Reopened, output.Error
+}
+
+func (d *Deduplicator) UndeduplicateDataset() error {
+	d.UndeduplicateDatasetInvocations++
+
+	if len(d.UndeduplicateDatasetOutputs) == 0 {
+		panic("Unexpected invocation of UndeduplicateDataset on Deduplicator")
+	}
+
+	output := d.UndeduplicateDatasetOutputs[0]
+	d.UndeduplicateDatasetOutputs = d.UndeduplicateDatasetOutputs[1:]
+	return output
+}
+
 func (d *Deduplicator) DeleteDataset() error {
 	d.DeleteDatasetInvocations++
 
@@ -108,5 +160,8 @@ func (d *Deduplicator) UnusedOutputsCount() int {
 		len(d.RegisterDatasetOutputs) +
 		len(d.AddDatasetDataOutputs) +
 		len(d.DeduplicateDatasetOutputs) +
+		len(d.PreviewDeduplicateDatasetOutputs) +
+		len(d.ReopenDatasetOutputs) +
+		len(d.UndeduplicateDatasetOutputs) +
 		len(d.DeleteDatasetOutputs)
 }
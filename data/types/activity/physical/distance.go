@@ -0,0 +1,76 @@
+package physical
+
+import (
+	"math"
+
+	"github.com/tidepool-org/platform/data"
+)
+
+const (
+	DistanceUnitsFeet       = "feet"
+	DistanceUnitsKilometers = "kilometers"
+	DistanceUnitsMeters     = "meters"
+	DistanceUnitsMiles      = "miles"
+	DistanceUnitsYards      = "yards"
+
+	DistanceValueMinimum           = 0.0
+	DistanceValueFeetMaximum       = 3280839.895
+	DistanceValueKilometersMaximum = 1000.0
+	DistanceValueMetersMaximum     = 1000000.0
+	DistanceValueMilesMaximum      = 621.371
+	DistanceValueYardsMaximum      = 1093613.298
+)
+
+func DistanceUnits() []string {
+	return []string{DistanceUnitsFeet, DistanceUnitsKilometers, DistanceUnitsMeters, DistanceUnitsMiles, DistanceUnitsYards}
+}
+
+func DistanceValueRangeForUnits(units *string) (float64, float64) {
+	if units != nil {
+		switch *units {
+		case DistanceUnitsFeet:
+			return DistanceValueMinimum, DistanceValueFeetMaximum
+		case DistanceUnitsKilometers:
+			return DistanceValueMinimum, DistanceValueKilometersMaximum
+		case DistanceUnitsMeters:
+			return DistanceValueMinimum, DistanceValueMetersMaximum
+		case DistanceUnitsMiles:
+			return DistanceValueMinimum, DistanceValueMilesMaximum
+		case DistanceUnitsYards:
+			return DistanceValueMinimum, DistanceValueYardsMaximum
+		}
+	}
+	return -math.MaxFloat64, math.MaxFloat64
+}
+
+type Distance struct {
+	Units *string  `json:"units,omitempty" bson:"units,omitempty"`
+	Value *float64 `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+func NewDistance() *Distance {
+	return &Distance{}
+}
+
+func (d *Distance) Parse(parser data.ObjectParser) {
+	d.Units = parser.ParseString("units")
+	d.Value = parser.ParseFloat("value")
+}
+
+func (d *Distance) Validate(validator data.Validator) {
+	validator.ValidateString("units", d.Units).Exists().OneOf(DistanceUnits())
+	validator.ValidateFloat("value", d.Value).Exists().InRange(DistanceValueRangeForUnits(d.Units))
+}
+
+func (d *Distance) Normalize(normalizer data.Normalizer) {
+}
+
+func ParseDistance(parser data.ObjectParser) *Distance {
+	var distance *Distance
+	if parser.Object() != nil {
+		distance = NewDistance()
+		distance.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return distance
+}
@@ -0,0 +1,68 @@
+package physical
+
+import (
+	"math"
+
+	"github.com/tidepool-org/platform/data"
+)
+
+const (
+	DurationUnitsHours   = "hours"
+	DurationUnitsMinutes = "minutes"
+	DurationUnitsSeconds = "seconds"
+
+	DurationValueMinimum        = 0.0
+	DurationValueHoursMaximum   = 24.0
+	DurationValueMinutesMaximum = 1440.0
+	DurationValueSecondsMaximum = 86400.0
+)
+
+func DurationUnits() []string {
+	return []string{DurationUnitsHours, DurationUnitsMinutes, DurationUnitsSeconds}
+}
+
+func DurationValueRangeForUnits(units *string) (float64, float64) {
+	if units != nil {
+		switch *units {
+		case DurationUnitsHours:
+			return DurationValueMinimum, DurationValueHoursMaximum
+		case DurationUnitsMinutes:
+			return DurationValueMinimum, DurationValueMinutesMaximum
+		case DurationUnitsSeconds:
+			return DurationValueMinimum, DurationValueSecondsMaximum
+		}
+	}
+	return -math.MaxFloat64, math.MaxFloat64
+}
+
+type Duration struct {
+	Units *string  `json:"units,omitempty" bson:"units,omitempty"`
+	Value *float64 `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+func NewDuration() *Duration {
+	return &Duration{}
+}
+
+func (d *Duration) Parse(parser data.ObjectParser) {
+	d.Units = parser.ParseString("units")
+	d.Value = parser.ParseFloat("value")
+}
+
+func (d *Duration) Validate(validator data.Validator) {
+	validator.ValidateString("units", d.Units).Exists().OneOf(DurationUnits())
+	validator.ValidateFloat("value", d.Value).Exists().InRange(DurationValueRangeForUnits(d.Units))
+}
+
+func (d *Duration) Normalize(normalizer data.Normalizer) {
+}
+
+func ParseDuration(parser data.ObjectParser) *Duration {
+	var duration *Duration
+	if parser.Object() != nil {
+		duration = NewDuration()
+		duration.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return duration
+}
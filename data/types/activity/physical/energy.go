@@ -0,0 +1,64 @@
+package physical
+
+import (
+	"math"
+
+	"github.com/tidepool-org/platform/data"
+)
+
+const (
+	EnergyUnitsKilocalories = "kilocalories"
+	EnergyUnitsKilojoules   = "kilojoules"
+
+	EnergyValueMinimum             = 0.0
+	EnergyValueKilocaloriesMaximum = 10000.0
+	EnergyValueKilojoulesMaximum   = 41840.0
+)
+
+func EnergyUnits() []string {
+	return []string{EnergyUnitsKilocalories, EnergyUnitsKilojoules}
+}
+
+func EnergyValueRangeForUnits(units *string) (float64, float64) {
+	if units != nil {
+		switch *units {
+		case EnergyUnitsKilocalories:
+			return EnergyValueMinimum, EnergyValueKilocaloriesMaximum
+		case EnergyUnitsKilojoules:
+			return EnergyValueMinimum, EnergyValueKilojoulesMaximum
+		}
+	}
+	return -math.MaxFloat64, math.MaxFloat64
+}
+
+type Energy struct {
+	Units *string  `json:"units,omitempty" bson:"units,omitempty"`
+	Value *float64 `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+func NewEnergy() *Energy {
+	return &Energy{}
+}
+
+func (e *Energy) Parse(parser data.ObjectParser) {
+	e.Units = parser.ParseString("units")
+	e.Value = parser.ParseFloat("value")
+}
+
+func (e *Energy) Validate(validator data.Validator) {
+	validator.ValidateString("units", e.Units).Exists().OneOf(EnergyUnits())
+	validator.ValidateFloat("value", e.Value).Exists().InRange(EnergyValueRangeForUnits(e.Units))
+}
+
+func (e *Energy) Normalize(normalizer data.Normalizer) {
+}
+
+func ParseEnergy(parser data.ObjectParser) *Energy {
+	var energy *Energy
+	if parser.Object() != nil {
+		energy = NewEnergy()
+		energy.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return energy
+}
@@ -0,0 +1,191 @@
+package physical
+
+import (
+	"strconv"
+
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
+)
+
+const (
+	ActivityTypeAerobics         = "aerobics"
+	ActivityTypeCycling          = "cycling"
+	ActivityTypeDancing          = "dancing"
+	ActivityTypeElliptical       = "elliptical"
+	ActivityTypeHiking           = "hiking"
+	ActivityTypeOther            = "other"
+	ActivityTypeRowing           = "rowing"
+	ActivityTypeRunning          = "running"
+	ActivityTypeSports           = "sports"
+	ActivityTypeStrengthTraining = "strengthTraining"
+	ActivityTypeSwimming         = "swimming"
+	ActivityTypeWalking          = "walking"
+	ActivityTypeYoga             = "yoga"
+
+	ReportedIntensityLow    = "low"
+	ReportedIntensityMedium = "medium"
+	ReportedIntensityHigh   = "high"
+)
+
+func ActivityTypes() []string {
+	return []string{
+		ActivityTypeAerobics,
+		ActivityTypeCycling,
+		ActivityTypeDancing,
+		ActivityTypeElliptical,
+		ActivityTypeHiking,
+		ActivityTypeOther,
+		ActivityTypeRowing,
+		ActivityTypeRunning,
+		ActivityTypeSports,
+		ActivityTypeStrengthTraining,
+		ActivityTypeSwimming,
+		ActivityTypeWalking,
+		ActivityTypeYoga,
+	}
+}
+
+func ReportedIntensities() []string {
+	return []string{ReportedIntensityLow, ReportedIntensityMedium, ReportedIntensityHigh}
+}
+
+type Physical struct {
+	types.Base `bson:",inline"`
+
+	ActivityType      *string   `json:"activityType,omitempty" bson:"activityType,omitempty"`
+	Duration          *Duration `json:"duration,omitempty" bson:"duration,omitempty"`
+	Distance          *Distance `json:"distance,omitempty" bson:"distance,omitempty"`
+	Energy            *Energy   `json:"energy,omitempty" bson:"energy,omitempty"`
+	ReportedIntensity *string   `json:"reportedIntensity,omitempty" bson:"reportedIntensity,omitempty"`
+}
+
+func Type() string {
+	return "physicalActivity"
+}
+
+func NewDatum() data.Datum {
+	return New()
+}
+
+func New() *Physical {
+	return &Physical{}
+}
+
+func Init() *Physical {
+	physical := New()
+	physical.Init()
+	return physical
+}
+
+func (p *Physical) Init() {
+	p.Base.Init()
+	p.Type = Type()
+
+	p.ActivityType = nil
+	p.Duration = nil
+	p.Distance = nil
+	p.Energy = nil
+	p.ReportedIntensity = nil
+}
+
+func (p *Physical) Parse(parser data.ObjectParser) error {
+	parser.SetMeta(p.Meta())
+
+	if err := p.Base.Parse(parser); err != nil {
+		return err
+	}
+
+	p.ActivityType = parser.ParseString("activityType")
+	p.ReportedIntensity = parser.ParseString("reportedIntensity")
+
+	p.Duration = ParseDuration(parser.NewChildObjectParser("duration"))
+	p.Distance = ParseDistance(parser.NewChildObjectParser("distance"))
+	p.Energy = ParseEnergy(parser.NewChildObjectParser("energy"))
+
+	return nil
+}
+
+func (p *Physical) Validate(validator data.Validator) error {
+	validator.SetMeta(p.Meta())
+
+	if err := p.Base.Validate(validator); err != nil {
+		return err
+	}
+
+	validator.ValidateString("type", &p.Type).EqualTo(Type())
+
+	validator.ValidateString("activityType", p.ActivityType).OneOf(ActivityTypes())
+	validator.ValidateString("reportedIntensity", p.ReportedIntensity).OneOf(ReportedIntensities())
+
+	if p.Duration != nil {
+		p.Duration.Validate(validator.NewChildValidator("duration"))
+	}
+
+	if p.Distance != nil {
+		p.Distance.Validate(validator.NewChildValidator("distance"))
+	}
+
+	if p.Energy != nil {
+		p.Energy.Validate(validator.NewChildValidator("energy"))
+	}
+
+	return nil
+}
+
+func (p *Physical) Normalize(normalizer data.Normalizer) error {
+	normalizer.SetMeta(p.Meta())
+
+	if err := p.Base.Normalize(normalizer); err != nil {
+		return err
+	}
+
+	if p.Duration != nil {
+		p.Duration.Normalize(normalizer.NewChildNormalizer("duration"))
+	}
+
+	if p.Distance != nil {
+		p.Distance.Normalize(normalizer.NewChildNormalizer("distance"))
+	}
+
+	if p.Energy != nil {
+		p.Energy.Normalize(normalizer.NewChildNormalizer("energy"))
+	}
+
+	return nil
+}
+
+func (p *Physical) IdentityFields() ([]string, error) {
+	return p.identityFields(p.Base.IdentityFields())
+}
+
+func (p *Physical) FuzzyIdentityFields() ([]string, error) {
+	return p.identityFields(p.Base.FuzzyIdentityFields())
+}
+
+func (p *Physical) identityFields(identityFields []string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if p.ActivityType != nil {
+		identityFields = append(identityFields, "activityType", *p.ActivityType)
+	}
+	if p.Duration != nil {
+		identityFields = appendIdentityFields(identityFields, "duration", p.Duration.Units, p.Duration.Value)
+	}
+	if p.Distance != nil {
+		identityFields = appendIdentityFields(identityFields, "distance", p.Distance.Units, p.Distance.Value)
+	}
+	if p.Energy != nil {
+		identityFields = appendIdentityFields(identityFields, "energy", p.Energy.Units, p.Energy.Value)
+	}
+
+	return identityFields, nil
+}
+
+func appendIdentityFields(identityFields []string, name string, units *string, value *float64) []string {
+	if units == nil || value == nil {
+		return identityFields
+	}
+	return append(identityFields, name, strconv.FormatFloat(*value, 'f', -1, 64), *units)
+}
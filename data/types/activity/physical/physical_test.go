@@ -0,0 +1,287 @@
+package physical_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/deduplicator"
+	"github.com/tidepool-org/platform/data/parser"
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/activity/physical"
+	"github.com/tidepool-org/platform/service"
+)
+
+func NewRawObject() map[string]interface{} {
+	rawObject := testData.RawBaseObject()
+	rawObject["type"] = "physicalActivity"
+	rawObject["activityType"] = "running"
+	rawObject["duration"] = map[string]interface{}{"units": "minutes", "value": 45.0}
+	rawObject["distance"] = map[string]interface{}{"units": "kilometers", "value": 8.5}
+	rawObject["energy"] = map[string]interface{}{"units": "kilocalories", "value": 520.0}
+	rawObject["reportedIntensity"] = "medium"
+	return rawObject
+}
+
+func NewMeasurement(units interface{}, value interface{}) map[string]interface{} {
+	measurement := map[string]interface{}{}
+	if units != nil {
+		measurement["units"] = units
+	}
+	if value != nil {
+		measurement["value"] = value
+	}
+	return measurement
+}
+
+func NewMeta() interface{} {
+	return &types.Meta{
+		Type: "physicalActivity",
+	}
+}
+
+var _ = Describe("Physical", func() {
+	Context("Type", func() {
+		It("returns the expected type", func() {
+			Expect(physical.Type()).To(Equal("physicalActivity"))
+		})
+	})
+
+	Context("NewDatum", func() {
+		It("returns the expected datum", func() {
+			Expect(physical.NewDatum()).To(Equal(&physical.Physical{}))
+		})
+	})
+
+	Context("New", func() {
+		It("returns the expected physical", func() {
+			Expect(physical.New()).To(Equal(&physical.Physical{}))
+		})
+	})
+
+	Context("Init", func() {
+		It("returns the expected physical", func() {
+			testPhysical := physical.Init()
+			Expect(testPhysical).ToNot(BeNil())
+			Expect(testPhysical.ID).ToNot(BeEmpty())
+			Expect(testPhysical.Type).To(Equal("physicalActivity"))
+		})
+	})
+
+	Context("activityType", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is empty", NewRawObject(), "activityType", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("", physical.ActivityTypes()), "/activityType", NewMeta())},
+			),
+			Entry("is not one of the predefined values", NewRawObject(), "activityType", "skydiving",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("skydiving", physical.ActivityTypes()), "/activityType", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "activityType", nil),
+			Entry("is cycling", NewRawObject(), "activityType", "cycling"),
+			Entry("is strengthTraining", NewRawObject(), "activityType", "strengthTraining"),
+			Entry("is other", NewRawObject(), "activityType", "other"),
+		)
+	})
+
+	Context("reportedIntensity", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is not one of the predefined values", NewRawObject(), "reportedIntensity", "extreme",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("extreme", []string{"low", "medium", "high"}), "/reportedIntensity", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "reportedIntensity", nil),
+			Entry("is low", NewRawObject(), "reportedIntensity", "low"),
+			Entry("is medium", NewRawObject(), "reportedIntensity", "medium"),
+			Entry("is high", NewRawObject(), "reportedIntensity", "high"),
+		)
+	})
+
+	Context("duration", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("has missing units", NewRawObject(), "duration", NewMeasurement(nil, 45.0),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/duration/units", NewMeta())},
+			),
+			Entry("has unknown units", NewRawObject(), "duration", NewMeasurement("days", 1.0),
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("days", []string{"hours", "minutes", "seconds"}), "/duration/units", NewMeta())},
+			),
+			Entry("has missing value", NewRawObject(), "duration", NewMeasurement("minutes", nil),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/duration/value", NewMeta())},
+			),
+			Entry("has negative value", NewRawObject(), "duration", NewMeasurement("minutes", -0.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(-0.1, 0.0, 1440.0), "/duration/value", NewMeta())},
+			),
+			Entry("has hours greater than 24", NewRawObject(), "duration", NewMeasurement("hours", 24.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(24.1, 0.0, 24.0), "/duration/value", NewMeta())},
+			),
+			Entry("has minutes greater than 1440", NewRawObject(), "duration", NewMeasurement("minutes", 1440.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(1440.1, 0.0, 1440.0), "/duration/value", NewMeta())},
+			),
+			Entry("has seconds greater than 86400", NewRawObject(), "duration", NewMeasurement("seconds", 86400.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(86400.1, 0.0, 86400.0), "/duration/value", NewMeta())},
+			),
+			Entry("has unknown field", NewRawObject(), "duration", map[string]interface{}{"units": "minutes", "value": 45.0, "unknown": true},
+				[]*service.Error{testData.ComposeError(parser.ErrorNotParsed(), "/duration/unknown", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "duration", nil),
+			Entry("has hours", NewRawObject(), "duration", NewMeasurement("hours", 24.0)),
+			Entry("has minutes", NewRawObject(), "duration", NewMeasurement("minutes", 1440.0)),
+			Entry("has seconds", NewRawObject(), "duration", NewMeasurement("seconds", 86400.0)),
+		)
+	})
+
+	Context("distance", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("has missing units", NewRawObject(), "distance", NewMeasurement(nil, 8.5),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/distance/units", NewMeta())},
+			),
+			Entry("has unknown units", NewRawObject(), "distance", NewMeasurement("furlongs", 1.0),
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("furlongs", []string{"feet", "kilometers", "meters", "miles", "yards"}), "/distance/units", NewMeta())},
+			),
+			Entry("has missing value", NewRawObject(), "distance", NewMeasurement("kilometers", nil),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/distance/value", NewMeta())},
+			),
+			Entry("has negative value", NewRawObject(), "distance", NewMeasurement("kilometers", -0.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(-0.1, 0.0, 1000.0), "/distance/value", NewMeta())},
+			),
+			Entry("has feet greater than 3280839.895", NewRawObject(), "distance", NewMeasurement("feet", 3280840.0),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(3280840.0, 0.0, 3280839.895), "/distance/value", NewMeta())},
+			),
+			Entry("has kilometers greater than 1000", NewRawObject(), "distance", NewMeasurement("kilometers", 1000.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(1000.1, 0.0, 1000.0), "/distance/value", NewMeta())},
+			),
+			Entry("has meters greater than 1000000", NewRawObject(), "distance", NewMeasurement("meters", 1000000.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(1000000.1, 0.0, 1000000.0), "/distance/value", NewMeta())},
+			),
+			Entry("has miles greater than 621.371", NewRawObject(), "distance", NewMeasurement("miles", 621.4),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(621.4, 0.0, 621.371), "/distance/value", NewMeta())},
+			),
+			Entry("has yards greater than 1093613.298", NewRawObject(), "distance", NewMeasurement("yards", 1093613.3),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(1093613.3, 0.0, 1093613.298), "/distance/value", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "distance", nil),
+			Entry("has feet", NewRawObject(), "distance", NewMeasurement("feet", 5280.0)),
+			Entry("has kilometers", NewRawObject(), "distance", NewMeasurement("kilometers", 1000.0)),
+			Entry("has meters", NewRawObject(), "distance", NewMeasurement("meters", 400.0)),
+			Entry("has miles", NewRawObject(), "distance", NewMeasurement("miles", 26.2)),
+			Entry("has yards", NewRawObject(), "distance", NewMeasurement("yards", 100.0)),
+		)
+	})
+
+	Context("energy", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("has missing units", NewRawObject(), "energy", NewMeasurement(nil, 520.0),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/energy/units", NewMeta())},
+			),
+			Entry("has unknown units", NewRawObject(), "energy", NewMeasurement("calories", 520.0),
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("calories", []string{"kilocalories", "kilojoules"}), "/energy/units", NewMeta())},
+			),
+			Entry("has missing value", NewRawObject(), "energy", NewMeasurement("kilocalories", nil),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/energy/value", NewMeta())},
+			),
+			Entry("has kilocalories greater than 10000", NewRawObject(), "energy", NewMeasurement("kilocalories", 10000.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(10000.1, 0.0, 10000.0), "/energy/value", NewMeta())},
+			),
+			Entry("has kilojoules greater than 41840", NewRawObject(), "energy", NewMeasurement("kilojoules", 41840.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(41840.1, 0.0, 41840.0), "/energy/value", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "energy", nil),
+			Entry("has kilocalories", NewRawObject(), "energy", NewMeasurement("kilocalories", 10000.0)),
+			Entry("has kilojoules", NewRawObject(), "energy", NewMeasurement("kilojoules", 41840.0)),
+		)
+	})
+
+	Context("with parsed physical", func() {
+		var testPhysical *physical.Physical
+
+		BeforeEach(func() {
+			datum := testData.ParseAndNormalize(NewRawObject(), "type", "physicalActivity")
+			Expect(datum).To(BeAssignableToTypeOf(&physical.Physical{}))
+			testPhysical = datum.(*physical.Physical)
+		})
+
+		It("parses the physical activity", func() {
+			Expect(testPhysical.ActivityType).To(Equal(app.StringAsPointer("running")))
+			Expect(testPhysical.Duration).To(Equal(&physical.Duration{Units: app.StringAsPointer("minutes"), Value: app.FloatAsPointer(45.0)}))
+			Expect(testPhysical.Distance).To(Equal(&physical.Distance{Units: app.StringAsPointer("kilometers"), Value: app.FloatAsPointer(8.5)}))
+			Expect(testPhysical.Energy).To(Equal(&physical.Energy{Units: app.StringAsPointer("kilocalories"), Value: app.FloatAsPointer(520.0)}))
+			Expect(testPhysical.ReportedIntensity).To(Equal(app.StringAsPointer("medium")))
+		})
+
+		Context("IdentityFields", func() {
+			var userID string
+
+			BeforeEach(func() {
+				userID = app.NewID()
+				testPhysical.UserID = userID
+			})
+
+			It("returns error if user id is empty", func() {
+				testPhysical.UserID = ""
+				identityFields, err := testPhysical.IdentityFields()
+				Expect(err).To(MatchError("base: user id is empty"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns the expected identity fields", func() {
+				identityFields, err := testPhysical.IdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, "InsOmn-111111111", "2014-06-11T06:00:00.000Z", "physicalActivity", "activityType", "running", "duration", "45", "minutes", "distance", "8.5", "kilometers", "energy", "520", "kilocalories"}))
+			})
+
+			It("returns the expected identity fields with only activity type", func() {
+				testPhysical.Duration = nil
+				testPhysical.Distance = nil
+				testPhysical.Energy = nil
+				identityFields, err := testPhysical.IdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, "InsOmn-111111111", "2014-06-11T06:00:00.000Z", "physicalActivity", "activityType", "running"}))
+			})
+
+			It("returns identity fields that generate an identity hash", func() {
+				testPhysical.ActivityType = nil
+				testPhysical.Distance = nil
+				identityFields, err := testPhysical.IdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deduplicator.GenerateIdentityHash(identityFields)).ToNot(BeEmpty())
+			})
+		})
+
+		Context("FuzzyIdentityFields", func() {
+			var userID string
+
+			BeforeEach(func() {
+				userID = app.NewID()
+				testPhysical.UserID = userID
+			})
+
+			It("returns error if device time is missing", func() {
+				testPhysical.DeviceTime = nil
+				identityFields, err := testPhysical.FuzzyIdentityFields()
+				Expect(err).To(MatchError("base: device time is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns the expected identity fields", func() {
+				identityFields, err := testPhysical.FuzzyIdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, "InsOmn-111111111", "physicalActivity", "activityType", "running", "duration", "45", "minutes", "distance", "8.5", "kilometers", "energy", "520", "kilocalories"}))
+			})
+		})
+	})
+})
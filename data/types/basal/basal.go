@@ -62,7 +62,14 @@ func (b *Basal) Normalize(normalizer data.Normalizer) error {
 }
 
 func (b *Basal) IdentityFields() ([]string, error) {
-	identityFields, err := b.Base.IdentityFields()
+	return b.identityFields(b.Base.IdentityFields())
+}
+
+func (b *Basal) FuzzyIdentityFields() ([]string, error) {
+	return b.identityFields(b.Base.FuzzyIdentityFields())
+}
+
+func (b *Basal) identityFields(identityFields []string, err error) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
@@ -208,6 +208,40 @@ var _ = Describe("Basal", func() {
 					Expect(identityFields).To(Equal([]string{userID, deviceID, "2016-09-06T13:45:58-07:00", "basal", "scheduled"}))
 				})
 			})
+
+			Context("FuzzyIdentityFields", func() {
+				var userID string
+				var deviceID string
+
+				BeforeEach(func() {
+					userID = app.NewID()
+					deviceID = app.NewID()
+					testBasal.UserID = userID
+					testBasal.DeviceID = &deviceID
+					testBasal.DeviceTime = app.StringAsPointer("2016-09-06T13:45:58")
+					testBasal.DeliveryType = "scheduled"
+				})
+
+				It("returns error if device time is missing", func() {
+					testBasal.DeviceTime = nil
+					identityFields, err := testBasal.FuzzyIdentityFields()
+					Expect(err).To(MatchError("base: device time is missing"))
+					Expect(identityFields).To(BeEmpty())
+				})
+
+				It("returns error if delivery type is empty", func() {
+					testBasal.DeliveryType = ""
+					identityFields, err := testBasal.FuzzyIdentityFields()
+					Expect(err).To(MatchError("basal: delivery type is empty"))
+					Expect(identityFields).To(BeEmpty())
+				})
+
+				It("returns the expected identity fields", func() {
+					identityFields, err := testBasal.FuzzyIdentityFields()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(identityFields).To(Equal([]string{userID, deviceID, "basal", "scheduled"}))
+				})
+			})
 		})
 	})
 })
@@ -2,6 +2,7 @@ package suspend
 
 import (
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/basal"
 )
 
@@ -77,3 +78,12 @@ func (s *Suspend) Validate(validator data.Validator) error {
 
 	return nil
 }
+
+func (s *Suspend) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := s.Basal.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, s.Duration, s.ExpectedDuration)
+}
@@ -2,6 +2,7 @@ package temporary
 
 import (
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/basal"
 )
 
@@ -86,3 +87,12 @@ func (t *Temporary) Validate(validator data.Validator) error {
 
 	return nil
 }
+
+func (t *Temporary) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := t.Basal.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, t.Rate, t.Percent, t.Duration, t.ExpectedDuration)
+}
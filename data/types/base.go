@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+
 	"github.com/tidepool-org/platform/app"
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/errors"
@@ -139,6 +141,48 @@ func (b *Base) IdentityFields() ([]string, error) {
 	return []string{b.UserID, *b.DeviceID, *b.Time, b.Type}, nil
 }
 
+func (b *Base) FuzzyIdentityFields() ([]string, error) {
+	if b.UserID == "" {
+		return nil, errors.New("base", "user id is empty")
+	}
+	if b.DeviceID == nil {
+		return nil, errors.New("base", "device id is missing")
+	}
+	if *b.DeviceID == "" {
+		return nil, errors.New("base", "device id is empty")
+	}
+	if b.DeviceTime == nil {
+		return nil, errors.New("base", "device time is missing")
+	}
+	if *b.DeviceTime == "" {
+		return nil, errors.New("base", "device time is empty")
+	}
+	if b.Type == "" {
+		return nil, errors.New("base", "type is empty")
+	}
+
+	return []string{b.UserID, *b.DeviceID, b.Type}, nil
+}
+
+// Appends the values of a datum, such as the amounts of a bolus, to its fuzzy identity fields, so that only data
+// with equal values are considered the same event when matched on device time within a tolerance. A missing value
+// is appended as null.
+func AppendFuzzyIdentityValues(identityFields []string, values ...interface{}) ([]string, error) {
+	for _, value := range values {
+		bytes, err := json.Marshal(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "base", "unable to marshal fuzzy identity value")
+		}
+		identityFields = append(identityFields, string(bytes))
+	}
+
+	return identityFields, nil
+}
+
+func (b *Base) DatumID() string {
+	return b.ID
+}
+
 func (b *Base) SetUserID(userID string) {
 	b.UserID = userID
 }
@@ -82,6 +82,98 @@ var _ = Describe("Base", func() {
 			})
 		})
 
+		Context("FuzzyIdentityFields", func() {
+			var userID string
+			var deviceID string
+
+			BeforeEach(func() {
+				userID = app.NewID()
+				deviceID = app.NewID()
+				testBase.UserID = userID
+				testBase.DeviceID = &deviceID
+				testBase.DeviceTime = app.StringAsPointer("2016-09-06T13:45:58")
+				testBase.Time = app.StringAsPointer("2016-09-06T13:45:58-07:00")
+				testBase.Type = "testBase"
+			})
+
+			It("returns error if user id is empty", func() {
+				testBase.UserID = ""
+				identityFields, err := testBase.FuzzyIdentityFields()
+				Expect(err).To(MatchError("base: user id is empty"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if device id is missing", func() {
+				testBase.DeviceID = nil
+				identityFields, err := testBase.FuzzyIdentityFields()
+				Expect(err).To(MatchError("base: device id is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if device id is empty", func() {
+				testBase.DeviceID = app.StringAsPointer("")
+				identityFields, err := testBase.FuzzyIdentityFields()
+				Expect(err).To(MatchError("base: device id is empty"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if device time is missing", func() {
+				testBase.DeviceTime = nil
+				identityFields, err := testBase.FuzzyIdentityFields()
+				Expect(err).To(MatchError("base: device time is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if device time is empty", func() {
+				testBase.DeviceTime = app.StringAsPointer("")
+				identityFields, err := testBase.FuzzyIdentityFields()
+				Expect(err).To(MatchError("base: device time is empty"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if type is empty", func() {
+				testBase.Type = ""
+				identityFields, err := testBase.FuzzyIdentityFields()
+				Expect(err).To(MatchError("base: type is empty"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns the expected identity fields without time", func() {
+				testBase.Time = nil
+				identityFields, err := testBase.FuzzyIdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, deviceID, "testBase"}))
+			})
+		})
+
+		Context("AppendFuzzyIdentityValues", func() {
+			It("appends the values", func() {
+				identityFields, err := types.AppendFuzzyIdentityValues([]string{"test"}, app.FloatAsPointer(1.5), app.IntegerAsPointer(3600000), app.StringAsPointer("value"), map[string]interface{}{"b": 2, "a": 1})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{"test", "1.5", "3600000", `"value"`, `{"a":1,"b":2}`}))
+			})
+
+			It("appends missing values as null", func() {
+				var missing *float64
+				identityFields, err := types.AppendFuzzyIdentityValues([]string{"test"}, missing)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{"test", "null"}))
+			})
+
+			It("returns an error if a value cannot be marshaled", func() {
+				identityFields, err := types.AppendFuzzyIdentityValues([]string{"test"}, func() {})
+				Expect(err).To(MatchError("base: unable to marshal fuzzy identity value; json: unsupported type: func()"))
+				Expect(identityFields).To(BeNil())
+			})
+		})
+
+		Context("DatumID", func() {
+			It("gets the id", func() {
+				Expect(testBase.DatumID()).ToNot(BeEmpty())
+				Expect(testBase.DatumID()).To(Equal(testBase.ID))
+			})
+		})
+
 		Context("with deduplicator descriptor", func() {
 			var testDeduplicatorDescriptor *data.DeduplicatorDescriptor
 
@@ -55,7 +55,14 @@ func (b *Blood) Normalize(normalizer data.Normalizer) error {
 }
 
 func (b *Blood) IdentityFields() ([]string, error) {
-	identityFields, err := b.Base.IdentityFields()
+	return b.identityFields(b.Base.IdentityFields())
+}
+
+func (b *Blood) FuzzyIdentityFields() ([]string, error) {
+	return b.identityFields(b.Base.FuzzyIdentityFields())
+}
+
+func (b *Blood) identityFields(identityFields []string, err error) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
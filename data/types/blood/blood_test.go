@@ -223,6 +223,41 @@ var _ = Describe("Blood", func() {
 					Expect(identityFields).To(Equal([]string{userID, deviceID, "2016-09-06T13:45:58-07:00", "testBlood", "mmol/L", "1"}))
 				})
 			})
+
+			Context("FuzzyIdentityFields", func() {
+				var userID string
+				var deviceID string
+
+				BeforeEach(func() {
+					userID = app.NewID()
+					deviceID = app.NewID()
+					testBlood.UserID = userID
+					testBlood.DeviceID = &deviceID
+					testBlood.DeviceTime = app.StringAsPointer("2016-09-06T13:45:58")
+					testBlood.Units = app.StringAsPointer("mmol/L")
+					testBlood.Value = app.FloatAsPointer(1)
+				})
+
+				It("returns error if device time is missing", func() {
+					testBlood.DeviceTime = nil
+					identityFields, err := testBlood.FuzzyIdentityFields()
+					Expect(err).To(MatchError("base: device time is missing"))
+					Expect(identityFields).To(BeEmpty())
+				})
+
+				It("returns error if units is missing", func() {
+					testBlood.Units = nil
+					identityFields, err := testBlood.FuzzyIdentityFields()
+					Expect(err).To(MatchError("blood: units is missing"))
+					Expect(identityFields).To(BeEmpty())
+				})
+
+				It("returns the expected identity fields", func() {
+					identityFields, err := testBlood.FuzzyIdentityFields()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(identityFields).To(Equal([]string{userID, deviceID, "testBlood", "mmol/L", "1"}))
+				})
+			})
 		})
 	})
 })
@@ -62,7 +62,14 @@ func (b *Bolus) Normalize(normalizer data.Normalizer) error {
 }
 
 func (b *Bolus) IdentityFields() ([]string, error) {
-	identityFields, err := b.Base.IdentityFields()
+	return b.identityFields(b.Base.IdentityFields())
+}
+
+func (b *Bolus) FuzzyIdentityFields() ([]string, error) {
+	return b.identityFields(b.Base.FuzzyIdentityFields())
+}
+
+func (b *Bolus) identityFields(identityFields []string, err error) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
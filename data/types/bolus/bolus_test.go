@@ -208,6 +208,40 @@ var _ = Describe("Bolus", func() {
 					Expect(identityFields).To(Equal([]string{userID, deviceID, "2016-09-06T13:45:58-07:00", "bolus", "dual/square"}))
 				})
 			})
+
+			Context("FuzzyIdentityFields", func() {
+				var userID string
+				var deviceID string
+
+				BeforeEach(func() {
+					userID = app.NewID()
+					deviceID = app.NewID()
+					testBolus.UserID = userID
+					testBolus.DeviceID = &deviceID
+					testBolus.DeviceTime = app.StringAsPointer("2016-09-06T13:45:58")
+					testBolus.SubType = "dual/square"
+				})
+
+				It("returns error if device time is missing", func() {
+					testBolus.DeviceTime = nil
+					identityFields, err := testBolus.FuzzyIdentityFields()
+					Expect(err).To(MatchError("base: device time is missing"))
+					Expect(identityFields).To(BeEmpty())
+				})
+
+				It("returns error if sub type is empty", func() {
+					testBolus.SubType = ""
+					identityFields, err := testBolus.FuzzyIdentityFields()
+					Expect(err).To(MatchError("bolus: sub type is empty"))
+					Expect(identityFields).To(BeEmpty())
+				})
+
+				It("returns the expected identity fields", func() {
+					identityFields, err := testBolus.FuzzyIdentityFields()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(identityFields).To(Equal([]string{userID, deviceID, "bolus", "dual/square"}))
+				})
+			})
 		})
 	})
 })
@@ -2,6 +2,7 @@ package combination
 
 import (
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/bolus"
 )
 
@@ -115,3 +116,12 @@ func (c *Combination) Validate(validator data.Validator) error {
 
 	return nil
 }
+
+func (c *Combination) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := c.Bolus.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, c.Normal, c.ExpectedNormal, c.Extended, c.ExpectedExtended, c.Duration, c.ExpectedDuration)
+}
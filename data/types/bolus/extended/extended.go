@@ -2,6 +2,7 @@ package extended
 
 import (
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/bolus"
 )
 
@@ -90,3 +91,12 @@ func (e *Extended) Validate(validator data.Validator) error {
 
 	return nil
 }
+
+func (e *Extended) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := e.Bolus.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, e.Extended, e.ExpectedExtended, e.Duration, e.ExpectedDuration)
+}
@@ -2,6 +2,7 @@ package normal
 
 import (
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/bolus"
 )
 
@@ -70,3 +71,12 @@ func (n *Normal) Validate(validator data.Validator) error {
 
 	return nil
 }
+
+func (n *Normal) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := n.Bolus.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, n.Normal, n.ExpectedNormal)
+}
@@ -2,6 +2,7 @@ package alarm
 
 import (
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/device"
 	"github.com/tidepool-org/platform/data/types/device/status"
 	"github.com/tidepool-org/platform/service"
@@ -117,3 +118,12 @@ func (a *Alarm) Normalize(normalizer data.Normalizer) error {
 
 	return nil
 }
+
+func (a *Alarm) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := a.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, a.AlarmType)
+}
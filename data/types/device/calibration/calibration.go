@@ -3,6 +3,7 @@ package calibration
 import (
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/blood/glucose"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/device"
 )
 
@@ -73,3 +74,12 @@ func (c *Calibration) Normalize(normalizer data.Normalizer) error {
 
 	return nil
 }
+
+func (c *Calibration) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := c.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, c.Value, c.Units)
+}
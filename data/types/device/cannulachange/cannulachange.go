@@ -0,0 +1,70 @@
+package cannulachange
+
+import (
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/device"
+	"github.com/tidepool-org/platform/data/types/insulin"
+)
+
+type CannulaChange struct {
+	device.Device `bson:",inline"`
+
+	Site *string `json:"site,omitempty" bson:"site,omitempty"`
+}
+
+func SubType() string {
+	return "cannulaChange"
+}
+
+func NewDatum() data.Datum {
+	return New()
+}
+
+func New() *CannulaChange {
+	return &CannulaChange{}
+}
+
+func Init() *CannulaChange {
+	cannulaChange := New()
+	cannulaChange.Init()
+	return cannulaChange
+}
+
+func (c *CannulaChange) Init() {
+	c.Device.Init()
+	c.SubType = SubType()
+
+	c.Site = nil
+}
+
+func (c *CannulaChange) Parse(parser data.ObjectParser) error {
+	if err := c.Device.Parse(parser); err != nil {
+		return err
+	}
+
+	c.Site = parser.ParseString("site")
+
+	return nil
+}
+
+func (c *CannulaChange) Validate(validator data.Validator) error {
+	if err := c.Device.Validate(validator); err != nil {
+		return err
+	}
+
+	validator.ValidateString("subType", &c.SubType).EqualTo(SubType())
+
+	validator.ValidateString("site", c.Site).OneOf(insulin.Sites())
+
+	return nil
+}
+
+func (c *CannulaChange) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := c.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, c.Site)
+}
@@ -0,0 +1,48 @@
+package cannulachange_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/device"
+	"github.com/tidepool-org/platform/data/types/insulin"
+	"github.com/tidepool-org/platform/service"
+)
+
+func NewRawObject() map[string]interface{} {
+	rawObject := testData.RawBaseObject()
+	rawObject["type"] = "deviceEvent"
+	rawObject["subType"] = "cannulaChange"
+	rawObject["site"] = "abdomen"
+	return rawObject
+}
+
+func NewMeta() interface{} {
+	return &device.Meta{
+		Type:    "deviceEvent",
+		SubType: "cannulaChange",
+	}
+}
+
+var _ = Describe("CannulaChange", func() {
+	Context("site", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is empty", NewRawObject(), "site", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("", insulin.Sites()), "/site", NewMeta())},
+			),
+			Entry("is not one of the predefined values", NewRawObject(), "site", "bad",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("bad", insulin.Sites()), "/site", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "site", nil),
+			Entry("is abdomen", NewRawObject(), "site", "abdomen"),
+			Entry("is arm", NewRawObject(), "site", "arm"),
+			Entry("is buttock", NewRawObject(), "site", "buttock"),
+			Entry("is thigh", NewRawObject(), "site", "thigh"),
+			Entry("is other", NewRawObject(), "site", "other"),
+		)
+	})
+})
@@ -62,7 +62,14 @@ func (d *Device) Normalize(normalizer data.Normalizer) error {
 }
 
 func (d *Device) IdentityFields() ([]string, error) {
-	identityFields, err := d.Base.IdentityFields()
+	return d.identityFields(d.Base.IdentityFields())
+}
+
+func (d *Device) FuzzyIdentityFields() ([]string, error) {
+	return d.identityFields(d.Base.FuzzyIdentityFields())
+}
+
+func (d *Device) identityFields(identityFields []string, err error) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
@@ -208,6 +208,40 @@ var _ = Describe("Device", func() {
 					Expect(identityFields).To(Equal([]string{userID, deviceID, "2016-09-06T13:45:58-07:00", "deviceEvent", "alarm"}))
 				})
 			})
+
+			Context("FuzzyIdentityFields", func() {
+				var userID string
+				var deviceID string
+
+				BeforeEach(func() {
+					userID = app.NewID()
+					deviceID = app.NewID()
+					testDevice.UserID = userID
+					testDevice.DeviceID = &deviceID
+					testDevice.DeviceTime = app.StringAsPointer("2016-09-06T13:45:58")
+					testDevice.SubType = "alarm"
+				})
+
+				It("returns error if device time is missing", func() {
+					testDevice.DeviceTime = nil
+					identityFields, err := testDevice.FuzzyIdentityFields()
+					Expect(err).To(MatchError("base: device time is missing"))
+					Expect(identityFields).To(BeEmpty())
+				})
+
+				It("returns error if sub type is empty", func() {
+					testDevice.SubType = ""
+					identityFields, err := testDevice.FuzzyIdentityFields()
+					Expect(err).To(MatchError("device: sub type is empty"))
+					Expect(identityFields).To(BeEmpty())
+				})
+
+				It("returns the expected identity fields", func() {
+					identityFields, err := testDevice.FuzzyIdentityFields()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(identityFields).To(Equal([]string{userID, deviceID, "deviceEvent", "alarm"}))
+				})
+			})
 		})
 	})
 })
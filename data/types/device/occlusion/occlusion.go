@@ -0,0 +1,80 @@
+package occlusion
+
+import (
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/device"
+)
+
+const (
+	LocationCannula   = "cannula"
+	LocationReservoir = "reservoir"
+	LocationTubing    = "tubing"
+	LocationUnknown   = "unknown"
+)
+
+func Locations() []string {
+	return []string{LocationCannula, LocationReservoir, LocationTubing, LocationUnknown}
+}
+
+type Occlusion struct {
+	device.Device `bson:",inline"`
+
+	Location *string `json:"location,omitempty" bson:"location,omitempty"`
+}
+
+func SubType() string {
+	return "occlusion"
+}
+
+func NewDatum() data.Datum {
+	return New()
+}
+
+func New() *Occlusion {
+	return &Occlusion{}
+}
+
+func Init() *Occlusion {
+	occlusion := New()
+	occlusion.Init()
+	return occlusion
+}
+
+func (o *Occlusion) Init() {
+	o.Device.Init()
+	o.SubType = SubType()
+
+	o.Location = nil
+}
+
+func (o *Occlusion) Parse(parser data.ObjectParser) error {
+	if err := o.Device.Parse(parser); err != nil {
+		return err
+	}
+
+	o.Location = parser.ParseString("location")
+
+	return nil
+}
+
+func (o *Occlusion) Validate(validator data.Validator) error {
+	if err := o.Device.Validate(validator); err != nil {
+		return err
+	}
+
+	validator.ValidateString("subType", &o.SubType).EqualTo(SubType())
+
+	validator.ValidateString("location", o.Location).Exists().OneOf(Locations())
+
+	return nil
+}
+
+func (o *Occlusion) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := o.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, o.Location)
+}
@@ -0,0 +1,48 @@
+package occlusion_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/device"
+	"github.com/tidepool-org/platform/service"
+)
+
+func NewRawObject() map[string]interface{} {
+	rawObject := testData.RawBaseObject()
+	rawObject["type"] = "deviceEvent"
+	rawObject["subType"] = "occlusion"
+	rawObject["location"] = "tubing"
+	return rawObject
+}
+
+func NewMeta() interface{} {
+	return &device.Meta{
+		Type:    "deviceEvent",
+		SubType: "occlusion",
+	}
+}
+
+var _ = Describe("Occlusion", func() {
+	Context("location", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is missing", NewRawObject(), "location", nil,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/location", NewMeta())},
+			),
+			Entry("is empty", NewRawObject(), "location", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("", []string{"cannula", "reservoir", "tubing", "unknown"}), "/location", NewMeta())},
+			),
+			Entry("is not one of the predefined values", NewRawObject(), "location", "bad",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("bad", []string{"cannula", "reservoir", "tubing", "unknown"}), "/location", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is cannula", NewRawObject(), "location", "cannula"),
+			Entry("is reservoir", NewRawObject(), "location", "reservoir"),
+			Entry("is tubing", NewRawObject(), "location", "tubing"),
+			Entry("is unknown", NewRawObject(), "location", "unknown"),
+		)
+	})
+})
@@ -2,6 +2,7 @@ package prime
 
 import (
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/device"
 )
 
@@ -68,3 +69,12 @@ func (p *Prime) Validate(validator data.Validator) error {
 
 	return nil
 }
+
+func (p *Prime) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := p.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, p.Target, p.Volume)
+}
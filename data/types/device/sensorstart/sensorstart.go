@@ -0,0 +1,80 @@
+package sensorstart
+
+import (
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/device"
+)
+
+const (
+	SensorIDLengthMaximum = 100
+
+	WarmupDurationMinimum = 0
+	WarmupDurationMaximum = 86400000
+)
+
+type SensorStart struct {
+	device.Device `bson:",inline"`
+
+	SensorID       *string `json:"sensorId,omitempty" bson:"sensorId,omitempty"`
+	WarmupDuration *int    `json:"warmupDuration,omitempty" bson:"warmupDuration,omitempty"`
+}
+
+func SubType() string {
+	return "sensorStart"
+}
+
+func NewDatum() data.Datum {
+	return New()
+}
+
+func New() *SensorStart {
+	return &SensorStart{}
+}
+
+func Init() *SensorStart {
+	sensorStart := New()
+	sensorStart.Init()
+	return sensorStart
+}
+
+func (s *SensorStart) Init() {
+	s.Device.Init()
+	s.SubType = SubType()
+
+	s.SensorID = nil
+	s.WarmupDuration = nil
+}
+
+func (s *SensorStart) Parse(parser data.ObjectParser) error {
+	if err := s.Device.Parse(parser); err != nil {
+		return err
+	}
+
+	s.SensorID = parser.ParseString("sensorId")
+	s.WarmupDuration = parser.ParseInteger("warmupDuration")
+
+	return nil
+}
+
+func (s *SensorStart) Validate(validator data.Validator) error {
+	if err := s.Device.Validate(validator); err != nil {
+		return err
+	}
+
+	validator.ValidateString("subType", &s.SubType).EqualTo(SubType())
+
+	validator.ValidateString("sensorId", s.SensorID).NotEmpty().LengthLessThanOrEqualTo(SensorIDLengthMaximum)
+	validator.ValidateInteger("warmupDuration", s.WarmupDuration).InRange(WarmupDurationMinimum, WarmupDurationMaximum)
+
+	return nil
+}
+
+func (s *SensorStart) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := s.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, s.SensorID, s.WarmupDuration)
+}
@@ -0,0 +1,63 @@
+package sensorstart_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+
+	"strings"
+
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/device"
+	"github.com/tidepool-org/platform/service"
+)
+
+func NewRawObject() map[string]interface{} {
+	rawObject := testData.RawBaseObject()
+	rawObject["type"] = "deviceEvent"
+	rawObject["subType"] = "sensorStart"
+	rawObject["sensorId"] = "SM12345678"
+	rawObject["warmupDuration"] = 7200000
+	return rawObject
+}
+
+func NewMeta() interface{} {
+	return &device.Meta{
+		Type:    "deviceEvent",
+		SubType: "sensorStart",
+	}
+}
+
+var _ = Describe("SensorStart", func() {
+	Context("sensorId", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is empty", NewRawObject(), "sensorId", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueEmpty(), "/sensorId", NewMeta())},
+			),
+			Entry("is longer than 100", NewRawObject(), "sensorId", strings.Repeat("a", 101),
+				[]*service.Error{testData.ComposeError(service.ErrorLengthNotLessThanOrEqualTo(101, 100), "/sensorId", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "sensorId", nil),
+			Entry("is of length 100", NewRawObject(), "sensorId", strings.Repeat("a", 100)),
+		)
+	})
+
+	Context("warmupDuration", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is less than 0", NewRawObject(), "warmupDuration", -1,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(-1, 0, 86400000), "/warmupDuration", NewMeta())},
+			),
+			Entry("is more than 86400000", NewRawObject(), "warmupDuration", 86400001,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(86400001, 0, 86400000), "/warmupDuration", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "warmupDuration", nil),
+			Entry("is 0", NewRawObject(), "warmupDuration", 0),
+			Entry("is 86400000", NewRawObject(), "warmupDuration", 86400000),
+		)
+	})
+})
@@ -0,0 +1,86 @@
+package sensorstop
+
+import (
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/device"
+)
+
+const (
+	SensorIDLengthMaximum = 100
+
+	ReasonExpired     = "expired"
+	ReasonFailed      = "failed"
+	ReasonUserStopped = "userStopped"
+	ReasonOther       = "other"
+)
+
+func Reasons() []string {
+	return []string{ReasonExpired, ReasonFailed, ReasonUserStopped, ReasonOther}
+}
+
+type SensorStop struct {
+	device.Device `bson:",inline"`
+
+	SensorID *string `json:"sensorId,omitempty" bson:"sensorId,omitempty"`
+	Reason   *string `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+func SubType() string {
+	return "sensorStop"
+}
+
+func NewDatum() data.Datum {
+	return New()
+}
+
+func New() *SensorStop {
+	return &SensorStop{}
+}
+
+func Init() *SensorStop {
+	sensorStop := New()
+	sensorStop.Init()
+	return sensorStop
+}
+
+func (s *SensorStop) Init() {
+	s.Device.Init()
+	s.SubType = SubType()
+
+	s.SensorID = nil
+	s.Reason = nil
+}
+
+func (s *SensorStop) Parse(parser data.ObjectParser) error {
+	if err := s.Device.Parse(parser); err != nil {
+		return err
+	}
+
+	s.SensorID = parser.ParseString("sensorId")
+	s.Reason = parser.ParseString("reason")
+
+	return nil
+}
+
+func (s *SensorStop) Validate(validator data.Validator) error {
+	if err := s.Device.Validate(validator); err != nil {
+		return err
+	}
+
+	validator.ValidateString("subType", &s.SubType).EqualTo(SubType())
+
+	validator.ValidateString("sensorId", s.SensorID).NotEmpty().LengthLessThanOrEqualTo(SensorIDLengthMaximum)
+	validator.ValidateString("reason", s.Reason).OneOf(Reasons())
+
+	return nil
+}
+
+func (s *SensorStop) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := s.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, s.SensorID, s.Reason)
+}
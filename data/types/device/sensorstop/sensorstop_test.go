@@ -0,0 +1,65 @@
+package sensorstop_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+
+	"strings"
+
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/device"
+	"github.com/tidepool-org/platform/service"
+)
+
+func NewRawObject() map[string]interface{} {
+	rawObject := testData.RawBaseObject()
+	rawObject["type"] = "deviceEvent"
+	rawObject["subType"] = "sensorStop"
+	rawObject["sensorId"] = "SM12345678"
+	rawObject["reason"] = "expired"
+	return rawObject
+}
+
+func NewMeta() interface{} {
+	return &device.Meta{
+		Type:    "deviceEvent",
+		SubType: "sensorStop",
+	}
+}
+
+var _ = Describe("SensorStop", func() {
+	Context("sensorId", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is empty", NewRawObject(), "sensorId", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueEmpty(), "/sensorId", NewMeta())},
+			),
+			Entry("is longer than 100", NewRawObject(), "sensorId", strings.Repeat("a", 101),
+				[]*service.Error{testData.ComposeError(service.ErrorLengthNotLessThanOrEqualTo(101, 100), "/sensorId", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "sensorId", nil),
+			Entry("is of length 100", NewRawObject(), "sensorId", strings.Repeat("a", 100)),
+		)
+	})
+
+	Context("reason", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is empty", NewRawObject(), "reason", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("", []string{"expired", "failed", "userStopped", "other"}), "/reason", NewMeta())},
+			),
+			Entry("is not one of the predefined values", NewRawObject(), "reason", "bad",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("bad", []string{"expired", "failed", "userStopped", "other"}), "/reason", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "reason", nil),
+			Entry("is expired", NewRawObject(), "reason", "expired"),
+			Entry("is failed", NewRawObject(), "reason", "failed"),
+			Entry("is userStopped", NewRawObject(), "reason", "userStopped"),
+			Entry("is other", NewRawObject(), "reason", "other"),
+		)
+	})
+})
@@ -2,6 +2,7 @@ package status
 
 import (
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/device"
 )
 
@@ -65,3 +66,12 @@ func (s *Status) Validate(validator data.Validator) error {
 
 	return nil
 }
+
+func (s *Status) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := s.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, s.Name, s.Duration, s.Reason)
+}
@@ -2,6 +2,7 @@ package timechange
 
 import (
 	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
 	"github.com/tidepool-org/platform/data/types/device"
 )
 
@@ -59,3 +60,12 @@ func (t *TimeChange) Validate(validator data.Validator) error {
 
 	return nil
 }
+
+func (t *TimeChange) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := t.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, t.Change)
+}
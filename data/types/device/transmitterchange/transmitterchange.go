@@ -0,0 +1,77 @@
+package transmitterchange
+
+import (
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/device"
+)
+
+const (
+	TransmitterIDLengthMaximum = 100
+)
+
+type TransmitterChange struct {
+	device.Device `bson:",inline"`
+
+	TransmitterID         *string `json:"transmitterId,omitempty" bson:"transmitterId,omitempty"`
+	PreviousTransmitterID *string `json:"previousTransmitterId,omitempty" bson:"previousTransmitterId,omitempty"`
+}
+
+func SubType() string {
+	return "transmitterChange"
+}
+
+func NewDatum() data.Datum {
+	return New()
+}
+
+func New() *TransmitterChange {
+	return &TransmitterChange{}
+}
+
+func Init() *TransmitterChange {
+	transmitterChange := New()
+	transmitterChange.Init()
+	return transmitterChange
+}
+
+func (t *TransmitterChange) Init() {
+	t.Device.Init()
+	t.SubType = SubType()
+
+	t.TransmitterID = nil
+	t.PreviousTransmitterID = nil
+}
+
+func (t *TransmitterChange) Parse(parser data.ObjectParser) error {
+	if err := t.Device.Parse(parser); err != nil {
+		return err
+	}
+
+	t.TransmitterID = parser.ParseString("transmitterId")
+	t.PreviousTransmitterID = parser.ParseString("previousTransmitterId")
+
+	return nil
+}
+
+func (t *TransmitterChange) Validate(validator data.Validator) error {
+	if err := t.Device.Validate(validator); err != nil {
+		return err
+	}
+
+	validator.ValidateString("subType", &t.SubType).EqualTo(SubType())
+
+	validator.ValidateString("transmitterId", t.TransmitterID).Exists().NotEmpty().LengthLessThanOrEqualTo(TransmitterIDLengthMaximum)
+	validator.ValidateString("previousTransmitterId", t.PreviousTransmitterID).NotEmpty().LengthLessThanOrEqualTo(TransmitterIDLengthMaximum)
+
+	return nil
+}
+
+func (t *TransmitterChange) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := t.Device.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, t.TransmitterID, t.PreviousTransmitterID)
+}
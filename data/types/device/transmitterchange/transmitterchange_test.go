@@ -0,0 +1,64 @@
+package transmitterchange_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+
+	"strings"
+
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/device"
+	"github.com/tidepool-org/platform/service"
+)
+
+func NewRawObject() map[string]interface{} {
+	rawObject := testData.RawBaseObject()
+	rawObject["type"] = "deviceEvent"
+	rawObject["subType"] = "transmitterChange"
+	rawObject["transmitterId"] = "80AB12"
+	rawObject["previousTransmitterId"] = "80CD34"
+	return rawObject
+}
+
+func NewMeta() interface{} {
+	return &device.Meta{
+		Type:    "deviceEvent",
+		SubType: "transmitterChange",
+	}
+}
+
+var _ = Describe("TransmitterChange", func() {
+	Context("transmitterId", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is missing", NewRawObject(), "transmitterId", nil,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/transmitterId", NewMeta())},
+			),
+			Entry("is empty", NewRawObject(), "transmitterId", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueEmpty(), "/transmitterId", NewMeta())},
+			),
+			Entry("is longer than 100", NewRawObject(), "transmitterId", strings.Repeat("a", 101),
+				[]*service.Error{testData.ComposeError(service.ErrorLengthNotLessThanOrEqualTo(101, 100), "/transmitterId", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is of length 100", NewRawObject(), "transmitterId", strings.Repeat("a", 100)),
+		)
+	})
+
+	Context("previousTransmitterId", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is empty", NewRawObject(), "previousTransmitterId", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueEmpty(), "/previousTransmitterId", NewMeta())},
+			),
+			Entry("is longer than 100", NewRawObject(), "previousTransmitterId", strings.Repeat("a", 101),
+				[]*service.Error{testData.ComposeError(service.ErrorLengthNotLessThanOrEqualTo(101, 100), "/previousTransmitterId", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "previousTransmitterId", nil),
+			Entry("is of length 100", NewRawObject(), "previousTransmitterId", strings.Repeat("a", 100)),
+		)
+	})
+})
@@ -0,0 +1,137 @@
+package food
+
+import (
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/errors"
+	"github.com/tidepool-org/platform/service"
+)
+
+const (
+	MealBreakfast = "breakfast"
+	MealLunch     = "lunch"
+	MealDinner    = "dinner"
+	MealSnack     = "snack"
+	MealOther     = "other"
+
+	AbsorptionDurationMinimum = 0
+	AbsorptionDurationMaximum = 86400000
+)
+
+func Meals() []string {
+	return []string{MealBreakfast, MealLunch, MealDinner, MealSnack, MealOther}
+}
+
+type Food struct {
+	types.Base `bson:",inline"`
+
+	Nutrition          *Nutrition `json:"nutrition,omitempty" bson:"nutrition,omitempty"`
+	Meal               *string    `json:"meal,omitempty" bson:"meal,omitempty"`
+	AbsorptionDuration *int       `json:"absorptionDuration,omitempty" bson:"absorptionDuration,omitempty"`
+}
+
+func Type() string {
+	return "food"
+}
+
+func NewDatum() data.Datum {
+	return New()
+}
+
+func New() *Food {
+	return &Food{}
+}
+
+func Init() *Food {
+	food := New()
+	food.Init()
+	return food
+}
+
+func (f *Food) Init() {
+	f.Base.Init()
+	f.Type = Type()
+
+	f.Nutrition = nil
+	f.Meal = nil
+	f.AbsorptionDuration = nil
+}
+
+func (f *Food) Parse(parser data.ObjectParser) error {
+	parser.SetMeta(f.Meta())
+
+	if err := f.Base.Parse(parser); err != nil {
+		return err
+	}
+
+	f.Meal = parser.ParseString("meal")
+	f.AbsorptionDuration = parser.ParseInteger("absorptionDuration")
+
+	f.Nutrition = ParseNutrition(parser.NewChildObjectParser("nutrition"))
+
+	return nil
+}
+
+func (f *Food) Validate(validator data.Validator) error {
+	validator.SetMeta(f.Meta())
+
+	if err := f.Base.Validate(validator); err != nil {
+		return err
+	}
+
+	validator.ValidateString("type", &f.Type).EqualTo(Type())
+
+	validator.ValidateString("meal", f.Meal).OneOf(Meals())
+	validator.ValidateInteger("absorptionDuration", f.AbsorptionDuration).InRange(AbsorptionDurationMinimum, AbsorptionDurationMaximum)
+
+	if f.Nutrition != nil {
+		f.Nutrition.Validate(validator.NewChildValidator("nutrition"))
+	} else {
+		validator.AppendError("nutrition", service.ErrorValueNotExists())
+	}
+
+	return nil
+}
+
+func (f *Food) Normalize(normalizer data.Normalizer) error {
+	normalizer.SetMeta(f.Meta())
+
+	if err := f.Base.Normalize(normalizer); err != nil {
+		return err
+	}
+
+	if f.Nutrition != nil {
+		f.Nutrition.Normalize(normalizer.NewChildNormalizer("nutrition"))
+	}
+
+	return nil
+}
+
+func (f *Food) IdentityFields() ([]string, error) {
+	return f.identityFields(f.Base.IdentityFields())
+}
+
+func (f *Food) FuzzyIdentityFields() ([]string, error) {
+	return f.identityFields(f.Base.FuzzyIdentityFields())
+}
+
+func (f *Food) identityFields(identityFields []string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Nutrition == nil {
+		return nil, errors.New("food", "nutrition is missing")
+	}
+
+	if f.Meal != nil {
+		identityFields = append(identityFields, "meal", *f.Meal)
+	}
+
+	identityFields = f.Nutrition.Carbohydrate.appendIdentityFields(identityFields, "carbohydrate")
+	identityFields = f.Nutrition.Fat.appendIdentityFields(identityFields, "fat")
+	identityFields = f.Nutrition.Protein.appendIdentityFields(identityFields, "protein")
+	identityFields = f.Nutrition.Energy.appendIdentityFields(identityFields, "energy")
+
+	return identityFields, nil
+}
@@ -0,0 +1,249 @@
+package food_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/parser"
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/food"
+	"github.com/tidepool-org/platform/service"
+)
+
+func NewRawObject() map[string]interface{} {
+	rawObject := testData.RawBaseObject()
+	rawObject["type"] = "food"
+	rawObject["meal"] = "lunch"
+	rawObject["absorptionDuration"] = 10800000
+	rawObject["nutrition"] = map[string]interface{}{
+		"carbohydrate": map[string]interface{}{"units": "grams", "value": 45.0},
+		"fat":          map[string]interface{}{"units": "grams", "value": 12.5},
+		"protein":      map[string]interface{}{"units": "grams", "value": 20.0},
+		"energy":       map[string]interface{}{"units": "kilocalories", "value": 372.5},
+	}
+	return rawObject
+}
+
+func NewNutritionWithEnergy(units interface{}, value interface{}) map[string]interface{} {
+	energy := map[string]interface{}{}
+	if units != nil {
+		energy["units"] = units
+	}
+	if value != nil {
+		energy["value"] = value
+	}
+	return map[string]interface{}{"energy": energy}
+}
+
+func NewMeta() interface{} {
+	return &types.Meta{
+		Type: "food",
+	}
+}
+
+var _ = Describe("Food", func() {
+	Context("Type", func() {
+		It("returns the expected type", func() {
+			Expect(food.Type()).To(Equal("food"))
+		})
+	})
+
+	Context("NewDatum", func() {
+		It("returns the expected datum", func() {
+			Expect(food.NewDatum()).To(Equal(&food.Food{}))
+		})
+	})
+
+	Context("New", func() {
+		It("returns the expected food", func() {
+			Expect(food.New()).To(Equal(&food.Food{}))
+		})
+	})
+
+	Context("Init", func() {
+		It("returns the expected food", func() {
+			testFood := food.Init()
+			Expect(testFood).ToNot(BeNil())
+			Expect(testFood.ID).ToNot(BeEmpty())
+			Expect(testFood.Type).To(Equal("food"))
+		})
+	})
+
+	Context("meal", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is empty", NewRawObject(), "meal", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("", food.Meals()), "/meal", NewMeta())},
+			),
+			Entry("is not one of the predefined values", NewRawObject(), "meal", "brunch",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("brunch", food.Meals()), "/meal", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "meal", nil),
+			Entry("is breakfast", NewRawObject(), "meal", "breakfast"),
+			Entry("is lunch", NewRawObject(), "meal", "lunch"),
+			Entry("is dinner", NewRawObject(), "meal", "dinner"),
+			Entry("is snack", NewRawObject(), "meal", "snack"),
+			Entry("is other", NewRawObject(), "meal", "other"),
+		)
+	})
+
+	Context("absorptionDuration", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is negative", NewRawObject(), "absorptionDuration", -1,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(-1, 0, 86400000), "/absorptionDuration", NewMeta())},
+			),
+			Entry("is greater than 86400000", NewRawObject(), "absorptionDuration", 86400001,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(86400001, 0, 86400000), "/absorptionDuration", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "absorptionDuration", nil),
+			Entry("is zero", NewRawObject(), "absorptionDuration", 0),
+			Entry("is 86400000", NewRawObject(), "absorptionDuration", 86400000),
+		)
+	})
+
+	Context("nutrition", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is missing", NewRawObject(), "nutrition", nil,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/nutrition", NewMeta())},
+			),
+			Entry("has carbohydrate with missing units", NewRawObject(), "nutrition", map[string]interface{}{"carbohydrate": map[string]interface{}{"value": 45.0}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/nutrition/carbohydrate/units", NewMeta())},
+			),
+			Entry("has carbohydrate with unknown units", NewRawObject(), "nutrition", map[string]interface{}{"carbohydrate": map[string]interface{}{"units": "ounces", "value": 45.0}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("ounces", []string{"grams"}), "/nutrition/carbohydrate/units", NewMeta())},
+			),
+			Entry("has carbohydrate with missing value", NewRawObject(), "nutrition", map[string]interface{}{"carbohydrate": map[string]interface{}{"units": "grams"}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/nutrition/carbohydrate/value", NewMeta())},
+			),
+			Entry("has carbohydrate with negative value", NewRawObject(), "nutrition", map[string]interface{}{"carbohydrate": map[string]interface{}{"units": "grams", "value": -0.1}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(-0.1, 0.0, 1000.0), "/nutrition/carbohydrate/value", NewMeta())},
+			),
+			Entry("has fat with value greater than 1000", NewRawObject(), "nutrition", map[string]interface{}{"fat": map[string]interface{}{"units": "grams", "value": 1000.1}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(1000.1, 0.0, 1000.0), "/nutrition/fat/value", NewMeta())},
+			),
+			Entry("has protein with value greater than 1000", NewRawObject(), "nutrition", map[string]interface{}{"protein": map[string]interface{}{"units": "grams", "value": 1000.1}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(1000.1, 0.0, 1000.0), "/nutrition/protein/value", NewMeta())},
+			),
+			Entry("has energy with missing units", NewRawObject(), "nutrition", NewNutritionWithEnergy(nil, 100.0),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/nutrition/energy/units", NewMeta())},
+			),
+			Entry("has energy with unknown units", NewRawObject(), "nutrition", NewNutritionWithEnergy("calories", 100.0),
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("calories", []string{"kilocalories", "kilojoules"}), "/nutrition/energy/units", NewMeta())},
+			),
+			Entry("has energy with missing value", NewRawObject(), "nutrition", NewNutritionWithEnergy("kilocalories", nil),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/nutrition/energy/value", NewMeta())},
+			),
+			Entry("has energy in kilocalories with value greater than 10000", NewRawObject(), "nutrition", NewNutritionWithEnergy("kilocalories", 10000.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(10000.1, 0.0, 10000.0), "/nutrition/energy/value", NewMeta())},
+			),
+			Entry("has energy in kilojoules with value greater than 41840", NewRawObject(), "nutrition", NewNutritionWithEnergy("kilojoules", 41840.1),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(41840.1, 0.0, 41840.0), "/nutrition/energy/value", NewMeta())},
+			),
+			Entry("has unknown field", NewRawObject(), "nutrition", map[string]interface{}{"fiber": map[string]interface{}{"units": "grams", "value": 5.0}},
+				[]*service.Error{testData.ComposeError(parser.ErrorNotParsed(), "/nutrition/fiber", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is empty", NewRawObject(), "nutrition", map[string]interface{}{}),
+			Entry("has only carbohydrate", NewRawObject(), "nutrition", map[string]interface{}{"carbohydrate": map[string]interface{}{"units": "grams", "value": 1000.0}}),
+			Entry("has energy in kilocalories", NewRawObject(), "nutrition", NewNutritionWithEnergy("kilocalories", 10000.0)),
+			Entry("has energy in kilojoules", NewRawObject(), "nutrition", NewNutritionWithEnergy("kilojoules", 41840.0)),
+		)
+	})
+
+	Context("with parsed food", func() {
+		var testFood *food.Food
+
+		BeforeEach(func() {
+			datum := testData.ParseAndNormalize(NewRawObject(), "type", "food")
+			Expect(datum).To(BeAssignableToTypeOf(&food.Food{}))
+			testFood = datum.(*food.Food)
+		})
+
+		It("parses the nutrition", func() {
+			Expect(testFood.Meal).To(Equal(app.StringAsPointer("lunch")))
+			Expect(testFood.AbsorptionDuration).To(Equal(app.IntegerAsPointer(10800000)))
+			Expect(testFood.Nutrition).To(Equal(&food.Nutrition{
+				Carbohydrate: &food.Nutrient{Units: app.StringAsPointer("grams"), Value: app.FloatAsPointer(45.0)},
+				Fat:          &food.Nutrient{Units: app.StringAsPointer("grams"), Value: app.FloatAsPointer(12.5)},
+				Protein:      &food.Nutrient{Units: app.StringAsPointer("grams"), Value: app.FloatAsPointer(20.0)},
+				Energy:       &food.Energy{Units: app.StringAsPointer("kilocalories"), Value: app.FloatAsPointer(372.5)},
+			}))
+		})
+
+		Context("IdentityFields", func() {
+			var userID string
+
+			BeforeEach(func() {
+				userID = app.NewID()
+				testFood.UserID = userID
+			})
+
+			It("returns error if user id is empty", func() {
+				testFood.UserID = ""
+				identityFields, err := testFood.IdentityFields()
+				Expect(err).To(MatchError("base: user id is empty"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if nutrition is missing", func() {
+				testFood.Nutrition = nil
+				identityFields, err := testFood.IdentityFields()
+				Expect(err).To(MatchError("food: nutrition is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns the expected identity fields", func() {
+				identityFields, err := testFood.IdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, "InsOmn-111111111", "2014-06-11T06:00:00.000Z", "food", "meal", "lunch", "carbohydrate", "45", "grams", "fat", "12.5", "grams", "protein", "20", "grams", "energy", "372.5", "kilocalories"}))
+			})
+
+			It("returns the expected identity fields with missing meal and nutrients", func() {
+				testFood.Meal = nil
+				testFood.Nutrition = &food.Nutrition{Carbohydrate: &food.Nutrient{Units: app.StringAsPointer("grams"), Value: app.FloatAsPointer(45.0)}}
+				identityFields, err := testFood.IdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, "InsOmn-111111111", "2014-06-11T06:00:00.000Z", "food", "carbohydrate", "45", "grams"}))
+			})
+		})
+
+		Context("FuzzyIdentityFields", func() {
+			var userID string
+
+			BeforeEach(func() {
+				userID = app.NewID()
+				testFood.UserID = userID
+			})
+
+			It("returns error if device time is missing", func() {
+				testFood.DeviceTime = nil
+				identityFields, err := testFood.FuzzyIdentityFields()
+				Expect(err).To(MatchError("base: device time is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if nutrition is missing", func() {
+				testFood.Nutrition = nil
+				identityFields, err := testFood.FuzzyIdentityFields()
+				Expect(err).To(MatchError("food: nutrition is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns the expected identity fields", func() {
+				identityFields, err := testFood.FuzzyIdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, "InsOmn-111111111", "food", "meal", "lunch", "carbohydrate", "45", "grams", "fat", "12.5", "grams", "protein", "20", "grams", "energy", "372.5", "kilocalories"}))
+			})
+		})
+	})
+})
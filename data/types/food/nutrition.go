@@ -0,0 +1,178 @@
+package food
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/tidepool-org/platform/data"
+)
+
+const (
+	NutrientUnitsGrams = "grams"
+
+	NutrientValueMinimum = 0.0
+	NutrientValueMaximum = 1000.0
+
+	EnergyUnitsKilocalories = "kilocalories"
+	EnergyUnitsKilojoules   = "kilojoules"
+
+	EnergyValueMinimum             = 0.0
+	EnergyValueKilocaloriesMaximum = 10000.0
+	EnergyValueKilojoulesMaximum   = 41840.0
+)
+
+func NutrientUnits() []string {
+	return []string{NutrientUnitsGrams}
+}
+
+func EnergyUnits() []string {
+	return []string{EnergyUnitsKilocalories, EnergyUnitsKilojoules}
+}
+
+func EnergyValueRangeForUnits(units *string) (float64, float64) {
+	if units != nil {
+		switch *units {
+		case EnergyUnitsKilocalories:
+			return EnergyValueMinimum, EnergyValueKilocaloriesMaximum
+		case EnergyUnitsKilojoules:
+			return EnergyValueMinimum, EnergyValueKilojoulesMaximum
+		}
+	}
+	return -math.MaxFloat64, math.MaxFloat64
+}
+
+type Nutrition struct {
+	Carbohydrate *Nutrient `json:"carbohydrate,omitempty" bson:"carbohydrate,omitempty"`
+	Fat          *Nutrient `json:"fat,omitempty" bson:"fat,omitempty"`
+	Protein      *Nutrient `json:"protein,omitempty" bson:"protein,omitempty"`
+	Energy       *Energy   `json:"energy,omitempty" bson:"energy,omitempty"`
+}
+
+func NewNutrition() *Nutrition {
+	return &Nutrition{}
+}
+
+func (n *Nutrition) Parse(parser data.ObjectParser) {
+	n.Carbohydrate = ParseNutrient(parser.NewChildObjectParser("carbohydrate"))
+	n.Fat = ParseNutrient(parser.NewChildObjectParser("fat"))
+	n.Protein = ParseNutrient(parser.NewChildObjectParser("protein"))
+	n.Energy = ParseEnergy(parser.NewChildObjectParser("energy"))
+}
+
+func (n *Nutrition) Validate(validator data.Validator) {
+	if n.Carbohydrate != nil {
+		n.Carbohydrate.Validate(validator.NewChildValidator("carbohydrate"))
+	}
+	if n.Fat != nil {
+		n.Fat.Validate(validator.NewChildValidator("fat"))
+	}
+	if n.Protein != nil {
+		n.Protein.Validate(validator.NewChildValidator("protein"))
+	}
+	if n.Energy != nil {
+		n.Energy.Validate(validator.NewChildValidator("energy"))
+	}
+}
+
+func (n *Nutrition) Normalize(normalizer data.Normalizer) {
+	if n.Carbohydrate != nil {
+		n.Carbohydrate.Normalize(normalizer.NewChildNormalizer("carbohydrate"))
+	}
+	if n.Fat != nil {
+		n.Fat.Normalize(normalizer.NewChildNormalizer("fat"))
+	}
+	if n.Protein != nil {
+		n.Protein.Normalize(normalizer.NewChildNormalizer("protein"))
+	}
+	if n.Energy != nil {
+		n.Energy.Normalize(normalizer.NewChildNormalizer("energy"))
+	}
+}
+
+func ParseNutrition(parser data.ObjectParser) *Nutrition {
+	var nutrition *Nutrition
+	if parser.Object() != nil {
+		nutrition = NewNutrition()
+		nutrition.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return nutrition
+}
+
+type Nutrient struct {
+	Units *string  `json:"units,omitempty" bson:"units,omitempty"`
+	Value *float64 `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+func NewNutrient() *Nutrient {
+	return &Nutrient{}
+}
+
+func (n *Nutrient) Parse(parser data.ObjectParser) {
+	n.Units = parser.ParseString("units")
+	n.Value = parser.ParseFloat("value")
+}
+
+func (n *Nutrient) Validate(validator data.Validator) {
+	validator.ValidateString("units", n.Units).Exists().OneOf(NutrientUnits())
+	validator.ValidateFloat("value", n.Value).Exists().InRange(NutrientValueMinimum, NutrientValueMaximum)
+}
+
+func (n *Nutrient) Normalize(normalizer data.Normalizer) {
+}
+
+func (n *Nutrient) appendIdentityFields(identityFields []string, name string) []string {
+	if n == nil || n.Units == nil || n.Value == nil {
+		return identityFields
+	}
+	return append(identityFields, name, strconv.FormatFloat(*n.Value, 'f', -1, 64), *n.Units)
+}
+
+func ParseNutrient(parser data.ObjectParser) *Nutrient {
+	var nutrient *Nutrient
+	if parser.Object() != nil {
+		nutrient = NewNutrient()
+		nutrient.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return nutrient
+}
+
+type Energy struct {
+	Units *string  `json:"units,omitempty" bson:"units,omitempty"`
+	Value *float64 `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+func NewEnergy() *Energy {
+	return &Energy{}
+}
+
+func (e *Energy) Parse(parser data.ObjectParser) {
+	e.Units = parser.ParseString("units")
+	e.Value = parser.ParseFloat("value")
+}
+
+func (e *Energy) Validate(validator data.Validator) {
+	validator.ValidateString("units", e.Units).Exists().OneOf(EnergyUnits())
+	validator.ValidateFloat("value", e.Value).Exists().InRange(EnergyValueRangeForUnits(e.Units))
+}
+
+func (e *Energy) Normalize(normalizer data.Normalizer) {
+}
+
+func (e *Energy) appendIdentityFields(identityFields []string, name string) []string {
+	if e == nil || e.Units == nil || e.Value == nil {
+		return identityFields
+	}
+	return append(identityFields, name, strconv.FormatFloat(*e.Value, 'f', -1, 64), *e.Units)
+}
+
+func ParseEnergy(parser data.ObjectParser) *Energy {
+	var energy *Energy
+	if parser.Object() != nil {
+		energy = NewEnergy()
+		energy.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return energy
+}
@@ -0,0 +1,46 @@
+package insulin
+
+import "github.com/tidepool-org/platform/data"
+
+const (
+	DoseUnitsUnits = "Units"
+
+	DoseValueMinimum = 0.0
+	DoseValueMaximum = 100.0
+)
+
+func DoseUnits() []string {
+	return []string{DoseUnitsUnits}
+}
+
+type Dose struct {
+	Units *string  `json:"units,omitempty" bson:"units,omitempty"`
+	Value *float64 `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+func NewDose() *Dose {
+	return &Dose{}
+}
+
+func (d *Dose) Parse(parser data.ObjectParser) {
+	d.Units = parser.ParseString("units")
+	d.Value = parser.ParseFloat("value")
+}
+
+func (d *Dose) Validate(validator data.Validator) {
+	validator.ValidateString("units", d.Units).Exists().OneOf(DoseUnits())
+	validator.ValidateFloat("value", d.Value).Exists().InRange(DoseValueMinimum, DoseValueMaximum)
+}
+
+func (d *Dose) Normalize(normalizer data.Normalizer) {
+}
+
+func ParseDose(parser data.ObjectParser) *Dose {
+	var dose *Dose
+	if parser.Object() != nil {
+		dose = NewDose()
+		dose.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return dose
+}
@@ -0,0 +1,60 @@
+package insulin
+
+import "github.com/tidepool-org/platform/data"
+
+const (
+	ActingTypeRapid        = "rapid"
+	ActingTypeShort        = "short"
+	ActingTypeIntermediate = "intermediate"
+	ActingTypeLong         = "long"
+
+	BrandLengthMaximum = 100
+
+	ConcentrationU100 = "U-100"
+	ConcentrationU200 = "U-200"
+	ConcentrationU300 = "U-300"
+	ConcentrationU500 = "U-500"
+)
+
+func ActingTypes() []string {
+	return []string{ActingTypeRapid, ActingTypeShort, ActingTypeIntermediate, ActingTypeLong}
+}
+
+func Concentrations() []string {
+	return []string{ConcentrationU100, ConcentrationU200, ConcentrationU300, ConcentrationU500}
+}
+
+type Formulation struct {
+	ActingType    *string `json:"actingType,omitempty" bson:"actingType,omitempty"`
+	Brand         *string `json:"brand,omitempty" bson:"brand,omitempty"`
+	Concentration *string `json:"concentration,omitempty" bson:"concentration,omitempty"`
+}
+
+func NewFormulation() *Formulation {
+	return &Formulation{}
+}
+
+func (f *Formulation) Parse(parser data.ObjectParser) {
+	f.ActingType = parser.ParseString("actingType")
+	f.Brand = parser.ParseString("brand")
+	f.Concentration = parser.ParseString("concentration")
+}
+
+func (f *Formulation) Validate(validator data.Validator) {
+	validator.ValidateString("actingType", f.ActingType).Exists().OneOf(ActingTypes())
+	validator.ValidateString("brand", f.Brand).NotEmpty().LengthLessThanOrEqualTo(BrandLengthMaximum)
+	validator.ValidateString("concentration", f.Concentration).OneOf(Concentrations())
+}
+
+func (f *Formulation) Normalize(normalizer data.Normalizer) {
+}
+
+func ParseFormulation(parser data.ObjectParser) *Formulation {
+	var formulation *Formulation
+	if parser.Object() != nil {
+		formulation = NewFormulation()
+		formulation.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return formulation
+}
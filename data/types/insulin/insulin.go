@@ -0,0 +1,153 @@
+package insulin
+
+import (
+	"strconv"
+
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/errors"
+	"github.com/tidepool-org/platform/service"
+)
+
+const (
+	SiteAbdomen = "abdomen"
+	SiteArm     = "arm"
+	SiteButtock = "buttock"
+	SiteThigh   = "thigh"
+	SiteOther   = "other"
+)
+
+func Sites() []string {
+	return []string{SiteAbdomen, SiteArm, SiteButtock, SiteThigh, SiteOther}
+}
+
+type Insulin struct {
+	types.Base `bson:",inline"`
+
+	Dose        *Dose        `json:"dose,omitempty" bson:"dose,omitempty"`
+	Formulation *Formulation `json:"formulation,omitempty" bson:"formulation,omitempty"`
+	Site        *string      `json:"site,omitempty" bson:"site,omitempty"`
+}
+
+func Type() string {
+	return "insulin"
+}
+
+func NewDatum() data.Datum {
+	return New()
+}
+
+func New() *Insulin {
+	return &Insulin{}
+}
+
+func Init() *Insulin {
+	insulin := New()
+	insulin.Init()
+	return insulin
+}
+
+func (i *Insulin) Init() {
+	i.Base.Init()
+	i.Type = Type()
+
+	i.Dose = nil
+	i.Formulation = nil
+	i.Site = nil
+}
+
+func (i *Insulin) Parse(parser data.ObjectParser) error {
+	parser.SetMeta(i.Meta())
+
+	if err := i.Base.Parse(parser); err != nil {
+		return err
+	}
+
+	i.Site = parser.ParseString("site")
+
+	i.Dose = ParseDose(parser.NewChildObjectParser("dose"))
+	i.Formulation = ParseFormulation(parser.NewChildObjectParser("formulation"))
+
+	return nil
+}
+
+func (i *Insulin) Validate(validator data.Validator) error {
+	validator.SetMeta(i.Meta())
+
+	if err := i.Base.Validate(validator); err != nil {
+		return err
+	}
+
+	validator.ValidateString("type", &i.Type).EqualTo(Type())
+
+	validator.ValidateString("site", i.Site).OneOf(Sites())
+
+	if i.Dose != nil {
+		i.Dose.Validate(validator.NewChildValidator("dose"))
+	} else {
+		validator.AppendError("dose", service.ErrorValueNotExists())
+	}
+
+	if i.Formulation != nil {
+		i.Formulation.Validate(validator.NewChildValidator("formulation"))
+	} else {
+		validator.AppendError("formulation", service.ErrorValueNotExists())
+	}
+
+	return nil
+}
+
+func (i *Insulin) Normalize(normalizer data.Normalizer) error {
+	normalizer.SetMeta(i.Meta())
+
+	if err := i.Base.Normalize(normalizer); err != nil {
+		return err
+	}
+
+	if i.Dose != nil {
+		i.Dose.Normalize(normalizer.NewChildNormalizer("dose"))
+	}
+
+	if i.Formulation != nil {
+		i.Formulation.Normalize(normalizer.NewChildNormalizer("formulation"))
+	}
+
+	return nil
+}
+
+func (i *Insulin) IdentityFields() ([]string, error) {
+	return i.identityFields(i.Base.IdentityFields())
+}
+
+func (i *Insulin) FuzzyIdentityFields() ([]string, error) {
+	return i.identityFields(i.Base.FuzzyIdentityFields())
+}
+
+func (i *Insulin) identityFields(identityFields []string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if i.Dose == nil {
+		return nil, errors.New("insulin", "dose is missing")
+	}
+	if i.Dose.Units == nil {
+		return nil, errors.New("insulin", "dose units is missing")
+	}
+	if i.Dose.Value == nil {
+		return nil, errors.New("insulin", "dose value is missing")
+	}
+	if i.Formulation == nil {
+		return nil, errors.New("insulin", "formulation is missing")
+	}
+	if i.Formulation.ActingType == nil {
+		return nil, errors.New("insulin", "formulation acting type is missing")
+	}
+
+	identityFields = append(identityFields, *i.Dose.Units, strconv.FormatFloat(*i.Dose.Value, 'f', -1, 64), *i.Formulation.ActingType)
+	if i.Formulation.Brand != nil {
+		identityFields = append(identityFields, *i.Formulation.Brand)
+	}
+
+	return identityFields, nil
+}
@@ -0,0 +1,254 @@
+package insulin_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"strings"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/parser"
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/insulin"
+	"github.com/tidepool-org/platform/service"
+)
+
+func NewRawObject() map[string]interface{} {
+	rawObject := testData.RawBaseObject()
+	rawObject["type"] = "insulin"
+	rawObject["dose"] = map[string]interface{}{"units": "Units", "value": 4.5}
+	rawObject["formulation"] = map[string]interface{}{"actingType": "rapid", "brand": "Humalog", "concentration": "U-100"}
+	rawObject["site"] = "abdomen"
+	return rawObject
+}
+
+func NewFormulation(key string, value interface{}) map[string]interface{} {
+	formulation := map[string]interface{}{"actingType": "rapid", "brand": "Humalog", "concentration": "U-100"}
+	if value != nil {
+		formulation[key] = value
+	} else {
+		delete(formulation, key)
+	}
+	return formulation
+}
+
+func NewMeta() interface{} {
+	return &types.Meta{
+		Type: "insulin",
+	}
+}
+
+var _ = Describe("Insulin", func() {
+	Context("Type", func() {
+		It("returns the expected type", func() {
+			Expect(insulin.Type()).To(Equal("insulin"))
+		})
+	})
+
+	Context("NewDatum", func() {
+		It("returns the expected datum", func() {
+			Expect(insulin.NewDatum()).To(Equal(&insulin.Insulin{}))
+		})
+	})
+
+	Context("New", func() {
+		It("returns the expected insulin", func() {
+			Expect(insulin.New()).To(Equal(&insulin.Insulin{}))
+		})
+	})
+
+	Context("Init", func() {
+		It("returns the expected insulin", func() {
+			testInsulin := insulin.Init()
+			Expect(testInsulin).ToNot(BeNil())
+			Expect(testInsulin.ID).ToNot(BeEmpty())
+			Expect(testInsulin.Type).To(Equal("insulin"))
+		})
+	})
+
+	Context("dose", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is missing", NewRawObject(), "dose", nil,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/dose", NewMeta())},
+			),
+			Entry("has missing units", NewRawObject(), "dose", map[string]interface{}{"value": 4.5},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/dose/units", NewMeta())},
+			),
+			Entry("has unknown units", NewRawObject(), "dose", map[string]interface{}{"units": "mL", "value": 4.5},
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("mL", []string{"Units"}), "/dose/units", NewMeta())},
+			),
+			Entry("has missing value", NewRawObject(), "dose", map[string]interface{}{"units": "Units"},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/dose/value", NewMeta())},
+			),
+			Entry("has negative value", NewRawObject(), "dose", map[string]interface{}{"units": "Units", "value": -0.1},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(-0.1, 0.0, 100.0), "/dose/value", NewMeta())},
+			),
+			Entry("has value greater than 100", NewRawObject(), "dose", map[string]interface{}{"units": "Units", "value": 100.1},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(100.1, 0.0, 100.0), "/dose/value", NewMeta())},
+			),
+			Entry("has unknown field", NewRawObject(), "dose", map[string]interface{}{"units": "Units", "value": 4.5, "extended": 1.0},
+				[]*service.Error{testData.ComposeError(parser.ErrorNotParsed(), "/dose/extended", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("has zero value", NewRawObject(), "dose", map[string]interface{}{"units": "Units", "value": 0.0}),
+			Entry("has value of 100", NewRawObject(), "dose", map[string]interface{}{"units": "Units", "value": 100.0}),
+		)
+	})
+
+	Context("formulation", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is missing", NewRawObject(), "formulation", nil,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/formulation", NewMeta())},
+			),
+			Entry("has missing acting type", NewRawObject(), "formulation", NewFormulation("actingType", nil),
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/formulation/actingType", NewMeta())},
+			),
+			Entry("has unknown acting type", NewRawObject(), "formulation", NewFormulation("actingType", "slow"),
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("slow", []string{"rapid", "short", "intermediate", "long"}), "/formulation/actingType", NewMeta())},
+			),
+			Entry("has empty brand", NewRawObject(), "formulation", NewFormulation("brand", ""),
+				[]*service.Error{testData.ComposeError(service.ErrorValueEmpty(), "/formulation/brand", NewMeta())},
+			),
+			Entry("has brand longer than 100", NewRawObject(), "formulation", NewFormulation("brand", strings.Repeat("a", 101)),
+				[]*service.Error{testData.ComposeError(service.ErrorLengthNotLessThanOrEqualTo(101, 100), "/formulation/brand", NewMeta())},
+			),
+			Entry("has unknown concentration", NewRawObject(), "formulation", NewFormulation("concentration", "U-40"),
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("U-40", []string{"U-100", "U-200", "U-300", "U-500"}), "/formulation/concentration", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("has only acting type", NewRawObject(), "formulation", map[string]interface{}{"actingType": "rapid"}),
+			Entry("has long acting type", NewRawObject(), "formulation", NewFormulation("actingType", "long")),
+			Entry("has brand of length 100", NewRawObject(), "formulation", NewFormulation("brand", strings.Repeat("a", 100))),
+			Entry("has U-200 concentration", NewRawObject(), "formulation", NewFormulation("concentration", "U-200")),
+		)
+	})
+
+	Context("site", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is not one of the predefined values", NewRawObject(), "site", "neck",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("neck", insulin.Sites()), "/site", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObject(), "site", nil),
+			Entry("is arm", NewRawObject(), "site", "arm"),
+			Entry("is thigh", NewRawObject(), "site", "thigh"),
+		)
+	})
+
+	Context("with parsed insulin", func() {
+		var testInsulin *insulin.Insulin
+
+		BeforeEach(func() {
+			datum := testData.ParseAndNormalize(NewRawObject(), "type", "insulin")
+			Expect(datum).To(BeAssignableToTypeOf(&insulin.Insulin{}))
+			testInsulin = datum.(*insulin.Insulin)
+		})
+
+		It("parses the insulin", func() {
+			Expect(testInsulin.Dose).To(Equal(&insulin.Dose{Units: app.StringAsPointer("Units"), Value: app.FloatAsPointer(4.5)}))
+			Expect(testInsulin.Formulation).To(Equal(&insulin.Formulation{ActingType: app.StringAsPointer("rapid"), Brand: app.StringAsPointer("Humalog"), Concentration: app.StringAsPointer("U-100")}))
+			Expect(testInsulin.Site).To(Equal(app.StringAsPointer("abdomen")))
+		})
+
+		Context("IdentityFields", func() {
+			var userID string
+
+			BeforeEach(func() {
+				userID = app.NewID()
+				testInsulin.UserID = userID
+			})
+
+			It("returns error if user id is empty", func() {
+				testInsulin.UserID = ""
+				identityFields, err := testInsulin.IdentityFields()
+				Expect(err).To(MatchError("base: user id is empty"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if dose is missing", func() {
+				testInsulin.Dose = nil
+				identityFields, err := testInsulin.IdentityFields()
+				Expect(err).To(MatchError("insulin: dose is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if dose units is missing", func() {
+				testInsulin.Dose.Units = nil
+				identityFields, err := testInsulin.IdentityFields()
+				Expect(err).To(MatchError("insulin: dose units is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if dose value is missing", func() {
+				testInsulin.Dose.Value = nil
+				identityFields, err := testInsulin.IdentityFields()
+				Expect(err).To(MatchError("insulin: dose value is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if formulation is missing", func() {
+				testInsulin.Formulation = nil
+				identityFields, err := testInsulin.IdentityFields()
+				Expect(err).To(MatchError("insulin: formulation is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if formulation acting type is missing", func() {
+				testInsulin.Formulation.ActingType = nil
+				identityFields, err := testInsulin.IdentityFields()
+				Expect(err).To(MatchError("insulin: formulation acting type is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns the expected identity fields", func() {
+				identityFields, err := testInsulin.IdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, "InsOmn-111111111", "2014-06-11T06:00:00.000Z", "insulin", "Units", "4.5", "rapid", "Humalog"}))
+			})
+
+			It("returns the expected identity fields without brand", func() {
+				testInsulin.Formulation.Brand = nil
+				identityFields, err := testInsulin.IdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, "InsOmn-111111111", "2014-06-11T06:00:00.000Z", "insulin", "Units", "4.5", "rapid"}))
+			})
+		})
+
+		Context("FuzzyIdentityFields", func() {
+			var userID string
+
+			BeforeEach(func() {
+				userID = app.NewID()
+				testInsulin.UserID = userID
+			})
+
+			It("returns error if device time is missing", func() {
+				testInsulin.DeviceTime = nil
+				identityFields, err := testInsulin.FuzzyIdentityFields()
+				Expect(err).To(MatchError("base: device time is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns error if dose is missing", func() {
+				testInsulin.Dose = nil
+				identityFields, err := testInsulin.FuzzyIdentityFields()
+				Expect(err).To(MatchError("insulin: dose is missing"))
+				Expect(identityFields).To(BeEmpty())
+			})
+
+			It("returns the expected identity fields", func() {
+				identityFields, err := testInsulin.FuzzyIdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields).To(Equal([]string{userID, "InsOmn-111111111", "insulin", "Units", "4.5", "rapid", "Humalog"}))
+			})
+		})
+	})
+})
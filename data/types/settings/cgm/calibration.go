@@ -0,0 +1,45 @@
+package cgm
+
+import "github.com/tidepool-org/platform/data"
+
+const (
+	CalibrationIntervalMinimum = 0
+	CalibrationIntervalMaximum = 86400000
+)
+
+type Calibration struct {
+	Enabled  *bool `json:"enabled,omitempty" bson:"enabled,omitempty"`
+	Interval *int  `json:"interval,omitempty" bson:"interval,omitempty"`
+}
+
+func NewCalibration() *Calibration {
+	return &Calibration{}
+}
+
+func (c *Calibration) Parse(parser data.ObjectParser) {
+	c.Enabled = parser.ParseBoolean("enabled")
+	c.Interval = parser.ParseInteger("interval")
+}
+
+func (c *Calibration) Validate(validator data.Validator) {
+	validator.ValidateBoolean("enabled", c.Enabled).Exists()
+
+	intervalValidator := validator.ValidateInteger("interval", c.Interval)
+	if c.Enabled != nil && *c.Enabled {
+		intervalValidator.Exists()
+	}
+	intervalValidator.InRange(CalibrationIntervalMinimum, CalibrationIntervalMaximum)
+}
+
+func (c *Calibration) Normalize(normalizer data.Normalizer) {
+}
+
+func ParseCalibration(parser data.ObjectParser) *Calibration {
+	var calibration *Calibration
+	if parser.Object() != nil {
+		calibration = NewCalibration()
+		calibration.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return calibration
+}
@@ -0,0 +1,150 @@
+package cgm
+
+import (
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/blood/glucose"
+	"github.com/tidepool-org/platform/data/types"
+)
+
+const (
+	TransmitterIDLengthMaximum = 100
+)
+
+type CGM struct {
+	types.Base `bson:",inline"`
+
+	Units *string `json:"units,omitempty" bson:"units,omitempty"`
+
+	TransmitterID *string `json:"transmitterId,omitempty" bson:"transmitterId,omitempty"`
+
+	HighAlerts         *LevelAlert         `json:"highAlerts,omitempty" bson:"highAlerts,omitempty"`
+	LowAlerts          *LevelAlert         `json:"lowAlerts,omitempty" bson:"lowAlerts,omitempty"`
+	RateOfChangeAlerts *RateOfChangeAlerts `json:"rateOfChangeAlerts,omitempty" bson:"rateOfChangeAlerts,omitempty"`
+	Calibration        *Calibration        `json:"calibration,omitempty" bson:"calibration,omitempty"`
+}
+
+func Type() string {
+	return "cgmSettings"
+}
+
+func NewDatum() data.Datum {
+	return New()
+}
+
+func New() *CGM {
+	return &CGM{}
+}
+
+func Init() *CGM {
+	cgm := New()
+	cgm.Init()
+	return cgm
+}
+
+func (c *CGM) Init() {
+	c.Base.Init()
+	c.Type = Type()
+
+	c.Units = nil
+
+	c.TransmitterID = nil
+
+	c.HighAlerts = nil
+	c.LowAlerts = nil
+	c.RateOfChangeAlerts = nil
+	c.Calibration = nil
+}
+
+func (c *CGM) Parse(parser data.ObjectParser) error {
+	parser.SetMeta(c.Meta())
+
+	if err := c.Base.Parse(parser); err != nil {
+		return err
+	}
+
+	c.Units = parser.ParseString("units")
+
+	c.TransmitterID = parser.ParseString("transmitterId")
+
+	c.HighAlerts = ParseLevelAlert(parser.NewChildObjectParser("highAlerts"))
+	c.LowAlerts = ParseLevelAlert(parser.NewChildObjectParser("lowAlerts"))
+	c.RateOfChangeAlerts = ParseRateOfChangeAlerts(parser.NewChildObjectParser("rateOfChangeAlerts"))
+	c.Calibration = ParseCalibration(parser.NewChildObjectParser("calibration"))
+
+	return nil
+}
+
+func (c *CGM) Validate(validator data.Validator) error {
+	validator.SetMeta(c.Meta())
+
+	if err := c.Base.Validate(validator); err != nil {
+		return err
+	}
+
+	validator.ValidateString("type", &c.Type).EqualTo(Type())
+
+	validator.ValidateString("units", c.Units).Exists().OneOf(glucose.Units())
+
+	validator.ValidateString("transmitterId", c.TransmitterID).NotEmpty().LengthLessThanOrEqualTo(TransmitterIDLengthMaximum)
+
+	if c.HighAlerts != nil {
+		highAlertsValidator := validator.NewChildValidator("highAlerts")
+		c.HighAlerts.Validate(highAlertsValidator, c.Units)
+		if c.LowAlerts != nil && c.LowAlerts.Level != nil {
+			highAlertsValidator.ValidateFloat("level", c.HighAlerts.Level).GreaterThan(*c.LowAlerts.Level)
+		}
+	}
+
+	if c.LowAlerts != nil {
+		c.LowAlerts.Validate(validator.NewChildValidator("lowAlerts"), c.Units)
+	}
+
+	if c.RateOfChangeAlerts != nil {
+		c.RateOfChangeAlerts.Validate(validator.NewChildValidator("rateOfChangeAlerts"), c.Units)
+	}
+
+	if c.Calibration != nil {
+		c.Calibration.Validate(validator.NewChildValidator("calibration"))
+	}
+
+	return nil
+}
+
+func (c *CGM) Normalize(normalizer data.Normalizer) error {
+	normalizer.SetMeta(c.Meta())
+
+	if err := c.Base.Normalize(normalizer); err != nil {
+		return err
+	}
+
+	units := c.Units
+
+	c.Units = glucose.NormalizeUnits(c.Units)
+
+	if c.HighAlerts != nil {
+		c.HighAlerts.Normalize(normalizer.NewChildNormalizer("highAlerts"), units)
+	}
+
+	if c.LowAlerts != nil {
+		c.LowAlerts.Normalize(normalizer.NewChildNormalizer("lowAlerts"), units)
+	}
+
+	if c.RateOfChangeAlerts != nil {
+		c.RateOfChangeAlerts.Normalize(normalizer.NewChildNormalizer("rateOfChangeAlerts"), units)
+	}
+
+	if c.Calibration != nil {
+		c.Calibration.Normalize(normalizer.NewChildNormalizer("calibration"))
+	}
+
+	return nil
+}
+
+func (c *CGM) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := c.Base.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, c.Units, c.TransmitterID, c.HighAlerts, c.LowAlerts, c.RateOfChangeAlerts, c.Calibration)
+}
@@ -0,0 +1,229 @@
+package cgm_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"strings"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/blood/glucose"
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/settings/cgm"
+	"github.com/tidepool-org/platform/service"
+)
+
+func NewRawObjectMmolL() map[string]interface{} {
+	rawObject := testData.RawBaseObject()
+	rawObject["type"] = "cgmSettings"
+	rawObject["units"] = glucose.MmolL
+	rawObject["transmitterId"] = "80AB12"
+	rawObject["highAlerts"] = map[string]interface{}{"enabled": true, "level": 13.9, "snooze": 7200000}
+	rawObject["lowAlerts"] = map[string]interface{}{"enabled": true, "level": 3.9, "snooze": 1800000}
+	rawObject["rateOfChangeAlerts"] = map[string]interface{}{
+		"fallRate": map[string]interface{}{"enabled": true, "rate": 0.11},
+		"riseRate": map[string]interface{}{"enabled": false},
+	}
+	rawObject["calibration"] = map[string]interface{}{"enabled": true, "interval": 43200000}
+	return rawObject
+}
+
+func NewRawObjectMgdL() map[string]interface{} {
+	rawObject := NewRawObjectMmolL()
+	rawObject["units"] = glucose.MgdL
+	rawObject["highAlerts"] = map[string]interface{}{"enabled": true, "level": 250.0, "snooze": 7200000}
+	rawObject["lowAlerts"] = map[string]interface{}{"enabled": true, "level": 70.0, "snooze": 1800000}
+	rawObject["rateOfChangeAlerts"] = map[string]interface{}{
+		"fallRate": map[string]interface{}{"enabled": true, "rate": 2.0},
+		"riseRate": map[string]interface{}{"enabled": true, "rate": 3.0},
+	}
+	return rawObject
+}
+
+func NewMeta() interface{} {
+	return &types.Meta{
+		Type: "cgmSettings",
+	}
+}
+
+var _ = Describe("CGM", func() {
+	Context("Type", func() {
+		It("returns the expected type", func() {
+			Expect(cgm.Type()).To(Equal("cgmSettings"))
+		})
+	})
+
+	Context("NewDatum", func() {
+		It("returns the expected datum", func() {
+			Expect(cgm.NewDatum()).To(Equal(&cgm.CGM{}))
+		})
+	})
+
+	Context("New", func() {
+		It("returns the expected cgm", func() {
+			Expect(cgm.New()).To(Equal(&cgm.CGM{}))
+		})
+	})
+
+	Context("Init", func() {
+		It("returns the expected cgm", func() {
+			testCGM := cgm.Init()
+			Expect(testCGM).ToNot(BeNil())
+			Expect(testCGM.ID).ToNot(BeEmpty())
+			Expect(testCGM.Type).To(Equal("cgmSettings"))
+		})
+	})
+
+	Context("units", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is missing", NewRawObjectMmolL(), "units", nil,
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/units", NewMeta())},
+			),
+			Entry("is not one of the predefined values", NewRawObjectMmolL(), "units", "wrong",
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("wrong", glucose.Units()), "/units", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is mmol/L", NewRawObjectMmolL(), "units", glucose.MmolL),
+			Entry("is mmol/l", NewRawObjectMmolL(), "units", glucose.Mmoll),
+			Entry("is mg/dL", NewRawObjectMgdL(), "units", glucose.MgdL),
+			Entry("is mg/dl", NewRawObjectMgdL(), "units", glucose.Mgdl),
+		)
+	})
+
+	Context("transmitterId", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("is empty", NewRawObjectMmolL(), "transmitterId", "",
+				[]*service.Error{testData.ComposeError(service.ErrorValueEmpty(), "/transmitterId", NewMeta())},
+			),
+			Entry("is longer than 100", NewRawObjectMmolL(), "transmitterId", strings.Repeat("a", 101),
+				[]*service.Error{testData.ComposeError(service.ErrorLengthNotLessThanOrEqualTo(101, 100), "/transmitterId", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObjectMmolL(), "transmitterId", nil),
+			Entry("is of length 100", NewRawObjectMmolL(), "transmitterId", strings.Repeat("a", 100)),
+		)
+	})
+
+	Context("highAlerts", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("has missing enabled", NewRawObjectMmolL(), "highAlerts", map[string]interface{}{"level": 13.9},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/highAlerts/enabled", NewMeta())},
+			),
+			Entry("is enabled with missing level", NewRawObjectMmolL(), "highAlerts", map[string]interface{}{"enabled": true},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/highAlerts/level", NewMeta())},
+			),
+			Entry("has mmol/L level out of range", NewRawObjectMmolL(), "highAlerts", map[string]interface{}{"enabled": true, "level": 55.1},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(55.1, 0.0, 55.0), "/highAlerts/level", NewMeta())},
+			),
+			Entry("has mg/dL level out of range", NewRawObjectMgdL(), "highAlerts", map[string]interface{}{"enabled": true, "level": 1000.1},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(1000.1, 0.0, 1000.0), "/highAlerts/level", NewMeta())},
+			),
+			Entry("has level not greater than low alerts level", NewRawObjectMmolL(), "highAlerts", map[string]interface{}{"enabled": true, "level": 3.9},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotGreaterThan(3.9, 3.9), "/highAlerts/level", NewMeta())},
+			),
+			Entry("has snooze out of range", NewRawObjectMmolL(), "highAlerts", map[string]interface{}{"enabled": true, "level": 13.9, "snooze": 86400001},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(86400001, 0, 86400000), "/highAlerts/snooze", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObjectMmolL(), "highAlerts", nil),
+			Entry("is disabled without level", NewRawObjectMmolL(), "highAlerts", map[string]interface{}{"enabled": false}),
+			Entry("has mmol/L level at upper limit", NewRawObjectMmolL(), "highAlerts", map[string]interface{}{"enabled": true, "level": 55.0}),
+			Entry("has mg/dL level at upper limit", NewRawObjectMgdL(), "highAlerts", map[string]interface{}{"enabled": true, "level": 1000.0}),
+		)
+	})
+
+	Context("lowAlerts", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("has missing enabled", NewRawObjectMmolL(), "lowAlerts", map[string]interface{}{"level": 3.9},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/lowAlerts/enabled", NewMeta())},
+			),
+			Entry("is enabled with missing level", NewRawObjectMmolL(), "lowAlerts", map[string]interface{}{"enabled": true},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/lowAlerts/level", NewMeta())},
+			),
+			Entry("has negative level", NewRawObjectMmolL(), "lowAlerts", map[string]interface{}{"enabled": true, "level": -0.1},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(-0.1, 0.0, 55.0), "/lowAlerts/level", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObjectMmolL(), "lowAlerts", nil),
+			Entry("is disabled without level", NewRawObjectMmolL(), "lowAlerts", map[string]interface{}{"enabled": false}),
+		)
+	})
+
+	Context("rateOfChangeAlerts", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("has fall rate with missing enabled", NewRawObjectMmolL(), "rateOfChangeAlerts", map[string]interface{}{"fallRate": map[string]interface{}{"rate": 0.11}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/rateOfChangeAlerts/fallRate/enabled", NewMeta())},
+			),
+			Entry("has enabled fall rate with missing rate", NewRawObjectMmolL(), "rateOfChangeAlerts", map[string]interface{}{"fallRate": map[string]interface{}{"enabled": true}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/rateOfChangeAlerts/fallRate/rate", NewMeta())},
+			),
+			Entry("has mmol/L rise rate out of range", NewRawObjectMmolL(), "rateOfChangeAlerts", map[string]interface{}{"riseRate": map[string]interface{}{"enabled": true, "rate": 1.1}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(1.1, 0.0, 1.0), "/rateOfChangeAlerts/riseRate/rate", NewMeta())},
+			),
+			Entry("has mg/dL rise rate out of range", NewRawObjectMgdL(), "rateOfChangeAlerts", map[string]interface{}{"riseRate": map[string]interface{}{"enabled": true, "rate": 18.1}},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(18.1, 0.0, 18.0), "/rateOfChangeAlerts/riseRate/rate", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObjectMmolL(), "rateOfChangeAlerts", nil),
+			Entry("is empty", NewRawObjectMmolL(), "rateOfChangeAlerts", map[string]interface{}{}),
+			Entry("has mmol/L rate at upper limit", NewRawObjectMmolL(), "rateOfChangeAlerts", map[string]interface{}{"riseRate": map[string]interface{}{"enabled": true, "rate": 1.0}}),
+			Entry("has mg/dL rate at upper limit", NewRawObjectMgdL(), "rateOfChangeAlerts", map[string]interface{}{"riseRate": map[string]interface{}{"enabled": true, "rate": 18.0}}),
+		)
+	})
+
+	Context("calibration", func() {
+		DescribeTable("invalid when", testData.ExpectFieldNotValid,
+			Entry("has missing enabled", NewRawObjectMmolL(), "calibration", map[string]interface{}{"interval": 43200000},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/calibration/enabled", NewMeta())},
+			),
+			Entry("is enabled with missing interval", NewRawObjectMmolL(), "calibration", map[string]interface{}{"enabled": true},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotExists(), "/calibration/interval", NewMeta())},
+			),
+			Entry("has interval out of range", NewRawObjectMmolL(), "calibration", map[string]interface{}{"enabled": true, "interval": 86400001},
+				[]*service.Error{testData.ComposeError(service.ErrorValueNotInRange(86400001, 0, 86400000), "/calibration/interval", NewMeta())},
+			),
+		)
+
+		DescribeTable("valid when", testData.ExpectFieldIsValid,
+			Entry("is missing", NewRawObjectMmolL(), "calibration", nil),
+			Entry("is disabled without interval", NewRawObjectMmolL(), "calibration", map[string]interface{}{"enabled": false}),
+		)
+	})
+
+	Context("Normalize", func() {
+		It("does not convert mmol/L values", func() {
+			datum := testData.ParseAndNormalize(NewRawObjectMmolL(), "units", glucose.Mmoll)
+			Expect(datum).To(BeAssignableToTypeOf(&cgm.CGM{}))
+			testCGM := datum.(*cgm.CGM)
+			Expect(testCGM.Units).To(Equal(app.StringAsPointer(glucose.MmolL)))
+			Expect(testCGM.HighAlerts.Level).To(Equal(app.FloatAsPointer(13.9)))
+			Expect(testCGM.LowAlerts.Level).To(Equal(app.FloatAsPointer(3.9)))
+			Expect(testCGM.RateOfChangeAlerts.FallRate.Rate).To(Equal(app.FloatAsPointer(0.11)))
+			Expect(testCGM.RateOfChangeAlerts.RiseRate.Rate).To(BeNil())
+			Expect(testCGM.Calibration).To(Equal(&cgm.Calibration{Enabled: app.BooleanAsPointer(true), Interval: app.IntegerAsPointer(43200000)}))
+		})
+
+		It("converts mg/dL values to mmol/L", func() {
+			datum := testData.ParseAndNormalize(NewRawObjectMgdL(), "units", glucose.Mgdl)
+			Expect(datum).To(BeAssignableToTypeOf(&cgm.CGM{}))
+			testCGM := datum.(*cgm.CGM)
+			Expect(testCGM.Units).To(Equal(app.StringAsPointer(glucose.MmolL)))
+			Expect(testCGM.HighAlerts).To(Equal(&cgm.LevelAlert{Enabled: app.BooleanAsPointer(true), Level: app.FloatAsPointer(13.87687), Snooze: app.IntegerAsPointer(7200000)}))
+			Expect(testCGM.LowAlerts).To(Equal(&cgm.LevelAlert{Enabled: app.BooleanAsPointer(true), Level: app.FloatAsPointer(3.88552), Snooze: app.IntegerAsPointer(1800000)}))
+			Expect(testCGM.RateOfChangeAlerts.FallRate.Rate).To(Equal(app.FloatAsPointer(0.11101)))
+			Expect(testCGM.RateOfChangeAlerts.RiseRate.Rate).To(Equal(app.FloatAsPointer(0.16652)))
+		})
+	})
+})
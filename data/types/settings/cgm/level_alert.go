@@ -0,0 +1,53 @@
+package cgm
+
+import (
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/blood/glucose"
+)
+
+const (
+	SnoozeMinimum = 0
+	SnoozeMaximum = 86400000
+)
+
+type LevelAlert struct {
+	Enabled *bool    `json:"enabled,omitempty" bson:"enabled,omitempty"`
+	Level   *float64 `json:"level,omitempty" bson:"level,omitempty"`
+	Snooze  *int     `json:"snooze,omitempty" bson:"snooze,omitempty"`
+}
+
+func NewLevelAlert() *LevelAlert {
+	return &LevelAlert{}
+}
+
+func (l *LevelAlert) Parse(parser data.ObjectParser) {
+	l.Enabled = parser.ParseBoolean("enabled")
+	l.Level = parser.ParseFloat("level")
+	l.Snooze = parser.ParseInteger("snooze")
+}
+
+func (l *LevelAlert) Validate(validator data.Validator, units *string) {
+	validator.ValidateBoolean("enabled", l.Enabled).Exists()
+
+	levelValidator := validator.ValidateFloat("level", l.Level)
+	if l.Enabled != nil && *l.Enabled {
+		levelValidator.Exists()
+	}
+	levelValidator.InRange(glucose.ValueRangeForUnits(units))
+
+	validator.ValidateInteger("snooze", l.Snooze).InRange(SnoozeMinimum, SnoozeMaximum)
+}
+
+func (l *LevelAlert) Normalize(normalizer data.Normalizer, units *string) {
+	l.Level = glucose.NormalizeValueForUnits(l.Level, units)
+}
+
+func ParseLevelAlert(parser data.ObjectParser) *LevelAlert {
+	var levelAlert *LevelAlert
+	if parser.Object() != nil {
+		levelAlert = NewLevelAlert()
+		levelAlert.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return levelAlert
+}
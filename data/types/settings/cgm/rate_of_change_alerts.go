@@ -0,0 +1,109 @@
+package cgm
+
+import (
+	"math"
+
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/blood/glucose"
+)
+
+const (
+	RateMmolLLowerLimit float64 = 0.0
+	RateMmolLUpperLimit float64 = 1.0
+
+	RateMgdLLowerLimit float64 = 0.0
+	RateMgdLUpperLimit float64 = 18.0
+)
+
+// RateRangeForUnits returns the allowed range of a rate of change, per minute, for the units.
+func RateRangeForUnits(units *string) (float64, float64) {
+	if units != nil {
+		switch *units {
+		case glucose.MmolL, glucose.Mmoll:
+			return RateMmolLLowerLimit, RateMmolLUpperLimit
+		case glucose.MgdL, glucose.Mgdl:
+			return RateMgdLLowerLimit, RateMgdLUpperLimit
+		}
+	}
+	return -math.MaxFloat64, math.MaxFloat64
+}
+
+type RateOfChangeAlerts struct {
+	FallRate *RateAlert `json:"fallRate,omitempty" bson:"fallRate,omitempty"`
+	RiseRate *RateAlert `json:"riseRate,omitempty" bson:"riseRate,omitempty"`
+}
+
+func NewRateOfChangeAlerts() *RateOfChangeAlerts {
+	return &RateOfChangeAlerts{}
+}
+
+func (r *RateOfChangeAlerts) Parse(parser data.ObjectParser) {
+	r.FallRate = ParseRateAlert(parser.NewChildObjectParser("fallRate"))
+	r.RiseRate = ParseRateAlert(parser.NewChildObjectParser("riseRate"))
+}
+
+func (r *RateOfChangeAlerts) Validate(validator data.Validator, units *string) {
+	if r.FallRate != nil {
+		r.FallRate.Validate(validator.NewChildValidator("fallRate"), units)
+	}
+	if r.RiseRate != nil {
+		r.RiseRate.Validate(validator.NewChildValidator("riseRate"), units)
+	}
+}
+
+func (r *RateOfChangeAlerts) Normalize(normalizer data.Normalizer, units *string) {
+	if r.FallRate != nil {
+		r.FallRate.Normalize(normalizer.NewChildNormalizer("fallRate"), units)
+	}
+	if r.RiseRate != nil {
+		r.RiseRate.Normalize(normalizer.NewChildNormalizer("riseRate"), units)
+	}
+}
+
+func ParseRateOfChangeAlerts(parser data.ObjectParser) *RateOfChangeAlerts {
+	var rateOfChangeAlerts *RateOfChangeAlerts
+	if parser.Object() != nil {
+		rateOfChangeAlerts = NewRateOfChangeAlerts()
+		rateOfChangeAlerts.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return rateOfChangeAlerts
+}
+
+type RateAlert struct {
+	Enabled *bool    `json:"enabled,omitempty" bson:"enabled,omitempty"`
+	Rate    *float64 `json:"rate,omitempty" bson:"rate,omitempty"`
+}
+
+func NewRateAlert() *RateAlert {
+	return &RateAlert{}
+}
+
+func (r *RateAlert) Parse(parser data.ObjectParser) {
+	r.Enabled = parser.ParseBoolean("enabled")
+	r.Rate = parser.ParseFloat("rate")
+}
+
+func (r *RateAlert) Validate(validator data.Validator, units *string) {
+	validator.ValidateBoolean("enabled", r.Enabled).Exists()
+
+	rateValidator := validator.ValidateFloat("rate", r.Rate)
+	if r.Enabled != nil && *r.Enabled {
+		rateValidator.Exists()
+	}
+	rateValidator.InRange(RateRangeForUnits(units))
+}
+
+func (r *RateAlert) Normalize(normalizer data.Normalizer, units *string) {
+	r.Rate = glucose.NormalizeValueForUnits(r.Rate, units)
+}
+
+func ParseRateAlert(parser data.ObjectParser) *RateAlert {
+	var rateAlert *RateAlert
+	if parser.Object() != nil {
+		rateAlert = NewRateAlert()
+		rateAlert.Parse(parser)
+		parser.ProcessNotParsed()
+	}
+	return rateAlert
+}
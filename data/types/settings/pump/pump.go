@@ -163,3 +163,12 @@ func (p *Pump) Normalize(normalizer data.Normalizer) error {
 
 	return nil
 }
+
+func (p *Pump) FuzzyIdentityFields() ([]string, error) {
+	identityFields, err := p.Base.FuzzyIdentityFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.AppendFuzzyIdentityValues(identityFields, p.Units, p.BasalSchedules, p.CarbohydrateRatios, p.InsulinSensitivities, p.BloodGlucoseTargets, p.ActiveSchedule)
+}
@@ -1,17 +1,24 @@
 package upload
 
 import (
+	"strconv"
+
 	"github.com/tidepool-org/platform/app"
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/errors"
 )
 
 type Upload struct {
 	types.Base `bson:",inline"`
 
-	State     string `json:"-" bson:"_state,omitempty"`
-	DataState string `json:"-" bson:"_dataState,omitempty"` // TODO: Deprecated DataState (after data migration)
-	ByUser    string `json:"byUser,omitempty" bson:"byUser,omitempty"`
+	State       string        `json:"-" bson:"_state,omitempty"`
+	DataState   string        `json:"-" bson:"_dataState,omitempty"` // TODO: Deprecated DataState (after data migration)
+	ByUser      string        `json:"byUser,omitempty" bson:"byUser,omitempty"`
+	Transitions []*Transition `json:"-" bson:"_transitions,omitempty"`
+	Journal     *Journal      `json:"-" bson:"_journal,omitempty"`
+
+	RededuplicateJournal *Journal `json:"-" bson:"_rededuplicateJournal,omitempty"`
 
 	ComputerTime        *string   `json:"computerTime,omitempty" bson:"computerTime,omitempty"`
 	DeviceManufacturers *[]string `json:"deviceManufacturers,omitempty" bson:"deviceManufacturers,omitempty"`
@@ -23,6 +30,66 @@ type Upload struct {
 	Version             *string   `json:"version,omitempty" bson:"version,omitempty"`
 }
 
+const (
+	StateOpen    = "open"
+	StateClosing = "closing"
+	StateClosed  = "closed"
+	StateAborted = "aborted"
+)
+
+var _StateTransitions = map[string][]string{
+	StateOpen:    {StateClosing, StateAborted},
+	StateClosing: {StateClosed},
+	StateClosed:  {StateOpen},
+}
+
+type Transition struct {
+	From   string `bson:"from"`
+	To     string `bson:"to"`
+	Time   string `bson:"time"`
+	UserID string `bson:"userId,omitempty"`
+}
+
+func IsStateTransitionValid(from string, to string) bool {
+	for _, state := range _StateTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	JournalOperationDeduplicate   = "deduplicate"
+	JournalOperationRededuplicate = "rededuplicate"
+)
+
+type Journal struct {
+	Operation      string   `bson:"operation"`
+	Steps          []string `bson:"steps"`
+	CompletedSteps int      `bson:"completedSteps"`
+	CreatedTime    string   `bson:"createdTime"`
+}
+
+func NewJournal(operation string, steps []string, createdTime string) *Journal {
+	return &Journal{
+		Operation:   operation,
+		Steps:       steps,
+		CreatedTime: createdTime,
+	}
+}
+
+func (j *Journal) IsCompleted() bool {
+	return j.CompletedSteps >= len(j.Steps)
+}
+
+func (j *Journal) NextStep() string {
+	if j.IsCompleted() {
+		return ""
+	}
+	return j.Steps[j.CompletedSteps]
+}
+
 func Type() string {
 	return "upload"
 }
@@ -46,9 +113,12 @@ func (u *Upload) Init() {
 	u.Type = Type()
 	u.UploadID = app.NewID()
 
-	u.State = "open"
-	u.DataState = "open" // TODO: Deprecated DataState (after data migration)
+	u.State = StateOpen
+	u.DataState = StateOpen // TODO: Deprecated DataState (after data migration)
 	u.ByUser = ""
+	u.Transitions = nil
+	u.Journal = nil
+	u.RededuplicateJournal = nil
 
 	u.ComputerTime = nil
 	u.DeviceManufacturers = nil
@@ -92,7 +162,7 @@ func (u *Upload) Validate(validator data.Validator) error {
 	validator.ValidateStringArray("deviceManufacturers", u.DeviceManufacturers).Exists().NotEmpty()
 	validator.ValidateString("deviceModel", u.DeviceModel).Exists().LengthGreaterThan(1)
 	validator.ValidateString("deviceSerialNumber", u.DeviceSerialNumber).Exists().LengthGreaterThan(1)
-	validator.ValidateStringArray("deviceTags", u.DeviceTags).Exists().NotEmpty().EachOneOf([]string{"insulin-pump", "cgm", "bgm"})
+	validator.ValidateStringArray("deviceTags", u.DeviceTags).Exists().NotEmpty().EachOneOf([]string{"insulin-pump", "insulin-pen", "cgm", "bgm"})
 	validator.ValidateString("timeProcessing", u.TimeProcessing).Exists().OneOf([]string{"across-the-board-timezone", "utc-bootstrapping", "none"})
 	validator.ValidateString("timezone", u.TimeZone).Exists().LengthGreaterThan(1)
 	validator.ValidateString("version", u.Version).Exists().LengthGreaterThanOrEqualTo(5)
@@ -100,6 +170,32 @@ func (u *Upload) Validate(validator data.Validator) error {
 	return nil
 }
 
+func (u *Upload) CurrentState() string {
+	if u.State != "" {
+		return u.State
+	} else if u.DataState != "" { // TODO: Deprecated DataState (after data migration)
+		return u.DataState
+	}
+	return StateOpen
+}
+
+func (u *Upload) TransitionState(state string, userID string, time string) error {
+	currentState := u.CurrentState()
+	if !IsStateTransitionValid(currentState, state) {
+		return errors.Newf("upload", "state transition from %s to %s is not valid", strconv.Quote(currentState), strconv.Quote(state))
+	}
+
+	u.State = state
+	u.DataState = state // TODO: Deprecated DataState (after data migration)
+	u.Transitions = append(u.Transitions, &Transition{
+		From:   currentState,
+		To:     state,
+		Time:   time,
+		UserID: userID,
+	})
+	return nil
+}
+
 func (u *Upload) Normalize(normalizer data.Normalizer) error {
 	normalizer.SetMeta(u.Meta())
 
@@ -3,9 +3,11 @@ package upload_test
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
 
 	testData "github.com/tidepool-org/platform/data/test"
 	"github.com/tidepool-org/platform/data/types"
+	"github.com/tidepool-org/platform/data/types/upload"
 	"github.com/tidepool-org/platform/service"
 )
 
@@ -52,15 +54,16 @@ var _ = Describe("Upload", func() {
 				[]*service.Error{testData.ComposeError(service.ErrorValueEmpty(), "/deviceTags", NewMeta())},
 			),
 			Entry("is not one of the allowed types", NewRawObject(), "deviceTags", []string{"not-valid"},
-				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("not-valid", []string{"insulin-pump", "cgm", "bgm"}), "/deviceTags/0", NewMeta())},
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("not-valid", []string{"insulin-pump", "insulin-pen", "cgm", "bgm"}), "/deviceTags/0", NewMeta())},
 			),
 			Entry("is not one of the allowed types", NewRawObject(), "deviceTags", []string{"bgm", "cgm", "not-valid"},
-				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("not-valid", []string{"insulin-pump", "cgm", "bgm"}), "/deviceTags/2", NewMeta())},
+				[]*service.Error{testData.ComposeError(service.ErrorValueStringNotOneOf("not-valid", []string{"insulin-pump", "insulin-pen", "cgm", "bgm"}), "/deviceTags/2", NewMeta())},
 			),
 		)
 
 		DescribeTable("valid when", testData.ExpectFieldIsValid,
 			Entry("is insulin-pump", NewRawObject(), "deviceTags", []string{"insulin-pump"}),
+			Entry("is insulin-pen", NewRawObject(), "deviceTags", []string{"insulin-pen"}),
 			Entry("is cgm", NewRawObject(), "deviceTags", []string{"cgm"}),
 			Entry("is bgm", NewRawObject(), "deviceTags", []string{"bgm"}),
 			Entry("is multiple", NewRawObject(), "deviceTags", []string{"bgm", "cgm", "insulin-pump"}),
@@ -161,4 +164,78 @@ var _ = Describe("Upload", func() {
 			Entry("is across-the-board-timezone", NewRawObject(), "timeProcessing", "across-the-board-timezone"),
 		)
 	})
+
+	Context("CurrentState", func() {
+		It("returns the state if set", func() {
+			testUpload := upload.Init()
+			testUpload.State = upload.StateClosed
+			Expect(testUpload.CurrentState()).To(Equal(upload.StateClosed))
+		})
+
+		It("returns the data state if the state is not set", func() {
+			testUpload := upload.Init()
+			testUpload.State = ""
+			testUpload.DataState = upload.StateClosed
+			Expect(testUpload.CurrentState()).To(Equal(upload.StateClosed))
+		})
+
+		It("returns open if neither state is set", func() {
+			Expect(upload.New().CurrentState()).To(Equal(upload.StateOpen))
+		})
+	})
+
+	DescribeTable("IsStateTransitionValid",
+		func(from string, to string, expected bool) {
+			Expect(upload.IsStateTransitionValid(from, to)).To(Equal(expected))
+		},
+		Entry("is open to closing", upload.StateOpen, upload.StateClosing, true),
+		Entry("is open to aborted", upload.StateOpen, upload.StateAborted, true),
+		Entry("is open to closed", upload.StateOpen, upload.StateClosed, false),
+		Entry("is closing to closed", upload.StateClosing, upload.StateClosed, true),
+		Entry("is closing to aborted", upload.StateClosing, upload.StateAborted, false),
+		Entry("is closed to open", upload.StateClosed, upload.StateOpen, true),
+		Entry("is closed to aborted", upload.StateClosed, upload.StateAborted, false),
+		Entry("is aborted to open", upload.StateAborted, upload.StateOpen, false),
+		Entry("is unknown to open", "unknown", upload.StateOpen, false),
+	)
+
+	Context("TransitionState", func() {
+		It("transitions the state and records the transition", func() {
+			testUpload := upload.Init()
+			Expect(testUpload.TransitionState(upload.StateClosing, "user-one", "2017-09-01T12:00:00Z")).To(Succeed())
+			Expect(testUpload.State).To(Equal(upload.StateClosing))
+			Expect(testUpload.DataState).To(Equal(upload.StateClosing))
+			Expect(testUpload.Transitions).To(Equal([]*upload.Transition{{From: upload.StateOpen, To: upload.StateClosing, Time: "2017-09-01T12:00:00Z", UserID: "user-one"}}))
+		})
+
+		It("returns an error if the transition is not valid", func() {
+			testUpload := upload.Init()
+			Expect(testUpload.TransitionState(upload.StateClosed, "user-one", "2017-09-01T12:00:00Z")).To(MatchError(`upload: state transition from "open" to "closed" is not valid`))
+			Expect(testUpload.State).To(Equal(upload.StateOpen))
+			Expect(testUpload.Transitions).To(BeEmpty())
+		})
+	})
+
+	Context("Journal", func() {
+		It("returns a new journal", func() {
+			journal := upload.NewJournal(upload.JournalOperationDeduplicate, []string{"step-one", "step-two"}, "2017-09-01T12:00:00Z")
+			Expect(journal).To(Equal(&upload.Journal{Operation: upload.JournalOperationDeduplicate, Steps: []string{"step-one", "step-two"}, CreatedTime: "2017-09-01T12:00:00Z"}))
+			Expect(journal.IsCompleted()).To(BeFalse())
+			Expect(journal.NextStep()).To(Equal("step-one"))
+		})
+
+		It("returns the next step after completed steps", func() {
+			journal := upload.NewJournal(upload.JournalOperationDeduplicate, []string{"step-one", "step-two"}, "2017-09-01T12:00:00Z")
+			journal.CompletedSteps = 1
+			Expect(journal.IsCompleted()).To(BeFalse())
+			Expect(journal.NextStep()).To(Equal("step-two"))
+		})
+
+		It("returns no next step if all steps are completed", func() {
+			journal := upload.NewJournal(upload.JournalOperationDeduplicate, []string{"step-one", "step-two"}, "2017-09-01T12:00:00Z")
+			journal.CompletedSteps = 2
+			Expect(journal.IsCompleted()).To(BeTrue())
+			Expect(journal.NextStep()).To(BeEmpty())
+		})
+	})
 })
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"io"
+
 	"github.com/ant0ine/go-json-rest/rest"
 
 	"github.com/tidepool-org/platform/log"
@@ -15,4 +17,7 @@ type Context interface {
 
 type Client interface {
 	DestroyDataForUserByID(context Context, userID string) error
+	LockDataForUserByID(context Context, userID string) error
+	UnlockDataForUserByID(context Context, userID string) error
+	StreamDataForUserByID(context Context, userID string, writer io.Writer) error
 }
@@ -1,8 +1,11 @@
 package client
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,8 +14,9 @@ import (
 )
 
 type Standard struct {
-	config     *Config
-	httpClient *http.Client
+	config           *Config
+	httpClient       *http.Client
+	streamHTTPClient *http.Client
 }
 
 const TidepoolAuthenticationTokenHeaderName = "X-Tidepool-Session-Token"
@@ -31,9 +35,17 @@ func NewStandard(config *Config) (*Standard, error) {
 		Timeout: time.Duration(config.RequestTimeout) * time.Second,
 	}
 
+	// Streamed responses may take arbitrarily long to read, so only the response header is time limited
+	streamHTTPTransport := http.DefaultTransport.(*http.Transport).Clone()
+	streamHTTPTransport.ResponseHeaderTimeout = time.Duration(config.RequestTimeout) * time.Second
+	streamHTTPClient := &http.Client{
+		Transport: streamHTTPTransport,
+	}
+
 	return &Standard{
-		config:     config,
-		httpClient: httpClient,
+		config:           config,
+		httpClient:       httpClient,
+		streamHTTPClient: streamHTTPClient,
 	}, nil
 }
 
@@ -47,42 +59,156 @@ func (s *Standard) DestroyDataForUserByID(context Context, userID string) error
 
 	context.Logger().WithField("userId", userID).Debug("Deleting data for user")
 
-	return s.sendRequest(context, "DELETE", s.buildURL("dataservices", "v1", "users", userID, "data"))
+	request, err := s.newRequest(context, "DELETE", s.buildURL("dataservices", "v1", "users", userID, "data"))
+	if err != nil {
+		return err
+	}
+
+	response, err := s.sendRequest(s.httpClient, request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+func (s *Standard) LockDataForUserByID(context Context, userID string) error {
+	if context == nil {
+		return errors.New("client", "context is missing")
+	}
+	if userID == "" {
+		return errors.New("client", "user id is missing")
+	}
+
+	context.Logger().WithField("userId", userID).Debug("Locking data for user")
+
+	request, err := s.newRequest(context, "PUT", s.buildURL("dataservices", "v1", "users", userID, "data", "lock"))
+	if err != nil {
+		return err
+	}
+
+	response, err := s.sendRequest(s.httpClient, request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+func (s *Standard) UnlockDataForUserByID(context Context, userID string) error {
+	if context == nil {
+		return errors.New("client", "context is missing")
+	}
+	if userID == "" {
+		return errors.New("client", "user id is missing")
+	}
+
+	context.Logger().WithField("userId", userID).Debug("Unlocking data for user")
+
+	request, err := s.newRequest(context, "DELETE", s.buildURL("dataservices", "v1", "users", userID, "data", "lock"))
+	if err != nil {
+		return err
+	}
+
+	response, err := s.sendRequest(s.httpClient, request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
 }
 
-func (s *Standard) sendRequest(context Context, requestMethod string, requestURL string) error {
+func (s *Standard) StreamDataForUserByID(context Context, userID string, writer io.Writer) error {
+	if context == nil {
+		return errors.New("client", "context is missing")
+	}
+	if userID == "" {
+		return errors.New("client", "user id is missing")
+	}
+	if writer == nil {
+		return errors.New("client", "writer is missing")
+	}
+
+	context.Logger().WithField("userId", userID).Debug("Streaming data for user")
+
+	request, err := s.newRequest(context, "GET", s.buildURL("dataservices", "v1", "users", userID, "data", "export"))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Add("Accept", service.MediaTypeNDJSON)
+
+	response, err := s.sendRequest(s.streamHTTPClient, request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	countWriter := &lineCountWriter{writer: writer}
+	if _, err = io.Copy(countWriter, response.Body); err != nil {
+		return errors.Wrapf(err, "client", "unable to copy response from %s %s", request.Method, request.URL)
+	}
+
+	// The count trailer is only sent once the entire stream is written, so a missing or mismatched count means truncated
+	if count := response.Trailer.Get(service.StreamCountTrailer); count != strconv.Itoa(countWriter.count) {
+		return errors.Newf("client", "incomplete response from %s %s", request.Method, request.URL)
+	}
+
+	return nil
+}
+
+func (s *Standard) newRequest(context Context, requestMethod string, requestURL string) (*http.Request, error) {
 	request, err := http.NewRequest(requestMethod, requestURL, nil)
 	if err != nil {
-		return errors.Wrapf(err, "client", "unable to create new request for %s %s", requestMethod, requestURL)
+		return nil, errors.Wrapf(err, "client", "unable to create new request for %s %s", requestMethod, requestURL)
 	}
 
 	if err = service.CopyRequestTrace(context.Request(), request); err != nil {
-		return errors.Wrapf(err, "client", "unable to copy request trace")
+		return nil, errors.Wrapf(err, "client", "unable to copy request trace")
 	}
 
 	serverToken, err := context.UserServicesClient().ServerToken()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	request.Header.Add(TidepoolAuthenticationTokenHeaderName, serverToken)
 
-	response, err := s.httpClient.Do(request)
+	return request, nil
+}
+
+func (s *Standard) sendRequest(httpClient *http.Client, request *http.Request) (*http.Response, error) {
+	response, err := httpClient.Do(request)
 	if err != nil {
-		return errors.Wrapf(err, "client", "unable to perform request %s %s", requestMethod, requestURL)
+		return nil, errors.Wrapf(err, "client", "unable to perform request %s %s", request.Method, request.URL)
 	}
-	defer response.Body.Close()
 
 	switch response.StatusCode {
 	case http.StatusOK:
-		return nil
+		return response, nil
 	case http.StatusUnauthorized:
-		return errors.New("client", "unauthorized")
+		response.Body.Close()
+		return nil, errors.New("client", "unauthorized")
 	default:
-		return errors.New("client", fmt.Sprintf("unexpected response status code %d from %s %s", response.StatusCode, requestMethod, requestURL))
+		response.Body.Close()
+		return nil, errors.New("client", fmt.Sprintf("unexpected response status code %d from %s %s", response.StatusCode, request.Method, request.URL))
 	}
 }
 
 func (s *Standard) buildURL(paths ...string) string {
 	return strings.Join(append([]string{s.config.Address}, paths...), "/")
 }
+
+type lineCountWriter struct {
+	writer io.Writer
+	count  int
+}
+
+func (l *lineCountWriter) Write(data []byte) (int, error) {
+	count, err := l.writer.Write(data)
+	l.count += bytes.Count(data[:count], []byte{'\n'})
+	return count, err
+}
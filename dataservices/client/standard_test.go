@@ -1,6 +1,7 @@
 package client_test
 
 import (
+	"bytes"
 	"errors"
 	"net/http"
 
@@ -238,5 +239,307 @@ var _ = Describe("Standard", func() {
 				})
 			})
 		})
+
+		Context("LockDataForUserByID", func() {
+			It("returns error if context is missing", func() {
+				context.TestUserServicesClient.ServerTokenOutputs = nil
+				Expect(standard.LockDataForUserByID(nil, "test-user-id")).To(MatchError("client: context is missing"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if user id is missing", func() {
+				context.TestUserServicesClient.ServerTokenOutputs = nil
+				Expect(standard.LockDataForUserByID(context, "")).To(MatchError("client: user id is missing"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if the context request is missing", func() {
+				context.TestRequest = nil
+				context.TestUserServicesClient.ServerTokenOutputs = nil
+				Expect(standard.LockDataForUserByID(context, "test-user-id")).To(MatchError("client: unable to copy request trace; service: source request is missing"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if the user services client server token returns an error", func() {
+				err := errors.New("test-error")
+				context.TestUserServicesClient.ServerTokenOutputs = []ServerTokenOutput{{"", err}}
+				Expect(standard.LockDataForUserByID(context, "test-user-id")).To(Equal(err))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if the server is not reachable", func() {
+				server.Close()
+				server = nil
+				err := standard.LockDataForUserByID(context, "test-user-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("client: unable to perform request PUT "))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			Context("with an unexpected response", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("PUT", "/dataservices/v1/users/test-user-id/data/lock"),
+							ghttp.VerifyHeaderKV("X-Tidepool-Session-Token", "test-server-token"),
+							ghttp.VerifyBody([]byte{}),
+							ghttp.RespondWith(http.StatusBadRequest, nil, nil)),
+					)
+				})
+
+				It("returns an error", func() {
+					err := standard.LockDataForUserByID(context, "test-user-id")
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(HavePrefix("client: unexpected response status code 400 from PUT "))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+
+			Context("with an unauthorized response", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("PUT", "/dataservices/v1/users/test-user-id/data/lock"),
+							ghttp.VerifyHeaderKV("X-Tidepool-Session-Token", "test-server-token"),
+							ghttp.VerifyBody([]byte{}),
+							ghttp.RespondWith(http.StatusUnauthorized, nil, nil)),
+					)
+				})
+
+				It("returns an error", func() {
+					err := standard.LockDataForUserByID(context, "test-user-id")
+					Expect(err).To(MatchError("client: unauthorized"))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+
+			Context("with a successful response", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("PUT", "/dataservices/v1/users/test-user-id/data/lock"),
+							ghttp.VerifyHeaderKV("X-Tidepool-Session-Token", "test-server-token"),
+							ghttp.VerifyBody([]byte{}),
+							ghttp.RespondWith(http.StatusOK, nil, nil)),
+					)
+				})
+
+				It("returns success", func() {
+					Expect(standard.LockDataForUserByID(context, "test-user-id")).To(Succeed())
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+		})
+
+		Context("UnlockDataForUserByID", func() {
+			It("returns error if context is missing", func() {
+				context.TestUserServicesClient.ServerTokenOutputs = nil
+				Expect(standard.UnlockDataForUserByID(nil, "test-user-id")).To(MatchError("client: context is missing"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if user id is missing", func() {
+				context.TestUserServicesClient.ServerTokenOutputs = nil
+				Expect(standard.UnlockDataForUserByID(context, "")).To(MatchError("client: user id is missing"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if the context request is missing", func() {
+				context.TestRequest = nil
+				context.TestUserServicesClient.ServerTokenOutputs = nil
+				Expect(standard.UnlockDataForUserByID(context, "test-user-id")).To(MatchError("client: unable to copy request trace; service: source request is missing"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if the user services client server token returns an error", func() {
+				err := errors.New("test-error")
+				context.TestUserServicesClient.ServerTokenOutputs = []ServerTokenOutput{{"", err}}
+				Expect(standard.UnlockDataForUserByID(context, "test-user-id")).To(Equal(err))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if the server is not reachable", func() {
+				server.Close()
+				server = nil
+				err := standard.UnlockDataForUserByID(context, "test-user-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("client: unable to perform request DELETE "))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			Context("with an unexpected response", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("DELETE", "/dataservices/v1/users/test-user-id/data/lock"),
+							ghttp.VerifyHeaderKV("X-Tidepool-Session-Token", "test-server-token"),
+							ghttp.VerifyBody([]byte{}),
+							ghttp.RespondWith(http.StatusBadRequest, nil, nil)),
+					)
+				})
+
+				It("returns an error", func() {
+					err := standard.UnlockDataForUserByID(context, "test-user-id")
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(HavePrefix("client: unexpected response status code 400 from DELETE "))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+
+			Context("with an unauthorized response", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("DELETE", "/dataservices/v1/users/test-user-id/data/lock"),
+							ghttp.VerifyHeaderKV("X-Tidepool-Session-Token", "test-server-token"),
+							ghttp.VerifyBody([]byte{}),
+							ghttp.RespondWith(http.StatusUnauthorized, nil, nil)),
+					)
+				})
+
+				It("returns an error", func() {
+					err := standard.UnlockDataForUserByID(context, "test-user-id")
+					Expect(err).To(MatchError("client: unauthorized"))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+
+			Context("with a successful response", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("DELETE", "/dataservices/v1/users/test-user-id/data/lock"),
+							ghttp.VerifyHeaderKV("X-Tidepool-Session-Token", "test-server-token"),
+							ghttp.VerifyBody([]byte{}),
+							ghttp.RespondWith(http.StatusOK, nil, nil)),
+					)
+				})
+
+				It("returns success", func() {
+					Expect(standard.UnlockDataForUserByID(context, "test-user-id")).To(Succeed())
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+		})
+
+		Context("StreamDataForUserByID", func() {
+			var writer *bytes.Buffer
+
+			BeforeEach(func() {
+				writer = &bytes.Buffer{}
+			})
+
+			It("returns error if context is missing", func() {
+				context.TestUserServicesClient.ServerTokenOutputs = nil
+				Expect(standard.StreamDataForUserByID(nil, "test-user-id", writer)).To(MatchError("client: context is missing"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if user id is missing", func() {
+				context.TestUserServicesClient.ServerTokenOutputs = nil
+				Expect(standard.StreamDataForUserByID(context, "", writer)).To(MatchError("client: user id is missing"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if writer is missing", func() {
+				context.TestUserServicesClient.ServerTokenOutputs = nil
+				Expect(standard.StreamDataForUserByID(context, "test-user-id", nil)).To(MatchError("client: writer is missing"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("returns error if the user services client server token returns an error", func() {
+				err := errors.New("test-error")
+				context.TestUserServicesClient.ServerTokenOutputs = []ServerTokenOutput{{"", err}}
+				Expect(standard.StreamDataForUserByID(context, "test-user-id", writer)).To(Equal(err))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			Context("with an unauthorized response", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/dataservices/v1/users/test-user-id/data/export"),
+							ghttp.VerifyHeaderKV("X-Tidepool-Session-Token", "test-server-token"),
+							ghttp.RespondWith(http.StatusUnauthorized, nil, nil)),
+					)
+				})
+
+				It("returns an error", func() {
+					Expect(standard.StreamDataForUserByID(context, "test-user-id", writer)).To(MatchError("client: unauthorized"))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(writer.Len()).To(Equal(0))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+
+			Context("with a successful response", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/dataservices/v1/users/test-user-id/data/export"),
+							ghttp.VerifyHeaderKV("X-Tidepool-Session-Token", "test-server-token"),
+							ghttp.VerifyHeaderKV("Accept", "application/x-ndjson"),
+							ghttp.RespondWith(http.StatusOK, "{\"id\":\"one\"}\n{\"id\":\"two\"}\n", http.Header{http.TrailerPrefix + "X-Tidepool-Stream-Count": []string{"2"}})),
+					)
+				})
+
+				It("returns success and writes the streamed data", func() {
+					Expect(standard.StreamDataForUserByID(context, "test-user-id", writer)).To(Succeed())
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(writer.String()).To(Equal("{\"id\":\"one\"}\n{\"id\":\"two\"}\n"))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+
+			Context("with a successful response without a stream count", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/dataservices/v1/users/test-user-id/data/export"),
+							ghttp.RespondWith(http.StatusOK, "{\"id\":\"one\"}\n", nil)),
+					)
+				})
+
+				It("returns an error", func() {
+					Expect(standard.StreamDataForUserByID(context, "test-user-id", writer)).To(MatchError(HavePrefix("client: incomplete response from GET ")))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+
+			Context("with a successful response with a mismatched stream count", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/dataservices/v1/users/test-user-id/data/export"),
+							ghttp.RespondWith(http.StatusOK, "{\"id\":\"one\"}\n", http.Header{http.TrailerPrefix + "X-Tidepool-Stream-Count": []string{"2"}})),
+					)
+				})
+
+				It("returns an error", func() {
+					Expect(standard.StreamDataForUserByID(context, "test-user-id", writer)).To(MatchError(HavePrefix("client: incomplete response from GET ")))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(context.ValidateTest()).To(BeTrue())
+				})
+			})
+		})
 	})
 })
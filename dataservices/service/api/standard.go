@@ -10,6 +10,7 @@ import (
 	"github.com/tidepool-org/platform/dataservices/service/context"
 	"github.com/tidepool-org/platform/environment"
 	"github.com/tidepool-org/platform/errors"
+	lockStore "github.com/tidepool-org/platform/lock/store"
 	"github.com/tidepool-org/platform/log"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
 	"github.com/tidepool-org/platform/service/api"
@@ -25,13 +26,15 @@ type Standard struct {
 	dataFactory             data.Factory
 	dataDeduplicatorFactory deduplicator.Factory
 	dataStore               dataStore.Store
+	syncTaskStore           taskStore.Store
 	taskStore               taskStore.Store
+	lockStore               lockStore.Store
 }
 
 func NewStandard(versionReporter version.Reporter, environmentReporter environment.Reporter, logger log.Logger,
 	metricServicesClient metricservicesClient.Client, userServicesClient userservicesClient.Client,
 	dataFactory data.Factory, dataDeduplicatorFactory deduplicator.Factory,
-	dataStore dataStore.Store, taskStore taskStore.Store) (*Standard, error) {
+	dataStore dataStore.Store, syncTaskStore taskStore.Store, taskStore taskStore.Store, lockStore lockStore.Store) (*Standard, error) {
 	if versionReporter == nil {
 		return nil, errors.New("api", "version reporter is missing")
 	}
@@ -56,9 +59,15 @@ func NewStandard(versionReporter version.Reporter, environmentReporter environme
 	if dataStore == nil {
 		return nil, errors.New("api", "data store is missing")
 	}
+	if syncTaskStore == nil {
+		return nil, errors.New("api", "sync task store is missing")
+	}
 	if taskStore == nil {
 		return nil, errors.New("api", "task store is missing")
 	}
+	if lockStore == nil {
+		return nil, errors.New("api", "lock store is missing")
+	}
 
 	standard, err := api.NewStandard(versionReporter, environmentReporter, logger)
 	if err != nil {
@@ -72,7 +81,9 @@ func NewStandard(versionReporter version.Reporter, environmentReporter environme
 		dataFactory:             dataFactory,
 		dataDeduplicatorFactory: dataDeduplicatorFactory,
 		dataStore:               dataStore,
+		syncTaskStore:           syncTaskStore,
 		taskStore:               taskStore,
+		lockStore:               lockStore,
 	}, nil
 }
 
@@ -106,5 +117,5 @@ func (s *Standard) InitializeRouter(routes []service.Route) error {
 func (s *Standard) withContext(handler service.HandlerFunc) rest.HandlerFunc {
 	return context.WithContext(s.metricServicesClient, s.userServicesClient,
 		s.dataFactory, s.dataDeduplicatorFactory,
-		s.dataStore, s.taskStore, handler)
+		s.dataStore, s.syncTaskStore, s.taskStore, s.lockStore, handler)
 }
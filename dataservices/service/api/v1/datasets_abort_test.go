@@ -0,0 +1,212 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	lockStore "github.com/tidepool-org/platform/lock/store"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+var _ = Describe("DatasetsAbort", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var targetUpload *upload.Upload
+		var context *TestContext
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			targetUpload = upload.Init()
+			targetUpload.UserID = targetUserID
+			context = NewTestContext()
+			context.RequestImpl.PathParams["datasetid"] = targetUpload.UploadID
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: targetUpload, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID, authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.UploadPermission: client.Permission{}}, nil}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: nil, Error: nil}}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{{Transitioned: true, Error: nil}}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{nil}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if authenticated as user with upload permission", func() {
+			v1.DatasetsAbort(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.DataStoreSessionImpl.TransitionDatasetStateInputs).To(Equal([]testDataStore.TransitionDatasetStateInput{{Dataset: targetUpload, State: upload.StateAborted, UserID: authenticatedUserID}}))
+			Expect(context.DataStoreSessionImpl.DeleteInactiveDatasetDataInputs).To(Equal([]*upload.Upload{targetUpload}))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "datasets_abort", nil}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetUpload}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{""}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			v1.DatasetsAbort(context)
+			Expect(context.DataStoreSessionImpl.TransitionDatasetStateInputs).To(Equal([]testDataStore.TransitionDatasetStateInput{{Dataset: targetUpload, State: upload.StateAborted, UserID: ""}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetUpload}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if dataset id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "datasetid")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: err}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get dataset by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns no dataset", func() {
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDNotFound(targetUpload.UploadID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions returns unauthorized error", func() {
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, client.NewUnauthorizedError()}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions returns any other error", func() {
+			err := errors.New("other")
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, err}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get user permissions", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions does not return needed permissions", func() {
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.ViewPermission: client.Permission{}}, nil}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user data is locked", func() {
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: &lockStore.Lock{UserID: targetUserID}, Error: nil}}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.LockStoreSessionImpl.GetLockForUserByIDInputs).To(Equal([]string{targetUserID}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDataLocked(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if lock store session get lock for user by id returns error", func() {
+			err := errors.New("other")
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: nil, Error: err}}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get lock for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the dataset is closed", func() {
+			targetUpload.State = upload.StateClosed
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(targetUpload.State).To(Equal(upload.StateClosed))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetStateTransitionNotValid(targetUpload.UploadID, upload.StateClosed, upload.StateAborted)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session transition dataset state returns an error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{{Transitioned: false, Error: err}}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to transition dataset state", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the dataset state is changed concurrently", func() {
+			context.DataStoreSessionImpl.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{{Transitioned: false, Error: nil}}
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetStateTransitionNotValid(targetUpload.UploadID, upload.StateOpen, upload.StateAborted)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session delete inactive dataset data returns an error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.DeleteInactiveDatasetDataOutputs = []error{err}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsAbort(context)
+			Expect(context.DataStoreSessionImpl.DeleteInactiveDatasetDataInputs).To(Equal([]*upload.Upload{targetUpload}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to delete inactive dataset data", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
@@ -1,19 +1,43 @@
 package v1
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/context"
+	dataDeduplicator "github.com/tidepool-org/platform/data/deduplicator"
 	"github.com/tidepool-org/platform/data/normalizer"
 	"github.com/tidepool-org/platform/data/parser"
+	"github.com/tidepool-org/platform/data/store"
+	"github.com/tidepool-org/platform/data/types/upload"
 	"github.com/tidepool-org/platform/data/validator"
 	"github.com/tidepool-org/platform/dataservices/service"
 	commonService "github.com/tidepool-org/platform/service"
 	"github.com/tidepool-org/platform/userservices/client"
 )
 
+const (
+	ParameterPartialSuccess = "partialSuccess"
+	HeaderPartialSuccess    = "X-Tidepool-Partial-Success"
+	HeaderIdempotencyKey    = "Idempotency-Key"
+
+	IdempotencyKeyLengthMaximum = 100
+
+	DatumResultStatusAccepted  = "accepted"
+	DatumResultStatusRejected  = "rejected"
+	DatumResultStatusDuplicate = "duplicate"
+)
+
+type DatumResult struct {
+	Index  int                    `json:"index"`
+	Status string                 `json:"status"`
+	Errors []*commonService.Error `json:"errors,omitempty"`
+}
+
 func DatasetsDataCreate(serviceContext service.Context) {
 	datasetID := serviceContext.Request().PathParam("datasetid")
 	if datasetID == "" {
@@ -48,11 +72,62 @@ func DatasetsDataCreate(serviceContext service.Context) {
 		}
 	}
 
-	if dataset.State == "closed" || dataset.DataState == "closed" { // TODO: Deprecated DataState (after data migration)
+	if respondIfUserDataLocked(serviceContext, dataset.UserID) {
+		return
+	}
+
+	chunkID := serviceContext.Request().Header.Get(HeaderIdempotencyKey)
+	if length := len(chunkID); length > IdempotencyKeyLengthMaximum {
+		serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, []*commonService.Error{commonService.ErrorLengthNotLessThanOrEqualTo(length, IdempotencyKeyLengthMaximum).WithSourceHeader(HeaderIdempotencyKey)})
+		return
+	} else if chunkID != "" {
+		var chunk *store.DatasetChunk
+		if chunk, err = serviceContext.DataStoreSession().GetDatasetChunk(dataset, chunkID); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to get dataset chunk", err)
+			return
+		} else if chunk != nil {
+			if chunk.State == store.DatasetChunkStateCompleted {
+				serviceContext.RespondWithStatusAndData(http.StatusOK, json.RawMessage(chunk.Response))
+				return
+			} else if chunk.IsInProgress(time.Now()) {
+				serviceContext.RespondWithError(ErrorDatasetChunkInProgress(datasetID, chunkID))
+				return
+			}
+		}
+	}
+
+	if dataset.CurrentState() != upload.StateOpen {
 		serviceContext.RespondWithError(ErrorDatasetClosed(datasetID))
 		return
 	}
 
+	partialSuccess := false
+
+	var errors []*commonService.Error
+	if value := serviceContext.Request().Header.Get(HeaderPartialSuccess); value != "" {
+		if parsedValue, err := strconv.ParseBool(value); err != nil {
+			errors = append(errors, commonService.ErrorTypeNotBoolean(value).WithSourceHeader(HeaderPartialSuccess))
+		} else {
+			partialSuccess = parsedValue
+		}
+	}
+	for key, values := range serviceContext.Request().URL.Query() {
+		for _, value := range values {
+			switch key {
+			case ParameterPartialSuccess:
+				if parsedValue, err := strconv.ParseBool(value); err != nil {
+					errors = append(errors, commonService.ErrorTypeNotBoolean(value).WithSourceParameter(ParameterPartialSuccess))
+				} else {
+					partialSuccess = parsedValue
+				}
+			}
+		}
+	}
+	if len(errors) > 0 {
+		serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, errors)
+		return
+	}
+
 	deduplicator, err := serviceContext.DataDeduplicatorFactory().NewRegisteredDeduplicatorForDataset(serviceContext.Logger(), serviceContext.DataStoreSession(), dataset)
 	if err != nil {
 		serviceContext.RespondWithInternalServerFailure("Unable to create registered deduplicator for dataset", err)
@@ -83,40 +158,92 @@ func DatasetsDataCreate(serviceContext service.Context) {
 		return
 	}
 
-	datumNormalizer, err := normalizer.NewStandard(datumArrayContext)
-	if err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to create datum normalizer", err)
-		return
-	}
-
 	datumArray := []data.Datum{}
+	datumIndexes := []int{}
 	for index := range *datumArrayParser.Array() {
 		if datum := datumArrayParser.ParseDatum(index); datum != nil && *datum != nil {
 			(*datum).Validate(datumValidator.NewChildValidator(index))
 			datumArray = append(datumArray, *datum)
+			datumIndexes = append(datumIndexes, index)
 		}
 	}
 
 	datumArrayParser.ProcessNotParsed()
 
-	if errors := datumArrayContext.Errors(); len(errors) > 0 {
-		serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, errors)
-		return
+	var datumResults []*DatumResult
+	if errors = datumArrayContext.Errors(); len(errors) > 0 || partialSuccess {
+		if !partialSuccess {
+			serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, errors)
+			return
+		}
+
+		var ok bool
+		if datumArray, datumIndexes, datumResults, ok = partitionDatumArray(len(*datumArrayParser.Array()), datumArray, datumIndexes, errors); !ok {
+			serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, errors)
+			return
+		}
 	}
 
+	normalizedDatumArrays := [][]data.Datum{}
 	for _, datum := range datumArray {
+		var datumNormalizer *normalizer.Standard
+		if datumNormalizer, err = normalizer.NewStandard(datumArrayContext); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to create datum normalizer", err)
+			return
+		}
 		datum.Normalize(datumNormalizer)
+		normalizedDatumArrays = append(normalizedDatumArrays, datumNormalizer.Data())
 	}
 
-	datumArray = append(datumArray, datumNormalizer.Data()...)
+	for position, datum := range datumArray {
+		for _, storedDatum := range append([]data.Datum{datum}, normalizedDatumArrays[position]...) {
+			storedDatum.SetUserID(dataset.UserID)
+			storedDatum.SetGroupID(dataset.GroupID)
+			storedDatum.SetDatasetID(dataset.UploadID)
+		}
+	}
 
-	for _, datum := range datumArray {
-		datum.SetUserID(dataset.UserID)
-		datum.SetGroupID(dataset.GroupID)
-		datum.SetDatasetID(dataset.UploadID)
+	if partialSuccess {
+		var uniquePositions []int
+		if uniquePositions, err = deduplicateDatumArray(serviceContext.DataStoreSession(), dataset, datumArray, datumIndexes, datumResults); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to deduplicate datum array", err)
+			return
+		}
+
+		uniqueDatumArray := []data.Datum{}
+		uniqueNormalizedDatumArrays := [][]data.Datum{}
+		for _, position := range uniquePositions {
+			uniqueDatumArray = append(uniqueDatumArray, datumArray[position])
+			uniqueNormalizedDatumArrays = append(uniqueNormalizedDatumArrays, normalizedDatumArrays[position])
+		}
+		datumArray = uniqueDatumArray
+		normalizedDatumArrays = uniqueNormalizedDatumArrays
+	}
+
+	for _, normalizedDatumArray := range normalizedDatumArrays {
+		datumArray = append(datumArray, normalizedDatumArray...)
+	}
+
+	if chunkID != "" {
+		datumIDs := []string{}
+		for _, datum := range datumArray {
+			datumIDs = append(datumIDs, datum.DatumID())
+		}
+
+		var created bool
+		if created, err = serviceContext.DataStoreSession().CreateDatasetChunk(dataset, chunkID, datumIDs); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to create dataset chunk", err)
+			return
+		} else if !created {
+			serviceContext.RespondWithError(ErrorDatasetChunkInProgress(datasetID, chunkID))
+			return
+		}
 	}
 
 	if err = deduplicator.AddDatasetData(datumArray); err != nil {
+		if chunkID != "" {
+			failDatasetChunk(serviceContext, dataset, chunkID)
+		}
 		serviceContext.RespondWithInternalServerFailure("Unable to add dataset data", err)
 		return
 	}
@@ -125,5 +252,115 @@ func DatasetsDataCreate(serviceContext service.Context) {
 		serviceContext.Logger().WithError(err).Error("Unable to record metric")
 	}
 
-	serviceContext.RespondWithStatusAndData(http.StatusOK, []struct{}{})
+	var responseData interface{} = []struct{}{}
+	if partialSuccess {
+		responseData = datumResults
+	}
+
+	if chunkID != "" {
+		var response []byte
+		if response, err = json.Marshal(responseData); err != nil {
+			failDatasetChunk(serviceContext, dataset, chunkID)
+			serviceContext.RespondWithInternalServerFailure("Unable to marshal dataset chunk response", err)
+			return
+		} else if err = serviceContext.DataStoreSession().CompleteDatasetChunk(dataset, chunkID, response); err != nil {
+			failDatasetChunk(serviceContext, dataset, chunkID)
+			serviceContext.RespondWithInternalServerFailure("Unable to complete dataset chunk", err)
+			return
+		}
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, responseData)
+}
+
+// A failed chunk records the ids of the data it may have written, so a retry of the chunk removes that
+// data before writing it again
+func failDatasetChunk(serviceContext service.Context, dataset *upload.Upload, chunkID string) {
+	if err := serviceContext.DataStoreSession().FailDatasetChunk(dataset, chunkID); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to fail dataset chunk")
+	}
+}
+
+func partitionDatumArray(length int, datumArray []data.Datum, datumIndexes []int, errors []*commonService.Error) ([]data.Datum, []int, []*DatumResult, bool) {
+	datumResults := make([]*DatumResult, length)
+	for index := range datumResults {
+		datumResults[index] = &DatumResult{Index: index, Status: DatumResultStatusRejected}
+	}
+
+	for _, err := range errors {
+		index, ok := datumIndexFromError(err)
+		if !ok || index >= length {
+			return nil, nil, nil, false
+		}
+		datumResults[index].Errors = append(datumResults[index].Errors, err)
+	}
+
+	acceptedDatumArray := []data.Datum{}
+	acceptedDatumIndexes := []int{}
+	for position, datum := range datumArray {
+		datumResult := datumResults[datumIndexes[position]]
+		if len(datumResult.Errors) > 0 {
+			continue
+		}
+
+		datumResult.Status = DatumResultStatusAccepted
+		acceptedDatumArray = append(acceptedDatumArray, datum)
+		acceptedDatumIndexes = append(acceptedDatumIndexes, datumIndexes[position])
+	}
+
+	return acceptedDatumArray, acceptedDatumIndexes, datumResults, true
+}
+
+func deduplicateDatumArray(dataStoreSession store.Session, dataset *upload.Upload, datumArray []data.Datum, datumIndexes []int, datumResults []*DatumResult) ([]int, error) {
+	if len(datumArray) == 0 {
+		return []int{}, nil
+	}
+
+	identityHashes := []string{}
+	for _, datum := range datumArray {
+		identityFields, err := datum.IdentityFields()
+		if err != nil {
+			return nil, err
+		}
+		identityHash, err := dataDeduplicator.GenerateIdentityHash(identityFields)
+		if err != nil {
+			return nil, err
+		}
+		identityHashes = append(identityHashes, identityHash)
+	}
+
+	existingIdentityHashes, err := dataStoreSession.GetDatasetDataDeduplicatorHashes(dataset, identityHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicateIdentityHashes := map[string]bool{}
+	for _, existingIdentityHash := range existingIdentityHashes {
+		duplicateIdentityHashes[existingIdentityHash] = true
+	}
+
+	uniquePositions := []int{}
+	for position := range datumArray {
+		if identityHash := identityHashes[position]; duplicateIdentityHashes[identityHash] {
+			datumResults[datumIndexes[position]].Status = DatumResultStatusDuplicate
+		} else {
+			duplicateIdentityHashes[identityHash] = true
+			uniquePositions = append(uniquePositions, position)
+		}
+	}
+
+	return uniquePositions, nil
+}
+
+func datumIndexFromError(err *commonService.Error) (int, bool) {
+	if err == nil || err.Source == nil || !strings.HasPrefix(err.Source.Pointer, "/") {
+		return 0, false
+	}
+
+	index, parseErr := strconv.Atoi(strings.SplitN(strings.TrimPrefix(err.Source.Pointer, "/"), "/", 2)[0])
+	if parseErr != nil || index < 0 {
+		return 0, false
+	}
+
+	return index, true
 }
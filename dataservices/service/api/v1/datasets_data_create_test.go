@@ -2,8 +2,328 @@ package v1_test
 
 import (
 	. "github.com/onsi/ginkgo"
-	// . "github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
+
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data"
+	dataDeduplicator "github.com/tidepool-org/platform/data/deduplicator"
+	testDataDeduplicator "github.com/tidepool-org/platform/data/deduplicator/test"
+	"github.com/tidepool-org/platform/data/factory"
+	dataStore "github.com/tidepool-org/platform/data/store"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/bolus/normal"
+	"github.com/tidepool-org/platform/data/types/calculator"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/service"
 )
 
-var _ = PDescribe("DatasetsDataCreate", func() {
+var _ = Describe("DatasetsDataCreate", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var targetDeviceID string
+		var targetUpload *upload.Upload
+		var targetDeduplicator *testData.Deduplicator
+		var context *TestContext
+
+		datum := func(value string) string {
+			return `{"type": "smbg", "deviceId": "` + targetDeviceID + `", "time": "2016-09-06T13:45:58-07:00", "units": "mmol/L", "value": ` + value + `}`
+		}
+
+		identityHash := func(value string) string {
+			identityHash, err := dataDeduplicator.GenerateIdentityHash([]string{targetUserID, targetDeviceID, "2016-09-06T13:45:58-07:00", "smbg", "mmol/L", value})
+			Expect(err).ToNot(HaveOccurred())
+			return identityHash
+		}
+
+		setBody := func(datumArray ...string) {
+			context.RequestImpl.Request.Body = ioutil.NopCloser(bytes.NewBufferString("[" + strings.Join(datumArray, ",") + "]"))
+		}
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			targetDeviceID = app.NewID()
+			targetUpload = upload.Init()
+			targetUpload.UserID = targetUserID
+			targetUpload.GroupID = app.NewID()
+			targetDeduplicator = testData.NewDeduplicator()
+			targetDeduplicator.AddDatasetDataOutputs = []error{nil}
+			dataFactory, err := factory.NewStandard()
+			Expect(err).ToNot(HaveOccurred())
+			context = NewTestContext()
+			context.DataFactoryImpl = dataFactory
+			context.RequestImpl.PathParams["datasetid"] = targetUpload.UploadID
+			context.RequestImpl.Request.Header = http.Header{}
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: targetUpload, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: nil, Error: nil}}
+			context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{{Deduplicator: targetDeduplicator, Error: nil}}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+			setBody(datum("5.5"), datum("6.5"))
+		})
+
+		AfterEach(func() {
+			Expect(targetDeduplicator.UnusedOutputsCount()).To(Equal(0))
+		})
+
+		It("succeeds if all data is valid", func() {
+			v1.DatasetsDataCreate(context)
+			Expect(targetDeduplicator.AddDatasetDataInputs).To(HaveLen(1))
+			Expect(targetDeduplicator.AddDatasetDataInputs[0]).To(HaveLen(2))
+			for _, datum := range targetDeduplicator.AddDatasetDataInputs[0] {
+				identityFields, err := datum.IdentityFields()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(identityFields[0]).To(Equal(targetUserID))
+			}
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, []struct{}{}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with errors if any data is invalid", func() {
+			setBody(datum("5.5"), datum(`"invalid"`))
+			targetDeduplicator.AddDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsDataCreate(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(HaveLen(1))
+			Expect(context.RespondWithStatusAndErrorsInputs[0].statusCode).To(Equal(http.StatusBadRequest))
+			Expect(context.RespondWithStatusAndErrorsInputs[0].errors).ToNot(BeEmpty())
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the partial success header is not a boolean", func() {
+			context.RequestImpl.Request.Header.Set(v1.HeaderPartialSuccess, "invalid")
+			context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{}
+			targetDeduplicator.AddDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsDataCreate(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorTypeNotBoolean("invalid").WithSourceHeader(v1.HeaderPartialSuccess)}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the idempotency key is too long", func() {
+			context.RequestImpl.Request.Header.Set(v1.HeaderIdempotencyKey, strings.Repeat("a", 101))
+			context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{}
+			targetDeduplicator.AddDatasetDataOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsDataCreate(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorLengthNotLessThanOrEqualTo(101, 100).WithSourceHeader(v1.HeaderIdempotencyKey)}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		Context("with idempotency key", func() {
+			BeforeEach(func() {
+				context.RequestImpl.Request.Header.Set(v1.HeaderIdempotencyKey, "chunk-one")
+				context.DataStoreSessionImpl.GetDatasetChunkOutputs = []testDataStore.GetDatasetChunkOutput{{Chunk: nil, Error: nil}}
+				context.DataStoreSessionImpl.CreateDatasetChunkOutputs = []testDataStore.CreateDatasetChunkOutput{{Created: true, Error: nil}}
+				context.DataStoreSessionImpl.CompleteDatasetChunkOutputs = []error{nil}
+			})
+
+			It("succeeds and completes the chunk with the response", func() {
+				v1.DatasetsDataCreate(context)
+				Expect(context.DataStoreSessionImpl.GetDatasetChunkInputs).To(Equal([]testDataStore.GetDatasetChunkInput{{Dataset: targetUpload, ChunkID: "chunk-one"}}))
+				Expect(targetDeduplicator.AddDatasetDataInputs).To(HaveLen(1))
+				Expect(context.DataStoreSessionImpl.CreateDatasetChunkInputs).To(HaveLen(1))
+				Expect(context.DataStoreSessionImpl.CreateDatasetChunkInputs[0].Dataset).To(Equal(targetUpload))
+				Expect(context.DataStoreSessionImpl.CreateDatasetChunkInputs[0].ChunkID).To(Equal("chunk-one"))
+				Expect(context.DataStoreSessionImpl.CreateDatasetChunkInputs[0].DatumIDs).To(HaveLen(2))
+				for index, datum := range targetDeduplicator.AddDatasetDataInputs[0] {
+					Expect(context.DataStoreSessionImpl.CreateDatasetChunkInputs[0].DatumIDs[index]).To(Equal(datum.DatumID()))
+				}
+				Expect(context.DataStoreSessionImpl.CompleteDatasetChunkInputs).To(Equal([]testDataStore.CompleteDatasetChunkInput{{Dataset: targetUpload, ChunkID: "chunk-one", Response: []byte("[]")}}))
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, []struct{}{}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("succeeds if the chunk failed and the chunk is reclaimed", func() {
+				context.DataStoreSessionImpl.GetDatasetChunkOutputs = []testDataStore.GetDatasetChunkOutput{{Chunk: &dataStore.DatasetChunk{ID: "chunk-one", State: dataStore.DatasetChunkStateFailed, DatumIDs: []string{app.NewID()}}, Error: nil}}
+				v1.DatasetsDataCreate(context)
+				Expect(targetDeduplicator.AddDatasetDataInputs).To(HaveLen(1))
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, []struct{}{}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("succeeds if the chunk lease has expired and the chunk is reclaimed", func() {
+				context.DataStoreSessionImpl.GetDatasetChunkOutputs = []testDataStore.GetDatasetChunkOutput{{Chunk: &dataStore.DatasetChunk{ID: "chunk-one", State: dataStore.DatasetChunkStatePending, ExpirationTime: time.Now().Add(-time.Minute)}, Error: nil}}
+				v1.DatasetsDataCreate(context)
+				Expect(targetDeduplicator.AddDatasetDataInputs).To(HaveLen(1))
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, []struct{}{}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error and fails the chunk if the chunk cannot be completed", func() {
+				err := errors.New("other")
+				context.DataStoreSessionImpl.CompleteDatasetChunkOutputs = []error{err}
+				context.DataStoreSessionImpl.FailDatasetChunkOutputs = []error{nil}
+				v1.DatasetsDataCreate(context)
+				Expect(context.DataStoreSessionImpl.FailDatasetChunkInputs).To(Equal([]testDataStore.FailDatasetChunkInput{{Dataset: targetUpload, ChunkID: "chunk-one"}}))
+				Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to complete dataset chunk", []interface{}{err}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("replays the response if the chunk is completed", func() {
+				context.DataStoreSessionImpl.GetDatasetChunkOutputs = []testDataStore.GetDatasetChunkOutput{{Chunk: &dataStore.DatasetChunk{ID: "chunk-one", State: dataStore.DatasetChunkStateCompleted, Response: []byte(`[{"index":0,"status":"accepted"}]`)}, Error: nil}}
+				context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{}
+				context.DataStoreSessionImpl.CreateDatasetChunkOutputs = []testDataStore.CreateDatasetChunkOutput{}
+				context.DataStoreSessionImpl.CompleteDatasetChunkOutputs = []error{}
+				targetDeduplicator.AddDatasetDataOutputs = []error{}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsDataCreate(context)
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, json.RawMessage(`[{"index":0,"status":"accepted"}]`)}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error if the chunk is in progress", func() {
+				context.DataStoreSessionImpl.GetDatasetChunkOutputs = []testDataStore.GetDatasetChunkOutput{{Chunk: &dataStore.DatasetChunk{ID: "chunk-one", State: dataStore.DatasetChunkStatePending, ExpirationTime: time.Now().Add(time.Minute)}, Error: nil}}
+				context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{}
+				context.DataStoreSessionImpl.CreateDatasetChunkOutputs = []testDataStore.CreateDatasetChunkOutput{}
+				context.DataStoreSessionImpl.CompleteDatasetChunkOutputs = []error{}
+				targetDeduplicator.AddDatasetDataOutputs = []error{}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsDataCreate(context)
+				Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetChunkInProgress(targetUpload.UploadID, "chunk-one")}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error if the chunk is created concurrently", func() {
+				context.DataStoreSessionImpl.CreateDatasetChunkOutputs = []testDataStore.CreateDatasetChunkOutput{{Created: false, Error: nil}}
+				context.DataStoreSessionImpl.CompleteDatasetChunkOutputs = []error{}
+				targetDeduplicator.AddDatasetDataOutputs = []error{}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsDataCreate(context)
+				Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetChunkInProgress(targetUpload.UploadID, "chunk-one")}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error and fails the chunk if adding the data fails", func() {
+				err := errors.New("other")
+				targetDeduplicator.AddDatasetDataOutputs = []error{err}
+				context.DataStoreSessionImpl.FailDatasetChunkOutputs = []error{nil}
+				context.DataStoreSessionImpl.CompleteDatasetChunkOutputs = []error{}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsDataCreate(context)
+				Expect(context.DataStoreSessionImpl.FailDatasetChunkInputs).To(Equal([]testDataStore.FailDatasetChunkInput{{Dataset: targetUpload, ChunkID: "chunk-one"}}))
+				Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to add dataset data", []interface{}{err}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error if data store session get dataset chunk returns error", func() {
+				err := errors.New("other")
+				context.DataStoreSessionImpl.GetDatasetChunkOutputs = []testDataStore.GetDatasetChunkOutput{{Chunk: nil, Error: err}}
+				context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{}
+				context.DataStoreSessionImpl.CreateDatasetChunkOutputs = []testDataStore.CreateDatasetChunkOutput{}
+				context.DataStoreSessionImpl.CompleteDatasetChunkOutputs = []error{}
+				targetDeduplicator.AddDatasetDataOutputs = []error{}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsDataCreate(context)
+				Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get dataset chunk", []interface{}{err}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+		})
+
+		Context("with partial success", func() {
+			BeforeEach(func() {
+				context.RequestImpl.Request.Header.Set(v1.HeaderPartialSuccess, "true")
+				context.DataStoreSessionImpl.GetDatasetDataDeduplicatorHashesOutputs = []testDataStore.GetDatasetDataDeduplicatorHashesOutput{{Hashes: []string{}, Error: nil}}
+			})
+
+			It("succeeds with accepted and rejected results if some data is invalid", func() {
+				setBody(datum("5.5"), datum(`"invalid"`), datum("6.5"))
+				v1.DatasetsDataCreate(context)
+				Expect(context.DataStoreSessionImpl.GetDatasetDataDeduplicatorHashesInputs).To(Equal([]testDataStore.GetDatasetDataDeduplicatorHashesInput{{Dataset: targetUpload, Hashes: []string{identityHash("5.5"), identityHash("6.5")}}}))
+				Expect(targetDeduplicator.AddDatasetDataInputs).To(HaveLen(1))
+				Expect(targetDeduplicator.AddDatasetDataInputs[0]).To(HaveLen(2))
+				Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+				Expect(context.RespondWithStatusAndDataInputs[0].statusCode).To(Equal(http.StatusOK))
+				datumResults, ok := context.RespondWithStatusAndDataInputs[0].data.([]*v1.DatumResult)
+				Expect(ok).To(BeTrue())
+				Expect(datumResults).To(HaveLen(3))
+				Expect(datumResults[0]).To(Equal(&v1.DatumResult{Index: 0, Status: v1.DatumResultStatusAccepted}))
+				Expect(datumResults[1].Index).To(Equal(1))
+				Expect(datumResults[1].Status).To(Equal(v1.DatumResultStatusRejected))
+				Expect(datumResults[1].Errors).ToNot(BeEmpty())
+				Expect(datumResults[2]).To(Equal(&v1.DatumResult{Index: 2, Status: v1.DatumResultStatusAccepted}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("succeeds with rejected results if all data is invalid", func() {
+				setBody(datum(`"invalid"`), datum(`"invalid"`))
+				context.DataStoreSessionImpl.GetDatasetDataDeduplicatorHashesOutputs = []testDataStore.GetDatasetDataDeduplicatorHashesOutput{}
+				v1.DatasetsDataCreate(context)
+				Expect(targetDeduplicator.AddDatasetDataInputs).To(Equal([][]data.Datum{{}}))
+				Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+				datumResults, ok := context.RespondWithStatusAndDataInputs[0].data.([]*v1.DatumResult)
+				Expect(ok).To(BeTrue())
+				Expect(datumResults).To(HaveLen(2))
+				for index, datumResult := range datumResults {
+					Expect(datumResult.Index).To(Equal(index))
+					Expect(datumResult.Status).To(Equal(v1.DatumResultStatusRejected))
+					Expect(datumResult.Errors).ToNot(BeEmpty())
+				}
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("succeeds with duplicate results if data is duplicated within the request", func() {
+				setBody(datum("5.5"), datum("5.5"))
+				v1.DatasetsDataCreate(context)
+				Expect(targetDeduplicator.AddDatasetDataInputs).To(HaveLen(1))
+				Expect(targetDeduplicator.AddDatasetDataInputs[0]).To(HaveLen(1))
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, []*v1.DatumResult{
+					{Index: 0, Status: v1.DatumResultStatusAccepted},
+					{Index: 1, Status: v1.DatumResultStatusDuplicate},
+				}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("succeeds with duplicate results if data is already stored for the dataset", func() {
+				context.DataStoreSessionImpl.GetDatasetDataDeduplicatorHashesOutputs = []testDataStore.GetDatasetDataDeduplicatorHashesOutput{{Hashes: []string{identityHash("6.5")}, Error: nil}}
+				v1.DatasetsDataCreate(context)
+				Expect(targetDeduplicator.AddDatasetDataInputs).To(HaveLen(1))
+				Expect(targetDeduplicator.AddDatasetDataInputs[0]).To(HaveLen(1))
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, []*v1.DatumResult{
+					{Index: 0, Status: v1.DatumResultStatusAccepted},
+					{Index: 1, Status: v1.DatumResultStatusDuplicate},
+				}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("succeeds without the normalized data of duplicate data", func() {
+				wizard := func(normal string) string {
+					return `{"type": "wizard", "deviceId": "` + targetDeviceID + `", "time": "2016-09-06T13:45:58-07:00", "units": "mmol/L", "bolus": {"type": "bolus", "subType": "normal", "deviceId": "` + targetDeviceID + `", "time": "2016-09-06T13:45:58-07:00", "normal": ` + normal + `}}`
+				}
+				setBody(wizard("1.5"), wizard("2.5"))
+				v1.DatasetsDataCreate(context)
+				Expect(targetDeduplicator.AddDatasetDataInputs).To(HaveLen(1))
+				Expect(targetDeduplicator.AddDatasetDataInputs[0]).To(HaveLen(2))
+				Expect(targetDeduplicator.AddDatasetDataInputs[0][0]).To(BeAssignableToTypeOf(&calculator.Calculator{}))
+				Expect(targetDeduplicator.AddDatasetDataInputs[0][1]).To(BeAssignableToTypeOf(&normal.Normal{}))
+				Expect(*targetDeduplicator.AddDatasetDataInputs[0][1].(*normal.Normal).Normal).To(Equal(1.5))
+				Expect(targetDeduplicator.AddDatasetDataInputs[0][1].(*normal.Normal).UploadID).To(Equal(targetUpload.UploadID))
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, []*v1.DatumResult{
+					{Index: 0, Status: v1.DatumResultStatusAccepted},
+					{Index: 1, Status: v1.DatumResultStatusDuplicate},
+				}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error if data store session get dataset data deduplicator hashes returns error", func() {
+				err := errors.New("other")
+				context.DataStoreSessionImpl.GetDatasetDataDeduplicatorHashesOutputs = []testDataStore.GetDatasetDataDeduplicatorHashesOutput{{Hashes: nil, Error: err}}
+				targetDeduplicator.AddDatasetDataOutputs = []error{}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsDataCreate(context)
+				Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to deduplicate datum array", []interface{}{err}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+		})
+	})
 })
@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/tidepool-org/platform/data/store"
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+const ParameterFilterArchived = "archived"
+
+func DatasetsDataGet(serviceContext service.Context) {
+	datasetID := serviceContext.Request().PathParam("datasetid")
+	if datasetID == "" {
+		serviceContext.RespondWithError(ErrorDatasetIDMissing())
+		return
+	}
+
+	dataset, err := serviceContext.DataStoreSession().GetDatasetByID(datasetID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get dataset by id", err)
+		return
+	}
+	if dataset == nil {
+		serviceContext.RespondWithError(ErrorDatasetIDNotFound(datasetID))
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		var permissions client.Permissions
+		permissions, err = serviceContext.UserServicesClient().GetUserPermissions(serviceContext, serviceContext.AuthenticationDetails().UserID(), dataset.UserID)
+		if err != nil {
+			if client.IsUnauthorizedError(err) {
+				serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			} else {
+				serviceContext.RespondWithInternalServerFailure("Unable to get user permissions", err)
+			}
+			return
+		}
+		if _, ok := permissions[client.ViewPermission]; !ok {
+			serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			return
+		}
+	}
+
+	filter := store.NewDatasetDataFilter()
+	pagination := store.NewPagination()
+
+	var errors []*commonService.Error
+	for key, values := range serviceContext.Request().URL.Query() {
+		for _, value := range values {
+			switch key {
+			case ParameterFilterArchived:
+				if parsedValue, err := strconv.ParseBool(value); err != nil {
+					errors = append(errors, commonService.ErrorTypeNotBoolean(value).WithSourceParameter(ParameterFilterArchived))
+				} else {
+					filter.Archived = parsedValue
+				}
+			case ParameterPaginationPage:
+				if parsedValue, err := strconv.Atoi(value); err != nil {
+					errors = append(errors, commonService.ErrorTypeNotInteger(value).WithSourceParameter(ParameterPaginationPage))
+				} else if parsedValue < store.PaginationPageMinimum {
+					errors = append(errors, commonService.ErrorValueNotGreaterThanOrEqualTo(parsedValue, store.PaginationPageMinimum).WithSourceParameter(ParameterPaginationPage))
+				} else {
+					pagination.Page = parsedValue
+				}
+			case ParameterPaginationCursor:
+				if parsedValue, err := store.ParseCursor(value); err != nil {
+					errors = append(errors, ErrorValueCursorNotValid(value).WithSourceParameter(ParameterPaginationCursor))
+				} else {
+					pagination.Cursor = parsedValue
+				}
+			case ParameterPaginationSize:
+				if parsedValue, err := strconv.Atoi(value); err != nil {
+					errors = append(errors, commonService.ErrorTypeNotInteger(value).WithSourceParameter(ParameterPaginationSize))
+				} else if parsedValue < store.PaginationSizeMinimum || parsedValue > store.PaginationSizeMaximum {
+					errors = append(errors, commonService.ErrorValueNotInRange(parsedValue, store.PaginationSizeMinimum, store.PaginationSizeMaximum).WithSourceParameter(ParameterPaginationSize))
+				} else {
+					pagination.Size = parsedValue
+				}
+			}
+		}
+	}
+	if pagination.Cursor != nil && pagination.Page != store.PaginationPageMinimum {
+		errors = append(errors, commonService.ErrorValueExists().WithSourceParameter(ParameterPaginationPage))
+	}
+	if len(errors) > 0 {
+		serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, errors)
+		return
+	}
+
+	datasetData, err := serviceContext.DataStoreSession().GetDataForDatasetByID(datasetID, filter, pagination)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get data for dataset", err)
+		return
+	}
+
+	if cursor := nextDataCursor(datasetData, pagination); cursor != "" {
+		serviceContext.RespondWithStatusAndDataAndCursor(http.StatusOK, datasetData, cursor)
+		return
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, datasetData)
+}
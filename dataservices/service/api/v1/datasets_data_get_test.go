@@ -0,0 +1,183 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/store"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+var _ = Describe("DatasetsDataGet", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var targetUpload *upload.Upload
+		var datasetData []map[string]interface{}
+		var context *TestContext
+		var filter *store.DatasetDataFilter
+		var pagination *store.Pagination
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			targetUpload = upload.Init()
+			targetUpload.UserID = targetUserID
+			datasetData = []map[string]interface{}{}
+			for i := 0; i < 3; i++ {
+				datasetData = append(datasetData, map[string]interface{}{"id": app.NewID(), "type": "cbg", "uploadId": targetUpload.UploadID})
+			}
+			context = NewTestContext()
+			context.RequestImpl.PathParams["datasetid"] = targetUpload.UploadID
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: targetUpload, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.ViewPermission: client.Permission{}}, nil}}
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{{Data: datasetData, Error: nil}}
+			filter = store.NewDatasetDataFilter()
+			pagination = store.NewPagination()
+		})
+
+		It("succeeds if authenticated as user with view permission", func() {
+			v1.DatasetsDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.DataStoreSessionImpl.GetDataForDatasetByIDInputs).To(Equal([]testDataStore.GetDataForDatasetByIDInput{{DatasetID: targetUpload.UploadID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			v1.DatasetsDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForDatasetByIDInputs).To(Equal([]testDataStore.GetDataForDatasetByIDInput{{DatasetID: targetUpload.UploadID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if archived query parameter specified", func() {
+			filter.Archived = true
+			context.RequestImpl.Request.URL.RawQuery = "archived=true"
+			v1.DatasetsDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForDatasetByIDInputs).To(Equal([]testDataStore.GetDataForDatasetByIDInput{{DatasetID: targetUpload.UploadID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if page and size query parameters specified", func() {
+			pagination.Page = 2
+			pagination.Size = 10
+			context.RequestImpl.Request.URL.RawQuery = "page=2&size=10"
+			v1.DatasetsDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForDatasetByIDInputs).To(Equal([]testDataStore.GetDataForDatasetByIDInput{{DatasetID: targetUpload.UploadID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds with next cursor if the page is full", func() {
+			lastDatum := datasetData[len(datasetData)-1]
+			lastDatum["time"] = "2016-09-01T12:00:00Z"
+			pagination.Size = 3
+			context.RequestImpl.Request.URL.RawQuery = "size=3"
+			v1.DatasetsDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForDatasetByIDInputs).To(Equal([]testDataStore.GetDataForDatasetByIDInput{{DatasetID: targetUpload.UploadID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataAndCursorInputs).To(Equal([]RespondWithStatusAndDataAndCursorInput{{http.StatusOK, datasetData, store.NewCursor("2016-09-01T12:00:00Z", lastDatum["id"].(string)).Token()}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if dataset id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "datasetid")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{}
+			v1.DatasetsDataGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: err}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{}
+			v1.DatasetsDataGet(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get dataset by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns no dataset", func() {
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{}
+			v1.DatasetsDataGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDNotFound(targetUpload.UploadID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions returns unauthorized error", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, client.NewUnauthorizedError()}}
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{}
+			v1.DatasetsDataGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions returns any other error", func() {
+			err := errors.New("other")
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, err}}
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{}
+			v1.DatasetsDataGet(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get user permissions", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions does not return needed permissions", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.UploadPermission: client.Permission{}}, nil}}
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{}
+			v1.DatasetsDataGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if archived query parameter not a boolean", func() {
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "archived=abc"
+			v1.DatasetsDataGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorTypeNotBoolean("abc").WithSourceParameter("archived")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if size query parameter is greater than maximum", func() {
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "size=101"
+			v1.DatasetsDataGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueNotInRange(101, 1, 100).WithSourceParameter("size")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get data for dataset returns an error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.GetDataForDatasetByIDOutputs = []testDataStore.GetDataForDatasetByIDOutput{{Data: nil, Error: err}}
+			v1.DatasetsDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForDatasetByIDInputs).To(Equal([]testDataStore.GetDataForDatasetByIDInput{{DatasetID: targetUpload.UploadID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get data for dataset", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
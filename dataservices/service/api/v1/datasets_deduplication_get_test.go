@@ -0,0 +1,112 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/dataservices/service/worker"
+	"github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+var _ = Describe("DatasetsDeduplicationGet", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var targetUpload *upload.Upload
+		var targetTask *taskStore.Task
+		var context *TestContext
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			targetUpload = upload.Init()
+			targetUpload.UserID = targetUserID
+			targetTask = taskStore.NewTask(worker.TaskTypeDeduplicateDataset)
+			targetTask.ID = app.NewID()
+			targetTask.UserID = targetUserID
+			targetTask.DatasetID = targetUpload.UploadID
+			context = NewTestContext()
+			context.RequestImpl.PathParams["datasetid"] = targetUpload.UploadID
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: targetUpload, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.ViewPermission: client.Permission{}}, nil}}
+			context.TaskStoreSessionImpl.GetTaskForDatasetByIDOutputs = []testTaskStore.GetTaskForDatasetByIDOutput{{Task: targetTask, Error: nil}}
+		})
+
+		It("succeeds if authenticated as user with view permission", func() {
+			v1.DatasetsDeduplicationGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.TaskStoreSessionImpl.GetTaskForDatasetByIDInputs).To(Equal([]testTaskStore.GetTaskForDatasetByIDInput{{DatasetID: targetUpload.UploadID, TaskType: worker.TaskTypeDeduplicateDataset}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetTask}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			v1.DatasetsDeduplicationGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetTask}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if dataset id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "datasetid")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.TaskStoreSessionImpl.GetTaskForDatasetByIDOutputs = []testTaskStore.GetTaskForDatasetByIDOutput{}
+			v1.DatasetsDeduplicationGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns no dataset", func() {
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.TaskStoreSessionImpl.GetTaskForDatasetByIDOutputs = []testTaskStore.GetTaskForDatasetByIDOutput{}
+			v1.DatasetsDeduplicationGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDNotFound(targetUpload.UploadID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions does not return needed permissions", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.UploadPermission: client.Permission{}}, nil}}
+			context.TaskStoreSessionImpl.GetTaskForDatasetByIDOutputs = []testTaskStore.GetTaskForDatasetByIDOutput{}
+			v1.DatasetsDeduplicationGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if task store session get task returns an error", func() {
+			err := errors.New("other")
+			context.TaskStoreSessionImpl.GetTaskForDatasetByIDOutputs = []testTaskStore.GetTaskForDatasetByIDOutput{{Task: nil, Error: err}}
+			v1.DatasetsDeduplicationGet(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get task for dataset by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if task store session get task returns no task", func() {
+			context.TaskStoreSessionImpl.GetTaskForDatasetByIDOutputs = []testTaskStore.GetTaskForDatasetByIDOutput{{Task: nil, Error: nil}}
+			v1.DatasetsDeduplicationGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetDeduplicationNotFound(targetUpload.UploadID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+func DatasetsDeduplicationPreviewGet(serviceContext service.Context) {
+	datasetID := serviceContext.Request().PathParam("datasetid")
+	if datasetID == "" {
+		serviceContext.RespondWithError(ErrorDatasetIDMissing())
+		return
+	}
+
+	dataset, err := serviceContext.DataStoreSession().GetDatasetByID(datasetID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get dataset by id", err)
+		return
+	}
+	if dataset == nil {
+		serviceContext.RespondWithError(ErrorDatasetIDNotFound(datasetID))
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		var permissions client.Permissions
+		permissions, err = serviceContext.UserServicesClient().GetUserPermissions(serviceContext, serviceContext.AuthenticationDetails().UserID(), dataset.UserID)
+		if err != nil {
+			if client.IsUnauthorizedError(err) {
+				serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			} else {
+				serviceContext.RespondWithInternalServerFailure("Unable to get user permissions", err)
+			}
+			return
+		}
+		if _, ok := permissions[client.ViewPermission]; !ok {
+			serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			return
+		}
+	}
+
+	deduplicator, err := serviceContext.DataDeduplicatorFactory().NewRegisteredDeduplicatorForDataset(serviceContext.Logger(), serviceContext.DataStoreSession(), dataset)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to create registered deduplicator for dataset", err)
+		return
+	}
+
+	preview, err := deduplicator.PreviewDeduplicateDataset()
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to preview deduplicate dataset", err)
+		return
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, preview)
+}
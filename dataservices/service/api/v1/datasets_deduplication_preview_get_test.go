@@ -0,0 +1,118 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data"
+	testDataDeduplicator "github.com/tidepool-org/platform/data/deduplicator/test"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+var _ = Describe("DatasetsDeduplicationPreviewGet", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var targetUpload *upload.Upload
+		var targetDeduplicator *testData.Deduplicator
+		var targetPreview *data.DeduplicatorPreview
+		var context *TestContext
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			targetUpload = upload.Init()
+			targetUpload.UserID = targetUserID
+			targetPreview = data.NewDeduplicatorPreview("test", "1.2.3")
+			targetPreview.AddAction("activate-dataset-data", 2, []string{app.NewID(), app.NewID()})
+			targetDeduplicator = testData.NewDeduplicator()
+			targetDeduplicator.PreviewDeduplicateDatasetOutputs = []testData.PreviewDeduplicateDatasetOutput{{Preview: targetPreview, Error: nil}}
+			context = NewTestContext()
+			context.RequestImpl.PathParams["datasetid"] = targetUpload.UploadID
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: targetUpload, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.ViewPermission: client.Permission{}}, nil}}
+			context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{{Deduplicator: targetDeduplicator, Error: nil}}
+		})
+
+		It("succeeds if authenticated as user with view permission", func() {
+			v1.DatasetsDeduplicationPreviewGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetInputs).To(HaveLen(1))
+			Expect(context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetInputs[0].Dataset).To(Equal(targetUpload))
+			Expect(targetDeduplicator.PreviewDeduplicateDatasetInvocations).To(Equal(1))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetPreview}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+			Expect(targetDeduplicator.UnusedOutputsCount()).To(Equal(0))
+		})
+
+		It("succeeds if authenticated as server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			v1.DatasetsDeduplicationPreviewGet(context)
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetPreview}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+			Expect(targetDeduplicator.UnusedOutputsCount()).To(Equal(0))
+		})
+
+		It("responds with error if dataset id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "datasetid")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{}
+			v1.DatasetsDeduplicationPreviewGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns no dataset", func() {
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{}
+			v1.DatasetsDeduplicationPreviewGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDNotFound(targetUpload.UploadID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions does not return needed permissions", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.UploadPermission: client.Permission{}}, nil}}
+			context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{}
+			v1.DatasetsDeduplicationPreviewGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data deduplicator factory new registered deduplicator for dataset returns an error", func() {
+			err := errors.New("other")
+			context.DataDeduplicatorFactoryImpl.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{{Deduplicator: nil, Error: err}}
+			v1.DatasetsDeduplicationPreviewGet(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to create registered deduplicator for dataset", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if deduplicator preview deduplicate dataset returns an error", func() {
+			err := errors.New("other")
+			targetDeduplicator.PreviewDeduplicateDatasetOutputs = []testData.PreviewDeduplicateDatasetOutput{{Preview: nil, Error: err}}
+			v1.DatasetsDeduplicationPreviewGet(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to preview deduplicate dataset", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+			Expect(targetDeduplicator.UnusedOutputsCount()).To(Equal(0))
+		})
+	})
+})
@@ -54,6 +54,10 @@ func DatasetsDelete(serviceContext service.Context) {
 		}
 	}
 
+	if respondIfUserDataLocked(serviceContext, targetUserID) {
+		return
+	}
+
 	registered, err := serviceContext.DataDeduplicatorFactory().IsRegisteredWithDataset(dataset)
 	if err != nil {
 		serviceContext.RespondWithInternalServerFailure("Unable to check if registered with dataset", err)
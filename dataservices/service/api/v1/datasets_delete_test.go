@@ -13,6 +13,8 @@ import (
 	testData "github.com/tidepool-org/platform/data/test"
 	"github.com/tidepool-org/platform/data/types/upload"
 	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	lockStore "github.com/tidepool-org/platform/lock/store"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
 	"github.com/tidepool-org/platform/service"
 	"github.com/tidepool-org/platform/userservices/client"
 )
@@ -36,6 +38,7 @@ var _ = Describe("DatasetsDelete", func() {
 			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
 			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"root": client.Permission{}}, nil}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: nil, Error: nil}}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{{Is: false, Error: nil}}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{nil}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
@@ -95,6 +98,7 @@ var _ = Describe("DatasetsDelete", func() {
 			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
 			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -108,6 +112,7 @@ var _ = Describe("DatasetsDelete", func() {
 			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
 			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -121,6 +126,7 @@ var _ = Describe("DatasetsDelete", func() {
 			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
 			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -134,6 +140,7 @@ var _ = Describe("DatasetsDelete", func() {
 			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
 			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
 			Expect(func() { v1.DatasetsDelete(context) }).To(Panic())
@@ -146,6 +153,7 @@ var _ = Describe("DatasetsDelete", func() {
 			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
 			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -160,6 +168,7 @@ var _ = Describe("DatasetsDelete", func() {
 			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
 			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -174,6 +183,7 @@ var _ = Describe("DatasetsDelete", func() {
 			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
 			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -186,6 +196,7 @@ var _ = Describe("DatasetsDelete", func() {
 		It("panics if authentication details is missing", func() {
 			context.AuthenticationDetailsImpl = nil
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -196,6 +207,7 @@ var _ = Describe("DatasetsDelete", func() {
 
 		It("panics if user services client is missing", func() {
 			context.UserServicesClientImpl = nil
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -206,6 +218,7 @@ var _ = Describe("DatasetsDelete", func() {
 
 		It("responds with error if user services client get user permissions returns unauthorized error", func() {
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, client.NewUnauthorizedError()}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -219,6 +232,7 @@ var _ = Describe("DatasetsDelete", func() {
 		It("responds with error if user services client get user permissions returns any other error", func() {
 			err := errors.New("other")
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, err}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -231,6 +245,7 @@ var _ = Describe("DatasetsDelete", func() {
 
 		It("responds with error if user services client get user permissions does not return needed permissions", func() {
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"view": client.Permission{}}, nil}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -243,6 +258,7 @@ var _ = Describe("DatasetsDelete", func() {
 
 		It("responds with error if user services client get user permissions returns upload permissions, but not user who uploaded", func() {
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"upload": client.Permission{}}, nil}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
 			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
 			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
@@ -253,6 +269,28 @@ var _ = Describe("DatasetsDelete", func() {
 			Expect(context.ValidateTest()).To(BeTrue())
 		})
 
+		It("responds with error if user data is locked", func() {
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: &lockStore.Lock{UserID: targetUserID}, Error: nil}}
+			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
+			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsDelete(context)
+			Expect(context.LockStoreSessionImpl.GetLockForUserByIDInputs).To(Equal([]string{targetUserID}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDataLocked(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if lock store session get lock for user by id returns error", func() {
+			err := errors.New("other")
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: nil, Error: err}}
+			context.DataDeduplicatorFactoryImpl.IsRegisteredWithDatasetOutputs = []testDataDeduplicator.IsRegisteredWithDatasetOutput{}
+			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsDelete(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get lock for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
 		It("panics if data deduplicator factory is missing", func() {
 			context.DataDeduplicatorFactoryImpl = nil
 			context.DataStoreSessionImpl.DeleteDatasetOutputs = []error{}
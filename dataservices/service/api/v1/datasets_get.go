@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/data/store"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+type DatasetWithSummary struct {
+	*upload.Upload
+	Summary *store.DatasetSummary `json:"summary"`
+}
+
+func DatasetsGet(serviceContext service.Context) {
+	datasetID := serviceContext.Request().PathParam("datasetid")
+	if datasetID == "" {
+		serviceContext.RespondWithError(ErrorDatasetIDMissing())
+		return
+	}
+
+	dataset, err := serviceContext.DataStoreSession().GetDatasetByID(datasetID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get dataset by id", err)
+		return
+	}
+	if dataset == nil {
+		serviceContext.RespondWithError(ErrorDatasetIDNotFound(datasetID))
+		return
+	}
+
+	targetUserID := dataset.UserID
+	if targetUserID == "" {
+		serviceContext.RespondWithInternalServerFailure("Unable to get user id from dataset")
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		var permissions client.Permissions
+		permissions, err = serviceContext.UserServicesClient().GetUserPermissions(serviceContext, serviceContext.AuthenticationDetails().UserID(), targetUserID)
+		if err != nil {
+			if client.IsUnauthorizedError(err) {
+				serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			} else {
+				serviceContext.RespondWithInternalServerFailure("Unable to get user permissions", err)
+			}
+			return
+		}
+		if _, ok := permissions[client.ViewPermission]; !ok {
+			serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			return
+		}
+	}
+
+	summary, err := serviceContext.DataStoreSession().GetDatasetSummary(dataset)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get dataset summary", err)
+		return
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, &DatasetWithSummary{Upload: dataset, Summary: summary})
+}
@@ -0,0 +1,149 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/store"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+var _ = Describe("DatasetsGet", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var targetUpload *upload.Upload
+		var targetSummary *store.DatasetSummary
+		var context *TestContext
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			targetUpload = upload.Init()
+			targetUpload.UserID = targetUserID
+			targetSummary = store.NewDatasetSummary()
+			targetSummary.AddCount(&store.DatasetSummaryCount{Type: "cbg", Count: 2, ActiveCount: 2}, "2016-09-01T11:00:00Z", "2016-09-01T12:00:00Z")
+			context = NewTestContext()
+			context.RequestImpl.PathParams["datasetid"] = targetUpload.UploadID
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: targetUpload, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.ViewPermission: client.Permission{}}, nil}}
+			context.DataStoreSessionImpl.GetDatasetSummaryOutputs = []testDataStore.GetDatasetSummaryOutput{{Summary: targetSummary, Error: nil}}
+		})
+
+		It("succeeds if authenticated as user with view permission", func() {
+			v1.DatasetsGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.DataStoreSessionImpl.GetDatasetSummaryInputs).To(Equal([]*upload.Upload{targetUpload}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, &v1.DatasetWithSummary{Upload: targetUpload, Summary: targetSummary}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			v1.DatasetsGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.DataStoreSessionImpl.GetDatasetSummaryInputs).To(Equal([]*upload.Upload{targetUpload}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, &v1.DatasetWithSummary{Upload: targetUpload, Summary: targetSummary}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if dataset id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "datasetid")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataStoreSessionImpl.GetDatasetSummaryOutputs = []testDataStore.GetDatasetSummaryOutput{}
+			v1.DatasetsGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: err}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataStoreSessionImpl.GetDatasetSummaryOutputs = []testDataStore.GetDatasetSummaryOutput{}
+			v1.DatasetsGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get dataset by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns no dataset", func() {
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataStoreSessionImpl.GetDatasetSummaryOutputs = []testDataStore.GetDatasetSummaryOutput{}
+			v1.DatasetsGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDNotFound(targetUpload.UploadID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id is missing on dataset", func() {
+			targetUpload.UserID = ""
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataStoreSessionImpl.GetDatasetSummaryOutputs = []testDataStore.GetDatasetSummaryOutput{}
+			v1.DatasetsGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get user id from dataset", nil}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions returns unauthorized error", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, client.NewUnauthorizedError()}}
+			context.DataStoreSessionImpl.GetDatasetSummaryOutputs = []testDataStore.GetDatasetSummaryOutput{}
+			v1.DatasetsGet(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions returns any other error", func() {
+			err := errors.New("other")
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, err}}
+			context.DataStoreSessionImpl.GetDatasetSummaryOutputs = []testDataStore.GetDatasetSummaryOutput{}
+			v1.DatasetsGet(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get user permissions", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions does not return needed permissions", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.UploadPermission: client.Permission{}}, nil}}
+			context.DataStoreSessionImpl.GetDatasetSummaryOutputs = []testDataStore.GetDatasetSummaryOutput{}
+			v1.DatasetsGet(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset summary returns an error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.GetDatasetSummaryOutputs = []testDataStore.GetDatasetSummaryOutput{{Summary: nil, Error: err}}
+			v1.DatasetsGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetSummaryInputs).To(Equal([]*upload.Upload{targetUpload}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get dataset summary", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
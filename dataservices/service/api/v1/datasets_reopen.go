@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+func DatasetsReopen(serviceContext service.Context) {
+	datasetID := serviceContext.Request().PathParam("datasetid")
+	if datasetID == "" {
+		serviceContext.RespondWithError(ErrorDatasetIDMissing())
+		return
+	}
+
+	dataset, err := serviceContext.DataStoreSession().GetDatasetByID(datasetID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get dataset by id", err)
+		return
+	}
+	if dataset == nil {
+		serviceContext.RespondWithError(ErrorDatasetIDNotFound(datasetID))
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		var permissions client.Permissions
+		permissions, err = serviceContext.UserServicesClient().GetUserPermissions(serviceContext, serviceContext.AuthenticationDetails().UserID(), dataset.UserID)
+		if err != nil {
+			if client.IsUnauthorizedError(err) {
+				serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			} else {
+				serviceContext.RespondWithInternalServerFailure("Unable to get user permissions", err)
+			}
+			return
+		}
+		if _, ok := permissions[client.UploadPermission]; !ok {
+			serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			return
+		}
+	}
+
+	if respondIfUserDataLocked(serviceContext, dataset.UserID) {
+		return
+	}
+
+	state := dataset.CurrentState()
+	if !upload.IsStateTransitionValid(state, upload.StateOpen) {
+		serviceContext.RespondWithError(ErrorDatasetStateTransitionNotValid(datasetID, state, upload.StateOpen))
+		return
+	}
+
+	deduplicator, err := serviceContext.DataDeduplicatorFactory().NewRegisteredDeduplicatorForDataset(serviceContext.Logger(), serviceContext.DataStoreSession(), dataset)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to create registered deduplicator for dataset", err)
+		return
+	}
+
+	reopened, err := deduplicator.ReopenDataset()
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to reopen dataset", err)
+		return
+	}
+	if !reopened {
+		serviceContext.RespondWithError(ErrorDatasetReopenNotSupported(datasetID))
+		return
+	}
+
+	transitioned, err := serviceContext.DataStoreSession().TransitionDatasetState(dataset, upload.StateOpen, serviceContext.AuthenticationDetails().UserID())
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to transition dataset state", err)
+		return
+	}
+	if !transitioned {
+		serviceContext.RespondWithError(ErrorDatasetStateTransitionNotValid(datasetID, state, upload.StateOpen))
+		return
+	}
+
+	if err = serviceContext.MetricServicesClient().RecordMetric(serviceContext, "datasets_reopen"); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, dataset)
+}
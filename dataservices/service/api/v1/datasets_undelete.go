@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/data/store"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service"
+	"github.com/tidepool-org/platform/dataservices/service/worker"
+	commonService "github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+func DatasetsUndelete(serviceContext service.Context) {
+	datasetID := serviceContext.Request().PathParam("datasetid")
+	if datasetID == "" {
+		serviceContext.RespondWithError(ErrorDatasetIDMissing())
+		return
+	}
+
+	dataset, err := serviceContext.DataStoreSession().GetDatasetByID(datasetID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get dataset by id", err)
+		return
+	}
+	if dataset == nil {
+		serviceContext.RespondWithError(ErrorDatasetIDNotFound(datasetID))
+		return
+	}
+
+	targetUserID := dataset.UserID
+	if targetUserID == "" {
+		serviceContext.RespondWithInternalServerFailure("Unable to get user id from dataset")
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		authenticatedUserID := serviceContext.AuthenticationDetails().UserID()
+
+		var permissions client.Permissions
+		permissions, err = serviceContext.UserServicesClient().GetUserPermissions(serviceContext, authenticatedUserID, targetUserID)
+		if err != nil {
+			if client.IsUnauthorizedError(err) {
+				serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			} else {
+				serviceContext.RespondWithInternalServerFailure("Unable to get user permissions", err)
+			}
+			return
+		}
+		if _, ok := permissions[client.OwnerPermission]; !ok {
+			if _, ok = permissions[client.CustodianPermission]; !ok {
+				if _, ok = permissions[client.UploadPermission]; !ok || authenticatedUserID != dataset.ByUser {
+					serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+					return
+				}
+			}
+		}
+	}
+
+	if respondIfUserDataLocked(serviceContext, targetUserID) {
+		return
+	}
+
+	if dataset.DeletedTime == "" {
+		serviceContext.RespondWithError(ErrorDatasetNotDeleted(datasetID))
+		return
+	}
+
+	if err = serviceContext.DataStoreSession().UndeleteDataset(dataset); err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to undelete dataset", err)
+		return
+	}
+
+	// Data of an undeleted closed dataset is inactive, so restore only its own state, that is, reactivate its
+	// data not archived by another dataset and archive again the data it had archived. Any other dataset of the
+	// same device may since depend on the absence of this dataset, so replay the user in upload order if so.
+	if dataset.CurrentState() == upload.StateClosed {
+		if err = serviceContext.DataStoreSession().ActivateDatasetData(dataset); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to activate dataset data", err)
+			return
+		}
+
+		if err = serviceContext.DataStoreSession().RearchiveDeviceDataRetainedByDataset(dataset); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to rearchive device data retained by dataset", err)
+			return
+		}
+
+		var replay bool
+		if replay, err = hasOtherClosedDatasetOfDevice(serviceContext, dataset); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to get datasets for user by id", err)
+			return
+		}
+
+		if replay {
+			task := taskStore.NewTask(worker.TaskTypeRededuplicateUser)
+			task.UserID = targetUserID
+			if err = serviceContext.TaskStoreSession().CreateTask(task); err != nil {
+				serviceContext.RespondWithInternalServerFailure("Unable to create task", err)
+				return
+			}
+		}
+	}
+
+	if err = serviceContext.MetricServicesClient().RecordMetric(serviceContext, "datasets_undelete"); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, dataset)
+}
+
+func hasOtherClosedDatasetOfDevice(serviceContext service.Context, dataset *upload.Upload) (bool, error) {
+	if dataset.DeviceID == nil {
+		return false, nil
+	}
+
+	pagination := store.NewPagination()
+	for {
+		datasets, err := serviceContext.DataStoreSession().GetDatasetsForUserByID(dataset.UserID, nil, pagination)
+		if err != nil {
+			return false, err
+		}
+
+		for _, other := range datasets {
+			if other.UploadID != dataset.UploadID && other.DeviceID != nil && *other.DeviceID == *dataset.DeviceID && other.CurrentState() == upload.StateClosed {
+				return true, nil
+			}
+		}
+
+		if len(datasets) < pagination.Size {
+			return false, nil
+		}
+
+		last := datasets[len(datasets)-1]
+		pagination.Cursor = store.NewCursor(last.CreatedTime, last.ID)
+	}
+}
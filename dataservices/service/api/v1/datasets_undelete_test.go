@@ -0,0 +1,277 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/store"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/dataservices/service/worker"
+	lockStore "github.com/tidepool-org/platform/lock/store"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+var _ = Describe("DatasetsUndelete", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var targetUpload *upload.Upload
+		var context *TestContext
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			targetUpload = upload.Init()
+			targetUpload.UserID = targetUserID
+			targetUpload.ByUser = app.NewID()
+			targetUpload.State = upload.StateOpen
+			targetUpload.DeletedTime = "2016-09-01T12:00:00Z"
+			context = NewTestContext()
+			context.RequestImpl.PathParams["datasetid"] = targetUpload.UploadID
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: targetUpload, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"root": client.Permission{}}, nil}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: nil, Error: nil}}
+			context.DataStoreSessionImpl.UndeleteDatasetOutputs = []error{nil}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if authenticated as owner", func() {
+			v1.DatasetsUndelete(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetByIDInputs).To(Equal([]string{targetUpload.UploadID}))
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.DataStoreSessionImpl.UndeleteDatasetInputs).To(Equal([]*upload.Upload{targetUpload}))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "datasets_undelete", nil}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetUpload}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as uploader and was the uploading user", func() {
+			targetUpload.ByUser = authenticatedUserID
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"upload": client.Permission{}}, nil}}
+			v1.DatasetsUndelete(context)
+			Expect(context.DataStoreSessionImpl.UndeleteDatasetInputs).To(Equal([]*upload.Upload{targetUpload}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetUpload}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			v1.DatasetsUndelete(context)
+			Expect(context.DataStoreSessionImpl.UndeleteDatasetInputs).To(Equal([]*upload.Upload{targetUpload}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetUpload}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if dataset id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "datasetid")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.UndeleteDatasetOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsUndelete(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: err}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.UndeleteDatasetOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsUndelete(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get dataset by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get dataset returns no dataset", func() {
+			context.DataStoreSessionImpl.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.UndeleteDatasetOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsUndelete(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetIDNotFound(targetUpload.UploadID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions does not return needed permissions", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"view": client.Permission{}}, nil}}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.DataStoreSessionImpl.UndeleteDatasetOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsUndelete(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user data is locked", func() {
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: &lockStore.Lock{UserID: targetUserID}, Error: nil}}
+			context.DataStoreSessionImpl.UndeleteDatasetOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsUndelete(context)
+			Expect(context.LockStoreSessionImpl.GetLockForUserByIDInputs).To(Equal([]string{targetUserID}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDataLocked(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if lock store session get lock for user by id returns error", func() {
+			err := errors.New("other")
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: nil, Error: err}}
+			context.DataStoreSessionImpl.UndeleteDatasetOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsUndelete(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get lock for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the dataset is not deleted", func() {
+			targetUpload.DeletedTime = ""
+			context.DataStoreSessionImpl.UndeleteDatasetOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsUndelete(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorDatasetNotDeleted(targetUpload.UploadID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session undelete dataset returns an error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.UndeleteDatasetOutputs = []error{err}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.DatasetsUndelete(context)
+			Expect(context.DataStoreSessionImpl.UndeleteDatasetInputs).To(Equal([]*upload.Upload{targetUpload}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to undelete dataset", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("logs and ignores if metric services record metric returns an error", func() {
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{errors.New("other")}
+			v1.DatasetsUndelete(context)
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "datasets_undelete", nil}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetUpload}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		Context("with closed dataset", func() {
+			var deviceID string
+
+			BeforeEach(func() {
+				deviceID = app.NewID()
+				targetUpload.State = upload.StateClosed
+				targetUpload.DeviceID = app.StringAsPointer(deviceID)
+				context.DataStoreSessionImpl.ActivateDatasetDataOutputs = []error{nil}
+				context.DataStoreSessionImpl.RearchiveDeviceDataRetainedByDatasetOutputs = []error{nil}
+				context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{targetUpload}, Error: nil}}
+			})
+
+			It("succeeds and restores the dataset data without replaying the user if no other closed dataset of the device", func() {
+				otherUpload := upload.Init()
+				otherUpload.UserID = targetUserID
+				otherUpload.State = upload.StateClosed
+				otherUpload.DeviceID = app.StringAsPointer(app.NewID())
+				context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{otherUpload, targetUpload}, Error: nil}}
+				v1.DatasetsUndelete(context)
+				Expect(context.DataStoreSessionImpl.UndeleteDatasetInputs).To(Equal([]*upload.Upload{targetUpload}))
+				Expect(context.DataStoreSessionImpl.ActivateDatasetDataInputs).To(Equal([]*upload.Upload{targetUpload}))
+				Expect(context.DataStoreSessionImpl.RearchiveDeviceDataRetainedByDatasetInputs).To(Equal([]*upload.Upload{targetUpload}))
+				Expect(context.DataStoreSessionImpl.GetDatasetsForUserByIDInputs).To(Equal([]testDataStore.GetDatasetsForUserByIDInput{{UserID: targetUserID, Filter: nil, Pagination: store.NewPagination()}}))
+				Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(BeEmpty())
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetUpload}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("succeeds and restores the dataset data without replaying the user if the dataset has no device id", func() {
+				targetUpload.DeviceID = nil
+				context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{}
+				v1.DatasetsUndelete(context)
+				Expect(context.DataStoreSessionImpl.ActivateDatasetDataInputs).To(Equal([]*upload.Upload{targetUpload}))
+				Expect(context.DataStoreSessionImpl.RearchiveDeviceDataRetainedByDatasetInputs).To(Equal([]*upload.Upload{targetUpload}))
+				Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(BeEmpty())
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetUpload}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("succeeds and queues a replay of the user if a newer closed dataset of the same device", func() {
+				newerUpload := upload.Init()
+				newerUpload.UserID = targetUserID
+				newerUpload.State = upload.StateClosed
+				newerUpload.DeviceID = app.StringAsPointer(deviceID)
+				newerUpload.CreatedTime = "2016-09-02T12:00:00Z"
+				context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{newerUpload, targetUpload}, Error: nil}}
+				context.TaskStoreSessionImpl.CreateTaskOutputs = []error{nil}
+				v1.DatasetsUndelete(context)
+				Expect(context.DataStoreSessionImpl.ActivateDatasetDataInputs).To(Equal([]*upload.Upload{targetUpload}))
+				Expect(context.DataStoreSessionImpl.RearchiveDeviceDataRetainedByDatasetInputs).To(Equal([]*upload.Upload{targetUpload}))
+				Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(HaveLen(1))
+				task := context.TaskStoreSessionImpl.CreateTaskInputs[0]
+				Expect(task.Type).To(Equal(worker.TaskTypeRededuplicateUser))
+				Expect(task.UserID).To(Equal(targetUserID))
+				Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetUpload}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error if data store session activate dataset data returns an error", func() {
+				err := errors.New("other")
+				context.DataStoreSessionImpl.ActivateDatasetDataOutputs = []error{err}
+				context.DataStoreSessionImpl.RearchiveDeviceDataRetainedByDatasetOutputs = []error{}
+				context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsUndelete(context)
+				Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to activate dataset data", []interface{}{err}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error if data store session rearchive device data retained by dataset returns an error", func() {
+				err := errors.New("other")
+				context.DataStoreSessionImpl.RearchiveDeviceDataRetainedByDatasetOutputs = []error{err}
+				context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsUndelete(context)
+				Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to rearchive device data retained by dataset", []interface{}{err}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error if data store session get datasets for user by id returns an error", func() {
+				err := errors.New("other")
+				context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: nil, Error: err}}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsUndelete(context)
+				Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get datasets for user by id", []interface{}{err}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+
+			It("responds with error if task store session create task returns an error", func() {
+				err := errors.New("other")
+				newerUpload := upload.Init()
+				newerUpload.State = upload.StateClosed
+				newerUpload.DeviceID = app.StringAsPointer(deviceID)
+				context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{newerUpload, targetUpload}, Error: nil}}
+				context.TaskStoreSessionImpl.CreateTaskOutputs = []error{err}
+				context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+				v1.DatasetsUndelete(context)
+				Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to create task", []interface{}{err}}}))
+				Expect(context.ValidateTest()).To(BeTrue())
+			})
+		})
+	})
+})
@@ -3,8 +3,11 @@ package v1
 import (
 	"net/http"
 
+	"github.com/tidepool-org/platform/data/types/upload"
 	"github.com/tidepool-org/platform/dataservices/service"
+	"github.com/tidepool-org/platform/dataservices/service/worker"
 	commonService "github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
 	"github.com/tidepool-org/platform/userservices/client"
 )
 
@@ -42,27 +45,47 @@ func DatasetsUpdate(serviceContext service.Context) {
 		}
 	}
 
-	if dataset.State == "closed" || dataset.DataState == "closed" { // TODO: Deprecated DataState (after data migration)
+	if respondIfUserDataLocked(serviceContext, dataset.UserID) {
+		return
+	}
+
+	state := dataset.CurrentState()
+	if state == upload.StateClosed {
 		serviceContext.RespondWithError(ErrorDatasetClosed(datasetID))
 		return
 	}
 
-	dataset.State = "closed"
+	if state != upload.StateClosing {
+		if !upload.IsStateTransitionValid(state, upload.StateClosing) {
+			serviceContext.RespondWithError(ErrorDatasetStateTransitionNotValid(datasetID, state, upload.StateClosing))
+			return
+		}
 
-	if err = serviceContext.DataStoreSession().UpdateDataset(dataset); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to update dataset", err)
-		return
+		var transitioned bool
+		if transitioned, err = serviceContext.DataStoreSession().TransitionDatasetState(dataset, upload.StateClosing, serviceContext.AuthenticationDetails().UserID()); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to transition dataset state", err)
+			return
+		} else if !transitioned {
+			serviceContext.RespondWithError(ErrorDatasetStateTransitionNotValid(datasetID, state, upload.StateClosing))
+			return
+		}
 	}
 
-	deduplicator, err := serviceContext.DataDeduplicatorFactory().NewRegisteredDeduplicatorForDataset(serviceContext.Logger(), serviceContext.DataStoreSession(), dataset)
+	task, err := serviceContext.TaskStoreSession().GetTaskForDatasetByID(datasetID, worker.TaskTypeDeduplicateDataset)
 	if err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to create registered deduplicator for dataset", err)
+		serviceContext.RespondWithInternalServerFailure("Unable to get task for dataset by id", err)
 		return
 	}
 
-	if err = deduplicator.DeduplicateDataset(); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to deduplicate dataset", err)
-		return
+	if task == nil || !task.IsActive() {
+		task = taskStore.NewTask(worker.TaskTypeDeduplicateDataset)
+		task.UserID = dataset.UserID
+		task.DatasetID = datasetID
+		task.CreatedUserID = serviceContext.AuthenticationDetails().UserID()
+		if err = serviceContext.TaskStoreSession().CreateTask(task); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to create task", err)
+			return
+		}
 	}
 
 	if err = serviceContext.MetricServicesClient().RecordMetric(serviceContext, "datasets_update"); err != nil {
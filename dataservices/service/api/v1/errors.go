@@ -3,6 +3,7 @@ package v1
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/tidepool-org/platform/service"
 )
@@ -25,6 +26,33 @@ func ErrorUserIDNotFound(userID string) *service.Error {
 	}
 }
 
+func ErrorUserDataLocked(userID string) *service.Error {
+	return &service.Error{
+		Code:   "user-data-locked",
+		Status: http.StatusLocked,
+		Title:  "data for user with specified id is locked",
+		Detail: fmt.Sprintf("Data for user with id %s is locked", userID),
+	}
+}
+
+func ErrorUserDataRestoreNotUndone(userID string) *service.Error {
+	return &service.Error{
+		Code:   "user-data-restore-not-undone",
+		Status: http.StatusConflict,
+		Title:  "data for user with specified id has a restore that is not undone",
+		Detail: fmt.Sprintf("Data for user with id %s has a restore that is not undone", userID),
+	}
+}
+
+func ErrorRestoreIDMissing() *service.Error {
+	return &service.Error{
+		Code:   "restore-id-missing",
+		Status: http.StatusBadRequest,
+		Title:  "restore id is missing",
+		Detail: "Restore id is missing",
+	}
+}
+
 func ErrorDatasetIDMissing() *service.Error {
 	return &service.Error{
 		Code:   "dataset-id-missing",
@@ -51,3 +79,56 @@ func ErrorDatasetClosed(datasetID string) *service.Error {
 		Detail: fmt.Sprintf("Dataset with id %s is closed for new data", datasetID),
 	}
 }
+
+func ErrorDatasetReopenNotSupported(datasetID string) *service.Error {
+	return &service.Error{
+		Code:   "dataset-reopen-not-supported",
+		Status: http.StatusConflict,
+		Title:  "dataset with specified id does not support reopen",
+		Detail: fmt.Sprintf("Dataset with id %s does not support reopen", datasetID),
+	}
+}
+
+func ErrorDatasetStateTransitionNotValid(datasetID string, fromState string, toState string) *service.Error {
+	return &service.Error{
+		Code:   "dataset-state-transition-not-valid",
+		Status: http.StatusConflict,
+		Title:  "dataset with specified id cannot transition to state",
+		Detail: fmt.Sprintf("Dataset with id %s cannot transition from state %s to state %s", datasetID, strconv.Quote(fromState), strconv.Quote(toState)),
+	}
+}
+
+func ErrorDatasetNotDeleted(datasetID string) *service.Error {
+	return &service.Error{
+		Code:   "dataset-not-deleted",
+		Status: http.StatusConflict,
+		Title:  "dataset with specified id is not deleted",
+		Detail: fmt.Sprintf("Dataset with id %s is not deleted", datasetID),
+	}
+}
+
+func ErrorDatasetChunkInProgress(datasetID string, chunkID string) *service.Error {
+	return &service.Error{
+		Code:   "dataset-chunk-in-progress",
+		Status: http.StatusConflict,
+		Title:  "dataset chunk with specified id is in progress",
+		Detail: fmt.Sprintf("Dataset with id %s has chunk with id %s in progress", datasetID, strconv.Quote(chunkID)),
+	}
+}
+
+func ErrorDatasetDeduplicationNotFound(datasetID string) *service.Error {
+	return &service.Error{
+		Code:   "dataset-deduplication-not-found",
+		Status: http.StatusNotFound,
+		Title:  "deduplication for dataset with specified id not found",
+		Detail: fmt.Sprintf("Deduplication for dataset with id %s not found", datasetID),
+	}
+}
+
+func ErrorValueCursorNotValid(value string) *service.Error {
+	return &service.Error{
+		Code:   "value-not-valid",
+		Title:  "value is not a valid cursor",
+		Detail: fmt.Sprintf("Value %s is not a valid cursor", strconv.Quote(value)),
+	}
+}
@@ -33,6 +33,42 @@ var _ = Describe("Errors", func() {
 		})
 	})
 
+	Context("ErrorUserDataLocked", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorUserDataLocked("1234567890")).To(Equal(
+				&service.Error{
+					Code:   "user-data-locked",
+					Status: 423,
+					Title:  "data for user with specified id is locked",
+					Detail: "Data for user with id 1234567890 is locked",
+				}))
+		})
+	})
+
+	Context("ErrorUserDataRestoreNotUndone", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorUserDataRestoreNotUndone("1234567890")).To(Equal(
+				&service.Error{
+					Code:   "user-data-restore-not-undone",
+					Status: 409,
+					Title:  "data for user with specified id has a restore that is not undone",
+					Detail: "Data for user with id 1234567890 has a restore that is not undone",
+				}))
+		})
+	})
+
+	Context("ErrorRestoreIDMissing", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorRestoreIDMissing()).To(Equal(
+				&service.Error{
+					Code:   "restore-id-missing",
+					Status: 400,
+					Title:  "restore id is missing",
+					Detail: "Restore id is missing",
+				}))
+		})
+	})
+
 	Context("ErrorDatasetIDMissing", func() {
 		It("matches the expected error", func() {
 			Expect(v1.ErrorDatasetIDMissing()).To(Equal(
@@ -68,4 +104,51 @@ var _ = Describe("Errors", func() {
 				}))
 		})
 	})
+
+	Context("ErrorDatasetReopenNotSupported", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorDatasetReopenNotSupported("1234567890abcdef")).To(Equal(
+				&service.Error{
+					Code:   "dataset-reopen-not-supported",
+					Status: 409,
+					Title:  "dataset with specified id does not support reopen",
+					Detail: "Dataset with id 1234567890abcdef does not support reopen",
+				}))
+		})
+	})
+
+	Context("ErrorDatasetNotDeleted", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorDatasetNotDeleted("1234567890abcdef")).To(Equal(
+				&service.Error{
+					Code:   "dataset-not-deleted",
+					Status: 409,
+					Title:  "dataset with specified id is not deleted",
+					Detail: "Dataset with id 1234567890abcdef is not deleted",
+				}))
+		})
+	})
+
+	Context("ErrorDatasetDeduplicationNotFound", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorDatasetDeduplicationNotFound("1234567890abcdef")).To(Equal(
+				&service.Error{
+					Code:   "dataset-deduplication-not-found",
+					Status: 404,
+					Title:  "deduplication for dataset with specified id not found",
+					Detail: "Deduplication for dataset with id 1234567890abcdef not found",
+				}))
+		})
+	})
+
+	Context("ErrorValueCursorNotValid", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorValueCursorNotValid("abc")).To(Equal(
+				&service.Error{
+					Code:   "value-not-valid",
+					Title:  "value is not a valid cursor",
+					Detail: "Value \"abc\" is not a valid cursor",
+				}))
+		})
+	})
 })
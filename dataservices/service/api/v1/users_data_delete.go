@@ -26,11 +26,21 @@ func UsersDataDelete(serviceContext service.Context) {
 
 	// TODO: This should probably be in its own API, but then again, these are very specific tasks and
 	// the whole syncTask thing needs to be reworked, so we'll leave it be for the time being.
+	if err := serviceContext.SyncTaskStoreSession().DestroyTasksForUserByID(targetUserID); err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to delete sync tasks for user by id", err)
+		return
+	}
+
 	if err := serviceContext.TaskStoreSession().DestroyTasksForUserByID(targetUserID); err != nil {
 		serviceContext.RespondWithInternalServerFailure("Unable to delete tasks for user by id", err)
 		return
 	}
 
+	if err := serviceContext.LockStoreSession().DestroyLockForUserByID(targetUserID); err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to delete lock for user by id", err)
+		return
+	}
+
 	if err := serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_data_delete"); err != nil {
 		serviceContext.Logger().WithError(err).Error("Unable to record metric")
 	}
@@ -0,0 +1,30 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+)
+
+// Unlike UsersDataGet, all data and datasets of the user are streamed, including inactive, archived, and deleted
+func UsersDataExportGet(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		return
+	}
+
+	iterator, err := serviceContext.DataStoreSession().IterateAllDataForUserByID(targetUserID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to iterate all data for user", err)
+		return
+	}
+
+	serviceContext.RespondWithStatusAndStream(http.StatusOK, iterator)
+}
@@ -0,0 +1,65 @@
+package v1_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/service"
+)
+
+var _ = Describe("UsersDataExportGet", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var iterator *testDataStore.Iterator
+		var context *TestContext
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			iterator = testDataStore.NewIterator()
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.DataStoreSessionImpl.IterateAllDataForUserByIDOutputs = []testDataStore.IterateAllDataForUserByIDOutput{{Iterator: iterator, Error: nil}}
+		})
+
+		It("succeeds with stream if authenticated as server", func() {
+			v1.UsersDataExportGet(context)
+			Expect(context.DataStoreSessionImpl.IterateAllDataForUserByIDInputs).To(Equal([]string{targetUserID}))
+			Expect(context.RespondWithStatusAndStreamInputs).To(Equal([]RespondWithStatusAndStreamInput{{http.StatusOK, iterator}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.DataStoreSessionImpl.IterateAllDataForUserByIDOutputs = []testDataStore.IterateAllDataForUserByIDOutput{}
+			v1.UsersDataExportGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if not authenticated as server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.DataStoreSessionImpl.IterateAllDataForUserByIDOutputs = []testDataStore.IterateAllDataForUserByIDOutput{}
+			v1.UsersDataExportGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session iterate all data for user by id returns an error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.IterateAllDataForUserByIDOutputs = []testDataStore.IterateAllDataForUserByIDOutput{{Iterator: nil, Error: err}}
+			v1.UsersDataExportGet(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to iterate all data for user", []interface{}{err}}}))
+			Expect(context.RespondWithStatusAndStreamInputs).To(BeEmpty())
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/store"
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+const (
+	ParameterFilterType      = "type"
+	ParameterFilterSubType   = "subType"
+	ParameterFilterDeviceID  = "deviceId"
+	ParameterFilterUploadID  = "uploadId"
+	ParameterFilterStartTime = "startTime"
+	ParameterFilterEndTime   = "endTime"
+)
+
+func UsersDataGet(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		permissions, err := serviceContext.UserServicesClient().GetUserPermissions(serviceContext, serviceContext.AuthenticationDetails().UserID(), targetUserID)
+		if err != nil {
+			if client.IsUnauthorizedError(err) {
+				serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			} else {
+				serviceContext.RespondWithInternalServerFailure("Unable to get user permissions", err)
+			}
+			return
+		}
+		if _, ok := permissions[client.ViewPermission]; !ok {
+			serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			return
+		}
+	}
+
+	filter := store.NewDataFilter()
+	pagination := store.NewPagination()
+
+	// TODO: Consider refactoring query string parsing into separate function/interface/package
+
+	var errors []*commonService.Error
+	for key, values := range serviceContext.Request().URL.Query() {
+		for _, value := range values {
+			switch key {
+			case ParameterFilterType:
+				filter.Types = append(filter.Types, app.SplitStringAndRemoveWhitespace(value, ",")...)
+			case ParameterFilterSubType:
+				filter.SubTypes = append(filter.SubTypes, app.SplitStringAndRemoveWhitespace(value, ",")...)
+			case ParameterFilterDeviceID:
+				filter.DeviceID = value
+			case ParameterFilterUploadID:
+				filter.UploadID = value
+			case ParameterFilterStartTime:
+				if parsedValue, err := time.Parse(time.RFC3339, value); err != nil {
+					errors = append(errors, commonService.ErrorValueTimeNotValid(value, time.RFC3339).WithSourceParameter(ParameterFilterStartTime))
+				} else {
+					filter.StartTime = &parsedValue
+				}
+			case ParameterFilterEndTime:
+				if parsedValue, err := time.Parse(time.RFC3339, value); err != nil {
+					errors = append(errors, commonService.ErrorValueTimeNotValid(value, time.RFC3339).WithSourceParameter(ParameterFilterEndTime))
+				} else {
+					filter.EndTime = &parsedValue
+				}
+			case ParameterPaginationPage:
+				if parsedValue, err := strconv.Atoi(value); err != nil {
+					errors = append(errors, commonService.ErrorTypeNotInteger(value).WithSourceParameter(ParameterPaginationPage))
+				} else if parsedValue < store.PaginationPageMinimum {
+					errors = append(errors, commonService.ErrorValueNotGreaterThanOrEqualTo(parsedValue, store.PaginationPageMinimum).WithSourceParameter(ParameterPaginationPage))
+				} else {
+					pagination.Page = parsedValue
+				}
+			case ParameterPaginationCursor:
+				if parsedValue, err := store.ParseCursor(value); err != nil {
+					errors = append(errors, ErrorValueCursorNotValid(value).WithSourceParameter(ParameterPaginationCursor))
+				} else {
+					pagination.Cursor = parsedValue
+				}
+			case ParameterPaginationSize:
+				if parsedValue, err := strconv.Atoi(value); err != nil {
+					errors = append(errors, commonService.ErrorTypeNotInteger(value).WithSourceParameter(ParameterPaginationSize))
+				} else if parsedValue < store.PaginationSizeMinimum || parsedValue > store.PaginationSizeMaximum {
+					errors = append(errors, commonService.ErrorValueNotInRange(parsedValue, store.PaginationSizeMinimum, store.PaginationSizeMaximum).WithSourceParameter(ParameterPaginationSize))
+				} else {
+					pagination.Size = parsedValue
+				}
+			}
+		}
+	}
+	if filter.StartTime != nil && filter.EndTime != nil && filter.EndTime.Before(*filter.StartTime) {
+		errors = append(errors, commonService.ErrorValueTimeNotAfter(*filter.EndTime, *filter.StartTime, time.RFC3339).WithSourceParameter(ParameterFilterEndTime))
+	}
+	if pagination.Cursor != nil && pagination.Page != store.PaginationPageMinimum {
+		errors = append(errors, commonService.ErrorValueExists().WithSourceParameter(ParameterPaginationPage))
+	}
+	if len(errors) > 0 {
+		serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, errors)
+		return
+	}
+
+	if commonService.IsStreamRequested(serviceContext.Request()) {
+		iterator, err := serviceContext.DataStoreSession().IterateDataForUserByID(targetUserID, filter)
+		if err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to iterate data for user", err)
+			return
+		}
+
+		serviceContext.RespondWithStatusAndStream(http.StatusOK, iterator)
+		return
+	}
+
+	datasetData, err := serviceContext.DataStoreSession().GetDataForUserByID(targetUserID, filter, pagination)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get data for user", err)
+		return
+	}
+
+	if cursor := nextDataCursor(datasetData, pagination); cursor != "" {
+		serviceContext.RespondWithStatusAndDataAndCursor(http.StatusOK, datasetData, cursor)
+		return
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, datasetData)
+}
+
+func nextDataCursor(datasetData []map[string]interface{}, pagination *store.Pagination) string {
+	if len(datasetData) == 0 || len(datasetData) != pagination.Size {
+		return ""
+	}
+
+	lastDatum := datasetData[len(datasetData)-1]
+	lastTime, ok := lastDatum["time"].(string)
+	if !ok || lastTime == "" {
+		return ""
+	}
+	lastID, ok := lastDatum["id"].(string)
+	if !ok || lastID == "" {
+		return ""
+	}
+
+	return store.NewCursor(lastTime, lastID).Token()
+}
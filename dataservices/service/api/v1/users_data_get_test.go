@@ -0,0 +1,259 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/store"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/service"
+	"github.com/tidepool-org/platform/userservices/client"
+)
+
+var _ = Describe("UsersDataGet", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var datasetData []map[string]interface{}
+		var context *TestContext
+		var filter *store.DataFilter
+		var pagination *store.Pagination
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			datasetData = []map[string]interface{}{}
+			for i := 0; i < 3; i++ {
+				datasetData = append(datasetData, map[string]interface{}{"id": app.NewID(), "type": "cbg"})
+			}
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.ViewPermission: client.Permission{}}, nil}}
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{{Data: datasetData, Error: nil}}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			filter = store.NewDataFilter()
+			pagination = store.NewPagination()
+		})
+
+		It("succeeds if authenticated as user, not server", func() {
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as server, not user", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if type query parameter specified", func() {
+			filter.Types = []string{"cbg", "smbg"}
+			context.RequestImpl.Request.URL.RawQuery = "type=cbg,smbg"
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if sub type query parameter specified", func() {
+			filter.SubTypes = []string{"normal"}
+			context.RequestImpl.Request.URL.RawQuery = "subType=normal"
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if device id and upload id query parameters specified", func() {
+			filter.DeviceID = "device-id"
+			filter.UploadID = "upload-id"
+			context.RequestImpl.Request.URL.RawQuery = "deviceId=device-id&uploadId=upload-id"
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if start time and end time query parameters specified", func() {
+			startTime, _ := time.Parse(time.RFC3339, "2016-09-01T00:00:00Z")
+			endTime, _ := time.Parse(time.RFC3339, "2016-09-02T00:00:00Z")
+			filter.StartTime = &startTime
+			filter.EndTime = &endTime
+			context.RequestImpl.Request.URL.RawQuery = "startTime=2016-09-01T00:00:00Z&endTime=2016-09-02T00:00:00Z"
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if page and size query parameters specified", func() {
+			pagination.Page = 2
+			pagination.Size = 10
+			context.RequestImpl.Request.URL.RawQuery = "page=2&size=10"
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if cursor query parameter specified", func() {
+			cursor := store.NewCursor("2016-09-01T12:00:00Z", app.NewID())
+			pagination.Cursor = cursor
+			context.RequestImpl.Request.URL.RawQuery = "cursor=" + cursor.Token()
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, datasetData}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds with next cursor if the page is full", func() {
+			lastDatum := datasetData[len(datasetData)-1]
+			lastDatum["time"] = "2016-09-01T12:00:00Z"
+			pagination.Size = 3
+			context.RequestImpl.Request.URL.RawQuery = "size=3"
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataAndCursorInputs).To(Equal([]RespondWithStatusAndDataAndCursorInput{{http.StatusOK, datasetData, store.NewCursor("2016-09-01T12:00:00Z", lastDatum["id"].(string)).Token()}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(BeEmpty())
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds with stream if stream requested", func() {
+			iterator := testDataStore.NewIterator()
+			filter.Types = []string{"cbg"}
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			context.DataStoreSessionImpl.IterateDataForUserByIDOutputs = []testDataStore.IterateDataForUserByIDOutput{{Iterator: iterator, Error: nil}}
+			context.RequestImpl.Request.Header = http.Header{"Accept": []string{"application/x-ndjson"}}
+			context.RequestImpl.Request.URL.RawQuery = "type=cbg&page=2"
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.IterateDataForUserByIDInputs).To(Equal([]testDataStore.IterateDataForUserByIDInput{{UserID: targetUserID, Filter: filter}}))
+			Expect(context.RespondWithStatusAndStreamInputs).To(Equal([]RespondWithStatusAndStreamInput{{http.StatusOK, iterator}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(BeEmpty())
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			delete(context.RequestImpl.PathParams, "userid")
+			v1.UsersDataGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions returns unauthorized error", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, client.NewUnauthorizedError()}}
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			v1.UsersDataGet(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions returns any other error", func() {
+			err := errors.New("other")
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, err}}
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			v1.UsersDataGet(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get user permissions", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user services client get user permissions does not return needed permissions", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{client.UploadPermission: client.Permission{}}, nil}}
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			v1.UsersDataGet(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if start time query parameter not a valid time", func() {
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "startTime=abc"
+			v1.UsersDataGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueTimeNotValid("abc", time.RFC3339).WithSourceParameter("startTime")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if end time query parameter not a valid time", func() {
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "endTime=abc"
+			v1.UsersDataGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueTimeNotValid("abc", time.RFC3339).WithSourceParameter("endTime")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if end time query parameter is before start time query parameter", func() {
+			startTime, _ := time.Parse(time.RFC3339, "2016-09-02T00:00:00Z")
+			endTime, _ := time.Parse(time.RFC3339, "2016-09-01T00:00:00Z")
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "startTime=2016-09-02T00:00:00Z&endTime=2016-09-01T00:00:00Z"
+			v1.UsersDataGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueTimeNotAfter(endTime, startTime, time.RFC3339).WithSourceParameter("endTime")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if page query parameter not an integer", func() {
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "page=abc"
+			v1.UsersDataGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorTypeNotInteger("").WithSourceParameter("page")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if cursor query parameter is not valid", func() {
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "cursor=abc"
+			v1.UsersDataGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{v1.ErrorValueCursorNotValid("abc").WithSourceParameter("cursor")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if size query parameter is greater than maximum", func() {
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "size=101"
+			v1.UsersDataGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueNotInRange(101, 1, 100).WithSourceParameter("size")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session get data for user returns an error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{{Data: nil, Error: err}}
+			v1.UsersDataGet(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.DataStoreSessionImpl.GetDataForUserByIDInputs).To(Equal([]testDataStore.GetDataForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get data for user", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session iterate data for user returns an error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.GetDataForUserByIDOutputs = []testDataStore.GetDataForUserByIDOutput{}
+			context.DataStoreSessionImpl.IterateDataForUserByIDOutputs = []testDataStore.IterateDataForUserByIDOutput{{Iterator: nil, Error: err}}
+			context.RequestImpl.Request.Header = http.Header{"Accept": []string{"application/x-ndjson"}}
+			v1.UsersDataGet(context)
+			Expect(context.DataStoreSessionImpl.IterateDataForUserByIDInputs).To(Equal([]testDataStore.IterateDataForUserByIDInput{{UserID: targetUserID, Filter: filter}}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to iterate data for user", []interface{}{err}}}))
+			Expect(context.RespondWithStatusAndStreamInputs).To(BeEmpty())
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,35 @@
+package v1
+
+import "github.com/tidepool-org/platform/dataservices/service"
+
+func respondIfUserDataLocked(serviceContext service.Context, targetUserID string) bool {
+	lock, err := serviceContext.LockStoreSession().GetLockForUserByID(targetUserID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get lock for user by id", err)
+		return true
+	}
+	if lock != nil {
+		serviceContext.RespondWithError(ErrorUserDataLocked(targetUserID))
+		return true
+	}
+	return false
+}
+
+func respondIfUserDataLockNotAcquired(serviceContext service.Context, targetUserID string, operation string) bool {
+	acquired, err := serviceContext.LockStoreSession().AcquireLockForUserByID(targetUserID, operation)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to acquire lock for user by id", err)
+		return true
+	}
+	if !acquired {
+		serviceContext.RespondWithError(ErrorUserDataLocked(targetUserID))
+		return true
+	}
+	return false
+}
+
+func releaseUserDataLock(serviceContext service.Context, targetUserID string, operation string) {
+	if err := serviceContext.LockStoreSession().ReleaseLockForUserByID(targetUserID, operation); err != nil {
+		serviceContext.Logger().WithError(err).WithField("userId", targetUserID).Error("Unable to release lock for user by id")
+	}
+}
@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+)
+
+func UsersDataLockCreate(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		return
+	}
+
+	lock, err := serviceContext.LockStoreSession().CreateLockForUserByID(targetUserID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to create lock for user by id", err)
+		return
+	}
+
+	if err = serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_data_lock_create"); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, lock)
+}
@@ -0,0 +1,77 @@
+package v1_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	lockStore "github.com/tidepool-org/platform/lock/store"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/service"
+)
+
+var _ = Describe("UsersDataLockCreate", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var targetLock *lockStore.Lock
+		var context *TestContext
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			targetLock = &lockStore.Lock{UserID: targetUserID, CreatedTime: "2016-09-01T12:00:00Z"}
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.LockStoreSessionImpl.CreateLockForUserByIDOutputs = []testLockStore.CreateLockForUserByIDOutput{{Lock: targetLock, Error: nil}}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if authenticated as server", func() {
+			v1.UsersDataLockCreate(context)
+			Expect(context.LockStoreSessionImpl.CreateLockForUserByIDInputs).To(Equal([]string{targetUserID}))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "users_data_lock_create", nil}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetLock}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.LockStoreSessionImpl.CreateLockForUserByIDOutputs = []testLockStore.CreateLockForUserByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataLockCreate(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if not server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.LockStoreSessionImpl.CreateLockForUserByIDOutputs = []testLockStore.CreateLockForUserByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataLockCreate(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if lock store session create lock for user by id returns error", func() {
+			err := errors.New("other")
+			context.LockStoreSessionImpl.CreateLockForUserByIDOutputs = []testLockStore.CreateLockForUserByIDOutput{{Lock: nil, Error: err}}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataLockCreate(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to create lock for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("logs and ignores if metric services record metric returns an error", func() {
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{errors.New("other")}
+			v1.UsersDataLockCreate(context)
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, targetLock}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
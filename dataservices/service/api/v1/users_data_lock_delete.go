@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+)
+
+func UsersDataLockDelete(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		return
+	}
+
+	if err := serviceContext.LockStoreSession().DestroyLockForUserByID(targetUserID); err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to delete lock for user by id", err)
+		return
+	}
+
+	if err := serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_data_lock_delete"); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, []struct{}{})
+}
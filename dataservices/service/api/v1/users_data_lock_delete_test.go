@@ -0,0 +1,66 @@
+package v1_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/service"
+)
+
+var _ = Describe("UsersDataLockDelete", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var context *TestContext
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.LockStoreSessionImpl.DestroyLockForUserByIDOutputs = []error{nil}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if authenticated as server", func() {
+			v1.UsersDataLockDelete(context)
+			Expect(context.LockStoreSessionImpl.DestroyLockForUserByIDInputs).To(Equal([]string{targetUserID}))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "users_data_lock_delete", nil}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, []struct{}{}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.LockStoreSessionImpl.DestroyLockForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataLockDelete(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if not server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.LockStoreSessionImpl.DestroyLockForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataLockDelete(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if lock store session delete lock for user by id returns error", func() {
+			err := errors.New("other")
+			context.LockStoreSessionImpl.DestroyLockForUserByIDOutputs = []error{err}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataLockDelete(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to delete lock for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
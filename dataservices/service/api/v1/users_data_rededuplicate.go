@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/dataservices/service"
+	"github.com/tidepool-org/platform/dataservices/service/worker"
+	commonService "github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+)
+
+func UsersDataRededuplicate(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		return
+	}
+
+	if respondIfUserDataLocked(serviceContext, targetUserID) {
+		return
+	}
+
+	task := taskStore.NewTask(worker.TaskTypeRededuplicateUser)
+	task.UserID = targetUserID
+	if err := serviceContext.TaskStoreSession().CreateTask(task); err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to create task", err)
+		return
+	}
+
+	if err := serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_data_rededuplicate"); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusAccepted, task)
+}
@@ -0,0 +1,97 @@
+package v1_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/dataservices/service/worker"
+	lockStore "github.com/tidepool-org/platform/lock/store"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+)
+
+var _ = Describe("UsersDataRededuplicate", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var context *TestContext
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: nil, Error: nil}}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{nil}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if authenticated as server", func() {
+			v1.UsersDataRededuplicate(context)
+			Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(HaveLen(1))
+			task := context.TaskStoreSessionImpl.CreateTaskInputs[0]
+			Expect(task.Type).To(Equal(worker.TaskTypeRededuplicateUser))
+			Expect(task.UserID).To(Equal(targetUserID))
+			Expect(task.State).To(Equal(taskStore.TaskStatePending))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "users_data_rededuplicate", nil}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusAccepted, task}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRededuplicate(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if not server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRededuplicate(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user data is locked", func() {
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: &lockStore.Lock{UserID: targetUserID}, Error: nil}}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRededuplicate(context)
+			Expect(context.LockStoreSessionImpl.GetLockForUserByIDInputs).To(Equal([]string{targetUserID}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDataLocked(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if lock store session get lock for user by id returns error", func() {
+			err := errors.New("other")
+			context.LockStoreSessionImpl.GetLockForUserByIDOutputs = []testLockStore.GetLockForUserByIDOutput{{Lock: nil, Error: err}}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRededuplicate(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get lock for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if task store session create task returns error", func() {
+			err := errors.New("other")
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{err}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRededuplicate(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to create task", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
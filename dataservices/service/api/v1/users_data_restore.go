@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+)
+
+const (
+	ParameterRestoreTime = "time"
+
+	RestoreLockOperation = "restore"
+)
+
+type UsersDataRestoreResult struct {
+	RestoreID string `json:"restoreId"`
+}
+
+func UsersDataRestore(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		return
+	}
+
+	restoreTime, errors := parseRestoreTime(serviceContext)
+	if len(errors) > 0 {
+		serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, errors)
+		return
+	}
+
+	if respondIfUserDataLockNotAcquired(serviceContext, targetUserID, RestoreLockOperation) {
+		return
+	}
+	defer releaseUserDataLock(serviceContext, targetUserID, RestoreLockOperation)
+
+	restoreID := app.NewID()
+	restored, err := serviceContext.DataStoreSession().RestoreDataForUserByID(targetUserID, restoreID, restoreTime)
+	if err != nil {
+		if undoErr := serviceContext.DataStoreSession().UndoRestoreDataForUserByID(targetUserID, restoreID); undoErr != nil {
+			serviceContext.Logger().WithError(undoErr).WithField("restoreId", restoreID).Error("Unable to undo restore data for user by id")
+		}
+		serviceContext.RespondWithInternalServerFailure("Unable to restore data for user by id", err)
+		return
+	} else if !restored {
+		serviceContext.RespondWithError(ErrorUserDataRestoreNotUndone(targetUserID))
+		return
+	}
+
+	if err = serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_data_restore"); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, &UsersDataRestoreResult{RestoreID: restoreID})
+}
+
+func parseRestoreTime(serviceContext service.Context) (time.Time, []*commonService.Error) {
+	value := serviceContext.Request().URL.Query().Get(ParameterRestoreTime)
+	if value == "" {
+		return time.Time{}, []*commonService.Error{commonService.ErrorValueNotExists().WithSourceParameter(ParameterRestoreTime)}
+	}
+
+	restoreTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, []*commonService.Error{commonService.ErrorValueTimeNotValid(value, time.RFC3339).WithSourceParameter(ParameterRestoreTime)}
+	}
+	if !restoreTime.Before(time.Now()) {
+		return time.Time{}, []*commonService.Error{commonService.ErrorValueTimeNotBeforeNow(restoreTime, time.RFC3339).WithSourceParameter(ParameterRestoreTime)}
+	}
+
+	return restoreTime, nil
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/tidepool-org/platform/data/store"
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+)
+
+type DataRestorePreview struct {
+	Time     string                    `json:"time"`
+	Activate *DataRestorePreviewAction `json:"activate"`
+	Archive  *DataRestorePreviewAction `json:"archive"`
+}
+
+type DataRestorePreviewAction struct {
+	Count     int      `json:"count"`
+	SampleIDs []string `json:"sampleIds"`
+}
+
+func NewDataRestorePreviewAction(dataPreview *store.DataPreview) *DataRestorePreviewAction {
+	if dataPreview == nil {
+		return &DataRestorePreviewAction{SampleIDs: []string{}}
+	}
+	return &DataRestorePreviewAction{
+		Count:     dataPreview.Count,
+		SampleIDs: dataPreview.SampleIDs,
+	}
+}
+
+func UsersDataRestorePreviewGet(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		return
+	}
+
+	restoreTime, errors := parseRestoreTime(serviceContext)
+	if len(errors) > 0 {
+		serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, errors)
+		return
+	}
+
+	preview, err := serviceContext.DataStoreSession().PreviewRestoreDataForUserByID(targetUserID, restoreTime)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to preview restore data for user by id", err)
+		return
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, &DataRestorePreview{
+		Time:     restoreTime.UTC().Format(time.RFC3339),
+		Activate: NewDataRestorePreviewAction(preview.Activate),
+		Archive:  NewDataRestorePreviewAction(preview.Archive),
+	})
+}
@@ -0,0 +1,84 @@
+package v1_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"net/http"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/store"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/service"
+)
+
+var _ = Describe("UsersDataRestorePreviewGet", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var restoreTime time.Time
+		var preview *store.DataRestorePreview
+		var context *TestContext
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			restoreTime, _ = time.Parse(time.RFC3339, "2016-09-01T05:00:00-07:00")
+			preview = &store.DataRestorePreview{
+				Activate: &store.DataPreview{Count: 1, SampleIDs: []string{"a"}},
+				Archive:  &store.DataPreview{Count: 2, SampleIDs: []string{"b", "c"}},
+			}
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.RequestImpl.Request.URL.RawQuery = "time=2016-09-01T05:00:00-07:00"
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.DataStoreSessionImpl.PreviewRestoreDataForUserByIDOutputs = []testDataStore.PreviewRestoreDataForUserByIDOutput{{Preview: preview, Error: nil}}
+		})
+
+		It("succeeds if authenticated as server", func() {
+			v1.UsersDataRestorePreviewGet(context)
+			Expect(context.DataStoreSessionImpl.PreviewRestoreDataForUserByIDInputs).To(Equal([]testDataStore.PreviewRestoreDataForUserByIDInput{{UserID: targetUserID, RestoreTime: restoreTime}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, &v1.DataRestorePreview{
+				Time:     "2016-09-01T12:00:00Z",
+				Activate: &v1.DataRestorePreviewAction{Count: 1, SampleIDs: []string{"a"}},
+				Archive:  &v1.DataRestorePreviewAction{Count: 2, SampleIDs: []string{"b", "c"}},
+			}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.DataStoreSessionImpl.PreviewRestoreDataForUserByIDOutputs = []testDataStore.PreviewRestoreDataForUserByIDOutput{}
+			v1.UsersDataRestorePreviewGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if not server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.DataStoreSessionImpl.PreviewRestoreDataForUserByIDOutputs = []testDataStore.PreviewRestoreDataForUserByIDOutput{}
+			v1.UsersDataRestorePreviewGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if time is not valid", func() {
+			context.RequestImpl.Request.URL.RawQuery = "time=abc"
+			context.DataStoreSessionImpl.PreviewRestoreDataForUserByIDOutputs = []testDataStore.PreviewRestoreDataForUserByIDOutput{}
+			v1.UsersDataRestorePreviewGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueTimeNotValid("abc", time.RFC3339).WithSourceParameter("time")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session preview restore data for user by id returns error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.PreviewRestoreDataForUserByIDOutputs = []testDataStore.PreviewRestoreDataForUserByIDOutput{{Preview: nil, Error: err}}
+			v1.UsersDataRestorePreviewGet(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to preview restore data for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,174 @@
+package v1_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"net/http"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/service"
+)
+
+var _ = Describe("UsersDataRestore", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var restoreTime time.Time
+		var context *TestContext
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			restoreTime, _ = time.Parse(time.RFC3339, "2016-09-01T12:00:00Z")
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.RequestImpl.Request.URL.RawQuery = "time=2016-09-01T12:00:00Z"
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{nil}
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{{Restored: true, Error: nil}}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if authenticated as server", func() {
+			v1.UsersDataRestore(context)
+			Expect(context.LockStoreSessionImpl.AcquireLockForUserByIDInputs).To(Equal([]testLockStore.AcquireLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.DataStoreSessionImpl.RestoreDataForUserByIDInputs).To(HaveLen(1))
+			restoreID := context.DataStoreSessionImpl.RestoreDataForUserByIDInputs[0].RestoreID
+			Expect(restoreID).To(MatchRegexp("^[0-9a-f]{32}$"))
+			Expect(context.DataStoreSessionImpl.RestoreDataForUserByIDInputs).To(Equal([]testDataStore.RestoreDataForUserByIDInput{{UserID: targetUserID, RestoreID: restoreID, RestoreTime: restoreTime}}))
+			Expect(context.LockStoreSessionImpl.ReleaseLockForUserByIDInputs).To(Equal([]testLockStore.ReleaseLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "users_data_restore", nil}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, &v1.UsersDataRestoreResult{RestoreID: restoreID}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if not server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user data is locked", func() {
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: false, Error: nil}}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.LockStoreSessionImpl.AcquireLockForUserByIDInputs).To(Equal([]testLockStore.AcquireLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDataLocked(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if lock store session acquire lock for user by id returns error", func() {
+			err := errors.New("other")
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: false, Error: err}}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to acquire lock for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if time is not provided", func() {
+			context.RequestImpl.Request.URL.RawQuery = ""
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueNotExists().WithSourceParameter("time")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if time is not valid", func() {
+			context.RequestImpl.Request.URL.RawQuery = "time=abc"
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueTimeNotValid("abc", time.RFC3339).WithSourceParameter("time")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if time is not before now", func() {
+			futureTime, _ := time.Parse(time.RFC3339, "2999-01-01T00:00:00Z")
+			context.RequestImpl.Request.URL.RawQuery = "time=2999-01-01T00:00:00Z"
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueTimeNotBeforeNow(futureTime, time.RFC3339).WithSourceParameter("time")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session restore data for user by id returns error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{{Restored: false, Error: err}}
+			context.DataStoreSessionImpl.UndoRestoreDataForUserByIDOutputs = []error{nil}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.DataStoreSessionImpl.RestoreDataForUserByIDInputs).To(HaveLen(1))
+			restoreID := context.DataStoreSessionImpl.RestoreDataForUserByIDInputs[0].RestoreID
+			Expect(context.DataStoreSessionImpl.UndoRestoreDataForUserByIDInputs).To(Equal([]testDataStore.UndoRestoreDataForUserByIDInput{{UserID: targetUserID, RestoreID: restoreID}}))
+			Expect(context.LockStoreSessionImpl.ReleaseLockForUserByIDInputs).To(Equal([]testLockStore.ReleaseLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to restore data for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session restore data for user by id returns error and undo restore data for user by id returns error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{{Restored: false, Error: err}}
+			context.DataStoreSessionImpl.UndoRestoreDataForUserByIDOutputs = []error{errors.New("undo")}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.LockStoreSessionImpl.ReleaseLockForUserByIDInputs).To(Equal([]testLockStore.ReleaseLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to restore data for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if an earlier restore of the data for user by id is not undone", func() {
+			context.DataStoreSessionImpl.RestoreDataForUserByIDOutputs = []testDataStore.RestoreDataForUserByIDOutput{{Restored: false, Error: nil}}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestore(context)
+			Expect(context.DataStoreSessionImpl.RestoreDataForUserByIDInputs).To(HaveLen(1))
+			Expect(context.DataStoreSessionImpl.UndoRestoreDataForUserByIDInputs).To(BeEmpty())
+			Expect(context.LockStoreSessionImpl.ReleaseLockForUserByIDInputs).To(Equal([]testLockStore.ReleaseLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDataRestoreNotUndone(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds even if lock store session release lock for user by id returns error", func() {
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{errors.New("other")}
+			v1.UsersDataRestore(context)
+			Expect(context.LockStoreSessionImpl.ReleaseLockForUserByIDInputs).To(Equal([]testLockStore.ReleaseLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/dataservices/service"
+	commonService "github.com/tidepool-org/platform/service"
+)
+
+func UsersDataRestoreUndo(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+	restoreID := serviceContext.Request().PathParam("restoreid")
+	if restoreID == "" {
+		serviceContext.RespondWithError(ErrorRestoreIDMissing())
+		return
+	}
+
+	if !serviceContext.AuthenticationDetails().IsServer() {
+		serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		return
+	}
+
+	if respondIfUserDataLockNotAcquired(serviceContext, targetUserID, RestoreLockOperation) {
+		return
+	}
+	defer releaseUserDataLock(serviceContext, targetUserID, RestoreLockOperation)
+
+	if err := serviceContext.DataStoreSession().UndoRestoreDataForUserByID(targetUserID, restoreID); err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to undo restore data for user by id", err)
+		return
+	}
+
+	if err := serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_data_restore_undo"); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, []struct{}{})
+}
@@ -0,0 +1,113 @@
+package v1_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/service"
+)
+
+var _ = Describe("UsersDataRestoreUndo", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var restoreID string
+		var context *TestContext
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			restoreID = app.NewID()
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.RequestImpl.PathParams["restoreid"] = restoreID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{nil}
+			context.DataStoreSessionImpl.UndoRestoreDataForUserByIDOutputs = []error{nil}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if authenticated as server", func() {
+			v1.UsersDataRestoreUndo(context)
+			Expect(context.LockStoreSessionImpl.AcquireLockForUserByIDInputs).To(Equal([]testLockStore.AcquireLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.DataStoreSessionImpl.UndoRestoreDataForUserByIDInputs).To(Equal([]testDataStore.UndoRestoreDataForUserByIDInput{{UserID: targetUserID, RestoreID: restoreID}}))
+			Expect(context.LockStoreSessionImpl.ReleaseLockForUserByIDInputs).To(Equal([]testLockStore.ReleaseLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "users_data_restore_undo", nil}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, []struct{}{}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.UndoRestoreDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestoreUndo(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if restore id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "restoreid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.UndoRestoreDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestoreUndo(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorRestoreIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if not server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.UndoRestoreDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestoreUndo(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user data is locked", func() {
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: false, Error: nil}}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.UndoRestoreDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestoreUndo(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDataLocked(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if lock store session acquire lock for user by id returns error", func() {
+			err := errors.New("other")
+			context.LockStoreSessionImpl.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: false, Error: err}}
+			context.LockStoreSessionImpl.ReleaseLockForUserByIDOutputs = []error{}
+			context.DataStoreSessionImpl.UndoRestoreDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestoreUndo(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to acquire lock for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if data store session undo restore data for user by id returns error", func() {
+			err := errors.New("other")
+			context.DataStoreSessionImpl.UndoRestoreDataForUserByIDOutputs = []error{err}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDataRestoreUndo(context)
+			Expect(context.LockStoreSessionImpl.ReleaseLockForUserByIDInputs).To(Equal([]testLockStore.ReleaseLockForUserByIDInput{{UserID: targetUserID, Operation: "restore"}}))
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to undo restore data for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
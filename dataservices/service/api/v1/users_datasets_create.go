@@ -36,6 +36,10 @@ func UsersDatasetsCreate(serviceContext service.Context) {
 		}
 	}
 
+	if respondIfUserDataLocked(serviceContext, targetUserID) {
+		return
+	}
+
 	targetUserGroupID, err := serviceContext.UserServicesClient().GetUserGroupID(serviceContext, targetUserID)
 	if err != nil {
 		if client.IsUnauthorizedError(err) {
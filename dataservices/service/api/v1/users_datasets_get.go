@@ -11,9 +11,10 @@ import (
 )
 
 const (
-	ParameterFilterDeleted  = "deleted"
-	ParameterPaginationPage = "page"
-	ParameterPaginationSize = "size"
+	ParameterFilterDeleted    = "deleted"
+	ParameterPaginationPage   = "page"
+	ParameterPaginationSize   = "size"
+	ParameterPaginationCursor = "cursor"
 )
 
 func UsersDatasetsGet(serviceContext service.Context) {
@@ -62,6 +63,12 @@ func UsersDatasetsGet(serviceContext service.Context) {
 				} else {
 					pagination.Page = parsedValue
 				}
+			case ParameterPaginationCursor:
+				if parsedValue, err := store.ParseCursor(value); err != nil {
+					errors = append(errors, ErrorValueCursorNotValid(value).WithSourceParameter(ParameterPaginationCursor))
+				} else {
+					pagination.Cursor = parsedValue
+				}
 			case ParameterPaginationSize:
 				if parsedValue, err := strconv.Atoi(value); err != nil {
 					errors = append(errors, commonService.ErrorTypeNotInteger(value).WithSourceParameter(ParameterPaginationSize))
@@ -73,6 +80,9 @@ func UsersDatasetsGet(serviceContext service.Context) {
 			}
 		}
 	}
+	if pagination.Cursor != nil && pagination.Page != store.PaginationPageMinimum {
+		errors = append(errors, commonService.ErrorValueExists().WithSourceParameter(ParameterPaginationPage))
+	}
 	if len(errors) > 0 {
 		serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, errors)
 		return
@@ -84,5 +94,12 @@ func UsersDatasetsGet(serviceContext service.Context) {
 		return
 	}
 
+	if len(datasets) == pagination.Size {
+		if lastDataset := datasets[len(datasets)-1]; lastDataset.CreatedTime != "" && lastDataset.ID != "" {
+			serviceContext.RespondWithStatusAndDataAndCursor(http.StatusOK, datasets, store.NewCursor(lastDataset.CreatedTime, lastDataset.ID).Token())
+			return
+		}
+	}
+
 	serviceContext.RespondWithStatusAndData(http.StatusOK, datasets)
 }
@@ -97,6 +97,29 @@ var _ = Describe("UsersDatasetsGet", func() {
 			Expect(context.ValidateTest()).To(BeTrue())
 		})
 
+		It("succeeds if cursor query parameter specified", func() {
+			cursor := store.NewCursor("2016-09-01T12:00:00Z", app.NewID())
+			pagination.Cursor = cursor
+			context.RequestImpl.Request.URL.RawQuery = "cursor=" + cursor.Token()
+			v1.UsersDatasetsGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetsForUserByIDInputs).To(Equal([]testDataStore.GetDatasetsForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, uploads}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds with next cursor if the page is full", func() {
+			lastUpload := uploads[len(uploads)-1]
+			lastUpload.ID = app.NewID()
+			lastUpload.CreatedTime = "2016-09-01T12:00:00Z"
+			pagination.Size = 3
+			context.RequestImpl.Request.URL.RawQuery = "size=3"
+			v1.UsersDatasetsGet(context)
+			Expect(context.DataStoreSessionImpl.GetDatasetsForUserByIDInputs).To(Equal([]testDataStore.GetDatasetsForUserByIDInput{{UserID: targetUserID, Filter: filter, Pagination: pagination}}))
+			Expect(context.RespondWithStatusAndDataAndCursorInputs).To(Equal([]RespondWithStatusAndDataAndCursorInput{{http.StatusOK, uploads, store.NewCursor(lastUpload.CreatedTime, lastUpload.ID).Token()}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(BeEmpty())
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
 		It("panics if context is missing", func() {
 			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
 			context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{}
@@ -196,6 +219,22 @@ var _ = Describe("UsersDatasetsGet", func() {
 			Expect(context.ValidateTest()).To(BeTrue())
 		})
 
+		It("responds with error if cursor query parameter is not valid", func() {
+			context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "cursor=abc"
+			v1.UsersDatasetsGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{v1.ErrorValueCursorNotValid("abc").WithSourceParameter("cursor")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if cursor and page query parameters are both specified", func() {
+			context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{}
+			context.RequestImpl.Request.URL.RawQuery = "page=1&cursor=" + store.NewCursor("2016-09-01T12:00:00Z", app.NewID()).Token()
+			v1.UsersDatasetsGet(context)
+			Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorValueExists().WithSourceParameter("page")}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
 		It("responds with error if size query parameter not an integer", func() {
 			context.DataStoreSessionImpl.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{}
 			context.RequestImpl.Request.URL.RawQuery = "size=abc"
@@ -4,10 +4,25 @@ import "github.com/tidepool-org/platform/dataservices/service"
 
 func Routes() []service.Route {
 	return []service.Route{
+		service.MakeRoute("POST", "/v1/datasets/:datasetid/abort", Authenticate(DatasetsAbort)),
 		service.MakeRoute("POST", "/v1/datasets/:datasetid/data", Authenticate(DatasetsDataCreate)),
+		service.MakeRoute("GET", "/v1/datasets/:datasetid/data", Authenticate(DatasetsDataGet)),
+		service.MakeRoute("GET", "/v1/datasets/:datasetid/deduplication", Authenticate(DatasetsDeduplicationGet)),
+		service.MakeRoute("GET", "/v1/datasets/:datasetid/deduplication-preview", Authenticate(DatasetsDeduplicationPreviewGet)),
 		service.MakeRoute("DELETE", "/v1/datasets/:datasetid", Authenticate(DatasetsDelete)),
+		service.MakeRoute("GET", "/v1/datasets/:datasetid", Authenticate(DatasetsGet)),
 		service.MakeRoute("PUT", "/v1/datasets/:datasetid", Authenticate(DatasetsUpdate)),
+		service.MakeRoute("POST", "/v1/datasets/:datasetid/reopen", Authenticate(DatasetsReopen)),
+		service.MakeRoute("POST", "/v1/datasets/:datasetid/undelete", Authenticate(DatasetsUndelete)),
 		service.MakeRoute("DELETE", "/v1/users/:userid/data", Authenticate(UsersDataDelete)),
+		service.MakeRoute("GET", "/v1/users/:userid/data", Authenticate(UsersDataGet)),
+		service.MakeRoute("GET", "/v1/users/:userid/data/export", Authenticate(UsersDataExportGet)),
+		service.MakeRoute("DELETE", "/v1/users/:userid/data/lock", Authenticate(UsersDataLockDelete)),
+		service.MakeRoute("PUT", "/v1/users/:userid/data/lock", Authenticate(UsersDataLockCreate)),
+		service.MakeRoute("POST", "/v1/users/:userid/data/rededuplicate", Authenticate(UsersDataRededuplicate)),
+		service.MakeRoute("POST", "/v1/users/:userid/data/restore", Authenticate(UsersDataRestore)),
+		service.MakeRoute("POST", "/v1/users/:userid/data/restore/:restoreid/undo", Authenticate(UsersDataRestoreUndo)),
+		service.MakeRoute("GET", "/v1/users/:userid/data/restore-preview", Authenticate(UsersDataRestorePreviewGet)),
 		service.MakeRoute("POST", "/v1/users/:userid/datasets", Authenticate(UsersDatasetsCreate)),
 		service.MakeRoute("GET", "/v1/users/:userid/datasets", Authenticate(UsersDatasetsGet)),
 		service.MakeRoute("GET", "/v1/time", TimeGet),
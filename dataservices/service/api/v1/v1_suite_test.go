@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"io"
 	"net/http"
 	"net/url"
 	"testing"
@@ -15,11 +16,13 @@ import (
 	testDataDeduplicator "github.com/tidepool-org/platform/data/deduplicator/test"
 	dataStore "github.com/tidepool-org/platform/data/store"
 	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	lockStore "github.com/tidepool-org/platform/lock/store"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
 	"github.com/tidepool-org/platform/log"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
 	"github.com/tidepool-org/platform/service"
-	commonStore "github.com/tidepool-org/platform/store"
 	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
 	userservicesClient "github.com/tidepool-org/platform/userservices/client"
 )
 
@@ -112,36 +115,22 @@ type RespondWithStatusAndDataInput struct {
 	data       interface{}
 }
 
-type TestTaskStoreSession struct {
-	DestroyTasksForUserByIDInputs  []string
-	DestroyTasksForUserByIDOutputs []error
-}
-
-func (t *TestTaskStoreSession) IsClosed() bool {
-	panic("Unexpected invocation of IsClosed on TestTaskStoreSession")
-}
-
-func (t *TestTaskStoreSession) Close() {
-	panic("Unexpected invocation of Close on TestTaskStoreSession")
-}
-
-func (t *TestTaskStoreSession) Logger() log.Logger {
-	panic("Unexpected invocation of Logger on TestTaskStoreSession")
-}
-
-func (t *TestTaskStoreSession) SetAgent(agent commonStore.Agent) {
-	panic("Unexpected invocation of SetAgent on TestTaskStoreSession")
+type RespondWithStatusAndDataAndCursorInput struct {
+	statusCode int
+	data       interface{}
+	cursor     string
 }
 
-func (t *TestTaskStoreSession) DestroyTasksForUserByID(userID string) error {
-	t.DestroyTasksForUserByIDInputs = append(t.DestroyTasksForUserByIDInputs, userID)
-	output := t.DestroyTasksForUserByIDOutputs[0]
-	t.DestroyTasksForUserByIDOutputs = t.DestroyTasksForUserByIDOutputs[1:]
-	return output
+type RespondWithStatusAndStreamInput struct {
+	statusCode int
+	stream     service.Stream
 }
 
-func (t *TestTaskStoreSession) ValidateTest() bool {
-	return len(t.DestroyTasksForUserByIDOutputs) == 0
+type RespondWithStatusAndAttachmentInput struct {
+	statusCode  int
+	contentType string
+	filename    string
+	attachment  io.ReadCloser
 }
 
 type TestAuthenticationDetails struct {
@@ -171,18 +160,24 @@ func (t *TestAuthenticationDetails) ValidateTest() bool {
 }
 
 type TestContext struct {
-	LoggerImpl                             log.Logger
-	RequestImpl                            *rest.Request
-	RespondWithErrorInputs                 []*service.Error
-	RespondWithInternalServerFailureInputs []RespondWithInternalServerFailureInput
-	RespondWithStatusAndErrorsInputs       []RespondWithStatusAndErrorsInput
-	RespondWithStatusAndDataInputs         []RespondWithStatusAndDataInput
-	MetricServicesClientImpl               *TestMetricServicesClient
-	UserServicesClientImpl                 *TestUserServicesClient
-	DataDeduplicatorFactoryImpl            *testDataDeduplicator.Factory
-	DataStoreSessionImpl                   *testDataStore.Session
-	TaskStoreSessionImpl                   *TestTaskStoreSession
-	AuthenticationDetailsImpl              *TestAuthenticationDetails
+	LoggerImpl                              log.Logger
+	RequestImpl                             *rest.Request
+	RespondWithErrorInputs                  []*service.Error
+	RespondWithInternalServerFailureInputs  []RespondWithInternalServerFailureInput
+	RespondWithStatusAndErrorsInputs        []RespondWithStatusAndErrorsInput
+	RespondWithStatusAndDataInputs          []RespondWithStatusAndDataInput
+	RespondWithStatusAndDataAndCursorInputs []RespondWithStatusAndDataAndCursorInput
+	RespondWithStatusAndStreamInputs        []RespondWithStatusAndStreamInput
+	RespondWithStatusAndAttachmentInputs    []RespondWithStatusAndAttachmentInput
+	MetricServicesClientImpl                *TestMetricServicesClient
+	UserServicesClientImpl                  *TestUserServicesClient
+	DataFactoryImpl                         data.Factory
+	DataDeduplicatorFactoryImpl             *testDataDeduplicator.Factory
+	DataStoreSessionImpl                    *testDataStore.Session
+	SyncTaskStoreSessionImpl                *testTaskStore.Session
+	TaskStoreSessionImpl                    *testTaskStore.Session
+	LockStoreSessionImpl                    *testLockStore.Session
+	AuthenticationDetailsImpl               *TestAuthenticationDetails
 }
 
 func NewTestContext() *TestContext {
@@ -198,7 +193,9 @@ func NewTestContext() *TestContext {
 		UserServicesClientImpl:      &TestUserServicesClient{},
 		DataDeduplicatorFactoryImpl: testDataDeduplicator.NewFactory(),
 		DataStoreSessionImpl:        testDataStore.NewSession(),
-		TaskStoreSessionImpl:        &TestTaskStoreSession{},
+		SyncTaskStoreSessionImpl:    testTaskStore.NewSession(),
+		TaskStoreSessionImpl:        testTaskStore.NewSession(),
+		LockStoreSessionImpl:        testLockStore.NewSession(),
 		AuthenticationDetailsImpl:   &TestAuthenticationDetails{},
 	}
 }
@@ -231,6 +228,18 @@ func (t *TestContext) RespondWithStatusAndData(statusCode int, data interface{})
 	t.RespondWithStatusAndDataInputs = append(t.RespondWithStatusAndDataInputs, RespondWithStatusAndDataInput{statusCode, data})
 }
 
+func (t *TestContext) RespondWithStatusAndDataAndCursor(statusCode int, data interface{}, cursor string) {
+	t.RespondWithStatusAndDataAndCursorInputs = append(t.RespondWithStatusAndDataAndCursorInputs, RespondWithStatusAndDataAndCursorInput{statusCode, data, cursor})
+}
+
+func (t *TestContext) RespondWithStatusAndStream(statusCode int, stream service.Stream) {
+	t.RespondWithStatusAndStreamInputs = append(t.RespondWithStatusAndStreamInputs, RespondWithStatusAndStreamInput{statusCode, stream})
+}
+
+func (t *TestContext) RespondWithStatusAndAttachment(statusCode int, contentType string, filename string, attachment io.ReadCloser) {
+	t.RespondWithStatusAndAttachmentInputs = append(t.RespondWithStatusAndAttachmentInputs, RespondWithStatusAndAttachmentInput{statusCode, contentType, filename, attachment})
+}
+
 func (t *TestContext) MetricServicesClient() metricservicesClient.Client {
 	return t.MetricServicesClientImpl
 }
@@ -240,7 +249,10 @@ func (t *TestContext) UserServicesClient() userservicesClient.Client {
 }
 
 func (t *TestContext) DataFactory() data.Factory {
-	panic("Unexpected invocation of DataFactory on TestContext")
+	if t.DataFactoryImpl == nil {
+		panic("Unexpected invocation of DataFactory on TestContext")
+	}
+	return t.DataFactoryImpl
 }
 
 func (t *TestContext) DataDeduplicatorFactory() deduplicator.Factory {
@@ -251,10 +263,18 @@ func (t *TestContext) DataStoreSession() dataStore.Session {
 	return t.DataStoreSessionImpl
 }
 
+func (t *TestContext) SyncTaskStoreSession() taskStore.Session {
+	return t.SyncTaskStoreSessionImpl
+}
+
 func (t *TestContext) TaskStoreSession() taskStore.Session {
 	return t.TaskStoreSessionImpl
 }
 
+func (t *TestContext) LockStoreSession() lockStore.Session {
+	return t.LockStoreSessionImpl
+}
+
 func (t *TestContext) AuthenticationDetails() userservicesClient.AuthenticationDetails {
 	return t.AuthenticationDetailsImpl
 }
@@ -268,6 +288,8 @@ func (t *TestContext) ValidateTest() bool {
 		(t.UserServicesClientImpl == nil || t.UserServicesClientImpl.ValidateTest()) &&
 		(t.DataDeduplicatorFactoryImpl == nil || t.DataDeduplicatorFactoryImpl.UnusedOutputsCount() == 0) &&
 		(t.DataStoreSessionImpl == nil || t.DataStoreSessionImpl.UnusedOutputsCount() == 0) &&
-		(t.TaskStoreSessionImpl == nil || t.TaskStoreSessionImpl.ValidateTest()) &&
+		(t.SyncTaskStoreSessionImpl == nil || t.SyncTaskStoreSessionImpl.UnusedOutputsCount() == 0) &&
+		(t.TaskStoreSessionImpl == nil || t.TaskStoreSessionImpl.UnusedOutputsCount() == 0) &&
+		(t.LockStoreSessionImpl == nil || t.LockStoreSessionImpl.UnusedOutputsCount() == 0) &&
 		(t.AuthenticationDetailsImpl == nil || t.AuthenticationDetailsImpl.ValidateTest())
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/tidepool-org/platform/data"
 	"github.com/tidepool-org/platform/data/deduplicator"
 	dataStore "github.com/tidepool-org/platform/data/store"
+	lockStore "github.com/tidepool-org/platform/lock/store"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
 	"github.com/tidepool-org/platform/service"
 	taskStore "github.com/tidepool-org/platform/task/store"
@@ -20,7 +21,9 @@ type Context interface {
 	DataDeduplicatorFactory() deduplicator.Factory
 
 	DataStoreSession() dataStore.Session
+	SyncTaskStoreSession() taskStore.Session
 	TaskStoreSession() taskStore.Session
+	LockStoreSession() lockStore.Session
 
 	AuthenticationDetails() userservicesClient.AuthenticationDetails
 	SetAuthenticationDetails(authenticationDetails userservicesClient.AuthenticationDetails)
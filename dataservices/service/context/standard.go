@@ -7,6 +7,7 @@ import (
 	"github.com/tidepool-org/platform/data/deduplicator"
 	dataStore "github.com/tidepool-org/platform/data/store"
 	"github.com/tidepool-org/platform/dataservices/service"
+	lockStore "github.com/tidepool-org/platform/lock/store"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
 	commonService "github.com/tidepool-org/platform/service"
 	"github.com/tidepool-org/platform/service/context"
@@ -23,13 +24,17 @@ type Standard struct {
 	authenticationDetails   userservicesClient.AuthenticationDetails
 	dataStore               dataStore.Store
 	dataStoreSession        dataStore.Session
+	syncTaskStore           taskStore.Store
+	syncTaskStoreSession    taskStore.Session
 	taskStore               taskStore.Store
 	taskStoreSession        taskStore.Session
+	lockStore               lockStore.Store
+	lockStoreSession        lockStore.Session
 }
 
 func WithContext(metricServicesClient metricservicesClient.Client, userServicesClient userservicesClient.Client,
 	dataFactory data.Factory, dataDeduplicatorFactory deduplicator.Factory,
-	dataStore dataStore.Store, taskStore taskStore.Store, handler service.HandlerFunc) rest.HandlerFunc {
+	dataStore dataStore.Store, syncTaskStore taskStore.Store, taskStore taskStore.Store, lockStore lockStore.Store, handler service.HandlerFunc) rest.HandlerFunc {
 	return func(response rest.ResponseWriter, request *rest.Request) {
 		context, err := context.NewStandard(response, request)
 		if err != nil {
@@ -44,13 +49,21 @@ func WithContext(metricServicesClient metricservicesClient.Client, userServicesC
 			dataFactory:             dataFactory,
 			dataDeduplicatorFactory: dataDeduplicatorFactory,
 			dataStore:               dataStore,
+			syncTaskStore:           syncTaskStore,
 			taskStore:               taskStore,
+			lockStore:               lockStore,
 		}
 
 		defer func() {
+			if standard.lockStoreSession != nil {
+				standard.lockStoreSession.Close()
+			}
 			if standard.taskStoreSession != nil {
 				standard.taskStoreSession.Close()
 			}
+			if standard.syncTaskStoreSession != nil {
+				standard.syncTaskStoreSession.Close()
+			}
 			if standard.dataStoreSession != nil {
 				standard.dataStoreSession.Close()
 			}
@@ -84,6 +97,14 @@ func (s *Standard) DataStoreSession() dataStore.Session {
 	return s.dataStoreSession
 }
 
+func (s *Standard) SyncTaskStoreSession() taskStore.Session {
+	if s.syncTaskStoreSession == nil {
+		s.syncTaskStoreSession = s.syncTaskStore.NewSession(s.Context.Logger())
+		s.syncTaskStoreSession.SetAgent(s.authenticationDetails)
+	}
+	return s.syncTaskStoreSession
+}
+
 func (s *Standard) TaskStoreSession() taskStore.Session {
 	if s.taskStoreSession == nil {
 		s.taskStoreSession = s.taskStore.NewSession(s.Context.Logger())
@@ -92,6 +113,14 @@ func (s *Standard) TaskStoreSession() taskStore.Session {
 	return s.taskStoreSession
 }
 
+func (s *Standard) LockStoreSession() lockStore.Session {
+	if s.lockStoreSession == nil {
+		s.lockStoreSession = s.lockStore.NewSession(s.Context.Logger())
+		s.lockStoreSession.SetAgent(s.authenticationDetails)
+	}
+	return s.lockStoreSession
+}
+
 func (s *Standard) AuthenticationDetails() userservicesClient.AuthenticationDetails {
 	return s.authenticationDetails
 }
@@ -102,7 +131,13 @@ func (s *Standard) SetAuthenticationDetails(authenticationDetails userservicesCl
 	if s.dataStoreSession != nil {
 		s.dataStoreSession.SetAgent(authenticationDetails)
 	}
+	if s.syncTaskStoreSession != nil {
+		s.syncTaskStoreSession.SetAgent(authenticationDetails)
+	}
 	if s.taskStoreSession != nil {
 		s.taskStoreSession.SetAgent(authenticationDetails)
 	}
+	if s.lockStoreSession != nil {
+		s.lockStoreSession.SetAgent(authenticationDetails)
+	}
 }
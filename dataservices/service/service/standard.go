@@ -1,13 +1,19 @@
 package service
 
 import (
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/tidepool-org/platform/data/deduplicator"
 	"github.com/tidepool-org/platform/data/factory"
 	dataMongo "github.com/tidepool-org/platform/data/store/mongo"
 	dataservicesClient "github.com/tidepool-org/platform/dataservices/client"
 	"github.com/tidepool-org/platform/dataservices/service/api"
 	"github.com/tidepool-org/platform/dataservices/service/api/v1"
+	"github.com/tidepool-org/platform/dataservices/service/worker"
 	"github.com/tidepool-org/platform/errors"
+	lockMongo "github.com/tidepool-org/platform/lock/store/mongo"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
 	"github.com/tidepool-org/platform/service/middleware"
 	"github.com/tidepool-org/platform/service/server"
@@ -22,9 +28,13 @@ type Standard struct {
 	metricServicesClient    *metricservicesClient.Standard
 	userServicesClient      *userservicesClient.Standard
 	dataFactory             *factory.Standard
-	dataDeduplicatorFactory deduplicator.Factory
+	dataDeduplicatorFactory *deduplicator.DelegateFactory
+	dataDeduplicatorSignals chan os.Signal
 	dataStore               *dataMongo.Store
+	syncTaskStore           *taskMongo.Store
 	taskStore               *taskMongo.Store
+	lockStore               *lockMongo.Store
+	dataServicesWorker      *worker.Standard
 	dataServicesAPI         *api.Standard
 	dataServicesServer      *server.Standard
 }
@@ -60,9 +70,18 @@ func (s *Standard) Initialize() error {
 	if err := s.initializeDataStore(); err != nil {
 		return err
 	}
+	if err := s.initializeSyncTaskStore(); err != nil {
+		return err
+	}
 	if err := s.initializeTaskStore(); err != nil {
 		return err
 	}
+	if err := s.initializeLockStore(); err != nil {
+		return err
+	}
+	if err := s.initializeDataServicesWorker(); err != nil {
+		return err
+	}
 	if err := s.initializeDataServicesAPI(); err != nil {
 		return err
 	}
@@ -76,14 +95,31 @@ func (s *Standard) Initialize() error {
 func (s *Standard) Terminate() {
 	s.dataServicesServer = nil
 	s.dataServicesAPI = nil
+	if s.dataServicesWorker != nil {
+		s.dataServicesWorker.Close()
+		s.dataServicesWorker = nil
+	}
+	if s.lockStore != nil {
+		s.lockStore.Close()
+		s.lockStore = nil
+	}
 	if s.taskStore != nil {
 		s.taskStore.Close()
 		s.taskStore = nil
 	}
+	if s.syncTaskStore != nil {
+		s.syncTaskStore.Close()
+		s.syncTaskStore = nil
+	}
 	if s.dataStore != nil {
 		s.dataStore.Close()
 		s.dataStore = nil
 	}
+	if s.dataDeduplicatorSignals != nil {
+		signal.Stop(s.dataDeduplicatorSignals)
+		close(s.dataDeduplicatorSignals)
+		s.dataDeduplicatorSignals = nil
+	}
 	s.dataDeduplicatorFactory = nil
 	s.dataFactory = nil
 	if s.userServicesClient != nil {
@@ -159,34 +195,59 @@ func (s *Standard) initializeDataFactory() error {
 }
 
 func (s *Standard) initializeDataDeduplicatorFactory() error {
-	s.Logger().Debug("Creating truncate data deduplicator factory")
-
-	truncateDeduplicatorFactory, err := deduplicator.NewTruncateFactory()
+	factories, err := s.loadDataDeduplicatorFactories()
 	if err != nil {
-		return errors.Wrap(err, "service", "unable to create truncate data deduplicator factory")
+		return err
 	}
 
-	s.Logger().Debug("Creating hash-deactivate-old data deduplicator factory")
+	s.Logger().Debug("Creating data deduplicator factory")
 
-	hashDeactivateOldDeduplicatorFactory, err := deduplicator.NewHashDeactivateOldFactory()
+	dataDeduplicatorFactory, err := deduplicator.NewDelegateFactory(factories)
 	if err != nil {
-		return errors.Wrap(err, "service", "unable to create hash-deactivate-old data deduplicator factory")
+		return errors.Wrap(err, "service", "unable to create data deduplicator factory")
 	}
+	s.dataDeduplicatorFactory = dataDeduplicatorFactory
 
-	s.Logger().Debug("Creating data deduplicator factory")
+	s.Logger().Debug("Starting data deduplicator factory reloader")
+
+	s.dataDeduplicatorSignals = make(chan os.Signal, 1)
+	signal.Notify(s.dataDeduplicatorSignals, syscall.SIGHUP)
+
+	go s.reloadDataDeduplicatorFactory(s.dataDeduplicatorFactory, s.dataDeduplicatorSignals)
+
+	return nil
+}
 
-	factories := []deduplicator.Factory{
-		truncateDeduplicatorFactory,
-		hashDeactivateOldDeduplicatorFactory,
+func (s *Standard) loadDataDeduplicatorFactories() ([]deduplicator.Factory, error) {
+	s.Logger().Debug("Loading data deduplicator config")
+
+	dataDeduplicatorConfig := &deduplicator.Config{}
+	if err := s.ConfigLoader().Load("data_deduplicator", dataDeduplicatorConfig); err != nil {
+		return nil, errors.Wrap(err, "service", "unable to load data deduplicator config")
 	}
 
-	dataDeduplicatorFactory, err := deduplicator.NewDelegateFactory(factories)
+	s.Logger().Debug("Creating data deduplicator factories")
+
+	factories, err := deduplicator.NewFactoriesFromConfig(dataDeduplicatorConfig)
 	if err != nil {
-		return errors.Wrap(err, "service", "unable to create data deduplicator factory")
+		return nil, errors.Wrap(err, "service", "unable to create data deduplicator factories")
 	}
-	s.dataDeduplicatorFactory = dataDeduplicatorFactory
 
-	return nil
+	return factories, nil
+}
+
+func (s *Standard) reloadDataDeduplicatorFactory(dataDeduplicatorFactory *deduplicator.DelegateFactory, signals <-chan os.Signal) {
+	for range signals {
+		s.Logger().Info("Reloading data deduplicator factory")
+
+		factories, err := s.loadDataDeduplicatorFactories()
+		if err == nil {
+			err = dataDeduplicatorFactory.SetFactories(factories)
+		}
+		if err != nil {
+			s.Logger().WithError(err).Error("Unable to reload data deduplicator factory; retaining existing factories")
+		}
+	}
 }
 
 func (s *Standard) initializeDataStore() error {
@@ -209,6 +270,26 @@ func (s *Standard) initializeDataStore() error {
 	return nil
 }
 
+func (s *Standard) initializeSyncTaskStore() error {
+	s.Logger().Debug("Loading sync task store config")
+
+	syncTaskStoreConfig := &baseMongo.Config{}
+	if err := s.ConfigLoader().Load("task_store", syncTaskStoreConfig); err != nil {
+		return errors.Wrap(err, "service", "unable to load sync task store config")
+	}
+	syncTaskStoreConfig.Collection = "syncTasks"
+
+	s.Logger().Debug("Creating sync task store")
+
+	syncTaskStore, err := taskMongo.New(s.Logger(), syncTaskStoreConfig)
+	if err != nil {
+		return errors.Wrap(err, "service", "unable to create sync task store")
+	}
+	s.syncTaskStore = syncTaskStore
+
+	return nil
+}
+
 func (s *Standard) initializeTaskStore() error {
 	s.Logger().Debug("Loading task store config")
 
@@ -216,7 +297,7 @@ func (s *Standard) initializeTaskStore() error {
 	if err := s.ConfigLoader().Load("task_store", taskStoreConfig); err != nil {
 		return errors.Wrap(err, "service", "unable to load task store config")
 	}
-	taskStoreConfig.Collection = "syncTasks"
+	taskStoreConfig.Collection = "dataTasks"
 
 	s.Logger().Debug("Creating task store")
 
@@ -229,13 +310,57 @@ func (s *Standard) initializeTaskStore() error {
 	return nil
 }
 
+func (s *Standard) initializeLockStore() error {
+	s.Logger().Debug("Loading lock store config")
+
+	lockStoreConfig := &baseMongo.Config{}
+	if err := s.ConfigLoader().Load("lock_store", lockStoreConfig); err != nil {
+		return errors.Wrap(err, "service", "unable to load lock store config")
+	}
+	lockStoreConfig.Collection = "dataLocks"
+
+	s.Logger().Debug("Creating lock store")
+
+	lockStore, err := lockMongo.New(s.Logger(), lockStoreConfig)
+	if err != nil {
+		return errors.Wrap(err, "service", "unable to create lock store")
+	}
+	s.lockStore = lockStore
+
+	return nil
+}
+
+func (s *Standard) initializeDataServicesWorker() error {
+	s.Logger().Debug("Loading data services worker config")
+
+	dataServicesWorkerConfig := &worker.Config{}
+	if err := s.ConfigLoader().Load("dataservices_worker", dataServicesWorkerConfig); err != nil {
+		return errors.Wrap(err, "service", "unable to load data services worker config")
+	}
+
+	s.Logger().Debug("Creating data services worker")
+
+	dataServicesWorker, err := worker.NewStandard(s.Logger(), dataServicesWorkerConfig, s.dataDeduplicatorFactory, s.dataStore, s.lockStore, s.taskStore)
+	if err != nil {
+		return errors.Wrap(err, "service", "unable to create data services worker")
+	}
+	s.dataServicesWorker = dataServicesWorker
+
+	s.Logger().Debug("Starting data services worker")
+	if err = s.dataServicesWorker.Start(); err != nil {
+		return errors.Wrap(err, "service", "unable to start data services worker")
+	}
+
+	return nil
+}
+
 func (s *Standard) initializeDataServicesAPI() error {
 	s.Logger().Debug("Creating data services api")
 
 	dataServicesAPI, err := api.NewStandard(s.VersionReporter(), s.EnvironmentReporter(), s.Logger(),
 		s.metricServicesClient, s.userServicesClient,
 		s.dataFactory, s.dataDeduplicatorFactory,
-		s.dataStore, s.taskStore)
+		s.dataStore, s.syncTaskStore, s.taskStore, s.lockStore)
 	if err != nil {
 		return errors.Wrap(err, "service", "unable to create data services api")
 	}
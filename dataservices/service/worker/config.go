@@ -0,0 +1,20 @@
+package worker
+
+import "github.com/tidepool-org/platform/errors"
+
+type Config struct {
+	PurgeRetentionDays int `json:"purgeRetentionDays" default:"30"`
+}
+
+func (c *Config) Validate() error {
+	if c.PurgeRetentionDays < 1 {
+		return errors.New("worker", "purge retention days is invalid")
+	}
+	return nil
+}
+
+func (c *Config) Clone() *Config {
+	return &Config{
+		PurgeRetentionDays: c.PurgeRetentionDays,
+	}
+}
@@ -0,0 +1,577 @@
+package worker
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/deduplicator"
+	dataStore "github.com/tidepool-org/platform/data/store"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/errors"
+	lockStore "github.com/tidepool-org/platform/lock/store"
+	"github.com/tidepool-org/platform/log"
+	"github.com/tidepool-org/platform/store"
+	taskStore "github.com/tidepool-org/platform/task/store"
+)
+
+const (
+	TaskTypeDeduplicateDataset = "deduplicate-dataset"
+	TaskTypeRededuplicateUser  = "rededuplicate-user"
+
+	PollInterval      = 5 * time.Second
+	LeaseDuration     = 5 * time.Minute
+	HeartbeatInterval = time.Minute
+	PurgeInterval     = time.Hour
+)
+
+type Standard struct {
+	logger                  log.Logger
+	config                  *Config
+	dataDeduplicatorFactory deduplicator.Factory
+	dataStore               dataStore.Store
+	lockStore               lockStore.Store
+	taskStore               taskStore.Store
+	closingChannel          chan chan bool
+	purgeTime               time.Time
+}
+
+func NewStandard(logger log.Logger, config *Config, dataDeduplicatorFactory deduplicator.Factory, dataStore dataStore.Store, lockStore lockStore.Store, taskStore taskStore.Store) (*Standard, error) {
+	if logger == nil {
+		return nil, errors.New("worker", "logger is missing")
+	}
+	if config == nil {
+		return nil, errors.New("worker", "config is missing")
+	}
+	if dataDeduplicatorFactory == nil {
+		return nil, errors.New("worker", "data deduplicator factory is missing")
+	}
+	if dataStore == nil {
+		return nil, errors.New("worker", "data store is missing")
+	}
+	if lockStore == nil {
+		return nil, errors.New("worker", "lock store is missing")
+	}
+	if taskStore == nil {
+		return nil, errors.New("worker", "task store is missing")
+	}
+
+	config = config.Clone()
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "worker", "config is invalid")
+	}
+
+	return &Standard{
+		logger:                  logger,
+		config:                  config,
+		dataDeduplicatorFactory: dataDeduplicatorFactory,
+		dataStore:               dataStore,
+		lockStore:               lockStore,
+		taskStore:               taskStore,
+	}, nil
+}
+
+func (s *Standard) Start() error {
+	if s.closingChannel == nil {
+		s.RecoverDatasets()
+
+		closingChannel := make(chan chan bool)
+		s.closingChannel = closingChannel
+
+		go func() {
+			for {
+				timer := time.After(PollInterval)
+				select {
+				case closedChannel := <-closingChannel:
+					closedChannel <- true
+					close(closedChannel)
+					return
+				case <-timer:
+					s.ProcessTasks()
+					if time.Since(s.purgeTime) >= PurgeInterval {
+						s.PurgeDeletedData()
+					}
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+func (s *Standard) Close() {
+	if s.closingChannel != nil {
+		closingChannel := s.closingChannel
+		s.closingChannel = nil
+
+		closedChannel := make(chan bool)
+		closingChannel <- closedChannel
+		close(closingChannel)
+		<-closedChannel
+	}
+}
+
+func (s *Standard) RecoverDatasets() {
+	taskStoreSession := s.taskStore.NewSession(s.logger)
+	defer taskStoreSession.Close()
+
+	dataStoreSession := s.dataStore.NewSession(s.logger)
+	defer dataStoreSession.Close()
+
+	datasets, err := dataStoreSession.GetJournaledDatasets()
+	if err != nil {
+		s.logger.WithError(err).Error("Unable to get journaled datasets")
+		return
+	}
+
+	for _, dataset := range datasets {
+		logger := s.logger.WithField("datasetId", dataset.UploadID)
+
+		task, err := taskStoreSession.GetTaskForDatasetByID(dataset.UploadID, TaskTypeDeduplicateDataset)
+		if err != nil {
+			logger.WithError(err).Error("Unable to get task for dataset by id")
+			continue
+		} else if task != nil && task.IsActive() {
+			continue
+		}
+
+		task = taskStore.NewTask(TaskTypeDeduplicateDataset)
+		task.UserID = dataset.UserID
+		task.DatasetID = dataset.UploadID
+		if err = taskStoreSession.CreateTask(task); err != nil {
+			logger.WithError(err).Error("Unable to create task")
+			continue
+		}
+
+		logger.Warn("Recovering journaled dataset")
+	}
+}
+
+func (s *Standard) ProcessTasks() {
+	taskStoreSession := s.taskStore.NewSession(s.logger)
+	defer taskStoreSession.Close()
+
+	dataStoreSession := s.dataStore.NewSession(s.logger)
+	defer dataStoreSession.Close()
+
+	for _, taskType := range []string{TaskTypeDeduplicateDataset, TaskTypeRededuplicateUser} {
+		for {
+			task, err := taskStoreSession.ClaimTask(taskType, LeaseDuration)
+			if err != nil {
+				s.logger.WithError(err).Error("Unable to claim task")
+				return
+			} else if task == nil {
+				break
+			}
+
+			s.ProcessTask(dataStoreSession, taskStoreSession, task)
+		}
+	}
+}
+
+func (s *Standard) PurgeDeletedData() {
+	s.purgeTime = time.Now()
+
+	dataStoreSession := s.dataStore.NewSession(s.logger)
+	defer dataStoreSession.Close()
+
+	deletedBefore := s.purgeTime.AddDate(0, 0, -s.config.PurgeRetentionDays)
+	if err := dataStoreSession.DestroyDeletedData(deletedBefore); err != nil {
+		s.logger.WithError(err).Error("Unable to destroy deleted data")
+	}
+}
+
+func (s *Standard) ProcessTask(dataStoreSession dataStore.Session, taskStoreSession taskStore.Session, task *taskStore.Task) {
+	logger := s.logger.WithFields(log.Fields{"taskId": task.ID, "taskType": task.Type, "userId": task.UserID, "datasetId": task.DatasetID, "attempts": task.Attempts})
+
+	stopChannel := make(chan bool)
+	stoppedChannel := make(chan bool)
+	leaseLostChannel := make(chan bool)
+	go func(heartbeatTask taskStore.Task) {
+		defer close(stoppedChannel)
+		ticker := time.NewTicker(HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopChannel:
+				return
+			case <-ticker.C:
+				if leased, err := taskStoreSession.HeartbeatTask(&heartbeatTask, LeaseDuration); err != nil {
+					logger.WithError(err).Error("Unable to heartbeat task")
+				} else if !leased {
+					logger.Error("Task lease is lost, cancelling task")
+					close(leaseLostChannel)
+					return
+				}
+			}
+		}
+	}(*task)
+
+	leasedDataStoreSession := newLeasedDataStoreSession(dataStoreSession, leaseLostChannel)
+
+	var err error
+	switch task.Type {
+	case TaskTypeDeduplicateDataset:
+		err = s.deduplicateDataset(leasedDataStoreSession, task)
+	case TaskTypeRededuplicateUser:
+		err = s.rededuplicateUser(logger, leasedDataStoreSession, task)
+	default:
+		err = errors.Newf("worker", "task type %s is not supported", strconv.Quote(task.Type))
+	}
+
+	close(stopChannel)
+	<-stoppedChannel
+
+	if leasedDataStoreSession.isLeaseLost() {
+		logger.WithError(err).Warn("Task cancelled after lease is lost")
+	} else if err != nil {
+		logger.WithError(err).Error("Unable to process task")
+		if err = taskStoreSession.FailTask(task, err); err != nil {
+			logger.WithError(err).Error("Unable to fail task")
+		}
+	} else if err = taskStoreSession.CompleteTask(task); err != nil {
+		logger.WithError(err).Error("Unable to complete task")
+	}
+}
+
+func (s *Standard) deduplicateDataset(dataStoreSession dataStore.Session, task *taskStore.Task) error {
+	dataset, err := dataStoreSession.GetDatasetByID(task.DatasetID)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to get dataset by id")
+	}
+	if dataset == nil {
+		return errors.Newf("worker", "dataset with id %s not found", strconv.Quote(task.DatasetID))
+	}
+	if dataset.DeletedTime != "" {
+		return errors.Newf("worker", "dataset with id %s is deleted", strconv.Quote(task.DatasetID))
+	}
+
+	switch state := dataset.CurrentState(); state {
+	case upload.StateClosed:
+		return nil
+	case upload.StateClosing:
+	default:
+		return errors.Newf("worker", "dataset with id %s is in state %s", strconv.Quote(task.DatasetID), strconv.Quote(state))
+	}
+
+	deduplicator, err := s.dataDeduplicatorFactory.NewRegisteredDeduplicatorForDataset(s.logger, dataStoreSession, dataset)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to create registered deduplicator for dataset")
+	}
+
+	if err = deduplicator.DeduplicateDataset(); err != nil {
+		return errors.Wrap(err, "worker", "unable to deduplicate dataset")
+	}
+
+	transitioned, err := dataStoreSession.TransitionDatasetState(dataset, upload.StateClosed, task.CreatedUserID)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to transition dataset state")
+	}
+	if !transitioned {
+		return errors.Newf("worker", "dataset with id %s changed state during deduplication", strconv.Quote(task.DatasetID))
+	}
+
+	return nil
+}
+
+func (s *Standard) rededuplicateUser(logger log.Logger, dataStoreSession dataStore.Session, task *taskStore.Task) error {
+	lockStoreSession := s.lockStore.NewSession(s.logger)
+	defer lockStoreSession.Close()
+
+	report, err := deduplicator.RededuplicateUser(s.logger, dataStoreSession, lockStoreSession, s.dataDeduplicatorFactory, task.UserID, false)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to rededuplicate user")
+	}
+
+	logger.WithFields(log.Fields{"datasetsCount": len(report.Datasets), "changedCount": report.ChangedCount(), "skippedCount": report.SkippedCount()}).Info("Rededuplicated user")
+
+	return nil
+}
+
+// The lease of a task may be lost, for example if a heartbeat is delayed past the lease expiration
+// time, and the task claimed by another worker. In that case, any further writes are refused so the
+// deduplication is cancelled at its next write and left to the worker that now holds the lease. Every
+// data store session method is implemented explicitly, so a new method does not bypass the lease.
+type leasedDataStoreSession struct {
+	store.Session
+	dataStoreSession dataStore.Session
+	leaseLostChannel <-chan bool
+}
+
+func newLeasedDataStoreSession(dataStoreSession dataStore.Session, leaseLostChannel <-chan bool) *leasedDataStoreSession {
+	return &leasedDataStoreSession{
+		Session:          dataStoreSession,
+		dataStoreSession: dataStoreSession,
+		leaseLostChannel: leaseLostChannel,
+	}
+}
+
+func (l *leasedDataStoreSession) isLeaseLost() bool {
+	select {
+	case <-l.leaseLostChannel:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *leasedDataStoreSession) validateLease() error {
+	if l.isLeaseLost() {
+		return errors.New("worker", "task lease is lost")
+	}
+	return nil
+}
+
+func (l *leasedDataStoreSession) GetDatasetsForUserByID(userID string, filter *dataStore.Filter, pagination *dataStore.Pagination) ([]*upload.Upload, error) {
+	return l.dataStoreSession.GetDatasetsForUserByID(userID, filter, pagination)
+}
+
+func (l *leasedDataStoreSession) GetDatasetByID(datasetID string) (*upload.Upload, error) {
+	return l.dataStoreSession.GetDatasetByID(datasetID)
+}
+
+func (l *leasedDataStoreSession) GetJournaledDatasets() ([]*upload.Upload, error) {
+	return l.dataStoreSession.GetJournaledDatasets()
+}
+
+func (l *leasedDataStoreSession) GetDatasetSummary(dataset *upload.Upload) (*dataStore.DatasetSummary, error) {
+	return l.dataStoreSession.GetDatasetSummary(dataset)
+}
+
+func (l *leasedDataStoreSession) GetDatasetLatestTime(dataset *upload.Upload) (*time.Time, error) {
+	return l.dataStoreSession.GetDatasetLatestTime(dataset)
+}
+
+func (l *leasedDataStoreSession) CreateDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.CreateDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) UpdateDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.UpdateDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) UpdateDatasetJournal(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.UpdateDatasetJournal(dataset)
+}
+
+func (l *leasedDataStoreSession) UpdateDatasetRededuplicateJournal(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.UpdateDatasetRededuplicateJournal(dataset)
+}
+
+func (l *leasedDataStoreSession) TransitionDatasetState(dataset *upload.Upload, state string, userID string) (bool, error) {
+	if err := l.validateLease(); err != nil {
+		return false, err
+	}
+	return l.dataStoreSession.TransitionDatasetState(dataset, state, userID)
+}
+
+func (l *leasedDataStoreSession) DeleteDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.DeleteDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) UndeleteDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.UndeleteDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) GetDatasetChunk(dataset *upload.Upload, chunkID string) (*dataStore.DatasetChunk, error) {
+	return l.dataStoreSession.GetDatasetChunk(dataset, chunkID)
+}
+
+func (l *leasedDataStoreSession) CreateDatasetChunk(dataset *upload.Upload, chunkID string, datumIDs []string) (bool, error) {
+	if err := l.validateLease(); err != nil {
+		return false, err
+	}
+	return l.dataStoreSession.CreateDatasetChunk(dataset, chunkID, datumIDs)
+}
+
+func (l *leasedDataStoreSession) CompleteDatasetChunk(dataset *upload.Upload, chunkID string, response []byte) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.CompleteDatasetChunk(dataset, chunkID, response)
+}
+
+func (l *leasedDataStoreSession) FailDatasetChunk(dataset *upload.Upload, chunkID string) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.FailDatasetChunk(dataset, chunkID)
+}
+
+func (l *leasedDataStoreSession) GetDatasetDataDeduplicatorHashes(dataset *upload.Upload, hashes []string) ([]string, error) {
+	return l.dataStoreSession.GetDatasetDataDeduplicatorHashes(dataset, hashes)
+}
+
+func (l *leasedDataStoreSession) CreateDatasetData(dataset *upload.Upload, datasetData []data.Datum) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.CreateDatasetData(dataset, datasetData)
+}
+
+func (l *leasedDataStoreSession) ActivateDatasetData(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.ActivateDatasetData(dataset)
+}
+
+func (l *leasedDataStoreSession) DeactivateDatasetData(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.DeactivateDatasetData(dataset)
+}
+
+func (l *leasedDataStoreSession) DeleteInactiveDatasetData(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.DeleteInactiveDatasetData(dataset)
+}
+
+func (l *leasedDataStoreSession) ArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.ArchiveDeviceDataUsingHashesFromDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) UnarchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.UnarchiveDeviceDataUsingHashesFromDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) ArchiveDeviceDataUsingTimeWindowFromDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.ArchiveDeviceDataUsingTimeWindowFromDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) UnarchiveDeviceDataArchivedByDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.UnarchiveDeviceDataArchivedByDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) RetainDeviceDataArchivedByDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.RetainDeviceDataArchivedByDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) RearchiveDeviceDataRetainedByDataset(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.RearchiveDeviceDataRetainedByDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset *upload.Upload, deviceTimeTolerance time.Duration) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.ArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, deviceTimeTolerance)
+}
+
+func (l *leasedDataStoreSession) DeleteOtherDatasetData(dataset *upload.Upload) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.DeleteOtherDatasetData(dataset)
+}
+
+func (l *leasedDataStoreSession) PreviewActivateDatasetData(dataset *upload.Upload) (*dataStore.DataPreview, error) {
+	return l.dataStoreSession.PreviewActivateDatasetData(dataset)
+}
+
+func (l *leasedDataStoreSession) PreviewArchiveDeviceDataUsingHashesFromDataset(dataset *upload.Upload) (*dataStore.DataPreview, error) {
+	return l.dataStoreSession.PreviewArchiveDeviceDataUsingHashesFromDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset *upload.Upload) (*dataStore.DataPreview, error) {
+	return l.dataStoreSession.PreviewArchiveDeviceDataUsingTimeWindowFromDataset(dataset)
+}
+
+func (l *leasedDataStoreSession) PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset *upload.Upload, deviceTimeTolerance time.Duration) (*dataStore.DataPreview, error) {
+	return l.dataStoreSession.PreviewArchiveDeviceDataUsingFuzzyHashesFromDataset(dataset, deviceTimeTolerance)
+}
+
+func (l *leasedDataStoreSession) PreviewDeleteOtherDatasets(dataset *upload.Upload) (*dataStore.DataPreview, error) {
+	return l.dataStoreSession.PreviewDeleteOtherDatasets(dataset)
+}
+
+func (l *leasedDataStoreSession) PreviewDeleteOtherDatasetData(dataset *upload.Upload) (*dataStore.DataPreview, error) {
+	return l.dataStoreSession.PreviewDeleteOtherDatasetData(dataset)
+}
+
+func (l *leasedDataStoreSession) GetDataForDatasetByID(datasetID string, filter *dataStore.DatasetDataFilter, pagination *dataStore.Pagination) ([]map[string]interface{}, error) {
+	return l.dataStoreSession.GetDataForDatasetByID(datasetID, filter, pagination)
+}
+
+func (l *leasedDataStoreSession) GetDataForUserByID(userID string, filter *dataStore.DataFilter, pagination *dataStore.Pagination) ([]map[string]interface{}, error) {
+	return l.dataStoreSession.GetDataForUserByID(userID, filter, pagination)
+}
+
+func (l *leasedDataStoreSession) IterateDataForUserByID(userID string, filter *dataStore.DataFilter) (dataStore.Iterator, error) {
+	return l.dataStoreSession.IterateDataForUserByID(userID, filter)
+}
+
+func (l *leasedDataStoreSession) IterateAllDataForUserByID(userID string) (dataStore.Iterator, error) {
+	return l.dataStoreSession.IterateAllDataForUserByID(userID)
+}
+
+func (l *leasedDataStoreSession) RestoreDataForUserByID(userID string, restoreID string, restoreTime time.Time) (bool, error) {
+	if err := l.validateLease(); err != nil {
+		return false, err
+	}
+	return l.dataStoreSession.RestoreDataForUserByID(userID, restoreID, restoreTime)
+}
+
+func (l *leasedDataStoreSession) UndoRestoreDataForUserByID(userID string, restoreID string) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.UndoRestoreDataForUserByID(userID, restoreID)
+}
+
+func (l *leasedDataStoreSession) PreviewRestoreDataForUserByID(userID string, restoreTime time.Time) (*dataStore.DataRestorePreview, error) {
+	return l.dataStoreSession.PreviewRestoreDataForUserByID(userID, restoreTime)
+}
+
+func (l *leasedDataStoreSession) DestroyDataForUserByID(userID string) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.DestroyDataForUserByID(userID)
+}
+
+func (l *leasedDataStoreSession) DestroyDeletedData(deletedBefore time.Time) error {
+	if err := l.validateLease(); err != nil {
+		return err
+	}
+	return l.dataStoreSession.DestroyDeletedData(deletedBefore)
+}
@@ -0,0 +1,355 @@
+package worker_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	testDataDeduplicator "github.com/tidepool-org/platform/data/deduplicator/test"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	testData "github.com/tidepool-org/platform/data/test"
+	"github.com/tidepool-org/platform/data/types/upload"
+	"github.com/tidepool-org/platform/dataservices/service/worker"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/log"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
+)
+
+var _ = Describe("Standard", func() {
+	var logger log.Logger
+	var config *worker.Config
+	var dataDeduplicatorFactory *testDataDeduplicator.Factory
+	var dataStoreImpl *TestDataStore
+	var lockStoreImpl *TestLockStore
+	var taskStoreImpl *TestTaskStore
+
+	BeforeEach(func() {
+		logger = log.NewNull()
+		config = &worker.Config{PurgeRetentionDays: 30}
+		dataDeduplicatorFactory = testDataDeduplicator.NewFactory()
+		dataStoreImpl = &TestDataStore{SessionImpl: testDataStore.NewSession()}
+		lockStoreImpl = &TestLockStore{SessionImpl: testLockStore.NewSession()}
+		taskStoreImpl = &TestTaskStore{SessionImpl: testTaskStore.NewSession()}
+	})
+
+	Context("NewStandard", func() {
+		It("returns an error if the logger is missing", func() {
+			standard, err := worker.NewStandard(nil, config, dataDeduplicatorFactory, dataStoreImpl, lockStoreImpl, taskStoreImpl)
+			Expect(err).To(MatchError("worker: logger is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the config is missing", func() {
+			standard, err := worker.NewStandard(logger, nil, dataDeduplicatorFactory, dataStoreImpl, lockStoreImpl, taskStoreImpl)
+			Expect(err).To(MatchError("worker: config is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the config is invalid", func() {
+			config.PurgeRetentionDays = 0
+			standard, err := worker.NewStandard(logger, config, dataDeduplicatorFactory, dataStoreImpl, lockStoreImpl, taskStoreImpl)
+			Expect(err).To(MatchError("worker: config is invalid; worker: purge retention days is invalid"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the data deduplicator factory is missing", func() {
+			standard, err := worker.NewStandard(logger, config, nil, dataStoreImpl, lockStoreImpl, taskStoreImpl)
+			Expect(err).To(MatchError("worker: data deduplicator factory is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the data store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, dataDeduplicatorFactory, nil, lockStoreImpl, taskStoreImpl)
+			Expect(err).To(MatchError("worker: data store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the lock store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, dataDeduplicatorFactory, dataStoreImpl, nil, taskStoreImpl)
+			Expect(err).To(MatchError("worker: lock store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the task store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, dataDeduplicatorFactory, dataStoreImpl, lockStoreImpl, nil)
+			Expect(err).To(MatchError("worker: task store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns successfully", func() {
+			Expect(worker.NewStandard(logger, config, dataDeduplicatorFactory, dataStoreImpl, lockStoreImpl, taskStoreImpl)).ToNot(BeNil())
+		})
+	})
+
+	Context("with new standard", func() {
+		var standard *worker.Standard
+		var dataStoreSession *testDataStore.Session
+		var lockStoreSession *testLockStore.Session
+		var taskStoreSession *testTaskStore.Session
+		var dataset *upload.Upload
+		var deduplicator *testData.Deduplicator
+		var task *taskStore.Task
+
+		BeforeEach(func() {
+			var err error
+			standard, err = worker.NewStandard(logger, config, dataDeduplicatorFactory, dataStoreImpl, lockStoreImpl, taskStoreImpl)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(standard).ToNot(BeNil())
+			dataStoreSession = dataStoreImpl.SessionImpl
+			lockStoreSession = lockStoreImpl.SessionImpl
+			taskStoreSession = taskStoreImpl.SessionImpl
+			dataset = upload.Init()
+			dataset.State = upload.StateClosing
+			deduplicator = testData.NewDeduplicator()
+			task = taskStore.NewTask(worker.TaskTypeDeduplicateDataset)
+			task.ID = app.NewID()
+			task.DatasetID = dataset.UploadID
+			task.CreatedUserID = app.NewID()
+			task.LeaseID = app.NewID()
+			task.State = taskStore.TaskStateRunning
+			task.Attempts = 1
+		})
+
+		AfterEach(func() {
+			Expect(dataDeduplicatorFactory.UnusedOutputsCount()).To(Equal(0))
+			Expect(dataStoreSession.UnusedOutputsCount()).To(Equal(0))
+			Expect(lockStoreSession.UnusedOutputsCount()).To(Equal(0))
+			Expect(taskStoreSession.UnusedOutputsCount()).To(Equal(0))
+			Expect(deduplicator.UnusedOutputsCount()).To(Equal(0))
+		})
+
+		Context("Start", func() {
+			It("recovers datasets, starts, and closes successfully", func() {
+				dataStoreSession.GetJournaledDatasetsOutputs = []testDataStore.GetJournaledDatasetsOutput{{Datasets: []*upload.Upload{}, Error: nil}}
+				Expect(standard.Start()).To(Succeed())
+				standard.Close()
+				Expect(dataStoreSession.GetJournaledDatasetsInvocations).To(Equal(1))
+			})
+		})
+
+		Context("RecoverDatasets", func() {
+			It("stops if get journaled datasets returns an error", func() {
+				dataStoreSession.GetJournaledDatasetsOutputs = []testDataStore.GetJournaledDatasetsOutput{{Datasets: nil, Error: errors.New("test error")}}
+				standard.RecoverDatasets()
+				Expect(taskStoreSession.GetTaskForDatasetByIDInvocations).To(Equal(0))
+				Expect(taskStoreSession.CloseInvocations).To(Equal(1))
+				Expect(dataStoreSession.CloseInvocations).To(Equal(1))
+			})
+
+			It("creates a task for each journaled dataset without an active task", func() {
+				activeDataset := upload.Init()
+				inactiveDataset := upload.Init()
+				inactiveDataset.UserID = app.NewID()
+				erroringDataset := upload.Init()
+				activeTask := taskStore.NewTask(worker.TaskTypeDeduplicateDataset)
+				completedTask := taskStore.NewTask(worker.TaskTypeDeduplicateDataset)
+				completedTask.State = taskStore.TaskStateCompleted
+				dataStoreSession.GetJournaledDatasetsOutputs = []testDataStore.GetJournaledDatasetsOutput{{Datasets: []*upload.Upload{erroringDataset, activeDataset, inactiveDataset}, Error: nil}}
+				taskStoreSession.GetTaskForDatasetByIDOutputs = []testTaskStore.GetTaskForDatasetByIDOutput{{Task: nil, Error: errors.New("test error")}, {Task: activeTask, Error: nil}, {Task: completedTask, Error: nil}}
+				taskStoreSession.CreateTaskOutputs = []error{nil}
+				standard.RecoverDatasets()
+				Expect(taskStoreSession.GetTaskForDatasetByIDInputs).To(Equal([]testTaskStore.GetTaskForDatasetByIDInput{
+					{DatasetID: erroringDataset.UploadID, TaskType: worker.TaskTypeDeduplicateDataset},
+					{DatasetID: activeDataset.UploadID, TaskType: worker.TaskTypeDeduplicateDataset},
+					{DatasetID: inactiveDataset.UploadID, TaskType: worker.TaskTypeDeduplicateDataset},
+				}))
+				Expect(taskStoreSession.CreateTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.CreateTaskInputs[0].Type).To(Equal(worker.TaskTypeDeduplicateDataset))
+				Expect(taskStoreSession.CreateTaskInputs[0].UserID).To(Equal(inactiveDataset.UserID))
+				Expect(taskStoreSession.CreateTaskInputs[0].DatasetID).To(Equal(inactiveDataset.UploadID))
+			})
+		})
+
+		Context("ProcessTasks", func() {
+			It("claims tasks until there are none", func() {
+				taskStoreSession.ClaimTaskOutputs = []testTaskStore.ClaimTaskOutput{{Task: nil, Error: nil}, {Task: nil, Error: nil}}
+				standard.ProcessTasks()
+				Expect(taskStoreSession.ClaimTaskInputs).To(Equal([]testTaskStore.ClaimTaskInput{
+					{TaskType: worker.TaskTypeDeduplicateDataset, LeaseDuration: worker.LeaseDuration},
+					{TaskType: worker.TaskTypeRededuplicateUser, LeaseDuration: worker.LeaseDuration},
+				}))
+				Expect(taskStoreSession.CloseInvocations).To(Equal(1))
+				Expect(dataStoreSession.CloseInvocations).To(Equal(1))
+			})
+
+			It("stops if claim task returns an error", func() {
+				taskStoreSession.ClaimTaskOutputs = []testTaskStore.ClaimTaskOutput{{Task: nil, Error: errors.New("test error")}}
+				standard.ProcessTasks()
+				Expect(taskStoreSession.ClaimTaskInvocations).To(Equal(1))
+			})
+
+			It("processes a claimed task", func() {
+				taskStoreSession.ClaimTaskOutputs = []testTaskStore.ClaimTaskOutput{{Task: task, Error: nil}, {Task: nil, Error: nil}, {Task: nil, Error: nil}}
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: dataset, Error: nil}}
+				dataDeduplicatorFactory.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{{Deduplicator: deduplicator, Error: nil}}
+				deduplicator.DeduplicateDatasetOutputs = []error{nil}
+				dataStoreSession.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{{Transitioned: true, Error: nil}}
+				taskStoreSession.CompleteTaskOutputs = []error{nil}
+				standard.ProcessTasks()
+				Expect(taskStoreSession.CompleteTaskInputs).To(Equal([]*taskStore.Task{task}))
+			})
+		})
+
+		Context("PurgeDeletedData", func() {
+			It("destroys data deleted before the purge retention period", func() {
+				dataStoreSession.DestroyDeletedDataOutputs = []error{nil}
+				startTime := time.Now()
+				standard.PurgeDeletedData()
+				Expect(dataStoreSession.DestroyDeletedDataInputs).To(HaveLen(1))
+				Expect(dataStoreSession.DestroyDeletedDataInputs[0]).To(BeTemporally("~", startTime.AddDate(0, 0, -30), time.Second))
+				Expect(dataStoreSession.CloseInvocations).To(Equal(1))
+			})
+
+			It("logs and ignores if destroy deleted data returns an error", func() {
+				dataStoreSession.DestroyDeletedDataOutputs = []error{errors.New("test error")}
+				standard.PurgeDeletedData()
+				Expect(dataStoreSession.DestroyDeletedDataInvocations).To(Equal(1))
+				Expect(dataStoreSession.CloseInvocations).To(Equal(1))
+			})
+		})
+
+		Context("ProcessTask", func() {
+			It("deduplicates and closes the dataset and completes the task", func() {
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: dataset, Error: nil}}
+				dataDeduplicatorFactory.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{{Deduplicator: deduplicator, Error: nil}}
+				deduplicator.DeduplicateDatasetOutputs = []error{nil}
+				dataStoreSession.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{{Transitioned: true, Error: nil}}
+				taskStoreSession.CompleteTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(dataStoreSession.GetDatasetByIDInputs).To(Equal([]string{dataset.UploadID}))
+				Expect(deduplicator.DeduplicateDatasetInvocations).To(Equal(1))
+				Expect(dataStoreSession.TransitionDatasetStateInputs).To(Equal([]testDataStore.TransitionDatasetStateInput{{Dataset: dataset, State: upload.StateClosed, UserID: task.CreatedUserID}}))
+				Expect(taskStoreSession.CompleteTaskInputs).To(Equal([]*taskStore.Task{task}))
+			})
+
+			It("completes the task if the dataset is already closed", func() {
+				dataset.State = upload.StateClosed
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: dataset, Error: nil}}
+				taskStoreSession.CompleteTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(taskStoreSession.CompleteTaskInputs).To(Equal([]*taskStore.Task{task}))
+			})
+
+			It("fails the task if the dataset is not closing", func() {
+				dataset.State = upload.StateOpen
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: dataset, Error: nil}}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError(`worker: dataset with id "` + dataset.UploadID + `" is in state "open"`))
+			})
+
+			It("fails the task if get dataset returns an error", func() {
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: errors.New("test error")}}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to get dataset by id; test error"))
+			})
+
+			It("fails the task if the dataset is not found", func() {
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: nil, Error: nil}}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError(`worker: dataset with id "` + dataset.UploadID + `" not found`))
+			})
+
+			It("fails the task if the dataset is deleted", func() {
+				dataset.DeletedTime = time.Now().UTC().Format(time.RFC3339)
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: dataset, Error: nil}}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError(`worker: dataset with id "` + dataset.UploadID + `" is deleted`))
+			})
+
+			It("fails the task if deduplicate dataset returns an error", func() {
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: dataset, Error: nil}}
+				dataDeduplicatorFactory.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{{Deduplicator: deduplicator, Error: nil}}
+				deduplicator.DeduplicateDatasetOutputs = []error{errors.New("test error")}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(dataset.State).To(Equal(upload.StateClosing))
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to deduplicate dataset; test error"))
+			})
+
+			It("fails the task if transition dataset state returns an error", func() {
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: dataset, Error: nil}}
+				dataDeduplicatorFactory.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{{Deduplicator: deduplicator, Error: nil}}
+				deduplicator.DeduplicateDatasetOutputs = []error{nil}
+				dataStoreSession.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{{Transitioned: false, Error: errors.New("test error")}}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to transition dataset state; test error"))
+			})
+
+			It("fails the task if the dataset state is changed during deduplication", func() {
+				dataStoreSession.GetDatasetByIDOutputs = []testDataStore.GetDatasetByIDOutput{{Dataset: dataset, Error: nil}}
+				dataDeduplicatorFactory.NewRegisteredDeduplicatorForDatasetOutputs = []testDataDeduplicator.NewRegisteredDeduplicatorForDatasetOutput{{Deduplicator: deduplicator, Error: nil}}
+				deduplicator.DeduplicateDatasetOutputs = []error{nil}
+				dataStoreSession.TransitionDatasetStateOutputs = []testDataStore.TransitionDatasetStateOutput{{Transitioned: false, Error: nil}}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError(`worker: dataset with id "` + dataset.UploadID + `" changed state during deduplication`))
+			})
+
+			It("fails the task if the task type is not supported", func() {
+				task.Type = "unknown"
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError(`worker: task type "unknown" is not supported`))
+			})
+
+			Context("with rededuplicate user task", func() {
+				BeforeEach(func() {
+					task.Type = worker.TaskTypeRededuplicateUser
+					task.UserID = app.NewID()
+					task.DatasetID = ""
+				})
+
+				It("rededuplicates the user and completes the task", func() {
+					dataset.UserID = task.UserID
+					dataset.State = upload.StateOpen
+					lockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+					dataStoreSession.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: []*upload.Upload{dataset}, Error: nil}}
+					dataStoreSession.GetDatasetLatestTimeOutputs = []testDataStore.GetDatasetLatestTimeOutput{{LatestTime: nil, Error: nil}}
+					lockStoreSession.ReleaseLockForUserByIDOutputs = []error{nil}
+					taskStoreSession.CompleteTaskOutputs = []error{nil}
+					standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+					Expect(lockStoreSession.AcquireLockForUserByIDInputs).To(Equal([]testLockStore.AcquireLockForUserByIDInput{{UserID: task.UserID, Operation: "rededuplicate"}}))
+					Expect(dataStoreSession.GetDatasetsForUserByIDInputs).To(HaveLen(1))
+					Expect(dataStoreSession.GetDatasetsForUserByIDInputs[0].UserID).To(Equal(task.UserID))
+					Expect(lockStoreSession.ReleaseLockForUserByIDInputs).To(Equal([]testLockStore.ReleaseLockForUserByIDInput{{UserID: task.UserID, Operation: "rededuplicate"}}))
+					Expect(lockStoreSession.CloseInvocations).To(Equal(1))
+					Expect(taskStoreSession.CompleteTaskInputs).To(Equal([]*taskStore.Task{task}))
+				})
+
+				It("fails the task if the user is locked", func() {
+					lockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: false, Error: nil}}
+					taskStoreSession.FailTaskOutputs = []error{nil}
+					standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+					Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+					Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError(`worker: unable to rededuplicate user; deduplicator: user with id "` + task.UserID + `" is locked`))
+				})
+
+				It("fails the task if rededuplicate user returns an error", func() {
+					lockStoreSession.AcquireLockForUserByIDOutputs = []testLockStore.AcquireLockForUserByIDOutput{{Acquired: true, Error: nil}}
+					dataStoreSession.GetDatasetsForUserByIDOutputs = []testDataStore.GetDatasetsForUserByIDOutput{{Datasets: nil, Error: errors.New("test error")}}
+					taskStoreSession.FailTaskOutputs = []error{nil}
+					standard.ProcessTask(dataStoreSession, taskStoreSession, task)
+					Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+					Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError(`worker: unable to rededuplicate user; deduplicator: unable to get datasets for user with id "` + task.UserID + `"; test error`))
+				})
+			})
+		})
+	})
+})
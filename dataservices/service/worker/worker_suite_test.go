@@ -0,0 +1,87 @@
+package worker_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+
+	dataStore "github.com/tidepool-org/platform/data/store"
+	testDataStore "github.com/tidepool-org/platform/data/store/test"
+	lockStore "github.com/tidepool-org/platform/lock/store"
+	testLockStore "github.com/tidepool-org/platform/lock/store/test"
+	"github.com/tidepool-org/platform/log"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "dataservices/service/worker")
+}
+
+type TestDataStore struct {
+	NewSessionInvocations int
+	SessionImpl           *testDataStore.Session
+}
+
+func (t *TestDataStore) IsClosed() bool {
+	panic("Unexpected invocation of IsClosed on TestDataStore")
+}
+
+func (t *TestDataStore) Close() {
+	panic("Unexpected invocation of Close on TestDataStore")
+}
+
+func (t *TestDataStore) GetStatus() interface{} {
+	panic("Unexpected invocation of GetStatus on TestDataStore")
+}
+
+func (t *TestDataStore) NewSession(logger log.Logger) dataStore.Session {
+	t.NewSessionInvocations++
+	return t.SessionImpl
+}
+
+type TestLockStore struct {
+	NewSessionInvocations int
+	SessionImpl           *testLockStore.Session
+}
+
+func (t *TestLockStore) IsClosed() bool {
+	panic("Unexpected invocation of IsClosed on TestLockStore")
+}
+
+func (t *TestLockStore) Close() {
+	panic("Unexpected invocation of Close on TestLockStore")
+}
+
+func (t *TestLockStore) GetStatus() interface{} {
+	panic("Unexpected invocation of GetStatus on TestLockStore")
+}
+
+func (t *TestLockStore) NewSession(logger log.Logger) lockStore.Session {
+	t.NewSessionInvocations++
+	return t.SessionImpl
+}
+
+type TestTaskStore struct {
+	NewSessionInvocations int
+	SessionImpl           *testTaskStore.Session
+}
+
+func (t *TestTaskStore) IsClosed() bool {
+	panic("Unexpected invocation of IsClosed on TestTaskStore")
+}
+
+func (t *TestTaskStore) Close() {
+	panic("Unexpected invocation of Close on TestTaskStore")
+}
+
+func (t *TestTaskStore) GetStatus() interface{} {
+	panic("Unexpected invocation of GetStatus on TestTaskStore")
+}
+
+func (t *TestTaskStore) NewSession(logger log.Logger) taskStore.Session {
+	t.NewSessionInvocations++
+	return t.SessionImpl
+}
@@ -0,0 +1,230 @@
+package mongo
+
+import (
+	"io"
+	"regexp"
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/tidepool-org/platform/errors"
+	"github.com/tidepool-org/platform/export/store"
+	"github.com/tidepool-org/platform/log"
+	"github.com/tidepool-org/platform/store/mongo"
+)
+
+const archivePartialSuffix = ".partial"
+
+var exportIDRegexp = regexp.MustCompile("^[0-9a-f]{32}$")
+
+// Archives are stored in GridFS, using the collection as the prefix, so they are available to every instance
+func New(logger log.Logger, config *mongo.Config) (*Store, error) {
+	if logger == nil {
+		return nil, errors.New("mongo", "logger is missing")
+	}
+
+	baseStore, err := mongo.New(logger, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		Store:  baseStore,
+		logger: logger,
+	}, nil
+}
+
+type Store struct {
+	*mongo.Store
+	logger log.Logger
+}
+
+func (s *Store) CreateArchive(exportID string, userID string) (store.ArchiveWriter, error) {
+	if !exportIDRegexp.MatchString(exportID) {
+		return nil, errors.New("mongo", "export id is invalid")
+	}
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "store closed")
+	}
+
+	session := s.Session.Copy()
+	gridFS := s.gridFS(session)
+
+	// The archive is created with a partial name, so it is not available until renamed on commit
+	file, err := gridFS.Create(exportID + archivePartialSuffix)
+	if err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "mongo", "unable to create archive")
+	}
+
+	// The user id is recorded with the archive, so the archives of a user are destroyed with the user; the chunks
+	// of an archive never committed or aborted have no file and so are only removed by DestroyArchivesCreatedBefore
+	file.SetMeta(bson.M{"userId": userID})
+
+	s.logger.WithFields(log.Fields{"exportId": exportID, "userId": userID}).Debug("CreateArchive")
+
+	return &archiveWriter{
+		session:  session,
+		gridFS:   gridFS,
+		file:     file,
+		exportID: exportID,
+	}, nil
+}
+
+func (s *Store) OpenArchive(exportID string) (io.ReadCloser, error) {
+	if !exportIDRegexp.MatchString(exportID) {
+		return nil, errors.New("mongo", "export id is invalid")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "store closed")
+	}
+
+	session := s.Session.Copy()
+
+	file, err := s.gridFS(session).Open(exportID)
+	if err == mgo.ErrNotFound {
+		session.Close()
+		return nil, nil
+	} else if err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "mongo", "unable to open archive")
+	}
+
+	s.logger.WithField("exportId", exportID).Debug("OpenArchive")
+
+	return &archiveReader{
+		session: session,
+		file:    file,
+	}, nil
+}
+
+func (s *Store) DestroyArchivesCreatedBefore(createdBefore time.Time) error {
+	if createdBefore.IsZero() {
+		return errors.New("mongo", "created before time is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "store closed")
+	}
+
+	session := s.Session.Copy()
+	defer session.Close()
+
+	gridFS := s.gridFS(session)
+
+	// The file id is created with the archive, so it also covers the chunks of any archive never committed or aborted
+	createdBeforeID := bson.NewObjectIdWithTime(createdBefore)
+
+	filesChangeInfo, err := gridFS.Files.RemoveAll(bson.M{"_id": bson.M{"$lt": createdBeforeID}})
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to remove archive files")
+	}
+	chunksChangeInfo, err := gridFS.Chunks.RemoveAll(bson.M{"files_id": bson.M{"$lt": createdBeforeID}})
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to remove archive chunks")
+	}
+
+	s.logger.WithFields(log.Fields{"createdBefore": createdBefore, "filesChangeInfo": filesChangeInfo, "chunksChangeInfo": chunksChangeInfo}).Debug("DestroyArchivesCreatedBefore")
+
+	return nil
+}
+
+func (s *Store) DestroyArchivesForUserByID(userID string) error {
+	if userID == "" {
+		return errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "store closed")
+	}
+
+	session := s.Session.Copy()
+	defer session.Close()
+
+	gridFS := s.gridFS(session)
+
+	var fileIDs []bson.ObjectId
+	if err := gridFS.Files.Find(bson.M{"metadata.userId": userID}).Distinct("_id", &fileIDs); err != nil {
+		return errors.Wrap(err, "mongo", "unable to get archive files")
+	}
+
+	// The chunks are removed before the files, so a failed destroy still finds the remaining chunks when retried
+	var chunksChangeInfo *mgo.ChangeInfo
+	var filesChangeInfo *mgo.ChangeInfo
+	if len(fileIDs) > 0 {
+		var err error
+		chunksChangeInfo, err = gridFS.Chunks.RemoveAll(bson.M{"files_id": bson.M{"$in": fileIDs}})
+		if err != nil {
+			return errors.Wrap(err, "mongo", "unable to remove archive chunks")
+		}
+		filesChangeInfo, err = gridFS.Files.RemoveAll(bson.M{"_id": bson.M{"$in": fileIDs}})
+		if err != nil {
+			return errors.Wrap(err, "mongo", "unable to remove archive files")
+		}
+	}
+
+	s.logger.WithFields(log.Fields{"userId": userID, "filesChangeInfo": filesChangeInfo, "chunksChangeInfo": chunksChangeInfo}).Debug("DestroyArchivesForUserByID")
+
+	return nil
+}
+
+func (s *Store) gridFS(session *mgo.Session) *mgo.GridFS {
+	return session.DB(s.Config.Database).GridFS(s.Config.Collection)
+}
+
+type archiveWriter struct {
+	session  *mgo.Session
+	gridFS   *mgo.GridFS
+	file     *mgo.GridFile
+	exportID string
+}
+
+func (a *archiveWriter) Write(bytes []byte) (int, error) {
+	return a.file.Write(bytes)
+}
+
+func (a *archiveWriter) Commit() error {
+	defer a.session.Close()
+
+	if err := a.file.Close(); err != nil {
+		return errors.Wrap(err, "mongo", "unable to close archive")
+	}
+	if err := a.gridFS.Files.UpdateId(a.file.Id(), bson.M{"$set": bson.M{"filename": a.exportID}}); err != nil {
+		a.gridFS.RemoveId(a.file.Id())
+		return errors.Wrap(err, "mongo", "unable to rename archive")
+	}
+	return nil
+}
+
+func (a *archiveWriter) Abort() error {
+	defer a.session.Close()
+
+	// Close always returns an error once aborted and ignores any error removing chunks, so remove them explicitly
+	a.file.Abort()
+	a.file.Close()
+	if _, err := a.gridFS.Chunks.RemoveAll(bson.M{"files_id": a.file.Id()}); err != nil {
+		return errors.Wrap(err, "mongo", "unable to remove archive")
+	}
+	return nil
+}
+
+type archiveReader struct {
+	session *mgo.Session
+	file    *mgo.GridFile
+}
+
+func (a *archiveReader) Read(bytes []byte) (int, error) {
+	return a.file.Read(bytes)
+}
+
+func (a *archiveReader) Close() error {
+	defer a.session.Close()
+
+	return a.file.Close()
+}
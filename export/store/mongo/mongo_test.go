@@ -0,0 +1,228 @@
+package mongo_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"io/ioutil"
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/export/store/mongo"
+	"github.com/tidepool-org/platform/log"
+	baseMongo "github.com/tidepool-org/platform/store/mongo"
+	testMongo "github.com/tidepool-org/platform/test/mongo"
+)
+
+var _ = Describe("Mongo", func() {
+	var mongoConfig *baseMongo.Config
+	var mongoStore *mongo.Store
+
+	BeforeEach(func() {
+		mongoConfig = &baseMongo.Config{
+			Addresses:  testMongo.Address(),
+			Database:   testMongo.Database(),
+			Collection: testMongo.NewCollectionName(),
+			Timeout:    app.DurationAsPointer(5 * time.Second),
+		}
+	})
+
+	AfterEach(func() {
+		if mongoStore != nil {
+			mongoStore.Close()
+		}
+	})
+
+	Context("New", func() {
+		It("returns an error if the logger is missing", func() {
+			var err error
+			mongoStore, err = mongo.New(nil, mongoConfig)
+			Expect(err).To(MatchError("mongo: logger is missing"))
+			Expect(mongoStore).To(BeNil())
+		})
+
+		It("returns an error if unsuccessful", func() {
+			var err error
+			mongoStore, err = mongo.New(log.NewNull(), nil)
+			Expect(err).To(HaveOccurred())
+			Expect(mongoStore).To(BeNil())
+		})
+
+		It("returns a new store and no error if successful", func() {
+			var err error
+			mongoStore, err = mongo.New(log.NewNull(), mongoConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mongoStore).ToNot(BeNil())
+		})
+	})
+
+	Context("with a new store", func() {
+		var exportID string
+		var userID string
+		var gridFS *mgo.GridFS
+
+		BeforeEach(func() {
+			var err error
+			mongoStore, err = mongo.New(log.NewNull(), mongoConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mongoStore).ToNot(BeNil())
+			exportID = app.NewID()
+			userID = app.NewID()
+			gridFS = mongoStore.Session.DB(mongoConfig.Database).GridFS(mongoConfig.Collection)
+		})
+
+		Context("CreateArchive", func() {
+			It("returns an error if the export id is missing", func() {
+				archiveWriter, err := mongoStore.CreateArchive("", userID)
+				Expect(err).To(MatchError("mongo: export id is invalid"))
+				Expect(archiveWriter).To(BeNil())
+			})
+
+			It("returns an error if the export id is invalid", func() {
+				archiveWriter, err := mongoStore.CreateArchive("../"+exportID, userID)
+				Expect(err).To(MatchError("mongo: export id is invalid"))
+				Expect(archiveWriter).To(BeNil())
+			})
+
+			It("returns an error if the user id is missing", func() {
+				archiveWriter, err := mongoStore.CreateArchive(exportID, "")
+				Expect(err).To(MatchError("mongo: user id is missing"))
+				Expect(archiveWriter).To(BeNil())
+			})
+
+			It("returns an error if the store is closed", func() {
+				mongoStore.Close()
+				archiveWriter, err := mongoStore.CreateArchive(exportID, userID)
+				Expect(err).To(MatchError("mongo: store closed"))
+				Expect(archiveWriter).To(BeNil())
+			})
+
+			It("makes the archive available only after commit", func() {
+				archiveWriter, err := mongoStore.CreateArchive(exportID, userID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(archiveWriter).ToNot(BeNil())
+				Expect(archiveWriter.Write([]byte("test-content"))).To(Equal(12))
+				Expect(mongoStore.OpenArchive(exportID)).To(BeNil())
+				Expect(archiveWriter.Commit()).To(Succeed())
+				archiveReader, err := mongoStore.OpenArchive(exportID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(archiveReader).ToNot(BeNil())
+				Expect(ioutil.ReadAll(archiveReader)).To(Equal([]byte("test-content")))
+				Expect(archiveReader.Close()).To(Succeed())
+			})
+
+			It("does not make the archive available after abort", func() {
+				archiveWriter, err := mongoStore.CreateArchive(exportID, userID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(archiveWriter).ToNot(BeNil())
+				Expect(archiveWriter.Write([]byte("test-content"))).To(Equal(12))
+				Expect(archiveWriter.Abort()).To(Succeed())
+				Expect(mongoStore.OpenArchive(exportID)).To(BeNil())
+				Expect(gridFS.Files.Count()).To(Equal(0))
+				Expect(gridFS.Chunks.Count()).To(Equal(0))
+			})
+		})
+
+		Context("OpenArchive", func() {
+			It("returns an error if the export id is invalid", func() {
+				archiveReader, err := mongoStore.OpenArchive("invalid")
+				Expect(err).To(MatchError("mongo: export id is invalid"))
+				Expect(archiveReader).To(BeNil())
+			})
+
+			It("returns an error if the store is closed", func() {
+				mongoStore.Close()
+				archiveReader, err := mongoStore.OpenArchive(exportID)
+				Expect(err).To(MatchError("mongo: store closed"))
+				Expect(archiveReader).To(BeNil())
+			})
+
+			It("returns nil if the archive does not exist", func() {
+				Expect(mongoStore.OpenArchive(exportID)).To(BeNil())
+			})
+		})
+
+		Context("DestroyArchivesCreatedBefore", func() {
+			var partialExportID string
+
+			BeforeEach(func() {
+				archiveWriter, err := mongoStore.CreateArchive(exportID, userID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(archiveWriter.Write([]byte("test-content"))).To(Equal(12))
+				Expect(archiveWriter.Commit()).To(Succeed())
+				partialExportID = app.NewID()
+				partialArchiveWriter, err := mongoStore.CreateArchive(partialExportID, userID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(partialArchiveWriter.Write(make([]byte, 300*1024))).To(Equal(300 * 1024))
+			})
+
+			It("returns an error if the created before time is missing", func() {
+				Expect(mongoStore.DestroyArchivesCreatedBefore(time.Time{})).To(MatchError("mongo: created before time is missing"))
+			})
+
+			It("returns an error if the store is closed", func() {
+				mongoStore.Close()
+				Expect(mongoStore.DestroyArchivesCreatedBefore(time.Now())).To(MatchError("mongo: store closed"))
+			})
+
+			It("does not remove archives created after the time", func() {
+				Expect(mongoStore.DestroyArchivesCreatedBefore(time.Now().Add(-time.Hour))).To(Succeed())
+				archiveReader, err := mongoStore.OpenArchive(exportID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(archiveReader).ToNot(BeNil())
+				Expect(archiveReader.Close()).To(Succeed())
+			})
+
+			It("removes archives, and the chunks of any partial archives, created before the time", func() {
+				Expect(mongoStore.DestroyArchivesCreatedBefore(time.Now().Add(time.Hour))).To(Succeed())
+				Expect(mongoStore.OpenArchive(exportID)).To(BeNil())
+				Expect(gridFS.Files.Count()).To(Equal(0))
+				Expect(gridFS.Chunks.Count()).To(Equal(0))
+			})
+		})
+
+		Context("DestroyArchivesForUserByID", func() {
+			var otherExportID string
+
+			BeforeEach(func() {
+				archiveWriter, err := mongoStore.CreateArchive(exportID, userID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(archiveWriter.Write([]byte("test-content"))).To(Equal(12))
+				Expect(archiveWriter.Commit()).To(Succeed())
+				otherExportID = app.NewID()
+				otherArchiveWriter, err := mongoStore.CreateArchive(otherExportID, app.NewID())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(otherArchiveWriter.Write([]byte("other-content"))).To(Equal(13))
+				Expect(otherArchiveWriter.Commit()).To(Succeed())
+			})
+
+			It("returns an error if the user id is missing", func() {
+				Expect(mongoStore.DestroyArchivesForUserByID("")).To(MatchError("mongo: user id is missing"))
+			})
+
+			It("returns an error if the store is closed", func() {
+				mongoStore.Close()
+				Expect(mongoStore.DestroyArchivesForUserByID(userID)).To(MatchError("mongo: store closed"))
+			})
+
+			It("removes the archives of the user only", func() {
+				Expect(mongoStore.DestroyArchivesForUserByID(userID)).To(Succeed())
+				Expect(mongoStore.OpenArchive(exportID)).To(BeNil())
+				Expect(gridFS.Files.Count()).To(Equal(1))
+				Expect(gridFS.Chunks.Count()).To(Equal(1))
+				archiveReader, err := mongoStore.OpenArchive(otherExportID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(archiveReader).ToNot(BeNil())
+				Expect(archiveReader.Close()).To(Succeed())
+			})
+
+			It("succeeds if the user has no archives", func() {
+				Expect(mongoStore.DestroyArchivesForUserByID(userID)).To(Succeed())
+				Expect(mongoStore.DestroyArchivesForUserByID(userID)).To(Succeed())
+				Expect(gridFS.Files.Count()).To(Equal(1))
+			})
+		})
+	})
+})
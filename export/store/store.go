@@ -0,0 +1,21 @@
+package store
+
+import (
+	"io"
+	"time"
+)
+
+type Store interface {
+	CreateArchive(exportID string, userID string) (ArchiveWriter, error)
+	OpenArchive(exportID string) (io.ReadCloser, error)
+	DestroyArchivesCreatedBefore(createdBefore time.Time) error
+	DestroyArchivesForUserByID(userID string) error
+}
+
+// ArchiveWriter must be finished with exactly one of Commit, which makes the archive available, or Abort
+type ArchiveWriter interface {
+	io.Writer
+
+	Commit() error
+	Abort() error
+}
@@ -0,0 +1,204 @@
+package mongo
+
+import (
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/tidepool-org/platform/errors"
+	"github.com/tidepool-org/platform/lock/store"
+	"github.com/tidepool-org/platform/log"
+	"github.com/tidepool-org/platform/store/mongo"
+)
+
+func New(logger log.Logger, config *mongo.Config) (*Store, error) {
+	baseStore, err := mongo.New(logger, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		Store: baseStore,
+	}, nil
+}
+
+type Store struct {
+	*mongo.Store
+}
+
+func (s *Store) NewSession(logger log.Logger) store.Session {
+	return &Session{
+		Session: s.Store.NewSession(logger),
+	}
+}
+
+type Session struct {
+	*mongo.Session
+}
+
+func (s *Session) CreateLockForUserByID(userID string) (*store.Lock, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	setOnInsert := bson.M{
+		"createdTime": s.Timestamp(),
+	}
+	if agentUserID := s.AgentUserID(); agentUserID != "" {
+		setOnInsert["createdUserId"] = agentUserID
+	}
+	change := mgo.Change{
+		Update:    bson.M{"$setOnInsert": setOnInsert},
+		Upsert:    true,
+		ReturnNew: true,
+	}
+
+	lock := &store.Lock{}
+	_, err := s.C().Find(bson.M{"userId": userID, "operation": bson.M{"$exists": false}}).Apply(change, lock)
+
+	loggerFields := log.Fields{"userId": userID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("CreateLockForUserByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to create lock for user by id")
+	}
+	return lock, nil
+}
+
+func (s *Session) GetLockForUserByID(userID string) (*store.Lock, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	locks := []*store.Lock{}
+	err := s.C().Find(bson.M{"userId": userID}).Limit(1).All(&locks)
+
+	loggerFields := log.Fields{"userId": userID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetLockForUserByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get lock for user by id")
+	}
+
+	if len(locks) == 0 {
+		return nil, nil
+	}
+	return locks[0], nil
+}
+
+func (s *Session) DestroyLockForUserByID(userID string) error {
+	if userID == "" {
+		return errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	selector := bson.M{
+		"userId":    userID,
+		"operation": bson.M{"$exists": false},
+	}
+	removeInfo, err := s.C().RemoveAll(selector)
+
+	loggerFields := log.Fields{"userId": userID, "removeInfo": removeInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("DestroyLockForUserByID")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to destroy lock for user by id")
+	}
+	return nil
+}
+
+func (s *Session) AcquireLockForUserByID(userID string, operation string) (bool, error) {
+	if userID == "" {
+		return false, errors.New("mongo", "user id is missing")
+	}
+	if operation == "" {
+		return false, errors.New("mongo", "operation is missing")
+	}
+
+	if s.IsClosed() {
+		return false, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	setOnInsert := bson.M{
+		"createdTime": s.Timestamp(),
+	}
+	if agentUserID := s.AgentUserID(); agentUserID != "" {
+		setOnInsert["createdUserId"] = agentUserID
+	}
+	change := mgo.Change{
+		Update:    bson.M{"$setOnInsert": setOnInsert},
+		Upsert:    true,
+		ReturnNew: true,
+	}
+
+	// The lock for the operation is upserted first and then released again, if it was just inserted, when
+	// the user is also locked without an operation or for another operation
+	acquired := false
+	lock := &store.Lock{}
+	changeInfo, err := s.C().Find(bson.M{"userId": userID, "operation": operation}).Apply(change, lock)
+	if err == nil {
+		var count int
+		if count, err = s.C().Find(bson.M{"userId": userID, "operation": bson.M{"$ne": operation}}).Count(); err == nil {
+			if acquired = count == 0; !acquired && changeInfo.UpsertedId != nil {
+				err = s.C().RemoveId(changeInfo.UpsertedId)
+			}
+		}
+	}
+
+	loggerFields := log.Fields{"userId": userID, "operation": operation, "acquired": acquired, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("AcquireLockForUserByID")
+
+	if err != nil {
+		return false, errors.Wrap(err, "mongo", "unable to acquire lock for user by id")
+	}
+	return acquired, nil
+}
+
+func (s *Session) ReleaseLockForUserByID(userID string, operation string) error {
+	if userID == "" {
+		return errors.New("mongo", "user id is missing")
+	}
+	if operation == "" {
+		return errors.New("mongo", "operation is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	selector := bson.M{
+		"userId":    userID,
+		"operation": operation,
+	}
+	removeInfo, err := s.C().RemoveAll(selector)
+
+	loggerFields := log.Fields{"userId": userID, "operation": operation, "removeInfo": removeInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("ReleaseLockForUserByID")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to release lock for user by id")
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+package mongo_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/lock/store"
+	"github.com/tidepool-org/platform/lock/store/mongo"
+	"github.com/tidepool-org/platform/log"
+	baseMongo "github.com/tidepool-org/platform/store/mongo"
+	testMongo "github.com/tidepool-org/platform/test/mongo"
+)
+
+func NewLock(userID string) bson.M {
+	return bson.M{
+		"userId":      userID,
+		"createdTime": time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func NewLocks() []interface{} {
+	locks := []interface{}{}
+	locks = append(locks, NewLock(app.NewID()), NewLock(app.NewID()), NewLock(app.NewID()))
+	return locks
+}
+
+func ValidateLocks(testMongoCollection *mgo.Collection, selector bson.M, expectedLocks []interface{}) {
+	var actualLocks []interface{}
+	Expect(testMongoCollection.Find(selector).Select(bson.M{"_id": 0}).All(&actualLocks)).To(Succeed())
+	Expect(actualLocks).To(ConsistOf(expectedLocks...))
+}
+
+var _ = Describe("Mongo", func() {
+	var mongoConfig *baseMongo.Config
+	var mongoStore *mongo.Store
+	var mongoSession store.Session
+
+	BeforeEach(func() {
+		mongoConfig = &baseMongo.Config{
+			Addresses:  testMongo.Address(),
+			Database:   testMongo.Database(),
+			Collection: testMongo.NewCollectionName(),
+			Timeout:    app.DurationAsPointer(5 * time.Second),
+		}
+	})
+
+	AfterEach(func() {
+		if mongoSession != nil {
+			mongoSession.Close()
+		}
+		if mongoStore != nil {
+			mongoStore.Close()
+		}
+	})
+
+	Context("New", func() {
+		It("returns an error if unsuccessful", func() {
+			var err error
+			mongoStore, err = mongo.New(nil, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(mongoStore).To(BeNil())
+		})
+
+		It("returns a new store and no error if successful", func() {
+			var err error
+			mongoStore, err = mongo.New(log.NewNull(), mongoConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mongoStore).ToNot(BeNil())
+		})
+	})
+
+	Context("with a new store", func() {
+		BeforeEach(func() {
+			var err error
+			mongoStore, err = mongo.New(log.NewNull(), mongoConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mongoStore).ToNot(BeNil())
+		})
+
+		Context("NewSession", func() {
+			It("returns a new session if no logger specified", func() {
+				mongoSession = mongoStore.NewSession(nil)
+				Expect(mongoSession).ToNot(BeNil())
+				Expect(mongoSession.Logger()).ToNot(BeNil())
+			})
+
+			It("returns a new session if logger specified", func() {
+				logger := log.NewNull()
+				mongoSession = mongoStore.NewSession(logger)
+				Expect(mongoSession).ToNot(BeNil())
+				Expect(mongoSession.Logger()).To(Equal(logger))
+			})
+		})
+
+		Context("with a new session", func() {
+			BeforeEach(func() {
+				mongoSession = mongoStore.NewSession(log.NewNull())
+				Expect(mongoSession).ToNot(BeNil())
+			})
+
+			Context("with persisted data", func() {
+				var testMongoSession *mgo.Session
+				var testMongoCollection *mgo.Collection
+				var locks []interface{}
+				var userID string
+
+				BeforeEach(func() {
+					testMongoSession = testMongo.Session().Copy()
+					testMongoCollection = testMongoSession.DB(mongoConfig.Database).C(mongoConfig.Collection)
+					locks = NewLocks()
+					userID = app.NewID()
+				})
+
+				JustBeforeEach(func() {
+					Expect(testMongoCollection.Insert(locks...)).To(Succeed())
+				})
+
+				AfterEach(func() {
+					if testMongoSession != nil {
+						testMongoSession.Close()
+					}
+				})
+
+				Context("CreateLockForUserByID", func() {
+					It("creates the lock", func() {
+						lock, err := mongoSession.CreateLockForUserByID(userID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lock).ToNot(BeNil())
+						Expect(lock.UserID).To(Equal(userID))
+						Expect(lock.CreatedTime).ToNot(BeEmpty())
+						Expect(mongoSession.GetLockForUserByID(userID)).To(Equal(lock))
+					})
+
+					It("returns the existing lock if the user is already locked", func() {
+						lock, err := mongoSession.CreateLockForUserByID(userID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(mongoSession.CreateLockForUserByID(userID)).To(Equal(lock))
+						Expect(testMongoCollection.Find(bson.M{"userId": userID}).Count()).To(Equal(1))
+					})
+
+					It("creates a separate lock if the user is already locked for an operation", func() {
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeTrue())
+						lock, err := mongoSession.CreateLockForUserByID(userID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lock).ToNot(BeNil())
+						Expect(lock.Operation).To(BeEmpty())
+						Expect(testMongoCollection.Find(bson.M{"userId": userID}).Count()).To(Equal(2))
+					})
+
+					It("returns an error if the user id is missing", func() {
+						lock, err := mongoSession.CreateLockForUserByID("")
+						Expect(err).To(MatchError("mongo: user id is missing"))
+						Expect(lock).To(BeNil())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						lock, err := mongoSession.CreateLockForUserByID(userID)
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(lock).To(BeNil())
+					})
+				})
+
+				Context("GetLockForUserByID", func() {
+					It("returns nil if the user is not locked", func() {
+						Expect(mongoSession.GetLockForUserByID(userID)).To(BeNil())
+					})
+
+					It("returns an error if the user id is missing", func() {
+						lock, err := mongoSession.GetLockForUserByID("")
+						Expect(err).To(MatchError("mongo: user id is missing"))
+						Expect(lock).To(BeNil())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						lock, err := mongoSession.GetLockForUserByID(userID)
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(lock).To(BeNil())
+					})
+				})
+
+				Context("DestroyLockForUserByID", func() {
+					var destroyLock bson.M
+
+					BeforeEach(func() {
+						destroyLock = NewLock(userID)
+					})
+
+					JustBeforeEach(func() {
+						Expect(testMongoCollection.Insert(destroyLock)).To(Succeed())
+					})
+
+					It("succeeds if it successfully removes the lock", func() {
+						Expect(mongoSession.DestroyLockForUserByID(userID)).To(Succeed())
+					})
+
+					It("does not destroy the lock acquired for an operation", func() {
+						operationLock := NewLock(userID)
+						operationLock["operation"] = "other"
+						Expect(testMongoCollection.Insert(operationLock)).To(Succeed())
+						Expect(mongoSession.DestroyLockForUserByID(userID)).To(Succeed())
+						lock, err := mongoSession.GetLockForUserByID(userID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lock).ToNot(BeNil())
+						Expect(lock.Operation).To(Equal("other"))
+					})
+
+					It("returns an error if the user id is missing", func() {
+						Expect(mongoSession.DestroyLockForUserByID("")).To(MatchError("mongo: user id is missing"))
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						Expect(mongoSession.DestroyLockForUserByID(userID)).To(MatchError("mongo: session closed"))
+					})
+
+					It("has the correct stored locks", func() {
+						ValidateLocks(testMongoCollection, bson.M{}, append(locks, destroyLock))
+						Expect(mongoSession.DestroyLockForUserByID(userID)).To(Succeed())
+						ValidateLocks(testMongoCollection, bson.M{}, locks)
+					})
+				})
+
+				Context("AcquireLockForUserByID", func() {
+					It("acquires the lock if the user is not locked", func() {
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeTrue())
+						lock, err := mongoSession.GetLockForUserByID(userID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(lock).ToNot(BeNil())
+						Expect(lock.Operation).To(Equal("test"))
+					})
+
+					It("acquires the lock if the user is already locked for the same operation", func() {
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeTrue())
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeTrue())
+						Expect(testMongoCollection.Find(bson.M{"userId": userID}).Count()).To(Equal(1))
+					})
+
+					It("does not acquire the lock if the user is already locked for another operation", func() {
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeTrue())
+						Expect(mongoSession.AcquireLockForUserByID(userID, "other")).To(BeFalse())
+					})
+
+					It("does not acquire the lock if the user is already locked without an operation", func() {
+						_, err := mongoSession.CreateLockForUserByID(userID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeFalse())
+						Expect(testMongoCollection.Find(bson.M{"userId": userID, "operation": "test"}).Count()).To(Equal(0))
+					})
+
+					It("does not remove the existing lock for the operation if the user is also locked without an operation", func() {
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeTrue())
+						_, err := mongoSession.CreateLockForUserByID(userID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeFalse())
+						Expect(testMongoCollection.Find(bson.M{"userId": userID, "operation": "test"}).Count()).To(Equal(1))
+					})
+
+					It("returns an error if the user id is missing", func() {
+						acquired, err := mongoSession.AcquireLockForUserByID("", "test")
+						Expect(err).To(MatchError("mongo: user id is missing"))
+						Expect(acquired).To(BeFalse())
+					})
+
+					It("returns an error if the operation is missing", func() {
+						acquired, err := mongoSession.AcquireLockForUserByID(userID, "")
+						Expect(err).To(MatchError("mongo: operation is missing"))
+						Expect(acquired).To(BeFalse())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						acquired, err := mongoSession.AcquireLockForUserByID(userID, "test")
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(acquired).To(BeFalse())
+					})
+				})
+
+				Context("ReleaseLockForUserByID", func() {
+					It("releases the lock acquired for the operation", func() {
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeTrue())
+						Expect(mongoSession.ReleaseLockForUserByID(userID, "test")).To(Succeed())
+						Expect(mongoSession.GetLockForUserByID(userID)).To(BeNil())
+					})
+
+					It("does not release the lock acquired for another operation", func() {
+						Expect(mongoSession.AcquireLockForUserByID(userID, "test")).To(BeTrue())
+						Expect(mongoSession.ReleaseLockForUserByID(userID, "other")).To(Succeed())
+						Expect(mongoSession.GetLockForUserByID(userID)).ToNot(BeNil())
+					})
+
+					It("does not release the lock created without an operation", func() {
+						_, err := mongoSession.CreateLockForUserByID(userID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(mongoSession.ReleaseLockForUserByID(userID, "test")).To(Succeed())
+						Expect(mongoSession.GetLockForUserByID(userID)).ToNot(BeNil())
+					})
+
+					It("returns an error if the user id is missing", func() {
+						Expect(mongoSession.ReleaseLockForUserByID("", "test")).To(MatchError("mongo: user id is missing"))
+					})
+
+					It("returns an error if the operation is missing", func() {
+						Expect(mongoSession.ReleaseLockForUserByID(userID, "")).To(MatchError("mongo: operation is missing"))
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						Expect(mongoSession.ReleaseLockForUserByID(userID, "test")).To(MatchError("mongo: session closed"))
+					})
+				})
+			})
+		})
+	})
+})
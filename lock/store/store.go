@@ -0,0 +1,34 @@
+package store
+
+import (
+	"github.com/tidepool-org/platform/log"
+	"github.com/tidepool-org/platform/store"
+)
+
+type Store interface {
+	store.Store
+
+	NewSession(logger log.Logger) Session
+}
+
+type Session interface {
+	store.Session
+
+	CreateLockForUserByID(userID string) (*Lock, error)
+	GetLockForUserByID(userID string) (*Lock, error)
+	DestroyLockForUserByID(userID string) error
+
+	AcquireLockForUserByID(userID string, operation string) (bool, error)
+	ReleaseLockForUserByID(userID string, operation string) error
+}
+
+// A lock on a user makes the data of the user read only. A lock acquired for an operation, such as
+// rededuplicating the data of the user, is held by that operation until released. Locks are kept per user
+// and operation, so destroying the lock created without an operation, or releasing the lock of one
+// operation, never removes the lock of another operation.
+type Lock struct {
+	UserID        string `json:"userId" bson:"userId"`
+	Operation     string `json:"operation,omitempty" bson:"operation,omitempty"`
+	CreatedTime   string `json:"createdTime,omitempty" bson:"createdTime,omitempty"`
+	CreatedUserID string `json:"createdUserId,omitempty" bson:"createdUserId,omitempty"`
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/lock/store"
+	"github.com/tidepool-org/platform/log"
+	commonStore "github.com/tidepool-org/platform/store"
+)
+
+type CreateLockForUserByIDOutput struct {
+	Lock  *store.Lock
+	Error error
+}
+
+type GetLockForUserByIDOutput struct {
+	Lock  *store.Lock
+	Error error
+}
+
+type AcquireLockForUserByIDInput struct {
+	UserID    string
+	Operation string
+}
+
+type AcquireLockForUserByIDOutput struct {
+	Acquired bool
+	Error    error
+}
+
+type ReleaseLockForUserByIDInput struct {
+	UserID    string
+	Operation string
+}
+
+type Session struct {
+	ID                                string
+	IsClosedInvocations               int
+	IsClosedOutputs                   []bool
+	CloseInvocations                  int
+	LoggerInvocations                 int
+	LoggerImpl                        log.Logger
+	SetAgentInvocations               int
+	SetAgentInputs                    []commonStore.Agent
+	CreateLockForUserByIDInvocations  int
+	CreateLockForUserByIDInputs       []string
+	CreateLockForUserByIDOutputs      []CreateLockForUserByIDOutput
+	GetLockForUserByIDInvocations     int
+	GetLockForUserByIDInputs          []string
+	GetLockForUserByIDOutputs         []GetLockForUserByIDOutput
+	DestroyLockForUserByIDInvocations int
+	DestroyLockForUserByIDInputs      []string
+	DestroyLockForUserByIDOutputs     []error
+	AcquireLockForUserByIDInvocations int
+	AcquireLockForUserByIDInputs      []AcquireLockForUserByIDInput
+	AcquireLockForUserByIDOutputs     []AcquireLockForUserByIDOutput
+	ReleaseLockForUserByIDInvocations int
+	ReleaseLockForUserByIDInputs      []ReleaseLockForUserByIDInput
+	ReleaseLockForUserByIDOutputs     []error
+}
+
+func NewSession() *Session {
+	return &Session{
+		ID:         app.NewID(),
+		LoggerImpl: log.NewNull(),
+	}
+}
+
+func (s *Session) IsClosed() bool {
+	s.IsClosedInvocations++
+
+	if len(s.IsClosedOutputs) == 0 {
+		panic("Unexpected invocation of IsClosed on Session")
+	}
+
+	output := s.IsClosedOutputs[0]
+	s.IsClosedOutputs = s.IsClosedOutputs[1:]
+	return output
+}
+
+func (s *Session) Close() {
+	s.CloseInvocations++
+}
+
+func (s *Session) Logger() log.Logger {
+	s.LoggerInvocations++
+
+	return s.LoggerImpl
+}
+
+func (s *Session) SetAgent(agent commonStore.Agent) {
+	s.SetAgentInvocations++
+
+	s.SetAgentInputs = append(s.SetAgentInputs, agent)
+}
+
+func (s *Session) CreateLockForUserByID(userID string) (*store.Lock, error) {
+	s.CreateLockForUserByIDInvocations++
+
+	s.CreateLockForUserByIDInputs = append(s.CreateLockForUserByIDInputs, userID)
+
+	if len(s.CreateLockForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of CreateLockForUserByID on Session")
+	}
+
+	output := s.CreateLockForUserByIDOutputs[0]
+	s.CreateLockForUserByIDOutputs = s.CreateLockForUserByIDOutputs[1:]
+	return output.Lock, output.Error
+}
+
+func (s *Session) GetLockForUserByID(userID string) (*store.Lock, error) {
+	s.GetLockForUserByIDInvocations++
+
+	s.GetLockForUserByIDInputs = append(s.GetLockForUserByIDInputs, userID)
+
+	if len(s.GetLockForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of GetLockForUserByID on Session")
+	}
+
+	output := s.GetLockForUserByIDOutputs[0]
+	s.GetLockForUserByIDOutputs = s.GetLockForUserByIDOutputs[1:]
+	return output.Lock, output.Error
+}
+
+func (s *Session) DestroyLockForUserByID(userID string) error {
+	s.DestroyLockForUserByIDInvocations++
+
+	s.DestroyLockForUserByIDInputs = append(s.DestroyLockForUserByIDInputs, userID)
+
+	if len(s.DestroyLockForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of DestroyLockForUserByID on Session")
+	}
+
+	output := s.DestroyLockForUserByIDOutputs[0]
+	s.DestroyLockForUserByIDOutputs = s.DestroyLockForUserByIDOutputs[1:]
+	return output
+}
+
+func (s *Session) AcquireLockForUserByID(userID string, operation string) (bool, error) {
+	s.AcquireLockForUserByIDInvocations++
+
+	s.AcquireLockForUserByIDInputs = append(s.AcquireLockForUserByIDInputs, AcquireLockForUserByIDInput{UserID: userID, Operation: operation})
+
+	if len(s.AcquireLockForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of AcquireLockForUserByID on Session")
+	}
+
+	output := s.AcquireLockForUserByIDOutputs[0]
+	s.AcquireLockForUserByIDOutputs = s.AcquireLockForUserByIDOutputs[1:]
+	return output.Acquired, output.Error
+}
+
+func (s *Session) ReleaseLockForUserByID(userID string, operation string) error {
+	s.ReleaseLockForUserByIDInvocations++
+
+	s.ReleaseLockForUserByIDInputs = append(s.ReleaseLockForUserByIDInputs, ReleaseLockForUserByIDInput{UserID: userID, Operation: operation})
+
+	if len(s.ReleaseLockForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of ReleaseLockForUserByID on Session")
+	}
+
+	output := s.ReleaseLockForUserByIDOutputs[0]
+	s.ReleaseLockForUserByIDOutputs = s.ReleaseLockForUserByIDOutputs[1:]
+	return output
+}
+
+func (s *Session) UnusedOutputsCount() int {
+	return len(s.IsClosedOutputs) +
+		len(s.CreateLockForUserByIDOutputs) +
+		len(s.GetLockForUserByIDOutputs) +
+		len(s.DestroyLockForUserByIDOutputs) +
+		len(s.AcquireLockForUserByIDOutputs) +
+		len(s.ReleaseLockForUserByIDOutputs)
+}
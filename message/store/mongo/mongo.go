@@ -36,6 +36,36 @@ type Session struct {
 	*mongo.Session
 }
 
+func (s *Session) GetMessagesForUserByID(userID string) ([]map[string]interface{}, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	messages := []map[string]interface{}{}
+	selector := bson.M{
+		"$or": []bson.M{
+			{"groupid": userID},
+			{"userid": userID},
+		},
+	}
+	err := s.C().Find(selector).Select(bson.M{"_id": 0}).All(&messages)
+
+	loggerFields := log.Fields{"userId": userID, "messagesCount": len(messages), "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetMessagesForUserByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get messages for user by id")
+	}
+
+	return messages, nil
+}
+
 func (s *Session) DeleteMessagesFromUser(user *store.User) error {
 	if user == nil {
 		return errors.New("mongo", "user is missing")
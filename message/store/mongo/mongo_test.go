@@ -154,6 +154,47 @@ var _ = Describe("Mongo", func() {
 					}
 				})
 
+				Context("GetMessagesForUserByID", func() {
+					var getUserID string
+					var getMessages []interface{}
+
+					BeforeEach(func() {
+						getUserID = app.NewID()
+						getMessages = append(NewMessages(getUserID, app.NewID()), NewMessages(app.NewID(), getUserID)...)
+					})
+
+					JustBeforeEach(func() {
+						Expect(testMongoCollection.Insert(getMessages...)).To(Succeed())
+					})
+
+					It("succeeds if it successfully gets messages", func() {
+						messages, err := mongoSession.GetMessagesForUserByID(getUserID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(messages).To(HaveLen(len(getMessages)))
+						for _, message := range messages {
+							Expect(message).ToNot(HaveKey("_id"))
+							Expect([]interface{}{message["groupid"], message["userid"]}).To(ContainElement(getUserID))
+						}
+					})
+
+					It("returns no messages if there are none for the user", func() {
+						Expect(mongoSession.GetMessagesForUserByID(app.NewID())).To(BeEmpty())
+					})
+
+					It("returns an error if the user id is missing", func() {
+						messages, err := mongoSession.GetMessagesForUserByID("")
+						Expect(err).To(MatchError("mongo: user id is missing"))
+						Expect(messages).To(BeNil())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						messages, err := mongoSession.GetMessagesForUserByID(getUserID)
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(messages).To(BeNil())
+					})
+				})
+
 				Context("DeleteMessagesFromUser", func() {
 					var deleteGroupID string
 					var deleteUserID string
@@ -14,6 +14,7 @@ type Store interface {
 type Session interface {
 	store.Session
 
+	GetMessagesForUserByID(userID string) ([]map[string]interface{}, error)
 	DeleteMessagesFromUser(user *User) error
 	DestroyMessagesForUserByID(userID string) error
 }
@@ -36,6 +36,36 @@ type Session struct {
 	*mongo.Session
 }
 
+func (s *Session) GetNotificationsForUserByID(userID string) ([]map[string]interface{}, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	notifications := []map[string]interface{}{}
+	selector := bson.M{
+		"$or": []bson.M{
+			{"userId": userID},
+			{"creatorId": userID},
+		},
+	}
+	err := s.C().Find(selector).Select(bson.M{"_id": 0}).All(&notifications)
+
+	loggerFields := log.Fields{"userId": userID, "notificationsCount": len(notifications), "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetNotificationsForUserByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get notifications for user by id")
+	}
+
+	return notifications, nil
+}
+
 func (s *Session) DestroyNotificationsForUserByID(userID string) error {
 	if userID == "" {
 		return errors.New("mongo", "user id is missing")
@@ -140,6 +140,47 @@ var _ = Describe("Mongo", func() {
 					}
 				})
 
+				Context("GetNotificationsForUserByID", func() {
+					var getUserID string
+					var getNotifications []interface{}
+
+					BeforeEach(func() {
+						getUserID = app.NewID()
+						getNotifications = NewNotifications(getUserID, app.NewID())
+					})
+
+					JustBeforeEach(func() {
+						Expect(testMongoCollection.Insert(getNotifications...)).To(Succeed())
+					})
+
+					It("succeeds if it successfully gets notifications", func() {
+						notifications, err := mongoSession.GetNotificationsForUserByID(getUserID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(notifications).To(HaveLen(len(getNotifications)))
+						for _, notification := range notifications {
+							Expect(notification).ToNot(HaveKey("_id"))
+							Expect([]interface{}{notification["userId"], notification["creatorId"]}).To(ContainElement(getUserID))
+						}
+					})
+
+					It("returns no notifications if there are none for the user", func() {
+						Expect(mongoSession.GetNotificationsForUserByID(app.NewID())).To(BeEmpty())
+					})
+
+					It("returns an error if the user id is missing", func() {
+						notifications, err := mongoSession.GetNotificationsForUserByID("")
+						Expect(err).To(MatchError("mongo: user id is missing"))
+						Expect(notifications).To(BeNil())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						notifications, err := mongoSession.GetNotificationsForUserByID(getUserID)
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(notifications).To(BeNil())
+					})
+				})
+
 				Context("DestroyNotificationsForUserByID", func() {
 					var destroyUserID string
 					var destroyOtherID string
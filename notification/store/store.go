@@ -14,5 +14,6 @@ type Store interface {
 type Session interface {
 	store.Session
 
+	GetNotificationsForUserByID(userID string) ([]map[string]interface{}, error)
 	DestroyNotificationsForUserByID(userID string) error
 }
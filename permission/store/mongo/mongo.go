@@ -50,6 +50,49 @@ type Session struct {
 	config *Config
 }
 
+func (s *Session) GetPermissionsForUserByID(userID string) ([]map[string]interface{}, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	groupID, err := permission.GroupIDFromUserID(userID, s.config.Secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to determine group id from user id")
+	}
+
+	permissions := []map[string]interface{}{}
+	selector := bson.M{
+		"$or": []bson.M{
+			{"groupId": groupID},
+			{"userId": userID},
+		},
+	}
+	err = s.C().Find(selector).Select(bson.M{"_id": 0}).All(&permissions)
+
+	loggerFields := log.Fields{"userId": userID, "permissionsCount": len(permissions), "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetPermissionsForUserByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get permissions for user by id")
+	}
+
+	for _, document := range permissions {
+		if documentGroupID, ok := document["groupId"].(string); ok {
+			if document["groupId"], err = permission.UserIDFromGroupID(documentGroupID, s.config.Secret); err != nil {
+				return nil, errors.Wrap(err, "mongo", "unable to determine user id from group id")
+			}
+		}
+	}
+
+	return permissions, nil
+}
+
 func (s *Session) DestroyPermissionsForUserByID(userID string) error {
 	if userID == "" {
 		return errors.New("mongo", "user id is missing")
@@ -168,6 +168,47 @@ var _ = Describe("Mongo", func() {
 					}
 				})
 
+				Context("GetPermissionsForUserByID", func() {
+					var getUserID string
+					var getPermissions []interface{}
+
+					BeforeEach(func() {
+						getUserID = app.NewID()
+						getPermissions = NewPermissions(getUserID)
+					})
+
+					JustBeforeEach(func() {
+						Expect(testMongoCollection.Insert(getPermissions...)).To(Succeed())
+					})
+
+					It("succeeds if it successfully gets permissions with decrypted group ids", func() {
+						permissions, err := mongoSession.GetPermissionsForUserByID(getUserID)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(permissions).To(HaveLen(len(getPermissions)))
+						for _, permission := range permissions {
+							Expect(permission).ToNot(HaveKey("_id"))
+							Expect([]interface{}{permission["groupId"], permission["userId"]}).To(ContainElement(getUserID))
+						}
+					})
+
+					It("returns no permissions if there are none for the user", func() {
+						Expect(mongoSession.GetPermissionsForUserByID(app.NewID())).To(BeEmpty())
+					})
+
+					It("returns an error if the user id is missing", func() {
+						permissions, err := mongoSession.GetPermissionsForUserByID("")
+						Expect(err).To(MatchError("mongo: user id is missing"))
+						Expect(permissions).To(BeNil())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						permissions, err := mongoSession.GetPermissionsForUserByID(getUserID)
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(permissions).To(BeNil())
+					})
+				})
+
 				Context("DestroyPermissionsForUserByID", func() {
 					var destroyUserID string
 					var destroyPermissions []interface{}
@@ -14,5 +14,6 @@ type Store interface {
 type Session interface {
 	store.Session
 
+	GetPermissionsForUserByID(userID string) ([]map[string]interface{}, error)
 	DestroyPermissionsForUserByID(userID string) error
 }
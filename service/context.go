@@ -1,6 +1,8 @@
 package service
 
 import (
+	"io"
+
 	"github.com/ant0ine/go-json-rest/rest"
 
 	"github.com/tidepool-org/platform/log"
@@ -16,4 +18,7 @@ type Context interface {
 	RespondWithInternalServerFailure(message string, failure ...interface{})
 	RespondWithStatusAndErrors(statusCode int, errors []*Error)
 	RespondWithStatusAndData(statusCode int, data interface{})
+	RespondWithStatusAndDataAndCursor(statusCode int, data interface{}, cursor string)
+	RespondWithStatusAndStream(statusCode int, stream Stream)
+	RespondWithStatusAndAttachment(statusCode int, contentType string, filename string, attachment io.ReadCloser)
 }
@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"bytes"
 	"net/http"
 	"testing"
 
@@ -38,6 +39,9 @@ type TestResponseWriter struct {
 	EncodeJSONInputs  []interface{}
 	EncodeJSONOutputs []EncodeJSONOutput
 	WriteHeaderInputs []int
+	WriteInputs       [][]byte
+	WriteOutputs      []error
+	FlushInvocations  int
 }
 
 func (t *TestResponseWriter) Header() http.Header {
@@ -62,8 +66,74 @@ func (t *TestResponseWriter) WriteHeader(code int) {
 	t.WriteHeaderInputs = append(t.WriteHeaderInputs, code)
 }
 
+func (t *TestResponseWriter) Write(bytes []byte) (int, error) {
+	t.WriteInputs = append(t.WriteInputs, bytes)
+	output := t.WriteOutputs[0]
+	t.WriteOutputs = t.WriteOutputs[1:]
+	if output != nil {
+		return 0, output
+	}
+	return len(bytes), nil
+}
+
+func (t *TestResponseWriter) Flush() {
+	t.FlushInvocations++
+}
+
+type TestRestResponseWriter struct {
+	rest.ResponseWriter
+}
+
 func NewTestResponseWriter() *TestResponseWriter {
 	return &TestResponseWriter{
 		header: http.Header{},
 	}
 }
+
+type TestStream struct {
+	NextOutputs      []interface{}
+	ErrOutputs       []error
+	CloseInvocations int
+	CloseOutputs     []error
+}
+
+func (t *TestStream) Next(result interface{}) bool {
+	if len(t.NextOutputs) == 0 {
+		return false
+	}
+	*result.(*interface{}) = t.NextOutputs[0]
+	t.NextOutputs = t.NextOutputs[1:]
+	return true
+}
+
+func (t *TestStream) Err() error {
+	output := t.ErrOutputs[0]
+	t.ErrOutputs = t.ErrOutputs[1:]
+	return output
+}
+
+func (t *TestStream) Close() error {
+	t.CloseInvocations++
+	output := t.CloseOutputs[0]
+	t.CloseOutputs = t.CloseOutputs[1:]
+	return output
+}
+
+type TestAttachment struct {
+	*bytes.Reader
+	CloseInvocations int
+	CloseOutputs     []error
+}
+
+func NewTestAttachment(content string) *TestAttachment {
+	return &TestAttachment{
+		Reader: bytes.NewReader([]byte(content)),
+	}
+}
+
+func (t *TestAttachment) Close() error {
+	t.CloseInvocations++
+	output := t.CloseOutputs[0]
+	t.CloseOutputs = t.CloseOutputs[1:]
+	return output
+}
@@ -1,7 +1,12 @@
 package context
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
 
 	"github.com/ant0ine/go-json-rest/rest"
 
@@ -17,7 +22,8 @@ type JSONResponse struct {
 }
 
 type Meta struct {
-	Trace *Trace `json:"trace,omitempty"`
+	Trace      *Trace      `json:"trace,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
 }
 
 type Trace struct {
@@ -25,6 +31,12 @@ type Trace struct {
 	Session string `json:"session,omitempty"`
 }
 
+type Pagination struct {
+	Next string `json:"next,omitempty"`
+}
+
+const _StreamFlushCount = 100
+
 type Standard struct {
 	response rest.ResponseWriter
 	request  *rest.Request
@@ -121,6 +133,139 @@ func (s *Standard) RespondWithStatusAndData(statusCode int, data interface{}) {
 	s.respondWithStatusAndResponse(statusCode, response)
 }
 
+func (s *Standard) RespondWithStatusAndDataAndCursor(statusCode int, data interface{}, cursor string) {
+	response := &JSONResponse{
+		Data: data,
+		Meta: &Meta{
+			Trace: &Trace{
+				Request: service.GetRequestTraceRequest(s.Request()),
+				Session: service.GetRequestTraceSession(s.Request()),
+			},
+		},
+	}
+	if cursor != "" {
+		response.Meta.Pagination = &Pagination{
+			Next: cursor,
+		}
+	}
+
+	s.respondWithStatusAndResponse(statusCode, response)
+}
+
+func (s *Standard) RespondWithStatusAndStream(statusCode int, stream service.Stream) {
+	if stream == nil {
+		s.RespondWithInternalServerFailure("Stream is missing")
+		return
+	}
+
+	defer func() {
+		if err := stream.Close(); err != nil {
+			s.Logger().WithError(err).Error("Unable to close stream")
+		}
+	}()
+
+	writer, ok := s.response.(http.ResponseWriter)
+	if !ok {
+		s.RespondWithInternalServerFailure("Response does not support streaming")
+		return
+	}
+	flusher, _ := s.response.(http.Flusher)
+
+	done := s.request.Context().Done()
+	written := false
+	count := 0
+
+	var result interface{}
+	for stream.Next(&result) {
+		select {
+		case <-done:
+			s.Logger().WithField("count", count).Warn("Client disconnected during stream")
+			return
+		default:
+		}
+
+		bytes, err := json.Marshal(result)
+		if err != nil {
+			s.Logger().WithError(err).WithField("count", count).Error("Unable to encode stream result")
+			return
+		}
+
+		if !written {
+			s.respondWithStatusAndStreamHeader(statusCode)
+			written = true
+		}
+
+		if _, err = writer.Write(append(bytes, '\n')); err != nil {
+			s.Logger().WithError(err).WithField("count", count).Warn("Unable to write stream result")
+			return
+		}
+
+		count++
+		if flusher != nil && count%_StreamFlushCount == 0 {
+			flusher.Flush()
+		}
+
+		result = nil
+	}
+
+	if err := stream.Err(); err != nil {
+		if !written {
+			s.RespondWithInternalServerFailure("Unable to iterate stream", err)
+			return
+		}
+		s.Logger().WithError(err).WithField("count", count).Error("Unable to iterate stream")
+		return
+	}
+
+	if !written {
+		s.respondWithStatusAndStreamHeader(statusCode)
+	}
+
+	// The count trailer is only sent once the entire stream is written, so the client can detect a truncated stream
+	s.response.Header().Set(service.StreamCountTrailer, strconv.Itoa(count))
+
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+func (s *Standard) RespondWithStatusAndAttachment(statusCode int, contentType string, filename string, attachment io.ReadCloser) {
+	if attachment == nil {
+		s.RespondWithInternalServerFailure("Attachment is missing")
+		return
+	}
+
+	defer func() {
+		if err := attachment.Close(); err != nil {
+			s.Logger().WithError(err).Error("Unable to close attachment")
+		}
+	}()
+
+	writer, ok := s.response.(http.ResponseWriter)
+	if !ok {
+		s.RespondWithInternalServerFailure("Response does not support attachments")
+		return
+	}
+
+	service.AddDateHeader(s.response)
+
+	s.response.Header().Set("Content-Type", contentType)
+	s.response.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	s.response.WriteHeader(statusCode)
+
+	if written, err := io.Copy(writer, attachment); err != nil {
+		s.Logger().WithError(err).WithField("written", written).Warn("Unable to write attachment")
+	}
+}
+
+func (s *Standard) respondWithStatusAndStreamHeader(statusCode int) {
+	service.AddDateHeader(s.response)
+
+	s.response.Header().Set("Content-Type", service.MediaTypeNDJSON)
+	s.response.Header().Set("Trailer", service.StreamCountTrailer)
+	s.response.WriteHeader(statusCode)
+}
+
 func (s *Standard) respondWithStatusAndResponse(statusCode int, response *JSONResponse) {
 	service.AddDateHeader(s.response)
 
@@ -1,6 +1,7 @@
 package context_test
 
 import (
+	netContext "context"
 	"errors"
 
 	. "github.com/onsi/ginkgo"
@@ -78,17 +79,41 @@ var _ = Describe("Standard", func() {
 						Request: "test-request",
 						Session: "test-session",
 					},
+					Pagination: &context.Pagination{
+						Next: "test-next",
+					},
 				}
 				Expect(json.Marshal(meta)).To(MatchJSON(`{
 					"trace": {
 						"request": "test-request",
 						"session": "test-session"
+					},
+					"pagination": {
+						"next": "test-next"
 					}
 				}`))
 			})
 		})
 	})
 
+	Context("Pagination", func() {
+		Context("encoded as JSON", func() {
+			It("is an empty object if no fields are specified", func() {
+				pagination := &context.Pagination{}
+				Expect(json.Marshal(pagination)).To(MatchJSON(`{}`))
+			})
+
+			It("is a populated object if fields are specified", func() {
+				pagination := &context.Pagination{
+					Next: "test-next",
+				}
+				Expect(json.Marshal(pagination)).To(MatchJSON(`{
+					"next": "test-next"
+				}`))
+			})
+		})
+	})
+
 	Context("Trace", func() {
 		Context("encoded as JSON", func() {
 			It("is an empty object if no fields are specified", func() {
@@ -398,6 +423,190 @@ var _ = Describe("Standard", func() {
 					})
 				})
 			})
+
+			Context("with data and cursor", func() {
+				var testData interface{}
+
+				BeforeEach(func() {
+					response.WriteJSONOutputs = []error{nil}
+					testData = []map[string]string{{"data-1": "value-1"}}
+				})
+
+				Context("RespondWithStatusAndDataAndCursor", func() {
+					It("is successful", func() {
+						standardContext.RespondWithStatusAndDataAndCursor(200, testData, "cursor-cipher")
+						Expect(response.WriteHeaderInputs).To(ConsistOf(200))
+						Expect(response.WriteJSONInputs).To(ConsistOf(&context.JSONResponse{
+							Data: testData,
+							Meta: &context.Meta{
+								Trace: &context.Trace{
+									Request: "request-revenant",
+									Session: "session-spectre",
+								},
+								Pagination: &context.Pagination{
+									Next: "cursor-cipher",
+								},
+							},
+						}))
+					})
+
+					It("does not add pagination if cursor is empty", func() {
+						standardContext.RespondWithStatusAndDataAndCursor(200, testData, "")
+						Expect(response.WriteHeaderInputs).To(ConsistOf(200))
+						Expect(response.WriteJSONInputs).To(ConsistOf(&context.JSONResponse{
+							Data: testData,
+							Meta: &context.Meta{
+								Trace: &context.Trace{
+									Request: "request-revenant",
+									Session: "session-spectre",
+								},
+							},
+						}))
+					})
+				})
+			})
+
+			Context("with stream", func() {
+				var stream *TestStream
+
+				BeforeEach(func() {
+					stream = &TestStream{
+						NextOutputs:  []interface{}{map[string]string{"id": "one"}, map[string]string{"id": "two"}},
+						ErrOutputs:   []error{nil},
+						CloseOutputs: []error{nil},
+					}
+				})
+
+				Context("RespondWithStatusAndStream", func() {
+					It("is successful", func() {
+						response.WriteOutputs = []error{nil, nil}
+						standardContext.RespondWithStatusAndStream(200, stream)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(200))
+						Expect(response.Header().Get("Content-Type")).To(Equal("application/x-ndjson"))
+						Expect(response.Header().Get("Date")).ToNot(BeEmpty())
+						Expect(response.Header().Get("Trailer")).To(Equal("X-Tidepool-Stream-Count"))
+						Expect(response.Header().Get("X-Tidepool-Stream-Count")).To(Equal("2"))
+						Expect(response.WriteInputs).To(Equal([][]byte{[]byte("{\"id\":\"one\"}\n"), []byte("{\"id\":\"two\"}\n")}))
+						Expect(response.WriteJSONInputs).To(BeEmpty())
+						Expect(response.FlushInvocations).To(Equal(1))
+						Expect(stream.CloseInvocations).To(Equal(1))
+					})
+
+					It("is successful with an empty stream", func() {
+						stream.NextOutputs = nil
+						standardContext.RespondWithStatusAndStream(200, stream)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(200))
+						Expect(response.Header().Get("Content-Type")).To(Equal("application/x-ndjson"))
+						Expect(response.Header().Get("X-Tidepool-Stream-Count")).To(Equal("0"))
+						Expect(response.WriteInputs).To(BeEmpty())
+						Expect(stream.CloseInvocations).To(Equal(1))
+					})
+
+					It("responds with internal server failure if stream is missing", func() {
+						response.WriteJSONOutputs = []error{nil}
+						standardContext.RespondWithStatusAndStream(200, nil)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(500))
+						Expect(response.WriteInputs).To(BeEmpty())
+					})
+
+					It("responds with internal server failure if response does not support streaming", func() {
+						response.WriteJSONOutputs = []error{nil}
+						standardContext, err := context.NewStandard(&TestRestResponseWriter{response}, request)
+						Expect(err).ToNot(HaveOccurred())
+						standardContext.RespondWithStatusAndStream(200, stream)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(500))
+						Expect(response.WriteInputs).To(BeEmpty())
+						Expect(stream.CloseInvocations).To(Equal(1))
+					})
+
+					It("responds with internal server failure if stream returns an error before any result", func() {
+						response.WriteJSONOutputs = []error{nil}
+						stream.NextOutputs = nil
+						stream.ErrOutputs = []error{errors.New("test-error")}
+						standardContext.RespondWithStatusAndStream(200, stream)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(500))
+						Expect(response.WriteInputs).To(BeEmpty())
+						Expect(stream.CloseInvocations).To(Equal(1))
+					})
+
+					It("stops the stream if stream returns an error after results", func() {
+						response.WriteOutputs = []error{nil, nil}
+						stream.ErrOutputs = []error{errors.New("test-error")}
+						standardContext.RespondWithStatusAndStream(200, stream)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(200))
+						Expect(response.WriteInputs).To(HaveLen(2))
+						Expect(response.WriteJSONInputs).To(BeEmpty())
+						Expect(response.Header().Get("X-Tidepool-Stream-Count")).To(BeEmpty())
+						Expect(stream.CloseInvocations).To(Equal(1))
+					})
+
+					It("stops the stream if write returns an error", func() {
+						response.WriteOutputs = []error{errors.New("test-error")}
+						standardContext.RespondWithStatusAndStream(200, stream)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(200))
+						Expect(response.WriteInputs).To(HaveLen(1))
+						Expect(stream.ErrOutputs).To(HaveLen(1))
+						Expect(response.Header().Get("X-Tidepool-Stream-Count")).To(BeEmpty())
+						Expect(stream.CloseInvocations).To(Equal(1))
+					})
+
+					It("stops the stream if the client disconnects", func() {
+						cancelContext, cancel := netContext.WithCancel(request.Context())
+						cancel()
+						request.Request = request.Request.WithContext(cancelContext)
+						standardContext.RespondWithStatusAndStream(200, stream)
+						Expect(response.WriteHeaderInputs).To(BeEmpty())
+						Expect(response.WriteInputs).To(BeEmpty())
+						Expect(stream.CloseInvocations).To(Equal(1))
+					})
+				})
+
+				Context("RespondWithStatusAndAttachment", func() {
+					var attachment *TestAttachment
+
+					BeforeEach(func() {
+						attachment = NewTestAttachment("test-content")
+						attachment.CloseOutputs = []error{nil}
+					})
+
+					It("is successful", func() {
+						response.WriteOutputs = []error{nil}
+						standardContext.RespondWithStatusAndAttachment(200, "application/zip", "test.zip", attachment)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(200))
+						Expect(response.Header().Get("Content-Type")).To(Equal("application/zip"))
+						Expect(response.Header().Get("Content-Disposition")).To(Equal("attachment; filename=test.zip"))
+						Expect(response.Header().Get("Date")).ToNot(BeEmpty())
+						Expect(response.WriteInputs).To(Equal([][]byte{[]byte("test-content")}))
+						Expect(response.WriteJSONInputs).To(BeEmpty())
+						Expect(attachment.CloseInvocations).To(Equal(1))
+					})
+
+					It("responds with internal server failure if attachment is missing", func() {
+						response.WriteJSONOutputs = []error{nil}
+						standardContext.RespondWithStatusAndAttachment(200, "application/zip", "test.zip", nil)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(500))
+						Expect(response.WriteInputs).To(BeEmpty())
+					})
+
+					It("responds with internal server failure if response does not support attachments", func() {
+						response.WriteJSONOutputs = []error{nil}
+						standardContext, err := context.NewStandard(&TestRestResponseWriter{response}, request)
+						Expect(err).ToNot(HaveOccurred())
+						standardContext.RespondWithStatusAndAttachment(200, "application/zip", "test.zip", attachment)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(500))
+						Expect(response.WriteInputs).To(BeEmpty())
+						Expect(attachment.CloseInvocations).To(Equal(1))
+					})
+
+					It("stops the attachment if write returns an error", func() {
+						response.WriteOutputs = []error{errors.New("test-error")}
+						standardContext.RespondWithStatusAndAttachment(200, "application/zip", "test.zip", attachment)
+						Expect(response.WriteHeaderInputs).To(ConsistOf(200))
+						Expect(response.WriteInputs).To(HaveLen(1))
+						Expect(attachment.CloseInvocations).To(Equal(1))
+					})
+				})
+			})
 		})
 	})
 })
@@ -3,6 +3,7 @@ package service
 type Source struct {
 	Parameter string `json:"parameter,omitempty"`
 	Pointer   string `json:"pointer,omitempty"`
+	Header    string `json:"header,omitempty"`
 }
 
 type Error struct {
@@ -30,6 +31,14 @@ func (e *Error) WithSourcePointer(pointer string) *Error {
 	return e
 }
 
+func (e *Error) WithSourceHeader(header string) *Error {
+	if e.Source == nil {
+		e.Source = &Source{}
+	}
+	e.Source.Header = header
+	return e
+}
+
 func (e *Error) WithMeta(meta interface{}) *Error {
 	e.Meta = meta
 	return e
@@ -21,10 +21,12 @@ var _ = Describe("Error", func() {
 				source := &service.Source{
 					Parameter: "test-parameter",
 					Pointer:   "test-pointer",
+					Header:    "test-header",
 				}
 				Expect(json.Marshal(source)).To(MatchJSON(`{
 					"parameter": "test-parameter",
-					"pointer": "test-pointer"
+					"pointer": "test-pointer",
+					"header": "test-header"
 				}`))
 			})
 		})
@@ -135,6 +137,27 @@ var _ = Describe("Error", func() {
 				})
 			})
 
+			Context("WithSourceHeader", func() {
+				It("sets the header", func() {
+					err.WithSourceHeader("new-test-header")
+					Expect(err.Source).ToNot(BeNil())
+					Expect(err.Source.Header).To(Equal("new-test-header"))
+				})
+
+				It("sets an empty header", func() {
+					err.WithSourceHeader("")
+					Expect(err.Source).ToNot(BeNil())
+					Expect(err.Source.Header).To(Equal(""))
+				})
+
+				It("sets the header even if source is initially missing", func() {
+					err.Source = nil
+					err.WithSourceHeader("new-test-header")
+					Expect(err.Source).ToNot(BeNil())
+					Expect(err.Source.Header).To(Equal("new-test-header"))
+				})
+			})
+
 			Context("WithMeta", func() {
 				It("sets the meta", func() {
 					err.WithMeta("new-test-meta")
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/ant0ine/go-json-rest/rest"
+)
+
+const (
+	MediaTypeNDJSON    = "application/x-ndjson"
+	StreamCountTrailer = "X-Tidepool-Stream-Count"
+)
+
+type Stream interface {
+	Next(result interface{}) bool
+	Err() error
+	Close() error
+}
+
+func IsStreamRequested(request *rest.Request) bool {
+	if request == nil {
+		return false
+	}
+
+	for _, accept := range strings.Split(request.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == MediaTypeNDJSON {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package service_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"net/http"
+
+	"github.com/ant0ine/go-json-rest/rest"
+
+	"github.com/tidepool-org/platform/service"
+)
+
+var _ = Describe("Stream", func() {
+	Context("IsStreamRequested", func() {
+		var request *rest.Request
+
+		BeforeEach(func() {
+			request = &rest.Request{
+				Request: &http.Request{
+					Header: http.Header{},
+				},
+			}
+		})
+
+		It("returns false if request is missing", func() {
+			Expect(service.IsStreamRequested(nil)).To(BeFalse())
+		})
+
+		It("returns false if accept header is missing", func() {
+			Expect(service.IsStreamRequested(request)).To(BeFalse())
+		})
+
+		It("returns false if accept header is json", func() {
+			request.Header.Set("Accept", "application/json")
+			Expect(service.IsStreamRequested(request)).To(BeFalse())
+		})
+
+		It("returns true if accept header is ndjson", func() {
+			request.Header.Set("Accept", "application/x-ndjson")
+			Expect(service.IsStreamRequested(request)).To(BeTrue())
+		})
+
+		It("returns true if accept header includes ndjson with parameters", func() {
+			request.Header.Set("Accept", "application/json, application/x-ndjson; q=0.9")
+			Expect(service.IsStreamRequested(request)).To(BeTrue())
+		})
+	})
+})
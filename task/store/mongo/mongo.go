@@ -3,8 +3,10 @@ package mongo
 import (
 	"time"
 
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 
+	"github.com/tidepool-org/platform/app"
 	"github.com/tidepool-org/platform/errors"
 	"github.com/tidepool-org/platform/log"
 	"github.com/tidepool-org/platform/store/mongo"
@@ -36,6 +38,454 @@ type Session struct {
 	*mongo.Session
 }
 
+func (s *Session) CreateTask(task *store.Task) error {
+	if task == nil {
+		return errors.New("mongo", "task is missing")
+	}
+	if task.Type == "" {
+		return errors.New("mongo", "task type is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+
+	task.ID = app.NewID()
+	task.State = store.TaskStatePending
+	task.Attempts = 0
+	if task.MaximumAttempts <= 0 {
+		task.MaximumAttempts = store.TaskMaximumAttemptsDefault
+	}
+	if task.AvailableTime == "" {
+		task.AvailableTime = timestamp
+	}
+	task.CreatedTime = timestamp
+	task.CreatedUserID = s.AgentUserID()
+
+	err := s.C().Insert(task)
+
+	loggerFields := log.Fields{"taskId": task.ID, "taskType": task.Type, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("CreateTask")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to create task")
+	}
+	return nil
+}
+
+func (s *Session) GetTaskByID(taskID string) (*store.Task, error) {
+	if taskID == "" {
+		return nil, errors.New("mongo", "task id is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	tasks := []*store.Task{}
+	err := s.C().Find(bson.M{"id": taskID}).Limit(1).All(&tasks)
+
+	loggerFields := log.Fields{"taskId": taskID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetTaskByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get task by id")
+	}
+
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	return tasks[0], nil
+}
+
+func (s *Session) GetTaskForDatasetByID(datasetID string, taskType string) (*store.Task, error) {
+	if datasetID == "" {
+		return nil, errors.New("mongo", "dataset id is missing")
+	}
+	if taskType == "" {
+		return nil, errors.New("mongo", "task type is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	tasks := []*store.Task{}
+	query := bson.M{
+		"_datasetId": datasetID,
+		"type":       taskType,
+	}
+	err := s.C().Find(query).Sort("-createdTime", "-_id").Limit(1).All(&tasks)
+
+	loggerFields := log.Fields{"datasetId": datasetID, "taskType": taskType, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetTaskForDatasetByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get task for dataset by id")
+	}
+
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	return tasks[0], nil
+}
+
+func (s *Session) GetTaskForUserByID(userID string, taskType string) (*store.Task, error) {
+	if userID == "" {
+		return nil, errors.New("mongo", "user id is missing")
+	}
+	if taskType == "" {
+		return nil, errors.New("mongo", "task type is missing")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	tasks := []*store.Task{}
+	query := bson.M{
+		"_userId": userID,
+		"type":    taskType,
+	}
+	err := s.C().Find(query).Sort("-createdTime", "-_id").Limit(1).All(&tasks)
+
+	loggerFields := log.Fields{"userId": userID, "taskType": taskType, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("GetTaskForUserByID")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to get task for user by id")
+	}
+
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	return tasks[0], nil
+}
+
+func (s *Session) ClaimTask(taskType string, leaseDuration time.Duration) (*store.Task, error) {
+	if taskType == "" {
+		return nil, errors.New("mongo", "task type is missing")
+	}
+	if leaseDuration <= 0 {
+		return nil, errors.New("mongo", "lease duration is invalid")
+	}
+
+	if s.IsClosed() {
+		return nil, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+
+	query := bson.M{
+		"type": taskType,
+		"$or": []bson.M{
+			{"state": store.TaskStatePending, "availableTime": bson.M{"$lte": timestamp}},
+			{"state": store.TaskStateRunning, "leaseExpirationTime": bson.M{"$lte": timestamp}},
+		},
+	}
+	change := mgo.Change{
+		Update: bson.M{
+			"$set": bson.M{
+				"state":               store.TaskStateRunning,
+				"leaseId":             app.NewID(),
+				"leaseExpirationTime": s.timestampAfter(leaseDuration),
+				"modifiedTime":        timestamp,
+			},
+			"$inc": bson.M{
+				"attempts": 1,
+			},
+		},
+		ReturnNew: true,
+	}
+
+	task := &store.Task{}
+	_, err := s.C().Find(query).Sort("availableTime", "_id").Apply(change, task)
+	if err == mgo.ErrNotFound {
+		task = nil
+		err = nil
+	}
+
+	loggerFields := log.Fields{"taskType": taskType, "claimed": task != nil, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("ClaimTask")
+
+	if err != nil {
+		return nil, errors.Wrap(err, "mongo", "unable to claim task")
+	}
+	return task, nil
+}
+
+func (s *Session) HeartbeatTask(task *store.Task, leaseDuration time.Duration) (bool, error) {
+	if err := s.validateClaimedTask(task); err != nil {
+		return false, err
+	}
+	if leaseDuration <= 0 {
+		return false, errors.New("mongo", "lease duration is invalid")
+	}
+
+	if s.IsClosed() {
+		return false, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+	leaseExpirationTime := s.timestampAfter(leaseDuration)
+
+	set := bson.M{
+		"leaseExpirationTime": leaseExpirationTime,
+		"modifiedTime":        timestamp,
+	}
+	err := s.C().Update(s.claimedTaskSelector(task), s.constructUpdate(set, nil))
+
+	loggerFields := log.Fields{"taskId": task.ID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("HeartbeatTask")
+
+	if err == mgo.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "mongo", "unable to heartbeat task")
+	}
+
+	task.LeaseExpirationTime = leaseExpirationTime
+	task.ModifiedTime = timestamp
+	return true, nil
+}
+
+func (s *Session) CompleteTask(task *store.Task) error {
+	if err := s.validateClaimedTask(task); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+
+	set := bson.M{
+		"state":         store.TaskStateCompleted,
+		"modifiedTime":  timestamp,
+		"completedTime": timestamp,
+	}
+	unset := bson.M{
+		"leaseId":             true,
+		"leaseExpirationTime": true,
+		"error":               true,
+	}
+	err := s.C().Update(s.claimedTaskSelector(task), s.constructUpdate(set, unset))
+
+	loggerFields := log.Fields{"taskId": task.ID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("CompleteTask")
+
+	if err == mgo.ErrNotFound {
+		return errors.New("mongo", "task lease is lost")
+	} else if err != nil {
+		return errors.Wrap(err, "mongo", "unable to complete task")
+	}
+
+	task.State = store.TaskStateCompleted
+	task.LeaseID = ""
+	task.LeaseExpirationTime = ""
+	task.Error = ""
+	task.ModifiedTime = timestamp
+	task.CompletedTime = timestamp
+	return nil
+}
+
+func (s *Session) UpdateTaskSteps(task *store.Task) error {
+	if err := s.validateClaimedTask(task); err != nil {
+		return err
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+
+	set := bson.M{
+		"steps":        task.Steps,
+		"modifiedTime": timestamp,
+	}
+	err := s.C().Update(s.claimedTaskSelector(task), s.constructUpdate(set, nil))
+
+	loggerFields := log.Fields{"taskId": task.ID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("UpdateTaskSteps")
+
+	if err == mgo.ErrNotFound {
+		return errors.New("mongo", "task lease is lost")
+	} else if err != nil {
+		return errors.Wrap(err, "mongo", "unable to update task steps")
+	}
+
+	task.ModifiedTime = timestamp
+	return nil
+}
+
+func (s *Session) FailTask(task *store.Task, failure error) error {
+	if err := s.validateClaimedTask(task); err != nil {
+		return err
+	}
+	if failure == nil {
+		return errors.New("mongo", "failure is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+
+	set := bson.M{
+		"error":        failure.Error(),
+		"modifiedTime": timestamp,
+	}
+	unset := bson.M{
+		"leaseId":             true,
+		"leaseExpirationTime": true,
+	}
+	if task.Attempts >= task.MaximumAttempts {
+		set["state"] = store.TaskStateFailed
+		set["completedTime"] = timestamp
+	} else {
+		set["state"] = store.TaskStatePending
+		set["availableTime"] = s.timestampAfter(store.TaskBackoff(task.Attempts))
+	}
+	err := s.C().Update(s.claimedTaskSelector(task), s.constructUpdate(set, unset))
+
+	loggerFields := log.Fields{"taskId": task.ID, "attempts": task.Attempts, "state": set["state"], "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("FailTask")
+
+	if err == mgo.ErrNotFound {
+		return errors.New("mongo", "task lease is lost")
+	} else if err != nil {
+		return errors.Wrap(err, "mongo", "unable to fail task")
+	}
+
+	task.State = set["state"].(string)
+	if availableTime, ok := set["availableTime"].(string); ok {
+		task.AvailableTime = availableTime
+	}
+	if completedTime, ok := set["completedTime"].(string); ok {
+		task.CompletedTime = completedTime
+	}
+	task.LeaseID = ""
+	task.LeaseExpirationTime = ""
+	task.Error = failure.Error()
+	task.ModifiedTime = timestamp
+	return nil
+}
+
+func (s *Session) ResumeTask(task *store.Task) error {
+	if task == nil {
+		return errors.New("mongo", "task is missing")
+	}
+	if task.ID == "" {
+		return errors.New("mongo", "task id is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+
+	selector := bson.M{
+		"id":    task.ID,
+		"state": store.TaskStateFailed,
+	}
+	set := bson.M{
+		"state":         store.TaskStatePending,
+		"attempts":      0,
+		"availableTime": timestamp,
+		"modifiedTime":  timestamp,
+	}
+	unset := bson.M{
+		"error":         true,
+		"completedTime": true,
+	}
+	err := s.C().Update(selector, s.constructUpdate(set, unset))
+
+	loggerFields := log.Fields{"taskId": task.ID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("ResumeTask")
+
+	if err == mgo.ErrNotFound {
+		return errors.New("mongo", "task is not failed")
+	} else if err != nil {
+		return errors.Wrap(err, "mongo", "unable to resume task")
+	}
+
+	task.State = store.TaskStatePending
+	task.Attempts = 0
+	task.AvailableTime = timestamp
+	task.Error = ""
+	task.CompletedTime = ""
+	task.ModifiedTime = timestamp
+	return nil
+}
+
+func (s *Session) CancelTask(task *store.Task) (bool, error) {
+	if task == nil {
+		return false, errors.New("mongo", "task is missing")
+	}
+	if task.ID == "" {
+		return false, errors.New("mongo", "task id is missing")
+	}
+
+	if s.IsClosed() {
+		return false, errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	timestamp := s.Timestamp()
+
+	selector := bson.M{
+		"id":    task.ID,
+		"state": store.TaskStatePending,
+	}
+	set := bson.M{
+		"state":         store.TaskStateCanceled,
+		"completedTime": timestamp,
+		"modifiedTime":  timestamp,
+	}
+	err := s.C().Update(selector, s.constructUpdate(set, nil))
+
+	loggerFields := log.Fields{"taskId": task.ID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("CancelTask")
+
+	if err == mgo.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "mongo", "unable to cancel task")
+	}
+
+	task.State = store.TaskStateCanceled
+	task.CompletedTime = timestamp
+	task.ModifiedTime = timestamp
+	return true, nil
+}
+
 func (s *Session) DestroyTasksForUserByID(userID string) error {
 	if userID == "" {
 		return errors.New("mongo", "user id is missing")
@@ -61,3 +511,71 @@ func (s *Session) DestroyTasksForUserByID(userID string) error {
 
 	return nil
 }
+
+// Unlike DestroyTasksForUserByID, the task with the task id is kept, so a task may destroy the other tasks of its user
+func (s *Session) DestroyOtherTasksForUserByID(userID string, taskID string) error {
+	if userID == "" {
+		return errors.New("mongo", "user id is missing")
+	}
+	if taskID == "" {
+		return errors.New("mongo", "task id is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	selector := bson.M{
+		"_userId": userID,
+		"id":      bson.M{"$ne": taskID},
+	}
+	removeInfo, err := s.C().RemoveAll(selector)
+
+	loggerFields := log.Fields{"userId": userID, "taskId": taskID, "removeInfo": removeInfo, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("DestroyOtherTasksForUserByID")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to destroy other tasks for user by id")
+	}
+
+	return nil
+}
+
+func (s *Session) validateClaimedTask(task *store.Task) error {
+	if task == nil {
+		return errors.New("mongo", "task is missing")
+	}
+	if task.ID == "" {
+		return errors.New("mongo", "task id is missing")
+	}
+	if task.LeaseID == "" {
+		return errors.New("mongo", "task lease id is missing")
+	}
+
+	return nil
+}
+
+func (s *Session) claimedTaskSelector(task *store.Task) bson.M {
+	return bson.M{
+		"id":      task.ID,
+		"leaseId": task.LeaseID,
+		"state":   store.TaskStateRunning,
+	}
+}
+
+func (s *Session) timestampAfter(duration time.Duration) string {
+	return time.Now().Add(duration).UTC().Format(time.RFC3339)
+}
+
+func (s *Session) constructUpdate(set bson.M, unset bson.M) bson.M {
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"errors"
 	"time"
 
 	mgo "gopkg.in/mgo.v2"
@@ -136,6 +137,382 @@ var _ = Describe("Mongo", func() {
 					}
 				})
 
+				Context("CreateTask", func() {
+					It("succeeds if it successfully creates the task", func() {
+						task := store.NewTask("test-type")
+						task.DatasetID = app.NewID()
+						Expect(mongoSession.CreateTask(task)).To(Succeed())
+						Expect(task.ID).ToNot(BeEmpty())
+						Expect(task.State).To(Equal(store.TaskStatePending))
+						Expect(task.AvailableTime).ToNot(BeEmpty())
+						Expect(task.CreatedTime).ToNot(BeEmpty())
+						Expect(mongoSession.GetTaskByID(task.ID)).To(Equal(task))
+					})
+
+					It("keeps the available time if already set", func() {
+						task := store.NewTask("test-type")
+						task.AvailableTime = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+						availableTime := task.AvailableTime
+						Expect(mongoSession.CreateTask(task)).To(Succeed())
+						Expect(task.AvailableTime).To(Equal(availableTime))
+						Expect(mongoSession.ClaimTask("test-type", time.Minute)).To(BeNil())
+					})
+
+					It("returns an error if the task is missing", func() {
+						Expect(mongoSession.CreateTask(nil)).To(MatchError("mongo: task is missing"))
+					})
+
+					It("returns an error if the task type is missing", func() {
+						Expect(mongoSession.CreateTask(store.NewTask(""))).To(MatchError("mongo: task type is missing"))
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						Expect(mongoSession.CreateTask(store.NewTask("test-type"))).To(MatchError("mongo: session closed"))
+					})
+				})
+
+				Context("CancelTask", func() {
+					var task *store.Task
+
+					BeforeEach(func() {
+						task = store.NewTask("test-type")
+						Expect(mongoSession.CreateTask(task)).To(Succeed())
+					})
+
+					It("cancels a pending task", func() {
+						Expect(mongoSession.CancelTask(task)).To(BeTrue())
+						Expect(task.State).To(Equal(store.TaskStateCanceled))
+						Expect(task.CompletedTime).ToNot(BeEmpty())
+						Expect(mongoSession.GetTaskByID(task.ID)).To(Equal(task))
+						Expect(mongoSession.ClaimTask("test-type", time.Minute)).To(BeNil())
+					})
+
+					It("does not cancel the task if the task is not pending", func() {
+						claimedTask, err := mongoSession.ClaimTask("test-type", time.Minute)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(claimedTask).ToNot(BeNil())
+						Expect(mongoSession.CancelTask(task)).To(BeFalse())
+						Expect(task.State).To(Equal(store.TaskStatePending))
+						Expect(mongoSession.GetTaskByID(task.ID)).To(Equal(claimedTask))
+					})
+
+					It("returns an error if the task is missing", func() {
+						canceled, err := mongoSession.CancelTask(nil)
+						Expect(err).To(MatchError("mongo: task is missing"))
+						Expect(canceled).To(BeFalse())
+					})
+
+					It("returns an error if the task id is missing", func() {
+						task.ID = ""
+						canceled, err := mongoSession.CancelTask(task)
+						Expect(err).To(MatchError("mongo: task id is missing"))
+						Expect(canceled).To(BeFalse())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						canceled, err := mongoSession.CancelTask(task)
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(canceled).To(BeFalse())
+					})
+				})
+
+				Context("GetTaskByID", func() {
+					It("returns nil if the task is not found", func() {
+						Expect(mongoSession.GetTaskByID(app.NewID())).To(BeNil())
+					})
+
+					It("returns an error if the task id is missing", func() {
+						task, err := mongoSession.GetTaskByID("")
+						Expect(err).To(MatchError("mongo: task id is missing"))
+						Expect(task).To(BeNil())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						task, err := mongoSession.GetTaskByID(app.NewID())
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(task).To(BeNil())
+					})
+				})
+
+				Context("GetTaskForDatasetByID", func() {
+					var datasetID string
+
+					BeforeEach(func() {
+						datasetID = app.NewID()
+					})
+
+					It("returns the task for the dataset", func() {
+						task := store.NewTask("test-type")
+						task.DatasetID = datasetID
+						Expect(mongoSession.CreateTask(task)).To(Succeed())
+						Expect(mongoSession.GetTaskForDatasetByID(datasetID, "test-type")).To(Equal(task))
+					})
+
+					It("returns nil if there is no task for the dataset and type", func() {
+						task := store.NewTask("test-type")
+						task.DatasetID = datasetID
+						Expect(mongoSession.CreateTask(task)).To(Succeed())
+						Expect(mongoSession.GetTaskForDatasetByID(datasetID, "other-type")).To(BeNil())
+					})
+
+					It("returns an error if the dataset id is missing", func() {
+						task, err := mongoSession.GetTaskForDatasetByID("", "test-type")
+						Expect(err).To(MatchError("mongo: dataset id is missing"))
+						Expect(task).To(BeNil())
+					})
+
+					It("returns an error if the task type is missing", func() {
+						task, err := mongoSession.GetTaskForDatasetByID(datasetID, "")
+						Expect(err).To(MatchError("mongo: task type is missing"))
+						Expect(task).To(BeNil())
+					})
+				})
+
+				Context("GetTaskForUserByID", func() {
+					var userID string
+
+					BeforeEach(func() {
+						userID = app.NewID()
+					})
+
+					It("returns the task for the user", func() {
+						task := store.NewTask("test-type")
+						task.UserID = userID
+						Expect(mongoSession.CreateTask(task)).To(Succeed())
+						Expect(mongoSession.GetTaskForUserByID(userID, "test-type")).To(Equal(task))
+					})
+
+					It("returns nil if there is no task for the user and type", func() {
+						task := store.NewTask("test-type")
+						task.UserID = userID
+						Expect(mongoSession.CreateTask(task)).To(Succeed())
+						Expect(mongoSession.GetTaskForUserByID(userID, "other-type")).To(BeNil())
+					})
+
+					It("returns an error if the user id is missing", func() {
+						task, err := mongoSession.GetTaskForUserByID("", "test-type")
+						Expect(err).To(MatchError("mongo: user id is missing"))
+						Expect(task).To(BeNil())
+					})
+
+					It("returns an error if the task type is missing", func() {
+						task, err := mongoSession.GetTaskForUserByID(userID, "")
+						Expect(err).To(MatchError("mongo: task type is missing"))
+						Expect(task).To(BeNil())
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						task, err := mongoSession.GetTaskForUserByID(userID, "test-type")
+						Expect(err).To(MatchError("mongo: session closed"))
+						Expect(task).To(BeNil())
+					})
+				})
+
+				Context("with a created task", func() {
+					var task *store.Task
+
+					BeforeEach(func() {
+						task = store.NewTask("test-type")
+						task.MaximumAttempts = 2
+						Expect(mongoSession.CreateTask(task)).To(Succeed())
+					})
+
+					Context("ClaimTask", func() {
+						It("claims the available task", func() {
+							claimedTask, err := mongoSession.ClaimTask("test-type", time.Minute)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(claimedTask).ToNot(BeNil())
+							Expect(claimedTask.ID).To(Equal(task.ID))
+							Expect(claimedTask.State).To(Equal(store.TaskStateRunning))
+							Expect(claimedTask.Attempts).To(Equal(1))
+							Expect(claimedTask.LeaseID).ToNot(BeEmpty())
+							Expect(claimedTask.LeaseExpirationTime).ToNot(BeEmpty())
+						})
+
+						It("does not claim a task that is already claimed", func() {
+							Expect(mongoSession.ClaimTask("test-type", time.Minute)).ToNot(BeNil())
+							Expect(mongoSession.ClaimTask("test-type", time.Minute)).To(BeNil())
+						})
+
+						It("claims a task whose lease has expired", func() {
+							Expect(testMongoCollection.Update(bson.M{"id": task.ID}, bson.M{"$set": bson.M{"state": store.TaskStateRunning, "leaseExpirationTime": "2017-01-01T00:00:00Z"}})).To(Succeed())
+							claimedTask, err := mongoSession.ClaimTask("test-type", time.Minute)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(claimedTask).ToNot(BeNil())
+							Expect(claimedTask.ID).To(Equal(task.ID))
+						})
+
+						It("does not claim a task of another type", func() {
+							Expect(mongoSession.ClaimTask("other-type", time.Minute)).To(BeNil())
+						})
+
+						It("returns an error if the task type is missing", func() {
+							claimedTask, err := mongoSession.ClaimTask("", time.Minute)
+							Expect(err).To(MatchError("mongo: task type is missing"))
+							Expect(claimedTask).To(BeNil())
+						})
+
+						It("returns an error if the lease duration is invalid", func() {
+							claimedTask, err := mongoSession.ClaimTask("test-type", 0)
+							Expect(err).To(MatchError("mongo: lease duration is invalid"))
+							Expect(claimedTask).To(BeNil())
+						})
+
+						It("returns an error if the session is closed", func() {
+							mongoSession.Close()
+							claimedTask, err := mongoSession.ClaimTask("test-type", time.Minute)
+							Expect(err).To(MatchError("mongo: session closed"))
+							Expect(claimedTask).To(BeNil())
+						})
+					})
+
+					Context("with a claimed task", func() {
+						var claimedTask *store.Task
+
+						BeforeEach(func() {
+							var err error
+							claimedTask, err = mongoSession.ClaimTask("test-type", time.Minute)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(claimedTask).ToNot(BeNil())
+						})
+
+						Context("HeartbeatTask", func() {
+							It("extends the lease", func() {
+								Expect(mongoSession.HeartbeatTask(claimedTask, time.Hour)).To(BeTrue())
+								storedTask, err := mongoSession.GetTaskByID(claimedTask.ID)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(storedTask.LeaseExpirationTime).To(Equal(claimedTask.LeaseExpirationTime))
+							})
+
+							It("returns false if the lease is lost", func() {
+								claimedTask.LeaseID = app.NewID()
+								Expect(mongoSession.HeartbeatTask(claimedTask, time.Hour)).To(BeFalse())
+							})
+
+							It("returns an error if the task lease id is missing", func() {
+								claimedTask.LeaseID = ""
+								leased, err := mongoSession.HeartbeatTask(claimedTask, time.Hour)
+								Expect(err).To(MatchError("mongo: task lease id is missing"))
+								Expect(leased).To(BeFalse())
+							})
+						})
+
+						Context("CompleteTask", func() {
+							It("completes the task", func() {
+								Expect(mongoSession.CompleteTask(claimedTask)).To(Succeed())
+								storedTask, err := mongoSession.GetTaskByID(claimedTask.ID)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(storedTask.State).To(Equal(store.TaskStateCompleted))
+								Expect(storedTask.CompletedTime).ToNot(BeEmpty())
+								Expect(storedTask.LeaseID).To(BeEmpty())
+							})
+
+							It("returns an error if the task is missing", func() {
+								Expect(mongoSession.CompleteTask(nil)).To(MatchError("mongo: task is missing"))
+							})
+
+							It("returns an error if the lease is lost", func() {
+								claimedTask.LeaseID = app.NewID()
+								Expect(mongoSession.CompleteTask(claimedTask)).To(MatchError("mongo: task lease is lost"))
+							})
+						})
+
+						Context("UpdateTaskSteps", func() {
+							It("updates the steps", func() {
+								claimedTask.Steps = []*store.TaskStep{store.NewTaskStep("first"), store.NewTaskStep("second")}
+								claimedTask.Steps[0].State = store.TaskStateCompleted
+								claimedTask.Steps[0].Attempts = 1
+								Expect(mongoSession.UpdateTaskSteps(claimedTask)).To(Succeed())
+								storedTask, err := mongoSession.GetTaskByID(claimedTask.ID)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(storedTask.Steps).To(Equal(claimedTask.Steps))
+							})
+
+							It("returns an error if the task is missing", func() {
+								Expect(mongoSession.UpdateTaskSteps(nil)).To(MatchError("mongo: task is missing"))
+							})
+
+							It("returns an error if the lease is lost", func() {
+								claimedTask.LeaseID = app.NewID()
+								Expect(mongoSession.UpdateTaskSteps(claimedTask)).To(MatchError("mongo: task lease is lost"))
+							})
+						})
+
+						Context("ResumeTask", func() {
+							It("resumes a failed task with the steps", func() {
+								claimedTask.Steps = []*store.TaskStep{store.NewTaskStep("first")}
+								claimedTask.Steps[0].State = store.TaskStateCompleted
+								Expect(mongoSession.UpdateTaskSteps(claimedTask)).To(Succeed())
+								Expect(testMongoCollection.Update(bson.M{"id": claimedTask.ID}, bson.M{"$set": bson.M{"attempts": 2}})).To(Succeed())
+								claimedTask.Attempts = 2
+								Expect(mongoSession.FailTask(claimedTask, errors.New("test error"))).To(Succeed())
+								Expect(mongoSession.ResumeTask(claimedTask)).To(Succeed())
+								storedTask, err := mongoSession.GetTaskByID(claimedTask.ID)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(storedTask.State).To(Equal(store.TaskStatePending))
+								Expect(storedTask.Attempts).To(Equal(0))
+								Expect(storedTask.Error).To(BeEmpty())
+								Expect(storedTask.CompletedTime).To(BeEmpty())
+								Expect(storedTask.Steps).To(Equal(claimedTask.Steps))
+								Expect(mongoSession.ClaimTask("test-type", time.Minute)).ToNot(BeNil())
+							})
+
+							It("returns an error if the task is not failed", func() {
+								Expect(mongoSession.ResumeTask(claimedTask)).To(MatchError("mongo: task is not failed"))
+							})
+
+							It("returns an error if the task is missing", func() {
+								Expect(mongoSession.ResumeTask(nil)).To(MatchError("mongo: task is missing"))
+							})
+
+							It("returns an error if the task id is missing", func() {
+								claimedTask.ID = ""
+								Expect(mongoSession.ResumeTask(claimedTask)).To(MatchError("mongo: task id is missing"))
+							})
+
+							It("returns an error if the session is closed", func() {
+								mongoSession.Close()
+								Expect(mongoSession.ResumeTask(claimedTask)).To(MatchError("mongo: session closed"))
+							})
+						})
+
+						Context("FailTask", func() {
+							It("returns the task to pending with backoff if attempts remain", func() {
+								Expect(mongoSession.FailTask(claimedTask, errors.New("test error"))).To(Succeed())
+								storedTask, err := mongoSession.GetTaskByID(claimedTask.ID)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(storedTask.State).To(Equal(store.TaskStatePending))
+								Expect(storedTask.Error).To(Equal("test error"))
+								Expect(storedTask.AvailableTime > storedTask.ModifiedTime).To(BeTrue())
+								Expect(mongoSession.ClaimTask("test-type", time.Minute)).To(BeNil())
+							})
+
+							It("fails the task if no attempts remain", func() {
+								Expect(testMongoCollection.Update(bson.M{"id": claimedTask.ID}, bson.M{"$set": bson.M{"attempts": 2}})).To(Succeed())
+								claimedTask.Attempts = 2
+								Expect(mongoSession.FailTask(claimedTask, errors.New("test error"))).To(Succeed())
+								storedTask, err := mongoSession.GetTaskByID(claimedTask.ID)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(storedTask.State).To(Equal(store.TaskStateFailed))
+								Expect(storedTask.CompletedTime).ToNot(BeEmpty())
+							})
+
+							It("returns an error if the failure is missing", func() {
+								Expect(mongoSession.FailTask(claimedTask, nil)).To(MatchError("mongo: failure is missing"))
+							})
+
+							It("returns an error if the lease is lost", func() {
+								claimedTask.LeaseID = app.NewID()
+								Expect(mongoSession.FailTask(claimedTask, errors.New("test error"))).To(MatchError("mongo: task lease is lost"))
+							})
+						})
+					})
+				})
+
 				Context("DestroyTasksForUserByID", func() {
 					var destroyUserID string
 					var destroyTasks []interface{}
@@ -168,6 +545,46 @@ var _ = Describe("Mongo", func() {
 						ValidateTasks(testMongoCollection, bson.M{}, tasks)
 					})
 				})
+
+				Context("DestroyOtherTasksForUserByID", func() {
+					var destroyUserID string
+					var keepTask bson.M
+					var destroyTasks []interface{}
+
+					BeforeEach(func() {
+						destroyUserID = app.NewID()
+						keepTask = NewTask(destroyUserID)
+						keepTask["id"] = app.NewID()
+						destroyTasks = NewTasks(destroyUserID)
+					})
+
+					JustBeforeEach(func() {
+						Expect(testMongoCollection.Insert(append(destroyTasks, keepTask)...)).To(Succeed())
+					})
+
+					It("succeeds if it successfully removes tasks", func() {
+						Expect(mongoSession.DestroyOtherTasksForUserByID(destroyUserID, keepTask["id"].(string))).To(Succeed())
+					})
+
+					It("returns an error if the user id is missing", func() {
+						Expect(mongoSession.DestroyOtherTasksForUserByID("", keepTask["id"].(string))).To(MatchError("mongo: user id is missing"))
+					})
+
+					It("returns an error if the task id is missing", func() {
+						Expect(mongoSession.DestroyOtherTasksForUserByID(destroyUserID, "")).To(MatchError("mongo: task id is missing"))
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						Expect(mongoSession.DestroyOtherTasksForUserByID(destroyUserID, keepTask["id"].(string))).To(MatchError("mongo: session closed"))
+					})
+
+					It("has the correct stored tasks", func() {
+						ValidateTasks(testMongoCollection, bson.M{}, append(append(tasks, destroyTasks...), keepTask))
+						Expect(mongoSession.DestroyOtherTasksForUserByID(destroyUserID, keepTask["id"].(string))).To(Succeed())
+						ValidateTasks(testMongoCollection, bson.M{}, append(tasks, keepTask))
+					})
+				})
 			})
 		})
 	})
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"time"
+
 	"github.com/tidepool-org/platform/log"
 	"github.com/tidepool-org/platform/store"
 )
@@ -14,5 +16,99 @@ type Store interface {
 type Session interface {
 	store.Session
 
+	CreateTask(task *Task) error
+	GetTaskByID(taskID string) (*Task, error)
+	GetTaskForDatasetByID(datasetID string, taskType string) (*Task, error)
+	GetTaskForUserByID(userID string, taskType string) (*Task, error)
+	ClaimTask(taskType string, leaseDuration time.Duration) (*Task, error)
+	HeartbeatTask(task *Task, leaseDuration time.Duration) (bool, error)
+	CompleteTask(task *Task) error
+	UpdateTaskSteps(task *Task) error
+	FailTask(task *Task, failure error) error
+	ResumeTask(task *Task) error
+	CancelTask(task *Task) (bool, error)
 	DestroyTasksForUserByID(userID string) error
+	DestroyOtherTasksForUserByID(userID string, taskID string) error
+}
+
+const (
+	TaskStatePending   = "pending"
+	TaskStateRunning   = "running"
+	TaskStateCompleted = "completed"
+	TaskStateFailed    = "failed"
+	TaskStateCanceled  = "canceled"
+
+	TaskMaximumAttemptsDefault = 5
+
+	TaskBackoffMinimum = 5 * time.Second
+	TaskBackoffMaximum = 10 * time.Minute
+)
+
+type Task struct {
+	ID                  string      `json:"id" bson:"id"`
+	Type                string      `json:"type" bson:"type"`
+	UserID              string      `json:"userId,omitempty" bson:"_userId,omitempty"`
+	DatasetID           string      `json:"datasetId,omitempty" bson:"_datasetId,omitempty"`
+	State               string      `json:"state" bson:"state"`
+	Attempts            int         `json:"attempts" bson:"attempts"`
+	MaximumAttempts     int         `json:"maximumAttempts" bson:"maximumAttempts"`
+	AvailableTime       string      `json:"availableTime,omitempty" bson:"availableTime,omitempty"`
+	LeaseID             string      `json:"-" bson:"leaseId,omitempty"`
+	LeaseExpirationTime string      `json:"leaseExpirationTime,omitempty" bson:"leaseExpirationTime,omitempty"`
+	Error               string      `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedTime         string      `json:"createdTime,omitempty" bson:"createdTime,omitempty"`
+	CreatedUserID       string      `json:"createdUserId,omitempty" bson:"createdUserId,omitempty"`
+	ModifiedTime        string      `json:"modifiedTime,omitempty" bson:"modifiedTime,omitempty"`
+	CompletedTime       string      `json:"completedTime,omitempty" bson:"completedTime,omitempty"`
+	Steps               []*TaskStep `json:"steps,omitempty" bson:"steps,omitempty"`
+}
+
+type TaskStep struct {
+	Name          string `json:"name" bson:"name"`
+	State         string `json:"state" bson:"state"`
+	Attempts      int    `json:"attempts" bson:"attempts"`
+	Error         string `json:"error,omitempty" bson:"error,omitempty"`
+	CompletedTime string `json:"completedTime,omitempty" bson:"completedTime,omitempty"`
+}
+
+func NewTask(taskType string) *Task {
+	return &Task{
+		Type:            taskType,
+		State:           TaskStatePending,
+		MaximumAttempts: TaskMaximumAttemptsDefault,
+	}
+}
+
+func (t *Task) IsActive() bool {
+	return t.State == TaskStatePending || t.State == TaskStateRunning
+}
+
+func (t *Task) Step(name string) *TaskStep {
+	for _, step := range t.Steps {
+		if step.Name == name {
+			return step
+		}
+	}
+	return nil
+}
+
+func NewTaskStep(name string) *TaskStep {
+	return &TaskStep{
+		Name:  name,
+		State: TaskStatePending,
+	}
+}
+
+func (t *TaskStep) IsCompleted() bool {
+	return t.State == TaskStateCompleted
+}
+
+func TaskBackoff(attempts int) time.Duration {
+	backoff := TaskBackoffMinimum
+	for attempt := 1; attempt < attempts; attempt++ {
+		if backoff *= 2; backoff >= TaskBackoffMaximum {
+			return TaskBackoffMaximum
+		}
+	}
+	return backoff
 }
@@ -0,0 +1,70 @@
+package store_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"time"
+
+	"github.com/tidepool-org/platform/task/store"
+)
+
+var _ = Describe("Store", func() {
+	Context("NewTask", func() {
+		It("returns a pending task with the specified type", func() {
+			task := store.NewTask("test-type")
+			Expect(task).ToNot(BeNil())
+			Expect(task.Type).To(Equal("test-type"))
+			Expect(task.State).To(Equal(store.TaskStatePending))
+			Expect(task.MaximumAttempts).To(Equal(store.TaskMaximumAttemptsDefault))
+		})
+	})
+
+	DescribeTable("IsActive",
+		func(state string, expected bool) {
+			task := store.NewTask("test-type")
+			task.State = state
+			Expect(task.IsActive()).To(Equal(expected))
+		},
+		Entry("is pending", store.TaskStatePending, true),
+		Entry("is running", store.TaskStateRunning, true),
+		Entry("is completed", store.TaskStateCompleted, false),
+		Entry("is failed", store.TaskStateFailed, false),
+	)
+
+	Context("Step", func() {
+		It("returns the step with the specified name", func() {
+			task := store.NewTask("test-type")
+			task.Steps = []*store.TaskStep{store.NewTaskStep("first"), store.NewTaskStep("second")}
+			Expect(task.Step("second")).To(Equal(task.Steps[1]))
+		})
+
+		It("returns nil if there is no step with the specified name", func() {
+			task := store.NewTask("test-type")
+			task.Steps = []*store.TaskStep{store.NewTaskStep("first")}
+			Expect(task.Step("other")).To(BeNil())
+		})
+	})
+
+	Context("NewTaskStep", func() {
+		It("returns a pending step with the specified name", func() {
+			step := store.NewTaskStep("test-step")
+			Expect(step).ToNot(BeNil())
+			Expect(step.Name).To(Equal("test-step"))
+			Expect(step.State).To(Equal(store.TaskStatePending))
+			Expect(step.IsCompleted()).To(BeFalse())
+		})
+	})
+
+	DescribeTable("TaskBackoff",
+		func(attempts int, expected time.Duration) {
+			Expect(store.TaskBackoff(attempts)).To(Equal(expected))
+		},
+		Entry("is zero attempts", 0, store.TaskBackoffMinimum),
+		Entry("is one attempt", 1, store.TaskBackoffMinimum),
+		Entry("is two attempts", 2, 2*store.TaskBackoffMinimum),
+		Entry("is three attempts", 3, 4*store.TaskBackoffMinimum),
+		Entry("is many attempts", 20, store.TaskBackoffMaximum),
+	)
+})
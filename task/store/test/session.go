@@ -0,0 +1,354 @@
+package test
+
+import (
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/log"
+	commonStore "github.com/tidepool-org/platform/store"
+	"github.com/tidepool-org/platform/task/store"
+)
+
+type GetTaskByIDOutput struct {
+	Task  *store.Task
+	Error error
+}
+
+type GetTaskForDatasetByIDInput struct {
+	DatasetID string
+	TaskType  string
+}
+
+type GetTaskForDatasetByIDOutput struct {
+	Task  *store.Task
+	Error error
+}
+
+type GetTaskForUserByIDInput struct {
+	UserID   string
+	TaskType string
+}
+
+type DestroyOtherTasksForUserByIDInput struct {
+	UserID string
+	TaskID string
+}
+
+type GetTaskForUserByIDOutput struct {
+	Task  *store.Task
+	Error error
+}
+
+type ClaimTaskInput struct {
+	TaskType      string
+	LeaseDuration time.Duration
+}
+
+type ClaimTaskOutput struct {
+	Task  *store.Task
+	Error error
+}
+
+type HeartbeatTaskInput struct {
+	Task          *store.Task
+	LeaseDuration time.Duration
+}
+
+type HeartbeatTaskOutput struct {
+	Leased bool
+	Error  error
+}
+
+type CancelTaskOutput struct {
+	Canceled bool
+	Error    error
+}
+
+type FailTaskInput struct {
+	Task    *store.Task
+	Failure error
+}
+
+type Session struct {
+	ID                                      string
+	IsClosedInvocations                     int
+	IsClosedOutputs                         []bool
+	CloseInvocations                        int
+	LoggerInvocations                       int
+	LoggerImpl                              log.Logger
+	SetAgentInvocations                     int
+	SetAgentInputs                          []commonStore.Agent
+	CreateTaskInvocations                   int
+	CreateTaskInputs                        []*store.Task
+	CreateTaskOutputs                       []error
+	GetTaskByIDInvocations                  int
+	GetTaskByIDInputs                       []string
+	GetTaskByIDOutputs                      []GetTaskByIDOutput
+	GetTaskForDatasetByIDInvocations        int
+	GetTaskForDatasetByIDInputs             []GetTaskForDatasetByIDInput
+	GetTaskForDatasetByIDOutputs            []GetTaskForDatasetByIDOutput
+	GetTaskForUserByIDInvocations           int
+	GetTaskForUserByIDInputs                []GetTaskForUserByIDInput
+	GetTaskForUserByIDOutputs               []GetTaskForUserByIDOutput
+	ClaimTaskInvocations                    int
+	ClaimTaskInputs                         []ClaimTaskInput
+	ClaimTaskOutputs                        []ClaimTaskOutput
+	HeartbeatTaskInvocations                int
+	HeartbeatTaskInputs                     []HeartbeatTaskInput
+	HeartbeatTaskOutputs                    []HeartbeatTaskOutput
+	CompleteTaskInvocations                 int
+	CompleteTaskInputs                      []*store.Task
+	CompleteTaskOutputs                     []error
+	UpdateTaskStepsInvocations              int
+	UpdateTaskStepsInputs                   []*store.Task
+	UpdateTaskStepsOutputs                  []error
+	FailTaskInvocations                     int
+	FailTaskInputs                          []FailTaskInput
+	FailTaskOutputs                         []error
+	ResumeTaskInvocations                   int
+	ResumeTaskInputs                        []*store.Task
+	ResumeTaskOutputs                       []error
+	CancelTaskInvocations                   int
+	CancelTaskInputs                        []*store.Task
+	CancelTaskOutputs                       []CancelTaskOutput
+	DestroyTasksForUserByIDInvocations      int
+	DestroyTasksForUserByIDInputs           []string
+	DestroyTasksForUserByIDOutputs          []error
+	DestroyOtherTasksForUserByIDInvocations int
+	DestroyOtherTasksForUserByIDInputs      []DestroyOtherTasksForUserByIDInput
+	DestroyOtherTasksForUserByIDOutputs     []error
+}
+
+func NewSession() *Session {
+	return &Session{
+		ID:         app.NewID(),
+		LoggerImpl: log.NewNull(),
+	}
+}
+
+func (s *Session) IsClosed() bool {
+	s.IsClosedInvocations++
+
+	if len(s.IsClosedOutputs) == 0 {
+		panic("Unexpected invocation of IsClosed on Session")
+	}
+
+	output := s.IsClosedOutputs[0]
+	s.IsClosedOutputs = s.IsClosedOutputs[1:]
+	return output
+}
+
+func (s *Session) Close() {
+	s.CloseInvocations++
+}
+
+func (s *Session) Logger() log.Logger {
+	s.LoggerInvocations++
+
+	return s.LoggerImpl
+}
+
+func (s *Session) SetAgent(agent commonStore.Agent) {
+	s.SetAgentInvocations++
+
+	s.SetAgentInputs = append(s.SetAgentInputs, agent)
+}
+
+func (s *Session) CreateTask(task *store.Task) error {
+	s.CreateTaskInvocations++
+
+	s.CreateTaskInputs = append(s.CreateTaskInputs, task)
+
+	if len(s.CreateTaskOutputs) == 0 {
+		panic("Unexpected invocation of CreateTask on Session")
+	}
+
+	output := s.CreateTaskOutputs[0]
+	s.CreateTaskOutputs = s.CreateTaskOutputs[1:]
+	return output
+}
+
+func (s *Session) GetTaskByID(taskID string) (*store.Task, error) {
+	s.GetTaskByIDInvocations++
+
+	s.GetTaskByIDInputs = append(s.GetTaskByIDInputs, taskID)
+
+	if len(s.GetTaskByIDOutputs) == 0 {
+		panic("Unexpected invocation of GetTaskByID on Session")
+	}
+
+	output := s.GetTaskByIDOutputs[0]
+	s.GetTaskByIDOutputs = s.GetTaskByIDOutputs[1:]
+	return output.Task, output.Error
+}
+
+func (s *Session) GetTaskForDatasetByID(datasetID string, taskType string) (*store.Task, error) {
+	s.GetTaskForDatasetByIDInvocations++
+
+	s.GetTaskForDatasetByIDInputs = append(s.GetTaskForDatasetByIDInputs, GetTaskForDatasetByIDInput{datasetID, taskType})
+
+	if len(s.GetTaskForDatasetByIDOutputs) == 0 {
+		panic("Unexpected invocation of GetTaskForDatasetByID on Session")
+	}
+
+	output := s.GetTaskForDatasetByIDOutputs[0]
+	s.GetTaskForDatasetByIDOutputs = s.GetTaskForDatasetByIDOutputs[1:]
+	return output.Task, output.Error
+}
+
+func (s *Session) GetTaskForUserByID(userID string, taskType string) (*store.Task, error) {
+	s.GetTaskForUserByIDInvocations++
+
+	s.GetTaskForUserByIDInputs = append(s.GetTaskForUserByIDInputs, GetTaskForUserByIDInput{userID, taskType})
+
+	if len(s.GetTaskForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of GetTaskForUserByID on Session")
+	}
+
+	output := s.GetTaskForUserByIDOutputs[0]
+	s.GetTaskForUserByIDOutputs = s.GetTaskForUserByIDOutputs[1:]
+	return output.Task, output.Error
+}
+
+func (s *Session) ClaimTask(taskType string, leaseDuration time.Duration) (*store.Task, error) {
+	s.ClaimTaskInvocations++
+
+	s.ClaimTaskInputs = append(s.ClaimTaskInputs, ClaimTaskInput{taskType, leaseDuration})
+
+	if len(s.ClaimTaskOutputs) == 0 {
+		panic("Unexpected invocation of ClaimTask on Session")
+	}
+
+	output := s.ClaimTaskOutputs[0]
+	s.ClaimTaskOutputs = s.ClaimTaskOutputs[1:]
+	return output.Task, output.Error
+}
+
+func (s *Session) HeartbeatTask(task *store.Task, leaseDuration time.Duration) (bool, error) {
+	s.HeartbeatTaskInvocations++
+
+	s.HeartbeatTaskInputs = append(s.HeartbeatTaskInputs, HeartbeatTaskInput{task, leaseDuration})
+
+	if len(s.HeartbeatTaskOutputs) == 0 {
+		panic("Unexpected invocation of HeartbeatTask on Session")
+	}
+
+	output := s.HeartbeatTaskOutputs[0]
+	s.HeartbeatTaskOutputs = s.HeartbeatTaskOutputs[1:]
+	return output.Leased, output.Error
+}
+
+func (s *Session) CompleteTask(task *store.Task) error {
+	s.CompleteTaskInvocations++
+
+	s.CompleteTaskInputs = append(s.CompleteTaskInputs, task)
+
+	if len(s.CompleteTaskOutputs) == 0 {
+		panic("Unexpected invocation of CompleteTask on Session")
+	}
+
+	output := s.CompleteTaskOutputs[0]
+	s.CompleteTaskOutputs = s.CompleteTaskOutputs[1:]
+	return output
+}
+
+func (s *Session) UpdateTaskSteps(task *store.Task) error {
+	s.UpdateTaskStepsInvocations++
+
+	s.UpdateTaskStepsInputs = append(s.UpdateTaskStepsInputs, task)
+
+	if len(s.UpdateTaskStepsOutputs) == 0 {
+		panic("Unexpected invocation of UpdateTaskSteps on Session")
+	}
+
+	output := s.UpdateTaskStepsOutputs[0]
+	s.UpdateTaskStepsOutputs = s.UpdateTaskStepsOutputs[1:]
+	return output
+}
+
+func (s *Session) FailTask(task *store.Task, failure error) error {
+	s.FailTaskInvocations++
+
+	s.FailTaskInputs = append(s.FailTaskInputs, FailTaskInput{task, failure})
+
+	if len(s.FailTaskOutputs) == 0 {
+		panic("Unexpected invocation of FailTask on Session")
+	}
+
+	output := s.FailTaskOutputs[0]
+	s.FailTaskOutputs = s.FailTaskOutputs[1:]
+	return output
+}
+
+func (s *Session) ResumeTask(task *store.Task) error {
+	s.ResumeTaskInvocations++
+
+	s.ResumeTaskInputs = append(s.ResumeTaskInputs, task)
+
+	if len(s.ResumeTaskOutputs) == 0 {
+		panic("Unexpected invocation of ResumeTask on Session")
+	}
+
+	output := s.ResumeTaskOutputs[0]
+	s.ResumeTaskOutputs = s.ResumeTaskOutputs[1:]
+	return output
+}
+
+func (s *Session) CancelTask(task *store.Task) (bool, error) {
+	s.CancelTaskInvocations++
+
+	s.CancelTaskInputs = append(s.CancelTaskInputs, task)
+
+	if len(s.CancelTaskOutputs) == 0 {
+		panic("Unexpected invocation of CancelTask on Session")
+	}
+
+	output := s.CancelTaskOutputs[0]
+	s.CancelTaskOutputs = s.CancelTaskOutputs[1:]
+	return output.Canceled, output.Error
+}
+
+func (s *Session) DestroyTasksForUserByID(userID string) error {
+	s.DestroyTasksForUserByIDInvocations++
+
+	s.DestroyTasksForUserByIDInputs = append(s.DestroyTasksForUserByIDInputs, userID)
+
+	if len(s.DestroyTasksForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of DestroyTasksForUserByID on Session")
+	}
+
+	output := s.DestroyTasksForUserByIDOutputs[0]
+	s.DestroyTasksForUserByIDOutputs = s.DestroyTasksForUserByIDOutputs[1:]
+	return output
+}
+
+func (s *Session) DestroyOtherTasksForUserByID(userID string, taskID string) error {
+	s.DestroyOtherTasksForUserByIDInvocations++
+
+	s.DestroyOtherTasksForUserByIDInputs = append(s.DestroyOtherTasksForUserByIDInputs, DestroyOtherTasksForUserByIDInput{UserID: userID, TaskID: taskID})
+
+	if len(s.DestroyOtherTasksForUserByIDOutputs) == 0 {
+		panic("Unexpected invocation of DestroyOtherTasksForUserByID on Session")
+	}
+
+	output := s.DestroyOtherTasksForUserByIDOutputs[0]
+	s.DestroyOtherTasksForUserByIDOutputs = s.DestroyOtherTasksForUserByIDOutputs[1:]
+	return output
+}
+
+func (s *Session) UnusedOutputsCount() int {
+	return len(s.IsClosedOutputs) +
+		len(s.CreateTaskOutputs) +
+		len(s.GetTaskByIDOutputs) +
+		len(s.GetTaskForDatasetByIDOutputs) +
+		len(s.GetTaskForUserByIDOutputs) +
+		len(s.ClaimTaskOutputs) +
+		len(s.HeartbeatTaskOutputs) +
+		len(s.CompleteTaskOutputs) +
+		len(s.UpdateTaskStepsOutputs) +
+		len(s.FailTaskOutputs) +
+		len(s.ResumeTaskOutputs) +
+		len(s.CancelTaskOutputs) +
+		len(s.DestroyTasksForUserByIDOutputs) +
+		len(s.DestroyOtherTasksForUserByIDOutputs)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data"
+	"github.com/tidepool-org/platform/data/deduplicator"
+	"github.com/tidepool-org/platform/data/factory"
+	"github.com/tidepool-org/platform/errors"
+	"github.com/tidepool-org/platform/log"
+	"github.com/tidepool-org/platform/store/mongo"
+	"github.com/tidepool-org/platform/version"
+)
+
+type Config struct {
+	Log    *log.Config
+	Mongo  *mongo.Config
+	DryRun bool
+}
+
+const (
+	HelpFlag      = "help"
+	VersionFlag   = "version"
+	VerboseFlag   = "verbose"
+	DryRunFlag    = "dry-run"
+	AddressesFlag = "addresses"
+	SSLFlag       = "ssl"
+)
+
+func main() {
+	application, err := initializeApplication()
+	if err != nil {
+		fmt.Println("ERROR: Unable to initialize application:", err)
+		os.Exit(1)
+	}
+
+	if err = application.Run(os.Args); err != nil {
+		fmt.Println("ERROR: Unable to run application:", err)
+		os.Exit(1)
+	}
+}
+
+func initializeApplication() (*cli.App, error) {
+	versionReporter, err := initializeVersionReporter()
+	if err != nil {
+		return nil, err
+	}
+
+	application := cli.NewApp()
+	application.Usage = "Backfill data deduplicator fuzzy hashes for existing device data"
+	application.Version = versionReporter.Long()
+	application.Authors = []cli.Author{{Name: "Darin Krauss", Email: "darin@tidepool.org"}}
+	application.Copyright = "Copyright \u00A9 2017, Tidepool Project"
+	application.HideHelp = true
+	application.HideVersion = true
+	application.Flags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  fmt.Sprintf("%s,%s,%s", HelpFlag, "h", "?"),
+			Usage: "print this page and exit",
+		},
+		cli.BoolFlag{
+			Name:  VersionFlag,
+			Usage: "print version and exit",
+		},
+		cli.BoolFlag{
+			Name:  fmt.Sprintf("%s,%s", VerboseFlag, "v"),
+			Usage: "increased verbosity",
+		},
+		cli.BoolFlag{
+			Name:  fmt.Sprintf("%s,%s", DryRunFlag, "n"),
+			Usage: "dry run only, do not update database",
+		},
+		cli.StringFlag{
+			Name:  fmt.Sprintf("%s,%s", AddressesFlag, "a"),
+			Usage: "comma-delimited list of address(es) to mongo database (host:port)",
+		},
+		cli.BoolFlag{
+			Name:  fmt.Sprintf("%s,%s", SSLFlag, "s"),
+			Usage: "use SSL to connect to mongo database",
+		},
+	}
+	application.Action = func(context *cli.Context) error {
+		executeApplication(versionReporter, context)
+		return nil
+	}
+
+	return application, nil
+}
+
+func initializeVersionReporter() (version.Reporter, error) {
+	versionReporter, err := version.NewDefaultReporter()
+	if err != nil {
+		return nil, errors.Wrap(err, "main", "unable to create version reporter")
+	}
+
+	return versionReporter, nil
+}
+
+func executeApplication(versionReporter version.Reporter, context *cli.Context) {
+	if context.Bool(HelpFlag) {
+		cli.ShowAppHelp(context)
+		return
+	}
+
+	if context.Bool(VersionFlag) {
+		fmt.Println(versionReporter.Long())
+		return
+	}
+
+	config, err := buildConfigFromContext(context)
+	if err != nil {
+		fmt.Println("ERROR: Unable to build config from context:", err)
+		os.Exit(1)
+	}
+
+	logger, err := initializeLogger(versionReporter, config)
+	if err != nil {
+		fmt.Println("ERROR: Unable to initialize logger:", err)
+		os.Exit(1)
+	}
+
+	err = migrateDataDeduplicatorFuzzyHashes(logger, config)
+	if err != nil {
+		logger.WithError(err).Error("Unable to migrate data deduplicator fuzzy hashes")
+		os.Exit(1)
+	}
+}
+
+func buildConfigFromContext(context *cli.Context) (*Config, error) {
+	config := &Config{
+		Log: &log.Config{
+			Level: "info",
+		},
+		Mongo: &mongo.Config{
+			Timeout: app.DurationAsPointer(60 * time.Second),
+		},
+	}
+
+	if context.Bool(VerboseFlag) {
+		config.Log.Level = "debug"
+	}
+	config.Mongo.Addresses = context.String(AddressesFlag)
+	if context.Bool(SSLFlag) {
+		config.Mongo.SSL = true
+	}
+	if context.Bool(DryRunFlag) {
+		config.DryRun = true
+	}
+
+	return config, nil
+}
+
+func initializeLogger(versionReporter version.Reporter, config *Config) (log.Logger, error) {
+	logger, err := log.NewStandard(versionReporter, config.Log)
+	if err != nil {
+		return nil, errors.Wrap(err, "main", "unable to create logger")
+	}
+
+	return logger, nil
+}
+
+func migrateDataDeduplicatorFuzzyHashes(logger log.Logger, config *Config) error {
+	logger.Debug("Migrating data deduplicator fuzzy hashes")
+
+	logger.Debug("Creating data factory")
+
+	dataFactory, err := factory.NewStandard()
+	if err != nil {
+		return errors.Wrap(err, "main", "unable to create data factory")
+	}
+
+	logger.Debug("Creating data store")
+
+	mongoConfig := config.Mongo.Clone()
+	mongoConfig.Database = "data"
+	mongoConfig.Collection = "deviceData"
+	mongoConfig.Timeout = app.DurationAsPointer(60 * time.Minute)
+	dataStore, err := mongo.New(logger, mongoConfig)
+	if err != nil {
+		return errors.Wrap(err, "main", "unable to create data store")
+	}
+	defer dataStore.Close()
+
+	logger.Debug("Creating data session")
+
+	dataStoreSession := dataStore.NewSession(logger)
+	defer dataStoreSession.Close()
+
+	selector := bson.M{
+		"type": bson.M{
+			"$ne": "upload",
+		},
+		"deviceTime": bson.M{
+			"$exists": true,
+		},
+		"_deduplicator.fuzzyHash": bson.M{
+			"$exists": false,
+		},
+	}
+
+	var count int
+	var failedCount int
+
+	iter := dataStoreSession.C().Find(selector).Iter()
+
+	var raw bson.Raw
+	for iter.Next(&raw) {
+		var id interface{}
+		var hash string
+		id, hash, err = generateFuzzyHash(dataFactory, raw)
+		if err != nil {
+			logger.WithError(err).WithField("_id", id).Warn("Unable to generate fuzzy hash for datum")
+			failedCount++
+			continue
+		}
+
+		if !config.DryRun {
+			update := bson.M{
+				"$set": bson.M{
+					"_deduplicator.fuzzyHash": hash,
+				},
+			}
+			if err = dataStoreSession.C().UpdateId(id, update); err != nil {
+				logger.WithError(err).WithField("_id", id).Warn("Unable to update fuzzy hash for datum")
+				failedCount++
+				continue
+			}
+		}
+
+		count++
+	}
+	if err = iter.Close(); err != nil {
+		return errors.Wrap(err, "main", "unable to iterate data")
+	}
+
+	logger.Info(fmt.Sprintf("Migrated %d data deduplicator fuzzy hashes", count))
+
+	if failedCount > 0 {
+		return errors.Newf("main", "unable to migrate %d data deduplicator fuzzy hashes", failedCount)
+	}
+
+	return nil
+}
+
+func generateFuzzyHash(dataFactory data.Factory, raw bson.Raw) (interface{}, string, error) {
+	var properties bson.M
+	if err := raw.Unmarshal(&properties); err != nil {
+		return nil, "", errors.Wrap(err, "main", "unable to unmarshal properties")
+	}
+
+	id := properties["_id"]
+
+	datum, err := dataFactory.New(&propertiesInspector{properties: properties})
+	if err != nil {
+		return id, "", errors.Wrap(err, "main", "unable to create datum")
+	} else if datum == nil {
+		return id, "", errors.New("main", "datum is missing")
+	}
+
+	if err = raw.Unmarshal(datum); err != nil {
+		return id, "", errors.Wrap(err, "main", "unable to unmarshal datum")
+	}
+
+	fields, err := datum.FuzzyIdentityFields()
+	if err != nil {
+		return id, "", errors.Wrap(err, "main", "unable to gather fuzzy identity fields for datum")
+	}
+
+	hash, err := deduplicator.GenerateIdentityHash(fields)
+	if err != nil {
+		return id, "", errors.Wrap(err, "main", "unable to generate fuzzy identity hash for datum")
+	}
+
+	return id, hash, nil
+}
+
+type propertiesInspector struct {
+	properties bson.M
+}
+
+func (p *propertiesInspector) GetProperty(key string) *string {
+	if value, ok := p.properties[key].(string); ok {
+		return &value
+	}
+	return nil
+}
+
+func (p *propertiesInspector) NewMissingPropertyError(key string) error {
+	return errors.Newf("main", "property %q is missing", key)
+}
+
+func (p *propertiesInspector) NewInvalidPropertyError(key string, value string, allowedValues []string) error {
+	return errors.Newf("main", "property %q value %q is not one of %v", key, value, allowedValues)
+}
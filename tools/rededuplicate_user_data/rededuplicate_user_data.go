@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/data/deduplicator"
+	dataMongo "github.com/tidepool-org/platform/data/store/mongo"
+	"github.com/tidepool-org/platform/errors"
+	lockMongo "github.com/tidepool-org/platform/lock/store/mongo"
+	"github.com/tidepool-org/platform/log"
+	"github.com/tidepool-org/platform/store/mongo"
+	"github.com/tidepool-org/platform/version"
+)
+
+type Config struct {
+	Log                    *log.Config
+	Mongo                  *mongo.Config
+	DataDeduplicatorConfig string
+	UserIDs                []string
+	DryRun                 bool
+}
+
+const (
+	HelpFlag                   = "help"
+	VersionFlag                = "version"
+	VerboseFlag                = "verbose"
+	DryRunFlag                 = "dry-run"
+	AddressesFlag              = "addresses"
+	SSLFlag                    = "ssl"
+	UserIDsFlag                = "user-ids"
+	DataDeduplicatorConfigFlag = "data-deduplicator-config"
+)
+
+func main() {
+	application, err := initializeApplication()
+	if err != nil {
+		fmt.Println("ERROR: Unable to initialize application:", err)
+		os.Exit(1)
+	}
+
+	if err = application.Run(os.Args); err != nil {
+		fmt.Println("ERROR: Unable to run application:", err)
+		os.Exit(1)
+	}
+}
+
+func initializeApplication() (*cli.App, error) {
+	versionReporter, err := initializeVersionReporter()
+	if err != nil {
+		return nil, err
+	}
+
+	application := cli.NewApp()
+	application.Usage = "Rededuplicate all data for users with the current data deduplicators"
+	application.Version = versionReporter.Long()
+	application.Authors = []cli.Author{{Name: "Darin Krauss", Email: "darin@tidepool.org"}}
+	application.Copyright = "Copyright \u00A9 2017, Tidepool Project"
+	application.HideHelp = true
+	application.HideVersion = true
+	application.Flags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  fmt.Sprintf("%s,%s,%s", HelpFlag, "h", "?"),
+			Usage: "print this page and exit",
+		},
+		cli.BoolFlag{
+			Name:  VersionFlag,
+			Usage: "print version and exit",
+		},
+		cli.BoolFlag{
+			Name:  fmt.Sprintf("%s,%s", VerboseFlag, "v"),
+			Usage: "increased verbosity",
+		},
+		cli.BoolFlag{
+			Name:  fmt.Sprintf("%s,%s", DryRunFlag, "n"),
+			Usage: "dry run only, do not update database",
+		},
+		cli.StringFlag{
+			Name:  fmt.Sprintf("%s,%s", AddressesFlag, "a"),
+			Usage: "comma-delimited list of address(es) to mongo database (host:port)",
+		},
+		cli.BoolFlag{
+			Name:  fmt.Sprintf("%s,%s", SSLFlag, "s"),
+			Usage: "use SSL to connect to mongo database",
+		},
+		cli.StringFlag{
+			Name:  fmt.Sprintf("%s,%s", UserIDsFlag, "u"),
+			Usage: "comma-delimited list of user id(s) to rededuplicate",
+		},
+		cli.StringFlag{
+			Name:  fmt.Sprintf("%s,%s", DataDeduplicatorConfigFlag, "c"),
+			Usage: "path to data deduplicator config file",
+			Value: "_config/dataservices/data_deduplicator.json",
+		},
+	}
+	application.Action = func(context *cli.Context) error {
+		executeApplication(versionReporter, context)
+		return nil
+	}
+
+	return application, nil
+}
+
+func initializeVersionReporter() (version.Reporter, error) {
+	versionReporter, err := version.NewDefaultReporter()
+	if err != nil {
+		return nil, errors.Wrap(err, "main", "unable to create version reporter")
+	}
+
+	return versionReporter, nil
+}
+
+func executeApplication(versionReporter version.Reporter, context *cli.Context) {
+	if context.Bool(HelpFlag) {
+		cli.ShowAppHelp(context)
+		return
+	}
+
+	if context.Bool(VersionFlag) {
+		fmt.Println(versionReporter.Long())
+		return
+	}
+
+	config, err := buildConfigFromContext(context)
+	if err != nil {
+		fmt.Println("ERROR: Unable to build config from context:", err)
+		os.Exit(1)
+	}
+
+	logger, err := initializeLogger(versionReporter, config)
+	if err != nil {
+		fmt.Println("ERROR: Unable to initialize logger:", err)
+		os.Exit(1)
+	}
+
+	err = rededuplicateUserData(logger, config)
+	if err != nil {
+		logger.WithError(err).Error("Unable to rededuplicate user data")
+		os.Exit(1)
+	}
+}
+
+func buildConfigFromContext(context *cli.Context) (*Config, error) {
+	config := &Config{
+		Log: &log.Config{
+			Level: "info",
+		},
+		Mongo: &mongo.Config{
+			Timeout: app.DurationAsPointer(60 * time.Second),
+		},
+	}
+
+	if context.Bool(VerboseFlag) {
+		config.Log.Level = "debug"
+	}
+	config.Mongo.Addresses = context.String(AddressesFlag)
+	if context.Bool(SSLFlag) {
+		config.Mongo.SSL = true
+	}
+	config.DataDeduplicatorConfig = context.String(DataDeduplicatorConfigFlag)
+	config.UserIDs = app.SplitStringAndRemoveWhitespace(context.String(UserIDsFlag), ",")
+	if context.Bool(DryRunFlag) {
+		config.DryRun = true
+	}
+
+	if len(config.UserIDs) == 0 {
+		return nil, errors.New("main", "user ids is missing")
+	}
+	if config.DataDeduplicatorConfig == "" {
+		return nil, errors.New("main", "data deduplicator config is missing")
+	}
+
+	return config, nil
+}
+
+func initializeLogger(versionReporter version.Reporter, config *Config) (log.Logger, error) {
+	logger, err := log.NewStandard(versionReporter, config.Log)
+	if err != nil {
+		return nil, errors.Wrap(err, "main", "unable to create logger")
+	}
+
+	return logger, nil
+}
+
+func rededuplicateUserData(logger log.Logger, config *Config) error {
+	logger.Debug("Rededuplicating user data")
+
+	logger.Debug("Creating data deduplicator factory")
+
+	dataDeduplicatorFactory, err := initializeDataDeduplicatorFactory(config)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Creating data store")
+
+	mongoConfig := config.Mongo.Clone()
+	mongoConfig.Database = "data"
+	mongoConfig.Collection = "deviceData"
+	mongoConfig.Timeout = app.DurationAsPointer(60 * time.Minute)
+	dataStore, err := dataMongo.New(logger, mongoConfig)
+	if err != nil {
+		return errors.Wrap(err, "main", "unable to create data store")
+	}
+	defer dataStore.Close()
+
+	logger.Debug("Creating data session")
+
+	dataStoreSession := dataStore.NewSession(logger)
+	defer dataStoreSession.Close()
+
+	logger.Debug("Creating lock store")
+
+	lockMongoConfig := config.Mongo.Clone()
+	lockMongoConfig.Database = "data"
+	lockMongoConfig.Collection = "dataLocks"
+	lockStore, err := lockMongo.New(logger, lockMongoConfig)
+	if err != nil {
+		return errors.Wrap(err, "main", "unable to create lock store")
+	}
+	defer lockStore.Close()
+
+	logger.Debug("Creating lock session")
+
+	lockStoreSession := lockStore.NewSession(logger)
+	defer lockStoreSession.Close()
+
+	var datasetsCount int
+	var changedCount int
+	var failedCount int
+	for _, userID := range config.UserIDs {
+		userLogger := logger.WithFields(log.Fields{"userId": userID, "dryRun": config.DryRun})
+
+		report, reportErr := deduplicator.RededuplicateUser(userLogger, dataStoreSession, lockStoreSession, dataDeduplicatorFactory, userID, config.DryRun)
+		if reportErr != nil {
+			userLogger.WithError(reportErr).Error("Unable to rededuplicate user data")
+			failedCount++
+			continue
+		}
+
+		for _, datasetReport := range report.Datasets {
+			datasetLogger := userLogger.WithFields(log.Fields{
+				"datasetId":   datasetReport.DatasetID,
+				"fromName":    datasetReport.FromName,
+				"fromVersion": datasetReport.FromVersion,
+				"toName":      datasetReport.ToName,
+				"toVersion":   datasetReport.ToVersion,
+				"changed":     datasetReport.IsChanged(),
+			})
+			if datasetReport.Skipped != "" {
+				datasetLogger = datasetLogger.WithField("skipped", datasetReport.Skipped)
+			}
+			if datasetReport.Preview != nil {
+				for _, action := range datasetReport.Preview.Actions {
+					datasetLogger = datasetLogger.WithField(action.Action, action.Count)
+				}
+			}
+			datasetLogger.Info("Rededuplicated dataset")
+		}
+
+		userLogger.Info(fmt.Sprintf("Rededuplicated %d datasets (%d changed, %d skipped) for user", len(report.Datasets), report.ChangedCount(), report.SkippedCount()))
+
+		datasetsCount += len(report.Datasets)
+		changedCount += report.ChangedCount()
+	}
+
+	logger.Info(fmt.Sprintf("Rededuplicated %d datasets (%d changed) for %d users", datasetsCount, changedCount, len(config.UserIDs)-failedCount))
+
+	if failedCount > 0 {
+		return errors.Newf("main", "unable to rededuplicate user data for %d users", failedCount)
+	}
+
+	return nil
+}
+
+func initializeDataDeduplicatorFactory(config *Config) (*deduplicator.DelegateFactory, error) {
+	bytes, err := ioutil.ReadFile(config.DataDeduplicatorConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "main", "unable to read data deduplicator config")
+	}
+
+	dataDeduplicatorConfig := &deduplicator.Config{}
+	if err = json.Unmarshal(bytes, dataDeduplicatorConfig); err != nil {
+		return nil, errors.Wrap(err, "main", "unable to parse data deduplicator config")
+	}
+
+	factories, err := deduplicator.NewFactoriesFromConfig(dataDeduplicatorConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "main", "unable to create data deduplicator factories")
+	}
+
+	return deduplicator.NewDelegateFactory(factories)
+}
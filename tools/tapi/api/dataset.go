@@ -12,8 +12,9 @@ type (
 	}
 
 	Pagination struct {
-		Page *int
-		Size *int
+		Page   *int
+		Size   *int
+		Cursor *string
 	}
 )
 
@@ -39,6 +40,9 @@ func (a *API) ListDatasets(userID string, filter *Filter, pagination *Pagination
 		if pagination.Size != nil {
 			queryMap["size"] = strconv.Itoa(*pagination.Size)
 		}
+		if pagination.Cursor != nil {
+			queryMap["cursor"] = *pagination.Cursor
+		}
 	}
 
 	return a.asResponseArray(a.request("GET", a.addQuery(a.joinPaths("dataservices", "v1", "users", userID, "datasets"), queryMap),
@@ -55,3 +59,13 @@ func (a *API) DeleteDataset(datasetID string) error {
 		requestFuncs{a.addSessionToken()},
 		responseFuncs{a.expectStatusCode(http.StatusOK)}))
 }
+
+func (a *API) UndeleteDataset(datasetID string) (*ResponseObject, error) {
+	if datasetID == "" {
+		return nil, errors.New("Dataset id is missing")
+	}
+
+	return a.asResponseObject(a.request("POST", a.joinPaths("dataservices", "v1", "datasets", datasetID, "undelete"),
+		requestFuncs{a.addSessionToken()},
+		responseFuncs{a.expectStatusCode(http.StatusOK)}))
+}
@@ -34,7 +34,12 @@ type Source struct {
 }
 
 type Meta struct {
-	Trace *Trace `json:"trace,omitempty"`
+	Trace      *Trace          `json:"trace,omitempty"`
+	Pagination *MetaPagination `json:"pagination,omitempty"`
+}
+
+type MetaPagination struct {
+	Next string `json:"next,omitempty"`
 }
 
 type Trace struct {
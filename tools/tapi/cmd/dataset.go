@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/urfave/cli"
 
 	"github.com/tidepool-org/platform/tools/tapi/api"
@@ -11,6 +13,8 @@ const (
 	DeletedFlag   = "deleted"
 	PageFlag      = "page"
 	SizeFlag      = "size"
+	CursorFlag    = "cursor"
+	AllFlag       = "all"
 )
 
 func DatasetCommands() cli.Commands {
@@ -39,6 +43,14 @@ func DatasetCommands() cli.Commands {
 							Name:  SizeFlag,
 							Usage: "pagination `SIZE`",
 						},
+						cli.StringFlag{
+							Name:  CursorFlag,
+							Usage: "pagination `CURSOR` returned by a previous list",
+						},
+						cli.BoolFlag{
+							Name:  AllFlag,
+							Usage: "list all datasets by following pagination cursors",
+						},
 					),
 					Before: ensureNoArgs,
 					Action: datasetList,
@@ -55,6 +67,18 @@ func DatasetCommands() cli.Commands {
 					Before: ensureNoArgs,
 					Action: datasetDelete,
 				},
+				{
+					Name:  "undelete",
+					Usage: "undelete dataset",
+					Flags: CommandFlags(
+						cli.StringFlag{
+							Name:  DatasetIDFlag,
+							Usage: "`DATASETID` of the dataset to undelete",
+						},
+					),
+					Before: ensureNoArgs,
+					Action: datasetUndelete,
+				},
 			},
 		},
 	}
@@ -86,18 +110,49 @@ func datasetList(c *cli.Context) error {
 		pagination.Size = &size
 	}
 
-	responseArray, err := API(c).ListDatasets(c.String(UserIDFlag), filter, pagination)
-	if err != nil {
-		return err
+	if c.IsSet(CursorFlag) {
+		if c.IsSet(PageFlag) {
+			return fmt.Errorf("Unexpected flags: --%s and --%s", PageFlag, CursorFlag)
+		}
+		if pagination == nil {
+			pagination = &api.Pagination{}
+		}
+		cursor := c.String(CursorFlag)
+		pagination.Cursor = &cursor
+	}
+	if c.Bool(AllFlag) && c.IsSet(PageFlag) {
+		return fmt.Errorf("Unexpected flags: --%s and --%s", PageFlag, AllFlag)
 	}
 
-	for _, dataset := range responseArray.Data {
-		if err = reportMessageWithJSON(c, dataset); err != nil {
+	for {
+		responseArray, err := API(c).ListDatasets(c.String(UserIDFlag), filter, pagination)
+		if err != nil {
 			return err
 		}
-	}
+		if responseArray == nil {
+			return nil
+		}
+
+		for _, dataset := range responseArray.Data {
+			if err = reportMessageWithJSON(c, dataset); err != nil {
+				return err
+			}
+		}
 
-	return nil
+		if responseArray.Meta == nil || responseArray.Meta.Pagination == nil || responseArray.Meta.Pagination.Next == "" {
+			return nil
+		}
+
+		next := responseArray.Meta.Pagination.Next
+		if !c.Bool(AllFlag) {
+			return reportMessage(c, "Next cursor:", next)
+		}
+
+		if pagination == nil {
+			pagination = &api.Pagination{}
+		}
+		pagination.Cursor = &next
+	}
 }
 
 func datasetDelete(c *cli.Context) error {
@@ -107,3 +162,18 @@ func datasetDelete(c *cli.Context) error {
 
 	return reportMessage(c, "Dataset deleted.")
 }
+
+func datasetUndelete(c *cli.Context) error {
+	responseObject, err := API(c).UndeleteDataset(c.String(DatasetIDFlag))
+	if err != nil {
+		return err
+	}
+
+	if responseObject != nil {
+		if err = reportMessageWithJSON(c, responseObject.Data); err != nil {
+			return err
+		}
+	}
+
+	return reportMessage(c, "Dataset undeleted.")
+}
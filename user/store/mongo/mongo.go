@@ -122,6 +122,73 @@ func (s *Session) DeleteUser(user *user.User) error {
 	return nil
 }
 
+func (s *Session) ScheduleUserDeletion(user *user.User, deletionScheduledTime string) error {
+	if user == nil {
+		return errors.New("mongo", "user is missing")
+	}
+	if user.ID == "" {
+		return errors.New("mongo", "user id is missing")
+	}
+	if deletionScheduledTime == "" {
+		return errors.New("mongo", "deletion scheduled time is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	user.DeletionScheduledTime = deletionScheduledTime
+	user.ModifiedTime = s.Timestamp()
+	user.ModifiedUserID = s.AgentUserID()
+
+	selector := bson.M{
+		"userid": user.ID,
+	}
+	err := s.C().Update(selector, user)
+
+	loggerFields := log.Fields{"userId": user.ID, "deletionScheduledTime": deletionScheduledTime, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("ScheduleUserDeletion")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to schedule user deletion")
+	}
+	return nil
+}
+
+func (s *Session) UnscheduleUserDeletion(user *user.User) error {
+	if user == nil {
+		return errors.New("mongo", "user is missing")
+	}
+	if user.ID == "" {
+		return errors.New("mongo", "user id is missing")
+	}
+
+	if s.IsClosed() {
+		return errors.New("mongo", "session closed")
+	}
+
+	startTime := time.Now()
+
+	user.DeletionScheduledTime = ""
+	user.ModifiedTime = s.Timestamp()
+	user.ModifiedUserID = s.AgentUserID()
+
+	selector := bson.M{
+		"userid": user.ID,
+	}
+	err := s.C().Update(selector, user)
+
+	loggerFields := log.Fields{"userId": user.ID, "duration": time.Since(startTime) / time.Microsecond}
+	s.Logger().WithFields(loggerFields).WithError(err).Debug("UnscheduleUserDeletion")
+
+	if err != nil {
+		return errors.Wrap(err, "mongo", "unable to unschedule user deletion")
+	}
+	return nil
+}
+
 func (s *Session) DestroyUserByID(userID string) error {
 	if userID == "" {
 		return errors.New("mongo", "user id is missing")
@@ -331,6 +331,80 @@ var _ = Describe("Mongo", func() {
 					})
 				})
 
+				Context("ScheduleUserDeletion", func() {
+					var scheduleUser *user.User
+					var deletionScheduledTime string
+
+					BeforeEach(func() {
+						scheduleUser = NewUser(app.NewID())
+						deletionScheduledTime = time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+					})
+
+					JustBeforeEach(func() {
+						Expect(testMongoCollection.Insert(scheduleUser)).To(Succeed())
+					})
+
+					It("succeeds if it successfully schedules the user deletion", func() {
+						Expect(mongoSession.ScheduleUserDeletion(scheduleUser, deletionScheduledTime)).To(Succeed())
+						Expect(scheduleUser.DeletionScheduledTime).To(Equal(deletionScheduledTime))
+						Expect(scheduleUser.ModifiedTime).ToNot(BeEmpty())
+						Expect(scheduleUser.DeletedTime).To(BeEmpty())
+						ValidateUsers(testMongoCollection, bson.M{}, append(users, scheduleUser))
+					})
+
+					It("returns an error if the user is missing", func() {
+						Expect(mongoSession.ScheduleUserDeletion(nil, deletionScheduledTime)).To(MatchError("mongo: user is missing"))
+					})
+
+					It("returns an error if the user id is missing", func() {
+						scheduleUser.ID = ""
+						Expect(mongoSession.ScheduleUserDeletion(scheduleUser, deletionScheduledTime)).To(MatchError("mongo: user id is missing"))
+					})
+
+					It("returns an error if the deletion scheduled time is missing", func() {
+						Expect(mongoSession.ScheduleUserDeletion(scheduleUser, "")).To(MatchError("mongo: deletion scheduled time is missing"))
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						Expect(mongoSession.ScheduleUserDeletion(scheduleUser, deletionScheduledTime)).To(MatchError("mongo: session closed"))
+					})
+				})
+
+				Context("UnscheduleUserDeletion", func() {
+					var unscheduleUser *user.User
+
+					BeforeEach(func() {
+						unscheduleUser = NewUser(app.NewID())
+						unscheduleUser.DeletionScheduledTime = time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+					})
+
+					JustBeforeEach(func() {
+						Expect(testMongoCollection.Insert(unscheduleUser)).To(Succeed())
+					})
+
+					It("succeeds if it successfully unschedules the user deletion", func() {
+						Expect(mongoSession.UnscheduleUserDeletion(unscheduleUser)).To(Succeed())
+						Expect(unscheduleUser.DeletionScheduledTime).To(BeEmpty())
+						Expect(unscheduleUser.ModifiedTime).ToNot(BeEmpty())
+						ValidateUsers(testMongoCollection, bson.M{}, append(users, unscheduleUser))
+					})
+
+					It("returns an error if the user is missing", func() {
+						Expect(mongoSession.UnscheduleUserDeletion(nil)).To(MatchError("mongo: user is missing"))
+					})
+
+					It("returns an error if the user id is missing", func() {
+						unscheduleUser.ID = ""
+						Expect(mongoSession.UnscheduleUserDeletion(unscheduleUser)).To(MatchError("mongo: user id is missing"))
+					})
+
+					It("returns an error if the session is closed", func() {
+						mongoSession.Close()
+						Expect(mongoSession.UnscheduleUserDeletion(unscheduleUser)).To(MatchError("mongo: session closed"))
+					})
+				})
+
 				Context("DestroyUserByID", func() {
 					var destroyUserID string
 					var destroyUser *user.User
@@ -17,6 +17,8 @@ type Session interface {
 
 	GetUserByID(userID string) (*user.User, error)
 	DeleteUser(user *user.User) error
+	ScheduleUserDeletion(user *user.User, deletionScheduledTime string) error
+	UnscheduleUserDeletion(user *user.User) error
 	DestroyUserByID(userID string) error
 
 	PasswordMatches(user *user.User, password string) bool
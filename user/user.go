@@ -19,6 +19,8 @@ type User struct {
 	DeletedTime       string             `json:"deletedTime,omitempty" bson:"deletedTime,omitempty"`
 	DeletedUserID     string             `json:"deletedUserId,omitempty" bson:"deletedUserId,omitempty"`
 
+	DeletionScheduledTime string `json:"deletionScheduledTime,omitempty" bson:"deletionScheduledTime,omitempty"`
+
 	ProfileID *string `json:"-" bson:"-"`
 }
 
@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"io"
 	"net/http"
 
 	"github.com/ant0ine/go-json-rest/rest"
@@ -26,6 +27,11 @@ func (t *TestContext) RespondWithError(err *service.Error)
 func (t *TestContext) RespondWithInternalServerFailure(message string, failure ...interface{}) {}
 func (t *TestContext) RespondWithStatusAndErrors(statusCode int, errors []*service.Error)      {}
 func (t *TestContext) RespondWithStatusAndData(statusCode int, data interface{})               {}
+func (t *TestContext) RespondWithStatusAndDataAndCursor(statusCode int, data interface{}, cursor string) {
+}
+func (t *TestContext) RespondWithStatusAndStream(statusCode int, stream service.Stream) {}
+func (t *TestContext) RespondWithStatusAndAttachment(statusCode int, contentType string, filename string, attachment io.ReadCloser) {
+}
 
 var _ = Describe("Standard", func() {
 	var logger log.Logger
@@ -6,6 +6,7 @@ import (
 	dataservicesClient "github.com/tidepool-org/platform/dataservices/client"
 	"github.com/tidepool-org/platform/environment"
 	"github.com/tidepool-org/platform/errors"
+	exportStore "github.com/tidepool-org/platform/export/store"
 	"github.com/tidepool-org/platform/log"
 	messageStore "github.com/tidepool-org/platform/message/store"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
@@ -14,6 +15,7 @@ import (
 	profileStore "github.com/tidepool-org/platform/profile/store"
 	"github.com/tidepool-org/platform/service/api"
 	sessionStore "github.com/tidepool-org/platform/session/store"
+	taskStore "github.com/tidepool-org/platform/task/store"
 	userStore "github.com/tidepool-org/platform/user/store"
 	userservicesClient "github.com/tidepool-org/platform/userservices/client"
 	"github.com/tidepool-org/platform/userservices/service"
@@ -26,18 +28,20 @@ type Standard struct {
 	metricServicesClient metricservicesClient.Client
 	userServicesClient   userservicesClient.Client
 	dataServicesClient   dataservicesClient.Client
+	exportStore          exportStore.Store
 	messageStore         messageStore.Store
 	notificationStore    notificationStore.Store
 	permissionStore      permissionStore.Store
 	profileStore         profileStore.Store
 	sessionStore         sessionStore.Store
+	taskStore            taskStore.Store
 	userStore            userStore.Store
 }
 
 func NewStandard(versionReporter version.Reporter, environmentReporter environment.Reporter, logger log.Logger,
 	metricServicesClient metricservicesClient.Client, userServicesClient userservicesClient.Client, dataServicesClient dataservicesClient.Client,
-	messageStore messageStore.Store, notificationStore notificationStore.Store, permissionStore permissionStore.Store,
-	profileStore profileStore.Store, sessionStore sessionStore.Store, userStore userStore.Store) (*Standard, error) {
+	exportStore exportStore.Store, messageStore messageStore.Store, notificationStore notificationStore.Store, permissionStore permissionStore.Store,
+	profileStore profileStore.Store, sessionStore sessionStore.Store, taskStore taskStore.Store, userStore userStore.Store) (*Standard, error) {
 	if versionReporter == nil {
 		return nil, errors.New("api", "version reporter is missing")
 	}
@@ -56,6 +60,9 @@ func NewStandard(versionReporter version.Reporter, environmentReporter environme
 	if dataServicesClient == nil {
 		return nil, errors.New("api", "data services client is missing")
 	}
+	if exportStore == nil {
+		return nil, errors.New("api", "export store is missing")
+	}
 	if messageStore == nil {
 		return nil, errors.New("api", "message store is missing")
 	}
@@ -71,6 +78,9 @@ func NewStandard(versionReporter version.Reporter, environmentReporter environme
 	if sessionStore == nil {
 		return nil, errors.New("api", "session store is missing")
 	}
+	if taskStore == nil {
+		return nil, errors.New("api", "task store is missing")
+	}
 	if userStore == nil {
 		return nil, errors.New("api", "user store is missing")
 	}
@@ -85,11 +95,13 @@ func NewStandard(versionReporter version.Reporter, environmentReporter environme
 		metricServicesClient: metricServicesClient,
 		userServicesClient:   userServicesClient,
 		dataServicesClient:   dataServicesClient,
+		exportStore:          exportStore,
 		messageStore:         messageStore,
 		notificationStore:    notificationStore,
 		permissionStore:      permissionStore,
 		profileStore:         profileStore,
 		sessionStore:         sessionStore,
+		taskStore:            taskStore,
 		userStore:            userStore,
 	}, nil
 }
@@ -123,6 +135,6 @@ func (s *Standard) InitializeRouter(routes []service.Route) error {
 
 func (s *Standard) withContext(handler service.HandlerFunc) rest.HandlerFunc {
 	return context.WithContext(s.metricServicesClient, s.userServicesClient, s.dataServicesClient,
-		s.messageStore, s.notificationStore, s.permissionStore, s.profileStore,
-		s.sessionStore, s.userStore, handler)
+		s.exportStore, s.messageStore, s.notificationStore, s.permissionStore,
+		s.profileStore, s.sessionStore, s.taskStore, s.userStore, handler)
 }
@@ -24,3 +24,48 @@ func ErrorUserIDNotFound(userID string) *service.Error {
 		Detail: fmt.Sprintf("User with id %s not found", userID),
 	}
 }
+
+func ErrorExportIDMissing() *service.Error {
+	return &service.Error{
+		Code:   "export-id-missing",
+		Status: http.StatusBadRequest,
+		Title:  "export id is missing",
+		Detail: "Export id is missing",
+	}
+}
+
+func ErrorExportIDNotFound(exportID string) *service.Error {
+	return &service.Error{
+		Code:   "export-id-not-found",
+		Status: http.StatusNotFound,
+		Title:  "export with specified id not found",
+		Detail: fmt.Sprintf("Export with id %s not found", exportID),
+	}
+}
+
+func ErrorExportNotCompleted(exportID string) *service.Error {
+	return &service.Error{
+		Code:   "export-not-completed",
+		Status: http.StatusConflict,
+		Title:  "export is not completed",
+		Detail: fmt.Sprintf("Export with id %s is not completed", exportID),
+	}
+}
+
+func ErrorUserDeletionNotFound(userID string) *service.Error {
+	return &service.Error{
+		Code:   "user-deletion-not-found",
+		Status: http.StatusNotFound,
+		Title:  "user deletion not found",
+		Detail: fmt.Sprintf("User deletion for user with id %s not found", userID),
+	}
+}
+
+func ErrorUserDeletionNotCancelable(userID string) *service.Error {
+	return &service.Error{
+		Code:   "user-deletion-not-cancelable",
+		Status: http.StatusConflict,
+		Title:  "user deletion is not cancelable",
+		Detail: fmt.Sprintf("User deletion for user with id %s is not cancelable", userID),
+	}
+}
@@ -32,4 +32,64 @@ var _ = Describe("Errors", func() {
 				}))
 		})
 	})
+
+	Context("ErrorExportIDMissing", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorExportIDMissing()).To(Equal(
+				&service.Error{
+					Code:   "export-id-missing",
+					Status: 400,
+					Title:  "export id is missing",
+					Detail: "Export id is missing",
+				}))
+		})
+	})
+
+	Context("ErrorExportIDNotFound", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorExportIDNotFound("1234567890abcdef")).To(Equal(
+				&service.Error{
+					Code:   "export-id-not-found",
+					Status: 404,
+					Title:  "export with specified id not found",
+					Detail: "Export with id 1234567890abcdef not found",
+				}))
+		})
+	})
+
+	Context("ErrorExportNotCompleted", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorExportNotCompleted("1234567890abcdef")).To(Equal(
+				&service.Error{
+					Code:   "export-not-completed",
+					Status: 409,
+					Title:  "export is not completed",
+					Detail: "Export with id 1234567890abcdef is not completed",
+				}))
+		})
+	})
+
+	Context("ErrorUserDeletionNotFound", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorUserDeletionNotFound("1234567890abcdef")).To(Equal(
+				&service.Error{
+					Code:   "user-deletion-not-found",
+					Status: 404,
+					Title:  "user deletion not found",
+					Detail: "User deletion for user with id 1234567890abcdef not found",
+				}))
+		})
+	})
+
+	Context("ErrorUserDeletionNotCancelable", func() {
+		It("matches the expected error", func() {
+			Expect(v1.ErrorUserDeletionNotCancelable("1234567890abcdef")).To(Equal(
+				&service.Error{
+					Code:   "user-deletion-not-cancelable",
+					Status: 409,
+					Title:  "user deletion is not cancelable",
+					Detail: "User deletion for user with id 1234567890abcdef is not cancelable",
+				}))
+		})
+	})
 })
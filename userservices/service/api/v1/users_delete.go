@@ -2,15 +2,19 @@ package v1
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
-	messageStore "github.com/tidepool-org/platform/message/store"
-	"github.com/tidepool-org/platform/profile"
 	commonService "github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
 	"github.com/tidepool-org/platform/user"
 	"github.com/tidepool-org/platform/userservices/client"
 	"github.com/tidepool-org/platform/userservices/service"
+	"github.com/tidepool-org/platform/userservices/service/worker"
 )
 
+const ParameterScheduled = "scheduled"
+
 type UsersDeleteParameters struct {
 	Password string `json:"password,omitempty"`
 }
@@ -22,6 +26,16 @@ func UsersDelete(serviceContext service.Context) {
 		return
 	}
 
+	scheduled := false
+	if value := serviceContext.Request().URL.Query().Get(ParameterScheduled); value != "" {
+		parsedValue, err := strconv.ParseBool(value)
+		if err != nil {
+			serviceContext.RespondWithStatusAndErrors(http.StatusBadRequest, []*commonService.Error{commonService.ErrorTypeNotBoolean(value).WithSourceParameter(ParameterScheduled)})
+			return
+		}
+		scheduled = parsedValue
+	}
+
 	var password *string
 	if !serviceContext.AuthenticationDetails().IsServer() {
 		authenticatedUserID := serviceContext.AuthenticationDetails().UserID()
@@ -71,72 +85,61 @@ func UsersDelete(serviceContext service.Context) {
 		}
 	}
 
-	messageUser := &messageStore.User{
-		ID: targetUserID,
-	}
-
-	if targetUser.ProfileID != nil {
-		var profile *profile.Profile
-		profile, err = serviceContext.ProfileStoreSession().GetProfileByID(*targetUser.ProfileID)
-		if err != nil {
-			serviceContext.RespondWithInternalServerFailure("Unable to get profile by id", err)
-			return
-		}
-		if profile != nil && profile.FullName != nil {
-			messageUser.FullName = *profile.FullName
-		}
-	}
-
 	if err = serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_delete", map[string]string{"userId": targetUserID}); err != nil {
 		serviceContext.Logger().WithError(err).Error("Unable to record metric")
 	}
 
-	if err = serviceContext.UserStoreSession().DeleteUser(targetUser); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to delete user", err)
-		return
-	}
-
-	if err = serviceContext.SessionStoreSession().DestroySessionsForUserByID(targetUserID); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to destroy sessions for user by id", err)
-		return
-	}
-
-	if err = serviceContext.PermissionStoreSession().DestroyPermissionsForUserByID(targetUserID); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to destroy permissions for user by id", err)
-		return
-	}
-
-	if err = serviceContext.NotificationStoreSession().DestroyNotificationsForUserByID(targetUserID); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to destroy notifications for user by id", err)
-		return
-	}
-
-	if err = serviceContext.DataServicesClient().DestroyDataForUserByID(serviceContext, targetUserID); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to destroy data for user by id", err)
+	task, err := serviceContext.TaskStoreSession().GetTaskForUserByID(targetUserID, worker.TaskTypeDeleteUser)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get task for user by id", err)
 		return
 	}
 
-	if err = serviceContext.MessageStoreSession().DestroyMessagesForUserByID(targetUserID); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to destroy messages for user by id", err)
-		return
+	// An immediate deletion does not wait for a scheduled deletion, so the scheduled task is canceled and replaced,
+	// unless it has since started
+	if task != nil && !scheduled && isScheduledDeletion(task) {
+		var canceled bool
+		if canceled, err = serviceContext.TaskStoreSession().CancelTask(task); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to cancel task", err)
+			return
+		}
+		if canceled {
+			task = nil
+		}
 	}
 
-	if err = serviceContext.MessageStoreSession().DeleteMessagesFromUser(messageUser); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to delete messages from user", err)
-		return
-	}
+	if task != nil && task.State == taskStore.TaskStateFailed {
+		if err = serviceContext.TaskStoreSession().ResumeTask(task); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to resume task", err)
+			return
+		}
+	} else if task == nil || !task.IsActive() {
+		task = worker.NewDeleteUserTask(targetUserID)
+		if scheduled {
+			task.AvailableTime = time.Now().Add(worker.DeleteUserGracePeriod).UTC().Format(time.RFC3339)
+		}
 
-	if targetUser.ProfileID != nil {
-		if err = serviceContext.ProfileStoreSession().DestroyProfileByID(*targetUser.ProfileID); err != nil {
-			serviceContext.RespondWithInternalServerFailure("Unable to destroy profile by id", err)
+		// The task is created before scheduling, so the deletion can be canceled if any later step fails
+		if err = serviceContext.TaskStoreSession().CreateTask(task); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to create task", err)
 			return
 		}
-	}
 
-	if err = serviceContext.UserStoreSession().DestroyUserByID(targetUserID); err != nil {
-		serviceContext.RespondWithInternalServerFailure("Unable to destroy user by id", err)
-		return
+		if scheduled {
+			if err = serviceContext.UserStoreSession().ScheduleUserDeletion(targetUser, task.AvailableTime); err != nil {
+				serviceContext.RespondWithInternalServerFailure("Unable to schedule user deletion", err)
+				return
+			}
+			if err = serviceContext.SessionStoreSession().DestroySessionsForUserByID(targetUserID); err != nil {
+				serviceContext.RespondWithInternalServerFailure("Unable to destroy sessions for user by id", err)
+				return
+			}
+			if err = serviceContext.DataServicesClient().LockDataForUserByID(serviceContext, targetUserID); err != nil {
+				serviceContext.RespondWithInternalServerFailure("Unable to lock data for user by id", err)
+				return
+			}
+		}
 	}
 
-	serviceContext.RespondWithStatusAndData(http.StatusOK, struct{}{})
+	serviceContext.RespondWithStatusAndData(http.StatusAccepted, NewDeletion(task))
 }
@@ -9,258 +9,248 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/tidepool-org/platform/app"
-	messageStore "github.com/tidepool-org/platform/message/store"
-	"github.com/tidepool-org/platform/profile"
 	"github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
 	"github.com/tidepool-org/platform/user"
 	"github.com/tidepool-org/platform/userservices/client"
 	"github.com/tidepool-org/platform/userservices/service/api/v1"
+	"github.com/tidepool-org/platform/userservices/service/worker"
 )
 
 var _ = Describe("UsersDelete", func() {
 	Context("Unit Tests", func() {
 		var authenticatedUserID string
 		var targetUserID string
-		var targetProfileID string
 		var targetPassword string
-		var targetFullName string
 		var targetUser *user.User
-		var targetProfile *profile.Profile
 		var context *TestContext
 
 		BeforeEach(func() {
 			authenticatedUserID = app.NewID()
 			targetUserID = app.NewID()
-			targetProfileID = app.NewID()
 			targetPassword = app.NewID()
-			targetFullName = app.NewID()
-			targetUser = &user.User{
-				ProfileID: &targetProfileID,
-			}
-			targetProfile = &profile.Profile{
-				FullName: &targetFullName,
-			}
+			targetUser = &user.User{}
 			context = NewTestContext()
 		})
 
-		WithDestroyingUser := func(flags *TestFlags) func() {
+		WithSchedulingDeletion := func(flags *TestFlags) func() {
 			return func() {
 				AfterEach(func() {
-					Expect(context.UserStoreSessionImpl.DestroyUserByIDInputs).To(Equal([]string{targetUserID}))
+					Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(HaveLen(1))
+					availableTime, err := time.Parse(time.RFC3339, context.TaskStoreSessionImpl.CreateTaskInputs[0].AvailableTime)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(availableTime).To(BeTemporally("~", time.Now().Add(worker.DeleteUserGracePeriod), 5*time.Second))
 				})
 
-				Context("with destroying user", func() {
+				Context("with creating task", func() {
 					BeforeEach(func() {
-						context.UserStoreSessionImpl.DestroyUserByIDOutputs = []error{nil}
+						context.TaskStoreSessionImpl.CreateTaskOutputs = []error{nil}
 					})
 
-					It("is successful", func() {
-						v1.UsersDelete(context)
-						Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, struct{}{}}}))
+					AfterEach(func() {
+						Expect(context.UserStoreSessionImpl.ScheduleUserDeletionInputs).To(HaveLen(1))
+						Expect(context.UserStoreSessionImpl.ScheduleUserDeletionInputs[0].User).To(Equal(targetUser))
+						Expect(context.UserStoreSessionImpl.ScheduleUserDeletionInputs[0].deletionScheduledTime).To(Equal(context.TaskStoreSessionImpl.CreateTaskInputs[0].AvailableTime))
 					})
-				})
 
-				It("responds with failure if it returns error", func() {
-					err := errors.New("test-error-destroying-user")
-					context.UserStoreSessionImpl.DestroyUserByIDOutputs = []error{err}
-					v1.UsersDelete(context)
-					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to destroy user by id", []interface{}{err}}}))
-				})
-			}
-		}
+					Context("with scheduling user deletion", func() {
+						BeforeEach(func() {
+							context.UserStoreSessionImpl.ScheduleUserDeletionOutputs = []error{nil}
+						})
 
-		WithDestroyingProfile := func(flags *TestFlags) func() {
-			return func() {
-				AfterEach(func() {
-					Expect(context.ProfileStoreSessionImpl.DestroyProfileByIDInputs).To(Equal([]string{targetProfileID}))
-				})
+						AfterEach(func() {
+							Expect(context.SessionStoreSessionImpl.DestroySessionsForUserByIDInputs).To(Equal([]string{targetUserID}))
+						})
 
-				Context("with destroying profile", func() {
-					BeforeEach(func() {
-						context.ProfileStoreSessionImpl.DestroyProfileByIDOutputs = []error{nil}
+						Context("with destroying sessions", func() {
+							BeforeEach(func() {
+								context.SessionStoreSessionImpl.DestroySessionsForUserByIDOutputs = []error{nil}
+							})
+
+							AfterEach(func() {
+								Expect(context.DataServicesClientImpl.LockDataForUserByIDInputs).To(Equal([]LockDataForUserByIDInput{{context, targetUserID}}))
+							})
+
+							It("is successful", func() {
+								context.DataServicesClientImpl.LockDataForUserByIDOutputs = []error{nil}
+								v1.UsersDelete(context)
+								deletion := v1.NewDeletion(context.TaskStoreSessionImpl.CreateTaskInputs[0])
+								Expect(deletion.ScheduledTime).To(Equal(context.UserStoreSessionImpl.ScheduleUserDeletionInputs[0].deletionScheduledTime))
+								Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusAccepted, deletion}}))
+							})
+
+							It("responds with failure if locking data returns error", func() {
+								err := errors.New("test-error-locking-data")
+								context.DataServicesClientImpl.LockDataForUserByIDOutputs = []error{err}
+								v1.UsersDelete(context)
+								Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to lock data for user by id", []interface{}{err}}}))
+							})
+						})
+
+						It("responds with failure if destroying sessions returns error", func() {
+							err := errors.New("test-error-destroying-sessions")
+							context.SessionStoreSessionImpl.DestroySessionsForUserByIDOutputs = []error{err}
+							v1.UsersDelete(context)
+							Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to destroy sessions for user by id", []interface{}{err}}}))
+						})
 					})
 
-					WithDestroyingUser(flags)()
+					It("responds with failure if scheduling user deletion returns error", func() {
+						err := errors.New("test-error-scheduling-user-deletion")
+						context.UserStoreSessionImpl.ScheduleUserDeletionOutputs = []error{err}
+						v1.UsersDelete(context)
+						Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to schedule user deletion", []interface{}{err}}}))
+					})
 				})
 
-				It("responds with failure if it returns error", func() {
-					err := errors.New("test-error-destroying-profile")
-					context.ProfileStoreSessionImpl.DestroyProfileByIDOutputs = []error{err}
+				It("responds with failure if creating task returns error", func() {
+					err := errors.New("test-error-creating-task")
+					context.TaskStoreSessionImpl.CreateTaskOutputs = []error{err}
 					v1.UsersDelete(context)
-					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to destroy profile by id", []interface{}{err}}}))
+					Expect(context.UserStoreSessionImpl.ScheduleUserDeletionInputs).To(BeEmpty())
+					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to create task", []interface{}{err}}}))
 				})
 			}
 		}
 
-		WithDeletingMessagesFromUser := func(flags *TestFlags) func() {
+		WithCreatingTask := func(flags *TestFlags) func() {
 			return func() {
+				if flags.IsSet("scheduled") {
+					WithSchedulingDeletion(flags)()
+					return
+				}
+
 				AfterEach(func() {
-					messageUser := &messageStore.User{
-						ID: targetUserID,
-					}
-					if flags.IsSet("with-full-name") {
-						messageUser.FullName = targetFullName
-					}
-					Expect(context.MessageStoreSessionImpl.DeleteMessagesFromUserInputs).To(Equal([]*messageStore.User{messageUser}))
+					Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(Equal([]*taskStore.Task{worker.NewDeleteUserTask(targetUserID)}))
 				})
 
-				Context("with deleting messages from user", func() {
-					BeforeEach(func() {
-						context.MessageStoreSessionImpl.DeleteMessagesFromUserOutputs = []error{nil}
-					})
-
-					if flags.IsSet("with-profile-id") {
-						WithDestroyingProfile(flags)()
-					} else {
-						WithDestroyingUser(flags)()
-					}
+				It("is successful", func() {
+					context.TaskStoreSessionImpl.CreateTaskOutputs = []error{nil}
+					v1.UsersDelete(context)
+					Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusAccepted, v1.NewDeletion(context.TaskStoreSessionImpl.CreateTaskInputs[0])}}))
 				})
 
 				It("responds with failure if it returns error", func() {
-					err := errors.New("test-error-deleting-messages-from-user")
-					context.MessageStoreSessionImpl.DeleteMessagesFromUserOutputs = []error{err}
+					err := errors.New("test-error-creating-task")
+					context.TaskStoreSessionImpl.CreateTaskOutputs = []error{err}
 					v1.UsersDelete(context)
-					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to delete messages from user", []interface{}{err}}}))
+					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to create task", []interface{}{err}}}))
 				})
 			}
 		}
 
-		WithDestroyingMessages := func(flags *TestFlags) func() {
+		WithGettingTask := func(flags *TestFlags) func() {
 			return func() {
-				AfterEach(func() {
-					Expect(context.MessageStoreSessionImpl.DestroyMessagesForUserByIDInputs).To(Equal([]string{targetUserID}))
-				})
+				var task *taskStore.Task
 
-				Context("with destroying messages", func() {
-					BeforeEach(func() {
-						context.MessageStoreSessionImpl.DestroyMessagesForUserByIDOutputs = []error{nil}
-					})
-
-					WithDeletingMessagesFromUser(flags)()
-				})
-
-				It("responds with failure if it returns error", func() {
-					err := errors.New("test-error-destroying-messages")
-					context.MessageStoreSessionImpl.DestroyMessagesForUserByIDOutputs = []error{err}
-					v1.UsersDelete(context)
-					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to destroy messages for user by id", []interface{}{err}}}))
+				BeforeEach(func() {
+					task = worker.NewDeleteUserTask(targetUserID)
+					task.ID = app.NewID()
 				})
-			}
-		}
 
-		WithDestroyingData := func(flags *TestFlags) func() {
-			return func() {
 				AfterEach(func() {
-					Expect(context.DataServicesClientImpl.DestroyDataForUserByIDInputs).To(Equal([]DestroyDataForUserByIDInput{{context, targetUserID}}))
+					Expect(context.TaskStoreSessionImpl.GetTaskForUserByIDInputs).To(Equal([]testTaskStore.GetTaskForUserByIDInput{{UserID: targetUserID, TaskType: worker.TaskTypeDeleteUser}}))
 				})
 
-				Context("with destroying data", func() {
+				Context("with no existing task", func() {
 					BeforeEach(func() {
-						context.DataServicesClientImpl.DestroyDataForUserByIDOutputs = []error{nil}
+						context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: nil, Error: nil}}
 					})
 
-					WithDestroyingMessages(flags)()
+					WithCreatingTask(flags)()
 				})
 
-				It("responds with failure if it returns error", func() {
-					err := errors.New("test-error-destroying-data")
-					context.DataServicesClientImpl.DestroyDataForUserByIDOutputs = []error{err}
-					v1.UsersDelete(context)
-					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to destroy data for user by id", []interface{}{err}}}))
-				})
-			}
-		}
+				Context("with existing completed task", func() {
+					BeforeEach(func() {
+						task.State = taskStore.TaskStateCompleted
+						context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: task, Error: nil}}
+					})
 
-		WithDestroyingNotifications := func(flags *TestFlags) func() {
-			return func() {
-				AfterEach(func() {
-					Expect(context.NotificationStoreSessionImpl.DestroyNotificationsForUserByIDInputs).To(Equal([]string{targetUserID}))
+					WithCreatingTask(flags)()
 				})
 
-				Context("with destroying notifications", func() {
+				Context("with existing scheduled task", func() {
 					BeforeEach(func() {
-						context.NotificationStoreSessionImpl.DestroyNotificationsForUserByIDOutputs = []error{nil}
+						task.AvailableTime = time.Now().Add(worker.DeleteUserGracePeriod).UTC().Format(time.RFC3339)
+						context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: task, Error: nil}}
 					})
 
-					WithDestroyingData(flags)()
-				})
+					if flags.IsSet("scheduled") {
+						It("responds with existing scheduled task", func() {
+							v1.UsersDelete(context)
+							Expect(context.TaskStoreSessionImpl.CancelTaskInputs).To(BeEmpty())
+							Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusAccepted, v1.NewDeletion(task)}}))
+						})
+					} else {
+						AfterEach(func() {
+							Expect(context.TaskStoreSessionImpl.CancelTaskInputs).To(Equal([]*taskStore.Task{task}))
+						})
 
-				It("responds with failure if it returns error", func() {
-					err := errors.New("test-error-destroying-notifications")
-					context.NotificationStoreSessionImpl.DestroyNotificationsForUserByIDOutputs = []error{err}
-					v1.UsersDelete(context)
-					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to destroy notifications for user by id", []interface{}{err}}}))
-				})
-			}
-		}
+						Context("with canceling task", func() {
+							BeforeEach(func() {
+								context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{{Canceled: true, Error: nil}}
+							})
 
-		WithDestroyingPermissions := func(flags *TestFlags) func() {
-			return func() {
-				AfterEach(func() {
-					Expect(context.PermissionStoreSessionImpl.DestroyPermissionsForUserByIDInputs).To(Equal([]string{targetUserID}))
-				})
+							WithCreatingTask(flags)()
+						})
 
-				Context("with destroying permissions", func() {
-					BeforeEach(func() {
-						context.PermissionStoreSessionImpl.DestroyPermissionsForUserByIDOutputs = []error{nil}
-					})
+						It("responds with existing task if it is claimed before it is canceled", func() {
+							context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{{Canceled: false, Error: nil}}
+							v1.UsersDelete(context)
+							Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(BeEmpty())
+							Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusAccepted, v1.NewDeletion(task)}}))
+						})
 
-					WithDestroyingNotifications(flags)()
+						It("responds with failure if canceling task returns error", func() {
+							err := errors.New("test-error-canceling-task")
+							context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{{Canceled: false, Error: err}}
+							v1.UsersDelete(context)
+							Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(BeEmpty())
+							Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to cancel task", []interface{}{err}}}))
+						})
+					}
 				})
 
-				It("responds with failure if it returns error", func() {
-					err := errors.New("test-error-destroying-permissions")
-					context.PermissionStoreSessionImpl.DestroyPermissionsForUserByIDOutputs = []error{err}
+				It("responds with existing active task", func() {
+					task.State = taskStore.TaskStateRunning
+					context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: task, Error: nil}}
 					v1.UsersDelete(context)
-					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to destroy permissions for user by id", []interface{}{err}}}))
+					Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusAccepted, v1.NewDeletion(task)}}))
 				})
-			}
-		}
 
-		WithDestroyingSessions := func(flags *TestFlags) func() {
-			return func() {
-				AfterEach(func() {
-					Expect(context.SessionStoreSessionImpl.DestroySessionsForUserByIDInputs).To(Equal([]string{targetUserID}))
-				})
-
-				Context("with destroying sessions", func() {
+				Context("with existing failed task", func() {
 					BeforeEach(func() {
-						context.SessionStoreSessionImpl.DestroySessionsForUserByIDOutputs = []error{nil}
+						task.State = taskStore.TaskStateFailed
+						context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: task, Error: nil}}
 					})
 
-					WithDestroyingPermissions(flags)()
-				})
-
-				It("responds with failure if it returns error", func() {
-					err := errors.New("test-error-destroying-sessions")
-					context.SessionStoreSessionImpl.DestroySessionsForUserByIDOutputs = []error{err}
-					v1.UsersDelete(context)
-					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to destroy sessions for user by id", []interface{}{err}}}))
-				})
-			}
-		}
-
-		WithDeletingUser := func(flags *TestFlags) func() {
-			return func() {
-				AfterEach(func() {
-					Expect(context.UserStoreSessionImpl.DeleteUserInputs).To(Equal([]*user.User{targetUser}))
-				})
+					AfterEach(func() {
+						Expect(context.TaskStoreSessionImpl.ResumeTaskInputs).To(Equal([]*taskStore.Task{task}))
+					})
 
-				Context("with deleting user", func() {
-					BeforeEach(func() {
-						context.UserStoreSessionImpl.DeleteUserOutputs = []error{nil}
+					It("resumes the task", func() {
+						context.TaskStoreSessionImpl.ResumeTaskOutputs = []error{nil}
+						v1.UsersDelete(context)
+						Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusAccepted, v1.NewDeletion(task)}}))
 					})
 
-					WithDestroyingSessions(flags)()
+					It("responds with failure if it returns error", func() {
+						err := errors.New("test-error-resuming-task")
+						context.TaskStoreSessionImpl.ResumeTaskOutputs = []error{err}
+						v1.UsersDelete(context)
+						Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to resume task", []interface{}{err}}}))
+					})
 				})
 
 				It("responds with failure if it returns error", func() {
-					err := errors.New("test-error-deleting-user")
-					context.UserStoreSessionImpl.DeleteUserOutputs = []error{err}
+					err := errors.New("test-error-getting-task")
+					context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: nil, Error: err}}
 					v1.UsersDelete(context)
-					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to delete user", []interface{}{err}}}))
+					Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get task for user by id", []interface{}{err}}}))
 				})
 			}
 		}
@@ -276,7 +266,7 @@ var _ = Describe("UsersDelete", func() {
 						context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
 					})
 
-					WithDeletingUser(flags)()
+					WithGettingTask(flags)()
 				})
 
 				Context("with recording metric returning an error", func() {
@@ -284,57 +274,7 @@ var _ = Describe("UsersDelete", func() {
 						context.MetricServicesClientImpl.RecordMetricOutputs = []error{errors.New("test-error-recording-metric")}
 					})
 
-					WithDeletingUser(flags)()
-				})
-			}
-		}
-
-		WithGetProfile := func(flags *TestFlags) func() {
-			return func() {
-				Context("with profile id", func() {
-					AfterEach(func() {
-						Expect(context.ProfileStoreSessionImpl.GetProfileByIDInputs).To(Equal([]string{targetProfileID}))
-					})
-
-					Context("with existing profile", func() {
-						BeforeEach(func() {
-							context.ProfileStoreSessionImpl.GetProfileByIDOutputs = []GetProfileByIDOutput{{targetProfile, nil}}
-						})
-
-						WithRecordingMetric(flags.Set("with-profile-id", "with-full-name"))()
-					})
-
-					Context("with existing profile without full name", func() {
-						BeforeEach(func() {
-							targetProfile.FullName = nil
-							context.ProfileStoreSessionImpl.GetProfileByIDOutputs = []GetProfileByIDOutput{{targetProfile, nil}}
-						})
-
-						WithRecordingMetric(flags.Set("with-profile-id"))()
-					})
-
-					Context("with no existing profile", func() {
-						BeforeEach(func() {
-							context.ProfileStoreSessionImpl.GetProfileByIDOutputs = []GetProfileByIDOutput{{nil, nil}}
-						})
-
-						WithRecordingMetric(flags.Set("with-profile-id"))()
-					})
-
-					It("responds with failure if it returns error", func() {
-						err := errors.New("test-error-getting-profile")
-						context.ProfileStoreSessionImpl.GetProfileByIDOutputs = []GetProfileByIDOutput{{nil, err}}
-						v1.UsersDelete(context)
-						Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get profile by id", []interface{}{err}}}))
-					})
-				})
-
-				Context("with no profile id", func() {
-					BeforeEach(func() {
-						targetUser.ProfileID = nil
-					})
-
-					WithRecordingMetric(flags)()
+					WithGettingTask(flags)()
 				})
 			}
 		}
@@ -350,7 +290,7 @@ var _ = Describe("UsersDelete", func() {
 						context.UserStoreSessionImpl.PasswordMatchesOutputs = []bool{true}
 					})
 
-					WithGetProfile(flags)()
+					WithRecordingMetric(flags)()
 				})
 
 				It("responds with failure if it returns false", func() {
@@ -367,7 +307,7 @@ var _ = Describe("UsersDelete", func() {
 					if flags.IsSet("with-password") {
 						WithMatchingPassword(flags)()
 					} else {
-						WithGetProfile(flags)()
+						WithRecordingMetric(flags)()
 					}
 				})
 
@@ -527,12 +467,32 @@ var _ = Describe("UsersDelete", func() {
 			}
 		}
 
+		WithScheduledParameter := func(flags *TestFlags) func() {
+			return func() {
+				Context("with scheduled parameter", func() {
+					BeforeEach(func() {
+						context.RequestImpl.URL.RawQuery = "scheduled=true"
+					})
+
+					WithRequestParameter(flags.Set("scheduled"))()
+				})
+
+				It("responds with failure if the scheduled parameter is not a boolean", func() {
+					context.RequestImpl.PathParams["userid"] = targetUserID
+					context.RequestImpl.URL.RawQuery = "scheduled=invalid"
+					v1.UsersDelete(context)
+					Expect(context.RespondWithStatusAndErrorsInputs).To(Equal([]RespondWithStatusAndErrorsInput{{http.StatusBadRequest, []*service.Error{service.ErrorTypeNotBoolean("invalid").WithSourceParameter("scheduled")}}}))
+				})
+			}
+		}
+
 		Context("with valid test data", func() {
 			AfterEach(func() {
 				Expect(context.ValidateTest()).To(BeTrue())
 			})
 
 			WithRequestParameter(NewTestFlags())()
+			WithScheduledParameter(NewTestFlags())()
 		})
 	})
 })
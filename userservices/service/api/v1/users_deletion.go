@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"time"
+
+	commonService "github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	"github.com/tidepool-org/platform/userservices/client"
+	"github.com/tidepool-org/platform/userservices/service"
+)
+
+type Deletion struct {
+	ID            string                `json:"id"`
+	UserID        string                `json:"userId"`
+	State         string                `json:"state"`
+	Attempts      int                   `json:"attempts"`
+	Error         string                `json:"error,omitempty"`
+	Steps         []*taskStore.TaskStep `json:"steps"`
+	ScheduledTime string                `json:"scheduledTime,omitempty"`
+	CreatedTime   string                `json:"createdTime,omitempty"`
+	CompletedTime string                `json:"completedTime,omitempty"`
+}
+
+func NewDeletion(task *taskStore.Task) *Deletion {
+	deletion := &Deletion{
+		ID:            task.ID,
+		UserID:        task.UserID,
+		State:         task.State,
+		Attempts:      task.Attempts,
+		Error:         task.Error,
+		Steps:         task.Steps,
+		CreatedTime:   task.CreatedTime,
+		CompletedTime: task.CompletedTime,
+	}
+	if task.State == taskStore.TaskStatePending {
+		deletion.ScheduledTime = task.AvailableTime
+	}
+	return deletion
+}
+
+// A scheduled deletion is a task that is pending, not yet attempted, and not yet available
+func isScheduledDeletion(task *taskStore.Task) bool {
+	if task.State != taskStore.TaskStatePending || task.Attempts > 0 {
+		return false
+	}
+
+	availableTime, err := time.Parse(time.RFC3339, task.AvailableTime)
+	return err == nil && availableTime.After(time.Now())
+}
+
+func authorizeUsersDeletion(serviceContext service.Context, targetUserID string) bool {
+	if serviceContext.AuthenticationDetails().IsServer() {
+		return true
+	}
+
+	authenticatedUserID := serviceContext.AuthenticationDetails().UserID()
+
+	permissions, err := serviceContext.UserServicesClient().GetUserPermissions(serviceContext, authenticatedUserID, targetUserID)
+	if err != nil {
+		if client.IsUnauthorizedError(err) {
+			serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		} else {
+			serviceContext.RespondWithInternalServerFailure("Unable to get user permissions", err)
+		}
+		return false
+	}
+	if _, ok := permissions[client.OwnerPermission]; !ok {
+		if _, ok = permissions[client.CustodianPermission]; !ok {
+			serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+			return false
+		}
+	}
+
+	return true
+}
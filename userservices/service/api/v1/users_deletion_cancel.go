@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"net/http"
+
+	taskStore "github.com/tidepool-org/platform/task/store"
+	"github.com/tidepool-org/platform/userservices/service"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+func UsersDeletionCancel(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !authorizeUsersDeletion(serviceContext, targetUserID) {
+		return
+	}
+
+	task, err := serviceContext.TaskStoreSession().GetTaskForUserByID(targetUserID, worker.TaskTypeDeleteUser)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get task for user by id", err)
+		return
+	}
+	if task == nil {
+		serviceContext.RespondWithError(ErrorUserDeletionNotFound(targetUserID))
+		return
+	}
+
+	// The task is canceled first, so the deletion never runs unscheduled or unlocked, and a retry of an already
+	// canceled task only retries the later steps, should any of them have failed
+	if task.State != taskStore.TaskStateCanceled {
+		if task.State != taskStore.TaskStatePending || task.Attempts > 0 {
+			serviceContext.RespondWithError(ErrorUserDeletionNotCancelable(targetUserID))
+			return
+		}
+
+		var canceled bool
+		if canceled, err = serviceContext.TaskStoreSession().CancelTask(task); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to cancel task", err)
+			return
+		}
+		if !canceled {
+			serviceContext.RespondWithError(ErrorUserDeletionNotCancelable(targetUserID))
+			return
+		}
+	}
+
+	targetUser, err := serviceContext.UserStoreSession().GetUserByID(targetUserID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get user by id", err)
+		return
+	}
+	if targetUser != nil && targetUser.DeletionScheduledTime != "" {
+		if err = serviceContext.UserStoreSession().UnscheduleUserDeletion(targetUser); err != nil {
+			serviceContext.RespondWithInternalServerFailure("Unable to unschedule user deletion", err)
+			return
+		}
+	}
+
+	if err = serviceContext.DataServicesClient().UnlockDataForUserByID(serviceContext, targetUserID); err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to unlock data for user by id", err)
+		return
+	}
+
+	if err = serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_deletion_cancel", map[string]string{"userId": targetUserID}); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, NewDeletion(task))
+}
@@ -0,0 +1,245 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
+	"github.com/tidepool-org/platform/user"
+	"github.com/tidepool-org/platform/userservices/client"
+	"github.com/tidepool-org/platform/userservices/service/api/v1"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+var _ = Describe("UsersDeletionCancel", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var targetUser *user.User
+		var task *taskStore.Task
+		var context *TestContext
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			targetUser = &user.User{ID: targetUserID, DeletionScheduledTime: "2016-10-01T12:00:00Z"}
+			task = worker.NewDeleteUserTask(targetUserID)
+			task.ID = app.NewID()
+			task.AvailableTime = targetUser.DeletionScheduledTime
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"root": client.Permission{}}, nil}}
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: task, Error: nil}}
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{{Canceled: true, Error: nil}}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{targetUser, nil}}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{nil}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{nil}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if authenticated as owner", func() {
+			v1.UsersDeletionCancel(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.TaskStoreSessionImpl.GetTaskForUserByIDInputs).To(Equal([]testTaskStore.GetTaskForUserByIDInput{{UserID: targetUserID, TaskType: worker.TaskTypeDeleteUser}}))
+			Expect(context.TaskStoreSessionImpl.CancelTaskInputs).To(Equal([]*taskStore.Task{task}))
+			Expect(context.UserStoreSessionImpl.GetUserByIDInputs).To(Equal([]string{targetUserID}))
+			Expect(context.UserStoreSessionImpl.UnscheduleUserDeletionInputs).To(Equal([]*user.User{targetUser}))
+			Expect(context.DataServicesClientImpl.UnlockDataForUserByIDInputs).To(Equal([]UnlockDataForUserByIDInput{{context, targetUserID}}))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "users_deletion_cancel", []map[string]string{{"userId": targetUserID}}}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, v1.NewDeletion(task)}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as custodian", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"custodian": client.Permission{}}, nil}}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds and retries unscheduling and unlocking if the task is already canceled", func() {
+			task.State = taskStore.TaskStateCanceled
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.TaskStoreSessionImpl.CancelTaskInputs).To(BeEmpty())
+			Expect(context.UserStoreSessionImpl.UnscheduleUserDeletionInputs).To(Equal([]*user.User{targetUser}))
+			Expect(context.DataServicesClientImpl.UnlockDataForUserByIDInputs).To(Equal([]UnlockDataForUserByIDInput{{context, targetUserID}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, v1.NewDeletion(task)}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds without unscheduling if the user deletion is not scheduled", func() {
+			targetUser.DeletionScheduledTime = ""
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.UserStoreSessionImpl.UnscheduleUserDeletionInputs).To(BeEmpty())
+			Expect(context.DataServicesClientImpl.UnlockDataForUserByIDInputs).To(HaveLen(1))
+			Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds without unscheduling if the user is not found", func() {
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{nil, nil}}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{}
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if neither owner nor custodian", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"view": client.Permission{}}, nil}}
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{}
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if get task returns error", func() {
+			err := errors.New("other")
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: nil, Error: err}}
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get task for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the deletion is not found", func() {
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: nil, Error: nil}}
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDeletionNotFound(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the deletion is running", func() {
+			task.State = taskStore.TaskStateRunning
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDeletionNotCancelable(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the deletion was attempted", func() {
+			task.Attempts = 1
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDeletionNotCancelable(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if cancel task returns error", func() {
+			err := errors.New("other")
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{{Canceled: false, Error: err}}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to cancel task", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the task is claimed before it is canceled", func() {
+			context.TaskStoreSessionImpl.CancelTaskOutputs = []testTaskStore.CancelTaskOutput{{Canceled: false, Error: nil}}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.TaskStoreSessionImpl.CancelTaskInputs).To(Equal([]*taskStore.Task{task}))
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDeletionNotCancelable(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if get user returns error", func() {
+			err := errors.New("other")
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{nil, err}}
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if unschedule user deletion returns error", func() {
+			err := errors.New("other")
+			context.UserStoreSessionImpl.UnscheduleUserDeletionOutputs = []error{err}
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to unschedule user deletion", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if unlock data returns error", func() {
+			err := errors.New("other")
+			context.DataServicesClientImpl.UnlockDataForUserByIDOutputs = []error{err}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to unlock data for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("logs and ignores if record metric returns error", func() {
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{errors.New("other")}
+			v1.UsersDeletionCancel(context)
+			Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
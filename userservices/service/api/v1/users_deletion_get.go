@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/userservices/service"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+func UsersDeletionGet(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !authorizeUsersDeletion(serviceContext, targetUserID) {
+		return
+	}
+
+	task, err := serviceContext.TaskStoreSession().GetTaskForUserByID(targetUserID, worker.TaskTypeDeleteUser)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get task for user by id", err)
+		return
+	}
+	if task == nil {
+		serviceContext.RespondWithError(ErrorUserDeletionNotFound(targetUserID))
+		return
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, NewDeletion(task))
+}
@@ -0,0 +1,89 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
+	"github.com/tidepool-org/platform/userservices/client"
+	"github.com/tidepool-org/platform/userservices/service/api/v1"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+var _ = Describe("UsersDeletionGet", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var task *taskStore.Task
+		var context *TestContext
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			task = worker.NewDeleteUserTask(targetUserID)
+			task.ID = app.NewID()
+			task.Steps[0].State = taskStore.TaskStateCompleted
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: task, Error: nil}}
+		})
+
+		It("succeeds if authenticated as server", func() {
+			v1.UsersDeletionGet(context)
+			Expect(context.TaskStoreSessionImpl.GetTaskForUserByIDInputs).To(Equal([]testTaskStore.GetTaskForUserByIDInput{{UserID: targetUserID, TaskType: worker.TaskTypeDeleteUser}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, v1.NewDeletion(task)}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as custodian", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"custodian": client.Permission{}}, nil}}
+			v1.UsersDeletionGet(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{}
+			v1.UsersDeletionGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if neither owner nor custodian", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"view": client.Permission{}}, nil}}
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{}
+			v1.UsersDeletionGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the deletion is not found", func() {
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: nil, Error: nil}}
+			v1.UsersDeletionGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserDeletionNotFound(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if get task returns error", func() {
+			err := errors.New("other")
+			context.TaskStoreSessionImpl.GetTaskForUserByIDOutputs = []testTaskStore.GetTaskForUserByIDOutput{{Task: nil, Error: err}}
+			v1.UsersDeletionGet(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get task for user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"fmt"
+
+	commonService "github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	"github.com/tidepool-org/platform/userservices/client"
+	"github.com/tidepool-org/platform/userservices/service"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+type Export struct {
+	ID            string `json:"id"`
+	UserID        string `json:"userId"`
+	State         string `json:"state"`
+	Error         string `json:"error,omitempty"`
+	CreatedTime   string `json:"createdTime,omitempty"`
+	CompletedTime string `json:"completedTime,omitempty"`
+	DownloadURL   string `json:"downloadUrl,omitempty"`
+}
+
+func NewExport(task *taskStore.Task) *Export {
+	export := &Export{
+		ID:            task.ID,
+		UserID:        task.UserID,
+		State:         task.State,
+		Error:         task.Error,
+		CreatedTime:   task.CreatedTime,
+		CompletedTime: task.CompletedTime,
+	}
+	if task.State == taskStore.TaskStateCompleted {
+		export.DownloadURL = fmt.Sprintf("/userservices/v1/users/%s/exports/%s/download", task.UserID, task.ID)
+	}
+	return export
+}
+
+func authorizeUsersExport(serviceContext service.Context, targetUserID string) bool {
+	if serviceContext.AuthenticationDetails().IsServer() {
+		return true
+	}
+
+	authenticatedUserID := serviceContext.AuthenticationDetails().UserID()
+
+	permissions, err := serviceContext.UserServicesClient().GetUserPermissions(serviceContext, authenticatedUserID, targetUserID)
+	if err != nil {
+		if client.IsUnauthorizedError(err) {
+			serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		} else {
+			serviceContext.RespondWithInternalServerFailure("Unable to get user permissions", err)
+		}
+		return false
+	}
+	if _, ok := permissions[client.OwnerPermission]; !ok {
+		serviceContext.RespondWithError(commonService.ErrorUnauthorized())
+		return false
+	}
+
+	return true
+}
+
+func getUsersExportTask(serviceContext service.Context, targetUserID string) *taskStore.Task {
+	exportID := serviceContext.Request().PathParam("exportid")
+	if exportID == "" {
+		serviceContext.RespondWithError(ErrorExportIDMissing())
+		return nil
+	}
+
+	task, err := serviceContext.TaskStoreSession().GetTaskByID(exportID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get task by id", err)
+		return nil
+	}
+	if task == nil || task.Type != worker.TaskTypeExportUser || task.UserID != targetUserID {
+		serviceContext.RespondWithError(ErrorExportIDNotFound(exportID))
+		return nil
+	}
+
+	return task
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"net/http"
+
+	taskStore "github.com/tidepool-org/platform/task/store"
+	"github.com/tidepool-org/platform/userservices/service"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+func UsersExportCreate(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !authorizeUsersExport(serviceContext, targetUserID) {
+		return
+	}
+
+	targetUser, err := serviceContext.UserStoreSession().GetUserByID(targetUserID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to get user by id", err)
+		return
+	}
+	if targetUser == nil {
+		serviceContext.RespondWithError(ErrorUserIDNotFound(targetUserID))
+		return
+	}
+
+	task := taskStore.NewTask(worker.TaskTypeExportUser)
+	task.UserID = targetUserID
+	if err = serviceContext.TaskStoreSession().CreateTask(task); err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to create task", err)
+		return
+	}
+
+	if err = serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_export_create", map[string]string{"userId": targetUserID}); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusAccepted, NewExport(task))
+}
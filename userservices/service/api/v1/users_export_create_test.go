@@ -0,0 +1,133 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	"github.com/tidepool-org/platform/user"
+	"github.com/tidepool-org/platform/userservices/client"
+	"github.com/tidepool-org/platform/userservices/service/api/v1"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+var _ = Describe("UsersExportCreate", func() {
+	Context("Unit Tests", func() {
+		var authenticatedUserID string
+		var targetUserID string
+		var context *TestContext
+
+		BeforeEach(func() {
+			authenticatedUserID = app.NewID()
+			targetUserID = app.NewID()
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{false}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{authenticatedUserID}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"root": client.Permission{}}, nil}}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{&user.User{ID: targetUserID}, nil}}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{nil}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if authenticated as owner", func() {
+			v1.UsersExportCreate(context)
+			Expect(context.UserServicesClientImpl.GetUserPermissionsInputs).To(Equal([]GetUserPermissionsInput{{context, authenticatedUserID, targetUserID}}))
+			Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(HaveLen(1))
+			task := context.TaskStoreSessionImpl.CreateTaskInputs[0]
+			Expect(task.Type).To(Equal(worker.TaskTypeExportUser))
+			Expect(task.UserID).To(Equal(targetUserID))
+			Expect(task.State).To(Equal(taskStore.TaskStatePending))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "users_export_create", []map[string]string{{"userId": targetUserID}}}}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusAccepted, v1.NewExport(task)}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds if authenticated as server", func() {
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			v1.UsersExportCreate(context)
+			Expect(context.TaskStoreSessionImpl.CreateTaskInputs).To(HaveLen(1))
+			Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.AuthenticationDetailsImpl.UserIDOutputs = []string{}
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportCreate(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if not owner", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{client.Permissions{"custodian": client.Permission{}}, nil}}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportCreate(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if get user permissions returns unauthorized error", func() {
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, client.NewUnauthorizedError()}}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportCreate(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{service.ErrorUnauthorized()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if get user permissions returns other error", func() {
+			err := errors.New("other")
+			context.UserServicesClientImpl.GetUserPermissionsOutputs = []GetUserPermissionsOutput{{nil, err}}
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportCreate(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get user permissions", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the user is not found", func() {
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{nil, nil}}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportCreate(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDNotFound(targetUserID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if get user returns error", func() {
+			err := errors.New("other")
+			context.UserStoreSessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{nil, err}}
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportCreate(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get user by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if task store session create task returns error", func() {
+			err := errors.New("other")
+			context.TaskStoreSessionImpl.CreateTaskOutputs = []error{err}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportCreate(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to create task", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
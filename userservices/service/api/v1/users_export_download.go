@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	taskStore "github.com/tidepool-org/platform/task/store"
+	"github.com/tidepool-org/platform/userservices/service"
+)
+
+const MediaTypeZip = "application/zip"
+
+func UsersExportDownload(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !authorizeUsersExport(serviceContext, targetUserID) {
+		return
+	}
+
+	task := getUsersExportTask(serviceContext, targetUserID)
+	if task == nil {
+		return
+	}
+
+	if task.State != taskStore.TaskStateCompleted {
+		serviceContext.RespondWithError(ErrorExportNotCompleted(task.ID))
+		return
+	}
+
+	archive, err := serviceContext.ExportStore().OpenArchive(task.ID)
+	if err != nil {
+		serviceContext.RespondWithInternalServerFailure("Unable to open archive", err)
+		return
+	}
+	if archive == nil {
+		serviceContext.RespondWithError(ErrorExportIDNotFound(task.ID))
+		return
+	}
+
+	if err = serviceContext.MetricServicesClient().RecordMetric(serviceContext, "users_export_download", map[string]string{"userId": targetUserID}); err != nil {
+		serviceContext.Logger().WithError(err).Error("Unable to record metric")
+	}
+
+	serviceContext.RespondWithStatusAndAttachment(http.StatusOK, MediaTypeZip, fmt.Sprintf("export-%s.zip", task.ID), archive)
+}
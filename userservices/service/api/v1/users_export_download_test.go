@@ -0,0 +1,85 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
+	"github.com/tidepool-org/platform/userservices/service/api/v1"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+var _ = Describe("UsersExportDownload", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var task *taskStore.Task
+		var context *TestContext
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			task = taskStore.NewTask(worker.TaskTypeExportUser)
+			task.ID = app.NewID()
+			task.UserID = targetUserID
+			task.State = taskStore.TaskStateCompleted
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.RequestImpl.PathParams["exportid"] = task.ID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.TaskStoreSessionImpl.GetTaskByIDOutputs = []testTaskStore.GetTaskByIDOutput{{Task: task, Error: nil}}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{nil}
+		})
+
+		It("succeeds if the export is completed", func() {
+			archive := ioutil.NopCloser(strings.NewReader("test-archive"))
+			context.ExportStoreImpl.OpenArchiveOutputs = []OpenArchiveOutput{{archive, nil}}
+			v1.UsersExportDownload(context)
+			Expect(context.ExportStoreImpl.OpenArchiveInputs).To(Equal([]string{task.ID}))
+			Expect(context.MetricServicesClientImpl.RecordMetricInputs).To(Equal([]RecordMetricInput{{context, "users_export_download", []map[string]string{{"userId": targetUserID}}}}))
+			Expect(context.RespondWithStatusAndAttachmentInputs).To(Equal([]RespondWithStatusAndAttachmentInput{{http.StatusOK, "application/zip", "export-" + task.ID + ".zip", archive}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the export is not completed", func() {
+			task.State = taskStore.TaskStateRunning
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportDownload(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorExportNotCompleted(task.ID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the archive is not found", func() {
+			context.ExportStoreImpl.OpenArchiveOutputs = []OpenArchiveOutput{{nil, nil}}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportDownload(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorExportIDNotFound(task.ID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if open archive returns error", func() {
+			err := errors.New("other")
+			context.ExportStoreImpl.OpenArchiveOutputs = []OpenArchiveOutput{{nil, err}}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportDownload(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to open archive", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if user id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "userid")
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{}
+			context.TaskStoreSessionImpl.GetTaskByIDOutputs = []testTaskStore.GetTaskByIDOutput{}
+			context.MetricServicesClientImpl.RecordMetricOutputs = []error{}
+			v1.UsersExportDownload(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorUserIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
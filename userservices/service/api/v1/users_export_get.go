@@ -0,0 +1,26 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/tidepool-org/platform/userservices/service"
+)
+
+func UsersExportGet(serviceContext service.Context) {
+	targetUserID := serviceContext.Request().PathParam("userid")
+	if targetUserID == "" {
+		serviceContext.RespondWithError(ErrorUserIDMissing())
+		return
+	}
+
+	if !authorizeUsersExport(serviceContext, targetUserID) {
+		return
+	}
+
+	task := getUsersExportTask(serviceContext, targetUserID)
+	if task == nil {
+		return
+	}
+
+	serviceContext.RespondWithStatusAndData(http.StatusOK, NewExport(task))
+}
@@ -0,0 +1,88 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+	"net/http"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
+	"github.com/tidepool-org/platform/userservices/service/api/v1"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+var _ = Describe("UsersExportGet", func() {
+	Context("Unit Tests", func() {
+		var targetUserID string
+		var task *taskStore.Task
+		var context *TestContext
+
+		BeforeEach(func() {
+			targetUserID = app.NewID()
+			task = taskStore.NewTask(worker.TaskTypeExportUser)
+			task.ID = app.NewID()
+			task.UserID = targetUserID
+			context = NewTestContext()
+			context.RequestImpl.PathParams["userid"] = targetUserID
+			context.RequestImpl.PathParams["exportid"] = task.ID
+			context.AuthenticationDetailsImpl.IsServerOutputs = []bool{true}
+			context.TaskStoreSessionImpl.GetTaskByIDOutputs = []testTaskStore.GetTaskByIDOutput{{Task: task, Error: nil}}
+		})
+
+		It("succeeds with a pending export without download url", func() {
+			v1.UsersExportGet(context)
+			Expect(context.TaskStoreSessionImpl.GetTaskByIDInputs).To(Equal([]string{task.ID}))
+			Expect(context.RespondWithStatusAndDataInputs).To(Equal([]RespondWithStatusAndDataInput{{http.StatusOK, &v1.Export{ID: task.ID, UserID: targetUserID, State: taskStore.TaskStatePending}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("succeeds with a completed export with download url", func() {
+			task.State = taskStore.TaskStateCompleted
+			v1.UsersExportGet(context)
+			Expect(context.RespondWithStatusAndDataInputs).To(HaveLen(1))
+			Expect(context.RespondWithStatusAndDataInputs[0].data.(*v1.Export).DownloadURL).To(Equal("/userservices/v1/users/" + targetUserID + "/exports/" + task.ID + "/download"))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if export id not provided as a parameter", func() {
+			delete(context.RequestImpl.PathParams, "exportid")
+			context.TaskStoreSessionImpl.GetTaskByIDOutputs = []testTaskStore.GetTaskByIDOutput{}
+			v1.UsersExportGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorExportIDMissing()}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the task is not found", func() {
+			context.TaskStoreSessionImpl.GetTaskByIDOutputs = []testTaskStore.GetTaskByIDOutput{{Task: nil, Error: nil}}
+			v1.UsersExportGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorExportIDNotFound(task.ID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the task is not an export", func() {
+			task.Type = "other"
+			v1.UsersExportGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorExportIDNotFound(task.ID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if the task is for another user", func() {
+			task.UserID = app.NewID()
+			v1.UsersExportGet(context)
+			Expect(context.RespondWithErrorInputs).To(Equal([]*service.Error{v1.ErrorExportIDNotFound(task.ID)}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+
+		It("responds with error if get task returns error", func() {
+			err := errors.New("other")
+			context.TaskStoreSessionImpl.GetTaskByIDOutputs = []testTaskStore.GetTaskByIDOutput{{Task: nil, Error: err}}
+			v1.UsersExportGet(context)
+			Expect(context.RespondWithInternalServerFailureInputs).To(Equal([]RespondWithInternalServerFailureInput{{"Unable to get task by id", []interface{}{err}}}))
+			Expect(context.ValidateTest()).To(BeTrue())
+		})
+	})
+})
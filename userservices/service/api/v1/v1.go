@@ -5,5 +5,10 @@ import "github.com/tidepool-org/platform/userservices/service"
 func Routes() []service.Route {
 	return []service.Route{
 		service.MakeRoute("DELETE", "/v1/users/:userid", Authenticate(UsersDelete)),
+		service.MakeRoute("DELETE", "/v1/users/:userid/deletion", Authenticate(UsersDeletionCancel)),
+		service.MakeRoute("GET", "/v1/users/:userid/deletion", Authenticate(UsersDeletionGet)),
+		service.MakeRoute("POST", "/v1/users/:userid/exports", Authenticate(UsersExportCreate)),
+		service.MakeRoute("GET", "/v1/users/:userid/exports/:exportid", Authenticate(UsersExportGet)),
+		service.MakeRoute("GET", "/v1/users/:userid/exports/:exportid/download", Authenticate(UsersExportDownload)),
 	}
 }
@@ -4,13 +4,16 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"io"
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/ant0ine/go-json-rest/rest"
 
 	dataservicesClient "github.com/tidepool-org/platform/dataservices/client"
+	exportStore "github.com/tidepool-org/platform/export/store"
 	"github.com/tidepool-org/platform/log"
 	messageStore "github.com/tidepool-org/platform/message/store"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
@@ -21,6 +24,8 @@ import (
 	"github.com/tidepool-org/platform/service"
 	sessionStore "github.com/tidepool-org/platform/session/store"
 	commonStore "github.com/tidepool-org/platform/store"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
 	"github.com/tidepool-org/platform/user"
 	userStore "github.com/tidepool-org/platform/user/store"
 	userservicesClient "github.com/tidepool-org/platform/userservices/client"
@@ -76,6 +81,24 @@ type RespondWithStatusAndDataInput struct {
 	data       interface{}
 }
 
+type RespondWithStatusAndDataAndCursorInput struct {
+	statusCode int
+	data       interface{}
+	cursor     string
+}
+
+type RespondWithStatusAndStreamInput struct {
+	statusCode int
+	stream     service.Stream
+}
+
+type RespondWithStatusAndAttachmentInput struct {
+	statusCode  int
+	contentType string
+	filename    string
+	attachment  io.ReadCloser
+}
+
 type RecordMetricInput struct {
 	context metricservicesClient.Context
 	metric  string
@@ -150,9 +173,23 @@ type DestroyDataForUserByIDInput struct {
 	userID  string
 }
 
+type LockDataForUserByIDInput struct {
+	context dataservicesClient.Context
+	userID  string
+}
+
+type UnlockDataForUserByIDInput struct {
+	context dataservicesClient.Context
+	userID  string
+}
+
 type TestDataServicesClient struct {
 	DestroyDataForUserByIDInputs  []DestroyDataForUserByIDInput
 	DestroyDataForUserByIDOutputs []error
+	LockDataForUserByIDInputs     []LockDataForUserByIDInput
+	LockDataForUserByIDOutputs    []error
+	UnlockDataForUserByIDInputs   []UnlockDataForUserByIDInput
+	UnlockDataForUserByIDOutputs  []error
 }
 
 func (t *TestDataServicesClient) DestroyDataForUserByID(context dataservicesClient.Context, userID string) error {
@@ -162,8 +199,61 @@ func (t *TestDataServicesClient) DestroyDataForUserByID(context dataservicesClie
 	return output
 }
 
+func (t *TestDataServicesClient) LockDataForUserByID(context dataservicesClient.Context, userID string) error {
+	t.LockDataForUserByIDInputs = append(t.LockDataForUserByIDInputs, LockDataForUserByIDInput{context, userID})
+	output := t.LockDataForUserByIDOutputs[0]
+	t.LockDataForUserByIDOutputs = t.LockDataForUserByIDOutputs[1:]
+	return output
+}
+
+func (t *TestDataServicesClient) UnlockDataForUserByID(context dataservicesClient.Context, userID string) error {
+	t.UnlockDataForUserByIDInputs = append(t.UnlockDataForUserByIDInputs, UnlockDataForUserByIDInput{context, userID})
+	output := t.UnlockDataForUserByIDOutputs[0]
+	t.UnlockDataForUserByIDOutputs = t.UnlockDataForUserByIDOutputs[1:]
+	return output
+}
+
+func (t *TestDataServicesClient) StreamDataForUserByID(context dataservicesClient.Context, userID string, writer io.Writer) error {
+	panic("Unexpected invocation of StreamDataForUserByID on TestDataServicesClient")
+}
+
 func (t *TestDataServicesClient) ValidateTest() bool {
-	return len(t.DestroyDataForUserByIDOutputs) == 0
+	return len(t.DestroyDataForUserByIDOutputs) == 0 &&
+		len(t.LockDataForUserByIDOutputs) == 0 &&
+		len(t.UnlockDataForUserByIDOutputs) == 0
+}
+
+type OpenArchiveOutput struct {
+	archive io.ReadCloser
+	err     error
+}
+
+type TestExportStore struct {
+	OpenArchiveInputs  []string
+	OpenArchiveOutputs []OpenArchiveOutput
+}
+
+func (t *TestExportStore) CreateArchive(exportID string, userID string) (exportStore.ArchiveWriter, error) {
+	panic("Unexpected invocation of CreateArchive on TestExportStore")
+}
+
+func (t *TestExportStore) OpenArchive(exportID string) (io.ReadCloser, error) {
+	t.OpenArchiveInputs = append(t.OpenArchiveInputs, exportID)
+	output := t.OpenArchiveOutputs[0]
+	t.OpenArchiveOutputs = t.OpenArchiveOutputs[1:]
+	return output.archive, output.err
+}
+
+func (t *TestExportStore) DestroyArchivesCreatedBefore(createdBefore time.Time) error {
+	panic("Unexpected invocation of DestroyArchivesCreatedBefore on TestExportStore")
+}
+
+func (t *TestExportStore) DestroyArchivesForUserByID(userID string) error {
+	panic("Unexpected invocation of DestroyArchivesForUserByID on TestExportStore")
+}
+
+func (t *TestExportStore) ValidateTest() bool {
+	return len(t.OpenArchiveOutputs) == 0
 }
 
 type TestMessageStoreSession struct {
@@ -189,6 +279,10 @@ func (t *TestMessageStoreSession) SetAgent(agent commonStore.Agent) {
 	panic("Unexpected invocation of SetAgent on TestMessageStoreSession")
 }
 
+func (t *TestMessageStoreSession) GetMessagesForUserByID(userID string) ([]map[string]interface{}, error) {
+	panic("Unexpected invocation of GetMessagesForUserByID on TestMessageStoreSession")
+}
+
 func (t *TestMessageStoreSession) DeleteMessagesFromUser(deleteUser *messageStore.User) error {
 	t.DeleteMessagesFromUserInputs = append(t.DeleteMessagesFromUserInputs, deleteUser)
 	output := t.DeleteMessagesFromUserOutputs[0]
@@ -229,6 +323,10 @@ func (t *TestNotificationStoreSession) SetAgent(agent commonStore.Agent) {
 	panic("Unexpected invocation of SetAgent on TestNotificationStoreSession")
 }
 
+func (t *TestNotificationStoreSession) GetNotificationsForUserByID(userID string) ([]map[string]interface{}, error) {
+	panic("Unexpected invocation of GetNotificationsForUserByID on TestNotificationStoreSession")
+}
+
 func (t *TestNotificationStoreSession) DestroyNotificationsForUserByID(userID string) error {
 	t.DestroyNotificationsForUserByIDInputs = append(t.DestroyNotificationsForUserByIDInputs, userID)
 	output := t.DestroyNotificationsForUserByIDOutputs[0]
@@ -261,6 +359,10 @@ func (t *TestPermissionStoreSession) SetAgent(agent commonStore.Agent) {
 	panic("Unexpected invocation of SetAgent on TestPermissionStoreSession")
 }
 
+func (t *TestPermissionStoreSession) GetPermissionsForUserByID(userID string) ([]map[string]interface{}, error) {
+	panic("Unexpected invocation of GetPermissionsForUserByID on TestPermissionStoreSession")
+}
+
 func (t *TestPermissionStoreSession) DestroyPermissionsForUserByID(userID string) error {
 	t.DestroyPermissionsForUserByIDInputs = append(t.DestroyPermissionsForUserByIDInputs, userID)
 	output := t.DestroyPermissionsForUserByIDOutputs[0]
@@ -361,15 +463,24 @@ type PasswordMatchesInput struct {
 	password string
 }
 
+type ScheduleUserDeletionInput struct {
+	*user.User
+	deletionScheduledTime string
+}
+
 type TestUserStoreSession struct {
-	GetUserByIDInputs      []string
-	GetUserByIDOutputs     []GetUserByIDOutput
-	DeleteUserInputs       []*user.User
-	DeleteUserOutputs      []error
-	DestroyUserByIDInputs  []string
-	DestroyUserByIDOutputs []error
-	PasswordMatchesInputs  []PasswordMatchesInput
-	PasswordMatchesOutputs []bool
+	GetUserByIDInputs             []string
+	GetUserByIDOutputs            []GetUserByIDOutput
+	DeleteUserInputs              []*user.User
+	DeleteUserOutputs             []error
+	ScheduleUserDeletionInputs    []ScheduleUserDeletionInput
+	ScheduleUserDeletionOutputs   []error
+	UnscheduleUserDeletionInputs  []*user.User
+	UnscheduleUserDeletionOutputs []error
+	DestroyUserByIDInputs         []string
+	DestroyUserByIDOutputs        []error
+	PasswordMatchesInputs         []PasswordMatchesInput
+	PasswordMatchesOutputs        []bool
 }
 
 func (t *TestUserStoreSession) IsClosed() bool {
@@ -402,6 +513,20 @@ func (t *TestUserStoreSession) DeleteUser(deleteUser *user.User) error {
 	return output
 }
 
+func (t *TestUserStoreSession) ScheduleUserDeletion(scheduleUser *user.User, deletionScheduledTime string) error {
+	t.ScheduleUserDeletionInputs = append(t.ScheduleUserDeletionInputs, ScheduleUserDeletionInput{scheduleUser, deletionScheduledTime})
+	output := t.ScheduleUserDeletionOutputs[0]
+	t.ScheduleUserDeletionOutputs = t.ScheduleUserDeletionOutputs[1:]
+	return output
+}
+
+func (t *TestUserStoreSession) UnscheduleUserDeletion(unscheduleUser *user.User) error {
+	t.UnscheduleUserDeletionInputs = append(t.UnscheduleUserDeletionInputs, unscheduleUser)
+	output := t.UnscheduleUserDeletionOutputs[0]
+	t.UnscheduleUserDeletionOutputs = t.UnscheduleUserDeletionOutputs[1:]
+	return output
+}
+
 func (t *TestUserStoreSession) DestroyUserByID(userID string) error {
 	t.DestroyUserByIDInputs = append(t.DestroyUserByIDInputs, userID)
 	output := t.DestroyUserByIDOutputs[0]
@@ -419,6 +544,8 @@ func (t *TestUserStoreSession) PasswordMatches(matchUser *user.User, password st
 func (t *TestUserStoreSession) ValidateTest() bool {
 	return len(t.GetUserByIDOutputs) == 0 &&
 		len(t.DeleteUserOutputs) == 0 &&
+		len(t.ScheduleUserDeletionOutputs) == 0 &&
+		len(t.UnscheduleUserDeletionOutputs) == 0 &&
 		len(t.DestroyUserByIDOutputs) == 0 &&
 		len(t.PasswordMatchesOutputs) == 0
 }
@@ -450,22 +577,27 @@ func (t *TestAuthenticationDetails) ValidateTest() bool {
 }
 
 type TestContext struct {
-	LoggerImpl                             log.Logger
-	RequestImpl                            *rest.Request
-	RespondWithErrorInputs                 []*service.Error
-	RespondWithInternalServerFailureInputs []RespondWithInternalServerFailureInput
-	RespondWithStatusAndErrorsInputs       []RespondWithStatusAndErrorsInput
-	RespondWithStatusAndDataInputs         []RespondWithStatusAndDataInput
-	MetricServicesClientImpl               *TestMetricServicesClient
-	UserServicesClientImpl                 *TestUserServicesClient
-	DataServicesClientImpl                 *TestDataServicesClient
-	MessageStoreSessionImpl                *TestMessageStoreSession
-	NotificationStoreSessionImpl           *TestNotificationStoreSession
-	PermissionStoreSessionImpl             *TestPermissionStoreSession
-	ProfileStoreSessionImpl                *TestProfileStoreSession
-	SessionStoreSessionImpl                *TestSessionStoreSession
-	UserStoreSessionImpl                   *TestUserStoreSession
-	AuthenticationDetailsImpl              *TestAuthenticationDetails
+	LoggerImpl                              log.Logger
+	RequestImpl                             *rest.Request
+	RespondWithErrorInputs                  []*service.Error
+	RespondWithInternalServerFailureInputs  []RespondWithInternalServerFailureInput
+	RespondWithStatusAndErrorsInputs        []RespondWithStatusAndErrorsInput
+	RespondWithStatusAndDataInputs          []RespondWithStatusAndDataInput
+	RespondWithStatusAndDataAndCursorInputs []RespondWithStatusAndDataAndCursorInput
+	RespondWithStatusAndStreamInputs        []RespondWithStatusAndStreamInput
+	RespondWithStatusAndAttachmentInputs    []RespondWithStatusAndAttachmentInput
+	MetricServicesClientImpl                *TestMetricServicesClient
+	UserServicesClientImpl                  *TestUserServicesClient
+	DataServicesClientImpl                  *TestDataServicesClient
+	ExportStoreImpl                         *TestExportStore
+	MessageStoreSessionImpl                 *TestMessageStoreSession
+	NotificationStoreSessionImpl            *TestNotificationStoreSession
+	PermissionStoreSessionImpl              *TestPermissionStoreSession
+	ProfileStoreSessionImpl                 *TestProfileStoreSession
+	SessionStoreSessionImpl                 *TestSessionStoreSession
+	TaskStoreSessionImpl                    *testTaskStore.Session
+	UserStoreSessionImpl                    *TestUserStoreSession
+	AuthenticationDetailsImpl               *TestAuthenticationDetails
 }
 
 func NewTestContext() *TestContext {
@@ -480,11 +612,13 @@ func NewTestContext() *TestContext {
 		MetricServicesClientImpl:     &TestMetricServicesClient{},
 		UserServicesClientImpl:       &TestUserServicesClient{},
 		DataServicesClientImpl:       &TestDataServicesClient{},
+		ExportStoreImpl:              &TestExportStore{},
 		MessageStoreSessionImpl:      &TestMessageStoreSession{},
 		NotificationStoreSessionImpl: &TestNotificationStoreSession{},
 		PermissionStoreSessionImpl:   &TestPermissionStoreSession{},
 		ProfileStoreSessionImpl:      &TestProfileStoreSession{},
 		SessionStoreSessionImpl:      &TestSessionStoreSession{},
+		TaskStoreSessionImpl:         testTaskStore.NewSession(),
 		UserStoreSessionImpl:         &TestUserStoreSession{},
 		AuthenticationDetailsImpl:    &TestAuthenticationDetails{},
 	}
@@ -518,6 +652,18 @@ func (t *TestContext) RespondWithStatusAndData(statusCode int, data interface{})
 	t.RespondWithStatusAndDataInputs = append(t.RespondWithStatusAndDataInputs, RespondWithStatusAndDataInput{statusCode, data})
 }
 
+func (t *TestContext) RespondWithStatusAndDataAndCursor(statusCode int, data interface{}, cursor string) {
+	t.RespondWithStatusAndDataAndCursorInputs = append(t.RespondWithStatusAndDataAndCursorInputs, RespondWithStatusAndDataAndCursorInput{statusCode, data, cursor})
+}
+
+func (t *TestContext) RespondWithStatusAndStream(statusCode int, stream service.Stream) {
+	t.RespondWithStatusAndStreamInputs = append(t.RespondWithStatusAndStreamInputs, RespondWithStatusAndStreamInput{statusCode, stream})
+}
+
+func (t *TestContext) RespondWithStatusAndAttachment(statusCode int, contentType string, filename string, attachment io.ReadCloser) {
+	t.RespondWithStatusAndAttachmentInputs = append(t.RespondWithStatusAndAttachmentInputs, RespondWithStatusAndAttachmentInput{statusCode, contentType, filename, attachment})
+}
+
 func (t *TestContext) MetricServicesClient() metricservicesClient.Client {
 	return t.MetricServicesClientImpl
 }
@@ -530,6 +676,10 @@ func (t *TestContext) DataServicesClient() dataservicesClient.Client {
 	return t.DataServicesClientImpl
 }
 
+func (t *TestContext) ExportStore() exportStore.Store {
+	return t.ExportStoreImpl
+}
+
 func (t *TestContext) MessageStoreSession() messageStore.Session {
 	return t.MessageStoreSessionImpl
 }
@@ -550,6 +700,10 @@ func (t *TestContext) SessionStoreSession() sessionStore.Session {
 	return t.SessionStoreSessionImpl
 }
 
+func (t *TestContext) TaskStoreSession() taskStore.Session {
+	return t.TaskStoreSessionImpl
+}
+
 func (t *TestContext) UserStoreSession() userStore.Session {
 	return t.UserStoreSessionImpl
 }
@@ -566,11 +720,13 @@ func (t *TestContext) ValidateTest() bool {
 	return (t.MetricServicesClientImpl == nil || t.MetricServicesClientImpl.ValidateTest()) &&
 		(t.UserServicesClientImpl == nil || t.UserServicesClientImpl.ValidateTest()) &&
 		(t.DataServicesClientImpl == nil || t.DataServicesClientImpl.ValidateTest()) &&
+		(t.ExportStoreImpl == nil || t.ExportStoreImpl.ValidateTest()) &&
 		(t.MessageStoreSessionImpl == nil || t.MessageStoreSessionImpl.ValidateTest()) &&
 		(t.NotificationStoreSessionImpl == nil || t.NotificationStoreSessionImpl.ValidateTest()) &&
 		(t.PermissionStoreSessionImpl == nil || t.PermissionStoreSessionImpl.ValidateTest()) &&
 		(t.ProfileStoreSessionImpl == nil || t.ProfileStoreSessionImpl.ValidateTest()) &&
 		(t.SessionStoreSessionImpl == nil || t.SessionStoreSessionImpl.ValidateTest()) &&
+		(t.TaskStoreSessionImpl == nil || t.TaskStoreSessionImpl.UnusedOutputsCount() == 0) &&
 		(t.UserStoreSessionImpl == nil || t.UserStoreSessionImpl.ValidateTest()) &&
 		(t.AuthenticationDetailsImpl == nil || t.AuthenticationDetailsImpl.ValidateTest())
 }
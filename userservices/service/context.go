@@ -2,6 +2,7 @@ package service
 
 import (
 	dataservicesClient "github.com/tidepool-org/platform/dataservices/client"
+	exportStore "github.com/tidepool-org/platform/export/store"
 	messageStore "github.com/tidepool-org/platform/message/store"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
 	notificationStore "github.com/tidepool-org/platform/notification/store"
@@ -9,6 +10,7 @@ import (
 	profileStore "github.com/tidepool-org/platform/profile/store"
 	"github.com/tidepool-org/platform/service"
 	sessionStore "github.com/tidepool-org/platform/session/store"
+	taskStore "github.com/tidepool-org/platform/task/store"
 	userStore "github.com/tidepool-org/platform/user/store"
 	userservicesClient "github.com/tidepool-org/platform/userservices/client"
 )
@@ -20,11 +22,14 @@ type Context interface {
 	UserServicesClient() userservicesClient.Client
 	DataServicesClient() dataservicesClient.Client
 
+	ExportStore() exportStore.Store
+
 	MessageStoreSession() messageStore.Session
 	NotificationStoreSession() notificationStore.Session
 	PermissionStoreSession() permissionStore.Session
 	ProfileStoreSession() profileStore.Session
 	SessionStoreSession() sessionStore.Session
+	TaskStoreSession() taskStore.Session
 	UserStoreSession() userStore.Session
 
 	AuthenticationDetails() userservicesClient.AuthenticationDetails
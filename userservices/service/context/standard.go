@@ -4,6 +4,7 @@ import (
 	"github.com/ant0ine/go-json-rest/rest"
 
 	dataservicesClient "github.com/tidepool-org/platform/dataservices/client"
+	exportStore "github.com/tidepool-org/platform/export/store"
 	messageStore "github.com/tidepool-org/platform/message/store"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
 	notificationStore "github.com/tidepool-org/platform/notification/store"
@@ -12,6 +13,7 @@ import (
 	commonService "github.com/tidepool-org/platform/service"
 	"github.com/tidepool-org/platform/service/context"
 	sessionStore "github.com/tidepool-org/platform/session/store"
+	taskStore "github.com/tidepool-org/platform/task/store"
 	userStore "github.com/tidepool-org/platform/user/store"
 	userservicesClient "github.com/tidepool-org/platform/userservices/client"
 	"github.com/tidepool-org/platform/userservices/service"
@@ -22,6 +24,7 @@ type Standard struct {
 	metricServicesClient     metricservicesClient.Client
 	userServicesClient       userservicesClient.Client
 	dataServicesClient       dataservicesClient.Client
+	exportStore              exportStore.Store
 	messageStore             messageStore.Store
 	messageStoreSession      messageStore.Session
 	notificationStore        notificationStore.Store
@@ -32,14 +35,16 @@ type Standard struct {
 	profileStoreSession      profileStore.Session
 	sessionStore             sessionStore.Store
 	sessionStoreSession      sessionStore.Session
+	taskStore                taskStore.Store
+	taskStoreSession         taskStore.Session
 	userStore                userStore.Store
 	userStoreSession         userStore.Session
 	authenticationDetails    userservicesClient.AuthenticationDetails
 }
 
 func WithContext(metricServicesClient metricservicesClient.Client, userServicesClient userservicesClient.Client, dataServicesClient dataservicesClient.Client,
-	messageStore messageStore.Store, notificationStore notificationStore.Store, permissionStore permissionStore.Store, profileStore profileStore.Store,
-	sessionStore sessionStore.Store, userStore userStore.Store, handler service.HandlerFunc) rest.HandlerFunc {
+	exportStore exportStore.Store, messageStore messageStore.Store, notificationStore notificationStore.Store, permissionStore permissionStore.Store,
+	profileStore profileStore.Store, sessionStore sessionStore.Store, taskStore taskStore.Store, userStore userStore.Store, handler service.HandlerFunc) rest.HandlerFunc {
 	return func(response rest.ResponseWriter, request *rest.Request) {
 		context, err := context.NewStandard(response, request)
 		if err != nil {
@@ -52,11 +57,13 @@ func WithContext(metricServicesClient metricservicesClient.Client, userServicesC
 			metricServicesClient: metricServicesClient,
 			userServicesClient:   userServicesClient,
 			dataServicesClient:   dataServicesClient,
+			exportStore:          exportStore,
 			messageStore:         messageStore,
 			notificationStore:    notificationStore,
 			permissionStore:      permissionStore,
 			profileStore:         profileStore,
 			sessionStore:         sessionStore,
+			taskStore:            taskStore,
 			userStore:            userStore,
 		}
 
@@ -64,6 +71,9 @@ func WithContext(metricServicesClient metricservicesClient.Client, userServicesC
 			if standard.userStoreSession != nil {
 				standard.userStoreSession.Close()
 			}
+			if standard.taskStoreSession != nil {
+				standard.taskStoreSession.Close()
+			}
 			if standard.sessionStoreSession != nil {
 				standard.sessionStoreSession.Close()
 			}
@@ -97,6 +107,10 @@ func (s *Standard) DataServicesClient() dataservicesClient.Client {
 	return s.dataServicesClient
 }
 
+func (s *Standard) ExportStore() exportStore.Store {
+	return s.exportStore
+}
+
 func (s *Standard) MessageStoreSession() messageStore.Session {
 	if s.messageStoreSession == nil {
 		s.messageStoreSession = s.messageStore.NewSession(s.Context.Logger())
@@ -137,6 +151,14 @@ func (s *Standard) SessionStoreSession() sessionStore.Session {
 	return s.sessionStoreSession
 }
 
+func (s *Standard) TaskStoreSession() taskStore.Session {
+	if s.taskStoreSession == nil {
+		s.taskStoreSession = s.taskStore.NewSession(s.Context.Logger())
+		s.taskStoreSession.SetAgent(s.authenticationDetails)
+	}
+	return s.taskStoreSession
+}
+
 func (s *Standard) UserStoreSession() userStore.Session {
 	if s.userStoreSession == nil {
 		s.userStoreSession = s.userStore.NewSession(s.Context.Logger())
@@ -167,6 +189,9 @@ func (s *Standard) SetAuthenticationDetails(authenticationDetails userservicesCl
 	if s.sessionStoreSession != nil {
 		s.sessionStoreSession.SetAgent(authenticationDetails)
 	}
+	if s.taskStoreSession != nil {
+		s.taskStoreSession.SetAgent(authenticationDetails)
+	}
 	if s.userStoreSession != nil {
 		s.userStoreSession.SetAgent(authenticationDetails)
 	}
@@ -3,6 +3,7 @@ package service
 import (
 	dataservicesClient "github.com/tidepool-org/platform/dataservices/client"
 	"github.com/tidepool-org/platform/errors"
+	exportMongo "github.com/tidepool-org/platform/export/store/mongo"
 	messageMongo "github.com/tidepool-org/platform/message/store/mongo"
 	metricservicesClient "github.com/tidepool-org/platform/metricservices/client"
 	notificationMongo "github.com/tidepool-org/platform/notification/store/mongo"
@@ -13,10 +14,12 @@ import (
 	"github.com/tidepool-org/platform/service/service"
 	sessionMongo "github.com/tidepool-org/platform/session/store/mongo"
 	baseMongo "github.com/tidepool-org/platform/store/mongo"
+	taskMongo "github.com/tidepool-org/platform/task/store/mongo"
 	userMongo "github.com/tidepool-org/platform/user/store/mongo"
 	userservicesClient "github.com/tidepool-org/platform/userservices/client"
 	"github.com/tidepool-org/platform/userservices/service/api"
 	"github.com/tidepool-org/platform/userservices/service/api/v1"
+	"github.com/tidepool-org/platform/userservices/service/worker"
 )
 
 type Standard struct {
@@ -24,12 +27,15 @@ type Standard struct {
 	metricServicesClient *metricservicesClient.Standard
 	userServicesClient   *userservicesClient.Standard
 	dataServicesClient   *dataservicesClient.Standard
+	exportStore          *exportMongo.Store
 	messageStore         *messageMongo.Store
 	notificationStore    *notificationMongo.Store
 	permissionStore      *permissionMongo.Store
 	profileStore         *profileMongo.Store
 	sessionStore         *sessionMongo.Store
+	taskStore            *taskMongo.Store
 	userStore            *userMongo.Store
+	userServicesWorker   *worker.Standard
 	userServicesAPI      *api.Standard
 	userServicesServer   *server.Standard
 }
@@ -59,6 +65,9 @@ func (s *Standard) Initialize() error {
 	if err := s.initializeDataServicesClient(); err != nil {
 		return err
 	}
+	if err := s.initializeExportStore(); err != nil {
+		return err
+	}
 	if err := s.initializeMessageStore(); err != nil {
 		return err
 	}
@@ -74,9 +83,15 @@ func (s *Standard) Initialize() error {
 	if err := s.initializeSessionStore(); err != nil {
 		return err
 	}
+	if err := s.initializeTaskStore(); err != nil {
+		return err
+	}
 	if err := s.initializeUserStore(); err != nil {
 		return err
 	}
+	if err := s.initializeUserServicesWorker(); err != nil {
+		return err
+	}
 	if err := s.initializeUserServicesAPI(); err != nil {
 		return err
 	}
@@ -90,10 +105,18 @@ func (s *Standard) Initialize() error {
 func (s *Standard) Terminate() {
 	s.userServicesServer = nil
 	s.userServicesAPI = nil
+	if s.userServicesWorker != nil {
+		s.userServicesWorker.Close()
+		s.userServicesWorker = nil
+	}
 	if s.userStore != nil {
 		s.userStore.Close()
 		s.userStore = nil
 	}
+	if s.taskStore != nil {
+		s.taskStore.Close()
+		s.taskStore = nil
+	}
 	if s.sessionStore != nil {
 		s.sessionStore.Close()
 		s.sessionStore = nil
@@ -114,6 +137,10 @@ func (s *Standard) Terminate() {
 		s.messageStore.Close()
 		s.messageStore = nil
 	}
+	if s.exportStore != nil {
+		s.exportStore.Close()
+		s.exportStore = nil
+	}
 	s.dataServicesClient = nil
 	if s.userServicesClient != nil {
 		s.userServicesClient.Close()
@@ -195,6 +222,26 @@ func (s *Standard) initializeDataServicesClient() error {
 	return nil
 }
 
+func (s *Standard) initializeExportStore() error {
+	s.Logger().Debug("Loading export store config")
+
+	exportStoreConfig := &baseMongo.Config{}
+	if err := s.ConfigLoader().Load("export_store", exportStoreConfig); err != nil {
+		return errors.Wrap(err, "service", "unable to load export store config")
+	}
+	exportStoreConfig.Collection = "exports"
+
+	s.Logger().Debug("Creating export store")
+
+	exportStore, err := exportMongo.New(s.Logger(), exportStoreConfig)
+	if err != nil {
+		return errors.Wrap(err, "service", "unable to create export store")
+	}
+	s.exportStore = exportStore
+
+	return nil
+}
+
 func (s *Standard) initializeMessageStore() error {
 	s.Logger().Debug("Loading message store config")
 
@@ -295,6 +342,26 @@ func (s *Standard) initializeSessionStore() error {
 	return nil
 }
 
+func (s *Standard) initializeTaskStore() error {
+	s.Logger().Debug("Loading task store config")
+
+	taskStoreConfig := &baseMongo.Config{}
+	if err := s.ConfigLoader().Load("task_store", taskStoreConfig); err != nil {
+		return errors.Wrap(err, "service", "unable to load task store config")
+	}
+	taskStoreConfig.Collection = "userTasks"
+
+	s.Logger().Debug("Creating task store")
+
+	taskStore, err := taskMongo.New(s.Logger(), taskStoreConfig)
+	if err != nil {
+		return errors.Wrap(err, "service", "unable to create task store")
+	}
+	s.taskStore = taskStore
+
+	return nil
+}
+
 func (s *Standard) initializeUserStore() error {
 	s.Logger().Debug("Loading user store config")
 
@@ -315,12 +382,37 @@ func (s *Standard) initializeUserStore() error {
 	return nil
 }
 
+func (s *Standard) initializeUserServicesWorker() error {
+	s.Logger().Debug("Loading user services worker config")
+
+	userServicesWorkerConfig := &worker.Config{}
+	if err := s.ConfigLoader().Load("userservices_worker", userServicesWorkerConfig); err != nil {
+		return errors.Wrap(err, "service", "unable to load user services worker config")
+	}
+
+	s.Logger().Debug("Creating user services worker")
+
+	userServicesWorker, err := worker.NewStandard(s.Logger(), userServicesWorkerConfig, s.userServicesClient, s.dataServicesClient,
+		s.exportStore, s.messageStore, s.notificationStore, s.permissionStore, s.profileStore, s.sessionStore, s.taskStore, s.userStore)
+	if err != nil {
+		return errors.Wrap(err, "service", "unable to create user services worker")
+	}
+	s.userServicesWorker = userServicesWorker
+
+	s.Logger().Debug("Starting user services worker")
+	if err = s.userServicesWorker.Start(); err != nil {
+		return errors.Wrap(err, "service", "unable to start user services worker")
+	}
+
+	return nil
+}
+
 func (s *Standard) initializeUserServicesAPI() error {
 	s.Logger().Debug("Creating user services api")
 
 	userServicesAPI, err := api.NewStandard(s.VersionReporter(), s.EnvironmentReporter(), s.Logger(),
 		s.metricServicesClient, s.userServicesClient, s.dataServicesClient,
-		s.messageStore, s.notificationStore, s.permissionStore, s.profileStore, s.sessionStore, s.userStore)
+		s.exportStore, s.messageStore, s.notificationStore, s.permissionStore, s.profileStore, s.sessionStore, s.taskStore, s.userStore)
 	if err != nil {
 		return errors.Wrap(err, "service", "unable to create user services api")
 	}
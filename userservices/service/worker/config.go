@@ -0,0 +1,20 @@
+package worker
+
+import "github.com/tidepool-org/platform/errors"
+
+type Config struct {
+	ExportRetentionDays int `json:"exportRetentionDays" default:"7"`
+}
+
+func (c *Config) Validate() error {
+	if c.ExportRetentionDays < 1 {
+		return errors.New("worker", "export retention days is invalid")
+	}
+	return nil
+}
+
+func (c *Config) Clone() *Config {
+	return &Config{
+		ExportRetentionDays: c.ExportRetentionDays,
+	}
+}
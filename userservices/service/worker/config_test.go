@@ -0,0 +1,42 @@
+package worker_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+var _ = Describe("Config", func() {
+	var config *worker.Config
+
+	BeforeEach(func() {
+		config = &worker.Config{
+			ExportRetentionDays: 7,
+		}
+	})
+
+	Context("Validate", func() {
+		It("returns success if all are valid", func() {
+			Expect(config.Validate()).To(Succeed())
+		})
+
+		It("returns an error if the export retention days is zero", func() {
+			config.ExportRetentionDays = 0
+			Expect(config.Validate()).To(MatchError("worker: export retention days is invalid"))
+		})
+
+		It("returns an error if the export retention days is less than zero", func() {
+			config.ExportRetentionDays = -1
+			Expect(config.Validate()).To(MatchError("worker: export retention days is invalid"))
+		})
+	})
+
+	Context("Clone", func() {
+		It("returns successfully", func() {
+			clone := config.Clone()
+			Expect(clone).ToNot(BeIdenticalTo(config))
+			Expect(clone.ExportRetentionDays).To(Equal(config.ExportRetentionDays))
+		})
+	})
+})
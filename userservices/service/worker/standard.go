@@ -0,0 +1,588 @@
+package worker
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ant0ine/go-json-rest/rest"
+
+	dataservicesClient "github.com/tidepool-org/platform/dataservices/client"
+	"github.com/tidepool-org/platform/errors"
+	exportStore "github.com/tidepool-org/platform/export/store"
+	"github.com/tidepool-org/platform/log"
+	messageStore "github.com/tidepool-org/platform/message/store"
+	notificationStore "github.com/tidepool-org/platform/notification/store"
+	permissionStore "github.com/tidepool-org/platform/permission/store"
+	profileStore "github.com/tidepool-org/platform/profile/store"
+	"github.com/tidepool-org/platform/service"
+	sessionStore "github.com/tidepool-org/platform/session/store"
+	commonStore "github.com/tidepool-org/platform/store"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	"github.com/tidepool-org/platform/user"
+	userStore "github.com/tidepool-org/platform/user/store"
+	userservicesClient "github.com/tidepool-org/platform/userservices/client"
+)
+
+const (
+	TaskTypeDeleteUser = "delete-user"
+	TaskTypeExportUser = "export-user"
+
+	PollInterval      = 5 * time.Second
+	LeaseDuration     = 5 * time.Minute
+	HeartbeatInterval = time.Minute
+	PurgeInterval     = time.Hour
+
+	DeleteUserGracePeriod = 30 * 24 * time.Hour
+
+	ArchiveFileUser          = "user.json"
+	ArchiveFileProfile       = "profile.json"
+	ArchiveFilePermissions   = "permissions.json"
+	ArchiveFileMessages      = "messages.json"
+	ArchiveFileNotifications = "notifications.json"
+	ArchiveFileData          = "data.ndjson"
+
+	DeleteUserStepDeleteUser             = "delete-user"
+	DeleteUserStepDestroySessions        = "destroy-sessions"
+	DeleteUserStepDestroyPermissions     = "destroy-permissions"
+	DeleteUserStepDestroyNotifications   = "destroy-notifications"
+	DeleteUserStepDestroyData            = "destroy-data"
+	DeleteUserStepDestroyMessages        = "destroy-messages"
+	DeleteUserStepDeleteMessagesFromUser = "delete-messages-from-user"
+	DeleteUserStepDestroyProfile         = "destroy-profile"
+	DeleteUserStepDestroyTasks           = "destroy-tasks"
+	DeleteUserStepDestroyExports         = "destroy-exports"
+	DeleteUserStepDestroyUser            = "destroy-user"
+)
+
+func DeleteUserSteps() []string {
+	return []string{
+		DeleteUserStepDeleteUser,
+		DeleteUserStepDestroySessions,
+		DeleteUserStepDestroyPermissions,
+		DeleteUserStepDestroyNotifications,
+		DeleteUserStepDestroyData,
+		DeleteUserStepDestroyMessages,
+		DeleteUserStepDeleteMessagesFromUser,
+		DeleteUserStepDestroyProfile,
+		DeleteUserStepDestroyTasks,
+		DeleteUserStepDestroyExports,
+		DeleteUserStepDestroyUser,
+	}
+}
+
+func NewDeleteUserTask(userID string) *taskStore.Task {
+	task := taskStore.NewTask(TaskTypeDeleteUser)
+	task.UserID = userID
+	for _, stepName := range DeleteUserSteps() {
+		task.Steps = append(task.Steps, taskStore.NewTaskStep(stepName))
+	}
+	return task
+}
+
+type Standard struct {
+	logger             log.Logger
+	config             *Config
+	userServicesClient userservicesClient.Client
+	dataServicesClient dataservicesClient.Client
+	exportStore        exportStore.Store
+	messageStore       messageStore.Store
+	notificationStore  notificationStore.Store
+	permissionStore    permissionStore.Store
+	profileStore       profileStore.Store
+	sessionStore       sessionStore.Store
+	taskStore          taskStore.Store
+	userStore          userStore.Store
+	closingChannel     chan chan bool
+	purgeTime          time.Time
+}
+
+func NewStandard(logger log.Logger, config *Config, userServicesClient userservicesClient.Client, dataServicesClient dataservicesClient.Client,
+	exportStore exportStore.Store, messageStore messageStore.Store, notificationStore notificationStore.Store, permissionStore permissionStore.Store,
+	profileStore profileStore.Store, sessionStore sessionStore.Store, taskStore taskStore.Store, userStore userStore.Store) (*Standard, error) {
+	if logger == nil {
+		return nil, errors.New("worker", "logger is missing")
+	}
+	if config == nil {
+		return nil, errors.New("worker", "config is missing")
+	}
+	if userServicesClient == nil {
+		return nil, errors.New("worker", "user services client is missing")
+	}
+	if dataServicesClient == nil {
+		return nil, errors.New("worker", "data services client is missing")
+	}
+	if exportStore == nil {
+		return nil, errors.New("worker", "export store is missing")
+	}
+	if messageStore == nil {
+		return nil, errors.New("worker", "message store is missing")
+	}
+	if notificationStore == nil {
+		return nil, errors.New("worker", "notification store is missing")
+	}
+	if permissionStore == nil {
+		return nil, errors.New("worker", "permission store is missing")
+	}
+	if profileStore == nil {
+		return nil, errors.New("worker", "profile store is missing")
+	}
+	if sessionStore == nil {
+		return nil, errors.New("worker", "session store is missing")
+	}
+	if taskStore == nil {
+		return nil, errors.New("worker", "task store is missing")
+	}
+	if userStore == nil {
+		return nil, errors.New("worker", "user store is missing")
+	}
+
+	config = config.Clone()
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "worker", "config is invalid")
+	}
+
+	return &Standard{
+		logger:             logger,
+		config:             config,
+		userServicesClient: userServicesClient,
+		dataServicesClient: dataServicesClient,
+		exportStore:        exportStore,
+		messageStore:       messageStore,
+		notificationStore:  notificationStore,
+		permissionStore:    permissionStore,
+		profileStore:       profileStore,
+		sessionStore:       sessionStore,
+		taskStore:          taskStore,
+		userStore:          userStore,
+	}, nil
+}
+
+func (s *Standard) Start() error {
+	if s.closingChannel == nil {
+		closingChannel := make(chan chan bool)
+		s.closingChannel = closingChannel
+
+		go func() {
+			for {
+				timer := time.After(PollInterval)
+				select {
+				case closedChannel := <-closingChannel:
+					closedChannel <- true
+					close(closedChannel)
+					return
+				case <-timer:
+					s.ProcessTasks()
+					if time.Since(s.purgeTime) >= PurgeInterval {
+						s.PurgeExports()
+					}
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+func (s *Standard) Close() {
+	if s.closingChannel != nil {
+		closingChannel := s.closingChannel
+		s.closingChannel = nil
+
+		closedChannel := make(chan bool)
+		closingChannel <- closedChannel
+		close(closingChannel)
+		<-closedChannel
+	}
+}
+
+func (s *Standard) ProcessTasks() {
+	taskStoreSession := s.taskStore.NewSession(s.logger)
+	defer taskStoreSession.Close()
+
+	for _, taskType := range []string{TaskTypeDeleteUser, TaskTypeExportUser} {
+		for {
+			task, err := taskStoreSession.ClaimTask(taskType, LeaseDuration)
+			if err != nil {
+				s.logger.WithError(err).Error("Unable to claim task")
+				return
+			} else if task == nil {
+				break
+			}
+
+			s.ProcessTask(taskStoreSession, task)
+		}
+	}
+}
+
+func (s *Standard) PurgeExports() {
+	s.purgeTime = time.Now()
+
+	createdBefore := s.purgeTime.AddDate(0, 0, -s.config.ExportRetentionDays)
+	if err := s.exportStore.DestroyArchivesCreatedBefore(createdBefore); err != nil {
+		s.logger.WithError(err).Error("Unable to destroy archives created before")
+	}
+}
+
+func (s *Standard) ProcessTask(taskStoreSession taskStore.Session, task *taskStore.Task) {
+	logger := s.logger.WithFields(log.Fields{"taskId": task.ID, "taskType": task.Type, "userId": task.UserID, "attempts": task.Attempts})
+
+	stopChannel := make(chan bool)
+	stoppedChannel := make(chan bool)
+	go func(heartbeatTask taskStore.Task) {
+		defer close(stoppedChannel)
+		ticker := time.NewTicker(HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopChannel:
+				return
+			case <-ticker.C:
+				if leased, err := taskStoreSession.HeartbeatTask(&heartbeatTask, LeaseDuration); err != nil {
+					logger.WithError(err).Error("Unable to heartbeat task")
+				} else if !leased {
+					logger.Error("Task lease is lost")
+					return
+				}
+			}
+		}
+	}(*task)
+
+	var err error
+	switch task.Type {
+	case TaskTypeDeleteUser:
+		err = s.deleteUser(logger, taskStoreSession, task)
+	case TaskTypeExportUser:
+		err = s.exportUser(logger, task)
+	default:
+		err = errors.Newf("worker", "task type %s is not supported", strconv.Quote(task.Type))
+	}
+
+	close(stopChannel)
+	<-stoppedChannel
+
+	if err != nil {
+		logger.WithError(err).Error("Unable to process task")
+		if err = taskStoreSession.FailTask(task, err); err != nil {
+			logger.WithError(err).Error("Unable to fail task")
+		}
+	} else if err = taskStoreSession.CompleteTask(task); err != nil {
+		logger.WithError(err).Error("Unable to complete task")
+	}
+}
+
+// Each step is idempotent and its status is persisted as soon as it is attempted, so a retried or
+// resumed task only reattempts the steps that have not yet completed
+func (s *Standard) deleteUser(logger log.Logger, taskStoreSession taskStore.Session, task *taskStore.Task) error {
+	agent := &taskAgent{userID: task.CreatedUserID}
+
+	userStoreSession := s.userStore.NewSession(logger)
+	defer userStoreSession.Close()
+	userStoreSession.SetAgent(agent)
+
+	targetUser, err := userStoreSession.GetUserByID(task.UserID)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to get user by id")
+	}
+
+	for _, stepName := range DeleteUserSteps() {
+		step := task.Step(stepName)
+		if step == nil {
+			step = taskStore.NewTaskStep(stepName)
+			task.Steps = append(task.Steps, step)
+		} else if step.IsCompleted() {
+			continue
+		}
+
+		step.Attempts++
+		if err = s.deleteUserStep(logger, agent, taskStoreSession, userStoreSession, targetUser, task, stepName); err != nil {
+			step.State = taskStore.TaskStateFailed
+			step.Error = err.Error()
+		} else {
+			step.State = taskStore.TaskStateCompleted
+			step.Error = ""
+			step.CompletedTime = time.Now().UTC().Format(time.RFC3339)
+		}
+
+		if updateErr := taskStoreSession.UpdateTaskSteps(task); updateErr != nil {
+			if err != nil {
+				logger.WithError(updateErr).Error("Unable to update task steps")
+				return err
+			}
+			return errors.Wrap(updateErr, "worker", "unable to update task steps")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Deleted user")
+
+	return nil
+}
+
+func (s *Standard) deleteUserStep(logger log.Logger, agent commonStore.Agent, taskStoreSession taskStore.Session, userStoreSession userStore.Session, targetUser *user.User, task *taskStore.Task, stepName string) error {
+	switch stepName {
+	case DeleteUserStepDeleteUser:
+		if targetUser != nil {
+			if err := userStoreSession.DeleteUser(targetUser); err != nil {
+				return errors.Wrap(err, "worker", "unable to delete user")
+			}
+		}
+	case DeleteUserStepDestroySessions:
+		sessionStoreSession := s.sessionStore.NewSession(logger)
+		defer sessionStoreSession.Close()
+		sessionStoreSession.SetAgent(agent)
+
+		if err := sessionStoreSession.DestroySessionsForUserByID(task.UserID); err != nil {
+			return errors.Wrap(err, "worker", "unable to destroy sessions for user by id")
+		}
+	case DeleteUserStepDestroyPermissions:
+		permissionStoreSession := s.permissionStore.NewSession(logger)
+		defer permissionStoreSession.Close()
+		permissionStoreSession.SetAgent(agent)
+
+		if err := permissionStoreSession.DestroyPermissionsForUserByID(task.UserID); err != nil {
+			return errors.Wrap(err, "worker", "unable to destroy permissions for user by id")
+		}
+	case DeleteUserStepDestroyNotifications:
+		notificationStoreSession := s.notificationStore.NewSession(logger)
+		defer notificationStoreSession.Close()
+		notificationStoreSession.SetAgent(agent)
+
+		if err := notificationStoreSession.DestroyNotificationsForUserByID(task.UserID); err != nil {
+			return errors.Wrap(err, "worker", "unable to destroy notifications for user by id")
+		}
+	case DeleteUserStepDestroyData:
+		if err := s.dataServicesClient.DestroyDataForUserByID(newDataServicesClientContext(logger, s.userServicesClient, task), task.UserID); err != nil {
+			return errors.Wrap(err, "worker", "unable to destroy data for user by id")
+		}
+	case DeleteUserStepDestroyMessages:
+		messageStoreSession := s.messageStore.NewSession(logger)
+		defer messageStoreSession.Close()
+		messageStoreSession.SetAgent(agent)
+
+		if err := messageStoreSession.DestroyMessagesForUserByID(task.UserID); err != nil {
+			return errors.Wrap(err, "worker", "unable to destroy messages for user by id")
+		}
+	case DeleteUserStepDeleteMessagesFromUser:
+		messageUser := &messageStore.User{
+			ID: task.UserID,
+		}
+
+		if targetUser != nil && targetUser.ProfileID != nil {
+			profileStoreSession := s.profileStore.NewSession(logger)
+			defer profileStoreSession.Close()
+			profileStoreSession.SetAgent(agent)
+
+			profile, err := profileStoreSession.GetProfileByID(*targetUser.ProfileID)
+			if err != nil {
+				return errors.Wrap(err, "worker", "unable to get profile by id")
+			}
+			if profile != nil && profile.FullName != nil {
+				messageUser.FullName = *profile.FullName
+			}
+		}
+
+		messageStoreSession := s.messageStore.NewSession(logger)
+		defer messageStoreSession.Close()
+		messageStoreSession.SetAgent(agent)
+
+		if err := messageStoreSession.DeleteMessagesFromUser(messageUser); err != nil {
+			return errors.Wrap(err, "worker", "unable to delete messages from user")
+		}
+	case DeleteUserStepDestroyProfile:
+		if targetUser != nil && targetUser.ProfileID != nil {
+			profileStoreSession := s.profileStore.NewSession(logger)
+			defer profileStoreSession.Close()
+			profileStoreSession.SetAgent(agent)
+
+			if err := profileStoreSession.DestroyProfileByID(*targetUser.ProfileID); err != nil {
+				return errors.Wrap(err, "worker", "unable to destroy profile by id")
+			}
+		}
+	case DeleteUserStepDestroyTasks:
+		// The delete user task itself is kept, so its steps and status remain available
+		if err := taskStoreSession.DestroyOtherTasksForUserByID(task.UserID, task.ID); err != nil {
+			return errors.Wrap(err, "worker", "unable to destroy other tasks for user by id")
+		}
+	case DeleteUserStepDestroyExports:
+		if err := s.exportStore.DestroyArchivesForUserByID(task.UserID); err != nil {
+			return errors.Wrap(err, "worker", "unable to destroy archives for user by id")
+		}
+	case DeleteUserStepDestroyUser:
+		if err := userStoreSession.DestroyUserByID(task.UserID); err != nil {
+			return errors.Wrap(err, "worker", "unable to destroy user by id")
+		}
+	default:
+		return errors.Newf("worker", "delete user step %s is not supported", strconv.Quote(stepName))
+	}
+
+	return nil
+}
+
+func (s *Standard) exportUser(logger log.Logger, task *taskStore.Task) error {
+	archiveWriter, err := s.exportStore.CreateArchive(task.ID, task.UserID)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to create archive")
+	}
+
+	if err = s.writeArchive(logger, zip.NewWriter(archiveWriter), task); err != nil {
+		if abortErr := archiveWriter.Abort(); abortErr != nil {
+			logger.WithError(abortErr).Error("Unable to abort archive")
+		}
+		return err
+	}
+
+	if err = archiveWriter.Commit(); err != nil {
+		return errors.Wrap(err, "worker", "unable to commit archive")
+	}
+
+	logger.Info("Exported user")
+
+	return nil
+}
+
+func (s *Standard) writeArchive(logger log.Logger, zipWriter *zip.Writer, task *taskStore.Task) error {
+	userStoreSession := s.userStore.NewSession(logger)
+	defer userStoreSession.Close()
+
+	user, err := userStoreSession.GetUserByID(task.UserID)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to get user by id")
+	}
+	if user == nil {
+		return errors.Newf("worker", "user with id %s not found", strconv.Quote(task.UserID))
+	}
+	if err = writeArchiveJSON(zipWriter, ArchiveFileUser, user); err != nil {
+		return err
+	}
+
+	var profileValue interface{}
+	if user.ProfileID != nil {
+		profileStoreSession := s.profileStore.NewSession(logger)
+		defer profileStoreSession.Close()
+
+		profile, profileErr := profileStoreSession.GetProfileByID(*user.ProfileID)
+		if profileErr != nil {
+			return errors.Wrap(profileErr, "worker", "unable to get profile by id")
+		}
+		if profile != nil && profile.Value != "" {
+			if json.Valid([]byte(profile.Value)) {
+				profileValue = json.RawMessage(profile.Value)
+			} else {
+				profileValue = profile.Value
+			}
+		}
+	}
+	if err = writeArchiveJSON(zipWriter, ArchiveFileProfile, profileValue); err != nil {
+		return err
+	}
+
+	permissionStoreSession := s.permissionStore.NewSession(logger)
+	defer permissionStoreSession.Close()
+
+	permissions, err := permissionStoreSession.GetPermissionsForUserByID(task.UserID)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to get permissions for user by id")
+	}
+	if err = writeArchiveJSON(zipWriter, ArchiveFilePermissions, permissions); err != nil {
+		return err
+	}
+
+	messageStoreSession := s.messageStore.NewSession(logger)
+	defer messageStoreSession.Close()
+
+	messages, err := messageStoreSession.GetMessagesForUserByID(task.UserID)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to get messages for user by id")
+	}
+	if err = writeArchiveJSON(zipWriter, ArchiveFileMessages, messages); err != nil {
+		return err
+	}
+
+	notificationStoreSession := s.notificationStore.NewSession(logger)
+	defer notificationStoreSession.Close()
+
+	notifications, err := notificationStoreSession.GetNotificationsForUserByID(task.UserID)
+	if err != nil {
+		return errors.Wrap(err, "worker", "unable to get notifications for user by id")
+	}
+	if err = writeArchiveJSON(zipWriter, ArchiveFileNotifications, notifications); err != nil {
+		return err
+	}
+
+	dataWriter, err := zipWriter.Create(ArchiveFileData)
+	if err != nil {
+		return errors.Wrapf(err, "worker", "unable to create archive file %s", strconv.Quote(ArchiveFileData))
+	}
+	if err = s.dataServicesClient.StreamDataForUserByID(newDataServicesClientContext(logger, s.userServicesClient, task), task.UserID, dataWriter); err != nil {
+		return errors.Wrap(err, "worker", "unable to stream data for user by id")
+	}
+
+	if err = zipWriter.Close(); err != nil {
+		return errors.Wrap(err, "worker", "unable to close archive")
+	}
+
+	return nil
+}
+
+func writeArchiveJSON(zipWriter *zip.Writer, name string, value interface{}) error {
+	fileWriter, err := zipWriter.Create(name)
+	if err != nil {
+		return errors.Wrapf(err, "worker", "unable to create archive file %s", strconv.Quote(name))
+	}
+
+	if err = json.NewEncoder(fileWriter).Encode(value); err != nil {
+		return errors.Wrapf(err, "worker", "unable to encode archive file %s", strconv.Quote(name))
+	}
+
+	return nil
+}
+
+type taskAgent struct {
+	userID string
+}
+
+func (t *taskAgent) IsServer() bool {
+	return t.userID == ""
+}
+
+func (t *taskAgent) UserID() string {
+	return t.userID
+}
+
+type dataServicesClientContext struct {
+	logger             log.Logger
+	request            *rest.Request
+	userServicesClient userservicesClient.Client
+}
+
+// The worker has no incoming request, so the task id is used to trace requests made on its behalf
+func newDataServicesClientContext(logger log.Logger, userServicesClient userservicesClient.Client, task *taskStore.Task) *dataServicesClientContext {
+	request := &rest.Request{
+		Request: &http.Request{Header: http.Header{}},
+		Env:     map[string]interface{}{},
+	}
+	service.SetRequestTraceRequest(request, task.ID)
+
+	return &dataServicesClientContext{
+		logger:             logger,
+		request:            request,
+		userServicesClient: userServicesClient,
+	}
+}
+
+func (d *dataServicesClientContext) Logger() log.Logger {
+	return d.logger
+}
+
+func (d *dataServicesClientContext) Request() *rest.Request {
+	return d.request
+}
+
+func (d *dataServicesClientContext) UserServicesClient() userservicesClient.Client {
+	return d.userServicesClient
+}
@@ -0,0 +1,511 @@
+package worker_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"time"
+
+	"github.com/tidepool-org/platform/app"
+	"github.com/tidepool-org/platform/log"
+	messageStore "github.com/tidepool-org/platform/message/store"
+	"github.com/tidepool-org/platform/profile"
+	"github.com/tidepool-org/platform/service"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
+	"github.com/tidepool-org/platform/user"
+	"github.com/tidepool-org/platform/userservices/service/worker"
+)
+
+func ReadArchive(content []byte) map[string]string {
+	zipReader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	Expect(err).ToNot(HaveOccurred())
+	files := map[string]string{}
+	for _, zipFile := range zipReader.File {
+		fileReader, err := zipFile.Open()
+		Expect(err).ToNot(HaveOccurred())
+		fileContent, err := ioutil.ReadAll(fileReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileReader.Close()).To(Succeed())
+		files[zipFile.Name] = string(fileContent)
+	}
+	return files
+}
+
+var _ = Describe("Standard", func() {
+	var logger log.Logger
+	var config *worker.Config
+	var userServicesClient *TestUserServicesClient
+	var dataServicesClient *TestDataServicesClient
+	var exportStoreImpl *TestExportStore
+	var messageStoreImpl *TestMessageStore
+	var notificationStoreImpl *TestNotificationStore
+	var permissionStoreImpl *TestPermissionStore
+	var profileStoreImpl *TestProfileStore
+	var sessionStoreImpl *TestSessionStore
+	var taskStoreImpl *TestTaskStore
+	var userStoreImpl *TestUserStore
+
+	BeforeEach(func() {
+		logger = log.NewNull()
+		config = &worker.Config{ExportRetentionDays: 7}
+		userServicesClient = &TestUserServicesClient{}
+		dataServicesClient = &TestDataServicesClient{}
+		exportStoreImpl = &TestExportStore{}
+		messageStoreImpl = &TestMessageStore{SessionImpl: &TestMessageStoreSession{}}
+		notificationStoreImpl = &TestNotificationStore{SessionImpl: &TestNotificationStoreSession{}}
+		permissionStoreImpl = &TestPermissionStore{SessionImpl: &TestPermissionStoreSession{}}
+		profileStoreImpl = &TestProfileStore{SessionImpl: &TestProfileStoreSession{}}
+		sessionStoreImpl = &TestSessionStore{SessionImpl: &TestSessionStoreSession{}}
+		taskStoreImpl = &TestTaskStore{SessionImpl: testTaskStore.NewSession()}
+		userStoreImpl = &TestUserStore{SessionImpl: &TestUserStoreSession{}}
+	})
+
+	Context("NewStandard", func() {
+		It("returns an error if the logger is missing", func() {
+			standard, err := worker.NewStandard(nil, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: logger is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the config is missing", func() {
+			standard, err := worker.NewStandard(logger, nil, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: config is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the config is invalid", func() {
+			config.ExportRetentionDays = 0
+			standard, err := worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: config is invalid; worker: export retention days is invalid"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the user services client is missing", func() {
+			standard, err := worker.NewStandard(logger, config, nil, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: user services client is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the data services client is missing", func() {
+			standard, err := worker.NewStandard(logger, config, userServicesClient, nil, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: data services client is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the export store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, userServicesClient, dataServicesClient, nil, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: export store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the message store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, nil, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: message store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the notification store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, nil, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: notification store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the permission store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, nil, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: permission store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the profile store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, nil, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: profile store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the session store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, nil, taskStoreImpl, userStoreImpl)
+			Expect(err).To(MatchError("worker: session store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the task store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, nil, userStoreImpl)
+			Expect(err).To(MatchError("worker: task store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns an error if the user store is missing", func() {
+			standard, err := worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, nil)
+			Expect(err).To(MatchError("worker: user store is missing"))
+			Expect(standard).To(BeNil())
+		})
+
+		It("returns successfully", func() {
+			Expect(worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)).ToNot(BeNil())
+		})
+	})
+
+	Context("with new standard", func() {
+		var standard *worker.Standard
+		var taskStoreSession *testTaskStore.Session
+		var archiveWriter *TestArchiveWriter
+		var profileID string
+		var targetUser *user.User
+		var task *taskStore.Task
+
+		BeforeEach(func() {
+			var err error
+			standard, err = worker.NewStandard(logger, config, userServicesClient, dataServicesClient, exportStoreImpl, messageStoreImpl, notificationStoreImpl, permissionStoreImpl, profileStoreImpl, sessionStoreImpl, taskStoreImpl, userStoreImpl)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(standard).ToNot(BeNil())
+			taskStoreSession = taskStoreImpl.SessionImpl
+			archiveWriter = &TestArchiveWriter{}
+			profileID = app.NewID()
+			targetUser = &user.User{
+				ID:           app.NewID(),
+				Email:        "test@test.com",
+				PasswordHash: "test-password-hash",
+				ProfileID:    &profileID,
+			}
+			task = taskStore.NewTask(worker.TaskTypeExportUser)
+			task.ID = app.NewID()
+			task.UserID = targetUser.ID
+			task.LeaseID = app.NewID()
+			task.State = taskStore.TaskStateRunning
+			task.Attempts = 1
+		})
+
+		AfterEach(func() {
+			Expect(taskStoreSession.UnusedOutputsCount()).To(Equal(0))
+			Expect(dataServicesClient.UnusedOutputsCount()).To(Equal(0))
+			Expect(exportStoreImpl.UnusedOutputsCount()).To(Equal(0))
+			Expect(archiveWriter.UnusedOutputsCount()).To(Equal(0))
+		})
+
+		Context("ProcessTasks", func() {
+			It("claims and processes delete and export tasks until none remain", func() {
+				exportStoreImpl.CreateArchiveOutputs = []CreateArchiveOutput{{ArchiveWriter: nil, Error: errors.New("test error")}}
+				taskStoreSession.ClaimTaskOutputs = []testTaskStore.ClaimTaskOutput{{Task: nil, Error: nil}, {Task: task, Error: nil}, {Task: nil, Error: nil}}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTasks()
+				Expect(taskStoreSession.ClaimTaskInputs).To(Equal([]testTaskStore.ClaimTaskInput{
+					{TaskType: worker.TaskTypeDeleteUser, LeaseDuration: worker.LeaseDuration},
+					{TaskType: worker.TaskTypeExportUser, LeaseDuration: worker.LeaseDuration},
+					{TaskType: worker.TaskTypeExportUser, LeaseDuration: worker.LeaseDuration},
+				}))
+				Expect(taskStoreSession.CloseInvocations).To(Equal(1))
+			})
+
+			It("stops if claim task returns an error", func() {
+				taskStoreSession.ClaimTaskOutputs = []testTaskStore.ClaimTaskOutput{{Task: nil, Error: errors.New("test error")}}
+				standard.ProcessTasks()
+				Expect(taskStoreSession.ClaimTaskInvocations).To(Equal(1))
+			})
+		})
+
+		Context("PurgeExports", func() {
+			It("destroys archives created before the retention", func() {
+				exportStoreImpl.DestroyArchivesCreatedBeforeOutputs = []error{nil}
+				standard.PurgeExports()
+				Expect(exportStoreImpl.DestroyArchivesCreatedBeforeInputs).To(HaveLen(1))
+				Expect(exportStoreImpl.DestroyArchivesCreatedBeforeInputs[0]).To(BeTemporally("~", time.Now().AddDate(0, 0, -7), time.Minute))
+			})
+
+			It("does not panic if destroy archives returns an error", func() {
+				exportStoreImpl.DestroyArchivesCreatedBeforeOutputs = []error{errors.New("test error")}
+				standard.PurgeExports()
+				Expect(exportStoreImpl.DestroyArchivesCreatedBeforeInputs).To(HaveLen(1))
+			})
+		})
+
+		Context("ProcessTask", func() {
+			BeforeEach(func() {
+				exportStoreImpl.CreateArchiveOutputs = []CreateArchiveOutput{{ArchiveWriter: archiveWriter, Error: nil}}
+				userStoreImpl.SessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{User: targetUser, Error: nil}}
+				profileStoreImpl.SessionImpl.GetProfileByIDOutputs = []GetProfileByIDOutput{{Profile: &profile.Profile{ID: profileID, Value: `{"fullName":"Test User"}`}, Error: nil}}
+				permissionStoreImpl.SessionImpl.GetPermissionsForUserByIDOutputs = []GetDocumentsOutput{{Documents: []map[string]interface{}{{"groupId": targetUser.ID, "userId": targetUser.ID}}, Error: nil}}
+				messageStoreImpl.SessionImpl.GetMessagesForUserByIDOutputs = []GetDocumentsOutput{{Documents: []map[string]interface{}{{"groupid": targetUser.ID, "messagetext": "test"}}, Error: nil}}
+				notificationStoreImpl.SessionImpl.GetNotificationsForUserByIDOutputs = []GetDocumentsOutput{{Documents: []map[string]interface{}{}, Error: nil}}
+				dataServicesClient.StreamDataForUserByIDOutputs = []StreamDataForUserByIDOutput{{Data: "{\"type\":\"cbg\"}\n", Error: nil}}
+			})
+
+			It("exports the user to a committed archive and completes the task", func() {
+				archiveWriter.CommitOutputs = []error{nil}
+				taskStoreSession.CompleteTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(exportStoreImpl.CreateArchiveInputs).To(Equal([]CreateArchiveInput{{ExportID: task.ID, UserID: targetUser.ID}}))
+				Expect(userStoreImpl.SessionImpl.GetUserByIDInputs).To(Equal([]string{targetUser.ID}))
+				Expect(profileStoreImpl.SessionImpl.GetProfileByIDInputs).To(Equal([]string{profileID}))
+				Expect(dataServicesClient.StreamDataForUserByIDInputs).To(HaveLen(1))
+				Expect(dataServicesClient.StreamDataForUserByIDInputs[0].UserID).To(Equal(targetUser.ID))
+				Expect(service.GetRequestTraceRequest(dataServicesClient.StreamDataForUserByIDInputs[0].Context.Request())).To(Equal(task.ID))
+				Expect(archiveWriter.CommitInvocations).To(Equal(1))
+				Expect(taskStoreSession.CompleteTaskInputs).To(Equal([]*taskStore.Task{task}))
+				files := ReadArchive(archiveWriter.Bytes())
+				Expect(files).To(HaveLen(6))
+				Expect(files[worker.ArchiveFileUser]).To(ContainSubstring(targetUser.ID))
+				Expect(files[worker.ArchiveFileUser]).ToNot(ContainSubstring("test-password-hash"))
+				Expect(files[worker.ArchiveFileProfile]).To(MatchJSON(`{"fullName":"Test User"}`))
+				Expect(files[worker.ArchiveFilePermissions]).To(MatchJSON(`[{"groupId":"` + targetUser.ID + `","userId":"` + targetUser.ID + `"}]`))
+				Expect(files[worker.ArchiveFileMessages]).To(MatchJSON(`[{"groupid":"` + targetUser.ID + `","messagetext":"test"}]`))
+				Expect(files[worker.ArchiveFileNotifications]).To(MatchJSON(`[]`))
+				Expect(files[worker.ArchiveFileData]).To(Equal("{\"type\":\"cbg\"}\n"))
+			})
+
+			It("exports a null profile if the user does not have a profile", func() {
+				targetUser.ProfileID = nil
+				profileStoreImpl.SessionImpl.GetProfileByIDOutputs = nil
+				archiveWriter.CommitOutputs = []error{nil}
+				taskStoreSession.CompleteTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(profileStoreImpl.SessionImpl.GetProfileByIDInputs).To(BeEmpty())
+				Expect(ReadArchive(archiveWriter.Bytes())[worker.ArchiveFileProfile]).To(MatchJSON(`null`))
+			})
+
+			It("fails the task if create archive returns an error", func() {
+				exportStoreImpl.CreateArchiveOutputs = []CreateArchiveOutput{{ArchiveWriter: nil, Error: errors.New("test error")}}
+				userStoreImpl.SessionImpl.GetUserByIDOutputs = nil
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				dataServicesClient.StreamDataForUserByIDOutputs = nil
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to create archive; test error"))
+			})
+
+			It("aborts the archive and fails the task if the user is not found", func() {
+				userStoreImpl.SessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{User: nil, Error: nil}}
+				dataServicesClient.StreamDataForUserByIDOutputs = nil
+				archiveWriter.AbortOutputs = []error{nil}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(archiveWriter.AbortInvocations).To(Equal(1))
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError(`worker: user with id "` + targetUser.ID + `" not found`))
+			})
+
+			It("aborts the archive and fails the task if stream data returns an error", func() {
+				dataServicesClient.StreamDataForUserByIDOutputs = []StreamDataForUserByIDOutput{{Data: "", Error: errors.New("test error")}}
+				archiveWriter.AbortOutputs = []error{nil}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(archiveWriter.AbortInvocations).To(Equal(1))
+				Expect(archiveWriter.CommitInvocations).To(Equal(0))
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to stream data for user by id; test error"))
+			})
+
+			It("fails the task if commit archive returns an error", func() {
+				archiveWriter.CommitOutputs = []error{errors.New("test error")}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to commit archive; test error"))
+			})
+
+			It("fails the task if the task type is not supported", func() {
+				task.Type = "unknown"
+				exportStoreImpl.CreateArchiveOutputs = nil
+				userStoreImpl.SessionImpl.GetUserByIDOutputs = nil
+				profileStoreImpl.SessionImpl.GetProfileByIDOutputs = nil
+				permissionStoreImpl.SessionImpl.GetPermissionsForUserByIDOutputs = nil
+				messageStoreImpl.SessionImpl.GetMessagesForUserByIDOutputs = nil
+				notificationStoreImpl.SessionImpl.GetNotificationsForUserByIDOutputs = nil
+				dataServicesClient.StreamDataForUserByIDOutputs = nil
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError(`worker: task type "unknown" is not supported`))
+			})
+		})
+
+		Context("ProcessTask with delete user task", func() {
+			var fullName string
+
+			BeforeEach(func() {
+				fullName = "Test User"
+				task = worker.NewDeleteUserTask(targetUser.ID)
+				task.ID = app.NewID()
+				task.LeaseID = app.NewID()
+				task.State = taskStore.TaskStateRunning
+				task.Attempts = 1
+				task.CreatedUserID = app.NewID()
+				userStoreImpl.SessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{User: targetUser, Error: nil}}
+			})
+
+			AfterEach(func() {
+				Expect(userStoreImpl.SessionImpl.UnusedOutputsCount()).To(Equal(0))
+				Expect(sessionStoreImpl.SessionImpl.UnusedOutputsCount()).To(Equal(0))
+				Expect(permissionStoreImpl.SessionImpl.UnusedOutputsCount()).To(Equal(0))
+				Expect(notificationStoreImpl.SessionImpl.UnusedOutputsCount()).To(Equal(0))
+				Expect(messageStoreImpl.SessionImpl.UnusedOutputsCount()).To(Equal(0))
+				Expect(profileStoreImpl.SessionImpl.UnusedOutputsCount()).To(Equal(0))
+			})
+
+			It("runs all steps in order, recording each as completed, and completes the task", func() {
+				userStoreImpl.SessionImpl.DeleteUserOutputs = []error{nil}
+				sessionStoreImpl.SessionImpl.DestroySessionsForUserByIDOutputs = []error{nil}
+				permissionStoreImpl.SessionImpl.DestroyPermissionsForUserByIDOutputs = []error{nil}
+				notificationStoreImpl.SessionImpl.DestroyNotificationsForUserByIDOutputs = []error{nil}
+				dataServicesClient.DestroyDataForUserByIDOutputs = []error{nil}
+				messageStoreImpl.SessionImpl.DestroyMessagesForUserByIDOutputs = []error{nil}
+				profileStoreImpl.SessionImpl.GetProfileByIDOutputs = []GetProfileByIDOutput{{Profile: &profile.Profile{ID: profileID, FullName: &fullName}, Error: nil}}
+				messageStoreImpl.SessionImpl.DeleteMessagesFromUserOutputs = []error{nil}
+				profileStoreImpl.SessionImpl.DestroyProfileByIDOutputs = []error{nil}
+				taskStoreSession.DestroyOtherTasksForUserByIDOutputs = []error{nil}
+				exportStoreImpl.DestroyArchivesForUserByIDOutputs = []error{nil}
+				userStoreImpl.SessionImpl.DestroyUserByIDOutputs = []error{nil}
+				taskStoreSession.UpdateTaskStepsOutputs = []error{nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil}
+				taskStoreSession.CompleteTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(userStoreImpl.SessionImpl.SetAgentInputs).To(HaveLen(1))
+				Expect(userStoreImpl.SessionImpl.SetAgentInputs[0].UserID()).To(Equal(task.CreatedUserID))
+				Expect(userStoreImpl.SessionImpl.DeleteUserInputs).To(Equal([]*user.User{targetUser}))
+				Expect(sessionStoreImpl.SessionImpl.DestroySessionsForUserByIDInputs).To(Equal([]string{targetUser.ID}))
+				Expect(permissionStoreImpl.SessionImpl.DestroyPermissionsForUserByIDInputs).To(Equal([]string{targetUser.ID}))
+				Expect(notificationStoreImpl.SessionImpl.DestroyNotificationsForUserByIDInputs).To(Equal([]string{targetUser.ID}))
+				Expect(dataServicesClient.DestroyDataForUserByIDInputs).To(HaveLen(1))
+				Expect(dataServicesClient.DestroyDataForUserByIDInputs[0].UserID).To(Equal(targetUser.ID))
+				Expect(service.GetRequestTraceRequest(dataServicesClient.DestroyDataForUserByIDInputs[0].Context.Request())).To(Equal(task.ID))
+				Expect(messageStoreImpl.SessionImpl.DestroyMessagesForUserByIDInputs).To(Equal([]string{targetUser.ID}))
+				Expect(messageStoreImpl.SessionImpl.DeleteMessagesFromUserInputs).To(Equal([]*messageStore.User{{ID: targetUser.ID, FullName: fullName}}))
+				Expect(profileStoreImpl.SessionImpl.DestroyProfileByIDInputs).To(Equal([]string{profileID}))
+				Expect(taskStoreSession.DestroyOtherTasksForUserByIDInputs).To(Equal([]testTaskStore.DestroyOtherTasksForUserByIDInput{{UserID: targetUser.ID, TaskID: task.ID}}))
+				Expect(exportStoreImpl.DestroyArchivesForUserByIDInputs).To(Equal([]string{targetUser.ID}))
+				Expect(userStoreImpl.SessionImpl.DestroyUserByIDInputs).To(Equal([]string{targetUser.ID}))
+				Expect(taskStoreSession.UpdateTaskStepsInputs).To(HaveLen(11))
+				Expect(task.Steps).To(HaveLen(11))
+				for index, step := range task.Steps {
+					Expect(step.Name).To(Equal(worker.DeleteUserSteps()[index]))
+					Expect(step.State).To(Equal(taskStore.TaskStateCompleted))
+					Expect(step.Attempts).To(Equal(1))
+					Expect(step.CompletedTime).ToNot(BeEmpty())
+				}
+				Expect(taskStoreSession.CompleteTaskInputs).To(Equal([]*taskStore.Task{task}))
+			})
+
+			It("records the failed step and fails the task if a step returns an error", func() {
+				userStoreImpl.SessionImpl.DeleteUserOutputs = []error{nil}
+				sessionStoreImpl.SessionImpl.DestroySessionsForUserByIDOutputs = []error{nil}
+				permissionStoreImpl.SessionImpl.DestroyPermissionsForUserByIDOutputs = []error{errors.New("test error")}
+				taskStoreSession.UpdateTaskStepsOutputs = []error{nil, nil, nil}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(task.Step(worker.DeleteUserStepDestroySessions).State).To(Equal(taskStore.TaskStateCompleted))
+				Expect(task.Step(worker.DeleteUserStepDestroyPermissions).State).To(Equal(taskStore.TaskStateFailed))
+				Expect(task.Step(worker.DeleteUserStepDestroyPermissions).Attempts).To(Equal(1))
+				Expect(task.Step(worker.DeleteUserStepDestroyPermissions).Error).To(Equal("worker: unable to destroy permissions for user by id; test error"))
+				Expect(task.Step(worker.DeleteUserStepDestroyNotifications).State).To(Equal(taskStore.TaskStatePending))
+				Expect(notificationStoreImpl.SessionImpl.DestroyNotificationsForUserByIDInputs).To(BeEmpty())
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to destroy permissions for user by id; test error"))
+			})
+
+			It("resumes from the first step that is not completed", func() {
+				for _, stepName := range []string{worker.DeleteUserStepDeleteUser, worker.DeleteUserStepDestroySessions, worker.DeleteUserStepDestroyPermissions, worker.DeleteUserStepDestroyNotifications, worker.DeleteUserStepDestroyData} {
+					task.Step(stepName).State = taskStore.TaskStateCompleted
+				}
+				task.Step(worker.DeleteUserStepDestroyMessages).State = taskStore.TaskStateFailed
+				task.Step(worker.DeleteUserStepDestroyMessages).Attempts = 2
+				messageStoreImpl.SessionImpl.DestroyMessagesForUserByIDOutputs = []error{nil}
+				profileStoreImpl.SessionImpl.GetProfileByIDOutputs = []GetProfileByIDOutput{{Profile: nil, Error: nil}}
+				messageStoreImpl.SessionImpl.DeleteMessagesFromUserOutputs = []error{nil}
+				profileStoreImpl.SessionImpl.DestroyProfileByIDOutputs = []error{nil}
+				taskStoreSession.DestroyOtherTasksForUserByIDOutputs = []error{nil}
+				exportStoreImpl.DestroyArchivesForUserByIDOutputs = []error{nil}
+				userStoreImpl.SessionImpl.DestroyUserByIDOutputs = []error{nil}
+				taskStoreSession.UpdateTaskStepsOutputs = []error{nil, nil, nil, nil, nil, nil}
+				taskStoreSession.CompleteTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(userStoreImpl.SessionImpl.DeleteUserInputs).To(BeEmpty())
+				Expect(sessionStoreImpl.SessionImpl.DestroySessionsForUserByIDInputs).To(BeEmpty())
+				Expect(dataServicesClient.DestroyDataForUserByIDInputs).To(BeEmpty())
+				Expect(messageStoreImpl.SessionImpl.DeleteMessagesFromUserInputs).To(Equal([]*messageStore.User{{ID: targetUser.ID}}))
+				Expect(task.Step(worker.DeleteUserStepDestroyMessages).State).To(Equal(taskStore.TaskStateCompleted))
+				Expect(task.Step(worker.DeleteUserStepDestroyMessages).Attempts).To(Equal(3))
+				Expect(taskStoreSession.UpdateTaskStepsInputs).To(HaveLen(6))
+				Expect(taskStoreSession.CompleteTaskInputs).To(Equal([]*taskStore.Task{task}))
+			})
+
+			It("skips the steps that require the user if the user is not found", func() {
+				userStoreImpl.SessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{User: nil, Error: nil}}
+				sessionStoreImpl.SessionImpl.DestroySessionsForUserByIDOutputs = []error{nil}
+				permissionStoreImpl.SessionImpl.DestroyPermissionsForUserByIDOutputs = []error{nil}
+				notificationStoreImpl.SessionImpl.DestroyNotificationsForUserByIDOutputs = []error{nil}
+				dataServicesClient.DestroyDataForUserByIDOutputs = []error{nil}
+				messageStoreImpl.SessionImpl.DestroyMessagesForUserByIDOutputs = []error{nil}
+				messageStoreImpl.SessionImpl.DeleteMessagesFromUserOutputs = []error{nil}
+				taskStoreSession.DestroyOtherTasksForUserByIDOutputs = []error{nil}
+				exportStoreImpl.DestroyArchivesForUserByIDOutputs = []error{nil}
+				userStoreImpl.SessionImpl.DestroyUserByIDOutputs = []error{nil}
+				taskStoreSession.UpdateTaskStepsOutputs = []error{nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil}
+				taskStoreSession.CompleteTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(userStoreImpl.SessionImpl.DeleteUserInputs).To(BeEmpty())
+				Expect(profileStoreImpl.SessionImpl.GetProfileByIDInputs).To(BeEmpty())
+				Expect(profileStoreImpl.SessionImpl.DestroyProfileByIDInputs).To(BeEmpty())
+				Expect(messageStoreImpl.SessionImpl.DeleteMessagesFromUserInputs).To(Equal([]*messageStore.User{{ID: targetUser.ID}}))
+				Expect(taskStoreSession.CompleteTaskInputs).To(Equal([]*taskStore.Task{task}))
+			})
+
+			It("records the failed step and fails the task if destroy other tasks returns an error", func() {
+				for _, stepName := range worker.DeleteUserSteps() {
+					if stepName == worker.DeleteUserStepDestroyTasks {
+						break
+					}
+					task.Step(stepName).State = taskStore.TaskStateCompleted
+				}
+				taskStoreSession.DestroyOtherTasksForUserByIDOutputs = []error{errors.New("test error")}
+				taskStoreSession.UpdateTaskStepsOutputs = []error{nil}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(task.Step(worker.DeleteUserStepDestroyTasks).State).To(Equal(taskStore.TaskStateFailed))
+				Expect(task.Step(worker.DeleteUserStepDestroyExports).State).To(Equal(taskStore.TaskStatePending))
+				Expect(exportStoreImpl.DestroyArchivesForUserByIDInputs).To(BeEmpty())
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to destroy other tasks for user by id; test error"))
+			})
+
+			It("records the failed step and fails the task if destroy archives returns an error", func() {
+				for _, stepName := range worker.DeleteUserSteps() {
+					if stepName == worker.DeleteUserStepDestroyExports {
+						break
+					}
+					task.Step(stepName).State = taskStore.TaskStateCompleted
+				}
+				exportStoreImpl.DestroyArchivesForUserByIDOutputs = []error{errors.New("test error")}
+				taskStoreSession.UpdateTaskStepsOutputs = []error{nil}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(task.Step(worker.DeleteUserStepDestroyExports).State).To(Equal(taskStore.TaskStateFailed))
+				Expect(task.Step(worker.DeleteUserStepDestroyUser).State).To(Equal(taskStore.TaskStatePending))
+				Expect(userStoreImpl.SessionImpl.DestroyUserByIDInputs).To(BeEmpty())
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to destroy archives for user by id; test error"))
+			})
+
+			It("fails the task if get user returns an error", func() {
+				userStoreImpl.SessionImpl.GetUserByIDOutputs = []GetUserByIDOutput{{User: nil, Error: errors.New("test error")}}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(taskStoreSession.UpdateTaskStepsInputs).To(BeEmpty())
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to get user by id; test error"))
+			})
+
+			It("fails the task if update task steps returns an error", func() {
+				userStoreImpl.SessionImpl.DeleteUserOutputs = []error{nil}
+				taskStoreSession.UpdateTaskStepsOutputs = []error{errors.New("test error")}
+				taskStoreSession.FailTaskOutputs = []error{nil}
+				standard.ProcessTask(taskStoreSession, task)
+				Expect(sessionStoreImpl.SessionImpl.DestroySessionsForUserByIDInputs).To(BeEmpty())
+				Expect(taskStoreSession.FailTaskInputs).To(HaveLen(1))
+				Expect(taskStoreSession.FailTaskInputs[0].Failure).To(MatchError("worker: unable to update task steps; test error"))
+			})
+		})
+	})
+})
@@ -0,0 +1,482 @@
+package worker_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	dataservicesClient "github.com/tidepool-org/platform/dataservices/client"
+	exportStore "github.com/tidepool-org/platform/export/store"
+	"github.com/tidepool-org/platform/log"
+	messageStore "github.com/tidepool-org/platform/message/store"
+	notificationStore "github.com/tidepool-org/platform/notification/store"
+	permissionStore "github.com/tidepool-org/platform/permission/store"
+	"github.com/tidepool-org/platform/profile"
+	profileStore "github.com/tidepool-org/platform/profile/store"
+	"github.com/tidepool-org/platform/service"
+	sessionStore "github.com/tidepool-org/platform/session/store"
+	commonStore "github.com/tidepool-org/platform/store"
+	taskStore "github.com/tidepool-org/platform/task/store"
+	testTaskStore "github.com/tidepool-org/platform/task/store/test"
+	"github.com/tidepool-org/platform/user"
+	userStore "github.com/tidepool-org/platform/user/store"
+	userservicesClient "github.com/tidepool-org/platform/userservices/client"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "userservices/service/worker")
+}
+
+type TestUserServicesClient struct{}
+
+func (t *TestUserServicesClient) ValidateAuthenticationToken(context service.Context, authenticationToken string) (userservicesClient.AuthenticationDetails, error) {
+	panic("Unexpected invocation of ValidateAuthenticationToken on TestUserServicesClient")
+}
+
+func (t *TestUserServicesClient) GetUserPermissions(context service.Context, requestUserID string, targetUserID string) (userservicesClient.Permissions, error) {
+	panic("Unexpected invocation of GetUserPermissions on TestUserServicesClient")
+}
+
+func (t *TestUserServicesClient) GetUserGroupID(context service.Context, userID string) (string, error) {
+	panic("Unexpected invocation of GetUserGroupID on TestUserServicesClient")
+}
+
+func (t *TestUserServicesClient) ServerToken() (string, error) {
+	panic("Unexpected invocation of ServerToken on TestUserServicesClient")
+}
+
+type StreamDataForUserByIDInput struct {
+	Context dataservicesClient.Context
+	UserID  string
+}
+
+type StreamDataForUserByIDOutput struct {
+	Data  string
+	Error error
+}
+
+type DestroyDataForUserByIDInput struct {
+	Context dataservicesClient.Context
+	UserID  string
+}
+
+type TestDataServicesClient struct {
+	DestroyDataForUserByIDInputs  []DestroyDataForUserByIDInput
+	DestroyDataForUserByIDOutputs []error
+	StreamDataForUserByIDInputs   []StreamDataForUserByIDInput
+	StreamDataForUserByIDOutputs  []StreamDataForUserByIDOutput
+}
+
+func (t *TestDataServicesClient) DestroyDataForUserByID(context dataservicesClient.Context, userID string) error {
+	t.DestroyDataForUserByIDInputs = append(t.DestroyDataForUserByIDInputs, DestroyDataForUserByIDInput{context, userID})
+	output := t.DestroyDataForUserByIDOutputs[0]
+	t.DestroyDataForUserByIDOutputs = t.DestroyDataForUserByIDOutputs[1:]
+	return output
+}
+
+func (t *TestDataServicesClient) LockDataForUserByID(context dataservicesClient.Context, userID string) error {
+	panic("Unexpected invocation of LockDataForUserByID on TestDataServicesClient")
+}
+
+func (t *TestDataServicesClient) UnlockDataForUserByID(context dataservicesClient.Context, userID string) error {
+	panic("Unexpected invocation of UnlockDataForUserByID on TestDataServicesClient")
+}
+
+func (t *TestDataServicesClient) StreamDataForUserByID(context dataservicesClient.Context, userID string, writer io.Writer) error {
+	t.StreamDataForUserByIDInputs = append(t.StreamDataForUserByIDInputs, StreamDataForUserByIDInput{context, userID})
+	output := t.StreamDataForUserByIDOutputs[0]
+	t.StreamDataForUserByIDOutputs = t.StreamDataForUserByIDOutputs[1:]
+	if _, err := io.WriteString(writer, output.Data); err != nil {
+		return err
+	}
+	return output.Error
+}
+
+func (t *TestDataServicesClient) UnusedOutputsCount() int {
+	return len(t.DestroyDataForUserByIDOutputs) +
+		len(t.StreamDataForUserByIDOutputs)
+}
+
+type TestArchiveWriter struct {
+	bytes.Buffer
+	CommitInvocations int
+	CommitOutputs     []error
+	AbortInvocations  int
+	AbortOutputs      []error
+}
+
+func (t *TestArchiveWriter) Commit() error {
+	t.CommitInvocations++
+	output := t.CommitOutputs[0]
+	t.CommitOutputs = t.CommitOutputs[1:]
+	return output
+}
+
+func (t *TestArchiveWriter) Abort() error {
+	t.AbortInvocations++
+	output := t.AbortOutputs[0]
+	t.AbortOutputs = t.AbortOutputs[1:]
+	return output
+}
+
+func (t *TestArchiveWriter) UnusedOutputsCount() int {
+	return len(t.CommitOutputs) +
+		len(t.AbortOutputs)
+}
+
+type CreateArchiveOutput struct {
+	ArchiveWriter *TestArchiveWriter
+	Error         error
+}
+
+type CreateArchiveInput struct {
+	ExportID string
+	UserID   string
+}
+
+type TestExportStore struct {
+	CreateArchiveInputs                 []CreateArchiveInput
+	CreateArchiveOutputs                []CreateArchiveOutput
+	DestroyArchivesCreatedBeforeInputs  []time.Time
+	DestroyArchivesCreatedBeforeOutputs []error
+	DestroyArchivesForUserByIDInputs    []string
+	DestroyArchivesForUserByIDOutputs   []error
+}
+
+func (t *TestExportStore) CreateArchive(exportID string, userID string) (exportStore.ArchiveWriter, error) {
+	t.CreateArchiveInputs = append(t.CreateArchiveInputs, CreateArchiveInput{ExportID: exportID, UserID: userID})
+	output := t.CreateArchiveOutputs[0]
+	t.CreateArchiveOutputs = t.CreateArchiveOutputs[1:]
+	if output.ArchiveWriter == nil {
+		return nil, output.Error
+	}
+	return output.ArchiveWriter, output.Error
+}
+
+func (t *TestExportStore) OpenArchive(exportID string) (io.ReadCloser, error) {
+	panic("Unexpected invocation of OpenArchive on TestExportStore")
+}
+
+func (t *TestExportStore) DestroyArchivesCreatedBefore(createdBefore time.Time) error {
+	t.DestroyArchivesCreatedBeforeInputs = append(t.DestroyArchivesCreatedBeforeInputs, createdBefore)
+	output := t.DestroyArchivesCreatedBeforeOutputs[0]
+	t.DestroyArchivesCreatedBeforeOutputs = t.DestroyArchivesCreatedBeforeOutputs[1:]
+	return output
+}
+
+func (t *TestExportStore) DestroyArchivesForUserByID(userID string) error {
+	t.DestroyArchivesForUserByIDInputs = append(t.DestroyArchivesForUserByIDInputs, userID)
+	output := t.DestroyArchivesForUserByIDOutputs[0]
+	t.DestroyArchivesForUserByIDOutputs = t.DestroyArchivesForUserByIDOutputs[1:]
+	return output
+}
+
+func (t *TestExportStore) UnusedOutputsCount() int {
+	return len(t.CreateArchiveOutputs) +
+		len(t.DestroyArchivesCreatedBeforeOutputs) +
+		len(t.DestroyArchivesForUserByIDOutputs)
+}
+
+type TestStore struct{}
+
+func (t *TestStore) IsClosed() bool {
+	panic("Unexpected invocation of IsClosed on TestStore")
+}
+
+func (t *TestStore) Close() {
+	panic("Unexpected invocation of Close on TestStore")
+}
+
+func (t *TestStore) GetStatus() interface{} {
+	panic("Unexpected invocation of GetStatus on TestStore")
+}
+
+type TestSession struct {
+	CloseInvocations int
+	SetAgentInputs   []commonStore.Agent
+}
+
+func (t *TestSession) IsClosed() bool {
+	panic("Unexpected invocation of IsClosed on TestSession")
+}
+
+func (t *TestSession) Close() {
+	t.CloseInvocations++
+}
+
+func (t *TestSession) Logger() log.Logger {
+	panic("Unexpected invocation of Logger on TestSession")
+}
+
+func (t *TestSession) SetAgent(agent commonStore.Agent) {
+	t.SetAgentInputs = append(t.SetAgentInputs, agent)
+}
+
+type GetDocumentsOutput struct {
+	Documents []map[string]interface{}
+	Error     error
+}
+
+type TestMessageStoreSession struct {
+	TestSession
+	GetMessagesForUserByIDInputs      []string
+	GetMessagesForUserByIDOutputs     []GetDocumentsOutput
+	DeleteMessagesFromUserInputs      []*messageStore.User
+	DeleteMessagesFromUserOutputs     []error
+	DestroyMessagesForUserByIDInputs  []string
+	DestroyMessagesForUserByIDOutputs []error
+}
+
+func (t *TestMessageStoreSession) GetMessagesForUserByID(userID string) ([]map[string]interface{}, error) {
+	t.GetMessagesForUserByIDInputs = append(t.GetMessagesForUserByIDInputs, userID)
+	output := t.GetMessagesForUserByIDOutputs[0]
+	t.GetMessagesForUserByIDOutputs = t.GetMessagesForUserByIDOutputs[1:]
+	return output.Documents, output.Error
+}
+
+func (t *TestMessageStoreSession) DeleteMessagesFromUser(user *messageStore.User) error {
+	t.DeleteMessagesFromUserInputs = append(t.DeleteMessagesFromUserInputs, user)
+	output := t.DeleteMessagesFromUserOutputs[0]
+	t.DeleteMessagesFromUserOutputs = t.DeleteMessagesFromUserOutputs[1:]
+	return output
+}
+
+func (t *TestMessageStoreSession) DestroyMessagesForUserByID(userID string) error {
+	t.DestroyMessagesForUserByIDInputs = append(t.DestroyMessagesForUserByIDInputs, userID)
+	output := t.DestroyMessagesForUserByIDOutputs[0]
+	t.DestroyMessagesForUserByIDOutputs = t.DestroyMessagesForUserByIDOutputs[1:]
+	return output
+}
+
+func (t *TestMessageStoreSession) UnusedOutputsCount() int {
+	return len(t.GetMessagesForUserByIDOutputs) +
+		len(t.DeleteMessagesFromUserOutputs) +
+		len(t.DestroyMessagesForUserByIDOutputs)
+}
+
+type TestMessageStore struct {
+	TestStore
+	SessionImpl *TestMessageStoreSession
+}
+
+func (t *TestMessageStore) NewSession(logger log.Logger) messageStore.Session {
+	return t.SessionImpl
+}
+
+type TestNotificationStoreSession struct {
+	TestSession
+	GetNotificationsForUserByIDInputs      []string
+	GetNotificationsForUserByIDOutputs     []GetDocumentsOutput
+	DestroyNotificationsForUserByIDInputs  []string
+	DestroyNotificationsForUserByIDOutputs []error
+}
+
+func (t *TestNotificationStoreSession) GetNotificationsForUserByID(userID string) ([]map[string]interface{}, error) {
+	t.GetNotificationsForUserByIDInputs = append(t.GetNotificationsForUserByIDInputs, userID)
+	output := t.GetNotificationsForUserByIDOutputs[0]
+	t.GetNotificationsForUserByIDOutputs = t.GetNotificationsForUserByIDOutputs[1:]
+	return output.Documents, output.Error
+}
+
+func (t *TestNotificationStoreSession) DestroyNotificationsForUserByID(userID string) error {
+	t.DestroyNotificationsForUserByIDInputs = append(t.DestroyNotificationsForUserByIDInputs, userID)
+	output := t.DestroyNotificationsForUserByIDOutputs[0]
+	t.DestroyNotificationsForUserByIDOutputs = t.DestroyNotificationsForUserByIDOutputs[1:]
+	return output
+}
+
+func (t *TestNotificationStoreSession) UnusedOutputsCount() int {
+	return len(t.GetNotificationsForUserByIDOutputs) +
+		len(t.DestroyNotificationsForUserByIDOutputs)
+}
+
+type TestNotificationStore struct {
+	TestStore
+	SessionImpl *TestNotificationStoreSession
+}
+
+func (t *TestNotificationStore) NewSession(logger log.Logger) notificationStore.Session {
+	return t.SessionImpl
+}
+
+type TestPermissionStoreSession struct {
+	TestSession
+	GetPermissionsForUserByIDInputs      []string
+	GetPermissionsForUserByIDOutputs     []GetDocumentsOutput
+	DestroyPermissionsForUserByIDInputs  []string
+	DestroyPermissionsForUserByIDOutputs []error
+}
+
+func (t *TestPermissionStoreSession) GetPermissionsForUserByID(userID string) ([]map[string]interface{}, error) {
+	t.GetPermissionsForUserByIDInputs = append(t.GetPermissionsForUserByIDInputs, userID)
+	output := t.GetPermissionsForUserByIDOutputs[0]
+	t.GetPermissionsForUserByIDOutputs = t.GetPermissionsForUserByIDOutputs[1:]
+	return output.Documents, output.Error
+}
+
+func (t *TestPermissionStoreSession) DestroyPermissionsForUserByID(userID string) error {
+	t.DestroyPermissionsForUserByIDInputs = append(t.DestroyPermissionsForUserByIDInputs, userID)
+	output := t.DestroyPermissionsForUserByIDOutputs[0]
+	t.DestroyPermissionsForUserByIDOutputs = t.DestroyPermissionsForUserByIDOutputs[1:]
+	return output
+}
+
+func (t *TestPermissionStoreSession) UnusedOutputsCount() int {
+	return len(t.GetPermissionsForUserByIDOutputs) +
+		len(t.DestroyPermissionsForUserByIDOutputs)
+}
+
+type TestPermissionStore struct {
+	TestStore
+	SessionImpl *TestPermissionStoreSession
+}
+
+func (t *TestPermissionStore) NewSession(logger log.Logger) permissionStore.Session {
+	return t.SessionImpl
+}
+
+type GetProfileByIDOutput struct {
+	Profile *profile.Profile
+	Error   error
+}
+
+type TestProfileStoreSession struct {
+	TestSession
+	GetProfileByIDInputs      []string
+	GetProfileByIDOutputs     []GetProfileByIDOutput
+	DestroyProfileByIDInputs  []string
+	DestroyProfileByIDOutputs []error
+}
+
+func (t *TestProfileStoreSession) GetProfileByID(profileID string) (*profile.Profile, error) {
+	t.GetProfileByIDInputs = append(t.GetProfileByIDInputs, profileID)
+	output := t.GetProfileByIDOutputs[0]
+	t.GetProfileByIDOutputs = t.GetProfileByIDOutputs[1:]
+	return output.Profile, output.Error
+}
+
+func (t *TestProfileStoreSession) DestroyProfileByID(profileID string) error {
+	t.DestroyProfileByIDInputs = append(t.DestroyProfileByIDInputs, profileID)
+	output := t.DestroyProfileByIDOutputs[0]
+	t.DestroyProfileByIDOutputs = t.DestroyProfileByIDOutputs[1:]
+	return output
+}
+
+func (t *TestProfileStoreSession) UnusedOutputsCount() int {
+	return len(t.GetProfileByIDOutputs) +
+		len(t.DestroyProfileByIDOutputs)
+}
+
+type TestProfileStore struct {
+	TestStore
+	SessionImpl *TestProfileStoreSession
+}
+
+func (t *TestProfileStore) NewSession(logger log.Logger) profileStore.Session {
+	return t.SessionImpl
+}
+
+type TestSessionStoreSession struct {
+	TestSession
+	DestroySessionsForUserByIDInputs  []string
+	DestroySessionsForUserByIDOutputs []error
+}
+
+func (t *TestSessionStoreSession) DestroySessionsForUserByID(userID string) error {
+	t.DestroySessionsForUserByIDInputs = append(t.DestroySessionsForUserByIDInputs, userID)
+	output := t.DestroySessionsForUserByIDOutputs[0]
+	t.DestroySessionsForUserByIDOutputs = t.DestroySessionsForUserByIDOutputs[1:]
+	return output
+}
+
+func (t *TestSessionStoreSession) UnusedOutputsCount() int {
+	return len(t.DestroySessionsForUserByIDOutputs)
+}
+
+type TestSessionStore struct {
+	TestStore
+	SessionImpl *TestSessionStoreSession
+}
+
+func (t *TestSessionStore) NewSession(logger log.Logger) sessionStore.Session {
+	return t.SessionImpl
+}
+
+type GetUserByIDOutput struct {
+	User  *user.User
+	Error error
+}
+
+type TestUserStoreSession struct {
+	TestSession
+	GetUserByIDInputs      []string
+	GetUserByIDOutputs     []GetUserByIDOutput
+	DeleteUserInputs       []*user.User
+	DeleteUserOutputs      []error
+	DestroyUserByIDInputs  []string
+	DestroyUserByIDOutputs []error
+}
+
+func (t *TestUserStoreSession) GetUserByID(userID string) (*user.User, error) {
+	t.GetUserByIDInputs = append(t.GetUserByIDInputs, userID)
+	output := t.GetUserByIDOutputs[0]
+	t.GetUserByIDOutputs = t.GetUserByIDOutputs[1:]
+	return output.User, output.Error
+}
+
+func (t *TestUserStoreSession) DeleteUser(user *user.User) error {
+	t.DeleteUserInputs = append(t.DeleteUserInputs, user)
+	output := t.DeleteUserOutputs[0]
+	t.DeleteUserOutputs = t.DeleteUserOutputs[1:]
+	return output
+}
+
+func (t *TestUserStoreSession) ScheduleUserDeletion(user *user.User, deletionScheduledTime string) error {
+	panic("Unexpected invocation of ScheduleUserDeletion on TestUserStoreSession")
+}
+
+func (t *TestUserStoreSession) UnscheduleUserDeletion(user *user.User) error {
+	panic("Unexpected invocation of UnscheduleUserDeletion on TestUserStoreSession")
+}
+
+func (t *TestUserStoreSession) DestroyUserByID(userID string) error {
+	t.DestroyUserByIDInputs = append(t.DestroyUserByIDInputs, userID)
+	output := t.DestroyUserByIDOutputs[0]
+	t.DestroyUserByIDOutputs = t.DestroyUserByIDOutputs[1:]
+	return output
+}
+
+func (t *TestUserStoreSession) PasswordMatches(user *user.User, password string) bool {
+	panic("Unexpected invocation of PasswordMatches on TestUserStoreSession")
+}
+
+func (t *TestUserStoreSession) UnusedOutputsCount() int {
+	return len(t.GetUserByIDOutputs) +
+		len(t.DeleteUserOutputs) +
+		len(t.DestroyUserByIDOutputs)
+}
+
+type TestUserStore struct {
+	TestStore
+	SessionImpl *TestUserStoreSession
+}
+
+func (t *TestUserStore) NewSession(logger log.Logger) userStore.Session {
+	return t.SessionImpl
+}
+
+type TestTaskStore struct {
+	TestStore
+	NewSessionInvocations int
+	SessionImpl           *testTaskStore.Session
+}
+
+func (t *TestTaskStore) NewSession(logger log.Logger) taskStore.Session {
+	t.NewSessionInvocations++
+	return t.SessionImpl
+}